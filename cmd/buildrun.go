@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/config"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/rubric"
+	"github.com/zinc-sig/ghost/internal/runner"
+)
+
+var (
+	// Command-specific I/O flags
+	buildRunInputFile  string
+	buildRunOutputFile string
+	buildRunStderrFile string
+
+	// Build phase
+	buildCommand    string
+	buildTimeoutStr string
+	buildTimeout    time.Duration
+	buildLog        string
+	buildArtifact   string
+
+	// Common flag structures
+	buildRunFlags         config.CommonFlags
+	buildRunContextConfig config.ContextConfig
+	buildRunWebhookConfig config.WebhookConfig
+)
+
+var buildRunCmd = &cobra.Command{
+	Use:   "build-run -i <input> -o <output> -e <stderr> --build-cmd <command> [flags] -- <command> [args...]",
+	Short: "Compile a submission, then run it with structured output",
+	Long: `Run a build command, then the command, and report both phases in one result.
+
+The build command line is split on whitespace without shell interpretation. It
+reads no input, and its stdout and stderr are written to --build-log (default: a
+temporary file). With --build-artifact the build must also produce that file.
+
+If the build fails, times out or produces no artifact, the command is not run and
+the result status is "compile_error", so compile failures can be told apart from
+runtime failures. Otherwise the command runs as with ghost run and the result
+describes its run. Either way the "build" block records the build phase.
+
+--build-timeout limits the build and --timeout the run.`,
+	Example: `  ghost build-run -i input.txt -o output.txt -e stderr.txt \
+    --build-cmd 'gcc -O2 -o solution solution.c' --build-artifact solution \
+    --build-timeout 30s --timeout 2s -- ./solution
+  ghost build-run -i input.txt -o output.txt -e stderr.txt --build-cmd 'javac Main.java' \
+    --build-log build.log --score 10 -- java Main`,
+	RunE: buildRunCommand,
+}
+
+func buildRunCommand(cmd *cobra.Command, args []string) error {
+	// Webhook settings are parsed per invocation and passed to every delivery
+	webhookConfig, webhookRetryConfig, err := helpers.ParseWebhookConfigToInternal(&buildRunWebhookConfig)
+	if err != nil {
+		return failure.Wrap(failure.WebhookConfigInvalid, err)
+	}
+
+	// Validate command separator
+	if err := helpers.ValidateCommandSeparator(cmd, args); err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	// Setup tracing, the run ID and the result file for this invocation
+	ctx, exec, err := helpers.StartExecution(cmd, &buildRunFlags, webhookConfig, webhookRetryConfig)
+	if err != nil {
+		return err
+	}
+	defer exec.End()
+
+	// Validate required I/O flags
+	ioFlags := helpers.IOFlags{
+		Input:  buildRunInputFile,
+		Output: buildRunOutputFile,
+		Stderr: buildRunStderrFile,
+	}
+	if err := helpers.ValidateIOFlags(ioFlags, false); err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+	if !buildRunFlags.DryRun {
+		if err := helpers.CheckInputFile(ioFlags.Input); err != nil {
+			return err
+		}
+	}
+
+	build := strings.Fields(buildCommand)
+	if len(build) == 0 {
+		return failure.Wrap(failure.Usage, fmt.Errorf("--build-cmd must not be empty"))
+	}
+
+	// The build log is only kept when asked for; both streams append to it,
+	// so it is emptied first
+	logFile := buildLog
+	switch {
+	case buildRunFlags.DryRun:
+		if logFile == "" {
+			logFile = os.DevNull
+		}
+	case logFile == "":
+		tempLog, err := os.CreateTemp("", "ghost-build-*.log")
+		if err != nil {
+			return fmt.Errorf("failed to create temp build log: %w", err)
+		}
+		logFile = tempLog.Name()
+		_ = tempLog.Close()
+		defer func() { _ = os.Remove(logFile) }()
+	default:
+		if err := os.WriteFile(logFile, nil, 0644); err != nil {
+			return fmt.Errorf("failed to create build log: %w", err)
+		}
+	}
+
+	buildConfig := &runner.Config{
+		RunID:      exec.RunID,
+		Command:    build[0],
+		Args:       build[1:],
+		InputFile:  os.DevNull,
+		OutputFile: logFile,
+		StderrFile: logFile,
+		Append:     true,
+		Verbose:    buildRunFlags.Verbose,
+		DryRun:     buildRunFlags.DryRun,
+		Timeout:    buildTimeout,
+	}
+
+	config := &runner.Config{
+		RunID:       exec.RunID,
+		Command:     args[0],
+		Args:        args[1:],
+		InputFile:   buildRunInputFile,
+		OutputFile:  buildRunOutputFile,
+		StderrFile:  buildRunStderrFile,
+		Verbose:     buildRunFlags.Verbose,
+		DryRun:      buildRunFlags.DryRun,
+		Timeout:     buildRunFlags.Timeout,
+		SoftTimeout: buildRunFlags.SoftTimeout,
+
+		ExpectExitCode: helpers.ExpectedExitCode(&buildRunFlags),
+		ExpectNonzero:  buildRunFlags.ExpectNonzero,
+	}
+
+	// Build context from all sources
+	if err := exec.BuildContext(&buildRunContextConfig); err != nil {
+		return err
+	}
+
+	// Lifecycle events let dashboards show in-progress executions
+	exec.SendStarted(ctx, config.FullCommand())
+
+	buildResult, err := helpers.ExecuteWithSpan(ctx, buildConfig)
+	if err != nil {
+		return failure.Wrap(failure.ExecutionFailed, fmt.Errorf("failed to execute build: %w", err))
+	}
+	buildReport := newBuildResult(buildResult, buildLog, buildRunFlags.DryRun)
+	// Compiler warnings and errors end up in the build log
+	if buildRunFlags.StderrClassify != "" && !buildRunFlags.DryRun {
+		buildReport.Diagnostics, err = helpers.ClassifyStderr(logFile, buildRunFlags.StderrClassify)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The command only runs once it has been built
+	var result *runner.Result
+	if buildReport.Status == string(runner.StatusSuccess) {
+		result, err = helpers.ExecuteWithSpan(ctx, config)
+		if err != nil {
+			return failure.Wrap(failure.ExecutionFailed, fmt.Errorf("failed to execute command: %w", err))
+		}
+		exec.SendTimeout(ctx, result)
+	} else {
+		result = &runner.Result{Command: config.FullCommand(), Status: runner.StatusCompileError, ExitCode: buildReport.ExitCode}
+	}
+
+	var timeoutMs int64
+	if buildRunFlags.Timeout > 0 {
+		timeoutMs = buildRunFlags.Timeout.Milliseconds()
+	}
+	jsonResult := helpers.CreateJSONResult(
+		config.InputFile,
+		config.OutputFile,
+		config.StderrFile,
+		"", // No expected file for build-run command
+		result,
+		timeoutMs,
+		buildRunFlags.ScoreSet,
+		buildRunFlags.Score,
+		exec.Context,
+	)
+
+	jsonResult.Build = buildReport
+
+	opts := helpers.FinishOptions{
+		Plan: &output.Plan{
+			Command: result.Command,
+			Input:   config.InputFile,
+			Output:  config.OutputFile,
+			Stderr:  config.StderrFile,
+		},
+	}
+	// The output and stderr files are only written by a run
+	if result.Status != runner.StatusCompileError {
+		opts.OutputFile = config.OutputFile
+		opts.StderrFile = config.StderrFile
+	}
+	return helpers.FinishExecution(ctx, exec, jsonResult, opts)
+}
+
+// newBuildResult describes the build phase, checking that the artifact was produced
+// The log is only reported when it is kept (--build-log).
+func newBuildResult(result *runner.Result, log string, dryRun bool) *output.BuildResult {
+	build := &output.BuildResult{
+		Command:       result.Command,
+		Status:        string(result.Status),
+		ExitCode:      result.ExitCode,
+		ExecutionTime: result.ExecutionTime,
+		Log:           log,
+		Artifact:      buildArtifact,
+	}
+	if buildTimeout > 0 {
+		timeoutMs := buildTimeout.Milliseconds()
+		build.Timeout = &timeoutMs
+	}
+	if buildArtifact == "" || dryRun || result.Status != runner.StatusSuccess {
+		return build
+	}
+
+	info, err := os.Stat(buildArtifact)
+	switch {
+	case err != nil:
+		build.Status = string(runner.StatusFailed)
+		build.Error = fmt.Sprintf("artifact %s was not produced", buildArtifact)
+	case info.IsDir():
+		build.Status = string(runner.StatusFailed)
+		build.Error = fmt.Sprintf("artifact %s is a directory", buildArtifact)
+	default:
+		build.ArtifactSize = info.Size()
+	}
+	return build
+}
+
+func init() {
+	// Command-specific flags
+	buildRunCmd.Flags().StringVarP(&buildRunInputFile, "input", "i", "", "Input file to redirect to the command's stdin (required)")
+	buildRunCmd.Flags().StringVarP(&buildRunOutputFile, "output", "o", "", "Output file to capture the command's stdout (required)")
+	buildRunCmd.Flags().StringVarP(&buildRunStderrFile, "stderr", "e", "", "Error file to capture the command's stderr (required)")
+	buildRunCmd.Flags().StringVar(&buildCommand, "build-cmd", "", "Command that builds the submission, run before it (required)")
+	buildRunCmd.Flags().StringVar(&buildTimeoutStr, "build-timeout", "", "Timeout for the build (e.g. 30s; --timeout limits the run)")
+	buildRunCmd.Flags().StringVar(&buildLog, "build-log", "", "Keep the build's stdout and stderr in this file (default: temporary)")
+	buildRunCmd.Flags().StringVar(&buildArtifact, "build-artifact", "", "File the build must produce, e.g. the compiled program")
+
+	// Mark flags as required
+	_ = buildRunCmd.MarkFlagRequired("input")
+	_ = buildRunCmd.MarkFlagRequired("output")
+	_ = buildRunCmd.MarkFlagRequired("stderr")
+	_ = buildRunCmd.MarkFlagRequired("build-cmd")
+
+	// Setup common flags using helper
+	helpers.SetupCommonFlags(buildRunCmd, &buildRunFlags)
+	helpers.SetupExpectFlags(buildRunCmd, &buildRunFlags)
+	helpers.SetupContextFlags(buildRunCmd, &buildRunContextConfig)
+	helpers.SetupWebhookFlags(buildRunCmd, &buildRunWebhookConfig)
+
+	buildRunCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		buildRunFlags.ScoreSet = cmd.Flags().Changed("score")
+		buildRunFlags.RecordEnvSet = cmd.Flags().Changed("record-env")
+		buildRunFlags.ExpectExitCodeSet = cmd.Flags().Changed("expect-exit-code")
+		if err := helpers.ValidateExpectExitCode(&buildRunFlags); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+
+		// Validate score expression early
+		if buildRunFlags.ScoreExpr != "" {
+			if _, err := helpers.ParseScoreExpression(buildRunFlags.ScoreExpr); err != nil {
+				return failure.Wrap(failure.Usage, err)
+			}
+		}
+		if buildRunFlags.Rubric != "" {
+			if _, err := rubric.Load(buildRunFlags.Rubric); err != nil {
+				return failure.Wrap(failure.ConfigInvalid, err)
+			}
+		}
+
+		// Validate preview sizes
+		if err := helpers.ValidateEmbedFlags(&buildRunFlags); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+
+		// Parse timeouts if provided
+		var err error
+		buildTimeout, err = helpers.ParseTimeout(buildTimeoutStr)
+		if err != nil {
+			return failure.Wrap(failure.Usage, fmt.Errorf("invalid --build-timeout: %w", err))
+		}
+		buildRunFlags.Timeout, err = helpers.ParseTimeout(buildRunFlags.TimeoutStr)
+		if err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		buildRunFlags.SoftTimeout, err = helpers.ParseSoftTimeout(buildRunFlags.SoftTimeoutStr, buildRunFlags.Timeout)
+		if err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		buildRunFlags.ResultSchema, err = helpers.ParseResultSchema(buildRunFlags.ResultSchemaStr)
+		return failure.Wrap(failure.Usage, err)
+	}
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+// resetBuildRunFlags clears the build-run flags so they don't leak between tests
+func resetBuildRunFlags() {
+	resetFlags(buildRunCmd, "input", "output", "stderr", "build-cmd", "build-timeout", "build-log", "build-artifact", "timeout", "score", "stderr-classify")
+	buildRunFlags.Timeout = 0
+	buildTimeout = 0
+}
+
+func TestBuildRunCommand(t *testing.T) {
+	tests := []struct {
+		name            string
+		build           string
+		flags           []string
+		command         []string
+		wantStatus      string
+		wantBuildStatus string
+		wantBuildError  string
+		wantExitCode    int
+		wantScore       string
+		wantOutput      string
+	}{
+		{
+			name:            "build and run",
+			build:           "sh build.sh",
+			flags:           []string{"--build-artifact", "prog.sh", "--score", "10"},
+			command:         []string{"sh", "prog.sh"},
+			wantStatus:      "success",
+			wantBuildStatus: "success",
+			wantScore:       "10",
+			wantOutput:      "built\n",
+		},
+		{
+			name:            "compile error",
+			build:           "sh fail.sh",
+			flags:           []string{"--score", "10"},
+			command:         []string{"sh", "prog.sh"},
+			wantStatus:      "compile_error",
+			wantBuildStatus: "failed",
+			wantExitCode:    1,
+			wantScore:       "0",
+		},
+		{
+			name:            "missing artifact",
+			build:           "true",
+			flags:           []string{"--build-artifact", "prog.sh"},
+			command:         []string{"sh", "prog.sh"},
+			wantStatus:      "compile_error",
+			wantBuildStatus: "failed",
+			wantBuildError:  "artifact prog.sh was not produced",
+		},
+		{
+			name:            "build timeout",
+			build:           "sleep 5",
+			flags:           []string{"--build-timeout", "100ms"},
+			command:         []string{"true"},
+			wantStatus:      "compile_error",
+			wantBuildStatus: "timeout",
+			wantExitCode:    -1,
+		},
+		{
+			name:            "runtime failure",
+			build:           "true",
+			command:         []string{"sh", "-c", "exit 3"},
+			wantStatus:      "failed",
+			wantBuildStatus: "success",
+			wantExitCode:    3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetBuildRunFlags()
+			defer resetBuildRunFlags()
+
+			// The build writes the program into the working directory
+			dir := t.TempDir()
+			t.Chdir(dir)
+			_ = os.WriteFile("build.sh", []byte("echo building; echo warning >&2; echo 'echo built' > prog.sh\n"), 0644)
+			_ = os.WriteFile("fail.sh", []byte("echo 'error: expected ;' >&2; exit 1\n"), 0644)
+
+			outputFile := filepath.Join(dir, "output.txt")
+			buildLogFile := filepath.Join(dir, "build.log")
+			args := append([]string{"build-run", "-i", "/dev/null", "-o", outputFile, "-e", filepath.Join(dir, "stderr.txt"),
+				"--build-cmd", tt.build, "--build-log", buildLogFile}, tt.flags...)
+			rootCmd.SetArgs(append(append(args, "--"), tt.command...))
+
+			out, err := captureOutput(rootCmd.Execute)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			var result struct {
+				Status   string `json:"status"`
+				ExitCode int    `json:"exit_code"`
+				Score    string `json:"score"`
+				Build    struct {
+					Status       string `json:"status"`
+					Error        string `json:"error"`
+					Log          string `json:"log"`
+					ArtifactSize int64  `json:"artifact_size"`
+				} `json:"build"`
+			}
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, out)
+			}
+
+			if result.Status != tt.wantStatus || result.ExitCode != tt.wantExitCode {
+				t.Errorf("Result = %s/%d, want %s/%d", result.Status, result.ExitCode, tt.wantStatus, tt.wantExitCode)
+			}
+			if result.Build.Status != tt.wantBuildStatus || result.Build.Error != tt.wantBuildError {
+				t.Errorf("Build = %s/%q, want %s/%q", result.Build.Status, result.Build.Error, tt.wantBuildStatus, tt.wantBuildError)
+			}
+			if tt.wantScore != "" && result.Score != tt.wantScore {
+				t.Errorf("Score = %s, want %s", result.Score, tt.wantScore)
+			}
+			if result.Build.Log != buildLogFile {
+				t.Errorf("Build log = %q, want %q", result.Build.Log, buildLogFile)
+			}
+			if tt.wantOutput != "" {
+				if data, _ := os.ReadFile(outputFile); string(data) != tt.wantOutput {
+					t.Errorf("Output = %q, want %q", data, tt.wantOutput)
+				}
+				if data, _ := os.ReadFile(buildLogFile); string(data) != "building\nwarning\n" {
+					t.Errorf("Build log = %q, want the build's stdout and stderr", data)
+				}
+				if result.Build.ArtifactSize != int64(len("echo built\n")) {
+					t.Errorf("Artifact size = %d", result.Build.ArtifactSize)
+				}
+			}
+			if tt.wantStatus == "compile_error" {
+				if _, err := os.Stat(outputFile); err == nil {
+					t.Error("Command ran although the build failed")
+				}
+			}
+		})
+	}
+}
+
+func TestBuildRunCommandStderrClassify(t *testing.T) {
+	resetBuildRunFlags()
+	defer resetBuildRunFlags()
+
+	dir := t.TempDir()
+	build := filepath.Join(dir, "build.sh")
+	_ = os.WriteFile(build, []byte("echo 'main.c:3:5: warning: unused variable' >&2\n"), 0644)
+	rootCmd.SetArgs([]string{"build-run", "-i", "/dev/null", "-o", filepath.Join(dir, "output.txt"), "-e", filepath.Join(dir, "stderr.txt"),
+		"--build-cmd", "sh " + build, "--stderr-classify", "gcc",
+		"--", "sh", "-c", "echo 'prog: error: bad input' >&2"})
+	out, err := captureOutput(rootCmd.Execute)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	var result output.Result
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, out)
+	}
+	if result.Build == nil || !reflect.DeepEqual(result.Build.Diagnostics, &output.Diagnostics{Format: "gcc", Warnings: 1}) {
+		t.Errorf("build diagnostics = %+v, want 1 warning", result.Build)
+	}
+	want := &output.Diagnostics{Format: "gcc", Errors: 1, FirstError: "prog: error: bad input"}
+	if !reflect.DeepEqual(result.Diagnostics, want) {
+		t.Errorf("diagnostics = %+v, want %+v", result.Diagnostics, want)
+	}
+}
+
+func TestBuildRunCommandValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []string
+	}{
+		{name: "empty build command", flags: []string{"--build-cmd", " "}},
+		{name: "invalid build timeout", flags: []string{"--build-cmd", "true", "--build-timeout", "soon"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetBuildRunFlags()
+			defer resetBuildRunFlags()
+
+			dir := t.TempDir()
+			args := append([]string{"build-run", "-i", "/dev/null", "-o", filepath.Join(dir, "output.txt"),
+				"-e", filepath.Join(dir, "stderr.txt")}, tt.flags...)
+			rootCmd.SetArgs(append(args, "--", "true"))
+			_, err := captureOutput(rootCmd.Execute)
+			if err == nil || failure.CodeOf(err) != failure.Usage {
+				t.Errorf("err = %v, want %s", err, failure.Usage)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func resetCacheFlags() {
+	resetFlags(runCmd, "cache-key-file", "cache-dir", "score")
+}
+
+func TestRunCommandCache(t *testing.T) {
+	resetCacheFlags()
+	defer resetCacheFlags()
+
+	tmpDir := t.TempDir()
+	cacheDir := filepath.Join(tmpDir, "cache")
+	input := filepath.Join(tmpDir, "input.txt")
+	output := filepath.Join(tmpDir, "output.txt")
+	stderr := filepath.Join(tmpDir, "stderr.txt")
+	runs := filepath.Join(tmpDir, "runs.txt")
+	submission := filepath.Join(tmpDir, "solution.sh")
+	notes := filepath.Join(tmpDir, "notes.txt")
+
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(input, "hello\n")
+	write(notes, "v1")
+	// Counts its runs, so a cache hit shows as a run that didn't happen
+	write(submission, "echo run >> "+runs+"\ncat\n")
+
+	run := func(extra ...string) (cached bool, score string) {
+		t.Helper()
+		args := []string{"run", "--cache-dir", cacheDir, "--cache-key-file", notes, "-i", input, "-o", output, "-e", stderr}
+		rootCmd.SetArgs(append(append(args, extra...), "--", "sh", submission))
+		stdout, err := captureOutput(func() error {
+			return rootCmd.Execute()
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var result struct {
+			Cached bool            `json:"cached"`
+			Score  json.RawMessage `json:"score"`
+			RunID  string          `json:"run_id"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("Failed to parse output: %v\nOutput: %s", err, stdout)
+		}
+		if result.RunID == "" {
+			t.Error("Cached result has no run ID")
+		}
+		// Cached or not, the output file holds the command's output
+		want, _ := os.ReadFile(input)
+		if data, _ := os.ReadFile(output); string(data) != string(want) {
+			t.Errorf("Output file = %q, want %q", data, want)
+		}
+		return result.Cached, string(result.Score)
+	}
+	countRuns := func() int {
+		data, _ := os.ReadFile(runs)
+		return strings.Count(string(data), "run")
+	}
+
+	steps := []struct {
+		name       string
+		change     func()
+		flags      []string
+		wantCached bool
+		wantRuns   int
+	}{
+		{name: "first run", flags: []string{"--score", "10"}, wantRuns: 1},
+		{name: "unchanged", change: func() { _ = os.Remove(output) }, flags: []string{"--score", "10"}, wantCached: true, wantRuns: 1},
+		{name: "different score", flags: []string{"--score", "5"}, wantRuns: 2},
+		{name: "changed input", change: func() { write(input, "hello again\n") }, flags: []string{"--score", "10"}, wantRuns: 3},
+		{name: "changed submission", change: func() { write(submission, "echo run >> "+runs+"\ncat\nexit 0\n") }, flags: []string{"--score", "10"}, wantRuns: 4},
+		{name: "changed key file", change: func() { write(notes, "v2") }, flags: []string{"--score", "10"}, wantRuns: 5},
+		{name: "unchanged again", change: func() { _ = os.Remove(output) }, flags: []string{"--score", "10"}, wantCached: true, wantRuns: 5},
+	}
+	for _, step := range steps {
+		if step.change != nil {
+			step.change()
+		}
+		cached, score := run(step.flags...)
+		if cached != step.wantCached {
+			t.Errorf("%s: cached = %v, want %v", step.name, cached, step.wantCached)
+		}
+		if step.wantCached && score != `"10"` {
+			t.Errorf("%s: score = %s, want the cached score", step.name, score)
+		}
+		if got := countRuns(); got != step.wantRuns {
+			t.Errorf("%s: command ran %d times, want %d", step.name, got, step.wantRuns)
+		}
+		resetCacheFlags()
+	}
+}
+
+func TestRunCommandCacheRefreshesInvocation(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	resetCacheFlags()
+	resetContextFlags()
+	resetFlags(runCmd, "attempt")
+	defer resetCacheFlags()
+	defer resetContextFlags()
+	defer resetFlags(runCmd, "attempt")
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	commit := func() string {
+		t.Helper()
+		for _, args := range [][]string{
+			{"init", "-q", "-b", "main"},
+			{"-c", "user.name=Ada", "-c", "user.email=ada@example.com", "commit", "-q", "--allow-empty", "-m", "attempt"},
+		} {
+			if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+				t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+			}
+		}
+		out, _ := exec.Command("git", "rev-parse", "HEAD").Output()
+		return strings.TrimSpace(string(out))
+	}
+
+	for i, wantCached := range []bool{false, true} {
+		head := commit()
+		attempt := strconv.Itoa(i + 1)
+		rootCmd.SetArgs([]string{"run", "--cache-dir", filepath.Join(dir, ".cache"), "--context-git", "--attempt", attempt,
+			"-i", "/dev/null", "-o", filepath.Join(dir, "output.txt"), "-e", filepath.Join(dir, "stderr.txt"), "--", "true"})
+		stdout, err := captureOutput(rootCmd.Execute)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var result struct {
+			Cached  bool `json:"cached"`
+			Attempt int  `json:"attempt"`
+			Context struct {
+				Git map[string]any `json:"git"`
+			} `json:"context"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("Failed to parse output: %v\nOutput: %s", err, stdout)
+		}
+		// A cached grade reports this invocation's attempt and context
+		if result.Cached != wantCached || strconv.Itoa(result.Attempt) != attempt || result.Context.Git["commit"] != head {
+			t.Errorf("attempt %s: cached = %v, attempt = %d, commit = %v, want %v, %s, %s",
+				attempt, result.Cached, result.Attempt, result.Context.Git["commit"], wantCached, attempt, head)
+		}
+	}
+}
+
+func TestRunCommandCacheKeyFileRequiresCacheDir(t *testing.T) {
+	resetCacheFlags()
+	defer resetCacheFlags()
+
+	rootCmd.SetArgs([]string{"run", "--cache-key-file", "src", "-i", "/dev/null", "-o", "/dev/null", "-e", "/dev/null", "--", "true"})
+	_, err := captureOutput(func() error {
+		return rootCmd.Execute()
+	})
+	if err == nil || !strings.Contains(err.Error(), "--cache-key-file requires --cache-dir") {
+		t.Errorf("Error = %v, want --cache-key-file requires --cache-dir", err)
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/failure"
+)
+
+// resetCaptureFlags resets the capture flags of run between tests
+func resetCaptureFlags() {
+	resetFlags(runCmd, "append", "capture-combined", "cache-dir")
+}
+
+func TestRunCommandAppendCombined(t *testing.T) {
+	resetTimeoutGlobals()
+	resetScoringFlags()
+	resetCaptureFlags()
+	defer resetCaptureFlags()
+
+	dir := t.TempDir()
+	outputFile := filepath.Join(dir, "output.txt")
+	stderrFile := filepath.Join(dir, "stderr.txt")
+	combinedFile := filepath.Join(dir, "combined.log")
+	for _, word := range []string{"first", "second"} {
+		rootCmd.SetArgs([]string{"run", "-i", "/dev/null", "-o", outputFile, "-e", stderrFile,
+			"--append", "--capture-combined", combinedFile, "--", "echo", word})
+		if _, err := captureOutput(rootCmd.Execute); err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+	}
+
+	if got, _ := os.ReadFile(outputFile); string(got) != "first\nsecond\n" {
+		t.Errorf("output = %q, want both runs", got)
+	}
+	if got, _ := os.ReadFile(combinedFile); string(got) != "[stdout] first\n[stdout] second\n" {
+		t.Errorf("combined = %q, want both runs tagged", got)
+	}
+}
+
+func TestRunCommandCaptureValidation(t *testing.T) {
+	dir := t.TempDir()
+	outputFile := filepath.Join(dir, "output.txt")
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "combined is the output file", args: []string{"--capture-combined", outputFile}},
+		{name: "append with cache", args: []string{"--append", "--cache-dir", filepath.Join(dir, "cache")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTimeoutGlobals()
+			resetCaptureFlags()
+			defer resetCaptureFlags()
+
+			args := append([]string{"run", "-i", "/dev/null", "-o", outputFile, "-e", filepath.Join(dir, "stderr.txt")}, tt.args...)
+			rootCmd.SetArgs(append(args, "--", "true"))
+			_, err := captureOutput(rootCmd.Execute)
+			if err == nil || failure.CodeOf(err) != failure.Usage {
+				t.Errorf("err = %v, want %s", err, failure.Usage)
+			}
+		})
+	}
+}
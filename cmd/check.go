@@ -0,0 +1,260 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/config"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+	"github.com/zinc-sig/ghost/internal/compare"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/rubric"
+	"github.com/zinc-sig/ghost/internal/runner"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	// Command-specific I/O flags
+	checkInputFile    string
+	checkOutputFile   string
+	checkStderrFile   string
+	checkRequired     []string
+	checkForbidden    []string
+	checkPatternsFile string
+
+	// Common flag structures
+	checkFlags         config.CommonFlags
+	checkContextConfig config.ContextConfig
+	checkWebhookConfig config.WebhookConfig
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check -i <input> -o <output> -e <stderr> [--require <pattern>]... [--forbid <pattern>]... [--patterns <file>]",
+	Short: "Check that a file contains required patterns and no forbidden ones",
+	Long: `Check a file against regular expressions instead of a full expected output,
+for rubrics that only require specific lines to appear.
+
+Each --require pattern must match some line of the input and each --forbid
+pattern must match no line. Patterns use RE2 syntax and match anywhere in a
+line unless anchored with ^ and $; prefix (?i) to ignore case.
+
+--patterns reads further patterns from a file, one per line. Lines starting
+with "!" are forbidden patterns; blank lines and lines starting with "#" are
+skipped.
+
+The check passes if every pattern is satisfied. The result lists each pattern
+in "patterns", and --score is awarded in proportion to the number of satisfied
+patterns. A PASS or FAIL line per pattern is written to the output file.`,
+	Example: `  ghost check -i output.txt -o report.txt -e errors.txt --require '^Total: 42$' --forbid 'Exception'
+  ghost check -i output.txt -o report.txt -e errors.txt --patterns rubric.txt --score 10`,
+	RunE: checkCommand,
+}
+
+func checkCommand(cmd *cobra.Command, args []string) error {
+	// Webhook settings are parsed per invocation and passed to every delivery
+	webhookConfig, webhookRetryConfig, err := helpers.ParseWebhookConfigToInternal(&checkWebhookConfig)
+	if err != nil {
+		return failure.Wrap(failure.WebhookConfigInvalid, err)
+	}
+
+	// Setup tracing, the run ID and the result file for this invocation
+	ctx, exec, err := helpers.StartExecution(cmd, &checkFlags, webhookConfig, webhookRetryConfig)
+	if err != nil {
+		return err
+	}
+	defer exec.End()
+
+	// Validate required I/O flags
+	ioFlags := helpers.IOFlags{
+		Input:  checkInputFile,
+		Output: checkOutputFile,
+		Stderr: checkStderrFile,
+	}
+	if err := helpers.ValidateIOFlags(ioFlags, false); err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+	if !checkFlags.DryRun {
+		if err := helpers.CheckInputFile(ioFlags.Input); err != nil {
+			return err
+		}
+	}
+
+	patterns, err := checkPatterns()
+	if err != nil {
+		return err
+	}
+	exec.Span.SetAttributes(attribute.Int("ghost.check.patterns", len(patterns)))
+
+	// The patterns are checked in-process, like the internal diff engine
+	var patternResults []compare.PatternResult
+	config := &runner.Config{
+		RunID:       exec.RunID,
+		Command:     "check",
+		Args:        []string{checkInputFile},
+		InputFile:   "/dev/null",
+		OutputFile:  checkOutputFile,
+		StderrFile:  checkStderrFile,
+		Verbose:     checkFlags.Verbose,
+		DryRun:      checkFlags.DryRun,
+		Timeout:     checkFlags.Timeout,
+		SoftTimeout: checkFlags.SoftTimeout,
+
+		ExpectExitCode: helpers.ExpectedExitCode(&checkFlags),
+		ExpectNonzero:  checkFlags.ExpectNonzero,
+	}
+	config.Builtin = func(ctx context.Context, stdout, stderr io.Writer) int {
+		results, err := compare.CheckPatterns(ctx, stdout, checkInputFile, patterns)
+		if err != nil {
+			fmt.Fprintf(stderr, "check: %v\n", err)
+			return 2
+		}
+		patternResults = results
+		for _, result := range results {
+			if !result.Passed {
+				return 1
+			}
+		}
+		return 0
+	}
+
+	// Build context from all sources
+	if err := exec.BuildContext(&checkContextConfig); err != nil {
+		return err
+	}
+
+	// Lifecycle events let dashboards show in-progress executions
+	exec.SendStarted(ctx, config.FullCommand())
+
+	result, err := helpers.ExecuteWithSpan(ctx, config)
+	if err != nil {
+		return failure.Wrap(failure.ExecutionFailed, fmt.Errorf("failed to check patterns: %w", err))
+	}
+	exec.SendTimeout(ctx, result)
+
+	var timeoutMs int64
+	if checkFlags.Timeout > 0 {
+		timeoutMs = checkFlags.Timeout.Milliseconds()
+	}
+	jsonResult := helpers.CreateJSONResult(
+		checkInputFile,
+		checkOutputFile,
+		checkStderrFile,
+		"", // no expected file
+		result,
+		timeoutMs,
+		checkFlags.ScoreSet,
+		checkFlags.Score,
+		exec.Context,
+	)
+
+	// Record per-pattern outcomes and partial score; a timed out check has none
+	if patternResults != nil {
+		helpers.ApplyPatternResults(jsonResult, patternResults, checkFlags.ScoreSet, checkFlags.Score)
+	}
+
+	return helpers.FinishExecution(ctx, exec, jsonResult, helpers.FinishOptions{
+		OutputFile: config.OutputFile,
+		StderrFile: config.StderrFile,
+		Plan: &output.Plan{
+			Command: result.Command,
+			Input:   checkInputFile,
+			Output:  config.OutputFile,
+			Stderr:  config.StderrFile,
+		},
+	})
+}
+
+// checkPatterns compiles the --require and --forbid patterns followed by those
+// of the --patterns file
+func checkPatterns() ([]compare.Pattern, error) {
+	var patterns []compare.Pattern
+	for _, group := range []struct {
+		exprs     []string
+		forbidden bool
+	}{{checkRequired, false}, {checkForbidden, true}} {
+		for _, expr := range group.exprs {
+			pattern, err := compare.NewPattern(expr, group.forbidden)
+			if err != nil {
+				return nil, failure.Wrap(failure.Usage, err)
+			}
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	if checkPatternsFile != "" {
+		filePatterns, err := compare.ReadPatterns(checkPatternsFile)
+		if err != nil {
+			return nil, failure.Wrap(failure.Usage, err)
+		}
+		patterns = append(patterns, filePatterns...)
+	}
+
+	if len(patterns) == 0 {
+		return nil, failure.Wrap(failure.Usage, fmt.Errorf("no patterns to check: use --require, --forbid or --patterns"))
+	}
+	return patterns, nil
+}
+
+func init() {
+	// Command-specific flags
+	checkCmd.Flags().StringVarP(&checkInputFile, "input", "i", "", "File to check (required)")
+	checkCmd.Flags().StringVarP(&checkOutputFile, "output", "o", "", "Output file for the report of each pattern (required)")
+	checkCmd.Flags().StringVarP(&checkStderrFile, "stderr", "e", "", "Error file for check errors (required)")
+	checkCmd.Flags().StringArrayVar(&checkRequired, "require", nil, "Pattern some line must match (repeatable)")
+	checkCmd.Flags().StringArrayVar(&checkForbidden, "forbid", nil, "Pattern no line may match (repeatable)")
+	checkCmd.Flags().StringVar(&checkPatternsFile, "patterns", "", "File of patterns, one per line (\"!\" prefix: forbidden, \"#\": comment)")
+
+	// Mark flags as required
+	_ = checkCmd.MarkFlagRequired("input")
+	_ = checkCmd.MarkFlagRequired("output")
+	_ = checkCmd.MarkFlagRequired("stderr")
+	checkCmd.MarkFlagsOneRequired("require", "forbid", "patterns")
+
+	// Setup common flags using helper
+	helpers.SetupCommonFlags(checkCmd, &checkFlags)
+	helpers.SetupExpectFlags(checkCmd, &checkFlags)
+	helpers.SetupContextFlags(checkCmd, &checkContextConfig)
+	helpers.SetupWebhookFlags(checkCmd, &checkWebhookConfig)
+
+	checkCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		checkFlags.ScoreSet = cmd.Flags().Changed("score")
+		checkFlags.RecordEnvSet = cmd.Flags().Changed("record-env")
+		checkFlags.ExpectExitCodeSet = cmd.Flags().Changed("expect-exit-code")
+		if err := helpers.ValidateExpectExitCode(&checkFlags); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+
+		// Validate score expression early
+		if checkFlags.ScoreExpr != "" {
+			if _, err := helpers.ParseScoreExpression(checkFlags.ScoreExpr); err != nil {
+				return failure.Wrap(failure.Usage, err)
+			}
+		}
+		if checkFlags.Rubric != "" {
+			if _, err := rubric.Load(checkFlags.Rubric); err != nil {
+				return failure.Wrap(failure.ConfigInvalid, err)
+			}
+		}
+
+		// Validate preview sizes
+		if err := helpers.ValidateEmbedFlags(&checkFlags); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+
+		// Parse timeout if provided
+		var err error
+		checkFlags.Timeout, err = helpers.ParseTimeout(checkFlags.TimeoutStr)
+		if err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		checkFlags.SoftTimeout, err = helpers.ParseSoftTimeout(checkFlags.SoftTimeoutStr, checkFlags.Timeout)
+		if err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		checkFlags.ResultSchema, err = helpers.ParseResultSchema(checkFlags.ResultSchemaStr)
+		return failure.Wrap(failure.Usage, err)
+	}
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+// resetCheckFlags clears the check flags so they don't leak between tests
+func resetCheckFlags() {
+	resetFlags(checkCmd, "input", "output", "stderr", "patterns", "score", "require", "forbid")
+}
+
+func TestCheckCommand(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		patternsFile string
+		flags        []string
+		wantStatus   string
+		wantScore    string
+		wantPatterns []output.PatternResult
+		wantReport   string
+		wantCode     failure.Code
+	}{
+		{
+			name:       "all patterns satisfied",
+			input:      "Result: 42\nDone\n",
+			flags:      []string{"--require", `^Result: \d+$`, "--forbid", "Exception", "--score", "10"},
+			wantStatus: "success",
+			wantScore:  "10",
+			wantPatterns: []output.PatternResult{
+				{Pattern: `^Result: \d+$`, Kind: "required", Passed: true, Line: 1},
+				{Pattern: "Exception", Kind: "forbidden", Passed: true},
+			},
+			wantReport: "PASS required /^Result: \\d+$/: line 1: \"Result: 42\"\n",
+		},
+		{
+			name:       "partial score",
+			input:      "Result: 41\nException in thread main\n",
+			flags:      []string{"--require", "Result: 41", "--require", "Done", "--forbid", "Exception", "--score", "9"},
+			wantStatus: "failed",
+			wantScore:  "3",
+			wantPatterns: []output.PatternResult{
+				{Pattern: "Result: 41", Kind: "required", Passed: true, Line: 1},
+				{Pattern: "Done", Kind: "required", Passed: false},
+				{Pattern: "Exception", Kind: "forbidden", Passed: false, Line: 2},
+			},
+			wantReport: "FAIL forbidden /Exception/: line 2: \"Exception in thread main\"\n",
+		},
+		{
+			name:         "pattern file",
+			input:        "hello world\n",
+			patternsFile: "# greeting\n(?i)HELLO\n\n!error\n",
+			wantStatus:   "success",
+			wantPatterns: []output.PatternResult{
+				{Pattern: "(?i)HELLO", Kind: "required", Passed: true, Line: 1},
+				{Pattern: "error", Kind: "forbidden", Passed: true},
+			},
+		},
+		{
+			name:     "invalid pattern",
+			input:    "a\n",
+			flags:    []string{"--require", "("},
+			wantCode: failure.Usage,
+		},
+		{
+			name:         "invalid pattern in file",
+			input:        "a\n",
+			patternsFile: "ok\n!(\n",
+			wantCode:     failure.Usage,
+		},
+		{
+			name:         "empty pattern file",
+			input:        "a\n",
+			patternsFile: "# nothing\n",
+			wantCode:     failure.Usage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetCheckFlags()
+			defer resetCheckFlags()
+
+			dir := t.TempDir()
+			input := filepath.Join(dir, "input.txt")
+			if err := os.WriteFile(input, []byte(tt.input), 0644); err != nil {
+				t.Fatal(err)
+			}
+			report := filepath.Join(dir, "report.txt")
+			args := []string{"check", "-i", input, "-o", report, "-e", filepath.Join(dir, "stderr.txt")}
+			if tt.patternsFile != "" {
+				patterns := filepath.Join(dir, "patterns.txt")
+				if err := os.WriteFile(patterns, []byte(tt.patternsFile), 0644); err != nil {
+					t.Fatal(err)
+				}
+				args = append(args, "--patterns", patterns)
+			}
+			rootCmd.SetArgs(append(args, tt.flags...))
+
+			out, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantCode != "" {
+				if failure.CodeOf(err) != tt.wantCode {
+					t.Errorf("Error = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result output.Result
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, out)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			if tt.wantScore != "" && (result.Score == nil || result.Score.String() != tt.wantScore) {
+				t.Errorf("Score = %v, want %s", result.Score, tt.wantScore)
+			}
+			if len(result.Patterns) != len(tt.wantPatterns) {
+				t.Fatalf("Patterns = %+v, want %+v", result.Patterns, tt.wantPatterns)
+			}
+			for i, want := range tt.wantPatterns {
+				if result.Patterns[i] != want {
+					t.Errorf("Patterns[%d] = %+v, want %+v", i, result.Patterns[i], want)
+				}
+			}
+			if tt.wantReport != "" {
+				data, err := os.ReadFile(report)
+				if err != nil || !strings.Contains(string(data), tt.wantReport) {
+					t.Errorf("report = %q (%v), want it to contain %q", data, err, tt.wantReport)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckCommandMissingInput(t *testing.T) {
+	resetCheckFlags()
+	defer resetCheckFlags()
+
+	dir := t.TempDir()
+	rootCmd.SetArgs([]string{"check", "-i", filepath.Join(dir, "missing.txt"), "-o", filepath.Join(dir, "report.txt"),
+		"-e", filepath.Join(dir, "stderr.txt"), "--require", "a"})
+	_, err := captureOutput(func() error {
+		return rootCmd.Execute()
+	})
+	if failure.CodeOf(err) != failure.InputNotFound {
+		t.Errorf("Error = %v, want %s", err, failure.InputNotFound)
+	}
+}
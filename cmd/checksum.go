@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+	"github.com/zinc-sig/ghost/internal/checksum"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+var (
+	checksumAlgorithm string
+	checksumExpected  string
+	checksumManifest  string
+)
+
+var checksumCmd = &cobra.Command{
+	Use:   "checksum [--expected <checksum>] [--manifest <file>] [file...]",
+	Short: "Compute or verify file checksums",
+	Long: `Compute or verify SHA-256/SHA-512 checksums of stored artifacts.
+
+Without --expected or --manifest the checksums of the given files are computed.
+With --expected the single given file is verified against the checksum. With
+--manifest every file listed in a sha256sum/sha512sum style manifest is verified;
+relative paths are resolved against the manifest's directory.
+
+Checksums may be given as plain hex or with an algorithm prefix
+(e.g. sha512:<hex>). Results are written as JSON; the command exits with code 1
+if any file is missing or does not match.`,
+	Example: `  ghost checksum output.txt
+  ghost checksum -a sha512 output.txt stderr.txt
+  ghost checksum --expected 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08 output.txt
+  ghost checksum --manifest SHA256SUMS`,
+	RunE: checksumCommand,
+}
+
+func checksumCommand(cmd *cobra.Command, args []string) error {
+	if err := checksum.ValidateAlgorithm(checksumAlgorithm); err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	// An explicit algorithm applies to unprefixed checksums; otherwise it is inferred
+	defaultAlgorithm := ""
+	if cmd.Flags().Changed("algorithm") {
+		defaultAlgorithm = checksumAlgorithm
+	}
+
+	var entries []checksum.Entry
+	switch {
+	case checksumManifest != "":
+		if len(args) > 0 || checksumExpected != "" {
+			return failure.Wrap(failure.Usage, fmt.Errorf("--manifest cannot be combined with files or --expected"))
+		}
+		var err error
+		entries, err = checksum.LoadManifest(checksumManifest, defaultAlgorithm)
+		if errors.Is(err, fs.ErrNotExist) {
+			return failure.Wrap(failure.InputNotFound, err)
+		}
+		if err != nil {
+			return err
+		}
+	case checksumExpected != "":
+		if len(args) != 1 {
+			return failure.Wrap(failure.Usage, fmt.Errorf("--expected requires exactly one file"))
+		}
+		algorithm, digest, err := checksum.ParseExpected(checksumExpected, defaultAlgorithm)
+		if err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		entries = []checksum.Entry{{Path: args[0], Algorithm: algorithm, Expected: digest}}
+	default:
+		if len(args) == 0 {
+			return failure.Wrap(failure.Usage, fmt.Errorf("no files given (pass files or --manifest)"))
+		}
+		for _, path := range args {
+			entries = append(entries, checksum.Entry{Path: path, Algorithm: checksumAlgorithm})
+		}
+	}
+
+	report := &output.ChecksumReport{
+		Command: "checksum",
+		Status:  "success",
+		Files:   make([]output.ChecksumFile, 0, len(entries)),
+	}
+	failed := 0
+	for _, result := range checksum.Verify(entries) {
+		if result.Status == checksum.StatusMismatch || result.Status == checksum.StatusMissing {
+			failed++
+		}
+		report.Files = append(report.Files, output.ChecksumFile{
+			Path:      result.Path,
+			Algorithm: result.Algorithm,
+			Checksum:  result.Checksum,
+			Expected:  result.Expected,
+			Status:    result.Status,
+			Error:     result.Error,
+		})
+	}
+	if failed > 0 {
+		report.Status = "failed"
+	}
+
+	if err := helpers.PrintJSON(report); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		// The report already explains the failure
+		cmd.SilenceUsage = true
+		return failure.Wrap(failure.ChecksumMismatch, fmt.Errorf("checksum verification failed for %d of %d files", failed, len(entries)))
+	}
+	return nil
+}
+
+func init() {
+	checksumCmd.Flags().StringVarP(&checksumAlgorithm, "algorithm", "a", checksum.SHA256, "Checksum algorithm: sha256, sha512")
+	checksumCmd.Flags().StringVar(&checksumExpected, "expected", "", "Expected checksum of the single given file")
+	checksumCmd.Flags().StringVarP(&checksumManifest, "manifest", "m", "", "Manifest of \"<checksum>  <path>\" lines to verify")
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// resetChecksumFlags clears checksum flags so they don't leak between tests
+func resetChecksumFlags() {
+	resetFlags(checksumCmd, "algorithm", "expected", "manifest")
+}
+
+func TestChecksumCommand(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "output.txt")
+	if err := os.WriteFile(file, []byte("test"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	digest := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	manifest := filepath.Join(dir, "SHA256SUMS")
+	_ = os.WriteFile(manifest, []byte(digest+"  output.txt\n"+digest+"  missing.txt\n"), 0644)
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantStatus string
+		wantFiles  []string // expected per-file statuses
+		wantErr    string
+	}{
+		{
+			name:       "compute",
+			args:       []string{file},
+			wantStatus: "success",
+			wantFiles:  []string{"computed"},
+		},
+		{
+			name:       "expected match",
+			args:       []string{"--expected", digest, file},
+			wantStatus: "success",
+			wantFiles:  []string{"match"},
+		},
+		{
+			name:       "expected mismatch",
+			args:       []string{"--expected", "sha256:" + strings.Repeat("0", 64), file},
+			wantStatus: "failed",
+			wantFiles:  []string{"mismatch"},
+			wantErr:    "checksum verification failed for 1 of 1 files",
+		},
+		{
+			name:       "manifest",
+			args:       []string{"--manifest", manifest},
+			wantStatus: "failed",
+			wantFiles:  []string{"match", "missing"},
+			wantErr:    "checksum verification failed for 1 of 2 files",
+		},
+		{
+			name:    "expected with multiple files",
+			args:    []string{"--expected", digest, file, file},
+			wantErr: "--expected requires exactly one file",
+		},
+		{
+			name:    "unsupported algorithm",
+			args:    []string{"-a", "md5", file},
+			wantErr: "unsupported checksum algorithm",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetChecksumFlags()
+			defer resetChecksumFlags()
+
+			rootCmd.SetArgs(append([]string{"checksum"}, tt.args...))
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tt.wantStatus == "" {
+				return
+			}
+
+			var report struct {
+				Status string `json:"status"`
+				Files  []struct {
+					Status string `json:"status"`
+				} `json:"files"`
+			}
+			if err := json.Unmarshal([]byte(output), &report); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if report.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", report.Status, tt.wantStatus)
+			}
+			if len(report.Files) != len(tt.wantFiles) {
+				t.Fatalf("Got %d files, want %d", len(report.Files), len(tt.wantFiles))
+			}
+			for i, file := range report.Files {
+				if file.Status != tt.wantFiles[i] {
+					t.Errorf("File %d status = %s, want %s", i, file.Status, tt.wantFiles[i])
+				}
+			}
+		})
+	}
+}
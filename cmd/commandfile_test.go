@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+// resetCommandFileFlags clears the command spec and the I/O flags it may have filled in
+func resetCommandFileFlags() {
+	resetFlags(runCmd, "command-file", "input", "output", "stderr")
+	rootCmd.SetIn(nil)
+}
+
+func TestRunCommandFile(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(input, []byte("from input\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	outputPath := filepath.Join(dir, "output.txt")
+	stderrPath := filepath.Join(dir, "stderr.txt")
+
+	specJSON, err := json.Marshal(map[string]any{
+		"command": "sh",
+		"args":    []string{"-c", `cat; printf '%s|' "$@"; echo "$GREETING"`, "sh", "--", "a b", "$HOME"},
+		"env":     map[string]string{"GREETING": "hello"},
+		"input":   input,
+		"output":  outputPath,
+		"stderr":  stderrPath,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	specFile := filepath.Join(dir, "spec.json")
+	if err := os.WriteFile(specFile, specJSON, 0644); err != nil {
+		t.Fatal(err)
+	}
+	wantOutput := "from input\n--|a b|$HOME|hello\n"
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantOutput string
+	}{
+		{name: "file", args: []string{"run", "--command-file", specFile}},
+		{name: "stdin", args: []string{"run", "--command-file", "-"}, stdin: string(specJSON)},
+		{name: "flags override spec paths", args: []string{"run", "--command-file", specFile, "-o", filepath.Join(dir, "override.txt")}, wantOutput: filepath.Join(dir, "override.txt")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetCommandFileFlags()
+			defer resetCommandFileFlags()
+			_ = os.Remove(outputPath)
+
+			if tt.stdin != "" {
+				rootCmd.SetIn(strings.NewReader(tt.stdin))
+			}
+			rootCmd.SetArgs(tt.args)
+			out, err := captureOutput(func() error { return rootCmd.Execute() })
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result output.Result
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, out)
+			}
+			wantPath := outputPath
+			if tt.wantOutput != "" {
+				wantPath = tt.wantOutput
+			}
+			if result.Status != "success" || result.Output != wantPath || result.Input != input {
+				t.Errorf("result = %+v", result)
+			}
+			if data, _ := os.ReadFile(wantPath); string(data) != wantOutput {
+				t.Errorf("output = %q, want %q", data, wantOutput)
+			}
+		})
+	}
+}
+
+func TestRunCommandFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	specFile := filepath.Join(dir, "spec.json")
+	if err := os.WriteFile(specFile, []byte(`{"args": ["x"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	partialSpec := filepath.Join(dir, "partial.json")
+	if err := os.WriteFile(partialSpec, []byte(`{"command": "true"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantErr  string
+		wantCode failure.Code
+	}{
+		{name: "invalid spec", args: []string{"run", "--command-file", specFile}, wantErr: "command is required", wantCode: failure.ConfigInvalid},
+		{name: "missing spec", args: []string{"run", "--command-file", filepath.Join(dir, "missing.json")}, wantErr: "failed to read command file", wantCode: failure.ConfigInvalid},
+		{name: "with command after separator", args: []string{"run", "--command-file", partialSpec, "-i", "/dev/null", "-o", "out", "-e", "err", "--", "true"}, wantErr: "cannot be used with a command after '--'", wantCode: failure.Usage},
+		{name: "missing I/O paths", args: []string{"run", "--command-file", partialSpec}, wantErr: "required flag 'input' not set", wantCode: failure.Usage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetCommandFileFlags()
+			defer resetCommandFileFlags()
+
+			rootCmd.SetArgs(tt.args)
+			_, err := captureOutput(func() error { return rootCmd.Execute() })
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Error = %v, want containing %q", err, tt.wantErr)
+			}
+			if code := failure.CodeOf(err); code != tt.wantCode {
+				t.Errorf("code = %v, want %v", code, tt.wantCode)
+			}
+		})
+	}
+}
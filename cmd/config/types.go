@@ -7,6 +7,7 @@ type ContextConfig struct {
 	JSON string
 	KV   []string
 	File string
+	Git  bool // Add the metadata of the working directory's git repository
 }
 
 // UploadConfig holds upload-related flags
@@ -16,6 +17,46 @@ type UploadConfig struct {
 	ConfigKV    []string
 	ConfigFile  string
 	UploadFiles []string // Additional files to upload (format: local[:remote])
+	Retries     int      // Maximum upload retry attempts per file
+	RetryDelay  string   // Initial delay between upload retries
+	FailPolicy  string   // What to do when an upload fails: error, warn, cleanup
+	Compress    string   // Compression applied to output/stderr before upload: gzip
+	Encrypt     string   // Encryption of uploaded objects: sse-c:file:<path> or sse-c:env:<VAR>
+	ArtifactTTL string   // Retention tagged on uploaded objects for lifecycle expiry, e.g. 7d
+	Presign     string   // Validity of presigned download URLs added to upload results, e.g. 24h
+	FromRemote  bool     // Also upload the input and expected files next to the remote output
+	Bandwidth   string   // Maximum upload rate, e.g. 10MB/s
+	Concurrency int      // Files uploaded at once (1 = one after another)
+	IfAbsent    bool     // Skip files already stored at their remote path
+	Streaming   bool     // Upload the output while the command writes it (ghost run only)
+	MaxFileSize string   // Maximum size of each additional file, e.g. 10MB
+	MaxTotal    string   // Maximum total size of the additional files, e.g. 100MB
+}
+
+// FilterConfig holds output filter flags (action:regex rules)
+type FilterConfig struct {
+	Stdout []string
+	Stderr []string
+}
+
+// CaptureConfig holds how capture files are written
+type CaptureConfig struct {
+	Append   bool   // Append to the output, stderr and combined files instead of truncating them
+	Combined string // File capturing stdout and stderr interleaved, each line tagged with its stream
+}
+
+// DeterminismConfig holds the environment variables pinned so a command's
+// output doesn't differ between hosts
+type DeterminismConfig struct {
+	Locale   string   // LANG and LC_ALL
+	Timezone string   // TZ
+	SeedEnv  []string // Further KEY=VALUE variables, e.g. RANDOM_SEED=42
+}
+
+// ProgressConfig holds progress reporting settings of batch commands
+type ProgressConfig struct {
+	Format string // none or json
+	FD     int    // file descriptor the events are written to
 }
 
 // CommonFlags holds commonly used flags across commands
@@ -24,8 +65,50 @@ type CommonFlags struct {
 	DryRun     bool
 	TimeoutStr string
 	Timeout    time.Duration
+
+	// SoftTimeout sends SIGTERM before Timeout kills the command (0 = none)
+	SoftTimeoutStr string
+	SoftTimeout    time.Duration
+
 	Score      string
 	ScoreSet   bool
+	ScoreExpr  string
+	ScoreCmd   string // External grading command receiving the result on stdin
+	Rubric     string // Rubric file whose criteria add up to the score
+	RunID      string // Overrides the generated run ID (for idempotent re-delivery)
+	ResultFile string // Also write the JSON result here (format: local[:remote])
+
+	// Environment capture
+	RecordEnv    []string // Prefixes of environment variables to record
+	RecordEnvSet bool     // Whether --record-env was given (even with no prefixes)
+
+	// Tracing
+	OtelEndpoint string
+
+	// Exit code expectations
+	ExpectExitCode    int
+	ExpectExitCodeSet bool
+	ExpectNonzero     bool
+
+	// Exit with the command's exit code instead of 0 once a result is printed
+	PropagateExitCode bool
+
+	// Bytes of the captured streams embedded in the result (0 = off)
+	EmbedOutputHead int
+	EmbedStderrTail int
+
+	// Format of the compiler or interpreter messages counted in the stderr file ("" = off)
+	StderrClassify string
+
+	// Print a summary for people instead of the JSON result
+	Human bool
+
+	// Sinks the result is also logged to (journal, syslog)
+	LogSinks []string
+
+	// Schema version of the result, e.g. "1" for --result-schema v1
+	ResultSchemaStr string
+	ResultSchema    string
 }
 
 // WebhookConfig holds webhook-related flags
@@ -35,9 +118,37 @@ type WebhookConfig struct {
 	Method     string // HTTP method (GET, POST, PUT, PATCH, DELETE)
 	AuthType   string
 	AuthToken  string
+	AuthHeader string // Header name for auth type header
 	Timeout    string
 	Retries    int
 	RetryDelay string
+	RateLimit  float64 // Maximum deliveries per second (0 = unlimited)
+
+	// Custom request headers ("Name: value")
+	Headers []string
+
+	// Lifecycle events to deliver (empty = final result only)
+	Events []string
+
+	// Response handling
+	ExpectStatus    []int // Status codes that count as delivered (empty = any 2xx)
+	CaptureResponse bool  // Record the receiver's response in the result
+
+	// Async delivery through a local spool
+	Async    bool   // Spool deliveries for `ghost webhook flush`
+	SpoolDir string // Spool directory (empty = default cache directory)
+
+	// Circuit breaker shared by the runs of a batch
+	BreakerThreshold int    // Consecutive failures that open the circuit (0 = no breaker)
+	BreakerCooldown  string // Wait before probing an open circuit
+
+	// Transport
+	Proxy             string // Proxy URL (overrides HTTP(S)_PROXY)
+	DisableKeepAlives bool   // Open a new connection for every delivery
+
+	// Payload filtering (dot-notation field paths)
+	IncludeFields []string
+	ExcludeFields []string
 
 	// Alternative configuration methods
 	Config     string   // JSON string configuration
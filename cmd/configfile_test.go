@@ -0,0 +1,261 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// resetConfigFileFlags clears the --config and --profile flags and the run flags set by the test config files
+func resetConfigFileFlags() {
+	configFile = ""
+	configProfile = ""
+	for _, name := range []string{"config", "profile"} {
+		if f := rootCmd.PersistentFlags().Lookup(name); f != nil {
+			f.Changed = false
+		}
+	}
+	resetFlags(runCmd, "timeout", "verbose", "webhook-url", "webhook-retries", "webhook-timeout", "webhook-spool-dir")
+	resetScoringFlags()
+	resetTimeoutGlobals()
+}
+
+func TestRunCommandConfigFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		flags       []string
+		wantScore   string
+		wantTimeout int64
+		wantErr     string
+	}{
+		{
+			name:        "top-level and command section values",
+			config:      "score: 10\nrun:\n  timeout: 5s\ndiff:\n  timeout: 1s\n  diff-flags: -w\n",
+			wantScore:   "10",
+			wantTimeout: 5000,
+		},
+		{
+			name:        "command line flags take precedence",
+			config:      "score: 10\ntimeout: 5s\n",
+			flags:       []string{"--score", "20"},
+			wantScore:   "20",
+			wantTimeout: 5000,
+		},
+		{
+			name:        "nested keys map to flag names",
+			config:      "score: 5\nwebhook:\n  retries: 0\n  timeout: 10s\n",
+			wantScore:   "5",
+			wantTimeout: 0,
+		},
+		{
+			name:    "unknown key",
+			config:  "scroe: 10\n",
+			wantErr: `unknown key "scroe"`,
+		},
+		{
+			name:    "invalid value",
+			config:  "webhook:\n  retries: many\n",
+			wantErr: `invalid value for "webhook-retries"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetConfigFileFlags()
+			defer resetConfigFileFlags()
+
+			dir := t.TempDir()
+			configPath := filepath.Join(dir, "ghost.yaml")
+			if err := os.WriteFile(configPath, []byte(tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			inputFile := filepath.Join(dir, "input.txt")
+			if err := os.WriteFile(inputFile, []byte(""), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			args := []string{"run", "--config", configPath, "-i", inputFile,
+				"-o", filepath.Join(dir, "output.txt"), "-e", filepath.Join(dir, "stderr.txt")}
+			args = append(args, tt.flags...)
+			args = append(args, "--", "true")
+			rootCmd.SetArgs(args)
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result struct {
+				Score   *string `json:"score,omitempty"`
+				Timeout *int64  `json:"timeout,omitempty"`
+			}
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if result.Score == nil || *result.Score != tt.wantScore {
+				t.Errorf("Score = %v, want %s", result.Score, tt.wantScore)
+			}
+			var timeout int64
+			if result.Timeout != nil {
+				timeout = *result.Timeout
+			}
+			if timeout != tt.wantTimeout {
+				t.Errorf("Timeout = %d, want %d", timeout, tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestRunCommandConfigProfile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	config := `score: 1
+timeout: 1s
+profiles:
+  course-cs101:
+    score: 10
+    webhook:
+      url: http://127.0.0.1:1/unreachable
+      retries: 0
+      timeout: 1s
+    run:
+      timeout: 5s
+  course-cs102:
+    score: 20
+`
+	tests := []struct {
+		name            string
+		flags           []string
+		env             map[string]string
+		wantScore       string
+		wantTimeout     int64
+		wantWebhookSent bool
+		wantErr         string
+	}{
+		{
+			name:        "no profile",
+			wantScore:   "1",
+			wantTimeout: 1000,
+		},
+		{
+			name:        "profile overrides top-level values",
+			flags:       []string{"--profile", "course-cs101"},
+			wantScore:   "10",
+			wantTimeout: 5000,
+		},
+		{
+			name:        "profile from the environment",
+			env:         map[string]string{"GHOST_PROFILE": "course-cs102"},
+			wantScore:   "20",
+			wantTimeout: 1000,
+		},
+		{
+			name:            "environment variables override the profile",
+			flags:           []string{"--profile", "course-cs101"},
+			env:             map[string]string{"GHOST_WEBHOOK_URL": server.URL},
+			wantScore:       "10",
+			wantTimeout:     5000,
+			wantWebhookSent: true,
+		},
+		{
+			name:        "flags override the profile",
+			flags:       []string{"--profile", "course-cs101", "--score", "30"},
+			wantScore:   "30",
+			wantTimeout: 5000,
+		},
+		{
+			name:    "unknown profile",
+			flags:   []string{"--profile", "course-cs999"},
+			wantErr: `unknown profile "course-cs999"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetConfigFileFlags()
+			defer resetConfigFileFlags()
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			dir := t.TempDir()
+			configPath := filepath.Join(dir, "ghost.yaml")
+			if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			args := []string{"run", "--config", configPath, "-i", "/dev/null",
+				"-o", filepath.Join(dir, "output.txt"), "-e", filepath.Join(dir, "stderr.txt"),
+				"--webhook-spool-dir", dir}
+			args = append(args, tt.flags...)
+			rootCmd.SetArgs(append(args, "--", "true"))
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result struct {
+				Score       *string `json:"score,omitempty"`
+				Timeout     *int64  `json:"timeout,omitempty"`
+				WebhookSent bool    `json:"webhook_sent"`
+			}
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if result.Score == nil || *result.Score != tt.wantScore {
+				t.Errorf("Score = %v, want %s", result.Score, tt.wantScore)
+			}
+			var timeout int64
+			if result.Timeout != nil {
+				timeout = *result.Timeout
+			}
+			if timeout != tt.wantTimeout {
+				t.Errorf("Timeout = %d, want %d", timeout, tt.wantTimeout)
+			}
+			if result.WebhookSent != tt.wantWebhookSent {
+				t.Errorf("WebhookSent = %v, want %v", result.WebhookSent, tt.wantWebhookSent)
+			}
+		})
+	}
+}
+
+func TestRunCommandConfigFileMissing(t *testing.T) {
+	resetConfigFileFlags()
+	defer resetConfigFileFlags()
+
+	dir := t.TempDir()
+	rootCmd.SetArgs([]string{"run", "--config", filepath.Join(dir, "missing.yaml"),
+		"-i", "/dev/null", "-o", filepath.Join(dir, "out.txt"), "-e", filepath.Join(dir, "err.txt"), "--", "true"})
+
+	_, err := captureOutput(func() error {
+		return rootCmd.Execute()
+	})
+	if err == nil || !strings.Contains(err.Error(), "failed to read config file") {
+		t.Errorf("Expected missing config file error, got %v", err)
+	}
+}
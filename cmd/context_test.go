@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/failure"
+)
+
+func TestRunCommandNestedContextKV(t *testing.T) {
+	tests := []struct {
+		name        string
+		flags       []string
+		wantContext any
+		wantErr     string
+	}{
+		{
+			name:  "dotted keys",
+			flags: []string{"--context-kv", "student.meta.section=3", "--context-kv", `student.id="007"`},
+			wantContext: map[string]any{
+				"student": map[string]any{"meta": map[string]any{"section": float64(3)}, "id": "007"},
+			},
+		},
+		{
+			name:  "merged into JSON context",
+			flags: []string{"--context", `{"student": {"name": "alice", "meta": {"section": 1}}}`, "--context-kv", "student.meta.section=3"},
+			wantContext: map[string]any{
+				"student": map[string]any{"name": "alice", "meta": map[string]any{"section": float64(3)}},
+			},
+		},
+		{
+			name:    "conflicting keys",
+			flags:   []string{"--context-kv", "student=alice", "--context-kv", "student.id=1"},
+			wantErr: "student is not an object",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetContextFlags()
+			defer resetContextFlags()
+			args := append([]string{"run", "-i", "/dev/null", "-o", "/dev/null", "-e", "/dev/null"}, tt.flags...)
+			rootCmd.SetArgs(append(args, "--", "true"))
+
+			stdout, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result struct {
+				Context any `json:"context"`
+			}
+			if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+				t.Fatalf("Failed to parse output: %v\nOutput: %s", err, stdout)
+			}
+			if !reflect.DeepEqual(result.Context, tt.wantContext) {
+				t.Errorf("context = %v, want %v", result.Context, tt.wantContext)
+			}
+		})
+	}
+}
+
+func TestRunCommandContextGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	resetContextFlags()
+	defer resetContextFlags()
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"-c", "user.name=Ada", "-c", "user.email=ada@example.com", "commit", "-q", "--allow-empty", "-m", "initial"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	// Explicit context overrides the git metadata
+	rootCmd.SetArgs([]string{"run", "-i", "/dev/null", "-o", "/dev/null", "-e", "/dev/null",
+		"--context-git", "--context-kv", "git.branch=release", "--", "true"})
+	stdout, err := captureOutput(rootCmd.Execute)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var result struct {
+		Context struct {
+			Git map[string]any `json:"git"`
+		} `json:"context"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("Failed to parse output: %v\nOutput: %s", err, stdout)
+	}
+	git := result.Context.Git
+	if git["branch"] != "release" || git["author"] != "Ada <ada@example.com>" || git["dirty"] != false || len(git["commit"].(string)) != 40 {
+		t.Errorf("context.git = %v", git)
+	}
+}
+
+func TestRunCommandContextGitOutsideRepository(t *testing.T) {
+	resetContextFlags()
+	defer resetContextFlags()
+	t.Chdir(t.TempDir())
+
+	rootCmd.SetArgs([]string{"run", "-i", "/dev/null", "-o", "/dev/null", "-e", "/dev/null", "--context-git", "--", "true"})
+	_, err := captureOutput(rootCmd.Execute)
+	if err == nil || failure.CodeOf(err) != failure.ContextInvalid {
+		t.Errorf("err = %v, want %s", err, failure.ContextInvalid)
+	}
+}
@@ -3,22 +3,51 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/zinc-sig/ghost/cmd/config"
 	"github.com/zinc-sig/ghost/cmd/helpers"
-	contextparser "github.com/zinc-sig/ghost/internal/context"
+	"github.com/zinc-sig/ghost/internal/compare"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/rubric"
 	"github.com/zinc-sig/ghost/internal/runner"
+	"github.com/zinc-sig/ghost/internal/upload"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 var (
 	// Command-specific I/O flags
-	diffInputFile    string
-	diffExpectedFile string
-	diffOutputFile   string
-	diffStderrFile   string
-	diffFlags        string
+	diffInputFile     string
+	diffExpectedFiles []string
+	diffExpectedAny   string
+	diffOutputFile    string
+	diffStderrFile    string
+	diffFlags         string
+	diffEngine        string
+	diffMode          string
+	diffTolerance     float64
+
+	// Options of --mode csv
+	diffCSVColumns      []string
+	diffCSVIgnoreHeader bool
+	diffCSVUnordered    bool
+	diffCSVTolerances   []string
+
+	// Options of --mode image
+	diffImageMethod    string
+	diffImageThreshold float64
+
+	// Normalisation applied to both sides before comparing
+	diffEncoding          string
+	diffIgnoreLineEndings bool
+
+	// Inline expected content instead of --expected
+	diffExpectedString    string
+	diffExpectedStringSet bool
+	diffExpectedStdin     bool
 
 	// Common flag structures
 	diffCommonFlags   config.CommonFlags
@@ -28,56 +57,247 @@ var (
 )
 
 var diffCmd = &cobra.Command{
-	Use:   "diff -i <input> -x <expected> -o <output> -e <stderr> [--diff-flags <flags>] [--score <value>]",
-	Short: "Compare two files with structured output",
+	Use:   "diff -i <input> -x <expected> -o <output> -e <stderr> [--score <value>] [-- <diff args>...]",
+	Short: "Compare two files or directories with structured output",
 	Long: `Compare two files using diff and output the results in JSON format.
 Returns exit code 0 if files are identical, 1 if they differ.
 
+If both --input and --expected are directories they are compared recursively.
+The result then includes a "files" array with the status of each file
+(match, differ, missing or extra) and --score is awarded in proportion to the
+number of matching files.
+
 The diff output is written to the specified output file, stderr to the stderr file,
 and metadata including execution time and optional scoring is returned as JSON.
 
-You can pass additional flags to the diff command using --diff-flags.
-Common flags for grading include:
+Additional arguments of the diff command go after '--' and are passed on as they
+are, so arguments containing spaces or quotes such as --ignore-matching-lines='^#'
+are not split. --diff-flags still takes them as a single whitespace-separated
+string. Common flags for grading include:
   --ignore-trailing-space (-Z): Ignore white space at line end
   --ignore-space-change (-b): Ignore changes in amount of white space
   --ignore-all-space (-w): Ignore all white space
-  --ignore-blank-lines (-B): Ignore changes where lines are all blank`,
+  --ignore-blank-lines (-B): Ignore changes where lines are all blank
+
+Output written on Windows can be compared with --ignore-line-endings (CRLF and CR
+become LF) and --encoding (the input is converted from utf-16 or latin1 to UTF-8).
+
+--mode selects how files are compared: diff (the default, a line diff), json
+(JSON values, ignoring formatting and key order), numeric (whitespace-separated
+tokens, numbers within --tolerance) or regex (each expected line is a pattern the
+input line must match), csv (cells, see the --csv-* flags) or image (PNG, JPEG
+or GIF images at least --threshold similar). Modes other than diff compare files
+only and write a list of differences instead of a diff, or a diff image for
+image.`,
 	Example: `  ghost diff -i actual.txt -x expected.txt -o diff_output.txt -e errors.txt
   ghost diff -i result.txt -x expected.txt -o diff.txt -e errors.txt --score 100
-  ghost diff -i student.txt -x solution.txt -o diff.txt -e errors.txt --diff-flags "--ignore-trailing-space"
-  ghost diff -i output.txt -x expected.txt -o diff.txt -e errors.txt --diff-flags "-w -B" --score 100`,
+  ghost diff -i student.txt -x solution.txt -o diff.txt -e errors.txt -- --ignore-trailing-space
+  ghost diff -i output.txt -x expected.txt -o diff.txt -e errors.txt --score 100 -- -w -B
+  ghost diff -i main.c -x expected.c -o diff.txt -e errors.txt --engine external -- --ignore-matching-lines='^#'
+  ghost diff -i generated/ -x expected/ -o diff.txt -e errors.txt --score 100
+  ghost diff -i answer.txt --expected-string 42 -o diff.txt -e errors.txt --score 1
+  generate-answer | ghost diff -i answer.txt --expected-stdin -o diff.txt -e errors.txt
+  ghost diff -i out.txt -x expected.txt -o diff.txt -e errors.txt --ignore-line-endings --encoding utf-16
+  ghost diff -i answer.json -x expected.json -o diff.txt -e errors.txt --mode json
+  ghost diff -i area.txt -x area.expected -o diff.txt -e errors.txt --mode numeric --tolerance 1e-4
+  ghost diff -i out.csv -x expected.csv -o diff.txt -e errors.txt --mode csv --csv-ignore-header --csv-unordered --csv-tolerance price=0.01
+  ghost diff -i render.png -x reference.png -o diff.png -e errors.txt --mode image --threshold 0.98`,
+	Args: validateDiffArgs,
 	RunE: diffCommand,
 }
 
+// validateDiffArgs only accepts positional arguments after '--', where they are
+// arguments of the diff command
+func validateDiffArgs(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 && cmd.ArgsLenAtDash() != 0 {
+		return failure.Wrap(failure.Usage, fmt.Errorf("unexpected argument %q (arguments of the diff command go after '--')", args[0]))
+	}
+	return nil
+}
+
 func diffCommand(cmd *cobra.Command, args []string) error {
+	// Webhook settings are parsed per invocation and passed to every delivery
+	webhookConfig, webhookRetryConfig, err := helpers.ParseWebhookConfigToInternal(&diffWebhookConfig)
+	if err != nil {
+		return failure.Wrap(failure.WebhookConfigInvalid, err)
+	}
+
+	// Setup tracing, the run ID and the result file for this invocation
+	ctx, exec, err := helpers.StartExecution(cmd, &diffCommonFlags, webhookConfig, webhookRetryConfig)
+	if err != nil {
+		return err
+	}
+	defer exec.End()
+
+	// Alternative correct outputs from repeated --expected and --expected-any
+	candidates, err := helpers.ExpectedCandidates(diffExpectedFiles, diffExpectedAny)
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+	var firstCandidate string
+	if len(candidates) > 0 {
+		firstCandidate = candidates[0]
+	}
+
+	// Inline expected content is compared through a temp file
+	expectedFile, reportedExpected, cleanupExpected, err := helpers.ResolveExpected(helpers.ExpectedSource{
+		File:      firstCandidate,
+		String:    diffExpectedString,
+		StringSet: diffExpectedStringSet,
+		Stdin:     diffExpectedStdin,
+	}, cmd.InOrStdin())
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+	defer cleanupExpected()
+
 	// Validate required I/O flags
 	ioFlags := helpers.IOFlags{
 		Input:    diffInputFile,
 		Output:   diffOutputFile,
 		Stderr:   diffStderrFile,
-		Expected: diffExpectedFile,
+		Expected: expectedFile,
 	}
 	if err := helpers.ValidateIOFlags(ioFlags, true); err != nil {
-		return err
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	// Compare recursively when both operands are directories
+	dirMode, err := helpers.IsDirectoryComparison(diffInputFile, expectedFile)
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	// Select the comparator and check the flags are supported by it
+	if err := validateModeFlags(cmd, dirMode, len(args) > 0); err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+	flags := args
+	if len(flags) == 0 {
+		flags = strings.Fields(diffFlags)
+	}
+	engine := compare.EngineInternal
+	if diffMode == compare.ModeDiff {
+		engine, err = compare.ResolveEngine(diffEngine)
+		if err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+	}
+	csvOpts, err := csvOptions()
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+	comparator, err := compare.NewComparator(diffMode, compare.Options{Engine: engine, DiffFlags: flags, Tolerance: diffTolerance, CSV: csvOpts,
+		Image: compare.ImageOptions{Method: diffImageMethod, Threshold: diffImageThreshold}})
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+	equal := compare.Equal(comparator)
+	exec.Span.SetAttributes(attribute.String("ghost.diff.mode", diffMode), attribute.String("ghost.diff.engine", engine))
+
+	encoding, err := compare.ParseEncoding(diffEncoding)
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+	// --encoding describes the input under test; expected files are read as UTF-8
+	inputNormalization := compare.Normalization{Encoding: encoding, IgnoreLineEndings: diffIgnoreLineEndings}
+	expectedNormalization := compare.Normalization{IgnoreLineEndings: diffIgnoreLineEndings}
+	if encoding != "" {
+		expectedNormalization.Encoding = compare.EncodingUTF8
+	}
+
+	if len(candidates) > 1 && dirMode {
+		return failure.Wrap(failure.Usage, fmt.Errorf("multiple expected outputs can only be compared with files, not directories"))
+	}
+	if len(candidates) == 0 {
+		// Inline expected content
+		candidates = []string{expectedFile}
+	}
+
+	// Decoded, normalised copies are compared in place of the originals
+	compareInput, compareCandidates := diffInputFile, candidates
+	if inputNormalization.Enabled() && !diffCommonFlags.DryRun {
+		inputCopies, cleanupInput, err := helpers.NormalizeForComparison(inputNormalization, dirMode, []string{diffInputFile})
+		if err != nil {
+			return err
+		}
+		defer cleanupInput()
+		candidateCopies, cleanupCandidates, err := helpers.NormalizeForComparison(expectedNormalization, dirMode, candidates)
+		if err != nil {
+			return err
+		}
+		defer cleanupCandidates()
+		compareInput, compareCandidates = inputCopies[0], candidateCopies
+	}
+	compareExpected := compareCandidates[0]
+
+	// With several acceptable outputs, diff against the first one that matches,
+	// or the first one if none does
+	var matchedExpected string
+	if len(candidates) > 1 {
+		if !diffCommonFlags.DryRun {
+			match, err := helpers.MatchExpected(ctx, compareInput, compareCandidates, equal)
+			if err != nil {
+				return err
+			}
+			if match >= 0 {
+				matchedExpected = candidates[match]
+				compareExpected, reportedExpected = compareCandidates[match], matchedExpected
+			}
+		}
+		exec.Span.SetAttributes(attribute.Int("ghost.diff.expected_candidates", len(candidates)))
 	}
 
 	// Setup upload provider if configured
 	provider, uploadConf, err := helpers.SetupUploadProvider(&diffUploadConfig, diffCommonFlags.DryRun)
 	if err != nil {
-		return err
+		return failure.Wrap(failure.UploadConfigInvalid, err)
+	}
+
+	// Parse the retry, encryption, bandwidth and concurrency settings of the uploads
+	uploadOpts, err := helpers.ParseUploadOptions(&diffUploadConfig)
+	if err != nil {
+		return failure.Wrap(failure.UploadConfigInvalid, err)
+	}
+	uploadLimits, err := helpers.ParseUploadLimits(&diffUploadConfig)
+	if err != nil {
+		return failure.Wrap(failure.UploadConfigInvalid, err)
+	}
+	presignExpiry, err := helpers.ParseUploadPresign(&diffUploadConfig, provider, uploadOpts.Encryption)
+	if err != nil {
+		return failure.Wrap(failure.UploadConfigInvalid, err)
+	}
+	if err := helpers.CheckUploadCapabilities(&diffUploadConfig, provider); err != nil {
+		return failure.Wrap(failure.UploadConfigInvalid, err)
 	}
 
 	// Parse additional upload files if specified
 	var additionalFiles map[string]string
+	var fileAttributes map[string]upload.Attributes
 	if len(diffUploadConfig.UploadFiles) > 0 {
-		additionalFiles, err = helpers.ParseUploadFiles(diffUploadConfig.UploadFiles)
+		additionalFiles, fileAttributes, err = helpers.ParseUploadFiles(diffUploadConfig.UploadFiles)
 		if err != nil {
-			return fmt.Errorf("failed to parse upload files: %w", err)
+			return failure.Wrap(failure.UploadConfigInvalid, fmt.Errorf("failed to parse upload files: %w", err))
 		}
+		helpers.ExpandRunIDInFiles(additionalFiles, exec.RunID)
+		helpers.ExpandRunIDInAttributes(fileAttributes, exec.RunID)
 	}
 
 	// Parse output paths to support local:remote syntax
-	outputPaths := helpers.ParseOutputPaths(diffOutputFile, diffStderrFile)
+	outputPaths := helpers.ParseOutputPaths(diffOutputFile, diffStderrFile).ExpandRunID(exec.RunID)
+
+	// Mirror the compared files next to the diff for provenance
+	uploadRoles := helpers.UploadRoles{}
+	if provider != nil && diffUploadConfig.FromRemote {
+		inputs := map[string]string{diffInputFile: helpers.UploadRoleInput}
+		for _, candidate := range candidates {
+			inputs[candidate] = helpers.UploadRoleExpected
+		}
+		additionalFiles, err = helpers.MirrorInputs(additionalFiles, uploadRoles, outputPaths.RemoteOutput, inputs)
+		if err != nil {
+			return failure.Wrap(failure.UploadConfigInvalid, err)
+		}
+	}
 
 	// Determine remote paths for display (what will be uploaded)
 	displayOutputPath := diffOutputFile
@@ -149,65 +369,87 @@ func diffCommand(cmd *cobra.Command, args []string) error {
 		defer cleanup()
 	}
 
+	// Stream targets cannot be read back for upload
+	if provider != nil {
+		if err := helpers.ValidateUploadTargets(actualOutputFile, actualStderrFile); err != nil {
+			return failure.Wrap(failure.UploadConfigInvalid, err)
+		}
+	}
+
 	// Build args for diff command
 	var diffArgs []string
-
-	// Add flags if provided
-	if diffFlags != "" {
-		// Parse the flags string by splitting on whitespace
-		flags := strings.Fields(diffFlags)
-		diffArgs = append(diffArgs, flags...)
+	if dirMode && !slices.Contains(flags, "-r") && !slices.Contains(flags, "--recursive") {
+		diffArgs = append(diffArgs, "-r")
+	}
+	diffArgs = append(diffArgs, flags...)
+	if diffMode != compare.ModeDiff {
+		// Shown in the reported command line
+		diffArgs = append(diffArgs, "--mode="+diffMode)
 	}
 
 	// Add the file paths
-	diffArgs = append(diffArgs, diffInputFile, diffExpectedFile)
+	diffArgs = append(diffArgs, compareInput, compareExpected)
 
 	// Build diff command config
 	config := &runner.Config{
-		Command:    "diff",
-		Args:       diffArgs,
-		InputFile:  "/dev/null", // diff doesn't need stdin
-		OutputFile: actualOutputFile,
-		StderrFile: actualStderrFile,
-		Verbose:    diffCommonFlags.Verbose,
-		DryRun:     diffCommonFlags.DryRun,
-		Timeout:    diffCommonFlags.Timeout,
+		RunID:       exec.RunID,
+		Command:     "diff",
+		Args:        diffArgs,
+		InputFile:   "/dev/null", // diff doesn't need stdin
+		OutputFile:  actualOutputFile,
+		StderrFile:  actualStderrFile,
+		Verbose:     diffCommonFlags.Verbose,
+		DryRun:      diffCommonFlags.DryRun,
+		Timeout:     diffCommonFlags.Timeout,
+		SoftTimeout: diffCommonFlags.SoftTimeout,
+	}
+	if engine == compare.EngineInternal {
+		config.Builtin = helpers.CompareBuiltin(comparator, compareInput, compareExpected)
 	}
 
 	// Execute diff command
-	result, err := runner.Execute(config)
+	// Build context from all sources
+	if err := exec.BuildContext(&diffContextConfig); err != nil {
+		return err
+	}
+
+	// Lifecycle events let dashboards show in-progress executions
+	exec.SendStarted(ctx, config.FullCommand())
+
+	result, err := helpers.ExecuteWithSpan(ctx, config)
 	if err != nil {
-		return fmt.Errorf("failed to execute diff: %w", err)
+		return failure.Wrap(failure.ExecutionFailed, fmt.Errorf("failed to execute diff: %w", err))
 	}
+	exec.SendTimeout(ctx, result)
+
+	// Map actual files to remote paths
+	uploadFiles := map[string]string{
+		actualOutputFile: outputPaths.RemoteOutput,
+		actualStderrFile: outputPaths.RemoteStderr,
+	}
+	uploadRoles[actualOutputFile] = helpers.UploadRoleOutput
+	uploadRoles[actualStderrFile] = helpers.UploadRoleStderr
 
 	// Upload files if provider is configured
+	var uploadResults []output.UploadResult
 	if provider != nil {
-		// Validate additional files exist after command execution
+		// Expand and check additional files after command execution
 		if additionalFiles != nil && !diffCommonFlags.DryRun {
-			if err := helpers.ValidateUploadFiles(additionalFiles); err != nil {
-				return err
+			additionalFiles, err = helpers.ExpandUploadFiles(additionalFiles, fileAttributes, uploadRoles, uploadLimits)
+			if err != nil {
+				return failure.Wrap(failure.UploadFailed, err)
 			}
 		}
 
-		// Map actual files to remote paths
-		files := map[string]string{
-			actualOutputFile: outputPaths.RemoteOutput,
-			actualStderrFile: outputPaths.RemoteStderr,
+		uploadResults, err = helpers.HandleUploads(ctx, provider, uploadFiles, additionalFiles, fileAttributes, uploadRoles, *uploadOpts, nil, diffCommonFlags.Verbose, diffCommonFlags.DryRun)
+		if err == nil {
+			err = helpers.PresignUploads(ctx, provider, uploadResults, presignExpiry, diffCommonFlags.Verbose, diffCommonFlags.DryRun)
 		}
-		if err := helpers.HandleUploads(provider, files, additionalFiles, diffCommonFlags.Verbose, diffCommonFlags.DryRun); err != nil {
-			return err
-		}
-	}
 
-	// Build context from all sources
-	ctx, err := contextparser.BuildContext(diffContextConfig.JSON, diffContextConfig.KV, diffContextConfig.File)
-	if err != nil {
-		return fmt.Errorf("failed to build context: %w", err)
-	}
-
-	// Print context info in dry run mode
-	if diffCommonFlags.DryRun && ctx != nil {
-		helpers.PrintContextInfo(ctx, true)
+		exec.SendUploadFinished(ctx, result.Command, uploadResults)
+		if err != nil {
+			return failure.Wrap(failure.UploadFailed, err)
+		}
 	}
 
 	// Create JSON result for diff command
@@ -219,29 +461,122 @@ func diffCommand(cmd *cobra.Command, args []string) error {
 		diffInputFile,
 		diffOutputFile,
 		diffStderrFile,
-		diffExpectedFile, // expected path for diff command
+		reportedExpected, // expected path for diff command ("-" for stdin, empty for --expected-string)
 		result,
 		timeoutMs,
 		diffCommonFlags.ScoreSet,
 		diffCommonFlags.Score,
-		ctx,
+		exec.Context,
 	)
 
-	// Output JSON and send webhook
-	return helpers.OutputJSONAndWebhook(jsonResult, diffCommonFlags.Verbose, diffCommonFlags.DryRun)
+	// Always present for diff, even when inline content has no path, so consumers
+	// can tell diff results from run results
+	jsonResult.Expected = &reportedExpected
+	jsonResult.MatchedExpected = matchedExpected
+
+	jsonResult.Uploads = uploadResults
+
+	// Record per-file status and partial score for directory comparisons
+	if dirMode && !diffCommonFlags.DryRun {
+		if err := helpers.ApplyDirectoryComparison(ctx, jsonResult, compareInput, compareExpected, equal, diffCommonFlags.ScoreSet, diffCommonFlags.Score); err != nil {
+			return err
+		}
+	}
+
+	return helpers.FinishExecution(ctx, exec, jsonResult, helpers.FinishOptions{
+		OutputFile: config.OutputFile,
+		StderrFile: config.StderrFile,
+		Plan: &output.Plan{
+			Command:  result.Command,
+			Input:    config.InputFile,
+			Output:   actualOutputFile,
+			Stderr:   actualStderrFile,
+			Expected: reportedExpected,
+			Upload:   helpers.PlanUploads(provider, uploadConf, &diffUploadConfig, uploadFiles, additionalFiles, fileAttributes, uploadRoles, uploadOpts),
+		},
+		Provider: provider,
+		Upload:   uploadOpts,
+	})
+}
+
+// modeFlags are the flags that only apply to a single comparison mode, in the
+// order they are checked
+var modeFlags = []struct{ name, mode string }{
+	{"diff-flags", compare.ModeDiff},
+	{"engine", compare.ModeDiff},
+	{"tolerance", compare.ModeNumeric},
+	{"csv-columns", compare.ModeCSV},
+	{"csv-ignore-header", compare.ModeCSV},
+	{"csv-unordered", compare.ModeCSV},
+	{"csv-tolerance", compare.ModeCSV},
+	{"image-method", compare.ModeImage},
+	{"threshold", compare.ModeImage},
+}
+
+// validateModeFlags rejects flags that do not apply to the selected comparison
+// mode, including arguments of the diff command after '--'
+func validateModeFlags(cmd *cobra.Command, dirMode, passthrough bool) error {
+	if err := compare.ValidateMode(diffMode); err != nil {
+		return err
+	}
+	if passthrough && cmd.Flags().Changed("diff-flags") {
+		return fmt.Errorf("--diff-flags cannot be combined with diff arguments after '--'")
+	}
+	if passthrough && diffMode != compare.ModeDiff {
+		return fmt.Errorf("diff arguments after '--' only apply to --mode %s", compare.ModeDiff)
+	}
+	for _, flag := range modeFlags {
+		if flag.mode != diffMode && cmd.Flags().Changed(flag.name) {
+			return fmt.Errorf("--%s only applies to --mode %s", flag.name, flag.mode)
+		}
+	}
+	if diffMode != compare.ModeDiff && dirMode {
+		return fmt.Errorf("--mode %s compares files, not directories", diffMode)
+	}
+	return nil
+}
+
+// csvOptions collects the --csv-* flags
+func csvOptions() (compare.CSVOptions, error) {
+	tolerances, err := compare.ParseCSVTolerances(diffCSVTolerances)
+	if err != nil {
+		return compare.CSVOptions{}, err
+	}
+	return compare.CSVOptions{
+		Columns:      diffCSVColumns,
+		IgnoreHeader: diffCSVIgnoreHeader,
+		Unordered:    diffCSVUnordered,
+		Tolerances:   tolerances,
+	}, nil
 }
 
 func init() {
 	// Command-specific flags
 	diffCmd.Flags().StringVarP(&diffInputFile, "input", "i", "", "Input file to compare (required)")
-	diffCmd.Flags().StringVarP(&diffExpectedFile, "expected", "x", "", "Expected file to compare against (required)")
+	diffCmd.Flags().StringArrayVarP(&diffExpectedFiles, "expected", "x", nil, "Expected file to compare against (repeatable: any of them may match; required unless --expected-any, --expected-string or --expected-stdin is used)")
+	diffCmd.Flags().StringVar(&diffExpectedAny, "expected-any", "", "Directory of alternative expected files; a match against any of them passes")
+	diffCmd.Flags().StringVar(&diffExpectedString, "expected-string", "", "Expected content given inline (a trailing newline is added if missing)")
+	diffCmd.Flags().BoolVar(&diffExpectedStdin, "expected-stdin", false, "Read the expected content from stdin")
 	diffCmd.Flags().StringVarP(&diffOutputFile, "output", "o", "", "Output file for diff results (required)")
 	diffCmd.Flags().StringVarP(&diffStderrFile, "stderr", "e", "", "Error file to capture diff's stderr (required)")
-	diffCmd.Flags().StringVar(&diffFlags, "diff-flags", "", "Flags to pass to the diff command (e.g., \"--ignore-trailing-space -B\")")
+	diffCmd.Flags().StringVar(&diffFlags, "diff-flags", "", "Flags to pass to the diff command, split on whitespace (e.g., \"--ignore-trailing-space -B\"); arguments after '--' are passed unsplit")
+	diffCmd.Flags().StringVar(&diffEncoding, "encoding", "", "Encoding of the input, converted to UTF-8 before comparing: utf-8, utf-16, utf-16le, utf-16be, latin1 (BOMs are stripped)")
+	diffCmd.Flags().BoolVar(&diffIgnoreLineEndings, "ignore-line-endings", false, "Treat CRLF and CR line endings as LF (a UTF-8 BOM is stripped)")
+	diffCmd.Flags().StringVar(&diffEngine, "engine", compare.EngineAuto, "Comparison engine: auto (external diff if installed), external, internal")
+	diffCmd.Flags().StringVar(&diffMode, "mode", compare.ModeDiff, "Comparison mode: diff (line diff), json (JSON values), numeric (tokens, numbers within --tolerance), regex (expected lines are patterns), csv (cells), image (similarity)")
+	diffCmd.Flags().Float64Var(&diffTolerance, "tolerance", compare.DefaultTolerance, "Absolute or relative error allowed between numbers with --mode numeric")
+	diffCmd.Flags().StringSliceVar(&diffCSVColumns, "csv-columns", nil, "Columns compared with --mode csv, by header name or 1-based number (default: all)")
+	diffCmd.Flags().BoolVar(&diffCSVIgnoreHeader, "csv-ignore-header", false, "Do not compare the first row with --mode csv")
+	diffCmd.Flags().BoolVar(&diffCSVUnordered, "csv-unordered", false, "Accept the rows in any order with --mode csv")
+	diffCmd.Flags().StringSliceVar(&diffCSVTolerances, "csv-tolerance", nil, "Numeric tolerance with --mode csv as column=tolerance, or tolerance for all columns (repeatable)")
+	diffCmd.Flags().StringVar(&diffImageMethod, "image-method", compare.ImageMethodPixel, "Similarity measure of --mode image: pixel (share of identical pixels), phash (perceptual hash)")
+	diffCmd.Flags().Float64Var(&diffImageThreshold, "threshold", compare.DefaultImageThreshold, "Minimum similarity from 0 to 1 for images to match with --mode image")
 
 	// Mark flags as required
 	_ = diffCmd.MarkFlagRequired("input")
-	_ = diffCmd.MarkFlagRequired("expected")
+	diffCmd.MarkFlagsOneRequired("expected", "expected-any", "expected-string", "expected-stdin")
+	diffCmd.MarkFlagsMutuallyExclusive("expected", "expected-string", "expected-stdin")
+	diffCmd.MarkFlagsMutuallyExclusive("expected-any", "expected-string", "expected-stdin")
 	_ = diffCmd.MarkFlagRequired("output")
 	_ = diffCmd.MarkFlagRequired("stderr")
 
@@ -253,19 +588,37 @@ func init() {
 
 	diffCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
 		diffCommonFlags.ScoreSet = cmd.Flags().Changed("score")
+		diffExpectedStringSet = cmd.Flags().Changed("expected-string")
+		diffCommonFlags.RecordEnvSet = cmd.Flags().Changed("record-env")
+
+		// Validate score expression early
+		if diffCommonFlags.ScoreExpr != "" {
+			if _, err := helpers.ParseScoreExpression(diffCommonFlags.ScoreExpr); err != nil {
+				return failure.Wrap(failure.Usage, err)
+			}
+		}
+		if diffCommonFlags.Rubric != "" {
+			if _, err := rubric.Load(diffCommonFlags.Rubric); err != nil {
+				return failure.Wrap(failure.ConfigInvalid, err)
+			}
+		}
+
+		// Validate preview sizes
+		if err := helpers.ValidateEmbedFlags(&diffCommonFlags); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
 
 		// Parse timeout if provided
 		var err error
 		diffCommonFlags.Timeout, err = helpers.ParseTimeout(diffCommonFlags.TimeoutStr)
 		if err != nil {
-			return err
+			return failure.Wrap(failure.Usage, err)
 		}
-
-		// Parse webhook configuration for diff
-		if err := helpers.ParseWebhookConfig(&diffWebhookConfig, false); err != nil {
-			return err
+		diffCommonFlags.SoftTimeout, err = helpers.ParseSoftTimeout(diffCommonFlags.SoftTimeoutStr, diffCommonFlags.Timeout)
+		if err != nil {
+			return failure.Wrap(failure.Usage, err)
 		}
-
-		return nil
+		diffCommonFlags.ResultSchema, err = helpers.ParseResultSchema(diffCommonFlags.ResultSchemaStr)
+		return failure.Wrap(failure.Usage, err)
 	}
 }
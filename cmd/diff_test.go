@@ -5,9 +5,13 @@ import (
 	"encoding/json"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 // captureOutput captures stdout during function execution
@@ -26,6 +30,32 @@ func captureOutput(f func() error) (string, error) {
 	return buf.String(), err
 }
 
+// resetFlags restores the named flags of a command to their defaults so they
+// don't leak between tests
+func resetFlags(cmd *cobra.Command, names ...string) {
+	for _, name := range names {
+		if f := cmd.Flags().Lookup(name); f != nil {
+			resetFlag(f)
+		}
+	}
+}
+
+// resetAllFlags restores every flag of a command to its default
+func resetAllFlags(cmd *cobra.Command) {
+	cmd.Flags().VisitAll(resetFlag)
+}
+
+// resetFlag restores a flag to its default
+// Repeatable flags append once set, so they are emptied instead.
+func resetFlag(f *pflag.Flag) {
+	if slice, ok := f.Value.(pflag.SliceValue); ok {
+		_ = slice.Replace(nil)
+	} else {
+		_ = f.Value.Set(f.DefValue)
+	}
+	f.Changed = false
+}
+
 func TestDiffCommand(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -143,7 +173,7 @@ func TestDiffCommand(t *testing.T) {
 
 			// Reset flags
 			diffInputFile = inputFile
-			diffExpectedFile = expectedFile
+			diffExpectedFiles = []string{expectedFile}
 			diffOutputFile = outputFile
 			diffStderrFile = stderrFile
 			diffFlags = ""
@@ -256,7 +286,7 @@ func TestDiffCommandValidation(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			// Set flags
 			diffInputFile = tt.inputFile
-			diffExpectedFile = tt.expectedFile
+			diffExpectedFiles = []string{tt.expectedFile}
 			diffOutputFile = tt.outputFile
 			diffStderrFile = tt.stderrFile
 			diffFlags = ""
@@ -289,7 +319,7 @@ func TestDiffCommandWithNestedDirectories(t *testing.T) {
 
 	// Set flags
 	diffInputFile = inputFile
-	diffExpectedFile = expectedFile
+	diffExpectedFiles = []string{expectedFile}
 	diffOutputFile = outputFile
 	diffStderrFile = stderrFile
 	diffFlags = ""
@@ -462,7 +492,7 @@ func TestDiffCommandWithFlags(t *testing.T) {
 
 			// Reset flags
 			diffInputFile = inputFile
-			diffExpectedFile = expectedFile
+			diffExpectedFiles = []string{expectedFile}
 			diffOutputFile = outputFile
 			diffStderrFile = stderrFile
 			diffFlags = tt.diffFlags
@@ -514,7 +544,621 @@ func TestDiffCommandWithFlags(t *testing.T) {
 	}
 }
 
+// TestDiffCommandDirectories tests recursive comparison of directories
+func TestDiffCommandDirectories(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputDir := filepath.Join(tmpDir, "input")
+	expectedDir := filepath.Join(tmpDir, "expected")
+
+	files := map[string][2]string{
+		"main.c":         {"int main() {}\n", "int main() {}\n"},
+		"src/util.c":     {"void util() {}\n", "void util() {}\n"},
+		"src/wrong.c":    {"return 1;\n", "return 0;\n"},
+		"include/util.h": {"", "void util();\n"},
+	}
+	for path, contents := range files {
+		for i, dir := range []string{inputDir, expectedDir} {
+			if path == "include/util.h" && i == 0 {
+				continue // missing from input
+			}
+			full := filepath.Join(dir, path)
+			_ = os.MkdirAll(filepath.Dir(full), 0755)
+			_ = os.WriteFile(full, []byte(contents[i]), 0644)
+		}
+	}
+
+	outputFile := filepath.Join(tmpDir, "diff_output.txt")
+	diffInputFile = inputDir
+	diffExpectedFiles = []string{expectedDir}
+	diffOutputFile = outputFile
+	diffStderrFile = filepath.Join(tmpDir, "diff_stderr.txt")
+	diffFlags = ""
+	diffCommonFlags.ScoreSet = true
+	diffCommonFlags.Score = "100"
+	defer func() {
+		diffCommonFlags.ScoreSet = false
+		diffCommonFlags.Score = ""
+	}()
+
+	output, err := captureOutput(func() error {
+		return diffCommand(diffCmd, []string{})
+	})
+	if err != nil {
+		t.Fatalf("diffCommand returned error: %v", err)
+	}
+
+	var result struct {
+		ExitCode int     `json:"exit_code"`
+		Score    *string `json:"score,omitempty"`
+		Files    []struct {
+			Path   string `json:"path"`
+			Status string `json:"status"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+	}
+
+	if result.ExitCode != 1 {
+		t.Errorf("Exit code = %d, want 1", result.ExitCode)
+	}
+	if result.Score == nil || *result.Score != "50" {
+		t.Errorf("Score = %v, want 50", result.Score)
+	}
+
+	wantStatus := map[string]string{
+		"include/util.h": "missing",
+		"main.c":         "match",
+		"src/util.c":     "match",
+		"src/wrong.c":    "differ",
+	}
+	if len(result.Files) != len(wantStatus) {
+		t.Fatalf("Files = %v, want %d entries", result.Files, len(wantStatus))
+	}
+	for _, file := range result.Files {
+		if wantStatus[file.Path] != file.Status {
+			t.Errorf("File %s status = %s, want %s", file.Path, file.Status, wantStatus[file.Path])
+		}
+	}
+
+	// The recursive diff output should mention the differing file
+	diffContent, _ := os.ReadFile(outputFile)
+	if !strings.Contains(string(diffContent), "wrong.c") {
+		t.Errorf("Expected recursive diff output to mention wrong.c, got: %s", diffContent)
+	}
+}
+
+func TestDiffCommandDirectoryWithFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	expectedFile := filepath.Join(tmpDir, "expected.txt")
+	_ = os.WriteFile(expectedFile, []byte("test"), 0644)
+
+	diffInputFile = tmpDir
+	diffExpectedFiles = []string{expectedFile}
+	diffOutputFile = filepath.Join(tmpDir, "diff_output.txt")
+	diffStderrFile = filepath.Join(tmpDir, "diff_stderr.txt")
+	diffFlags = ""
+
+	err := diffCommand(diffCmd, []string{})
+	if err == nil || !strings.Contains(err.Error(), "cannot compare a directory with a file") {
+		t.Errorf("Expected directory/file mismatch error, got %v", err)
+	}
+}
+
+func TestDiffCommandHasNoExpectFlags(t *testing.T) {
+	// diff's exit code is the mismatch status, so it cannot be redefined as success
+	for _, name := range []string{"expect-exit-code", "expect-nonzero"} {
+		if diffCmd.Flags().Lookup(name) != nil {
+			t.Errorf("diff registers --%s", name)
+		}
+		if runCmd.Flags().Lookup(name) == nil {
+			t.Errorf("run does not register --%s", name)
+		}
+	}
+}
+
+func TestDiffCommandEngine(t *testing.T) {
+	tests := []struct {
+		name         string
+		engine       string
+		input        string
+		expected     string
+		flags        string
+		wantExitCode int
+		wantStatus   string
+		wantOutput   string
+		wantErr      string
+	}{
+		{
+			name:         "internal identical",
+			engine:       "internal",
+			input:        "a\nb\n",
+			expected:     "a\nb\n",
+			wantExitCode: 0,
+			wantStatus:   "success",
+		},
+		{
+			name:         "internal different",
+			engine:       "internal",
+			input:        "a\nb\n",
+			expected:     "a\nc\n",
+			wantExitCode: 1,
+			wantStatus:   "failed",
+			wantOutput:   "@@ -1,2 +1,2 @@\n a\n-b\n+c\n",
+		},
+		{
+			name:         "internal with ignore flags",
+			engine:       "internal",
+			input:        "a  \n\nb\n",
+			expected:     "a\nb\n",
+			flags:        "--ignore-trailing-space -B",
+			wantExitCode: 0,
+			wantStatus:   "success",
+		},
+		{
+			name:     "internal rejects unsupported flags",
+			engine:   "internal",
+			input:    "a\n",
+			expected: "a\n",
+			flags:    "--side-by-side",
+			wantErr:  "not supported by the internal engine",
+		},
+		{
+			name:         "external",
+			engine:       "external",
+			input:        "a\n",
+			expected:     "b\n",
+			wantExitCode: 1,
+			wantStatus:   "failed",
+		},
+		{
+			name:     "invalid engine",
+			engine:   "fancy",
+			input:    "a\n",
+			expected: "a\n",
+			wantErr:  "invalid diff engine",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			inputFile := filepath.Join(tmpDir, "input.txt")
+			expectedFile := filepath.Join(tmpDir, "expected.txt")
+			outputFile := filepath.Join(tmpDir, "diff_output.txt")
+			_ = os.WriteFile(inputFile, []byte(tt.input), 0644)
+			_ = os.WriteFile(expectedFile, []byte(tt.expected), 0644)
+
+			diffInputFile = inputFile
+			diffExpectedFiles = []string{expectedFile}
+			diffOutputFile = outputFile
+			diffStderrFile = filepath.Join(tmpDir, "diff_stderr.txt")
+			diffFlags = tt.flags
+			diffEngine = tt.engine
+			defer func() {
+				diffFlags = ""
+				diffEngine = "auto"
+			}()
+
+			output, err := captureOutput(func() error {
+				return diffCommand(diffCmd, []string{})
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("diffCommand returned error: %v", err)
+			}
+
+			var result struct {
+				Status   string `json:"status"`
+				ExitCode int    `json:"exit_code"`
+			}
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if result.ExitCode != tt.wantExitCode || result.Status != tt.wantStatus {
+				t.Errorf("Exit code = %d, status = %s, want %d and %s", result.ExitCode, result.Status, tt.wantExitCode, tt.wantStatus)
+			}
+
+			if tt.wantOutput != "" {
+				diffContent, _ := os.ReadFile(outputFile)
+				if !strings.HasSuffix(string(diffContent), tt.wantOutput) {
+					t.Errorf("Diff output = %q, want suffix %q", diffContent, tt.wantOutput)
+				}
+			}
+		})
+	}
+}
+
 // Helper function to create string pointer
 func stringPtr(s string) *string {
 	return &s
 }
+
+func resetExpectedFlags() {
+	resetFlags(diffCmd, "expected-any", "expected-string", "expected-stdin", "expected")
+	diffExpectedStringSet = false
+	rootCmd.SetIn(nil)
+}
+
+func TestDiffCommandInlineExpected(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		args         []string
+		stdin        string
+		wantStatus   string
+		wantExpected *string
+		wantErr      string
+	}{
+		{name: "string gets a trailing newline", input: "42\n", args: []string{"--expected-string", "42"}, wantStatus: "success", wantExpected: stringPtr("")},
+		{name: "string mismatch", input: "41\n", args: []string{"--expected-string", "42"}, wantStatus: "failed", wantExpected: stringPtr("")},
+		{name: "empty string", input: "\n", args: []string{"--expected-string="}, wantStatus: "success", wantExpected: stringPtr("")},
+		{name: "stdin", input: "a\nb", args: []string{"--expected-stdin"}, stdin: "a\nb", wantStatus: "success", wantExpected: stringPtr("-")},
+		{name: "stdin is compared exactly", input: "a\nb\n", args: []string{"--expected-stdin"}, stdin: "a\nb", wantStatus: "failed", wantExpected: stringPtr("-")},
+		{name: "with expected file", input: "42\n", args: []string{"--expected-string", "42", "-x", "expected.txt"}, wantErr: "none of the others can be"},
+		{name: "no expected source", input: "42\n", wantErr: "at least one of the flags"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetExpectedFlags()
+			defer resetExpectedFlags()
+
+			tmpDir := t.TempDir()
+			inputFile := filepath.Join(tmpDir, "input.txt")
+			_ = os.WriteFile(inputFile, []byte(tt.input), 0644)
+			rootCmd.SetIn(strings.NewReader(tt.stdin))
+			rootCmd.SetArgs(append([]string{"diff", "-i", inputFile,
+				"-o", filepath.Join(tmpDir, "diff.txt"), "-e", filepath.Join(tmpDir, "stderr.txt")}, tt.args...))
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result struct {
+				Status   string  `json:"status"`
+				Expected *string `json:"expected"`
+			}
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			if (result.Expected == nil) != (tt.wantExpected == nil) ||
+				(result.Expected != nil && *result.Expected != *tt.wantExpected) {
+				t.Errorf("Expected = %v, want %v", result.Expected, tt.wantExpected)
+			}
+
+			// The temp file holding the inline content is removed afterwards
+			matches, _ := filepath.Glob(filepath.Join(os.TempDir(), "ghost-diff-expected-*.txt"))
+			if len(matches) > 0 {
+				t.Errorf("Temp expected files left behind: %v", matches)
+			}
+		})
+	}
+}
+
+func TestDiffCommandMultipleExpected(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	first := write("first.txt", "1 2 3\n")
+	second := write("second.txt", "3 2 1\n")
+	write("alternatives/a.txt", "1 2 3\n")
+	altB := write("alternatives/b.txt", "3 2 1\n")
+	emptyDir := filepath.Join(tmpDir, "empty")
+	if err := os.Mkdir(emptyDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		input        string
+		args         []string
+		wantStatus   string
+		wantExpected string
+		wantMatched  string
+		wantErr      string
+	}{
+		{name: "matches second", input: "3 2 1\n", args: []string{"-x", first, "-x", second}, wantStatus: "success", wantExpected: second, wantMatched: second},
+		{name: "matches first", input: "1 2 3\n", args: []string{"-x", first, "-x", second}, wantStatus: "success", wantExpected: first, wantMatched: first},
+		{name: "no match diffs against first", input: "2 1 3\n", args: []string{"-x", first, "-x", second}, wantStatus: "failed", wantExpected: first},
+		{name: "expected-any directory", input: "3 2 1\n", args: []string{"--expected-any", filepath.Join(tmpDir, "alternatives")}, wantStatus: "success", wantExpected: altB, wantMatched: altB},
+		{name: "single expected unchanged", input: "3 2 1\n", args: []string{"-x", second}, wantStatus: "success", wantExpected: second},
+		{name: "empty expected-any directory", input: "1\n", args: []string{"--expected-any", emptyDir}, wantErr: "no expected files"},
+		{name: "with expected string", input: "1\n", args: []string{"--expected-any", emptyDir, "--expected-string", "1"}, wantErr: "none of the others can be"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetExpectedFlags()
+			defer resetExpectedFlags()
+
+			inputFile := write("input.txt", tt.input)
+			rootCmd.SetArgs(append([]string{"diff", "-i", inputFile,
+				"-o", filepath.Join(tmpDir, "diff.txt"), "-e", filepath.Join(tmpDir, "stderr.txt")}, tt.args...))
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result struct {
+				Status          string `json:"status"`
+				Expected        string `json:"expected"`
+				MatchedExpected string `json:"matched_expected"`
+			}
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if result.Status != tt.wantStatus || result.Expected != tt.wantExpected || result.MatchedExpected != tt.wantMatched {
+				t.Errorf("status = %s, expected = %s, matched_expected = %s; want %s, %s, %s",
+					result.Status, result.Expected, result.MatchedExpected, tt.wantStatus, tt.wantExpected, tt.wantMatched)
+			}
+		})
+	}
+}
+
+func resetNormalizationFlags() {
+	resetFlags(diffCmd, "encoding", "ignore-line-endings")
+}
+
+func TestDiffCommandNormalization(t *testing.T) {
+	tmpDir := t.TempDir()
+	write := func(name string, content []byte) string {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	expected := write("expected.txt", []byte("héllo\nworld\n"))
+	alternative := write("alternative.txt", []byte("bye\n"))
+	write("want/a.txt", []byte("a\nb\n"))
+	write("got/a.txt", []byte("\xEF\xBB\xBFa\r\nb\r\n"))
+
+	// "héllo\r\nworld\r\n" as UTF-16LE with a BOM
+	utf16le := []byte{0xFF, 0xFE, 'h', 0, 0xE9, 0, 'l', 0, 'l', 0, 'o', 0, '\r', 0, '\n', 0,
+		'w', 0, 'o', 0, 'r', 0, 'l', 0, 'd', 0, '\r', 0, '\n', 0}
+
+	tests := []struct {
+		name        string
+		input       []byte
+		inputPath   string
+		expected    []string
+		args        []string
+		wantStatus  string
+		wantMatched string
+		wantErr     string
+	}{
+		{name: "crlf fails by default", input: []byte("héllo\r\nworld\r\n"), wantStatus: "failed"},
+		{name: "ignore line endings", input: []byte("héllo\r\nworld\r\n"), args: []string{"--ignore-line-endings"}, wantStatus: "success"},
+		{name: "bare cr", input: []byte("héllo\rworld\r"), args: []string{"--ignore-line-endings"}, wantStatus: "success"},
+		{name: "utf-8 bom", input: []byte("\xEF\xBB\xBFhéllo\nworld\n"), args: []string{"--encoding", "utf-8"}, wantStatus: "success"},
+		{name: "latin1", input: []byte("h\xE9llo\nworld\n"), args: []string{"--encoding", "latin1"}, wantStatus: "success"},
+		{name: "utf-16 without line ending flag", input: utf16le, args: []string{"--encoding", "utf-16"}, wantStatus: "failed"},
+		{name: "utf-16 with line ending flag", input: utf16le, args: []string{"--encoding", "utf-16", "--ignore-line-endings"}, wantStatus: "success"},
+		{
+			name: "matched expected reports original path", input: []byte("héllo\r\nworld\r\n"),
+			expected: []string{alternative, expected}, args: []string{"--ignore-line-endings"},
+			wantStatus: "success", wantMatched: expected,
+		},
+		{name: "directories", inputPath: filepath.Join(tmpDir, "got"), expected: []string{filepath.Join(tmpDir, "want")}, args: []string{"--ignore-line-endings"}, wantStatus: "success"},
+		{name: "unsupported encoding", input: []byte("x\n"), args: []string{"--encoding", "ebcdic"}, wantErr: "unsupported encoding"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetExpectedFlags()
+			resetNormalizationFlags()
+			defer resetExpectedFlags()
+			defer resetNormalizationFlags()
+
+			inputFile := tt.inputPath
+			if inputFile == "" {
+				inputFile = write("input.txt", tt.input)
+			}
+			expectedFiles := tt.expected
+			if expectedFiles == nil {
+				expectedFiles = []string{expected}
+			}
+			args := []string{"diff", "-i", inputFile,
+				"-o", filepath.Join(tmpDir, "diff.txt"), "-e", filepath.Join(tmpDir, "stderr.txt")}
+			for _, file := range expectedFiles {
+				args = append(args, "-x", file)
+			}
+			rootCmd.SetArgs(append(args, tt.args...))
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result struct {
+				Status          string `json:"status"`
+				Input           string `json:"input"`
+				MatchedExpected string `json:"matched_expected"`
+			}
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if result.Status != tt.wantStatus || result.MatchedExpected != tt.wantMatched {
+				t.Errorf("status = %s, matched_expected = %s; want %s, %s", result.Status, result.MatchedExpected, tt.wantStatus, tt.wantMatched)
+			}
+			if result.Input != inputFile {
+				t.Errorf("input = %s, want the original path %s", result.Input, inputFile)
+			}
+		})
+	}
+}
+
+// resetModeFlags clears the comparison mode flags so they don't leak between tests
+func resetModeFlags() {
+	resetFlags(diffCmd, "mode", "tolerance", "diff-flags", "engine", "csv-ignore-header", "csv-unordered", "image-method", "threshold", "csv-columns", "csv-tolerance")
+	// pflag remembers the position of '--' across parses
+	diffCmd.Flags().Init(diffCmd.Name(), pflag.ContinueOnError)
+}
+
+func TestDiffCommandModes(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		expected   string
+		args       []string
+		wantStatus string
+		wantOutput string
+		wantStderr string
+		wantErr    string
+	}{
+		{name: "json", input: `{"b":2,"a":1}`, expected: "{\n  \"a\": 1,\n  \"b\": 2\n}\n", args: []string{"--mode", "json"}, wantStatus: "success"},
+		{name: "json differs", input: `{"a":1}`, expected: `{"a":2}`, args: []string{"--mode", "json"}, wantStatus: "failed", wantOutput: "$.a: got 1, expected 2\n"},
+		{name: "numeric", input: "0.3333333\n", expected: "0.333333333\n", args: []string{"--mode", "numeric"}, wantStatus: "success"},
+		{name: "numeric tolerance", input: "0.335\n", expected: "0.33\n", args: []string{"--mode", "numeric", "--tolerance", "0.01"}, wantStatus: "success"},
+		{name: "regex", input: "Elapsed: 12ms\n", expected: `Elapsed: \d+ms` + "\n", args: []string{"--mode", "regex"}, wantStatus: "success"},
+		{name: "csv", input: "name,score\nbob,7.001\nann,9\n", expected: "Name,Score\nann,9\nbob,7\n", args: []string{"--mode", "csv", "--csv-ignore-header", "--csv-unordered", "--csv-tolerance", "2=0.01"}, wantStatus: "success"},
+		{name: "csv differs", input: "id,score\n1,7\n", expected: "id,score\n1,8\n", args: []string{"--mode", "csv", "--csv-ignore-header", "--csv-columns", "score"}, wantStatus: "failed", wantOutput: "line 2, column \"score\": got \"7\", expected \"8\"\n"},
+		{name: "csv unknown column", input: "a\n", expected: "a\n", args: []string{"--mode", "csv", "--csv-columns", "b"}, wantStatus: "failed", wantStderr: `column "b" not found`},
+		{name: "csv flags need csv mode", input: "a\n", expected: "a\n", args: []string{"--csv-unordered"}, wantErr: "--csv-unordered only applies to --mode csv"},
+		{name: "image not an image", input: "a\n", expected: "a\n", args: []string{"--mode", "image", "--threshold", "0.9"}, wantStatus: "failed", wantStderr: "invalid expected image"},
+		{name: "threshold needs image mode", input: "a\n", expected: "a\n", args: []string{"--threshold", "0.9"}, wantErr: "--threshold only applies to --mode image"},
+		{name: "unknown mode", input: "a\n", expected: "a\n", args: []string{"--mode", "xml"}, wantErr: `invalid comparison mode "xml"`},
+		{name: "diff flags need diff mode", input: "a\n", expected: "a\n", args: []string{"--mode", "json", "--diff-flags", "-w"}, wantErr: "--diff-flags only applies to --mode diff"},
+		{name: "tolerance needs numeric mode", input: "a\n", expected: "a\n", args: []string{"--tolerance", "0.1"}, wantErr: "--tolerance only applies to --mode numeric"},
+		{name: "diff arguments need diff mode", input: "a\n", expected: "a\n", args: []string{"--mode", "json", "--", "-w"}, wantErr: "diff arguments after '--' only apply to --mode diff"},
+		{name: "diff arguments and diff flags", input: "a\n", expected: "a\n", args: []string{"--diff-flags", "-w", "--", "-B"}, wantErr: "--diff-flags cannot be combined with diff arguments after '--'"},
+		{name: "argument before dash", input: "a\n", expected: "a\n", args: []string{"-w"}, wantErr: "unknown shorthand flag"},
+		{name: "positional argument", input: "a\n", expected: "a\n", args: []string{"extra"}, wantErr: `unexpected argument "extra"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetExpectedFlags()
+			resetModeFlags()
+			defer resetExpectedFlags()
+			defer resetModeFlags()
+
+			tmpDir := t.TempDir()
+			inputFile := filepath.Join(tmpDir, "input.txt")
+			expectedFile := filepath.Join(tmpDir, "expected.txt")
+			outputFile := filepath.Join(tmpDir, "diff.txt")
+			_ = os.WriteFile(inputFile, []byte(tt.input), 0644)
+			_ = os.WriteFile(expectedFile, []byte(tt.expected), 0644)
+			rootCmd.SetArgs(append([]string{"diff", "-i", inputFile, "-x", expectedFile,
+				"-o", outputFile, "-e", filepath.Join(tmpDir, "stderr.txt")}, tt.args...))
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result struct {
+				Command string `json:"command"`
+				Status  string `json:"status"`
+			}
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			if !strings.Contains(result.Command, "--mode=") {
+				t.Errorf("Command = %q, want the mode shown", result.Command)
+			}
+			diffContent, _ := os.ReadFile(outputFile)
+			if string(diffContent) != tt.wantOutput {
+				t.Errorf("Diff output = %q, want %q", diffContent, tt.wantOutput)
+			}
+			stderrContent, _ := os.ReadFile(filepath.Join(tmpDir, "stderr.txt"))
+			if !strings.Contains(string(stderrContent), tt.wantStderr) {
+				t.Errorf("Stderr = %q, want it to contain %q", stderrContent, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestDiffCommandPassthroughArgs(t *testing.T) {
+	if _, err := exec.LookPath("diff"); err != nil {
+		t.Skip("diff is not installed")
+	}
+	resetExpectedFlags()
+	resetModeFlags()
+	defer resetExpectedFlags()
+	defer resetModeFlags()
+
+	tmpDir := t.TempDir()
+	inputFile := filepath.Join(tmpDir, "input.c")
+	expectedFile := filepath.Join(tmpDir, "expected.c")
+	outputFile := filepath.Join(tmpDir, "diff.txt")
+	_ = os.WriteFile(inputFile, []byte("# include <stdio.h>\nint main() {}\n"), 0644)
+	_ = os.WriteFile(expectedFile, []byte("#include <stdio.h>\nint main() {}\n"), 0644)
+
+	// The pattern keeps its quotes and spaces, which --diff-flags would split
+	rootCmd.SetArgs([]string{"diff", "-i", inputFile, "-x", expectedFile, "-o", outputFile,
+		"-e", filepath.Join(tmpDir, "stderr.txt"), "--engine", "external", "--", "--ignore-matching-lines=^# *include"})
+	output, err := captureOutput(func() error {
+		return rootCmd.Execute()
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var result struct {
+		Status string `json:"status"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+	}
+	if result.Status != "success" {
+		t.Errorf("Status = %s, want success with the include lines ignored", result.Status)
+	}
+}
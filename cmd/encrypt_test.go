@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/upload"
+)
+
+// encryptingProvider records the encryption fingerprint used for each upload
+type encryptingProvider struct {
+	mu           sync.Mutex
+	fingerprints map[string]string
+}
+
+var testEncryptingProvider = &encryptingProvider{}
+
+func init() {
+	upload.RegisterProvider("test-encrypting", func() upload.Provider {
+		return testEncryptingProvider
+	})
+}
+
+func (p *encryptingProvider) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.fingerprints = make(map[string]string)
+}
+
+func (p *encryptingProvider) Name() string                   { return "test-encrypting" }
+func (p *encryptingProvider) Configure(map[string]any) error { return nil }
+
+func (p *encryptingProvider) Upload(ctx context.Context, reader io.Reader, remotePath string) error {
+	return p.UploadWithOptions(ctx, reader, remotePath, upload.Options{})
+}
+
+func (p *encryptingProvider) UploadWithOptions(ctx context.Context, reader io.Reader, remotePath string, opts upload.Options) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return err
+	}
+	p.fingerprints[remotePath] = ""
+	if opts.Encryption != nil {
+		p.fingerprints[remotePath] = opts.Encryption.Fingerprint
+	}
+	return nil
+}
+
+func (p *encryptingProvider) Download(context.Context, string, io.Writer) error {
+	return errors.New("not supported")
+}
+
+func TestRunCommandUploadEncrypt(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "ssec.key")
+	if err := os.WriteFile(keyFile, []byte(strings.Repeat("k", 32)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// sha256 of 32 'k' bytes
+	fingerprint := "sha256:5e318f8cf9cbe249a30812b8ca132d691ded7a91991413558db5758575f5e01f"
+
+	tests := []struct {
+		name     string
+		provider string
+		encrypt  string
+		wantErr  string
+	}{
+		{name: "encrypted uploads", provider: "test-encrypting", encrypt: "sse-c:file:" + keyFile},
+		{name: "provider without encryption", provider: "test-flaky", encrypt: "sse-c:file:" + keyFile, wantErr: "does not support encryption"},
+		{name: "invalid key", provider: "test-encrypting", encrypt: "sse-c:file:" + filepath.Join(dir, "missing"), wantErr: "failed to read encryption key"},
+		{name: "without provider", encrypt: "sse-c:file:" + keyFile, wantErr: "--upload-encrypt requires --upload-provider"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetUploadGlobals()
+			defer resetUploadGlobals()
+			testEncryptingProvider.reset()
+			testFlakyProvider.reset(nil)
+
+			args := []string{"run", "-i", "/dev/null",
+				"-o", filepath.Join(dir, "output.txt") + ":out.txt",
+				"-e", filepath.Join(dir, "stderr.txt") + ":err.txt",
+				"--upload-encrypt", tt.encrypt}
+			if tt.provider != "" {
+				args = append(args, "--upload-provider", tt.provider)
+			}
+			rootCmd.SetArgs(append(args, "--", "echo", "secret"))
+
+			out, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result output.Result
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, out)
+			}
+			if len(result.Uploads) != 2 {
+				t.Fatalf("Expected 2 uploads, got %+v", result.Uploads)
+			}
+			for _, u := range result.Uploads {
+				if u.Encryption != "sse-c" || u.KeyFingerprint != fingerprint {
+					t.Errorf("%s: encryption = %q, fingerprint = %q, want sse-c and %s", u.Remote, u.Encryption, u.KeyFingerprint, fingerprint)
+				}
+				if testEncryptingProvider.fingerprints[u.Remote] != fingerprint {
+					t.Errorf("%s was not uploaded with the encryption key", u.Remote)
+				}
+			}
+			if strings.Contains(out, strings.Repeat("k", 32)) {
+				t.Error("The encryption key must never appear in the result")
+			}
+		})
+	}
+}
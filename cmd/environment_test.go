@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/failure"
+)
+
+// resetRecordEnvFlags clears the environment capture flag so it doesn't leak between tests
+func resetRecordEnvFlags() {
+	resetFlags(runCmd, "record-env")
+}
+
+func TestRunCommandRecordEnv(t *testing.T) {
+	t.Setenv("GHOST_TEST_SEED", "1234")
+
+	tests := []struct {
+		name      string
+		flags     []string
+		wantBlock bool
+		wantVars  map[string]string
+	}{
+		{name: "not recorded by default"},
+		{
+			name:      "selected prefixes",
+			flags:     []string{"--record-env", "GHOST_TEST_,NO_SUCH_PREFIX_"},
+			wantBlock: true,
+			wantVars:  map[string]string{"GHOST_TEST_SEED": "1234"},
+		},
+		{
+			name:      "host details only",
+			flags:     []string{"--record-env="},
+			wantBlock: true,
+			wantVars:  map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetRecordEnvFlags()
+			defer resetRecordEnvFlags()
+
+			dir := t.TempDir()
+			args := []string{"run", "-i", "/dev/null", "-o", filepath.Join(dir, "output.txt"),
+				"-e", filepath.Join(dir, "stderr.txt")}
+			args = append(args, tt.flags...)
+			args = append(args, "--", "true")
+			rootCmd.SetArgs(args)
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result struct {
+				Environment *struct {
+					Variables  map[string]string `json:"variables"`
+					OS         string            `json:"os"`
+					WorkingDir string            `json:"working_dir"`
+				} `json:"environment"`
+			}
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+
+			if !tt.wantBlock {
+				if result.Environment != nil {
+					t.Errorf("Expected no environment block, got %+v", result.Environment)
+				}
+				return
+			}
+			if result.Environment == nil {
+				t.Fatal("Expected environment block")
+			}
+			if result.Environment.OS == "" || result.Environment.WorkingDir == "" {
+				t.Errorf("Expected host details, got %+v", result.Environment)
+			}
+			if len(result.Environment.Variables) != len(tt.wantVars) {
+				t.Errorf("Variables = %v, want %v", result.Environment.Variables, tt.wantVars)
+			}
+			for k, v := range tt.wantVars {
+				if result.Environment.Variables[k] != v {
+					t.Errorf("Variables[%s] = %q, want %q", k, result.Environment.Variables[k], v)
+				}
+			}
+		})
+	}
+}
+
+// resetDeterminismFlags clears the pinned environment flags so they don't leak between tests
+func resetDeterminismFlags() {
+	resetFlags(runCmd, "set-locale", "set-tz", "set-seed-env")
+}
+
+func TestRunCommandDeterminism(t *testing.T) {
+	resetDeterminismFlags()
+	defer resetDeterminismFlags()
+	t.Setenv("TZ", "America/New_York")
+
+	dir := t.TempDir()
+	outputFile := filepath.Join(dir, "output.txt")
+	rootCmd.SetArgs([]string{"run", "-i", "/dev/null", "-o", outputFile, "-e", filepath.Join(dir, "stderr.txt"),
+		"--set-locale", "C", "--set-tz", "UTC", "--set-seed-env", "RANDOM_SEED=42", "--set-seed-env", "PYTHONHASHSEED=0",
+		"--", "sh", "-c", "echo $LANG $LC_ALL $TZ $RANDOM_SEED $PYTHONHASHSEED"})
+	out, err := captureOutput(rootCmd.Execute)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if got, _ := os.ReadFile(outputFile); string(got) != "C C UTC 42 0\n" {
+		t.Errorf("Command environment = %q", got)
+	}
+	var result struct {
+		Determinism struct {
+			Locale   string            `json:"locale"`
+			Timezone string            `json:"timezone"`
+			Seeds    map[string]string `json:"seeds"`
+		} `json:"determinism"`
+	}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, out)
+	}
+	want := map[string]string{"RANDOM_SEED": "42", "PYTHONHASHSEED": "0"}
+	if result.Determinism.Locale != "C" || result.Determinism.Timezone != "UTC" || !reflect.DeepEqual(result.Determinism.Seeds, want) {
+		t.Errorf("Determinism = %+v", result.Determinism)
+	}
+}
+
+func TestRunCommandDeterminismInvalid(t *testing.T) {
+	resetDeterminismFlags()
+	defer resetDeterminismFlags()
+
+	dir := t.TempDir()
+	rootCmd.SetArgs([]string{"run", "-i", "/dev/null", "-o", filepath.Join(dir, "output.txt"), "-e", filepath.Join(dir, "stderr.txt"),
+		"--set-seed-env", "RANDOM_SEED", "--", "true"})
+	_, err := captureOutput(rootCmd.Execute)
+	if err == nil || failure.CodeOf(err) != failure.Usage {
+		t.Errorf("err = %v, want %s", err, failure.Usage)
+	}
+}
@@ -0,0 +1,144 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// resetWebhookEventFlags clears the webhook event flag so it doesn't leak between tests
+func resetWebhookEventFlags() {
+	resetFlags(runCmd, "webhook-events", "timeout")
+	runFlags.Timeout = 0
+	resetRunIDFlags()
+}
+
+// webhookDelivery is a webhook request received by the test server
+type webhookDelivery struct {
+	Event          string
+	IdempotencyKey string
+	Payload        map[string]any
+}
+
+func TestRunCommandWebhookEvents(t *testing.T) {
+	tests := []struct {
+		name       string
+		flags      []string
+		command    []string
+		wantEvents []string // payload "event" fields in delivery order ("" = final result without event)
+		wantSent   bool
+	}{
+		{
+			name:       "final result only by default",
+			command:    []string{"true"},
+			wantEvents: []string{""},
+			wantSent:   true,
+		},
+		{
+			name:       "started and completed",
+			flags:      []string{"--webhook-events", "started,completed"},
+			command:    []string{"true"},
+			wantEvents: []string{"started", "completed"},
+			wantSent:   true,
+		},
+		{
+			name:       "every event",
+			flags:      []string{"--webhook-events", "started,timeout,upload_finished,completed", "--timeout", "100ms", "--upload-provider", "test-flaky"},
+			command:    []string{"sleep", "5"},
+			wantEvents: []string{"started", "timeout", "upload_finished", "completed"},
+			wantSent:   true,
+		},
+		{
+			name:       "completed left out",
+			flags:      []string{"--webhook-events", "started"},
+			command:    []string{"true"},
+			wantEvents: []string{"started"},
+			wantSent:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetWebhookEventFlags()
+			defer resetWebhookEventFlags()
+			testFlakyProvider.reset(nil)
+
+			var mu sync.Mutex
+			var deliveries []webhookDelivery
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				var payload map[string]any
+				_ = json.Unmarshal(body, &payload)
+				mu.Lock()
+				deliveries = append(deliveries, webhookDelivery{
+					Event:          r.Header.Get("X-Ghost-Event"),
+					IdempotencyKey: r.Header.Get("Idempotency-Key"),
+					Payload:        payload,
+				})
+				mu.Unlock()
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+			args := []string{"run", "-i", "/dev/null", "-o", filepath.Join(dir, "output.txt") + ":output.txt",
+				"-e", filepath.Join(dir, "stderr.txt") + ":stderr.txt", "--run-id", "run-1",
+				"--webhook-url", server.URL, "--webhook-retries", "0"}
+			args = append(args, tt.flags...)
+			args = append(append(args, "--"), tt.command...)
+			rootCmd.SetArgs(args)
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result struct {
+				WebhookSent bool `json:"webhook_sent"`
+			}
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if result.WebhookSent != tt.wantSent {
+				t.Errorf("webhook_sent = %v, want %v", result.WebhookSent, tt.wantSent)
+			}
+
+			if len(deliveries) != len(tt.wantEvents) {
+				t.Fatalf("Got %d deliveries, want %d: %+v", len(deliveries), len(tt.wantEvents), deliveries)
+			}
+			keys := map[string]bool{}
+			for i, delivery := range deliveries {
+				event, _ := delivery.Payload["event"].(string)
+				if event != tt.wantEvents[i] || delivery.Event != tt.wantEvents[i] {
+					t.Errorf("Delivery %d event = %q (header %q), want %q", i, event, delivery.Event, tt.wantEvents[i])
+				}
+				if delivery.Payload["run_id"] != "run-1" {
+					t.Errorf("Delivery %d run_id = %v, want run-1", i, delivery.Payload["run_id"])
+				}
+				if delivery.Payload["schema_version"] != "2" {
+					t.Errorf("Delivery %d schema_version = %v, want 2", i, delivery.Payload["schema_version"])
+				}
+				if keys[delivery.IdempotencyKey] {
+					t.Errorf("Delivery %d reuses idempotency key %q", i, delivery.IdempotencyKey)
+				}
+				keys[delivery.IdempotencyKey] = true
+				if event == "" || event == "completed" {
+					if delivery.IdempotencyKey != "run-1" {
+						t.Errorf("Final result idempotency key = %q, want run-1", delivery.IdempotencyKey)
+					}
+				}
+				if event == "upload_finished" {
+					if uploads, _ := delivery.Payload["uploads"].([]any); len(uploads) != 2 {
+						t.Errorf("Expected 2 uploads in upload_finished event, got %v", delivery.Payload["uploads"])
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/runner"
+)
+
+// resetExecutorFlags restores the default executor so it doesn't leak between tests
+func resetExecutorFlags() {
+	resetFlags(runCmd, "executor", "image", "ssh-host", "ssh-dir", "cgroup-mode", "memory-limit", "cpu-limit")
+	executor = nil
+}
+
+// recordingExecutor reports success without running anything
+type recordingExecutor struct{ commands *[]string }
+
+func (r recordingExecutor) Name() string { return "test-recording" }
+
+func (r recordingExecutor) Run(config *runner.Config, verbose bool) (*runner.Execution, error) {
+	*r.commands = append(*r.commands, config.FullCommand())
+	return &runner.Execution{ExitCode: 0, ExecutionTime: 7}, nil
+}
+
+func TestRunCommandExecutor(t *testing.T) {
+	var commands []string
+	runner.RegisterExecutor("test-recording", func(runner.ExecutorOptions) (runner.Executor, error) {
+		return recordingExecutor{commands: &commands}, nil
+	})
+
+	tests := []struct {
+		name         string
+		flags        []string
+		wantRecorded bool
+		wantErr      string
+	}{
+		{name: "local", flags: []string{"--executor=local"}},
+		{name: "registered backend", flags: []string{"--executor=test-recording"}, wantRecorded: true},
+		{name: "unknown", flags: []string{"--executor=nope"}, wantErr: `unknown executor "nope"`},
+		{name: "docker without image", flags: []string{"--executor=docker"}, wantErr: "requires an image (--image)"},
+		{name: "image without docker", flags: []string{"--image=alpine"}, wantErr: "--image requires --executor docker"},
+		{name: "ssh without host", flags: []string{"--executor=ssh"}, wantErr: "requires a host (--ssh-host)"},
+		{name: "ssh dir without ssh", flags: []string{"--ssh-dir=/srv"}, wantErr: "--ssh-dir requires --executor ssh"},
+		{name: "cgroup mode without cgroup", flags: []string{"--cgroup-mode=systemd"}, wantErr: "--cgroup-mode requires --executor cgroup"},
+		{name: "invalid cgroup mode", flags: []string{"--executor=cgroup", "--cgroup-mode=v1"}, wantErr: `invalid cgroup mode "v1"`},
+		{name: "memory limit locally", flags: []string{"--memory-limit=512MiB"}, wantErr: "not enforced by the local executor"},
+		{name: "invalid memory limit", flags: []string{"--executor=docker", "--image=alpine", "--memory-limit=lots"}, wantErr: "invalid --memory-limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetExecutorFlags()
+			defer resetExecutorFlags()
+			commands = nil
+
+			dir := t.TempDir()
+			args := append([]string{"run", "-i", "/dev/null", "-o", filepath.Join(dir, "output.txt"),
+				"-e", filepath.Join(dir, "stderr.txt")}, tt.flags...)
+			rootCmd.SetArgs(append(args, "--", "echo", "backend"))
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result map[string]any
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if result["status"] != "success" || result["command"] != "echo backend" {
+				t.Errorf("Result = %v, want successful echo backend", result)
+			}
+			if recorded := len(commands) == 1; recorded != tt.wantRecorded {
+				t.Errorf("Commands run by the test executor = %v, want recorded: %v", commands, tt.wantRecorded)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func resetFDFlags() {
+	resetFlags(runCmd, "fd")
+	resetPriorityFlags()
+}
+
+func TestRunCommandFileDescriptors(t *testing.T) {
+	resetFDFlags()
+	defer resetFDFlags()
+
+	dir := t.TempDir()
+	cmdInput := filepath.Join(dir, "cmdinput.txt")
+	_ = os.WriteFile(cmdInput, []byte("move e4\n"), 0644)
+	trace := filepath.Join(dir, "trace.out")
+	rootCmd.SetArgs([]string{"run", "-i", "/dev/null", "-o", filepath.Join(dir, "output.txt"),
+		"-e", filepath.Join(dir, "stderr.txt"), "--fd", "3=" + cmdInput, "--fd", "4=" + trace + ":w",
+		"--", "sh", "-c", "read move <&3; echo \"got $move\" >&4"})
+
+	output, err := captureOutput(func() error {
+		return rootCmd.Execute()
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var result struct {
+		Status string `json:"status"`
+		FDs    []struct {
+			FD   int    `json:"fd"`
+			Path string `json:"path"`
+			Mode string `json:"mode"`
+		} `json:"fds"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+	}
+	if result.Status != "success" {
+		t.Errorf("Status = %s, want success", result.Status)
+	}
+	if len(result.FDs) != 2 || result.FDs[0].FD != 3 || result.FDs[0].Path != cmdInput || result.FDs[0].Mode != "r" ||
+		result.FDs[1].FD != 4 || result.FDs[1].Path != trace || result.FDs[1].Mode != "w" {
+		t.Errorf("fds = %+v, want fd 3 reading %s and fd 4 writing %s", result.FDs, cmdInput, trace)
+	}
+	if got, _ := os.ReadFile(trace); string(got) != "got move e4\n" {
+		t.Errorf("trace = %q, want the line read from fd 3", got)
+	}
+}
+
+func TestRunCommandFileDescriptorsInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   []string
+		wantErr string
+	}{
+		{name: "standard descriptor", flags: []string{"--fd", "1=out.txt:w"}, wantErr: "invalid --fd"},
+		{name: "mapped twice", flags: []string{"--fd", "3=a.txt", "--fd", "3=b.txt:w"}, wantErr: "file descriptor 3 is mapped more than once"},
+		{name: "remote executor", flags: []string{"--fd", "3=a.txt", "--executor", "docker", "--image", "alpine"}, wantErr: "--fd is not supported by the docker executor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFDFlags()
+			defer resetFDFlags()
+
+			dir := t.TempDir()
+			rootCmd.SetArgs(append(append([]string{"run", "-i", "/dev/null", "-o", filepath.Join(dir, "output.txt"),
+				"-e", filepath.Join(dir, "stderr.txt")}, tt.flags...), "--", "true"))
+
+			_, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/config"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/redact"
+	"github.com/zinc-sig/ghost/internal/retry"
+	"github.com/zinc-sig/ghost/internal/upload"
+)
+
+var (
+	fetchConfig  config.UploadConfig
+	fetchRemotes []string
+	fetchOutDir  string
+	fetchVerbose bool
+)
+
+var fetchCmd = &cobra.Command{
+	Use:   "fetch --provider <name> --remote <path> [--out <dir>]",
+	Short: "Download stored artifacts from an upload provider",
+	Long: `Download files uploaded by ghost run, such as student outputs, with the same
+provider configuration, so no separate storage client is needed.
+
+The provider is configured exactly as for uploads: --upload-config,
+--upload-config-kv, --upload-config-file and GHOST_UPLOAD_CONFIG_* environment
+variables, including the prefix.
+
+Each --remote is downloaded to the same path under --out, so files of different
+submissions don't collide. A remote path ending with a slash downloads every file
+under it. Files are written once complete; failed downloads are retried with
+exponential backoff, like uploads.
+
+The report is written as JSON; the command exits with code 74 if a file could
+not be downloaded.`,
+	Example: `  ghost fetch --provider minio --upload-config-file minio.json --remote hw1/alice/output.txt
+  ghost fetch --provider minio --remote hw1/ --out downloads/`,
+	RunE: fetchCommand,
+}
+
+func fetchCommand(cmd *cobra.Command, args []string) error {
+	retryConfig, err := helpers.ParseUploadRetryConfig(&fetchConfig)
+	if err != nil {
+		return failure.Wrap(failure.UploadConfigInvalid, err)
+	}
+	provider, _, err := helpers.SetupUploadProvider(&fetchConfig, false)
+	if err != nil {
+		return failure.Wrap(failure.UploadConfigInvalid, err)
+	}
+
+	ctx := helpers.CommandContext(cmd)
+	remotes, err := upload.ExpandRemotes(ctx, provider, fetchRemotes)
+	if err != nil {
+		return failure.Wrap(failure.DownloadFailed, err)
+	}
+
+	report := &output.FetchReport{Command: "fetch", Status: "success", Provider: provider.Name(), Files: []output.FetchedFile{}}
+	for _, remote := range remotes {
+		file := fetchFile(ctx, provider, remote, retryConfig)
+		if !file.Success {
+			report.Status = "failed"
+		}
+		report.Files = append(report.Files, file)
+	}
+
+	if err := helpers.PrintJSON(report); err != nil {
+		return err
+	}
+	if report.Status != "success" {
+		// The report already explains the failure
+		cmd.SilenceUsage = true
+		return failure.Wrap(failure.DownloadFailed, fmt.Errorf("failed to download some files"))
+	}
+	return nil
+}
+
+// fetchFile downloads a remote file under the output directory with retries
+func fetchFile(ctx context.Context, provider upload.Provider, remote string, retryConfig *retry.Config) output.FetchedFile {
+	file := output.FetchedFile{Remote: remote}
+	local, err := upload.LocalPath(fetchOutDir, remote)
+	if err != nil {
+		file.Error = err.Error()
+		return file
+	}
+	file.Local = local
+
+	start := time.Now()
+	file.Attempts, err = retry.Do(ctx, retryConfig, func() error {
+		size, err := upload.DownloadFile(ctx, provider, remote, local)
+		file.Size = size
+		return err
+	}, func(attempt int, delay time.Duration, err error) {
+		if fetchVerbose {
+			fmt.Fprintf(redact.Stderr, "[FETCH] Retry %d/%d for %s after %v: %v\n", attempt, retryConfig.MaxRetries, remote, delay, err)
+		}
+	})
+	file.Duration = time.Since(start).Milliseconds()
+
+	if err != nil {
+		file.Error = redact.Error(err)
+		return file
+	}
+	file.Success = true
+	if fetchVerbose {
+		fmt.Fprintf(redact.Stderr, "✓ Downloaded %s to %s\n", remote, local)
+	}
+	return file
+}
+
+func init() {
+	fetchCmd.Flags().StringVar(&fetchConfig.Provider, "provider", "", "Upload provider the files were stored with (e.g., minio) (required)")
+	fetchCmd.Flags().StringVar(&fetchConfig.Config, "upload-config", "", "Provider configuration as JSON string")
+	fetchCmd.Flags().StringArrayVar(&fetchConfig.ConfigKV, "upload-config-kv", nil, "Provider config key=value pairs; dotted keys build nested objects (can be used multiple times)")
+	fetchCmd.Flags().StringVar(&fetchConfig.ConfigFile, "upload-config-file", "", "Path to JSON file containing provider configuration")
+	fetchCmd.Flags().StringArrayVar(&fetchRemotes, "remote", nil, "Remote path to download; a trailing slash downloads every file under it (required, can be used multiple times)")
+	fetchCmd.Flags().StringVar(&fetchOutDir, "out", ".", "Directory to download into, keeping the remote paths")
+	fetchCmd.Flags().IntVar(&fetchConfig.Retries, "retries", helpers.DefaultUploadRetries, "Maximum download retry attempts per file (0 = no retries)")
+	fetchCmd.Flags().StringVar(&fetchConfig.RetryDelay, "retry-delay", helpers.DefaultUploadRetryDelay, "Initial delay between download retries")
+	fetchCmd.Flags().BoolVarP(&fetchVerbose, "verbose", "v", false, "Show download details on stderr")
+
+	_ = fetchCmd.MarkFlagRequired("provider")
+	_ = fetchCmd.MarkFlagRequired("remote")
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+// resetFetchFlags resets the fetch flags between tests
+func resetFetchFlags() {
+	resetAllFlags(fetchCmd)
+}
+
+func TestFetchCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		remotes     []string
+		failures    map[string]int
+		wantErr     failure.Code
+		wantFiles   map[string]string // local path under --out -> content
+		wantSuccess []bool
+	}{
+		{
+			name:        "single file",
+			remotes:     []string{"hw1/alice/output.txt"},
+			wantFiles:   map[string]string{"hw1/alice/output.txt": "alice out"},
+			wantSuccess: []bool{true},
+		},
+		{
+			name:    "directory",
+			remotes: []string{"hw1/"},
+			wantFiles: map[string]string{
+				"hw1/alice/output.txt": "alice out",
+				"hw1/alice/stderr.txt": "alice err",
+				"hw1/bob/output.txt":   "bob out",
+			},
+			wantSuccess: []bool{true, true, true},
+		},
+		{
+			name:        "transient failure is retried",
+			remotes:     []string{"hw1/bob/output.txt"},
+			failures:    map[string]int{"hw1/bob/output.txt": 1},
+			wantFiles:   map[string]string{"hw1/bob/output.txt": "bob out"},
+			wantSuccess: []bool{true},
+		},
+		{
+			name:        "missing file fails after the others",
+			remotes:     []string{"hw1/carol/output.txt", "hw1/bob/output.txt"},
+			wantErr:     failure.DownloadFailed,
+			wantFiles:   map[string]string{"hw1/bob/output.txt": "bob out"},
+			wantSuccess: []bool{false, true},
+		},
+		{
+			name:        "path escaping the output directory",
+			remotes:     []string{"../outside.txt"},
+			wantErr:     failure.DownloadFailed,
+			wantSuccess: []bool{false},
+		},
+		{
+			name:    "empty directory",
+			remotes: []string{"hw2/"},
+			wantErr: failure.DownloadFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFetchFlags()
+			testFlakyProvider.reset(tt.failures)
+			testFlakyProvider.uploads = map[string]string{
+				"hw1/alice/output.txt": "alice out",
+				"hw1/alice/stderr.txt": "alice err",
+				"hw1/bob/output.txt":   "bob out",
+				"hw10/dave/output.txt": "dave out",
+			}
+			outDir := t.TempDir()
+
+			args := []string{"fetch", "--provider", "test-flaky", "--retry-delay", "1ms", "--out", outDir}
+			for _, remote := range tt.remotes {
+				args = append(args, "--remote", remote)
+			}
+			rootCmd.SetArgs(args)
+			stdout, err := captureOutput(rootCmd.Execute)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || failure.CodeOf(err) != tt.wantErr) {
+				t.Fatalf("Execute() error = %v, want %s", err, tt.wantErr)
+			}
+
+			if tt.wantSuccess != nil {
+				var report output.FetchReport
+				if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+					t.Fatalf("invalid report: %v\n%s", err, stdout)
+				}
+				if len(report.Files) != len(tt.wantSuccess) {
+					t.Fatalf("report has %d files, want %d: %s", len(report.Files), len(tt.wantSuccess), stdout)
+				}
+				for i, file := range report.Files {
+					if file.Success != tt.wantSuccess[i] {
+						t.Errorf("file %s success = %v, want %v (%s)", file.Remote, file.Success, tt.wantSuccess[i], file.Error)
+					}
+				}
+			}
+
+			var found int
+			_ = filepath.WalkDir(outDir, func(path string, d os.DirEntry, err error) error {
+				if err == nil && !d.IsDir() {
+					found++
+				}
+				return nil
+			})
+			if found != len(tt.wantFiles) {
+				t.Errorf("downloaded %d files, want %d", found, len(tt.wantFiles))
+			}
+			for name, want := range tt.wantFiles {
+				got, err := os.ReadFile(filepath.Join(outDir, name))
+				if err != nil {
+					t.Errorf("missing download %s: %v", name, err)
+				} else if string(got) != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
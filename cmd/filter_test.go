@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func resetFilterFlags() {
+	for _, c := range []*cobra.Command{runCmd, pipelineCmd} {
+		resetFlags(c, "stdout-filter", "stderr-filter")
+	}
+}
+
+func TestRunCommandOutputFilters(t *testing.T) {
+	tests := []struct {
+		name       string
+		command    string
+		filters    []string
+		wantOutput string
+		wantErr    string
+	}{
+		{
+			name:       "run",
+			command:    "run",
+			filters:    []string{"--stdout-filter", "drop:^DEBUG", "--stdout-filter", "redact:[0-9]+"},
+			wantOutput: "answer ***REDACTED***\n",
+		},
+		{
+			name:       "pipeline",
+			command:    "pipeline",
+			filters:    []string{"--stdout-filter", "strip:^answer "},
+			wantOutput: "DEBUG 1\n42\n",
+		},
+		{
+			name:    "invalid stdout filter",
+			command: "run",
+			filters: []string{"--stdout-filter", "drop:("},
+			wantErr: "invalid --stdout-filter",
+		},
+		{
+			name:    "invalid stderr filter",
+			command: "run",
+			filters: []string{"--stderr-filter", "replace:x"},
+			wantErr: "invalid --stderr-filter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetFilterFlags()
+			defer resetFilterFlags()
+
+			dir := t.TempDir()
+			outputPath := filepath.Join(dir, "output.txt")
+			args := append([]string{tt.command, "-i", "/dev/null", "-o", outputPath,
+				"-e", filepath.Join(dir, "stderr.txt")}, tt.filters...)
+			rootCmd.SetArgs(append(args, "--", "printf", `DEBUG 1\nanswer 42\n`))
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result struct {
+				Status string `json:"status"`
+			}
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if result.Status != "success" {
+				t.Errorf("Status = %s, want success", result.Status)
+			}
+			data, _ := os.ReadFile(outputPath)
+			if string(data) != tt.wantOutput {
+				t.Errorf("Output file = %q, want %q", data, tt.wantOutput)
+			}
+		})
+	}
+}
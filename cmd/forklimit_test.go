@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/failure"
+)
+
+func resetForkLimitFlags() {
+	resetFlags(runCmd, "max-forks")
+}
+
+func TestRunCommandMaxForks(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    string
+		wantErr string
+	}{
+		{name: "limit applied", flag: "--max-forks=50"},
+		{name: "negative", flag: "--max-forks=-2", wantErr: "invalid max forks -2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetForkLimitFlags()
+			defer resetForkLimitFlags()
+
+			dir := t.TempDir()
+			rootCmd.SetArgs([]string{"run", "-i", "/dev/null", "-o", filepath.Join(dir, "output.txt"),
+				"-e", filepath.Join(dir, "stderr.txt"), tt.flag, "--", "echo", "forked"})
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result map[string]any
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if result["status"] != "success" || result["command"] != "echo forked" {
+				t.Errorf("Result = %v, want successful echo forked", result)
+			}
+			if _, ok := result["resource_exceeded"]; ok {
+				t.Errorf("Unexpected resource_exceeded in %v", result)
+			}
+		})
+	}
+}
+
+func resetPriorityFlags() {
+	resetFlags(runCmd, "nice", "ionice", "executor", "image")
+}
+
+func TestRunCommandPriority(t *testing.T) {
+	tests := []struct {
+		name       string
+		flags      []string
+		wantOutput string
+		wantErr    string
+	}{
+		{name: "nice", flags: []string{"--nice", "5"}, wantOutput: "5\n"},
+		{name: "out of range", flags: []string{"--nice", "40"}, wantErr: "invalid nice value 40"},
+		{name: "invalid class", flags: []string{"--ionice", "background"}, wantErr: `invalid I/O class "background"`},
+		{name: "docker", flags: []string{"--nice", "5", "--executor", "docker", "--image", "alpine"}, wantErr: "not supported by the docker executor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetPriorityFlags()
+			defer resetPriorityFlags()
+
+			dir := t.TempDir()
+			outputFile := filepath.Join(dir, "output.txt")
+			args := append([]string{"run", "-i", "/dev/null", "-o", outputFile, "-e", filepath.Join(dir, "stderr.txt")}, tt.flags...)
+			rootCmd.SetArgs(append(args, "--", "nice"))
+
+			_, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || failure.CodeOf(err) != failure.Usage || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want %s containing %q", err, failure.Usage, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if data, _ := os.ReadFile(outputFile); string(data) != tt.wantOutput {
+				t.Errorf("Output = %q, want %q", data, tt.wantOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/zinc-sig/ghost/internal/cache"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/redact"
+	"github.com/zinc-sig/ghost/internal/runner"
+)
+
+// Names of the artifacts stored with a cached grade
+const (
+	cacheArtifactOutput = "output"
+	cacheArtifactStderr = "stderr"
+)
+
+// cacheNeutralFlags are flags that don't change the grade, so they are left out of
+// the cache key; the input is keyed by its content rather than its path.
+var cacheNeutralFlags = map[string]bool{
+	"input": true, "output": true, "stderr": true,
+	"run-id": true, "attempt": true, "verbose": true, "dry-run": true, "human": true,
+	"result-file": true, "result-schema": true, "record-env": true,
+	"propagate-exit-code": true, "tee-output": true, "otel-endpoint": true,
+	"cache-dir": true, "cache-key-file": true, "help": true,
+	// The values they supply are keyed as those of the flags they set
+	"config": true, "profile": true,
+}
+
+// cacheNeutralPrefixes are prefixes of further flags that don't change the grade
+var cacheNeutralPrefixes = []string{"upload-", "webhook-", "embed-"}
+
+// cacheContentFlags name files whose content, not just their path, is part of the grade
+var cacheContentFlags = []string{"command-file", "context-file", "interact-script", "policy-file", "rubric"}
+
+// GradeCacheKey computes the cache key of a grade from the command line and the
+// content of the input, the executable, any arguments naming regular files and
+// keyFiles, together with every flag that may change the grade
+func GradeCacheKey(cmd *cobra.Command, argv, env []string, input string, keyFiles []string) (string, error) {
+	key := cache.NewKey()
+	key.Add("argv", argv...)
+	key.Add("env", env...)
+	if err := key.AddFile("input", input); err != nil {
+		return "", err
+	}
+	if path, err := exec.LookPath(argv[0]); err == nil {
+		if err := key.AddFile("executable", path); err != nil {
+			return "", err
+		}
+	}
+	for _, arg := range argv[1:] {
+		if info, err := os.Stat(arg); err == nil && info.Mode().IsRegular() {
+			if err := key.AddFile("arg", arg); err != nil {
+				return "", err
+			}
+		}
+	}
+	for _, file := range keyFiles {
+		if err := key.AddFile("key-file", file); err != nil {
+			return "", err
+		}
+	}
+
+	var keyErr error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if keyErr != nil || cacheNeutralFlags[f.Name] || hasAnyPrefix(f.Name, cacheNeutralPrefixes) {
+			return
+		}
+		key.Add("flag", f.Name, f.Value.String())
+		for _, name := range cacheContentFlags {
+			if f.Name == name && f.Value.String() != "" {
+				keyErr = key.AddFile(name, f.Value.String())
+			}
+		}
+	})
+	if keyErr != nil {
+		return "", keyErr
+	}
+
+	// The grading command is keyed by its program too
+	if score := cmd.Flags().Lookup("score-command"); score != nil {
+		if fields := strings.Fields(score.Value.String()); len(fields) > 0 {
+			if path, err := exec.LookPath(fields[0]); err == nil {
+				if err := key.AddFile("score-command", path); err != nil {
+					return "", err
+				}
+			}
+		}
+	}
+	return key.Sum(), nil
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// gradeArtifacts maps the artifact names of a grade to its capture files
+// Stream targets cannot be read back or replayed and are not cached.
+func gradeArtifacts(outputFile, stderrFile string) map[string]string {
+	artifacts := map[string]string{}
+	if !runner.IsStreamTarget(outputFile) {
+		artifacts[cacheArtifactOutput] = outputFile
+	}
+	if !runner.IsStreamTarget(stderrFile) {
+		artifacts[cacheArtifactStderr] = stderrFile
+	}
+	return artifacts
+}
+
+// LookupGrade returns the cache key of the command's grade, and the grade cached
+// under it with its output and stderr restored, or nil if there is none
+// The result describes this invocation's files and is marked cached.
+// A broken cache only costs a fresh run, so errors are reported and treated as misses;
+// the key is empty if it could not be computed.
+func LookupGrade(cmd *cobra.Command, dir string, keyFiles []string, config *runner.Config, verbose bool) (string, *output.Result) {
+	argv := append([]string{config.Command}, config.Args...)
+	// Files the command reads through further descriptors are keyed like the input
+	for _, fd := range config.Descriptors {
+		if info, err := os.Stat(fd.Path); err == nil && fd.Mode == runner.FDRead && info.Mode().IsRegular() {
+			keyFiles = append(slices.Clip(keyFiles), fd.Path)
+		}
+	}
+	key, err := GradeCacheKey(cmd, argv, config.Env, config.InputFile, keyFiles)
+	if err != nil {
+		fmt.Fprintf(redact.Stderr, "[CACHE] Not caching: %v\n", err)
+		return "", nil
+	}
+
+	result, err := cache.Load(dir, key, gradeArtifacts(config.OutputFile, config.StderrFile))
+	if err != nil {
+		fmt.Fprintf(redact.Stderr, "[CACHE] Ignoring cached grade %s: %v\n", key, err)
+		return key, nil
+	}
+	if result == nil {
+		if verbose {
+			fmt.Fprintf(redact.Stderr, "[CACHE] Miss %s\n", key)
+		}
+		return key, nil
+	}
+	if verbose {
+		fmt.Fprintf(redact.Stderr, "[CACHE] Hit %s\n", key)
+	}
+
+	result.SchemaVersion = output.SchemaVersion
+	result.Input = config.InputFile
+	result.Output = config.OutputFile
+	result.Stderr = config.StderrFile
+	result.Cached = true
+	return key, result
+}
+
+// StoreGrade caches a grade with its output and stderr under key
+// Only what the key determines is stored: the run ID, attempt, context,
+// environment, previews, uploads and webhook status belong to the invocation.
+func StoreGrade(dir, key string, result *output.Result, outputFile, stderrFile string, verbose bool) {
+	grade := *result
+	grade.RunID = ""
+	grade.Attempt = 0
+	grade.Context = nil
+	grade.Environment = nil
+	grade.OutputPreview, grade.OutputTruncated = "", false
+	grade.StderrPreview, grade.StderrTruncated = "", false
+	grade.Uploads = nil
+	grade.WebhookSent, grade.WebhookError, grade.WebhookResponse, grade.WebhookSpooled = false, "", nil, false
+	grade.Event, grade.WebhookAttempts = "", nil
+
+	if err := cache.Store(dir, key, &grade, gradeArtifacts(outputFile, stderrFile)); err != nil {
+		fmt.Fprintf(redact.Stderr, "[CACHE] Failed to cache grade %s: %v\n", key, err)
+		return
+	}
+	if verbose {
+		fmt.Fprintf(redact.Stderr, "[CACHE] Stored %s\n", key)
+	}
+}
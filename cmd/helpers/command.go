@@ -1,11 +1,18 @@
 package helpers
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/config"
+	"github.com/zinc-sig/ghost/internal/commandspec"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/linefilter"
+	"github.com/zinc-sig/ghost/internal/runner"
 )
 
 // IOFlags holds the common I/O flags for commands
@@ -33,6 +40,15 @@ func ValidateIOFlags(flags IOFlags, requireExpected bool) error {
 	return nil
 }
 
+// CheckInputFile reports a missing input file before anything is executed
+// Other errors are left to the runner, which reports them when opening the file.
+func CheckInputFile(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return failure.Wrap(failure.InputNotFound, fmt.Errorf("input file %s does not exist", path))
+	}
+	return nil
+}
+
 // CreateTempFiles creates temporary files for output and stderr when upload is configured
 // Returns the actual file paths and a cleanup function
 func CreateTempFiles(prefix string) (outputFile, stderrFile string, cleanup func(), err error) {
@@ -76,6 +92,32 @@ func ValidateCommandSeparator(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// ResolveCommand returns the command line to run: the arguments after '--', or
+// the command of --command-file ("-" reads the spec from stdin)
+func ResolveCommand(cmd *cobra.Command, commandFile string, args []string) ([]string, *commandspec.Spec, error) {
+	if commandFile == "" {
+		if err := ValidateCommandSeparator(cmd, args); err != nil {
+			return nil, nil, failure.Wrap(failure.Usage, err)
+		}
+		return args, nil, nil
+	}
+	if len(args) > 0 {
+		return nil, nil, failure.Wrap(failure.Usage, fmt.Errorf("--command-file cannot be used with a command after '--'"))
+	}
+
+	var spec *commandspec.Spec
+	var err error
+	if commandFile == "-" {
+		spec, err = commandspec.Read(cmd.InOrStdin(), "<stdin>")
+	} else {
+		spec, err = commandspec.Load(commandFile)
+	}
+	if err != nil {
+		return nil, nil, failure.Wrap(failure.ConfigInvalid, err)
+	}
+	return spec.Argv(), spec, nil
+}
+
 // ParseTimeout parses and validates a timeout duration string
 func ParseTimeout(timeoutStr string) (time.Duration, error) {
 	if timeoutStr == "" {
@@ -93,3 +135,81 @@ func ParseTimeout(timeoutStr string) (time.Duration, error) {
 
 	return timeout, nil
 }
+
+// ParseSoftTimeout parses a soft timeout, which must be shorter than the timeout
+func ParseSoftTimeout(softTimeoutStr string, timeout time.Duration) (time.Duration, error) {
+	if softTimeoutStr == "" {
+		return 0, nil
+	}
+
+	softTimeout, err := time.ParseDuration(softTimeoutStr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid soft timeout duration: %w", err)
+	}
+
+	switch {
+	case softTimeout <= 0:
+		return 0, fmt.Errorf("soft timeout must be positive")
+	case timeout == 0:
+		return 0, fmt.Errorf("--soft-timeout requires --timeout, which kills the command if it ignores SIGTERM")
+	case softTimeout >= timeout:
+		return 0, fmt.Errorf("soft timeout %s must be shorter than the timeout %s", softTimeout, timeout)
+	}
+	return softTimeout, nil
+}
+
+// ExpectedExitCode returns the expected exit code if --expect-exit-code was set
+func ExpectedExitCode(flags *config.CommonFlags) *int {
+	if !flags.ExpectExitCodeSet {
+		return nil
+	}
+	code := flags.ExpectExitCode
+	return &code
+}
+
+// ValidateExpectExitCode checks --expect-exit-code is an exit status a command can return
+func ValidateExpectExitCode(flags *config.CommonFlags) error {
+	if flags.ExpectExitCodeSet && (flags.ExpectExitCode < 0 || flags.ExpectExitCode > 255) {
+		return fmt.Errorf("invalid --expect-exit-code %d: must be between 0 and 255", flags.ExpectExitCode)
+	}
+	return nil
+}
+
+// CommandContext returns the command's context, or a background context if none is set
+func CommandContext(cmd *cobra.Command) context.Context {
+	if ctx := cmd.Context(); ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+// ParseOutputFilters parses the --stdout-filter and --stderr-filter rules
+func ParseOutputFilters(cfg *config.FilterConfig) (stdout, stderr []linefilter.Rule, err error) {
+	stdout, err = linefilter.ParseAll(cfg.Stdout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid --stdout-filter: %w", err)
+	}
+	stderr, err = linefilter.ParseAll(cfg.Stderr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid --stderr-filter: %w", err)
+	}
+	return stdout, stderr, nil
+}
+
+// ValidateCaptureConfig checks the --capture-combined file against the output
+// and stderr files, and that the capture mode can be cached
+func ValidateCaptureConfig(cfg *config.CaptureConfig, outputFile, stderrFile, cacheDir string) error {
+	if cfg.Combined != "" && cfg.Combined != runner.StreamPath {
+		combined := filepath.Clean(cfg.Combined)
+		for _, path := range []string{outputFile, stderrFile} {
+			if path != runner.StreamPath && filepath.Clean(path) == combined {
+				return fmt.Errorf("--capture-combined must differ from the output and stderr files, got %s", cfg.Combined)
+			}
+		}
+	}
+	// A cached grade restores the output and stderr files as they were stored
+	if cacheDir != "" && (cfg.Append || cfg.Combined != "") {
+		return fmt.Errorf("--append and --capture-combined cannot be used with --cache-dir")
+	}
+	return nil
+}
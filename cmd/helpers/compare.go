@@ -0,0 +1,261 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/zinc-sig/ghost/internal/compare"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/runner"
+)
+
+// ExpectedSource is where diff's expected content comes from
+// Exactly one of File, String (when StringSet) and Stdin is used.
+type ExpectedSource struct {
+	File      string
+	String    string
+	StringSet bool
+	Stdin     bool
+}
+
+// ResolveExpected returns the expected file to compare against and the path
+// reported in the result
+// Inline content from --expected-string or --expected-stdin is written to a temp
+// file that cleanup removes. A trailing newline is added to --expected-string so
+// a short answer such as "42" matches a program printing "42\n". Stdin is
+// reported as "-" and inline strings as "".
+func ResolveExpected(source ExpectedSource, stdin io.Reader) (path, reported string, cleanup func(), err error) {
+	sources := 0
+	for _, set := range []bool{source.File != "", source.StringSet, source.Stdin} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return "", "", nil, fmt.Errorf("only one of --expected, --expected-string and --expected-stdin may be used")
+	}
+
+	var content io.Reader
+	switch {
+	case source.StringSet:
+		value := source.String
+		if !strings.HasSuffix(value, "\n") {
+			value += "\n"
+		}
+		content = strings.NewReader(value)
+	case source.Stdin:
+		content = stdin
+		reported = runner.StreamPath
+	default:
+		return source.File, source.File, func() {}, nil
+	}
+
+	file, err := os.CreateTemp("", "ghost-diff-expected-*.txt")
+	if err != nil {
+		return "", "", nil, fmt.Errorf("failed to create temp expected file: %w", err)
+	}
+	cleanup = func() { _ = os.Remove(file.Name()) }
+	if _, err := io.Copy(file, content); err != nil {
+		_ = file.Close()
+		cleanup()
+		return "", "", nil, fmt.Errorf("failed to write expected content: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		cleanup()
+		return "", "", nil, fmt.Errorf("failed to write expected content: %w", err)
+	}
+	return file.Name(), reported, cleanup, nil
+}
+
+// ExpectedCandidates lists the acceptable expected files of a diff
+// The --expected files come first, followed by the regular files directly inside
+// the --expected-any directory in name order.
+func ExpectedCandidates(files []string, anyDir string) ([]string, error) {
+	var candidates []string
+	for _, file := range files {
+		if file != "" {
+			candidates = append(candidates, file)
+		}
+	}
+	if anyDir == "" {
+		return candidates, nil
+	}
+
+	entries, err := os.ReadDir(anyDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --expected-any directory: %w", err)
+	}
+	found := false
+	for _, entry := range entries {
+		// Stat follows symlinks to files
+		path := filepath.Join(anyDir, entry.Name())
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			candidates = append(candidates, path)
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no expected files in --expected-any directory %s", anyDir)
+	}
+	return candidates, nil
+}
+
+// MatchExpected returns the index of the first candidate the input is equal to, or -1 if none is
+func MatchExpected(ctx context.Context, input string, candidates []string, equal compare.EqualFunc) (int, error) {
+	for i, candidate := range candidates {
+		same, err := equal(ctx, input, candidate)
+		if err != nil {
+			return -1, fmt.Errorf("failed to compare with %s: %w", candidate, err)
+		}
+		if same {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+// NormalizeForComparison writes normalised copies of the given files or directories
+// to a temp directory and returns their paths in the same order
+// Copies keep their base names so diff output stays readable; cleanup removes them.
+func NormalizeForComparison(n compare.Normalization, dirMode bool, paths []string) (copies []string, cleanup func(), err error) {
+	tempDir, err := os.MkdirTemp("", "ghost-diff-normalized-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	cleanup = func() { _ = os.RemoveAll(tempDir) }
+
+	for i, path := range paths {
+		// A numbered parent keeps copies of files with the same name apart
+		parent := filepath.Join(tempDir, strconv.Itoa(i))
+		target := filepath.Join(parent, filepath.Base(path))
+		if err := os.MkdirAll(parent, 0700); err != nil {
+			cleanup()
+			return nil, nil, fmt.Errorf("failed to create temp directory: %w", err)
+		}
+		if dirMode {
+			err = n.CopyTree(path, target)
+		} else {
+			err = n.CopyFile(path, target)
+		}
+		if err != nil {
+			cleanup()
+			return nil, nil, err
+		}
+		copies = append(copies, target)
+	}
+	return copies, cleanup, nil
+}
+
+// IsDirectoryComparison reports whether both diff operands are directories
+// Returns an error if only one of them is a directory. Paths that cannot be
+// accessed are treated as files so diff reports the error as before.
+func IsDirectoryComparison(input, expected string) (bool, error) {
+	inputIsDir := isDir(input)
+	expectedIsDir := isDir(expected)
+
+	if inputIsDir != expectedIsDir {
+		return false, fmt.Errorf("cannot compare a directory with a file: both --input and --expected must be directories")
+	}
+	return inputIsDir, nil
+}
+
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// ApplyDirectoryComparison compares the directories file by file and records the per-file status
+// When a score is set it is awarded in proportion to the number of matching files,
+// rounded to two decimal places.
+func ApplyDirectoryComparison(ctx context.Context, result *output.Result, inputDir, expectedDir string, equal compare.EqualFunc, scoreSet bool, scoreStr string) error {
+	dirResult, err := compare.Dirs(ctx, inputDir, expectedDir, equal)
+	if err != nil {
+		return err
+	}
+
+	result.Files = make([]output.FileResult, 0, dirResult.Total())
+	for _, file := range dirResult.Files {
+		result.Files = append(result.Files, output.FileResult{Path: file.Path, Status: file.Status})
+	}
+
+	if scoreSet {
+		applyProportionalScore(result, scoreStr, dirResult.Matched, dirResult.Total())
+	}
+	return nil
+}
+
+// ApplyPatternResults records the outcome of each pattern of ghost check
+// When a score is set it is awarded in proportion to the number of passed
+// patterns, rounded to two decimal places.
+func ApplyPatternResults(result *output.Result, patterns []compare.PatternResult, scoreSet bool, scoreStr string) {
+	result.Patterns = make([]output.PatternResult, 0, len(patterns))
+	passed := 0
+	for _, pattern := range patterns {
+		kind := "required"
+		if pattern.Forbidden {
+			kind = "forbidden"
+		}
+		result.Patterns = append(result.Patterns, output.PatternResult{
+			Pattern: pattern.Pattern,
+			Kind:    kind,
+			Passed:  pattern.Passed,
+			Line:    pattern.Line,
+		})
+		if pattern.Passed {
+			passed++
+		}
+	}
+	if scoreSet {
+		applyProportionalScore(result, scoreStr, passed, len(patterns))
+	}
+}
+
+// applyProportionalScore awards the share passed/total of the score
+func applyProportionalScore(result *output.Result, scoreStr string, passed, total int) {
+	score, err := decimal.NewFromString(scoreStr)
+	if err != nil {
+		// Invalid scores are omitted, matching CreateJSONResult
+		return
+	}
+
+	// Nothing compared keeps the binary score from the status
+	if total > 0 {
+		scaled := score.Mul(decimal.NewFromInt(int64(passed))).
+			DivRound(decimal.NewFromInt(int64(total)), 2)
+		result.Score = &scaled
+	}
+}
+
+// CompareBuiltin returns a runner builtin that compares input and expected in-process
+// Exit codes follow diff: 0 if equal, 1 if different, 2 on error.
+func CompareBuiltin(comparator compare.Comparator, input, expected string) func(ctx context.Context, stdout, stderr io.Writer) int {
+	return func(ctx context.Context, stdout, stderr io.Writer) int {
+		equal, err := comparator.Compare(ctx, stdout, input, expected)
+		switch {
+		case err != nil:
+			fmt.Fprintf(stderr, "diff: %v\n", err)
+			return 2
+		case equal:
+			return 0
+		default:
+			return 1
+		}
+	}
+}
+
+// countMatchedFiles returns the number of matching files in a directory comparison
+func countMatchedFiles(files []output.FileResult) int {
+	matched := 0
+	for _, file := range files {
+		if file.Status == compare.StatusMatch {
+			matched++
+		}
+	}
+	return matched
+}
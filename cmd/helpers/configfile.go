@@ -0,0 +1,372 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/zinc-sig/ghost/internal/validate"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnvVar names the environment variable that overrides the default config file path
+const ConfigFileEnvVar = "GHOST_CONFIG"
+
+// DefaultConfigFileName is the config file looked up in the home directory
+const DefaultConfigFileName = ".ghost.yaml"
+
+// ProfileEnvVar names the environment variable that selects a profile when --profile is not given
+const ProfileEnvVar = "GHOST_PROFILE"
+
+// profilesKey is the config file section holding named profiles
+const profilesKey = "profiles"
+
+// ResolveProfile returns the profile to apply: --profile, else GHOST_PROFILE (empty = none)
+func ResolveProfile(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	return os.Getenv(ProfileEnvVar)
+}
+
+// ResolveConfigFile returns the config file to load and whether it must exist
+// Precedence: --config flag > GHOST_CONFIG > ~/.ghost.yaml (optional).
+func ResolveConfigFile(flagValue string) (string, bool) {
+	if flagValue != "" {
+		return flagValue, true
+	}
+	if path := os.Getenv(ConfigFileEnvVar); path != "" {
+		return path, true
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", false
+	}
+	return filepath.Join(home, DefaultConfigFileName), false
+}
+
+// LoadConfigFile reads a YAML config file
+// A missing file is not an error unless required is set; an empty map is returned instead.
+func LoadConfigFile(path string, required bool) (map[string]any, error) {
+	if path == "" {
+		return map[string]any{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !required && errors.Is(err, os.ErrNotExist) {
+			return map[string]any{}, nil
+		}
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	settings := map[string]any{}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return settings, nil
+}
+
+// ApplyConfigFile sets flag defaults for cmd from config file settings
+//
+// Keys are flag names; nested maps are joined with '-' so `webhook: {url: ...}` sets
+// --webhook-url. A section named after the command (e.g. `run:`) overrides top-level
+// values for that command only; command groups such as `ghost webhook` have no section.
+// A named profile under `profiles:` has the same layout and overrides both when selected.
+// Flags given on the command line are never overridden, and neither are flags whose
+// GHOST_<FLAG_NAME> environment variable is set (such as GHOST_WEBHOOK_URL). Keys
+// that are flags of other commands are ignored.
+func ApplyConfigFile(cmd *cobra.Command, settings map[string]any, path, profile string) error {
+	commandNames := map[string]bool{}
+	knownFlags := map[string]bool{}
+	var visit func(c *cobra.Command)
+	visit = func(c *cobra.Command) {
+		for _, sub := range c.Commands() {
+			// Command groups such as `webhook` share their name with a flag prefix
+			if sub.Runnable() {
+				commandNames[sub.Name()] = true
+			}
+			sub.Flags().VisitAll(func(f *pflag.Flag) { knownFlags[f.Name] = true })
+			visit(sub)
+		}
+	}
+	visit(cmd.Root())
+
+	profiles, err := configProfiles(settings)
+	if err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	values := map[string]any{}
+	if err := collectConfigValues(settings, cmd.Name(), commandNames, knownFlags, values); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if profile != "" {
+		profileSettings, ok := profiles[profile]
+		if !ok {
+			return fmt.Errorf("unknown profile %q in config file %s%s", profile, path, definedProfiles(profiles))
+		}
+		if err := collectConfigValues(profileSettings, cmd.Name(), commandNames, knownFlags, values); err != nil {
+			return fmt.Errorf("invalid config file %s: profile %q: %w", path, profile, err)
+		}
+	}
+
+	// Apply in a stable order so errors are deterministic
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || flag.Changed || os.Getenv(flagEnvVar(name)) != "" {
+			continue
+		}
+		if err := setFlagFromConfig(cmd, flag, values[name]); err != nil {
+			return fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// collectConfigValues adds the top-level values of settings and those of the
+// section of the named command, which take precedence, to values
+func collectConfigValues(settings map[string]any, command string, commandNames, knownFlags map[string]bool, values map[string]any) error {
+	for key, value := range settings {
+		// Command sections are maps; a scalar with a command's name (e.g. `score: 10`) is a flag
+		if _, isMap := value.(map[string]any); (isMap && commandNames[key]) || key == profilesKey {
+			continue
+		}
+		if err := flattenConfig(key, value, knownFlags, values); err != nil {
+			return err
+		}
+	}
+	if section, ok := settings[command]; ok {
+		sectionMap, ok := section.(map[string]any)
+		if !ok {
+			return fmt.Errorf("section %q must be a map", command)
+		}
+		for key, value := range sectionMap {
+			if err := flattenConfig(key, value, knownFlags, values); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// configProfiles returns the profiles of a config file by name
+func configProfiles(settings map[string]any) (map[string]map[string]any, error) {
+	profiles := map[string]map[string]any{}
+	section, ok := settings[profilesKey]
+	if !ok || section == nil {
+		return profiles, nil
+	}
+	sectionMap, ok := section.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("section %q must be a map of profile names to settings", profilesKey)
+	}
+	for name, value := range sectionMap {
+		profile, ok := value.(map[string]any)
+		if !ok && value != nil {
+			return nil, fmt.Errorf("profile %q must be a map", name)
+		}
+		profiles[name] = profile
+	}
+	return profiles, nil
+}
+
+// definedProfiles lists the profile names for an unknown profile error
+func definedProfiles(profiles map[string]map[string]any) string {
+	if len(profiles) == 0 {
+		return " (no profiles defined)"
+	}
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return " (defined: " + strings.Join(names, ", ") + ")"
+}
+
+// flattenConfig maps nested config keys onto flag names
+func flattenConfig(key string, value any, knownFlags map[string]bool, values map[string]any) error {
+	if knownFlags[key] {
+		values[key] = value
+		return nil
+	}
+	nested, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("unknown key %q", key)
+	}
+	for k, v := range nested {
+		if err := flattenConfig(key+"-"+k, v, knownFlags, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setFlagFromConfig sets a flag from a YAML value
+// Lists are applied element by element to repeatable flags and maps are encoded as
+// JSON for flags that accept JSON (e.g. --context, --upload-config).
+func setFlagFromConfig(cmd *cobra.Command, flag *pflag.Flag, value any) error {
+	switch v := value.(type) {
+	case []any:
+		if !strings.HasSuffix(flag.Value.Type(), "Slice") && !strings.HasSuffix(flag.Value.Type(), "Array") {
+			return fmt.Errorf("key %q does not accept a list", flag.Name)
+		}
+		for _, item := range v {
+			if err := cmd.Flags().Set(flag.Name, fmt.Sprint(item)); err != nil {
+				return fmt.Errorf("invalid value for %q: %w", flag.Name, err)
+			}
+		}
+		return nil
+	case map[string]any:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("invalid value for %q: %w", flag.Name, err)
+		}
+		value = string(encoded)
+	case nil:
+		return nil
+	}
+
+	if err := cmd.Flags().Set(flag.Name, fmt.Sprint(value)); err != nil {
+		return fmt.Errorf("invalid value for %q: %w", flag.Name, err)
+	}
+	return nil
+}
+
+// flagEnvVar returns the environment variable name that takes precedence over a flag's config value
+func flagEnvVar(name string) string {
+	return "GHOST_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// ValidateConfigFile checks a config file against the flags of every command
+// without applying it, returning every unknown key and malformed value found.
+func ValidateConfigFile(root *cobra.Command, path string) []validate.Problem {
+	node, problems := validate.ReadYAMLFile(path)
+	if node == nil {
+		return problems
+	}
+	if node.Kind != yaml.MappingNode {
+		return []validate.Problem{validate.At(node, "config file must be a map of flag names to values")}
+	}
+
+	commandNames := map[string]bool{}
+	flags := map[string]*pflag.Flag{}
+	var visit func(c *cobra.Command)
+	visit = func(c *cobra.Command) {
+		for _, sub := range c.Commands() {
+			// Command groups such as `webhook` share their name with a flag prefix
+			if sub.Runnable() {
+				commandNames[sub.Name()] = true
+			}
+			sub.Flags().VisitAll(func(f *pflag.Flag) {
+				if _, ok := flags[f.Name]; !ok {
+					flags[f.Name] = f
+				}
+			})
+			visit(sub)
+		}
+	}
+	visit(root)
+
+	problems = append(problems, validateConfigMapping(node, commandNames, flags)...)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value != profilesKey || value.Tag == "!!null" {
+			continue
+		}
+		if value.Kind != yaml.MappingNode {
+			problems = append(problems, validate.At(value, "section %q must be a map of profile names to settings", profilesKey))
+			continue
+		}
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			profile := value.Content[j+1]
+			switch {
+			case profile.Kind == yaml.MappingNode:
+				problems = append(problems, validateConfigMapping(profile, commandNames, flags)...)
+			case profile.Tag != "!!null":
+				problems = append(problems, validate.At(profile, "profile %q must be a map", value.Content[j].Value))
+			}
+		}
+	}
+	return problems
+}
+
+// validateConfigMapping checks the top-level keys and command sections of a config
+// file or profile; profiles themselves are checked by the caller
+func validateConfigMapping(node *yaml.Node, commandNames map[string]bool, flags map[string]*pflag.Flag) []validate.Problem {
+	var problems []validate.Problem
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value == profilesKey {
+			continue
+		}
+		if value.Kind == yaml.MappingNode && commandNames[key.Value] {
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				problems = append(problems, validateConfigEntry(value.Content[j].Value, value.Content[j], value.Content[j+1], flags)...)
+			}
+			continue
+		}
+		problems = append(problems, validateConfigEntry(key.Value, key, value, flags)...)
+	}
+	return problems
+}
+
+// validateConfigEntry mirrors flattenConfig and setFlagFromConfig for a single key
+func validateConfigEntry(name string, key, value *yaml.Node, flags map[string]*pflag.Flag) []validate.Problem {
+	flag, ok := flags[name]
+	if !ok {
+		if value.Kind != yaml.MappingNode {
+			return []validate.Problem{validate.At(key, "unknown key %q", name)}
+		}
+		var problems []validate.Problem
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			problems = append(problems, validateConfigEntry(name+"-"+value.Content[i].Value, value.Content[i], value.Content[i+1], flags)...)
+		}
+		return problems
+	}
+
+	flagType := flag.Value.Type()
+	switch value.Kind {
+	case yaml.SequenceNode:
+		if !strings.HasSuffix(flagType, "Slice") && !strings.HasSuffix(flagType, "Array") {
+			return []validate.Problem{validate.At(value, "key %q does not accept a list", name)}
+		}
+		return nil
+	case yaml.MappingNode:
+		if flagType != "string" {
+			return []validate.Problem{validate.At(value, "key %q does not accept a map", name)}
+		}
+		return nil
+	}
+
+	if value.Tag == "!!null" {
+		return nil
+	}
+	var err error
+	switch flagType {
+	case "bool":
+		_, err = strconv.ParseBool(value.Value)
+	case "int":
+		_, err = strconv.Atoi(value.Value)
+	case "float64":
+		_, err = strconv.ParseFloat(value.Value, 64)
+	}
+	if err != nil {
+		return []validate.Problem{validate.At(value, "invalid value for %q: expected %s, got %q", name, flagType, value.Value)}
+	}
+	return nil
+}
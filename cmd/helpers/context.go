@@ -0,0 +1,27 @@
+package helpers
+
+import (
+	"fmt"
+
+	"github.com/zinc-sig/ghost/cmd/config"
+	contextparser "github.com/zinc-sig/ghost/internal/context"
+)
+
+// BuildContext builds the context of a result from the context flags
+// With --context-git the metadata of the working directory's git repository is
+// added under "git", below every other source so they can override it.
+func BuildContext(cfg *config.ContextConfig) (any, error) {
+	ctxData, err := contextparser.BuildContext(cfg.JSON, cfg.KV, cfg.File)
+	if err != nil || !cfg.Git {
+		return ctxData, err
+	}
+	if _, ok := ctxData.(map[string]any); ctxData != nil && !ok {
+		return nil, fmt.Errorf("--context-git requires the context to be a JSON object")
+	}
+
+	metadata, err := contextparser.ParseGit(".")
+	if err != nil {
+		return nil, err
+	}
+	return contextparser.MergeContexts(map[string]any{contextparser.GitKey: metadata}, ctxData), nil
+}
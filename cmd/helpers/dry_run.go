@@ -3,7 +3,8 @@ package helpers
 import (
 	"encoding/json"
 	"fmt"
-	"os"
+
+	"github.com/zinc-sig/ghost/internal/redact"
 )
 
 // PrintContextInfo prints context configuration in verbose/dry-run mode
@@ -17,17 +18,17 @@ func PrintContextInfo(context any, dryRun bool) {
 		header = "Context Configuration (DRY RUN)"
 	}
 
-	fmt.Fprintln(os.Stderr, "========================================")
-	fmt.Fprintln(os.Stderr, header)
-	fmt.Fprintln(os.Stderr, "========================================")
+	fmt.Fprintln(redact.Stderr, "========================================")
+	fmt.Fprintln(redact.Stderr, header)
+	fmt.Fprintln(redact.Stderr, "========================================")
 
 	// Pretty print the context as JSON
 	jsonBytes, err := json.MarshalIndent(context, "", "  ")
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "  %v\n", context)
+		fmt.Fprintf(redact.Stderr, "  %v\n", context)
 	} else {
-		fmt.Fprintf(os.Stderr, "%s\n", string(jsonBytes))
+		fmt.Fprintf(redact.Stderr, "%s\n", string(jsonBytes))
 	}
 
-	fmt.Fprintln(os.Stderr, "----------------------------------------")
+	fmt.Fprintln(redact.Stderr, "----------------------------------------")
 }
@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/redact"
+	"github.com/zinc-sig/ghost/internal/runner"
+	"github.com/zinc-sig/ghost/internal/webhook"
+)
+
+// NewEvent creates a lifecycle event payload stamped with the current time
+func NewEvent(event, runID, command string, context any) *output.Event {
+	return &output.Event{
+		SchemaVersion: output.SchemaVersion,
+		Event:         event,
+		RunID:         runID,
+		Command:       command,
+		Timestamp:     time.Now().UTC().Format(time.RFC3339Nano),
+		Context:       context,
+	}
+}
+
+// SendEvent delivers a lifecycle event if --webhook-events enables it
+// Delivery errors are logged but never fail the command. In dry run mode the
+// event is only announced, since the final result prints the webhook settings.
+func SendEvent(ctx context.Context, config *webhook.Config, retryConfig *webhook.RetryConfig, event *output.Event, verbose bool, dryRun bool) {
+	if config == nil || config.URL == "" || !config.EventEnabled(event.Event) {
+		return
+	}
+
+	if dryRun {
+		fmt.Fprintf(redact.Stderr, "[DRY RUN] Would send webhook event: %s\n", event.Event)
+		return
+	}
+	if verbose {
+		fmt.Fprintf(redact.Stderr, "[WEBHOOK] Event: %s\n", event.Event)
+	}
+	_, _, _, _ = sendWebhook(ctx, config, retryConfig, runIDHeaders(event.RunID, event.Attempt, event.Event), event, verbose, false)
+}
+
+// SendTimeoutEvent delivers the timeout event if the command timed out
+// The attempt is that of --attempt (0 = not set).
+func SendTimeoutEvent(ctx context.Context, config *webhook.Config, retryConfig *webhook.RetryConfig, runID string, attempt int, result *runner.Result, timeout time.Duration, context any, verbose bool, dryRun bool) {
+	if result.Status != runner.StatusTimeout {
+		return
+	}
+	timeoutMs := timeout.Milliseconds()
+	event := NewEvent(webhook.EventTimeout, runID, result.Command, context)
+	event.Attempt = attempt
+	event.Status = string(result.Status)
+	event.Timeout = &timeoutMs
+	SendEvent(ctx, config, retryConfig, event, verbose, dryRun)
+}
@@ -0,0 +1,187 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/config"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/runner"
+	"github.com/zinc-sig/ghost/internal/tracing"
+	"github.com/zinc-sig/ghost/internal/upload"
+	"github.com/zinc-sig/ghost/internal/webhook"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Execution is one invocation of a command that executes something, from its
+// root span to its result file
+type Execution struct {
+	Flags       *config.CommonFlags
+	Webhook     *webhook.Config
+	RetryConfig *webhook.RetryConfig
+	Span        trace.Span
+
+	RunID      string
+	Attempt    int // --attempt (0 = not set)
+	ResultFile *ResultFile
+	Context    any // reported with the result and every lifecycle event
+
+	shutdownTracing func()
+}
+
+// StartExecution sets up tracing and the "ghost <command>" root span, then
+// resolves the run ID used by downstream consumers for deduplication and the
+// result file. End must be called once the command is done.
+func StartExecution(cmd *cobra.Command, flags *config.CommonFlags, webhookConfig *webhook.Config, retryConfig *webhook.RetryConfig) (context.Context, *Execution, error) {
+	shutdownTracing, err := SetupTracing(CommandContext(cmd), flags.OtelEndpoint, flags.Verbose)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, span := tracing.Tracer().Start(CommandContext(cmd), "ghost "+cmd.Name())
+	exec := &Execution{
+		Flags:           flags,
+		Webhook:         webhookConfig,
+		RetryConfig:     retryConfig,
+		Span:            span,
+		shutdownTracing: shutdownTracing,
+	}
+
+	exec.RunID, err = ResolveRunID(flags.RunID)
+	if err != nil {
+		exec.End()
+		return nil, nil, failure.Wrap(failure.Usage, err)
+	}
+	span.SetAttributes(attribute.String("ghost.run_id", exec.RunID))
+
+	exec.ResultFile, err = ParseResultFile(flags.ResultFile, exec.RunID)
+	if err != nil {
+		exec.End()
+		return nil, nil, failure.Wrap(failure.ResultFileFailed, err)
+	}
+	return ctx, exec, nil
+}
+
+// End ends the root span and flushes the spans
+func (e *Execution) End() {
+	e.Span.End()
+	e.shutdownTracing()
+}
+
+// BuildContext builds the context from all sources and records it on the span
+func (e *Execution) BuildContext(cfg *config.ContextConfig) error {
+	ctxData, err := BuildContext(cfg)
+	if err != nil {
+		return failure.Wrap(failure.ContextInvalid, fmt.Errorf("failed to build context: %w", err))
+	}
+	e.Context = ctxData
+	e.Span.SetAttributes(tracing.ContextAttributes(ctxData)...)
+	return nil
+}
+
+// SendStarted delivers the started event, letting dashboards show in-progress executions
+func (e *Execution) SendStarted(ctx context.Context, command string) {
+	event := NewEvent(webhook.EventStarted, e.RunID, command, e.Context)
+	event.Attempt = e.Attempt
+	SendEvent(ctx, e.Webhook, e.RetryConfig, event, e.Flags.Verbose, e.Flags.DryRun)
+}
+
+// SendTimeout delivers the timeout event if the command timed out
+func (e *Execution) SendTimeout(ctx context.Context, result *runner.Result) {
+	SendTimeoutEvent(ctx, e.Webhook, e.RetryConfig, e.RunID, e.Attempt, result, e.Flags.Timeout, e.Context, e.Flags.Verbose, e.Flags.DryRun)
+}
+
+// SendUploadFinished delivers the upload finished event with the upload results
+func (e *Execution) SendUploadFinished(ctx context.Context, command string, uploads []output.UploadResult) {
+	event := NewEvent(webhook.EventUploadFinished, e.RunID, command, e.Context)
+	event.Attempt = e.Attempt
+	event.Uploads = uploads
+	SendEvent(ctx, e.Webhook, e.RetryConfig, event, e.Flags.Verbose, e.Flags.DryRun)
+}
+
+// FinishOptions are what a command adds to the shared finish of its execution
+type FinishOptions struct {
+	// OutputFile and StderrFile are read for the previews; none are embedded without them
+	OutputFile string
+	StderrFile string
+
+	// Plan is reported by dry runs, with the timeout, result file and webhook filled in
+	Plan *output.Plan
+
+	// Provider uploads the result file with the upload settings of the command
+	Provider upload.Provider
+	Upload   *UploadOptions
+
+	// Graded is called with the scored result before it is output; cached grades
+	// are already scored and skip it
+	Graded func(result *output.Result)
+}
+
+// FinishExecution scores the result, outputs it, sends the webhook, logs it and
+// writes the result file, in the same way for every command
+func FinishExecution(ctx context.Context, exec *Execution, result *output.Result, opts FinishOptions) error {
+	flags := exec.Flags
+
+	// Print context info in dry run mode
+	if flags.DryRun && exec.Context != nil {
+		PrintContextInfo(exec.Context, true)
+	}
+
+	result.RunID = exec.RunID
+	result.Attempt = exec.Attempt
+	result.SchemaVersion = flags.ResultSchema
+	if opts.OutputFile != "" || opts.StderrFile != "" {
+		if err := EmbedPreviews(result, opts.OutputFile, opts.StderrFile, flags); err != nil {
+			return err
+		}
+	}
+	if flags.RecordEnvSet {
+		result.Environment = RecordEnvironment(flags.RecordEnv)
+	}
+
+	// Dry runs also describe everything that would happen, for validating configuration
+	if flags.DryRun && opts.Plan != nil {
+		opts.Plan.Timeout = result.Timeout
+		opts.Plan.ResultFile = PlanResultFile(exec.ResultFile)
+		opts.Plan.Webhook = PlanWebhook(exec.Webhook, exec.RetryConfig)
+		result.Plan = opts.Plan
+	}
+
+	// A cached grade is already scored
+	if !result.Cached {
+		// Apply score expression if provided (overrides binary scoring)
+		if err := ApplyScoreExpression(result, flags.ScoreExpr, flags.Score); err != nil {
+			return failure.Wrap(failure.ScoreFailed, err)
+		}
+
+		// Or score by the rubric, which records a breakdown per criterion
+		if err := ApplyRubric(result, flags.Rubric); err != nil {
+			return failure.Wrap(failure.ScoreFailed, err)
+		}
+
+		// Run the external grading command last so it sees the final status and score
+		if err := ApplyScoreCommand(ctx, result, flags.ScoreCmd, flags.Verbose, flags.DryRun); err != nil {
+			return failure.Wrap(failure.ScoreFailed, err)
+		}
+
+		if opts.Graded != nil {
+			opts.Graded(result)
+		}
+	}
+
+	if err := OutputJSONAndWebhook(ctx, exec.Webhook, exec.RetryConfig, result, flags.Human, flags.Verbose, flags.DryRun); err != nil {
+		return err
+	}
+	LogResult(result, flags)
+
+	// Let shell && chains see the outcome, not just that ghost itself worked
+	if flags.PropagateExitCode {
+		PropagateExitCode(result)
+	}
+
+	// Write the result file last so it matches stdout, including webhook status
+	return failure.Wrap(failure.ResultFileFailed, HandleResultFile(ctx, exec.ResultFile, result, opts.Provider, opts.Upload, flags.Verbose, flags.DryRun))
+}
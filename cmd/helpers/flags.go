@@ -3,22 +3,63 @@ package helpers
 import (
 	"github.com/spf13/cobra"
 	"github.com/zinc-sig/ghost/cmd/config"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/progress"
 )
 
 // SetupContextFlags adds context-related flags to a command
 func SetupContextFlags(cmd *cobra.Command, cfg *config.ContextConfig) {
 	cmd.Flags().StringVar(&cfg.JSON, "context", "", "Context data as JSON string")
-	cmd.Flags().StringArrayVar(&cfg.KV, "context-kv", nil, "Context key=value pairs (can be used multiple times)")
+	cmd.Flags().StringArrayVar(&cfg.KV, "context-kv", nil, "Context key=value pairs; dotted keys build nested objects, e.g. student.meta.section=3 (can be used multiple times)")
 	cmd.Flags().StringVar(&cfg.File, "context-file", "", "Path to JSON file containing context data")
+	cmd.Flags().BoolVar(&cfg.Git, "context-git", false, "Add the repository, branch, commit, dirty flag and author of the working directory's git repository as context.git")
 }
 
 // SetupUploadFlags adds upload-related flags to a command
 func SetupUploadFlags(cmd *cobra.Command, cfg *config.UploadConfig) {
 	cmd.Flags().StringVar(&cfg.Provider, "upload-provider", "", "Upload provider type (e.g., minio)")
 	cmd.Flags().StringVar(&cfg.Config, "upload-config", "", "Upload configuration as JSON string")
-	cmd.Flags().StringArrayVar(&cfg.ConfigKV, "upload-config-kv", nil, "Upload config key=value pairs (can be used multiple times)")
+	cmd.Flags().StringArrayVar(&cfg.ConfigKV, "upload-config-kv", nil, "Upload config key=value pairs; dotted keys build nested objects (can be used multiple times)")
 	cmd.Flags().StringVar(&cfg.ConfigFile, "upload-config-file", "", "Path to JSON file containing upload configuration")
-	cmd.Flags().StringArrayVar(&cfg.UploadFiles, "upload-files", nil, "Additional files to upload (format: local[:remote], can be used multiple times)")
+	cmd.Flags().StringArrayVar(&cfg.UploadFiles, "upload-files", nil, "Additional files or glob patterns to upload (format: local[:remote], can be used multiple times)")
+	cmd.Flags().IntVar(&cfg.Retries, "upload-retries", DefaultUploadRetries, "Maximum upload retry attempts per file (0 = no retries)")
+	cmd.Flags().StringVar(&cfg.RetryDelay, "upload-retry-delay", DefaultUploadRetryDelay, "Initial delay between upload retries")
+	cmd.Flags().StringVar(&cfg.FailPolicy, "upload-fail-policy", DefaultUploadFailPolicy, "Behaviour when an upload fails: error, warn, cleanup (error, removing the files this run uploaded)")
+	cmd.Flags().StringVar(&cfg.Encrypt, "upload-encrypt", "", "Encrypt uploaded objects at rest with a customer key: sse-c:file:<path> or sse-c:env:<VAR>")
+	cmd.Flags().StringVar(&cfg.ArtifactTTL, "artifact-ttl", "", "Retention of uploaded files, tagged for bucket lifecycle expiry (e.g. 7d, 36h; rounded up to days)")
+	cmd.Flags().StringVar(&cfg.Presign, "upload-presign", "", "Add presigned download URLs valid for this long to the upload results (e.g. 24h, at most 168h)")
+	cmd.Flags().StringVar(&cfg.Compress, "upload-compress", "", "Compress output and stderr before upload: gzip (appends .gz to the remote path)")
+	cmd.Flags().StringVar(&cfg.Bandwidth, "upload-bandwidth-limit", "", "Maximum upload rate across all files (e.g. 10MB/s, 512KiB/s)")
+	cmd.Flags().IntVar(&cfg.Concurrency, "upload-concurrency", DefaultUploadConcurrency, "Upload up to this many files at once (results keep their order)")
+	cmd.Flags().StringVar(&cfg.MaxFileSize, "upload-max-file-size", "", "Fail if an additional upload file is larger than this (e.g. 10MB)")
+	cmd.Flags().StringVar(&cfg.MaxTotal, "upload-max-total-size", "", "Fail if the additional upload files together are larger than this (e.g. 100MB)")
+	cmd.Flags().BoolVar(&cfg.IfAbsent, "upload-if-absent", false, "Skip uploading files already stored at their remote path, e.g. when re-running a batch")
+	cmd.Flags().BoolVar(&cfg.FromRemote, "upload-from-remote", false, "Also upload the input and expected files next to the remote output (input/ and expected/), recording their roles")
+}
+
+// SetupFilterFlags adds output filter flags to a command
+func SetupFilterFlags(cmd *cobra.Command, cfg *config.FilterConfig) {
+	cmd.Flags().StringArrayVar(&cfg.Stdout, "stdout-filter", nil, "Filter the output file line by line: drop:<regex>, redact:<regex> or strip:<regex> (can be used multiple times)")
+	cmd.Flags().StringArrayVar(&cfg.Stderr, "stderr-filter", nil, "Filter the stderr file line by line: drop:<regex>, redact:<regex> or strip:<regex> (can be used multiple times)")
+}
+
+// SetupCaptureFlags adds flags controlling how capture files are written to a command
+func SetupCaptureFlags(cmd *cobra.Command, cfg *config.CaptureConfig) {
+	cmd.Flags().BoolVar(&cfg.Append, "append", false, "Append to the output, stderr and combined files instead of truncating them, e.g. to collect several runs in one log")
+	cmd.Flags().StringVar(&cfg.Combined, "capture-combined", "", "Also capture stdout and stderr interleaved in this file, each line prefixed with [stdout] or [stderr]")
+}
+
+// SetupDeterminismFlags adds flags pinning the locale, timezone and seed variables of a command
+func SetupDeterminismFlags(cmd *cobra.Command, cfg *config.DeterminismConfig) {
+	cmd.Flags().StringVar(&cfg.Locale, "set-locale", "", "Run the command with this locale (LANG and LC_ALL), e.g. C")
+	cmd.Flags().StringVar(&cfg.Timezone, "set-tz", "", "Run the command in this timezone (TZ), e.g. UTC")
+	cmd.Flags().StringArrayVar(&cfg.SeedEnv, "set-seed-env", nil, "Seed variable NAME=VALUE passed to the command, e.g. RANDOM_SEED=42 (repeatable)")
+}
+
+// SetupProgressFlags adds progress reporting flags to a batch command
+func SetupProgressFlags(cmd *cobra.Command, cfg *config.ProgressConfig) {
+	cmd.Flags().StringVar(&cfg.Format, "progress", progress.FormatNone, "Report progress as JSON lines after every case: none, json")
+	cmd.Flags().IntVar(&cfg.FD, "progress-fd", 2, "File descriptor the progress lines are written to (default: stderr)")
 }
 
 // SetupCommonFlags adds commonly used flags to a command
@@ -26,7 +67,33 @@ func SetupCommonFlags(cmd *cobra.Command, flags *config.CommonFlags) {
 	cmd.Flags().BoolVarP(&flags.Verbose, "verbose", "v", false, "Show command stderr on terminal in addition to file")
 	cmd.Flags().BoolVar(&flags.DryRun, "dry-run", false, "Show what would be executed without running commands")
 	cmd.Flags().StringVarP(&flags.TimeoutStr, "timeout", "t", "", "Timeout duration (e.g., 30s, 2m, 500ms)")
-	cmd.Flags().StringVar(&flags.Score, "score", "", "Optional score value (included in output if exit code is 0)")
+	cmd.Flags().StringVar(&flags.SoftTimeoutStr, "soft-timeout", "", "Send SIGTERM after this duration so the command can flush its output, before --timeout kills it")
+	cmd.Flags().StringVar(&flags.Score, "score", "", "Optional score value (included in output if the run succeeds)")
+	cmd.Flags().StringVar(&flags.ScoreExpr, "score-expr", "", "Score expression evaluated against the result (e.g., 'exit_code == 0 ? 100 : 50')")
+	cmd.Flags().StringVar(&flags.ScoreCmd, "score-command", "", "Command that receives the result JSON on stdin and prints score, feedback and context JSON")
+	cmd.Flags().StringVar(&flags.Rubric, "rubric", "", "Rubric file (YAML or JSON) of criteria whose points add up to the score, with a breakdown in the result")
+	cmd.MarkFlagsMutuallyExclusive("rubric", "score")
+	cmd.MarkFlagsMutuallyExclusive("rubric", "score-expr")
+	cmd.Flags().BoolVar(&flags.PropagateExitCode, "propagate-exit-code", false, "Exit with the command's exit code (0 on success, 124 on timeout) instead of 0")
+	cmd.Flags().StringVar(&flags.RunID, "run-id", "", "Run ID for this execution (default: generated UUID); reuse it to re-deliver idempotently")
+	cmd.Flags().StringVar(&flags.ResultFile, "result-file", "", "Also write the JSON result to this file (format: local[:remote] to upload it)")
+	cmd.Flags().StringSliceVar(&flags.RecordEnv, "record-env", nil, "Record host details and environment variables with these name prefixes in the result (comma-separated, * for all)")
+	cmd.Flags().IntVar(&flags.EmbedOutputHead, "embed-output-head", 0, "Embed the first N bytes of the output file in the result as output_preview (0 = off)")
+	cmd.Flags().IntVar(&flags.EmbedStderrTail, "embed-stderr-tail", 0, "Embed the last N bytes of the stderr file in the result as stderr_preview (0 = off)")
+	cmd.Flags().StringVar(&flags.StderrClassify, "stderr-classify", "", "Count the errors and warnings in the stderr file as diagnostics: gcc, javac or python")
+	cmd.Flags().BoolVar(&flags.Human, "human", false, "Print a short colored summary instead of the JSON result on stdout (colors honor NO_COLOR and TERM)")
+	cmd.Flags().StringSliceVar(&flags.LogSinks, "log-sink", nil, "Also log the result to these sinks with structured fields: journal, syslog (comma-separated)")
+	cmd.Flags().StringVar(&flags.ResultSchemaStr, "result-schema", "v"+output.SchemaVersion, "Schema version of the result and webhook payload: v1 leaves out fields added since, such as uploads and rubric")
+	cmd.Flags().StringVar(&flags.OtelEndpoint, "otel-endpoint", "", "OTLP/HTTP endpoint for tracing (default: from OTEL_EXPORTER_OTLP_* env vars)")
+}
+
+// SetupExpectFlags adds flags choosing which exit codes of the command count as success
+// Only commands that run a user command get them: diff's exit code is the diff
+// tool's mismatch status, which must not be turned into success.
+func SetupExpectFlags(cmd *cobra.Command, flags *config.CommonFlags) {
+	cmd.Flags().IntVar(&flags.ExpectExitCode, "expect-exit-code", 0, "Exit code that counts as success (default: 0)")
+	cmd.Flags().BoolVar(&flags.ExpectNonzero, "expect-nonzero", false, "Treat any non-zero exit code as success")
+	cmd.MarkFlagsMutuallyExclusive("expect-exit-code", "expect-nonzero")
 }
 
 // SetupWebhookFlags adds webhook-related flags to a command
@@ -34,14 +101,28 @@ func SetupWebhookFlags(cmd *cobra.Command, cfg *config.WebhookConfig) {
 	// Direct configuration flags
 	cmd.Flags().StringVar(&cfg.URL, "webhook-url", "", "Webhook URL to send results to")
 	cmd.Flags().StringVar(&cfg.Method, "webhook-method", DefaultWebhookMethod, "HTTP method to use: GET, POST, PUT, PATCH, DELETE")
-	cmd.Flags().StringVar(&cfg.AuthType, "webhook-auth-type", DefaultWebhookAuthType, "Authentication type: none, bearer, api-key")
-	cmd.Flags().StringVar(&cfg.AuthToken, "webhook-auth-token", "", "Authentication token (use with --webhook-auth-type)")
+	cmd.Flags().StringVar(&cfg.AuthType, "webhook-auth-type", DefaultWebhookAuthType, "Authentication type: none, bearer, api-key, basic, header")
+	cmd.Flags().StringVar(&cfg.AuthToken, "webhook-auth-token", "", "Authentication token (use with --webhook-auth-type; user:password for basic)")
+	cmd.Flags().StringVar(&cfg.AuthHeader, "webhook-auth-header", "", "Header name carrying the token with --webhook-auth-type header (e.g. X-Service-Token)")
 	cmd.Flags().IntVar(&cfg.Retries, "webhook-retries", DefaultWebhookRetries, "Maximum webhook retry attempts (0 = no retries)")
 	cmd.Flags().StringVar(&cfg.RetryDelay, "webhook-retry-delay", DefaultWebhookRetryDelay, "Initial delay between webhook retries")
 	cmd.Flags().StringVar(&cfg.Timeout, "webhook-timeout", DefaultWebhookTimeout, "Total timeout for webhook including retries")
+	cmd.Flags().StringArrayVar(&cfg.Headers, "webhook-header", nil, "Custom webhook request header as 'Name: value', e.g. 'X-Course: cs101' or 'User-Agent: grader/2' (can be used multiple times)")
+	cmd.Flags().Float64Var(&cfg.RateLimit, "webhook-rate-limit", 0, "Maximum webhook deliveries per second (0 = unlimited)")
+	cmd.Flags().StringVar(&cfg.Proxy, "webhook-proxy", "", "Proxy URL for webhook delivery (default: HTTP_PROXY/HTTPS_PROXY/NO_PROXY)")
+	cmd.Flags().BoolVar(&cfg.DisableKeepAlives, "webhook-disable-keep-alives", false, "Open a new connection for every webhook delivery")
+	cmd.Flags().StringSliceVar(&cfg.Events, "webhook-events", nil, "Lifecycle events to deliver: started, timeout, upload_finished, completed (default: completed without event field)")
+	cmd.Flags().IntSliceVar(&cfg.ExpectStatus, "webhook-expect-status", nil, "Status codes that count as a successful delivery (comma-separated, default: any 2xx)")
+	cmd.Flags().BoolVar(&cfg.CaptureResponse, "webhook-capture-response", false, "Record the webhook response status and body in the result (webhook_response)")
+	cmd.Flags().BoolVar(&cfg.Async, "webhook-async", false, "Spool webhook deliveries locally and return immediately; deliver them with ghost webhook flush")
+	cmd.Flags().StringVar(&cfg.SpoolDir, "webhook-spool-dir", "", "Spool directory for --webhook-async (default: <user cache dir>/ghost/webhook-spool)")
+	cmd.Flags().IntVar(&cfg.BreakerThreshold, "webhook-breaker-threshold", 0, "Spool instead of sending after this many consecutive failed deliveries to the URL, across runs (0 = no circuit breaker)")
+	cmd.Flags().StringVar(&cfg.BreakerCooldown, "webhook-breaker-cooldown", DefaultWebhookBreakerCooldown, "Wait before an open circuit breaker lets one delivery probe the URL")
+	cmd.Flags().StringSliceVar(&cfg.IncludeFields, "webhook-include-fields", nil, "Only send these payload fields (comma-separated, dot notation, e.g. status,context.user_id)")
+	cmd.Flags().StringSliceVar(&cfg.ExcludeFields, "webhook-exclude-fields", nil, "Strip these payload fields from the webhook (comma-separated, dot notation, e.g. context.token)")
 
 	// Alternative configuration methods
 	cmd.Flags().StringVar(&cfg.Config, "webhook-config", "", "Webhook configuration as JSON string")
-	cmd.Flags().StringArrayVar(&cfg.ConfigKV, "webhook-config-kv", nil, "Webhook config key=value pairs (can be used multiple times)")
+	cmd.Flags().StringArrayVar(&cfg.ConfigKV, "webhook-config-kv", nil, "Webhook config key=value pairs; dotted keys build nested objects (can be used multiple times)")
 	cmd.Flags().StringVar(&cfg.ConfigFile, "webhook-config-file", "", "Path to JSON file containing webhook configuration")
 }
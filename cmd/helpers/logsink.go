@@ -0,0 +1,28 @@
+package helpers
+
+import (
+	"fmt"
+
+	"github.com/zinc-sig/ghost/cmd/config"
+	"github.com/zinc-sig/ghost/internal/logsink"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/redact"
+)
+
+// LogResult logs the result to the --log-sink sinks
+// Like webhook errors, failing to log is reported but does not fail the command.
+func LogResult(result *output.Result, flags *config.CommonFlags) {
+	for _, sink := range flags.LogSinks {
+		if flags.DryRun {
+			fmt.Fprintf(redact.Stderr, "[DRY RUN] Would log the result to %s\n", sink)
+			continue
+		}
+		if err := logsink.Log(sink, result); err != nil {
+			fmt.Fprintf(redact.Stderr, "[LOG] Error: %v\n", err)
+			continue
+		}
+		if flags.Verbose {
+			fmt.Fprintf(redact.Stderr, "[LOG] Logged the result to %s\n", sink)
+		}
+	}
+}
@@ -5,18 +5,103 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/shopspring/decimal"
 	"github.com/zinc-sig/ghost/cmd/config"
+	"github.com/zinc-sig/ghost/internal/diagnostics"
+	"github.com/zinc-sig/ghost/internal/environment"
+	"github.com/zinc-sig/ghost/internal/failure"
+	humanfmt "github.com/zinc-sig/ghost/internal/human"
+	"github.com/zinc-sig/ghost/internal/logsink"
 	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/preview"
+	"github.com/zinc-sig/ghost/internal/redact"
 	"github.com/zinc-sig/ghost/internal/runner"
 	"github.com/zinc-sig/ghost/internal/webhook"
 )
 
+// ValidateEmbedFlags checks the --embed-output-head and --embed-stderr-tail sizes,
+// the --stderr-classify format and the --log-sink sinks
+func ValidateEmbedFlags(flags *config.CommonFlags) error {
+	if flags.EmbedOutputHead < 0 {
+		return fmt.Errorf("--embed-output-head must not be negative")
+	}
+	if flags.EmbedStderrTail < 0 {
+		return fmt.Errorf("--embed-stderr-tail must not be negative")
+	}
+	if flags.StderrClassify != "" {
+		if err := diagnostics.Validate(flags.StderrClassify); err != nil {
+			return fmt.Errorf("invalid --stderr-classify: %w", err)
+		}
+	}
+	if err := logsink.Validate(flags.LogSinks); err != nil {
+		return fmt.Errorf("invalid --log-sink: %w", err)
+	}
+	return nil
+}
+
+// ParseResultSchema parses a --result-schema value such as v1 into a schema version
+func ParseResultSchema(value string) (string, error) {
+	version := strings.TrimPrefix(value, "v")
+	if !slices.Contains(output.SchemaVersions, version) {
+		versions := make([]string, len(output.SchemaVersions))
+		for i, v := range output.SchemaVersions {
+			versions[i] = "v" + v
+		}
+		return "", fmt.Errorf("invalid --result-schema %q (must be one of %s)", value, strings.Join(versions, ", "))
+	}
+	return version, nil
+}
+
+// EmbedPreviews adds the head of the output file and the tail of the stderr file
+// to the result, so consumers can show feedback without fetching the files
+// With --stderr-classify, the diagnostics in the stderr file are counted too.
+// Stream targets cannot be read back and get no preview.
+func EmbedPreviews(jsonResult *output.Result, outputFile, stderrFile string, flags *config.CommonFlags) error {
+	if flags.DryRun {
+		return nil
+	}
+	var err error
+	if flags.EmbedOutputHead > 0 && !runner.IsStreamTarget(outputFile) {
+		jsonResult.OutputPreview, jsonResult.OutputTruncated, err = preview.Head(outputFile, flags.EmbedOutputHead)
+		if err != nil {
+			return fmt.Errorf("failed to embed output preview: %w", err)
+		}
+	}
+	if flags.EmbedStderrTail > 0 && !runner.IsStreamTarget(stderrFile) {
+		jsonResult.StderrPreview, jsonResult.StderrTruncated, err = preview.Tail(stderrFile, flags.EmbedStderrTail)
+		if err != nil {
+			return fmt.Errorf("failed to embed stderr preview: %w", err)
+		}
+	}
+	if flags.StderrClassify != "" && !runner.IsStreamTarget(stderrFile) {
+		jsonResult.Diagnostics, err = ClassifyStderr(stderrFile, flags.StderrClassify)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClassifyStderr counts the compiler or interpreter diagnostics in a stderr file
+func ClassifyStderr(path, format string) (*output.Diagnostics, error) {
+	summary, err := diagnostics.ClassifyFile(path, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to classify stderr: %w", err)
+	}
+	return &output.Diagnostics{Format: format, Errors: summary.Errors, Warnings: summary.Warnings, FirstError: summary.FirstError}, nil
+}
+
 // createJSONResult creates a JSON result from execution results
 // The expectedPath parameter is optional - pass empty string for run command
 func CreateJSONResult(inputPath, outputPath, stderrPath, expectedPath string, result *runner.Result, timeoutMs int64, scoreSet bool, scoreStr string, context any) *output.Result {
 	jsonResult := &output.Result{
+		SchemaVersion: output.SchemaVersion,
 		Command:       result.Command,
 		Status:        string(result.Status),
 		Input:         inputPath,
@@ -24,7 +109,42 @@ func CreateJSONResult(inputPath, outputPath, stderrPath, expectedPath string, re
 		Stderr:        stderrPath,
 		ExitCode:      result.ExitCode,
 		ExecutionTime: result.ExecutionTime,
+		MaxRSSKB:      result.MaxRSSKB,
 		Context:       context,
+
+		PolicyViolation:  result.PolicyViolation,
+		ResourceExceeded: result.ResourceExceeded,
+		OOMKilled:        result.OOMKilled,
+		Signal:           result.Signal,
+		CoreDumped:       result.CoreDumped,
+		Deadline:         result.Deadline,
+		NetworkIsolated:  result.NetworkIsolated,
+	}
+
+	// Add interaction transcript if an interaction script was used
+	if result.Interaction != nil {
+		jsonResult.Interaction = convertInteraction(result.Interaction)
+	}
+
+	// Add per-step results for pipelines
+	for _, step := range result.Steps {
+		jsonResult.Steps = append(jsonResult.Steps, output.StepResult{
+			Command:       step.Command,
+			Status:        string(step.Status),
+			ExitCode:      step.ExitCode,
+			ExecutionTime: step.ExecutionTime,
+		})
+	}
+
+	// Add the grader's verdict for interactive runs
+	if grader := result.Grader; grader != nil {
+		jsonResult.Grader = &output.GraderResult{
+			Command:       grader.Command,
+			Status:        string(grader.Status),
+			ExitCode:      grader.ExitCode,
+			ExecutionTime: grader.ExecutionTime,
+			Verdict:       grader.Verdict,
+		}
 	}
 
 	// Add expected field only if provided (for diff command)
@@ -45,7 +165,7 @@ func CreateJSONResult(inputPath, outputPath, stderrPath, expectedPath string, re
 			return jsonResult
 		}
 
-		if result.ExitCode == 0 {
+		if result.Status == runner.StatusSuccess {
 			jsonResult.Score = &score
 		} else {
 			zero := decimal.NewFromInt(0)
@@ -56,114 +176,390 @@ func CreateJSONResult(inputPath, outputPath, stderrPath, expectedPath string, re
 	return jsonResult
 }
 
+// RecordEnvironment captures the host details and the selected environment variables
+func RecordEnvironment(prefixes []string) *output.Environment {
+	info := environment.Capture(prefixes)
+	return &output.Environment{
+		Variables:  info.Variables,
+		OS:         info.OS,
+		Arch:       info.Arch,
+		Kernel:     info.Kernel,
+		Machine:    info.Machine,
+		Hostname:   info.Hostname,
+		WorkingDir: info.WorkingDir,
+	}
+}
+
+// RecordDeterminism describes the pinned environment variables for the result
+func RecordDeterminism(n *environment.Normalization) *output.Determinism {
+	if n == nil {
+		return nil
+	}
+	return &output.Determinism{Locale: n.Locale, Timezone: n.Timezone, Seeds: n.Seeds}
+}
+
+// RecordDescriptors describes the files mapped to further descriptors for the result
+func RecordDescriptors(fds []runner.FileDescriptor) []output.FileDescriptor {
+	var recorded []output.FileDescriptor
+	for _, fd := range fds {
+		recorded = append(recorded, output.FileDescriptor{FD: fd.FD, Path: fd.Path, Mode: fd.Mode})
+	}
+	return recorded
+}
+
+// convertInteraction converts a runner interaction result to its JSON representation
+func convertInteraction(interaction *runner.InteractionResult) *output.Interaction {
+	converted := &output.Interaction{
+		Steps:      interaction.TotalSteps,
+		Completed:  interaction.CompletedSteps,
+		Error:      interaction.Error,
+		Transcript: make([]output.TranscriptEntry, 0, len(interaction.Transcript)),
+	}
+	for _, entry := range interaction.Transcript {
+		converted.Transcript = append(converted.Transcript, output.TranscriptEntry{
+			Step:    entry.Step,
+			Kind:    entry.Kind,
+			Text:    entry.Text,
+			Output:  entry.Output,
+			Elapsed: entry.Elapsed,
+		})
+	}
+	return converted
+}
+
+// runIDHeaders returns the headers that identify a delivery of the run
+// The run ID doubles as idempotency key so receivers can deduplicate re-deliveries.
+// Lifecycle events other than the final result get an idempotency key per event,
+// and each --attempt of a run gets its own, so a retry is not dropped as duplicate.
+func runIDHeaders(runID string, attempt int, event string) map[string]string {
+	headers := map[string]string{}
+	if runID != "" {
+		key := runID
+		if attempt > 0 {
+			key += ":attempt-" + strconv.Itoa(attempt)
+			headers[AttemptHeader] = strconv.Itoa(attempt)
+		}
+		if event != "" && event != webhook.EventCompleted {
+			key += ":" + event
+		}
+		headers[RunIDHeader] = runID
+		headers[IdempotencyKeyHeader] = key
+	}
+	if event != "" {
+		headers[EventHeader] = event
+	}
+	return headers
+}
+
+// withHeaders returns a copy of the webhook config that also sends the headers
+func withHeaders(config *webhook.Config, headers map[string]string) *webhook.Config {
+	if len(headers) == 0 {
+		return config
+	}
+
+	merged := *config
+	merged.Headers = make(map[string]string, len(config.Headers)+len(headers))
+	for k, v := range config.Headers {
+		merged.Headers[k] = v
+	}
+	for k, v := range headers {
+		merged.Headers[k] = v
+	}
+	return &merged
+}
+
 // outputJSON marshals and prints the result as JSON
 func OutputJSON(result *output.Result) error {
-	jsonOutput, err := json.Marshal(result)
+	return PrintJSON(result)
+}
+
+// OutputHuman prints a short summary of the result, colored when stdout is a terminal
+func OutputHuman(result *output.Result) error {
+	if err := humanfmt.WriteResult(os.Stdout, result, humanfmt.ColorEnabled(os.Stdout)); err != nil {
+		return fmt.Errorf("failed to write summary: %w", err)
+	}
+	resultPrinted = true
+	return nil
+}
+
+// resultPrinted records that a result was written to stdout, so an error
+// returned afterwards is not reported as a second JSON document
+var resultPrinted bool
+
+// PrintJSON marshals a result or report and prints it to stdout on one line
+func PrintJSON(v any) error {
+	jsonOutput, err := json.Marshal(v)
 	if err != nil {
 		return fmt.Errorf("failed to marshal JSON output: %w", err)
 	}
 
 	fmt.Println(string(jsonOutput))
+	resultPrinted = true
 	return nil
 }
 
-// Global webhook configuration variables (internal use)
-var (
-	runWebhookConfigParsed  *webhook.Config
-	runRetryConfig          *webhook.RetryConfig
-	diffWebhookConfigParsed *webhook.Config
-	diffRetryConfig         *webhook.RetryConfig
-)
+// MarkResultPrinted records a result written to stdout in another format
+func MarkResultPrinted() {
+	resultPrinted = true
+}
 
-// ResetWebhookConfigs resets the global webhook configurations (for testing)
-func ResetWebhookConfigs() {
-	runWebhookConfigParsed = nil
-	runRetryConfig = nil
-	diffWebhookConfigParsed = nil
-	diffRetryConfig = nil
+// ResultPrinted reports whether a result has been written to stdout
+func ResultPrinted() bool {
+	return resultPrinted
 }
 
-// ParseWebhookConfig parses webhook configuration for the specified command
-func ParseWebhookConfig(config *config.WebhookConfig, isRunCommand bool) error {
-	// Parse to internal structures (BuildWebhookConfig is called inside)
-	webhookConfig, retryConfig, err := ParseWebhookConfigToInternal(config)
-	if err != nil {
-		return err
+// TimeoutExitCode is the exit code for a timed out command with --propagate-exit-code,
+// as used by coreutils timeout
+const TimeoutExitCode = 124
+
+// exitCode is the exit code ghost terminates with after printing a result
+var exitCode int
+
+// PropagateExitCode makes ghost exit with the outcome of the result
+// Success exits 0 (also for an expected non-zero exit code), a timeout 124 and
+// any other failure with the command's exit code, or 1 if that is not 1-255.
+func PropagateExitCode(result *output.Result) {
+	switch {
+	case result.Status == string(runner.StatusSuccess):
+		exitCode = 0
+	case result.Status == string(runner.StatusTimeout):
+		exitCode = TimeoutExitCode
+	case result.ExitCode > 0 && result.ExitCode <= 255:
+		exitCode = result.ExitCode
+	default:
+		exitCode = 1
 	}
+}
 
-	// Store in appropriate global variables based on command type
-	if isRunCommand {
-		runWebhookConfigParsed = webhookConfig
-		runRetryConfig = retryConfig
-	} else {
-		diffWebhookConfigParsed = webhookConfig
-		diffRetryConfig = retryConfig
+// ExitCode returns the exit code ghost terminates with after printing a result
+func ExitCode() int {
+	return exitCode
+}
+
+// ResetExitCode restores the default exit code (for testing)
+func ResetExitCode() {
+	exitCode = 0
+}
+
+// NewErrorReport describes an error ghost failed with, classified by its failure code
+func NewErrorReport(command string, err error) *output.ErrorReport {
+	return &output.ErrorReport{
+		Command:   command,
+		Status:    "error",
+		ErrorCode: string(failure.CodeOf(err)),
+		Error:     redact.Error(err),
 	}
+}
 
-	return nil
+// OutputJSONAndWebhook outputs JSON to stdout and optionally sends it to the webhook
+// config and retryConfig are the invocation's parsed webhook settings (nil = no webhook).
+// With human set, stdout gets a summary for people instead; the webhook still gets JSON.
+func OutputJSONAndWebhook(ctx context.Context, config *webhook.Config, retryConfig *webhook.RetryConfig, result *output.Result, human bool, verbose bool, dryRun bool) error {
+	DeliverResult(ctx, config, retryConfig, result, verbose, dryRun)
+
+	// Always output to stdout, again without fields newer than its schema version
+	result.Restrict()
+	if human {
+		return OutputHuman(result)
+	}
+	return OutputJSON(result)
 }
 
-// outputJSONAndWebhook outputs JSON to stdout and optionally sends to webhook
-func OutputJSONAndWebhook(result *output.Result, verbose bool, dryRun bool) error {
-	// Determine which webhook config to use based on command
-	var config *webhook.Config
-	var retryConfig *webhook.RetryConfig
+// DeliverResult sends the result to the webhook as the completed event and
+// records the delivery in its webhook fields
+// Results pinned to an older schema version leave out the newer fields.
+func DeliverResult(ctx context.Context, config *webhook.Config, retryConfig *webhook.RetryConfig, result *output.Result, verbose bool, dryRun bool) {
+	attempt := result.Attempt
+	result.Restrict()
 
-	// Check if this is a diff command by looking for Expected field
-	if result.Expected != nil {
-		config = diffWebhookConfigParsed
-		retryConfig = diffRetryConfig
-	} else {
-		config = runWebhookConfigParsed
-		retryConfig = runRetryConfig
+	// The final result is only skipped if --webhook-events leaves out "completed"
+	if config != nil && config.EventEnabled(webhook.EventCompleted) {
+		// Create a copy of result without webhook fields for sending
+		webhookPayload := *result
+		webhookPayload.WebhookSent = false
+		webhookPayload.WebhookError = ""
+		webhookPayload.WebhookResponse = nil
+		webhookPayload.WebhookSpooled = false
+		webhookPayload.WebhookAttempts = nil
+
+		var event string
+		if len(config.Events) > 0 {
+			event = webhook.EventCompleted
+			webhookPayload.Event = event
+		}
+
+		// Send webhook if configured
+		response, receipts, spooled, err := sendWebhook(ctx, config, retryConfig, runIDHeaders(result.RunID, attempt, event), &webhookPayload, verbose, dryRun)
+		result.WebhookSent = err == nil && response != nil
+		result.WebhookSpooled = spooled
+		result.WebhookAttempts = webhookAttempts(receipts)
+		if err != nil {
+			result.WebhookError = redact.Error(err)
+		}
+		if config.CaptureResponse && response != nil {
+			result.WebhookResponse = &output.WebhookResponse{
+				StatusCode:    response.StatusCode,
+				Body:          string(response.Body),
+				BodyTruncated: response.Truncated,
+			}
+		}
+	}
+}
+
+// SendWebhook delivers the payload to the configured webhook
+// In dry run mode the webhook configuration is printed instead; async webhooks, and
+// deliveries while the circuit breaker is open, are spooled rather than sent
+// (spooled is true). Delivery errors are logged and returned but should not fail
+// the command. Nothing is sent without a URL.
+func SendWebhook(ctx context.Context, config *webhook.Config, retryConfig *webhook.RetryConfig, runID string, payload any, verbose bool, dryRun bool) (sent bool, spooled bool, err error) {
+	response, _, spooled, err := sendWebhook(ctx, config, retryConfig, runIDHeaders(runID, 0, ""), payload, verbose, dryRun)
+	return err == nil && response != nil, spooled, err
+}
+
+// sendWebhook delivers the payload with additional headers
+// It returns the receiver's last response, which is nil if nothing was received,
+// the receipts of the attempts and whether the delivery was spooled for
+// `ghost webhook flush` instead.
+func sendWebhook(ctx context.Context, config *webhook.Config, retryConfig *webhook.RetryConfig, headers map[string]string, payload any, verbose bool, dryRun bool) (*webhook.Response, []webhook.Receipt, bool, error) {
+	if config == nil || config.URL == "" {
+		return nil, nil, false, nil
+	}
+	if _, ok := config.Headers[UserAgentHeader]; !ok {
+		headers[UserAgentHeader] = UserAgent(headers[RunIDHeader])
 	}
 
-	// Handle webhook in dry run or normal mode
-	if dryRun && config != nil && config.URL != "" {
+	if dryRun {
 		// Print webhook info in dry run
-		fmt.Fprintln(os.Stderr, "========================================")
-		fmt.Fprintln(os.Stderr, "Webhook Configuration (DRY RUN)")
-		fmt.Fprintln(os.Stderr, "========================================")
-		fmt.Fprintf(os.Stderr, "URL:            %s\n", config.URL)
-		fmt.Fprintf(os.Stderr, "Method:         %s\n", config.Method)
-		fmt.Fprintf(os.Stderr, "Auth Type:      %s\n", config.AuthType)
+		fmt.Fprintln(redact.Stderr, "========================================")
+		fmt.Fprintln(redact.Stderr, "Webhook Configuration (DRY RUN)")
+		fmt.Fprintln(redact.Stderr, "========================================")
+		fmt.Fprintf(redact.Stderr, "URL:            %s\n", redact.URL(config.URL))
+		fmt.Fprintf(redact.Stderr, "Method:         %s\n", config.Method)
+		fmt.Fprintf(redact.Stderr, "Auth Type:      %s\n", config.AuthType)
+		if config.AuthHeader != "" {
+			fmt.Fprintf(redact.Stderr, "Auth Header:    %s\n", config.AuthHeader)
+		}
 		if config.AuthToken != "" {
-			fmt.Fprintf(os.Stderr, "Auth Token:     ***REDACTED***\n")
+			fmt.Fprintf(redact.Stderr, "Auth Token:     ***REDACTED***\n")
+		}
+		for _, name := range sortedKeys(config.Headers) {
+			value := config.Headers[name]
+			if redact.IsSensitiveKey(name) {
+				value = redact.Mask
+			}
+			fmt.Fprintf(redact.Stderr, "Header:         %s: %s\n", name, value)
+		}
+		fmt.Fprintf(redact.Stderr, "Timeout:        %s\n", config.Timeout)
+		if config.RateLimit > 0 {
+			fmt.Fprintf(redact.Stderr, "Rate Limit:     %g/s\n", config.RateLimit)
+		}
+		if config.Proxy != "" {
+			fmt.Fprintf(redact.Stderr, "Proxy:          %s\n", redact.URL(config.Proxy))
+		}
+		if config.DisableKeepAlives {
+			fmt.Fprintln(redact.Stderr, "Keep-Alives:    disabled")
+		}
+		if len(config.IncludeFields) > 0 {
+			fmt.Fprintf(redact.Stderr, "Include Fields: %s\n", strings.Join(config.IncludeFields, ", "))
+		}
+		if len(config.ExcludeFields) > 0 {
+			fmt.Fprintf(redact.Stderr, "Exclude Fields: %s\n", strings.Join(config.ExcludeFields, ", "))
+		}
+		if len(config.Events) > 0 {
+			fmt.Fprintf(redact.Stderr, "Events:         %s\n", strings.Join(config.Events, ", "))
+		}
+		if len(config.ExpectStatus) > 0 {
+			fmt.Fprintf(redact.Stderr, "Expect Status:  %s\n", strings.Trim(fmt.Sprint(config.ExpectStatus), "[]"))
+		}
+		if config.CaptureResponse {
+			fmt.Fprintln(redact.Stderr, "Response:       captured")
+		}
+		if config.Async {
+			fmt.Fprintf(redact.Stderr, "Async:          spool to %s\n", spoolDir(config))
+		}
+		if config.BreakerThreshold > 0 {
+			fmt.Fprintf(redact.Stderr, "Breaker:        open after %d failures, probe every %s\n", config.BreakerThreshold, config.BreakerCooldown)
 		}
-		fmt.Fprintf(os.Stderr, "Timeout:        %s\n", config.Timeout)
 		if retryConfig != nil {
-			fmt.Fprintf(os.Stderr, "Max Retries:    %d\n", retryConfig.MaxRetries)
-			fmt.Fprintf(os.Stderr, "Initial Delay:  %s\n", retryConfig.InitialDelay)
+			fmt.Fprintf(redact.Stderr, "Max Retries:    %d\n", retryConfig.MaxRetries)
+			fmt.Fprintf(redact.Stderr, "Initial Delay:  %s\n", retryConfig.InitialDelay)
 		}
-		fmt.Fprintln(os.Stderr, "----------------------------------------")
-		fmt.Fprintln(os.Stderr, "[DRY RUN] Would send webhook to above URL")
-		fmt.Fprintln(os.Stderr, "========================================")
-	} else if !dryRun && config != nil && config.URL != "" {
-		// Send webhook if configured (before outputting to stdout)
-		client := webhook.NewClient(config, retryConfig, verbose)
+		fmt.Fprintln(redact.Stderr, "----------------------------------------")
+		fmt.Fprintln(redact.Stderr, "[DRY RUN] Would send webhook to above URL")
+		fmt.Fprintln(redact.Stderr, "========================================")
+		return nil, nil, false, nil
+	}
 
+	// An open circuit breaker spools the delivery rather than waiting on a dead endpoint
+	var breaker *webhook.Breaker
+	if config.BreakerThreshold > 0 {
+		breaker = webhook.NewBreaker(spoolDir(config), config.URL, config.BreakerThreshold, config.BreakerCooldown)
+	}
+	if config.Async || !breaker.Allow() {
+		id, err := webhook.SpoolDelivery(spoolDir(config), withHeaders(config, headers), retryConfig, payload)
+		if err != nil {
+			fmt.Fprintf(redact.Stderr, "[WEBHOOK] Error: %v\n", err)
+			return nil, nil, false, err
+		}
 		if verbose {
-			fmt.Fprintf(os.Stderr, "[WEBHOOK] Sending to %s\n", config.URL)
+			reason := ""
+			if !config.Async {
+				reason = " (circuit breaker open)"
+			}
+			fmt.Fprintf(redact.Stderr, "[WEBHOOK] Spooled %s for %s%s\n", id, redact.URL(config.URL), reason)
 		}
+		return nil, nil, true, nil
+	}
 
-		// Create a copy of result without webhook fields for sending
-		webhookPayload := *result
-		webhookPayload.WebhookSent = false
-		webhookPayload.WebhookError = ""
+	client := webhook.NewClient(withHeaders(config, headers), retryConfig, verbose)
+
+	if verbose {
+		fmt.Fprintf(redact.Stderr, "[WEBHOOK] Sending to %s\n", redact.URL(config.URL))
+	}
 
-		ctx := context.Background()
-		if err := client.Send(ctx, &webhookPayload); err != nil {
-			// Log webhook error but don't fail the command
-			fmt.Fprintf(os.Stderr, "[WEBHOOK] Error: %v\n", err)
+	response, err := client.Deliver(ctx, payload)
+	if ctx.Err() == nil {
+		breaker.Record(err)
+	}
+	if err != nil {
+		// Log webhook error but don't fail the command
+		fmt.Fprintf(redact.Stderr, "[WEBHOOK] Error: %v\n", err)
 
-			// Add webhook status to result
-			result.WebhookSent = false
-			result.WebhookError = err.Error()
-		} else {
-			result.WebhookSent = true
+		// Keep the failed delivery and its receipts for auditing after ghost exits
+		id, recordErr := webhook.RecordFailedDelivery(spoolDir(config), withHeaders(config, headers), retryConfig, payload, client.Receipts(), err)
+		if recordErr != nil {
+			fmt.Fprintf(redact.Stderr, "[WEBHOOK] Warning: failed to record failed delivery: %v\n", recordErr)
+		} else if verbose {
+			fmt.Fprintf(redact.Stderr, "[WEBHOOK] Recorded failed delivery %s in %s\n", id, filepath.Join(spoolDir(config), webhook.SpoolFailedDir))
 		}
+		return response, client.Receipts(), false, err
 	}
+	return response, client.Receipts(), false, nil
+}
 
-	// Always output to stdout
-	return OutputJSON(result)
+// webhookAttempts converts delivery receipts for the result
+func webhookAttempts(receipts []webhook.Receipt) []output.WebhookAttempt {
+	if len(receipts) == 0 {
+		return nil
+	}
+	attempts := make([]output.WebhookAttempt, len(receipts))
+	for i, r := range receipts {
+		attempts[i] = output.WebhookAttempt{
+			Attempt:    r.Attempt,
+			StartedAt:  r.StartedAt.UTC().Format(time.RFC3339Nano),
+			Duration:   r.Duration.Milliseconds(),
+			StatusCode: r.StatusCode,
+			Delivered:  r.Delivered,
+			Error:      r.Error,
+		}
+	}
+	return attempts
+}
+
+// spoolDir returns the spool directory of an async webhook
+func spoolDir(config *webhook.Config) string {
+	if config.SpoolDir != "" {
+		return config.SpoolDir
+	}
+	return webhook.DefaultSpoolDir()
 }
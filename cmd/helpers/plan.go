@@ -0,0 +1,109 @@
+package helpers
+
+import (
+	"github.com/zinc-sig/ghost/cmd/config"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/redact"
+	"github.com/zinc-sig/ghost/internal/upload"
+	"github.com/zinc-sig/ghost/internal/webhook"
+)
+
+// PlanUploads describes the uploads a dry run would perform
+// files, additionalFiles, attributes and roles are the same passed to HandleUploads.
+func PlanUploads(provider upload.Provider, uploadConf map[string]any, cfg *config.UploadConfig, files, additionalFiles map[string]string, attributes map[string]upload.Attributes, roles UploadRoles, opts *UploadOptions) *output.UploadPlan {
+	if provider == nil {
+		return nil
+	}
+
+	plan := &output.UploadPlan{
+		Provider:       provider.Name(),
+		Config:         redact.Config(uploadConf),
+		Files:          make([]output.PlannedFile, 0, len(files)+len(additionalFiles)),
+		FailPolicy:     cfg.FailPolicy,
+		IfAbsent:       cfg.IfAbsent,
+		Compression:    cfg.Compress,
+		Presign:        cfg.Presign,
+		BandwidthLimit: cfg.Bandwidth,
+	}
+	if cfg.Concurrency > DefaultUploadConcurrency {
+		plan.Concurrency = cfg.Concurrency
+	}
+	if opts.Retry != nil {
+		plan.Retries = opts.Retry.MaxRetries
+	}
+	if opts.Encryption != nil {
+		plan.Encryption = opts.Encryption.Method
+		plan.KeyFingerprint = opts.Encryption.Fingerprint
+	}
+	for _, local := range sortedKeys(files) {
+		plan.Files = append(plan.Files, output.PlannedFile{Local: local, Remote: upload.CompressedPath(files[local], cfg.Compress), Role: roles[local]})
+	}
+	for _, local := range sortedKeys(additionalFiles) {
+		plan.Files = append(plan.Files, output.PlannedFile{
+			Local:       local,
+			Remote:      additionalFiles[local],
+			Role:        roles.additionalRole(local),
+			ContentType: attributes[local].ContentType,
+			Metadata:    attributes[local].Metadata,
+		})
+	}
+	return plan
+}
+
+// PlanWebhook describes the webhook delivery of a dry run, if any
+func PlanWebhook(cfg *webhook.Config, retryConfig *webhook.RetryConfig) *output.WebhookPlan {
+	if cfg == nil || cfg.URL == "" {
+		return nil
+	}
+
+	plan := &output.WebhookPlan{
+		URL:           redact.URL(cfg.URL),
+		Method:        cfg.Method,
+		AuthType:      cfg.AuthType,
+		AuthHeader:    cfg.AuthHeader,
+		Timeout:       cfg.Timeout.String(),
+		RateLimit:     cfg.RateLimit,
+		Events:        cfg.Events,
+		IncludeFields: cfg.IncludeFields,
+		ExcludeFields: cfg.ExcludeFields,
+
+		ExpectStatus:    cfg.ExpectStatus,
+		CaptureResponse: cfg.CaptureResponse,
+	}
+	if cfg.Async {
+		plan.Async = true
+		plan.SpoolDir = spoolDir(cfg)
+	}
+	if cfg.BreakerThreshold > 0 {
+		plan.BreakerThreshold = cfg.BreakerThreshold
+		plan.BreakerCooldown = cfg.BreakerCooldown.String()
+		plan.SpoolDir = spoolDir(cfg)
+	}
+	if cfg.AuthToken != "" {
+		plan.AuthToken = redact.Mask
+	}
+	if cfg.Proxy != "" {
+		plan.Proxy = redact.URL(cfg.Proxy)
+	}
+	if retryConfig != nil {
+		plan.Retries = retryConfig.MaxRetries
+	}
+	if len(cfg.Headers) > 0 {
+		plan.Headers = make(map[string]string, len(cfg.Headers))
+		for name, value := range cfg.Headers {
+			if redact.IsSensitiveKey(name) {
+				value = redact.Mask
+			}
+			plan.Headers[name] = redact.String(value)
+		}
+	}
+	return plan
+}
+
+// PlanResultFile describes where --result-file would be written and uploaded
+func PlanResultFile(rf *ResultFile) *output.PlannedFile {
+	if rf == nil {
+		return nil
+	}
+	return &output.PlannedFile{Local: rf.Local, Remote: rf.Remote}
+}
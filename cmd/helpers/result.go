@@ -0,0 +1,108 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/redact"
+	"github.com/zinc-sig/ghost/internal/upload"
+)
+
+// ResultFile is where the JSON result is written in addition to stdout
+type ResultFile struct {
+	Local  string // Local path the result is written to
+	Remote string // Remote path to upload the result to (empty = no upload)
+}
+
+// ParseResultFile parses a --result-file value in the format "local[:remote]"
+// Unlike output paths the local path is required. Returns nil if path is empty.
+func ParseResultFile(path, runID string) (*ResultFile, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	local, remote, _ := strings.Cut(path, ":")
+	local = strings.TrimSpace(local)
+	if local == "" {
+		return nil, fmt.Errorf("invalid result file %q: local path is required", path)
+	}
+	return &ResultFile{Local: local, Remote: ExpandRunID(strings.TrimSpace(remote), runID)}, nil
+}
+
+// WriteResultFile atomically writes the result as JSON to path
+// The JSON is written to a temporary file in the same directory and renamed into
+// place, so readers never observe a partially written result.
+func WriteResultFile(path string, result *output.Result) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON output: %w", err)
+	}
+	data = append(data, '\n')
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create result file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write result file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write result file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write result file: %w", err)
+	}
+	// CreateTemp uses 0600; match the permissions of the other output files
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write result file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write result file: %w", err)
+	}
+	return nil
+}
+
+// HandleResultFile writes the result file and uploads it if a remote path and provider are set
+// The upload uses the retries, fail policy, encryption and bandwidth of opts, and
+// is never compressed or skipped.
+func HandleResultFile(ctx context.Context, resultFile *ResultFile, result *output.Result, provider upload.Provider, opts *UploadOptions, verbose bool, dryRun bool) error {
+	if resultFile == nil {
+		return nil
+	}
+
+	if dryRun {
+		fmt.Fprintf(redact.Stderr, "[DRY RUN] Would write result to: %s\n", resultFile.Local)
+	} else {
+		if err := WriteResultFile(resultFile.Local, result); err != nil {
+			return err
+		}
+		if verbose {
+			fmt.Fprintf(redact.Stderr, "✓ Result written to: %s\n", resultFile.Local)
+		}
+	}
+
+	if provider == nil || resultFile.Remote == "" {
+		return nil
+	}
+	files := map[string]string{resultFile.Local: resultFile.Remote}
+	roles := UploadRoles{resultFile.Local: UploadRoleResult}
+	resultOpts := *opts
+	resultOpts.Compression = upload.CompressionNone
+	resultOpts.Concurrency = DefaultUploadConcurrency
+	resultOpts.IfAbsent = false
+	_, err := HandleUploads(ctx, provider, nil, files, nil, roles, resultOpts, nil, verbose, dryRun)
+	return err
+}
@@ -0,0 +1,108 @@
+package helpers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/zinc-sig/ghost/internal/upload"
+)
+
+// RunIDPlaceholder is replaced with the run ID in remote upload paths
+const RunIDPlaceholder = "{run_id}"
+
+// AttemptPlaceholder is replaced with the attempt number in remote upload paths
+const AttemptPlaceholder = "{attempt}"
+
+// Run ID headers sent with every webhook delivery
+const (
+	RunIDHeader          = "X-Ghost-Run-ID"
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// EventHeader names the lifecycle event when --webhook-events is set
+	EventHeader = "X-Ghost-Event"
+
+	// AttemptHeader numbers the attempt when --attempt is set
+	AttemptHeader = "X-Ghost-Attempt"
+)
+
+// Run IDs end up in object paths and HTTP headers, so keep them to safe characters
+var runIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// ResolveRunID returns the --run-id override or a newly generated UUID
+func ResolveRunID(override string) (string, error) {
+	if override == "" {
+		return uuid.NewString(), nil
+	}
+	if !runIDPattern.MatchString(override) {
+		return "", fmt.Errorf("invalid run ID %q: use 1-128 letters, digits, '.', '_' or '-'", override)
+	}
+	return override, nil
+}
+
+// ExpandRunID replaces the {run_id} placeholder in a remote path
+func ExpandRunID(path, runID string) string {
+	return strings.ReplaceAll(path, RunIDPlaceholder, runID)
+}
+
+// ExpandRunIDInFiles replaces the {run_id} placeholder in the remote paths of a local-to-remote file map
+func ExpandRunIDInFiles(files map[string]string, runID string) {
+	for local, remote := range files {
+		files[local] = ExpandRunID(remote, runID)
+	}
+}
+
+// ExpandRunIDInAttributes replaces the {run_id} placeholder in metadata values of upload files
+func ExpandRunIDInAttributes(attributes map[string]upload.Attributes, runID string) {
+	for _, attrs := range attributes {
+		for key, value := range attrs.Metadata {
+			attrs.Metadata[key] = ExpandRunID(value, runID)
+		}
+	}
+}
+
+// ExpandRunID returns the paths with the {run_id} placeholder replaced in the remote paths
+func (p OutputPaths) ExpandRunID(runID string) OutputPaths {
+	p.RemoteOutput = ExpandRunID(p.RemoteOutput, runID)
+	p.RemoteStderr = ExpandRunID(p.RemoteStderr, runID)
+	return p
+}
+
+// ValidateAttempt checks an --attempt value (0 = not set)
+func ValidateAttempt(attempt int) error {
+	if attempt < 0 {
+		return fmt.Errorf("invalid --attempt %d: must be 1 or more", attempt)
+	}
+	return nil
+}
+
+// ExpandAttempt replaces the {attempt} placeholder in a remote path
+// Without --attempt (0) the execution counts as the first attempt.
+func ExpandAttempt(path string, attempt int) string {
+	return strings.ReplaceAll(path, AttemptPlaceholder, strconv.Itoa(max(attempt, 1)))
+}
+
+// ExpandAttemptInFiles replaces the {attempt} placeholder in the remote paths of a local-to-remote file map
+func ExpandAttemptInFiles(files map[string]string, attempt int) {
+	for local, remote := range files {
+		files[local] = ExpandAttempt(remote, attempt)
+	}
+}
+
+// ExpandAttemptInAttributes replaces the {attempt} placeholder in metadata values of upload files
+func ExpandAttemptInAttributes(attributes map[string]upload.Attributes, attempt int) {
+	for _, attrs := range attributes {
+		for key, value := range attrs.Metadata {
+			attrs.Metadata[key] = ExpandAttempt(value, attempt)
+		}
+	}
+}
+
+// ExpandAttempt returns the paths with the {attempt} placeholder replaced in the remote paths
+func (p OutputPaths) ExpandAttempt(attempt int) OutputPaths {
+	p.RemoteOutput = ExpandAttempt(p.RemoteOutput, attempt)
+	p.RemoteStderr = ExpandAttempt(p.RemoteStderr, attempt)
+	return p
+}
@@ -0,0 +1,181 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/redact"
+	"github.com/zinc-sig/ghost/internal/rubric"
+	"github.com/zinc-sig/ghost/internal/score"
+)
+
+// ScoreExprVariables lists the variables available to --score-expr
+var ScoreExprVariables = []string{"exit_code", "status", "execution_time", "timeout", "deadline", "score", "files_matched", "files_total", "errors", "warnings"}
+
+// ParseScoreExpression parses and validates a --score-expr expression
+func ParseScoreExpression(expr string) (*score.Expr, error) {
+	parsed, err := score.Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range parsed.Variables() {
+		if !slices.Contains(ScoreExprVariables, name) {
+			return nil, fmt.Errorf("unknown variable %q in score expression (available: %v)", name, ScoreExprVariables)
+		}
+	}
+
+	return parsed, nil
+}
+
+// ApplyScoreExpression evaluates the score expression against the result and sets its score
+// The --score value, if provided, is exposed to the expression as the "score" variable.
+func ApplyScoreExpression(result *output.Result, expr string, scoreStr string) error {
+	if expr == "" {
+		return nil
+	}
+
+	parsed, err := ParseScoreExpression(expr)
+	if err != nil {
+		return err
+	}
+
+	baseScore := decimal.Zero
+	if scoreStr != "" {
+		baseScore, err = decimal.NewFromString(scoreStr)
+		if err != nil {
+			return fmt.Errorf("invalid score value: %w", err)
+		}
+	}
+
+	var timeoutMs int64
+	if result.Timeout != nil {
+		timeoutMs = *result.Timeout
+	}
+	errors, warnings, _ := result.CountDiagnostics()
+
+	value, err := parsed.Evaluate(map[string]any{
+		"exit_code":      result.ExitCode,
+		"status":         result.Status,
+		"signal":         result.Signal,
+		"execution_time": result.ExecutionTime,
+		"timeout":        timeoutMs,
+		"deadline":       result.Deadline,
+		"score":          baseScore,
+		"files_matched":  countMatchedFiles(result.Files),
+		"files_total":    len(result.Files),
+		"errors":         errors,
+		"warnings":       warnings,
+	})
+	if err != nil {
+		return err
+	}
+
+	result.Score = &value
+	return nil
+}
+
+// ApplyRubric scores the result by the criteria of a rubric file, recording the breakdown
+func ApplyRubric(result *output.Result, path string) error {
+	if path == "" {
+		return nil
+	}
+
+	loaded, err := rubric.Load(path)
+	if err != nil {
+		return err
+	}
+	result.Rubric = loaded.Evaluate(result)
+	total := result.Rubric.Total
+	result.Score = &total
+	return nil
+}
+
+// ScoreCommandTimeout bounds how long a --score-command may run
+const ScoreCommandTimeout = 60 * time.Second
+
+// scoreCommandResponse is the JSON a --score-command prints on stdout
+type scoreCommandResponse struct {
+	Score    *decimal.Decimal `json:"score"`
+	Feedback string           `json:"feedback"`
+	Context  map[string]any   `json:"context"`
+}
+
+// ApplyScoreCommand runs an external grading command and merges its response into the result
+// The command receives the result JSON on stdin and prints a JSON object with optional
+// score, feedback and context fields. The score replaces the result's score, and
+// context keys are merged into the result context, overriding existing keys.
+func ApplyScoreCommand(ctx context.Context, result *output.Result, command string, verbose bool, dryRun bool) error {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil
+	}
+
+	if dryRun {
+		fmt.Fprintf(redact.Stderr, "[DRY RUN] Would run score command: %s\n", command)
+		return nil
+	}
+
+	input, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON output: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ScoreCommandTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if verbose {
+		cmd.Stderr = io.MultiWriter(&stderr, os.Stderr)
+	}
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("score command timed out after %s", ScoreCommandTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("score command failed: %w: %s", err, msg)
+		}
+		return fmt.Errorf("score command failed: %w", err)
+	}
+
+	var response scoreCommandResponse
+	if err := json.Unmarshal(bytes.TrimSpace(stdout.Bytes()), &response); err != nil {
+		return fmt.Errorf("failed to parse score command output: %w", err)
+	}
+
+	if response.Score != nil {
+		result.Score = response.Score
+	}
+	if response.Feedback != "" {
+		result.Feedback = response.Feedback
+	}
+	if len(response.Context) > 0 {
+		merged, ok := result.Context.(map[string]any)
+		if !ok {
+			if result.Context != nil {
+				return fmt.Errorf("score command context cannot be merged into non-object context")
+			}
+			merged = make(map[string]any, len(response.Context))
+		}
+		for k, v := range response.Context {
+			merged[k] = v
+		}
+		result.Context = merged
+	}
+	return nil
+}
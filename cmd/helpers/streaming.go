@@ -0,0 +1,216 @@
+package helpers
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/redact"
+	"github.com/zinc-sig/ghost/internal/upload"
+)
+
+// streamQueueBytes bounds the output queued for a streaming upload that cannot
+// keep up with the command
+var streamQueueBytes int64 = 8 << 20
+
+// StreamingUpload uploads the output file while the command writes it
+// It is the runner's StreamOutput: writes never fail or block, so a slow or broken
+// upload does not disturb the command or its output file. Writes are queued for
+// the upload; once it has failed or fallen more than streamQueueBytes behind, the
+// rest of the output is discarded and the finished file is uploaded again instead.
+type StreamingUpload struct {
+	provider    upload.Provider
+	remotePath  string
+	compression string
+	encryption  *upload.Encryption
+	verbose     bool
+
+	pipe       *io.PipeWriter
+	compressor *gzip.Writer // nil without compression
+	sent       *upload.CountingReader
+	done       chan error
+	start      time.Time
+
+	size int64 // bytes written by the command
+	err  error // overflow of the queue, after which the stream is abandoned
+
+	// The queue between the command's writes and the upload
+	mu       sync.Mutex
+	cond     *sync.Cond
+	chunks   [][]byte
+	queued   int64
+	closed   bool
+	drained  chan struct{}
+	drainErr error // first failed write to the upload
+}
+
+// StartStreamingUpload begins uploading the output to remotePath, which already
+// carries the suffix of its compression
+// The stream is compressed, encrypted and throttled as uploadOpts says. Providers
+// buffer the stream in parts, e.g. part_size for MinIO, so only one part needs
+// memory at a time.
+func StartStreamingUpload(ctx context.Context, provider upload.Provider, remotePath string, uploadOpts *UploadOptions, verbose bool) *StreamingUpload {
+	compression, encryption := uploadOpts.Compression, uploadOpts.Encryption
+	reader, writer := io.Pipe()
+	s := &StreamingUpload{
+		provider:    provider,
+		remotePath:  remotePath,
+		compression: compression,
+		encryption:  encryption,
+		verbose:     verbose,
+		pipe:        writer,
+		sent:        &upload.CountingReader{Reader: &upload.ThrottledReader{Ctx: ctx, Reader: reader, Limiter: upload.NewBandwidthLimiter(uploadOpts.Bandwidth)}},
+		done:        make(chan error, 1),
+		start:       time.Now(),
+		drained:     make(chan struct{}),
+	}
+	s.cond = sync.NewCond(&s.mu)
+	if compression == upload.CompressionGzip {
+		s.compressor = gzip.NewWriter(writer)
+	}
+
+	opts := upload.Options{
+		Encryption:  encryption,
+		ContentType: upload.DetectContentType(strings.TrimSuffix(remotePath, upload.CompressedPath("", compression)), nil),
+	}
+	if s.compressor != nil {
+		opts.ContentEncoding = compression
+	}
+	go func() {
+		err := upload.UploadWithOptions(ctx, provider, s.sent, remotePath, opts)
+		// Unblock the command if the upload stopped reading early
+		_ = reader.CloseWithError(fmt.Errorf("streaming upload stopped"))
+		s.done <- err
+	}()
+	go s.drain()
+
+	if verbose {
+		fmt.Fprintf(redact.Stderr, "[UPLOAD] Streaming output to %s\n", remotePath)
+	}
+	return s
+}
+
+// Write queues the output for the upload; it always reports success
+func (s *StreamingUpload) Write(p []byte) (int, error) {
+	s.size += int64(len(p))
+	if s.err != nil {
+		return len(p), nil
+	}
+
+	s.mu.Lock()
+	if s.queued+int64(len(p)) > streamQueueBytes {
+		s.mu.Unlock()
+		// Give up on the stream rather than hold up the command
+		s.err = fmt.Errorf("streaming upload fell more than %d bytes behind the output", streamQueueBytes)
+		_ = s.pipe.CloseWithError(s.err)
+		return len(p), nil
+	}
+	s.chunks = append(s.chunks, append([]byte(nil), p...))
+	s.queued += int64(len(p))
+	s.cond.Signal()
+	s.mu.Unlock()
+	return len(p), nil
+}
+
+// drain passes the queued output on to the upload until the queue is closed
+func (s *StreamingUpload) drain() {
+	defer close(s.drained)
+	for {
+		s.mu.Lock()
+		for len(s.chunks) == 0 && !s.closed {
+			s.cond.Wait()
+		}
+		if len(s.chunks) == 0 {
+			s.mu.Unlock()
+			return
+		}
+		chunk := s.chunks[0]
+		s.chunks[0] = nil
+		s.chunks = s.chunks[1:]
+		s.mu.Unlock()
+
+		// After a failed write the rest is discarded
+		if s.drainErr == nil {
+			if s.compressor != nil {
+				_, s.drainErr = s.compressor.Write(chunk)
+			} else {
+				_, s.drainErr = s.pipe.Write(chunk)
+			}
+		}
+
+		s.mu.Lock()
+		s.queued -= int64(len(chunk))
+		s.mu.Unlock()
+	}
+}
+
+// closeQueue lets drain return once the queued output is written
+func (s *StreamingUpload) closeQueue() {
+	s.mu.Lock()
+	s.closed = true
+	s.cond.Signal()
+	s.mu.Unlock()
+}
+
+// Abort cancels the upload, e.g. when the command could not be run
+func (s *StreamingUpload) Abort() {
+	s.closeQueue()
+	_ = s.pipe.CloseWithError(fmt.Errorf("streaming upload aborted"))
+	<-s.drained
+	<-s.done
+}
+
+// Finish ends the stream once the command has finished and waits for the upload
+// The result is successful only if the whole output was uploaded.
+func (s *StreamingUpload) Finish() output.UploadResult {
+	s.closeQueue()
+	<-s.drained
+	streamErr := s.err
+	if streamErr == nil {
+		streamErr = s.drainErr
+	}
+	if s.compressor != nil && streamErr == nil {
+		streamErr = s.compressor.Close()
+	}
+	if streamErr != nil {
+		_ = s.pipe.CloseWithError(streamErr)
+	} else {
+		_ = s.pipe.Close()
+	}
+	err := <-s.done
+	if err == nil && streamErr != nil {
+		err = streamErr
+	}
+
+	result := output.UploadResult{
+		Remote:      s.remotePath,
+		Role:        UploadRoleOutput,
+		Size:        s.size,
+		Duration:    time.Since(s.start).Milliseconds(),
+		Attempts:    1,
+		Streamed:    true,
+		Compression: s.compression,
+	}
+	if s.compressor != nil {
+		result.CompressedSize = s.sent.N
+	}
+	if s.encryption != nil {
+		result.Encryption = s.encryption.Method
+		result.KeyFingerprint = s.encryption.Fingerprint
+	}
+	if err != nil {
+		result.Error = redact.Error(err)
+		result.ErrorCode = upload.ClassifyError(s.provider, err)
+		return result
+	}
+	result.Success = true
+	if s.verbose {
+		fmt.Fprintf(redact.Stderr, "✓ Streamed to: %s\n", s.remotePath)
+	}
+	return result
+}
@@ -0,0 +1,66 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/zinc-sig/ghost/internal/redact"
+	"github.com/zinc-sig/ghost/internal/runner"
+	"github.com/zinc-sig/ghost/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TracingShutdownTimeout bounds how long ghost waits to flush spans on exit
+var TracingShutdownTimeout = 5 * time.Second
+
+// SetupTracing configures tracing and returns a cleanup function that flushes spans
+func SetupTracing(ctx context.Context, endpoint string, verbose bool) (func(), error) {
+	shutdown, err := tracing.Setup(ctx, endpoint)
+	if err != nil {
+		return func() {}, err
+	}
+
+	return func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), TracingShutdownTimeout)
+		defer cancel()
+		if err := shutdown(shutdownCtx); err != nil && verbose {
+			fmt.Fprintf(redact.Stderr, "[TRACING] Failed to flush spans: %v\n", err)
+		}
+	}, nil
+}
+
+// ExecuteWithSpan runs the command inside an "execute" span
+func ExecuteWithSpan(ctx context.Context, config *runner.Config) (*runner.Result, error) {
+	_, span := tracing.Tracer().Start(ctx, "execute")
+	defer span.End()
+
+	span.SetAttributes(
+		attribute.String("ghost.command", config.Command),
+		attribute.StringSlice("ghost.args", config.Args),
+		attribute.Bool("ghost.dry_run", config.DryRun),
+	)
+	if config.Timeout > 0 {
+		span.SetAttributes(attribute.Int64("ghost.timeout_ms", config.Timeout.Milliseconds()))
+	}
+
+	result, err := runner.Execute(config)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(
+		attribute.String("ghost.status", string(result.Status)),
+		attribute.Int("ghost.exit_code", result.ExitCode),
+		attribute.Int64("ghost.execution_time_ms", result.ExecutionTime),
+	)
+	if result.MaxRSSKB > 0 {
+		span.SetAttributes(attribute.Int64("ghost.max_rss_kb", result.MaxRSSKB))
+	}
+	if result.Status != runner.StatusSuccess {
+		tracing.RecordError(span, fmt.Errorf("command %s with exit code %d", result.Status, result.ExitCode))
+	}
+
+	return result, nil
+}
@@ -2,15 +2,257 @@ package helpers
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/zinc-sig/ghost/cmd/config"
+	"github.com/zinc-sig/ghost/internal/bytesize"
 	contextparser "github.com/zinc-sig/ghost/internal/context"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/redact"
+	"github.com/zinc-sig/ghost/internal/retry"
+	"github.com/zinc-sig/ghost/internal/runner"
+	"github.com/zinc-sig/ghost/internal/secret"
+	"github.com/zinc-sig/ghost/internal/tracing"
 	"github.com/zinc-sig/ghost/internal/upload"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/time/rate"
 )
 
+// Default upload configuration constants
+const (
+	DefaultUploadRetries    = 3
+	DefaultUploadRetryDelay = "1s"
+	DefaultUploadFailPolicy = UploadFailPolicyError
+	UploadRetryMultiplier   = 2.0
+
+	// DefaultUploadConcurrency uploads one file after another
+	DefaultUploadConcurrency = 1
+	// MaxUploadConcurrency bounds the files uploaded at once
+	MaxUploadConcurrency = 64
+
+	// UploadFailPolicyError fails the command when an upload fails
+	UploadFailPolicyError = "error"
+	// UploadFailPolicyWarn records the failure in the result and continues
+	UploadFailPolicyWarn = "warn"
+	// UploadFailPolicyCleanup fails the command like UploadFailPolicyError, removing
+	// the files already uploaded so no partial set is left behind
+	UploadFailPolicyCleanup = "cleanup"
+)
+
+// Roles of uploaded files, recorded in their upload results
+const (
+	UploadRoleOutput     = "output"
+	UploadRoleStderr     = "stderr"
+	UploadRoleInput      = "input"
+	UploadRoleExpected   = "expected"
+	UploadRoleAdditional = "additional"
+	UploadRoleResult     = "result"
+)
+
+// UploadRoles maps local paths to the role of the uploaded file
+// Additional files without a role are recorded as UploadRoleAdditional.
+type UploadRoles map[string]string
+
+// UploadOptions are the settings shared by every file of an upload run
+type UploadOptions struct {
+	Retry       *retry.Config      // retries of each file
+	FailPolicy  string             // UploadFailPolicyError, UploadFailPolicyWarn or UploadFailPolicyCleanup
+	Compression string             // applied to the standard files only (additional files are uploaded as-is)
+	Encryption  *upload.Encryption // applied to every file (nil = provider default)
+	Bandwidth   int64              // maximum bytes per second across all files (0 = unlimited)
+	Concurrency int                // files uploaded at once (1 = one after another)
+	IfAbsent    bool               // skip files already stored at their remote path
+
+	limiter *rate.Limiter // shared by the files of one HandleUploads
+}
+
+// UploadMaxRetryDelay is the maximum delay between upload retry attempts in exponential backoff
+var UploadMaxRetryDelay = 30 * time.Second
+
+// UploadProgressInterval is how often verbose mode reports the progress of an upload
+var UploadProgressInterval = time.Second
+
+// ParseUploadRetryConfig validates upload retry flags and builds the retry configuration
+func ParseUploadRetryConfig(cfg *config.UploadConfig) (*retry.Config, error) {
+	if cfg.Retries < 0 {
+		return nil, fmt.Errorf("upload retries must not be negative")
+	}
+
+	switch cfg.FailPolicy {
+	case "", UploadFailPolicyError, UploadFailPolicyWarn, UploadFailPolicyCleanup:
+	default:
+		return nil, fmt.Errorf("invalid upload fail policy %q (must be %s, %s or %s)", cfg.FailPolicy, UploadFailPolicyError, UploadFailPolicyWarn, UploadFailPolicyCleanup)
+	}
+	if err := upload.ValidateCompression(cfg.Compress); err != nil {
+		return nil, err
+	}
+
+	retryDelay, _ := time.ParseDuration(DefaultUploadRetryDelay)
+	if cfg.RetryDelay != "" {
+		var err error
+		retryDelay, err = time.ParseDuration(cfg.RetryDelay)
+		if err != nil {
+			return nil, fmt.Errorf("invalid upload retry delay: %w", err)
+		}
+	}
+
+	return &retry.Config{
+		MaxRetries:   cfg.Retries,
+		InitialDelay: retryDelay,
+		MaxDelay:     UploadMaxRetryDelay,
+		Multiplier:   UploadRetryMultiplier,
+	}, nil
+}
+
+// ParseUploadOptions validates the upload flags that apply to every file
+func ParseUploadOptions(cfg *config.UploadConfig) (*UploadOptions, error) {
+	retryConfig, err := ParseUploadRetryConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	encryption, err := parseUploadEncryption(cfg)
+	if err != nil {
+		return nil, err
+	}
+	bandwidth, err := parseUploadBandwidth(cfg)
+	if err != nil {
+		return nil, err
+	}
+	concurrency, err := parseUploadConcurrency(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &UploadOptions{
+		Retry:       retryConfig,
+		FailPolicy:  cfg.FailPolicy,
+		Compression: cfg.Compress,
+		Encryption:  encryption,
+		Bandwidth:   bandwidth,
+		Concurrency: concurrency,
+		IfAbsent:    cfg.IfAbsent,
+	}, nil
+}
+
+// parseUploadEncryption loads the upload encryption key if --upload-encrypt is set
+func parseUploadEncryption(cfg *config.UploadConfig) (*upload.Encryption, error) {
+	encryption, err := upload.ParseEncryption(cfg.Encrypt)
+	if err != nil {
+		return nil, err
+	}
+	if encryption != nil && cfg.Provider == "" {
+		return nil, fmt.Errorf("--upload-encrypt requires --upload-provider")
+	}
+	return encryption, nil
+}
+
+// parseUploadBandwidth validates --upload-bandwidth-limit and returns bytes per second (0 = unlimited)
+func parseUploadBandwidth(cfg *config.UploadConfig) (int64, error) {
+	if cfg.Bandwidth == "" {
+		return 0, nil
+	}
+	if cfg.Provider == "" {
+		return 0, fmt.Errorf("--upload-bandwidth-limit requires --upload-provider")
+	}
+	return upload.ParseBandwidth(cfg.Bandwidth)
+}
+
+// parseUploadConcurrency validates --upload-concurrency and returns how many files are uploaded at once
+func parseUploadConcurrency(cfg *config.UploadConfig) (int, error) {
+	if cfg.Concurrency < 1 || cfg.Concurrency > MaxUploadConcurrency {
+		return 0, fmt.Errorf("--upload-concurrency must be between 1 and %d, got %d", MaxUploadConcurrency, cfg.Concurrency)
+	}
+	if cfg.Concurrency > DefaultUploadConcurrency && cfg.Provider == "" {
+		return 0, fmt.Errorf("--upload-concurrency requires --upload-provider")
+	}
+	return cfg.Concurrency, nil
+}
+
+// ParseUploadLimits validates --upload-max-file-size and --upload-max-total-size
+func ParseUploadLimits(cfg *config.UploadConfig) (upload.Limits, error) {
+	if (cfg.MaxFileSize != "" || cfg.MaxTotal != "") && cfg.Provider == "" {
+		return upload.Limits{}, fmt.Errorf("--upload-max-file-size and --upload-max-total-size require --upload-provider")
+	}
+	return upload.ParseLimits(cfg.MaxFileSize, cfg.MaxTotal)
+}
+
+// ParseUploadPresign validates --upload-presign and returns the URL validity (0 = off)
+func ParseUploadPresign(cfg *config.UploadConfig, provider upload.Provider, encryption *upload.Encryption) (time.Duration, error) {
+	if cfg.Presign == "" {
+		return 0, nil
+	}
+	if provider == nil {
+		return 0, fmt.Errorf("--upload-presign requires --upload-provider")
+	}
+	if _, ok := provider.(upload.Presigner); !ok {
+		return 0, fmt.Errorf("upload provider %s does not support presigned URLs", provider.Name())
+	}
+	// Objects encrypted with a customer key cannot be downloaded without it
+	if encryption != nil {
+		return 0, fmt.Errorf("--upload-presign cannot be used with --upload-encrypt")
+	}
+	return upload.ParsePresignExpiry(cfg.Presign)
+}
+
+// CheckUploadCapabilities checks that the provider supports what --upload-if-absent
+// and --upload-fail-policy cleanup need
+func CheckUploadCapabilities(cfg *config.UploadConfig, provider upload.Provider) error {
+	if cfg.IfAbsent {
+		if provider == nil {
+			return fmt.Errorf("--upload-if-absent requires --upload-provider")
+		}
+		if _, ok := provider.(upload.StatProvider); !ok {
+			return fmt.Errorf("upload provider %s cannot check for stored files (--upload-if-absent)", provider.Name())
+		}
+	}
+	if cfg.Streaming {
+		if provider == nil {
+			return fmt.Errorf("--upload-streaming requires --upload-provider")
+		}
+		if cfg.IfAbsent {
+			return fmt.Errorf("--upload-streaming cannot be used with --upload-if-absent, which checks for stored files after the command ran")
+		}
+	}
+	if cfg.FailPolicy == UploadFailPolicyCleanup && provider != nil {
+		if _, ok := provider.(upload.DeleteProvider); !ok {
+			return fmt.Errorf("upload provider %s cannot remove stored files (--upload-fail-policy %s)", provider.Name(), UploadFailPolicyCleanup)
+		}
+	}
+	return nil
+}
+
+// PresignUploads adds presigned download URLs to the successful uploads
+func PresignUploads(ctx context.Context, provider upload.Provider, results []output.UploadResult, expiry time.Duration, verbose bool, dryRun bool) error {
+	presigner, ok := provider.(upload.Presigner)
+	if expiry == 0 || !ok || dryRun {
+		return nil
+	}
+	for i := range results {
+		if !results[i].Success {
+			continue
+		}
+		expires := time.Now().Add(expiry).UTC()
+		url, err := presigner.PresignGet(ctx, results[i].Remote, expiry)
+		if err != nil {
+			return fmt.Errorf("failed to presign %s: %w", results[i].Remote, err)
+		}
+		results[i].URL = url
+		results[i].URLExpires = expires.Format(time.RFC3339)
+		if verbose {
+			fmt.Fprintf(redact.Stderr, "[UPLOAD] Presigned %s until %s\n", results[i].Remote, results[i].URLExpires)
+		}
+	}
+	return nil
+}
+
 // BuildUploadConfig builds upload configuration from all sources
 func BuildUploadConfig(cfg *config.UploadConfig) (map[string]any, error) {
 	// Use the new generic builder with GHOST_UPLOAD_CONFIG prefix
@@ -28,72 +270,220 @@ func BuildUploadConfig(cfg *config.UploadConfig) (map[string]any, error) {
 		return make(map[string]any), nil
 	}
 
-	if m, ok := result.(map[string]any); ok {
-		return m, nil
+	m, ok := result.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("upload config must be an object/map")
 	}
 
-	return nil, fmt.Errorf("upload config must be an object/map")
+	// Secret references such as vault://... are resolved once every source is merged
+	if err := secret.ResolveConfig(context.Background(), m); err != nil {
+		return nil, fmt.Errorf("failed to build upload config: %w", err)
+	}
+	redact.AddConfig(m)
+	return m, nil
 }
 
 // parseUploadEnv and toLowerSnakeCase are no longer needed - using ParseEnvWithPrefix
 
 // ParseUploadFiles parses the upload files list and returns a map of local to remote paths
-// Format: local[:remote] where remote is optional (defaults to local path)
-func ParseUploadFiles(files []string) (map[string]string, error) {
+// Format: local[:remote][:type=<mime>][:meta.<key>=<value>]... where remote is optional
+// (defaults to local path). Files with a content type or metadata also get an entry
+// in the returned attributes map, keyed by local path.
+func ParseUploadFiles(files []string) (map[string]string, map[string]upload.Attributes, error) {
 	result := make(map[string]string)
+	attributes := make(map[string]upload.Attributes)
 
 	for _, file := range files {
 		if file == "" {
 			continue
 		}
 
-		var localPath, remotePath string
-		parts := strings.SplitN(file, ":", 2)
+		// Trailing type= and meta.<key>= segments are options, not part of the remote path
+		parts := strings.Split(file, ":")
+		var attrs upload.Attributes
+		for len(parts) > 1 && isUploadFileOption(parts[len(parts)-1]) {
+			if err := parseUploadFileOption(&attrs, strings.TrimSpace(parts[len(parts)-1])); err != nil {
+				return nil, nil, fmt.Errorf("%w in upload file specification: %s", err, file)
+			}
+			parts = parts[:len(parts)-1]
+		}
 
-		if len(parts) == 2 {
+		var localPath, remotePath string
+		if len(parts) >= 2 {
 			// Explicit mapping: local:remote
 			localPath = strings.TrimSpace(parts[0])
-			remotePath = strings.TrimSpace(parts[1])
+			remotePath = strings.TrimSpace(strings.Join(parts[1:], ":"))
 		} else {
 			// No colon: use same path for both
-			localPath = strings.TrimSpace(file)
+			localPath = strings.TrimSpace(parts[0])
 			remotePath = localPath
 		}
 
 		if localPath == "" {
-			return nil, fmt.Errorf("empty local path in upload file specification: %s", file)
+			return nil, nil, fmt.Errorf("empty local path in upload file specification: %s", file)
 		}
 		if remotePath == "" {
-			return nil, fmt.Errorf("empty remote path in upload file specification: %s", file)
+			return nil, nil, fmt.Errorf("empty remote path in upload file specification: %s", file)
 		}
 
 		// Check for duplicate local paths
 		if _, exists := result[localPath]; exists {
-			return nil, fmt.Errorf("duplicate local path in upload files: %s", localPath)
+			return nil, nil, fmt.Errorf("duplicate local path in upload files: %s", localPath)
 		}
 
 		result[localPath] = remotePath
+		if attrs.ContentType != "" || len(attrs.Metadata) > 0 {
+			attributes[localPath] = attrs
+		}
 	}
 
-	return result, nil
+	return result, attributes, nil
 }
 
-// ValidateUploadFiles checks if all specified files exist
-func ValidateUploadFiles(files map[string]string) error {
-	for localPath := range files {
-		if _, err := os.Stat(localPath); err != nil {
-			if os.IsNotExist(err) {
-				return fmt.Errorf("upload file does not exist: %s", localPath)
-			}
-			return fmt.Errorf("failed to check upload file %s: %w", localPath, err)
+// isUploadFileOption reports whether a segment of an upload file specification is an option
+func isUploadFileOption(segment string) bool {
+	segment = strings.TrimSpace(segment)
+	return strings.HasPrefix(segment, "type=") || strings.HasPrefix(segment, "meta.")
+}
+
+// parseUploadFileOption applies a type= or meta.<key>=<value> option to the attributes
+func parseUploadFileOption(attrs *upload.Attributes, option string) error {
+	key, value, ok := strings.Cut(option, "=")
+	if !ok {
+		return fmt.Errorf("invalid option %q, expected meta.<key>=<value>", option)
+	}
+	if key == "type" {
+		if attrs.ContentType != "" {
+			return fmt.Errorf("duplicate type option")
 		}
+		if err := upload.ValidateContentType(value); err != nil {
+			return err
+		}
+		attrs.ContentType = value
+		return nil
+	}
+
+	name := strings.TrimPrefix(key, "meta.")
+	if _, exists := attrs.Metadata[name]; exists {
+		return fmt.Errorf("duplicate metadata key %q", name)
 	}
+	entry := map[string]string{name: value}
+	if err := upload.ValidateMetadata(entry); err != nil {
+		return err
+	}
+	attrs.Metadata = upload.MergeMetadata(attrs.Metadata, entry)
 	return nil
 }
 
+// ExpandUploadFiles resolves the additional files once the command has run
+// Glob patterns are replaced by the regular files they match, sharing the
+// pattern's attributes; a pattern with an explicit remote path uploads its
+// matches under it. Matches that are symbolic links, directories or devices
+// are quarantined with a warning. Every additional file must be a regular file
+// within the limits; input and expected files mirrored by MirrorInputs are not
+// checked.
+func ExpandUploadFiles(files map[string]string, attributes map[string]upload.Attributes, roles UploadRoles, limits upload.Limits) (map[string]string, error) {
+	expanded := make(map[string]string, len(files))
+	var total int64
+	var count int
+	for _, local := range sortedKeys(files) {
+		if role := roles[local]; role == UploadRoleInput || role == UploadRoleExpected {
+			expanded[local] = files[local]
+			continue
+		}
+		if !upload.IsPattern(local) {
+			size, err := limits.CheckFile(local)
+			if err != nil {
+				return nil, err
+			}
+			expanded[local] = files[local]
+			total += size
+			count++
+			continue
+		}
+
+		remoteDir := files[local]
+		if remoteDir == local {
+			remoteDir = ""
+		}
+		matches, skipped, err := upload.ExpandPattern(local, remoteDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range skipped {
+			fmt.Fprintf(redact.Stderr, "[UPLOAD] Warning: not uploading %s (matched %s): not a regular file\n", match, local)
+		}
+		if len(matches) == 0 {
+			fmt.Fprintf(redact.Stderr, "[UPLOAD] Warning: no files match %s\n", local)
+		}
+		for _, match := range sortedKeys(matches) {
+			if _, exists := expanded[match]; exists {
+				continue
+			}
+			if _, exists := files[match]; exists {
+				// An explicit entry for the same file takes precedence
+				continue
+			}
+			size, err := limits.CheckFile(match)
+			if err != nil {
+				return nil, err
+			}
+			expanded[match] = matches[match]
+			if attrs, ok := attributes[local]; ok {
+				attributes[match] = attrs
+			}
+			total += size
+			count++
+		}
+		delete(attributes, local)
+	}
+	if err := limits.CheckTotal(total, count); err != nil {
+		return nil, err
+	}
+	return expanded, nil
+}
+
+// MirrorInputs adds input and expected files to the additional uploads, so the
+// evidence of a grading lives next to its output
+// inputs maps local paths to UploadRoleInput or UploadRoleExpected. Each file is
+// uploaded as <remote output dir>/<role>/<name>, unless it is already an additional
+// file. Only regular files are mirrored: stdin, devices and directories are skipped.
+// roles receives the role of every mirrored file.
+func MirrorInputs(additionalFiles map[string]string, roles UploadRoles, remoteOutput string, inputs map[string]string) (map[string]string, error) {
+	if additionalFiles == nil {
+		additionalFiles = make(map[string]string)
+	}
+	remotes := make(map[string]string, len(additionalFiles))
+	for local, remote := range additionalFiles {
+		remotes[remote] = local
+	}
+
+	dir := path.Dir(filepath.ToSlash(remoteOutput))
+	for _, local := range sortedKeys(inputs) {
+		if info, err := os.Stat(local); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		role := inputs[local]
+		roles[local] = role
+		if _, exists := additionalFiles[local]; exists {
+			continue
+		}
+		remote := path.Join(dir, role, filepath.Base(local))
+		if other, exists := remotes[remote]; exists {
+			return nil, fmt.Errorf("%s and %s would both be uploaded to %s", other, local, remote)
+		}
+		additionalFiles[local] = remote
+		remotes[remote] = local
+	}
+	return additionalFiles, nil
+}
+
 // SetupUploadProvider creates and configures an upload provider
 func SetupUploadProvider(cfg *config.UploadConfig, dryRun bool) (upload.Provider, map[string]any, error) {
 	if cfg.Provider == "" {
+		if cfg.ArtifactTTL != "" {
+			return nil, nil, fmt.Errorf("--artifact-ttl requires --upload-provider")
+		}
 		return nil, nil, nil
 	}
 
@@ -102,6 +492,17 @@ func SetupUploadProvider(cfg *config.UploadConfig, dryRun bool) (upload.Provider
 		return nil, nil, fmt.Errorf("failed to build upload config: %w", err)
 	}
 
+	// The flag takes precedence over the artifact_ttl config key, and is checked
+	// even in dry runs
+	if cfg.ArtifactTTL != "" {
+		uploadConf["artifact_ttl"] = cfg.ArtifactTTL
+	}
+	if ttl, ok := uploadConf["artifact_ttl"]; ok {
+		if _, err := upload.ParseTTL(ttl); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	provider, err := upload.NewProvider(cfg.Provider)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to create upload provider: %w", err)
@@ -117,56 +518,349 @@ func SetupUploadProvider(cfg *config.UploadConfig, dryRun bool) (upload.Provider
 	return provider, uploadConf, nil
 }
 
-// HandleUploads uploads files using the provider
+// ValidateUploadConfig checks an upload config map against the provider without uploading
+func ValidateUploadConfig(providerName string, uploadConf map[string]any) error {
+	provider, err := upload.NewProvider(providerName)
+	if err != nil {
+		return fmt.Errorf("failed to create upload provider: %w", err)
+	}
+	if err := provider.Configure(uploadConf); err != nil {
+		return fmt.Errorf("failed to configure upload provider: %w", err)
+	}
+	return nil
+}
+
+// HandleUploads uploads files using the provider, retrying each file with backoff
 // files: map of standard output/error files (local -> remote)
 // additionalFiles: map of additional files to upload (local -> remote)
-func HandleUploads(provider upload.Provider, files map[string]string, additionalFiles map[string]string, verbose bool, dryRun bool) error {
+// attributes: content type and metadata of additional files (local -> attributes)
+// roles: role of each file, recorded in its result (nil = none for standard files)
+// opts: retry, fail policy, compression, encryption, bandwidth and concurrency
+// uploaded: results of files uploaded before, such as the streamed output; they
+// lead the returned results and are removed by the cleanup policy
+// Returns the per-file upload results in file order, whatever order the uploads
+// finish in. With the warn fail policy, failures are recorded in the results
+// instead of being returned as an error. Otherwise no further uploads start after
+// a failure, the ones in progress finish and every failure is returned; with the
+// cleanup policy, the uploaded files are then removed again.
+func HandleUploads(ctx context.Context, provider upload.Provider, files map[string]string, additionalFiles map[string]string, attributes map[string]upload.Attributes, roles UploadRoles, opts UploadOptions, uploaded []output.UploadResult, verbose bool, dryRun bool) ([]output.UploadResult, error) {
 	if provider == nil {
-		return nil
+		return nil, nil
 	}
 
-	// Merge all files to upload
+	// Merge all files to upload, standard files first
+	var localPaths []string
 	allFiles := make(map[string]string)
-	for k, v := range files {
-		allFiles[k] = v
+	for _, k := range sortedKeys(files) {
+		allFiles[k] = upload.CompressedPath(files[k], opts.Compression)
+		localPaths = append(localPaths, k)
 	}
-	for k, v := range additionalFiles {
+	for _, k := range sortedKeys(additionalFiles) {
 		if _, exists := allFiles[k]; exists {
-			return fmt.Errorf("additional file conflicts with standard output file: %s", k)
+			return nil, fmt.Errorf("additional file conflicts with standard output file: %s", k)
 		}
-		allFiles[k] = v
+		allFiles[k] = additionalFiles[k]
+		localPaths = append(localPaths, k)
 	}
 
 	if dryRun {
-		fmt.Fprintln(os.Stderr, "[DRY RUN] Would upload the following files:")
+		fmt.Fprintln(redact.Stderr, "[DRY RUN] Would upload the following files:")
+		if opts.Encryption != nil {
+			fmt.Fprintf(redact.Stderr, "  (encrypted with %s, key %s)\n", opts.Encryption.Method, opts.Encryption.Fingerprint)
+		}
+		if opts.Bandwidth > 0 {
+			fmt.Fprintf(redact.Stderr, "  (at most %s/s)\n", bytesize.Format(opts.Bandwidth))
+		}
+		if opts.Concurrency > 1 {
+			fmt.Fprintf(redact.Stderr, "  (%d at a time)\n", opts.Concurrency)
+		}
+		if opts.IfAbsent {
+			fmt.Fprintln(redact.Stderr, "  (unless already stored)")
+		}
 		// Show standard files first
-		for localPath, remotePath := range files {
-			fmt.Fprintf(os.Stderr, "  %s → %s (standard)\n", localPath, remotePath)
+		for _, localPath := range sortedKeys(files) {
+			fmt.Fprintf(redact.Stderr, "  %s → %s (standard)\n", localPath, allFiles[localPath])
 		}
 		// Then show additional files
-		for localPath, remotePath := range additionalFiles {
-			fmt.Fprintf(os.Stderr, "  %s → %s (additional)\n", localPath, remotePath)
+		for _, localPath := range sortedKeys(additionalFiles) {
+			fmt.Fprintf(redact.Stderr, "  %s → %s (%s%s)\n", localPath, additionalFiles[localPath], roles.additionalRole(localPath), describeAttributes(attributes[localPath]))
+		}
+		return nil, nil
+	}
+
+	concurrency := max(opts.Concurrency, 1)
+	opts.limiter = upload.NewBandwidthLimiter(opts.Bandwidth)
+	fileResults := make([]*output.UploadResult, len(localPaths))
+	var (
+		mu     sync.Mutex
+		failed bool
+		wg     sync.WaitGroup
+	)
+	slots := make(chan struct{}, concurrency)
+	for i, localPath := range localPaths {
+		slots <- struct{}{}
+		mu.Lock()
+		stop := failed
+		mu.Unlock()
+		if stop {
+			<-slots
+			break
+		}
+		wg.Add(1)
+		go func(i int, localPath string) {
+			defer func() { <-slots; wg.Done() }()
+			result := uploadOne(ctx, provider, localPath, allFiles[localPath], files, attributes, roles, &opts, verbose)
+			mu.Lock()
+			defer mu.Unlock()
+			fileResults[i] = &result
+			if !result.Success {
+				if opts.FailPolicy == UploadFailPolicyWarn {
+					fmt.Fprintf(redact.Stderr, "[UPLOAD] Warning: failed to upload to %s: %s\n", result.Remote, result.Error)
+					return
+				}
+				failed = true
+			}
+		}(i, localPath)
+	}
+	wg.Wait()
+
+	// Files never started after a failure are left out, as before the failure
+	results := make([]output.UploadResult, 0, len(uploaded)+len(localPaths))
+	results = append(results, uploaded...)
+	var errs []error
+	for _, result := range fileResults {
+		if result == nil {
+			continue
+		}
+		results = append(results, *result)
+		if !result.Success && opts.FailPolicy != UploadFailPolicyWarn {
+			errs = append(errs, fmt.Errorf("failed to upload to %s: %s", result.Remote, result.Error))
+		}
+	}
+	if len(errs) == 0 {
+		return results, nil
+	}
+	if opts.FailPolicy == UploadFailPolicyCleanup {
+		cleanupUploads(ctx, provider, results, verbose)
+	}
+	return results, errors.Join(errs...)
+}
+
+// uploadOne uploads a single file of HandleUploads, skipping it if opts.IfAbsent
+// and it is already stored
+func uploadOne(ctx context.Context, provider upload.Provider, localPath, remotePath string, files map[string]string, attributes map[string]upload.Attributes, roles UploadRoles, opts *UploadOptions, verbose bool) output.UploadResult {
+	fileCompression := upload.CompressionNone
+	role := roles[localPath]
+	if _, standard := files[localPath]; standard {
+		fileCompression = opts.Compression
+	} else {
+		role = roles.additionalRole(localPath)
+	}
+	if opts.IfAbsent && storedAlready(ctx, provider, remotePath, verbose) {
+		result := output.UploadResult{Remote: remotePath, Role: role, Success: true, Skipped: true}
+		if info, err := os.Stat(localPath); err == nil {
+			result.Size = info.Size()
+		}
+		if verbose {
+			fmt.Fprintf(redact.Stderr, "[UPLOAD] Skipped %s: already stored\n", remotePath)
+		}
+		return result
+	}
+	result := uploadFile(ctx, provider, localPath, remotePath, fileCompression, attributes[localPath], opts, verbose)
+	result.Role = role
+	if result.Success && verbose {
+		fmt.Fprintf(redact.Stderr, "✓ Uploaded to: %s\n", remotePath)
+	}
+	return result
+}
+
+// storedAlready reports whether a file is stored at remotePath
+// If that cannot be checked the file is uploaded, so a flaky check never loses a file.
+func storedAlready(ctx context.Context, provider upload.Provider, remotePath string, verbose bool) bool {
+	exists, err := upload.Exists(ctx, provider.(upload.StatProvider), remotePath)
+	if err != nil {
+		fmt.Fprintf(redact.Stderr, "[UPLOAD] Warning: failed to check %s, uploading it: %v\n", remotePath, err)
+		return false
+	}
+	return exists
+}
+
+// cleanupUploads removes the files uploaded by this run, marking them deleted
+// Skipped files were stored before and are kept. Files that cannot be removed are
+// reported and left in place.
+func cleanupUploads(ctx context.Context, provider upload.Provider, results []output.UploadResult, verbose bool) {
+	deleter := provider.(upload.DeleteProvider)
+	for i := range results {
+		if !results[i].Success || results[i].Skipped {
+			continue
+		}
+		if err := deleter.Delete(ctx, results[i].Remote); err != nil {
+			fmt.Fprintf(redact.Stderr, "[UPLOAD] Warning: failed to remove %s: %v\n", results[i].Remote, redact.Error(err))
+			continue
+		}
+		results[i].Deleted = true
+		if verbose {
+			fmt.Fprintf(redact.Stderr, "[UPLOAD] Removed %s\n", results[i].Remote)
 		}
-		return nil
 	}
+}
 
-	ctx := context.Background()
-	for localPath, remotePath := range allFiles {
+// additionalRole returns the role of an additional file
+func (r UploadRoles) additionalRole(localPath string) string {
+	if role := r[localPath]; role != "" {
+		return role
+	}
+	return UploadRoleAdditional
+}
+
+// describeAttributes renders the explicit attributes of a file for dry run output
+func describeAttributes(attrs upload.Attributes) string {
+	var s string
+	if attrs.ContentType != "" {
+		s += ", type " + attrs.ContentType
+	}
+	if len(attrs.Metadata) > 0 {
+		s += ", metadata " + upload.FormatMetadata(attrs.Metadata)
+	}
+	return s
+}
+
+// uploadFile uploads a single file with retries and records the outcome
+// compression is the file's own: opts.Compression only applies to standard files.
+// Without an explicit content type, it is detected from the remote name
+// (before compression) or the start of the file. The bytes sent are throttled
+// by the options' limiter, and in verbose mode the progress is reported on stderr.
+func uploadFile(ctx context.Context, provider upload.Provider, localPath, remotePath, compression string, attrs upload.Attributes, opts *UploadOptions, verbose bool) output.UploadResult {
+	ctx, span := tracing.Tracer().Start(ctx, "upload")
+	defer span.End()
+
+	encryption, limiter, retryConfig := opts.Encryption, opts.limiter, opts.Retry
+	result := output.UploadResult{Remote: remotePath, Compression: compression}
+	if encryption != nil {
+		result.Encryption = encryption.Method
+		result.KeyFingerprint = encryption.Fingerprint
+	}
+	defer func() {
+		span.SetAttributes(
+			attribute.String("ghost.upload.provider", provider.Name()),
+			attribute.String("ghost.upload.remote", result.Remote),
+			attribute.Int64("ghost.upload.size", result.Size),
+			attribute.Int64("ghost.upload.compressed_size", result.CompressedSize),
+			attribute.String("ghost.upload.encryption", result.Encryption),
+			attribute.Int("ghost.upload.attempts", result.Attempts),
+			attribute.Bool("ghost.upload.success", result.Success),
+			attribute.String("ghost.upload.error_code", result.ErrorCode),
+		)
+		if !result.Success {
+			tracing.RecordError(span, fmt.Errorf("%s", result.Error))
+		}
+	}()
+
+	if info, err := os.Stat(localPath); err == nil {
+		result.Size = info.Size()
+	}
+	result.ContentType = attrs.ContentType
+	if result.ContentType == "" {
+		result.ContentType = upload.DetectContentType(strings.TrimSuffix(remotePath, upload.CompressedPath("", compression)), readHead(localPath))
+	}
+
+	start := time.Now()
+	send := func() error {
+		// Reopen the file for every attempt since a failed upload may have consumed the reader
 		reader, err := os.Open(localPath)
 		if err != nil {
 			return fmt.Errorf("failed to open %s for upload: %w", localPath, err)
 		}
 		defer func() { _ = reader.Close() }()
 
-		if err := provider.Upload(ctx, reader, remotePath); err != nil {
-			return fmt.Errorf("failed to upload to %s: %w", remotePath, err)
+		// Wrapping the file hides it from providers that read it in parallel,
+		// so it is only wrapped when needed
+		var src io.Reader = reader
+		if verbose {
+			src = &upload.ProgressReader{Reader: src, Total: result.Size, Interval: UploadProgressInterval, Report: func(p upload.Progress) {
+				printUploadProgress(remotePath, p)
+			}}
+		}
+
+		opts := upload.Options{Encryption: encryption, ContentType: result.ContentType, Metadata: attrs.Metadata}
+		if compression != upload.CompressionGzip {
+			// The size is only known up front for uncompressed files
+			opts.Size = result.Size
+			if limiter != nil {
+				src = &upload.ThrottledReader{Ctx: ctx, Reader: src, Limiter: limiter}
+			}
+			return upload.UploadWithOptions(ctx, provider, src, remotePath, opts)
 		}
 
+		// The limit applies to the compressed bytes that are sent
+		compressed := upload.GzipReader(src)
+		defer func() { _ = compressed.Close() }()
+		counter := &upload.CountingReader{Reader: &upload.ThrottledReader{Ctx: ctx, Reader: compressed, Limiter: limiter}}
+		opts.ContentEncoding = compression
+		if err := upload.UploadWithOptions(ctx, provider, counter, remotePath, opts); err != nil {
+			return err
+		}
+		result.CompressedSize = counter.N
+		return nil
+	}
+	attempts, err := retry.Do(ctx, retryConfig, func() error {
+		err := send()
+		if err == nil {
+			return nil
+		}
+		// Errors such as rejected credentials fail the same way on every attempt
+		result.ErrorCode = upload.ClassifyError(provider, err)
+		if !upload.IsTransient(result.ErrorCode) {
+			return retry.Permanent(err)
+		}
+		return err
+	}, func(attempt int, delay time.Duration, err error) {
 		if verbose {
-			fmt.Fprintf(os.Stderr, "✓ Uploaded to: %s\n", remotePath)
+			fmt.Fprintf(redact.Stderr, "[UPLOAD] Retry %d/%d for %s after %v: %v\n",
+				attempt, retryConfig.MaxRetries, remotePath, delay, err)
 		}
+	})
+	result.Duration = time.Since(start).Milliseconds()
+	result.Attempts = attempts
+
+	if err != nil {
+		result.Error = redact.Error(err)
+	} else {
+		result.Success = true
+		result.ErrorCode = ""
 	}
-	return nil
+	return result
+}
+
+// printUploadProgress reports the progress of an upload on stderr
+func printUploadProgress(remotePath string, p upload.Progress) {
+	transferred := bytesize.Format(p.Bytes)
+	if percent := p.Percent(); percent >= 0 {
+		transferred = fmt.Sprintf("%s / %s (%.0f%%)", transferred, bytesize.Format(p.Total), percent)
+	}
+	fmt.Fprintf(redact.Stderr, "[UPLOAD] %s: %s at %s/s\n", remotePath, transferred, bytesize.Format(int64(p.Rate())))
+}
+
+// readHead returns the first bytes of a file for content type detection
+func readHead(path string) []byte {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	return head[:n]
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 // PrintUploadInfo prints upload configuration in verbose mode
@@ -175,41 +869,52 @@ func PrintUploadInfo(provider upload.Provider, config map[string]any, outputPath
 	if dryRun {
 		header = "Upload Configuration (DRY RUN)"
 	}
-	fmt.Fprintln(os.Stderr, "========================================")
-	fmt.Fprintln(os.Stderr, header)
-	fmt.Fprintln(os.Stderr, "========================================")
-	fmt.Fprintf(os.Stderr, "Provider:       %s\n", provider.Name())
+	fmt.Fprintln(redact.Stderr, "========================================")
+	fmt.Fprintln(redact.Stderr, header)
+	fmt.Fprintln(redact.Stderr, "========================================")
+	fmt.Fprintf(redact.Stderr, "Provider:       %s\n", provider.Name())
 
 	// Print relevant config based on provider type
 	if provider.Name() == "minio" {
 		if endpoint, ok := config["endpoint"]; ok {
-			fmt.Fprintf(os.Stderr, "Endpoint:       %v\n", endpoint)
+			fmt.Fprintf(redact.Stderr, "Endpoint:       %v\n", endpoint)
 		}
 		if bucket, ok := config["bucket"]; ok {
-			fmt.Fprintf(os.Stderr, "Bucket:         %v\n", bucket)
+			fmt.Fprintf(redact.Stderr, "Bucket:         %v\n", bucket)
 		}
 		if prefix, ok := config["prefix"]; ok && prefix != "" {
-			fmt.Fprintf(os.Stderr, "Prefix:         %v\n", prefix)
+			fmt.Fprintf(redact.Stderr, "Prefix:         %v\n", prefix)
 		}
 		// Redact sensitive fields
 		if _, ok := config["access_key"]; ok {
-			fmt.Fprintf(os.Stderr, "Access Key:     ***REDACTED***\n")
+			fmt.Fprintf(redact.Stderr, "Access Key:     ***REDACTED***\n")
 		}
 		if _, ok := config["secret_key"]; ok {
-			fmt.Fprintf(os.Stderr, "Secret Key:     ***REDACTED***\n")
+			fmt.Fprintf(redact.Stderr, "Secret Key:     ***REDACTED***\n")
 		}
 	}
 
-	fmt.Fprintf(os.Stderr, "Output Path:    %s\n", outputPath)
-	fmt.Fprintf(os.Stderr, "Stderr Path:    %s\n", stderrPath)
+	fmt.Fprintf(redact.Stderr, "Output Path:    %s\n", outputPath)
+	fmt.Fprintf(redact.Stderr, "Stderr Path:    %s\n", stderrPath)
 
 	// Print additional files if any
 	if len(additionalFiles) > 0 {
-		fmt.Fprintln(os.Stderr, "Additional Files:")
+		fmt.Fprintln(redact.Stderr, "Additional Files:")
 		for localPath, remotePath := range additionalFiles {
-			fmt.Fprintf(os.Stderr, "  %s → %s\n", localPath, remotePath)
+			fmt.Fprintf(redact.Stderr, "  %s → %s\n", localPath, remotePath)
 		}
 	}
 
-	fmt.Fprintln(os.Stderr, "----------------------------------------")
+	fmt.Fprintln(redact.Stderr, "----------------------------------------")
+}
+
+// ValidateUploadTargets checks that local output targets can be read back for upload
+// Stream targets ("-", FIFOs, devices) are consumed by their reader and cannot be uploaded.
+func ValidateUploadTargets(paths ...string) error {
+	for _, path := range paths {
+		if runner.IsStreamTarget(path) {
+			return fmt.Errorf("cannot upload stream output target %s: use a regular file as the local path", path)
+		}
+	}
+	return nil
 }
@@ -0,0 +1,33 @@
+package helpers
+
+import (
+	"runtime/debug"
+)
+
+// Version is the ghost version, set by main from the release build flags
+var Version = "dev"
+
+// UserAgentHeader is the header identifying ghost to webhook receivers
+const UserAgentHeader = "User-Agent"
+
+// version returns the ghost version, falling back to the module version for
+// binaries installed with go install
+func version() string {
+	if Version != "dev" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return Version
+}
+
+// UserAgent returns the default User-Agent of webhook deliveries, such as
+// "ghost/v1.4.0 (run_id 3f2c...)"
+func UserAgent(runID string) string {
+	agent := "ghost/" + version()
+	if runID != "" {
+		agent += " (run_id " + runID + ")"
+	}
+	return agent
+}
@@ -1,22 +1,31 @@
 package helpers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/zinc-sig/ghost/cmd/config"
 	contextparser "github.com/zinc-sig/ghost/internal/context"
+	"github.com/zinc-sig/ghost/internal/redact"
+	"github.com/zinc-sig/ghost/internal/secret"
 	"github.com/zinc-sig/ghost/internal/webhook"
 )
 
 // Default webhook configuration constants
 const (
-	DefaultWebhookTimeout    = "30s"
-	DefaultWebhookRetryDelay = "1s"
-	DefaultWebhookRetries    = 3
-	DefaultWebhookMethod     = "POST"
-	DefaultWebhookAuthType   = "none"
-	WebhookRetryMultiplier   = 2.0
+	DefaultWebhookTimeout         = "30s"
+	DefaultWebhookRetryDelay      = "1s"
+	DefaultWebhookRetries         = 3
+	DefaultWebhookMethod          = "POST"
+	DefaultWebhookAuthType        = "none"
+	DefaultWebhookBreakerCooldown = "30s"
+	WebhookRetryMultiplier        = 2.0
 )
 
 // WebhookMaxRetryDelay is the maximum delay between retry attempts in exponential backoff
@@ -59,6 +68,9 @@ func BuildWebhookConfig(cfg *config.WebhookConfig) (map[string]any, error) {
 	if cfg.AuthToken != "" {
 		webhookConf["auth_token"] = cfg.AuthToken
 	}
+	if cfg.AuthHeader != "" {
+		webhookConf["auth_header"] = cfg.AuthHeader
+	}
 	if cfg.Timeout != "" && cfg.Timeout != DefaultWebhookTimeout {
 		webhookConf["timeout"] = cfg.Timeout
 	}
@@ -68,6 +80,67 @@ func BuildWebhookConfig(cfg *config.WebhookConfig) (map[string]any, error) {
 	if cfg.RetryDelay != "" && cfg.RetryDelay != DefaultWebhookRetryDelay {
 		webhookConf["retry_delay"] = cfg.RetryDelay
 	}
+	if cfg.RateLimit != 0 {
+		webhookConf["rate_limit"] = cfg.RateLimit
+	}
+	if len(cfg.Headers) > 0 {
+		// Flag headers are merged into the configured ones, replacing them by name
+		configured, err := parseHeaders(webhookConf["headers"])
+		if err != nil {
+			return nil, err
+		}
+		headers := map[string]any{}
+		for name, value := range configured {
+			headers[name] = value
+		}
+		for _, spec := range cfg.Headers {
+			name, value, err := webhook.ParseHeader(spec)
+			if err != nil {
+				return nil, err
+			}
+			headers[name] = value
+		}
+		webhookConf["headers"] = headers
+	}
+	if cfg.Proxy != "" {
+		webhookConf["proxy"] = cfg.Proxy
+	}
+	if cfg.DisableKeepAlives {
+		webhookConf["disable_keep_alives"] = true
+	}
+	if len(cfg.Events) > 0 {
+		webhookConf["events"] = cfg.Events
+	}
+	if len(cfg.ExpectStatus) > 0 {
+		webhookConf["expect_status"] = cfg.ExpectStatus
+	}
+	if cfg.CaptureResponse {
+		webhookConf["capture_response"] = true
+	}
+	if cfg.Async {
+		webhookConf["async"] = true
+	}
+	if cfg.SpoolDir != "" {
+		webhookConf["spool_dir"] = cfg.SpoolDir
+	}
+	if cfg.BreakerThreshold != 0 {
+		webhookConf["breaker_threshold"] = cfg.BreakerThreshold
+	}
+	if cfg.BreakerCooldown != "" && cfg.BreakerCooldown != DefaultWebhookBreakerCooldown {
+		webhookConf["breaker_cooldown"] = cfg.BreakerCooldown
+	}
+	if len(cfg.IncludeFields) > 0 {
+		webhookConf["include_fields"] = cfg.IncludeFields
+	}
+	if len(cfg.ExcludeFields) > 0 {
+		webhookConf["exclude_fields"] = cfg.ExcludeFields
+	}
+
+	// Secret references such as vault://... are resolved once every source is merged
+	if err := secret.ResolveConfig(context.Background(), webhookConf); err != nil {
+		return nil, fmt.Errorf("failed to build webhook config: %w", err)
+	}
+	redact.AddConfig(webhookConf)
 
 	return webhookConf, nil
 }
@@ -86,24 +159,35 @@ func ParseWebhookConfigToInternal(cfg *config.WebhookConfig) (*webhook.Config, *
 		return nil, nil, nil // No webhook configured
 	}
 
+	return parseWebhookConfigMap(configMap)
+}
+
+// parseWebhookConfigMap converts a webhook config map with a URL into internal structures
+// Every invalid setting is reported; multiple errors are joined.
+func parseWebhookConfigMap(configMap map[string]any) (*webhook.Config, *webhook.RetryConfig, error) {
+	var errs []error
+	url, _ := configMap["url"].(string)
+
 	// Parse webhook timeout
 	defaultTimeout, _ := time.ParseDuration(DefaultWebhookTimeout)
 	var webhookTimeoutDur = defaultTimeout
 	if timeout, ok := configMap["timeout"].(string); ok && timeout != "" {
-		webhookTimeoutDur, err = time.ParseDuration(timeout)
+		parsed, err := time.ParseDuration(timeout)
 		if err != nil {
-			return nil, nil, fmt.Errorf("invalid webhook timeout duration: %w", err)
+			errs = append(errs, fmt.Errorf("invalid webhook timeout duration: %w", err))
 		}
+		webhookTimeoutDur = parsed
 	}
 
 	// Parse retry delay
 	defaultRetryDelay, _ := time.ParseDuration(DefaultWebhookRetryDelay)
 	var retryDelay = defaultRetryDelay
 	if delay, ok := configMap["retry_delay"].(string); ok && delay != "" {
-		retryDelay, err = time.ParseDuration(delay)
+		parsed, err := time.ParseDuration(delay)
 		if err != nil {
-			return nil, nil, fmt.Errorf("invalid webhook retry delay: %w", err)
+			errs = append(errs, fmt.Errorf("invalid webhook retry delay: %w", err))
 		}
+		retryDelay = parsed
 	}
 
 	// Get HTTP method (default to POST)
@@ -118,6 +202,10 @@ func ParseWebhookConfigToInternal(cfg *config.WebhookConfig) (*webhook.Config, *
 		authType = DefaultWebhookAuthType
 	}
 	authToken, _ := configMap["auth_token"].(string)
+	authHeader, _ := configMap["auth_header"].(string)
+	if err := webhook.ValidateAuth(authType, authToken, authHeader); err != nil {
+		errs = append(errs, err)
+	}
 
 	// Get retries (handle both int and float64 from JSON)
 	maxRetries := DefaultWebhookRetries
@@ -127,12 +215,110 @@ func ParseWebhookConfigToInternal(cfg *config.WebhookConfig) (*webhook.Config, *
 		maxRetries = int(r)
 	}
 
+	// Get delivery rate limit (float64 from flags/JSON, string from kv/env)
+	rateLimit, err := parseRateLimit(configMap["rate_limit"])
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	// Get custom headers
+	headers, err := parseHeaders(configMap["headers"])
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	// Get transport settings
+	proxy, _ := configMap["proxy"].(string)
+	if err := webhook.ValidateProxyURL(proxy); err != nil {
+		errs = append(errs, err)
+	}
+	disableKeepAlives, err := parseBool(configMap["disable_keep_alives"])
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid webhook disable_keep_alives: %w", err))
+	}
+
+	// Get payload field filters
+	includeFields, err := parseFieldList(configMap["include_fields"])
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid webhook include_fields: %w", err))
+	}
+	excludeFields, err := parseFieldList(configMap["exclude_fields"])
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid webhook exclude_fields: %w", err))
+	}
+
+	// Get lifecycle events
+	events, err := parseStringList(configMap["events"])
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid webhook events: %w", err))
+	} else if err := webhook.ValidateEvents(events); err != nil {
+		errs = append(errs, err)
+	}
+
+	// Get response handling
+	expectStatus, err := parseStatusList(configMap["expect_status"])
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid webhook expect_status: %w", err))
+	}
+	captureResponse, err := parseBool(configMap["capture_response"])
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid webhook capture_response: %w", err))
+	}
+
+	// Get async delivery settings
+	async, err := parseBool(configMap["async"])
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid webhook async: %w", err))
+	}
+	spoolDir, _ := configMap["spool_dir"].(string)
+	if async && captureResponse {
+		errs = append(errs, fmt.Errorf("webhook capture_response cannot be used with async delivery"))
+	}
+
+	// Get circuit breaker settings
+	breakerThreshold, err := parseBreakerThreshold(configMap["breaker_threshold"])
+	if err != nil {
+		errs = append(errs, err)
+	}
+	breakerCooldown, _ := time.ParseDuration(DefaultWebhookBreakerCooldown)
+	if cooldown, ok := configMap["breaker_cooldown"].(string); ok && cooldown != "" {
+		parsed, err := time.ParseDuration(cooldown)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid webhook breaker cooldown: %w", err))
+		} else if parsed <= 0 {
+			errs = append(errs, fmt.Errorf("invalid webhook breaker cooldown %s: must be positive", cooldown))
+		}
+		breakerCooldown = parsed
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+
 	webhookConfig := &webhook.Config{
-		URL:       url,
-		Method:    method,
-		Timeout:   webhookTimeoutDur,
-		AuthType:  authType,
-		AuthToken: authToken,
+		URL:           url,
+		Method:        method,
+		Headers:       headers,
+		Timeout:       webhookTimeoutDur,
+		AuthType:      authType,
+		AuthToken:     authToken,
+		AuthHeader:    authHeader,
+		RateLimit:     rateLimit,
+		IncludeFields: includeFields,
+		ExcludeFields: excludeFields,
+		Events:        events,
+
+		ExpectStatus:    expectStatus,
+		CaptureResponse: captureResponse,
+
+		Async:    async,
+		SpoolDir: spoolDir,
+
+		BreakerThreshold: breakerThreshold,
+		BreakerCooldown:  breakerCooldown,
+
+		Proxy:             proxy,
+		DisableKeepAlives: disableKeepAlives,
 	}
 
 	retryConfig := &webhook.RetryConfig{
@@ -144,3 +330,247 @@ func ParseWebhookConfigToInternal(cfg *config.WebhookConfig) (*webhook.Config, *
 
 	return webhookConfig, retryConfig, nil
 }
+
+// webhookConfigKeys lists the keys understood in webhook configuration
+var webhookConfigKeys = map[string]bool{
+	"url": true, "method": true, "headers": true, "auth_type": true, "auth_token": true, "auth_header": true,
+	"timeout": true, "retries": true, "retry_delay": true, "rate_limit": true,
+	"proxy": true, "disable_keep_alives": true, "events": true,
+	"include_fields": true, "exclude_fields": true,
+	"expect_status": true, "capture_response": true,
+	"async": true, "spool_dir": true,
+	"breaker_threshold": true, "breaker_cooldown": true,
+}
+
+// ValidateWebhookConfig checks a webhook config map as loaded from a config file
+// Unknown keys and a missing URL are reported alongside invalid settings.
+func ValidateWebhookConfig(configMap map[string]any) []error {
+	var errs []error
+	keys := make([]string, 0, len(configMap))
+	for key := range configMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !webhookConfigKeys[key] {
+			errs = append(errs, fmt.Errorf("unknown webhook config key %q", key))
+		}
+	}
+
+	if url, _ := configMap["url"].(string); url == "" {
+		errs = append(errs, fmt.Errorf("webhook url is required"))
+	}
+	if _, _, err := parseWebhookConfigMap(configMap); err != nil {
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			errs = append(errs, joined.Unwrap()...)
+		} else {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// parseFieldList converts a field list from any config source into validated field paths
+// Flags provide a string slice, JSON config an array, and key-value or environment
+// sources a comma-separated string.
+func parseFieldList(value any) ([]string, error) {
+	fields, err := parseStringList(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := webhook.ValidateFieldPaths(fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// parseStringList converts a list from any config source, dropping empty entries
+func parseStringList(value any) ([]string, error) {
+	var raw []string
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []string:
+		raw = v
+	case []any:
+		for _, item := range v {
+			field, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("list entries must be strings, got %T", item)
+			}
+			raw = append(raw, field)
+		}
+	case string:
+		raw = strings.Split(v, ",")
+	default:
+		return nil, fmt.Errorf("expected a list, got %T", value)
+	}
+
+	var list []string
+	for _, entry := range raw {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list, nil
+}
+
+// parseStatusList converts HTTP status codes from any config source
+// Flags provide an int slice, JSON config a number or an array of numbers, and
+// key-value or environment sources a comma-separated string.
+func parseStatusList(value any) ([]int, error) {
+	var codes []int
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []int:
+		codes = v
+	case int:
+		codes = []int{v}
+	case float64:
+		return parseStatusList([]any{v})
+	case []any:
+		for _, item := range v {
+			n, ok := item.(float64)
+			if !ok || n != float64(int(n)) {
+				return nil, fmt.Errorf("status codes must be integers, got %v", item)
+			}
+			codes = append(codes, int(n))
+		}
+	case string:
+		entries, _ := parseStringList(v)
+		for _, entry := range entries {
+			n, err := strconv.Atoi(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid status code %q", entry)
+			}
+			codes = append(codes, n)
+		}
+	default:
+		return nil, fmt.Errorf("expected a list of status codes, got %T", value)
+	}
+
+	for _, code := range codes {
+		if code < 100 || code > 599 {
+			return nil, fmt.Errorf("status code %d out of range (100-599)", code)
+		}
+	}
+	return codes, nil
+}
+
+// parseHeaders converts custom headers from any config source
+// JSON config and dotted key-value pairs provide an object of names and values,
+// flags a list of "Name: value" entries, and environment sources a string with
+// one entry per line.
+func parseHeaders(value any) (map[string]string, error) {
+	headers := map[string]string{}
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		for name, raw := range v {
+			switch raw.(type) {
+			case string, float64, int, bool:
+				headers[http.CanonicalHeaderKey(name)] = fmt.Sprint(raw)
+			default:
+				return nil, fmt.Errorf("invalid webhook header %s: value must be a string, got %T", name, raw)
+			}
+		}
+	case string:
+		return parseHeaders(strings.Split(strings.TrimSpace(v), "\n"))
+	case []string:
+		for _, spec := range v {
+			name, value, err := webhook.ParseHeader(spec)
+			if err != nil {
+				return nil, err
+			}
+			headers[name] = value
+		}
+	case []any:
+		specs := make([]string, len(v))
+		for i, item := range v {
+			spec, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid webhook headers: entries must be strings, got %T", item)
+			}
+			specs[i] = spec
+		}
+		return parseHeaders(specs)
+	default:
+		return nil, fmt.Errorf("invalid webhook headers: expected an object, got %T", value)
+	}
+
+	if err := webhook.ValidateHeaders(headers); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+// parseRateLimit converts a rate limit from any config source into requests per second
+func parseRateLimit(value any) (float64, error) {
+	var limit float64
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		limit = v
+	case int:
+		limit = float64(v)
+	case string:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid webhook rate limit %q: %w", v, err)
+		}
+		limit = parsed
+	default:
+		return 0, fmt.Errorf("invalid webhook rate limit: expected a number, got %T", value)
+	}
+
+	if limit < 0 {
+		return 0, fmt.Errorf("invalid webhook rate limit %v: must not be negative", limit)
+	}
+	return limit, nil
+}
+
+// parseBreakerThreshold converts a circuit breaker threshold from any config source
+func parseBreakerThreshold(value any) (int, error) {
+	var threshold int
+	switch v := value.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		threshold = v
+	case float64:
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("invalid webhook breaker threshold %v: must be a whole number", v)
+		}
+		threshold = int(v)
+	case string:
+		parsed, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return 0, fmt.Errorf("invalid webhook breaker threshold %q: %w", v, err)
+		}
+		threshold = parsed
+	default:
+		return 0, fmt.Errorf("invalid webhook breaker threshold: expected a number, got %T", value)
+	}
+
+	if threshold < 0 {
+		return 0, fmt.Errorf("invalid webhook breaker threshold %d: must not be negative", threshold)
+	}
+	return threshold, nil
+}
+
+// parseBool converts a boolean from any config source (bool from flags/JSON, string from kv/env)
+func parseBool(value any) (bool, error) {
+	switch v := value.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	case string:
+		return strconv.ParseBool(strings.TrimSpace(v))
+	default:
+		return false, fmt.Errorf("expected a boolean, got %T", value)
+	}
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func resetHumanFlag() {
+	for _, c := range []string{"run", "diff"} {
+		cmd, _, _ := rootCmd.Find([]string{c})
+		resetFlags(cmd, "human")
+	}
+}
+
+func TestHumanOutput(t *testing.T) {
+	tmpDir := t.TempDir()
+	input := filepath.Join(tmpDir, "input.txt")
+	if err := os.WriteFile(input, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(tmpDir, "output.txt")
+	stderr := filepath.Join(tmpDir, "stderr.txt")
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "passing run",
+			args: []string{"run", "--human", "-i", input, "-o", output, "-e", stderr, "--score", "10", "--", "cat"},
+			want: []string{"PASS  cat  ", "  score     10\n", "  output    " + output + "\n"},
+		},
+		{
+			name: "failing run",
+			args: []string{"run", "--human", "-i", input, "-o", output, "-e", stderr, "--", "false"},
+			want: []string{"FAIL  false  ", "  exit code 1\n"},
+		},
+		{
+			name: "failing diff",
+			args: []string{"diff", "--human", "-i", input, "-x", stderr, "-o", output, "-e", filepath.Join(tmpDir, "diff_err.txt")},
+			want: []string{"FAIL  diff ", "  expected  " + stderr + "\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetHumanFlag()
+			defer resetHumanFlag()
+			// Output to a pipe is never colored, but make sure of it
+			t.Setenv("NO_COLOR", "1")
+			rootCmd.SetArgs(tt.args)
+
+			stdout, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if strings.HasPrefix(stdout, "{") || strings.Contains(stdout, "\033[") {
+				t.Errorf("Output is not a plain summary: %q", stdout)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("Output %q does not contain %q", stdout, want)
+				}
+			}
+		})
+	}
+}
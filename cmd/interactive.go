@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/config"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/policy"
+	"github.com/zinc-sig/ghost/internal/rubric"
+	"github.com/zinc-sig/ghost/internal/runner"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	// Command-specific I/O flags
+	interactiveInputFile    string
+	interactiveOutputFile   string
+	interactiveStderrFile   string
+	interactiveGrader       string
+	interactiveGraderStderr string
+
+	// Policy file restricting which commands may be executed
+	interactivePolicyFile string
+
+	// Common flag structures
+	interactiveFlags         config.CommonFlags
+	interactiveContextConfig config.ContextConfig
+	interactiveWebhookConfig config.WebhookConfig
+	interactiveFilterConfig  config.FilterConfig
+	interactiveCaptureConfig config.CaptureConfig
+)
+
+var interactiveCmd = &cobra.Command{
+	Use:   "interactive -i <input> -o <output> -e <stderr> --grader <command> [flags] -- <command> [args...]",
+	Short: "Judge a command that talks to a grader over its stdin and stdout",
+	Long: `Run the command together with a grader for interactive problems: the grader's
+stdout is piped to the command's stdin and the command's stdout to the grader's
+stdin, so they can exchange queries and answers.
+
+The grader reads the input file on descriptor 3 and accepts the command by exiting
+with 0. Anything else rejects it, and the last line the grader wrote to stderr is
+reported as its verdict (keep its whole stderr with --grader-stderr). The result
+status is "success" only if the command also exited as expected; --timeout applies
+to both processes. The grader command line is split on whitespace without shell
+interpretation.
+
+What the command writes to the grader is also captured in the output file, and
+its stderr in the stderr file. The result's "grader" field holds the grader's
+status, exit code, execution time and verdict.`,
+	Example: `  ghost interactive -i tests/1.in -o transcript.txt -e stderr.txt --grader ./interactor -- ./solution
+  ghost interactive -i tests/1.in -o transcript.txt -e stderr.txt --grader 'python3 grader.py' \
+    --grader-stderr grader.log --timeout 2s --score 10 -- python3 solution.py`,
+	RunE: interactiveCommand,
+}
+
+func interactiveCommand(cmd *cobra.Command, args []string) error {
+	// Webhook settings are parsed per invocation and passed to every delivery
+	webhookConfig, webhookRetryConfig, err := helpers.ParseWebhookConfigToInternal(&interactiveWebhookConfig)
+	if err != nil {
+		return failure.Wrap(failure.WebhookConfigInvalid, err)
+	}
+
+	// Validate command separator
+	if err := helpers.ValidateCommandSeparator(cmd, args); err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	// Setup tracing, the run ID and the result file for this invocation
+	ctx, exec, err := helpers.StartExecution(cmd, &interactiveFlags, webhookConfig, webhookRetryConfig)
+	if err != nil {
+		return err
+	}
+	defer exec.End()
+
+	// Validate required I/O flags
+	ioFlags := helpers.IOFlags{
+		Input:  interactiveInputFile,
+		Output: interactiveOutputFile,
+		Stderr: interactiveStderrFile,
+	}
+	if err := helpers.ValidateIOFlags(ioFlags, false); err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+	if !interactiveFlags.DryRun {
+		if err := helpers.CheckInputFile(ioFlags.Input); err != nil {
+			return err
+		}
+	}
+
+	grader := strings.Fields(interactiveGrader)
+	if len(grader) == 0 {
+		return failure.Wrap(failure.Usage, fmt.Errorf("--grader must not be empty"))
+	}
+	exec.Span.SetAttributes(attribute.String("ghost.grader", interactiveGrader))
+
+	// Load execution policy if provided; it applies to the command, not the grader
+	var execPolicy *policy.Policy
+	if interactivePolicyFile != "" {
+		execPolicy, err = policy.Load(interactivePolicyFile)
+		if err != nil {
+			return failure.Wrap(failure.ConfigInvalid, err)
+		}
+	}
+
+	// Line filters normalise the capture files as they are written
+	stdoutFilter, stderrFilter, err := helpers.ParseOutputFilters(&interactiveFilterConfig)
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	if err := helpers.ValidateCaptureConfig(&interactiveCaptureConfig, interactiveOutputFile, interactiveStderrFile, ""); err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	config := &runner.Config{
+		RunID:            exec.RunID,
+		Command:          args[0],
+		Args:             args[1:],
+		Grader:           &runner.Step{Command: grader[0], Args: grader[1:]},
+		GraderStderrFile: interactiveGraderStderr,
+		InputFile:        interactiveInputFile,
+		OutputFile:       interactiveOutputFile,
+		StderrFile:       interactiveStderrFile,
+		Verbose:          interactiveFlags.Verbose,
+		DryRun:           interactiveFlags.DryRun,
+		Timeout:          interactiveFlags.Timeout,
+		SoftTimeout:      interactiveFlags.SoftTimeout,
+
+		StdoutFilter: stdoutFilter,
+		StderrFilter: stderrFilter,
+		Append:       interactiveCaptureConfig.Append,
+		CombinedFile: interactiveCaptureConfig.Combined,
+
+		ExpectExitCode: helpers.ExpectedExitCode(&interactiveFlags),
+		ExpectNonzero:  interactiveFlags.ExpectNonzero,
+
+		Policy: execPolicy,
+	}
+
+	// Build context from all sources
+	if err := exec.BuildContext(&interactiveContextConfig); err != nil {
+		return err
+	}
+
+	// Lifecycle events let dashboards show in-progress executions
+	exec.SendStarted(ctx, config.FullCommand())
+
+	result, err := helpers.ExecuteWithSpan(ctx, config)
+	if err != nil {
+		return failure.Wrap(failure.ExecutionFailed, fmt.Errorf("failed to execute command: %w", err))
+	}
+	exec.SendTimeout(ctx, result)
+
+	var timeoutMs int64
+	if interactiveFlags.Timeout > 0 {
+		timeoutMs = interactiveFlags.Timeout.Milliseconds()
+	}
+	jsonResult := helpers.CreateJSONResult(
+		config.InputFile,
+		config.OutputFile,
+		config.StderrFile,
+		"", // No expected file for interactive command
+		result,
+		timeoutMs,
+		interactiveFlags.ScoreSet,
+		interactiveFlags.Score,
+		exec.Context,
+	)
+
+	jsonResult.Argv = args
+
+	return helpers.FinishExecution(ctx, exec, jsonResult, helpers.FinishOptions{
+		OutputFile: config.OutputFile,
+		StderrFile: config.StderrFile,
+		Plan: &output.Plan{
+			Command: result.Command,
+			Input:   config.InputFile,
+			Output:  config.OutputFile,
+			Stderr:  config.StderrFile,
+		},
+	})
+}
+
+func init() {
+	// Command-specific flags
+	interactiveCmd.Flags().StringVarP(&interactiveInputFile, "input", "i", "", "Input file the grader reads on descriptor 3 (required)")
+	interactiveCmd.Flags().StringVarP(&interactiveOutputFile, "output", "o", "", "Output file to capture what the command writes to the grader (required)")
+	interactiveCmd.Flags().StringVarP(&interactiveStderrFile, "stderr", "e", "", "Error file to capture the command's stderr (required)")
+	interactiveCmd.Flags().StringVar(&interactiveGrader, "grader", "", "Grader talking to the command over its stdin and stdout; exit code 0 accepts the command (required)")
+	interactiveCmd.Flags().StringVar(&interactiveGraderStderr, "grader-stderr", "", "Capture the grader's stderr in this file (default: only its last line is kept as the verdict)")
+	interactiveCmd.Flags().StringVar(&interactivePolicyFile, "policy-file", "", "Policy file restricting which commands may be executed (not checked for the grader)")
+
+	// Mark flags as required
+	_ = interactiveCmd.MarkFlagRequired("input")
+	_ = interactiveCmd.MarkFlagRequired("output")
+	_ = interactiveCmd.MarkFlagRequired("stderr")
+	_ = interactiveCmd.MarkFlagRequired("grader")
+
+	// Setup common flags using helper
+	helpers.SetupCommonFlags(interactiveCmd, &interactiveFlags)
+	helpers.SetupExpectFlags(interactiveCmd, &interactiveFlags)
+	helpers.SetupContextFlags(interactiveCmd, &interactiveContextConfig)
+	helpers.SetupWebhookFlags(interactiveCmd, &interactiveWebhookConfig)
+	helpers.SetupFilterFlags(interactiveCmd, &interactiveFilterConfig)
+	helpers.SetupCaptureFlags(interactiveCmd, &interactiveCaptureConfig)
+
+	interactiveCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		interactiveFlags.ScoreSet = cmd.Flags().Changed("score")
+		interactiveFlags.RecordEnvSet = cmd.Flags().Changed("record-env")
+		interactiveFlags.ExpectExitCodeSet = cmd.Flags().Changed("expect-exit-code")
+		if err := helpers.ValidateExpectExitCode(&interactiveFlags); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+
+		// The grader's input descriptor cannot be passed on Windows
+		if runtime.GOOS == "windows" {
+			return failure.Wrap(failure.Usage, fmt.Errorf("ghost interactive is not supported on %s", runtime.GOOS))
+		}
+
+		// Validate score expression early
+		if interactiveFlags.ScoreExpr != "" {
+			if _, err := helpers.ParseScoreExpression(interactiveFlags.ScoreExpr); err != nil {
+				return failure.Wrap(failure.Usage, err)
+			}
+		}
+		if interactiveFlags.Rubric != "" {
+			if _, err := rubric.Load(interactiveFlags.Rubric); err != nil {
+				return failure.Wrap(failure.ConfigInvalid, err)
+			}
+		}
+
+		// Validate preview sizes
+		if err := helpers.ValidateEmbedFlags(&interactiveFlags); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+
+		// Parse timeout if provided
+		var err error
+		interactiveFlags.Timeout, err = helpers.ParseTimeout(interactiveFlags.TimeoutStr)
+		if err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		interactiveFlags.SoftTimeout, err = helpers.ParseSoftTimeout(interactiveFlags.SoftTimeoutStr, interactiveFlags.Timeout)
+		if err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		interactiveFlags.ResultSchema, err = helpers.ParseResultSchema(interactiveFlags.ResultSchemaStr)
+		return failure.Wrap(failure.Usage, err)
+	}
+}
@@ -0,0 +1,113 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/failure"
+)
+
+// resetInteractiveFlags clears the interactive flags so they don't leak between tests
+func resetInteractiveFlags() {
+	resetFlags(interactiveCmd, "input", "output", "stderr", "grader", "grader-stderr", "timeout", "score")
+	interactiveFlags.Timeout = 0
+}
+
+// guessGrader answers the guesses of the command with lower, higher or correct
+// until it finds the number read on descriptor 3
+const guessGrader = `read secret <&3
+guesses=0
+while read guess; do
+  guesses=$((guesses + 1))
+  if [ "$guess" -lt "$secret" ]; then echo higher
+  elif [ "$guess" -gt "$secret" ]; then echo lower
+  else echo correct; echo "found in $guesses guesses" >&2; exit 0
+  fi
+done
+echo "gave up after $guesses guesses" >&2
+exit 1
+`
+
+// binarySearch guesses a number from 1 to 100
+const binarySearch = `lo=1; hi=100
+while :; do
+  mid=$(((lo + hi) / 2)); echo $mid; read reply
+  case $reply in
+    higher) lo=$((mid + 1)) ;;
+    lower) hi=$((mid - 1)) ;;
+    *) exit 0 ;;
+  esac
+done`
+
+func TestInteractiveCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		solution    string
+		grader      string // "" = guessGrader
+		wantStatus  string
+		wantScore   string
+		wantGrader  string
+		wantVerdict string
+		wantCode    failure.Code
+	}{
+		{name: "accepted", solution: binarySearch, wantStatus: "success", wantScore: "10", wantGrader: "success", wantVerdict: "found in 7 guesses"},
+		{name: "rejected", solution: "echo 1; read reply", wantStatus: "failed", wantScore: "0", wantGrader: "failed", wantVerdict: "gave up after 1 guesses"},
+		{name: "empty grader", solution: binarySearch, grader: " ", wantCode: failure.Usage},
+		{name: "missing grader", solution: binarySearch, grader: "ghost-no-such-grader", wantCode: failure.ExecutionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetInteractiveFlags()
+			defer resetInteractiveFlags()
+
+			dir := t.TempDir()
+			input := filepath.Join(dir, "input.txt")
+			graderScript := filepath.Join(dir, "grader.sh")
+			_ = os.WriteFile(input, []byte("58\n"), 0644)
+			_ = os.WriteFile(graderScript, []byte(guessGrader), 0644)
+			grader := tt.grader
+			if grader == "" {
+				grader = "sh " + graderScript
+			}
+			rootCmd.SetArgs([]string{"interactive", "-i", input, "-o", filepath.Join(dir, "output.txt"), "-e", filepath.Join(dir, "stderr.txt"),
+				"--grader", grader, "--timeout", "5s", "--score", "10", "--", "sh", "-c", tt.solution})
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantCode != "" {
+				if failure.CodeOf(err) != tt.wantCode {
+					t.Errorf("Error = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result struct {
+				Status string `json:"status"`
+				Score  string `json:"score"`
+				Grader struct {
+					Command string `json:"command"`
+					Status  string `json:"status"`
+					Verdict string `json:"verdict"`
+				} `json:"grader"`
+			}
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if result.Status != tt.wantStatus || result.Score != tt.wantScore {
+				t.Errorf("Status = %s with score %s, want %s with score %s", result.Status, result.Score, tt.wantStatus, tt.wantScore)
+			}
+			if result.Grader.Command != grader || result.Grader.Status != tt.wantGrader || result.Grader.Verdict != tt.wantVerdict {
+				t.Errorf("Grader = %+v, want %q %s with verdict %q", result.Grader, grader, tt.wantGrader, tt.wantVerdict)
+			}
+		})
+	}
+}
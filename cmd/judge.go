@@ -0,0 +1,285 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/config"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+	"github.com/zinc-sig/ghost/internal/compare"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/rubric"
+	"github.com/zinc-sig/ghost/internal/runner"
+)
+
+var (
+	// Command-specific I/O flags
+	judgeInputFile       string
+	judgeOutputFile      string
+	judgeStderrFile      string
+	judgeReference       string
+	judgeReferenceOutput string
+	judgeDiffOutput      string
+	judgeDiffFlags       string
+
+	// Common flag structures
+	judgeFlags         config.CommonFlags
+	judgeContextConfig config.ContextConfig
+	judgeWebhookConfig config.WebhookConfig
+)
+
+var judgeCmd = &cobra.Command{
+	Use:   "judge -i <input> -o <output> -e <stderr> --reference <command> [flags] -- <command> [args...]",
+	Short: "Judge a command against a reference solution on the same input",
+	Long: `Run a reference solution and the command on the same input and compare their
+outputs, so no expected file has to be prepared for the input.
+
+The reference's output is the expected output. The command passes if it succeeds
+and its output matches; otherwise the result status is "failed" (or "timeout").
+The reference command line is split on whitespace without shell interpretation,
+and must itself succeed: a failing reference is reported as an error rather than
+blamed on the command.
+
+Outputs are compared with the built-in engine; --diff-flags accepts the flags it
+supports (e.g. -w or --ignore-trailing-space). The reference output is kept with
+--reference-output and the unified diff with --diff-output.`,
+	Example: `  ghost judge -i input.txt -o output.txt -e stderr.txt --reference ./solution -- ./student
+  ghost judge -i input.txt -o output.txt -e stderr.txt --reference 'python3 ref.py' \
+    --diff-flags -w --diff-output diff.txt --score 10 -- python3 student.py`,
+	RunE: judgeCommand,
+}
+
+func judgeCommand(cmd *cobra.Command, args []string) error {
+	// Webhook settings are parsed per invocation and passed to every delivery
+	webhookConfig, webhookRetryConfig, err := helpers.ParseWebhookConfigToInternal(&judgeWebhookConfig)
+	if err != nil {
+		return failure.Wrap(failure.WebhookConfigInvalid, err)
+	}
+
+	// Validate command separator
+	if err := helpers.ValidateCommandSeparator(cmd, args); err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	// Setup tracing, the run ID and the result file for this invocation
+	ctx, exec, err := helpers.StartExecution(cmd, &judgeFlags, webhookConfig, webhookRetryConfig)
+	if err != nil {
+		return err
+	}
+	defer exec.End()
+
+	// Validate required I/O flags
+	ioFlags := helpers.IOFlags{
+		Input:  judgeInputFile,
+		Output: judgeOutputFile,
+		Stderr: judgeStderrFile,
+	}
+	if err := helpers.ValidateIOFlags(ioFlags, false); err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+	if !judgeFlags.DryRun {
+		if err := helpers.CheckInputFile(ioFlags.Input); err != nil {
+			return err
+		}
+	}
+
+	reference := strings.Fields(judgeReference)
+	if len(reference) == 0 {
+		return failure.Wrap(failure.Usage, fmt.Errorf("--reference must not be empty"))
+	}
+	textOptions, err := compare.ParseTextOptions(strings.Fields(judgeDiffFlags))
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	// The reference output is only kept when asked for
+	referenceOutput := judgeReferenceOutput
+	if referenceOutput == "" && !judgeFlags.DryRun {
+		tempOut, err := os.CreateTemp("", "ghost-judge-reference-*.txt")
+		if err != nil {
+			return fmt.Errorf("failed to create temp reference output file: %w", err)
+		}
+		referenceOutput = tempOut.Name()
+		_ = tempOut.Close()
+		defer func() { _ = os.Remove(referenceOutput) }()
+	}
+
+	referenceConfig := &runner.Config{
+		RunID:       exec.RunID,
+		Command:     reference[0],
+		Args:        reference[1:],
+		InputFile:   judgeInputFile,
+		OutputFile:  referenceOutput,
+		StderrFile:  os.DevNull,
+		Verbose:     judgeFlags.Verbose,
+		DryRun:      judgeFlags.DryRun,
+		Timeout:     judgeFlags.Timeout,
+		SoftTimeout: judgeFlags.SoftTimeout,
+	}
+
+	config := &runner.Config{
+		RunID:       exec.RunID,
+		Command:     args[0],
+		Args:        args[1:],
+		InputFile:   judgeInputFile,
+		OutputFile:  judgeOutputFile,
+		StderrFile:  judgeStderrFile,
+		Verbose:     judgeFlags.Verbose,
+		DryRun:      judgeFlags.DryRun,
+		Timeout:     judgeFlags.Timeout,
+		SoftTimeout: judgeFlags.SoftTimeout,
+
+		ExpectExitCode: helpers.ExpectedExitCode(&judgeFlags),
+		ExpectNonzero:  judgeFlags.ExpectNonzero,
+	}
+
+	// Build context from all sources
+	if err := exec.BuildContext(&judgeContextConfig); err != nil {
+		return err
+	}
+
+	// Lifecycle events let dashboards show in-progress executions
+	exec.SendStarted(ctx, config.FullCommand())
+
+	// The reference runs first: without its output there is nothing to judge against
+	referenceResult, err := helpers.ExecuteWithSpan(ctx, referenceConfig)
+	if err != nil {
+		return failure.Wrap(failure.ExecutionFailed, fmt.Errorf("failed to execute reference: %w", err))
+	}
+	if !judgeFlags.DryRun && referenceResult.Status != runner.StatusSuccess {
+		return failure.Wrap(failure.ExecutionFailed, fmt.Errorf("reference command %s (exit code %d)", referenceResult.Status, referenceResult.ExitCode))
+	}
+
+	result, err := helpers.ExecuteWithSpan(ctx, config)
+	if err != nil {
+		return failure.Wrap(failure.ExecutionFailed, fmt.Errorf("failed to execute command: %w", err))
+	}
+	exec.SendTimeout(ctx, result)
+
+	// A successful run still fails when its output differs from the reference
+	if !judgeFlags.DryRun && result.Status == runner.StatusSuccess {
+		equal, err := judgeOutputs(ctx, judgeOutputFile, referenceOutput, textOptions)
+		if err != nil {
+			return err
+		}
+		if !equal {
+			result.Status = runner.StatusFailed
+		}
+	}
+
+	var timeoutMs int64
+	if judgeFlags.Timeout > 0 {
+		timeoutMs = judgeFlags.Timeout.Milliseconds()
+	}
+	jsonResult := helpers.CreateJSONResult(
+		config.InputFile,
+		config.OutputFile,
+		config.StderrFile,
+		judgeReferenceOutput, // only reported when the reference output is kept
+		result,
+		timeoutMs,
+		judgeFlags.ScoreSet,
+		judgeFlags.Score,
+		exec.Context,
+	)
+	jsonResult.Reference = referenceResult.Command
+
+	return helpers.FinishExecution(ctx, exec, jsonResult, helpers.FinishOptions{
+		OutputFile: config.OutputFile,
+		StderrFile: config.StderrFile,
+		Plan: &output.Plan{
+			Command:  result.Command,
+			Input:    config.InputFile,
+			Output:   config.OutputFile,
+			Stderr:   config.StderrFile,
+			Expected: judgeReferenceOutput,
+		},
+	})
+}
+
+// judgeOutputs compares the command's output with the reference output
+// The unified diff is written to --diff-output when set.
+func judgeOutputs(ctx context.Context, outputFile, referenceOutput string, textOptions compare.TextOptions) (bool, error) {
+	var w io.Writer = io.Discard
+	if judgeDiffOutput != "" {
+		diff, err := os.Create(judgeDiffOutput)
+		if err != nil {
+			return false, fmt.Errorf("failed to create diff output file: %w", err)
+		}
+		defer func() { _ = diff.Close() }()
+		w = diff
+	}
+
+	equal, err := compare.DiffFiles(ctx, w, outputFile, referenceOutput, textOptions)
+	if err != nil {
+		return false, fmt.Errorf("failed to compare with reference output: %w", err)
+	}
+	return equal, nil
+}
+
+func init() {
+	// Command-specific flags
+	judgeCmd.Flags().StringVarP(&judgeInputFile, "input", "i", "", "Input file given to both the reference and the command (required)")
+	judgeCmd.Flags().StringVarP(&judgeOutputFile, "output", "o", "", "Output file to capture the command's stdout (required)")
+	judgeCmd.Flags().StringVarP(&judgeStderrFile, "stderr", "e", "", "Error file to capture the command's stderr (required)")
+	judgeCmd.Flags().StringVar(&judgeReference, "reference", "", "Reference solution whose output is expected (required)")
+	judgeCmd.Flags().StringVar(&judgeReferenceOutput, "reference-output", "", "Keep the reference output in this file (default: temporary)")
+	judgeCmd.Flags().StringVar(&judgeDiffOutput, "diff-output", "", "Write the unified diff against the reference output to this file")
+	judgeCmd.Flags().StringVar(&judgeDiffFlags, "diff-flags", "", "Comparison flags of the built-in engine (e.g. \"-w -B\")")
+
+	// Mark flags as required
+	_ = judgeCmd.MarkFlagRequired("input")
+	_ = judgeCmd.MarkFlagRequired("output")
+	_ = judgeCmd.MarkFlagRequired("stderr")
+	_ = judgeCmd.MarkFlagRequired("reference")
+
+	// Setup common flags using helper
+	helpers.SetupCommonFlags(judgeCmd, &judgeFlags)
+	helpers.SetupExpectFlags(judgeCmd, &judgeFlags)
+	helpers.SetupContextFlags(judgeCmd, &judgeContextConfig)
+	helpers.SetupWebhookFlags(judgeCmd, &judgeWebhookConfig)
+
+	judgeCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		judgeFlags.ScoreSet = cmd.Flags().Changed("score")
+		judgeFlags.RecordEnvSet = cmd.Flags().Changed("record-env")
+		judgeFlags.ExpectExitCodeSet = cmd.Flags().Changed("expect-exit-code")
+		if err := helpers.ValidateExpectExitCode(&judgeFlags); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+
+		// Validate score expression early
+		if judgeFlags.ScoreExpr != "" {
+			if _, err := helpers.ParseScoreExpression(judgeFlags.ScoreExpr); err != nil {
+				return failure.Wrap(failure.Usage, err)
+			}
+		}
+		if judgeFlags.Rubric != "" {
+			if _, err := rubric.Load(judgeFlags.Rubric); err != nil {
+				return failure.Wrap(failure.ConfigInvalid, err)
+			}
+		}
+
+		// Validate preview sizes
+		if err := helpers.ValidateEmbedFlags(&judgeFlags); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+
+		// Parse timeout if provided
+		var err error
+		judgeFlags.Timeout, err = helpers.ParseTimeout(judgeFlags.TimeoutStr)
+		if err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		judgeFlags.SoftTimeout, err = helpers.ParseSoftTimeout(judgeFlags.SoftTimeoutStr, judgeFlags.Timeout)
+		if err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		judgeFlags.ResultSchema, err = helpers.ParseResultSchema(judgeFlags.ResultSchemaStr)
+		return failure.Wrap(failure.Usage, err)
+	}
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/failure"
+)
+
+// resetJudgeFlags clears the judge flags so they don't leak between tests
+func resetJudgeFlags() {
+	resetFlags(judgeCmd, "input", "output", "stderr", "reference", "reference-output", "diff-output", "diff-flags", "timeout", "score")
+	judgeFlags.Timeout = 0
+}
+
+func TestJudgeCommand(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		reference  string
+		flags      []string
+		command    []string
+		wantStatus string
+		wantScore  string
+		wantDiff   string
+		wantCode   failure.Code
+	}{
+		{
+			name:       "matching output",
+			input:      "b\na\n",
+			reference:  "sort",
+			flags:      []string{"--score", "10"},
+			command:    []string{"sh", "-c", "sort"},
+			wantStatus: "success",
+			wantScore:  "10",
+		},
+		{
+			name:       "different output",
+			input:      "b\na\n",
+			reference:  "sort",
+			flags:      []string{"--score", "10"},
+			command:    []string{"cat"},
+			wantStatus: "failed",
+			wantScore:  "0",
+			wantDiff:   "-b\n",
+		},
+		{
+			name:       "diff flags",
+			input:      "a\n",
+			reference:  "cat",
+			flags:      []string{"--diff-flags", "--ignore-trailing-space"},
+			command:    []string{"sed", "s/$/  /"},
+			wantStatus: "success",
+		},
+		{
+			name:       "failing command",
+			input:      "a\n",
+			reference:  "cat",
+			command:    []string{"sh", "-c", "cat; exit 2"},
+			wantStatus: "failed",
+		},
+		{
+			name:      "failing reference",
+			input:     "a\n",
+			reference: "false",
+			command:   []string{"cat"},
+			wantCode:  failure.ExecutionFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetJudgeFlags()
+			defer resetJudgeFlags()
+
+			dir := t.TempDir()
+			input := filepath.Join(dir, "input.txt")
+			if err := os.WriteFile(input, []byte(tt.input), 0644); err != nil {
+				t.Fatal(err)
+			}
+			diffPath := filepath.Join(dir, "diff.txt")
+			referencePath := filepath.Join(dir, "reference.txt")
+			args := []string{"judge", "-i", input, "-o", filepath.Join(dir, "output.txt"), "-e", filepath.Join(dir, "stderr.txt"),
+				"--reference", tt.reference, "--reference-output", referencePath, "--diff-output", diffPath}
+			args = append(args, tt.flags...)
+			rootCmd.SetArgs(append(append(args, "--"), tt.command...))
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantCode != "" {
+				if failure.CodeOf(err) != tt.wantCode {
+					t.Errorf("Error = %v, want %s", err, tt.wantCode)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result struct {
+				Status    string `json:"status"`
+				Score     string `json:"score"`
+				Expected  string `json:"expected"`
+				Reference string `json:"reference"`
+			}
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			if tt.wantScore != "" && result.Score != tt.wantScore {
+				t.Errorf("Score = %s, want %s", result.Score, tt.wantScore)
+			}
+			if result.Reference != tt.reference || result.Expected != referencePath {
+				t.Errorf("reference = %q, expected = %q, want %q and %q", result.Reference, result.Expected, tt.reference, referencePath)
+			}
+			if tt.wantDiff != "" {
+				diff, err := os.ReadFile(diffPath)
+				if err != nil || !strings.Contains(string(diff), tt.wantDiff) {
+					t.Errorf("diff = %q (%v), want it to contain %q", diff, err, tt.wantDiff)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/failure"
+)
+
+func resetLogSinkFlags() {
+	resetFlags(runCmd, "log-sink")
+}
+
+func TestRunCommandLogSinkInvalid(t *testing.T) {
+	resetLogSinkFlags()
+	defer resetLogSinkFlags()
+
+	dir := t.TempDir()
+	rootCmd.SetArgs([]string{"run", "-i", "/dev/null", "-o", filepath.Join(dir, "output.txt"), "-e", filepath.Join(dir, "stderr.txt"),
+		"--log-sink", "syslog,kafka", "--", "true"})
+
+	_, err := captureOutput(func() error {
+		return rootCmd.Execute()
+	})
+	if failure.CodeOf(err) != failure.Usage || !strings.Contains(err.Error(), `unknown log sink "kafka"`) {
+		t.Errorf("Error = %v, want a usage error for the unknown sink", err)
+	}
+}
@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/config"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+	"github.com/zinc-sig/ghost/internal/compare"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/manifest"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/progress"
+	"github.com/zinc-sig/ghost/internal/redact"
+	"github.com/zinc-sig/ghost/internal/runner"
+)
+
+// Files of every case of a manifest run, in <work dir>/<case number>/
+const (
+	manifestOutputFile = "output.txt"
+	manifestStderrFile = "stderr.txt"
+	manifestDiffFile   = "diff.txt"
+)
+
+var (
+	manifestShard         string
+	manifestWorkDir       string
+	manifestStopOnFailure bool
+	manifestVerbose       bool
+	manifestProgress      config.ProgressConfig
+	manifestWebhookConfig config.WebhookConfig
+)
+
+var manifestCmd = &cobra.Command{
+	Use:   "manifest",
+	Short: "Work with case manifests",
+}
+
+var manifestExpandCmd = &cobra.Command{
+	Use:   "expand [--shard <index>/<count>] <manifest>",
+	Short: "Print the concrete cases of a manifest, optionally one shard of them",
+	Long: `Expand the matrices of a case manifest, apply its defaults, and print the
+resulting cases as JSON for a batch job to grade.
+
+--shard index/count selects the cases of one of count parallel jobs. Cases are
+assigned to shards by a stable hash of their name, so each CI job can take its
+share of a large manifest without a coordinator: the shards 1/count to
+count/count together contain every case exactly once, and adding or removing a
+case does not move the others to another shard.`,
+	Example: `  ghost manifest expand cases.yaml
+  ghost manifest expand --shard 3/10 cases.yaml | jq -c '.cases[]'`,
+	Args: cobra.ExactArgs(1),
+	RunE: manifestExpandCommand,
+}
+
+var manifestRunCmd = &cobra.Command{
+	Use:   "run [--shard <index>/<count>] <manifest>",
+	Short: "Grade the cases of a manifest, optionally one shard of them",
+	Long: `Expand a case manifest like manifest expand and grade every case: run its
+command on its input, and compare the output with its expected output if it has
+one. Paths in the manifest are relative to the working directory; a case without
+an input reads /dev/null.
+
+Each case uses its own settings, falling back to the manifest's defaults:
+  timeout           kills the command after this long (status timeout)
+  expect_exit_code  the exit code that counts as success (default 0)
+  diff_flags        comparison flags of the built-in engine (e.g. "-w -B")
+  weight            the score of a passing case (default 1); failing cases score 0
+
+The files of every case (output.txt, stderr.txt, diff.txt) are written to
+<work dir>/<case number>/. Without --work-dir a temporary directory is used,
+which is removed unless a case failed.
+
+With a webhook, the result of every case is delivered as it finishes. Use
+--webhook-breaker-threshold so a dead endpoint is skipped after a few failures
+instead of adding its retries to every case; the skipped deliveries are spooled
+for ghost webhook flush.
+
+Results are written as JSON with the result of every case and the total score.`,
+	Example: `  ghost manifest run cases.yaml
+  ghost manifest run --shard 3/10 --work-dir grading/ cases.yaml
+  ghost manifest run --webhook-url https://grades.example.com/hook --webhook-breaker-threshold 5 cases.yaml`,
+	Args: cobra.ExactArgs(1),
+	RunE: manifestRunCommand,
+}
+
+// loadManifestCases loads and expands a manifest, returning all of its cases
+// and the cases of the --shard
+func loadManifestCases(path string) (all, selected []manifest.Case, shard *manifest.Shard, err error) {
+	if manifestShard != "" {
+		parsed, err := manifest.ParseShard(manifestShard)
+		if err != nil {
+			return nil, nil, nil, failure.Wrap(failure.Usage, err)
+		}
+		shard = &parsed
+	}
+
+	m, err := manifest.Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, nil, failure.Wrap(failure.InputNotFound, err)
+	}
+	if err != nil {
+		return nil, nil, nil, failure.Wrap(failure.ConfigInvalid, err)
+	}
+	all, err = m.Expand()
+	if err != nil {
+		return nil, nil, nil, failure.Wrap(failure.ConfigInvalid, fmt.Errorf("invalid manifest %s: %w", path, err))
+	}
+	if all == nil {
+		all = []manifest.Case{}
+	}
+
+	selected = all
+	if shard != nil {
+		selected = shard.Select(all)
+	}
+	return all, selected, shard, nil
+}
+
+func manifestExpandCommand(cmd *cobra.Command, args []string) error {
+	cases, selected, shard, err := loadManifestCases(args[0])
+	if err != nil {
+		return err
+	}
+
+	report := &manifest.Expansion{
+		Command:  "manifest expand",
+		Manifest: args[0],
+		Total:    len(cases),
+		Cases:    selected,
+	}
+	if shard != nil {
+		report.Shard = shard.String()
+	}
+	return helpers.PrintJSON(report)
+}
+
+func manifestRunCommand(cmd *cobra.Command, args []string) error {
+	webhookConfig, webhookRetryConfig, err := helpers.ParseWebhookConfigToInternal(&manifestWebhookConfig)
+	if err != nil {
+		return failure.Wrap(failure.WebhookConfigInvalid, err)
+	}
+	cases, selected, shard, err := loadManifestCases(args[0])
+	if err != nil {
+		return err
+	}
+	reporter, err := progress.Open(manifestProgress.Format, manifestProgress.FD, "manifest run", len(selected))
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	workDir := manifestWorkDir
+	if workDir == "" {
+		workDir, err = os.MkdirTemp("", "ghost-manifest-*")
+		if err != nil {
+			return fmt.Errorf("failed to create work directory: %w", err)
+		}
+	} else if err := os.MkdirAll(workDir, 0755); err != nil {
+		return fmt.Errorf("failed to create work directory: %w", err)
+	}
+
+	report := &output.ManifestRun{
+		Command:  "manifest run",
+		Manifest: args[0],
+		Status:   "success",
+		Total:    len(cases),
+		WorkDir:  workDir,
+		Cases:    make([]output.ManifestResult, 0, len(selected)),
+	}
+	if shard != nil {
+		report.Shard = shard.String()
+	}
+
+	ctx := helpers.CommandContext(cmd)
+	reporter.Start()
+	for i, c := range selected {
+		weight := 1.0
+		if c.Weight != nil {
+			weight = *c.Weight
+		}
+		result, err := runManifestCase(ctx, c, weight, filepath.Join(workDir, strconv.Itoa(i+1)))
+		if err != nil {
+			reporter.Finish("error")
+			return err
+		}
+		if result.RunID, err = helpers.ResolveRunID(""); err != nil {
+			reporter.Finish("error")
+			return err
+		}
+		// Deliveries share the circuit breaker, so a dead endpoint only slows the first cases
+		helpers.DeliverResult(ctx, webhookConfig, webhookRetryConfig, result, manifestVerbose, false)
+
+		report.Cases = append(report.Cases, output.ManifestResult{Name: c.Name, Weight: weight, Result: result})
+		report.Score = report.Score.Add(*result.Score)
+		report.MaxScore = report.MaxScore.Add(decimal.NewFromFloat(weight))
+		passed := result.Status == string(runner.StatusSuccess)
+		if manifestVerbose {
+			fmt.Fprintf(redact.Stderr, "[MANIFEST] Case %s: %s\n", c.Name, result.Status)
+		}
+		reporter.CaseDone(c.Name, result.Status, passed)
+
+		if passed {
+			report.Passed++
+			continue
+		}
+		report.Failed++
+		if manifestStopOnFailure {
+			break
+		}
+	}
+
+	if report.Failed > 0 {
+		report.Status = "failed"
+	} else if manifestWorkDir == "" {
+		// Nothing worth inspecting
+		_ = os.RemoveAll(workDir)
+		report.WorkDir = ""
+	}
+
+	reporter.Finish(report.Status)
+	return helpers.PrintJSON(report)
+}
+
+// runManifestCase runs the command of a case in dir and compares its output with
+// the expected output; a passing case scores its weight
+func runManifestCase(ctx context.Context, c manifest.Case, weight float64, dir string) (*output.Result, error) {
+	if len(c.Command) == 0 {
+		return nil, failure.Wrap(failure.ConfigInvalid, fmt.Errorf("case %s has no command", c.Name))
+	}
+	// The settings were validated when the manifest was expanded
+	timeout, _ := helpers.ParseTimeout(c.Timeout)
+	textOptions, _ := compare.ParseTextOptions(strings.Fields(c.DiffFlags))
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create case directory: %w", err)
+	}
+	input := c.Input
+	if input == "" {
+		input = os.DevNull
+	}
+	outputFile := filepath.Join(dir, manifestOutputFile)
+	stderrFile := filepath.Join(dir, manifestStderrFile)
+
+	result, err := helpers.ExecuteWithSpan(ctx, &runner.Config{
+		Command:        c.Command[0],
+		Args:           c.Command[1:],
+		InputFile:      input,
+		OutputFile:     outputFile,
+		StderrFile:     stderrFile,
+		Timeout:        timeout,
+		ExpectExitCode: c.ExpectExitCode,
+	})
+	if err != nil {
+		return nil, failure.Wrap(failure.ExecutionFailed, fmt.Errorf("failed to run case %s: %w", c.Name, err))
+	}
+
+	var caseContext any
+	if c.Context != nil {
+		caseContext = c.Context
+	}
+	jsonResult := helpers.CreateJSONResult(input, outputFile, stderrFile, c.Expected, result, timeout.Milliseconds(), true,
+		strconv.FormatFloat(weight, 'f', -1, 64), caseContext)
+	if result.Status != runner.StatusSuccess || c.Expected == "" {
+		return jsonResult, nil
+	}
+
+	diff, err := os.Create(filepath.Join(dir, manifestDiffFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create diff file: %w", err)
+	}
+	defer func() { _ = diff.Close() }()
+
+	equal, err := compare.DiffFiles(ctx, diff, outputFile, c.Expected, textOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare the output of case %s: %w", c.Name, err)
+	}
+	if !equal {
+		zero := decimal.NewFromInt(0)
+		jsonResult.Status = string(runner.StatusFailed)
+		jsonResult.Score = &zero
+	}
+	return jsonResult, nil
+}
+
+func init() {
+	manifestCmd.AddCommand(manifestExpandCmd)
+	manifestCmd.AddCommand(manifestRunCmd)
+
+	manifestExpandCmd.Flags().StringVar(&manifestShard, "shard", "", "Only the cases of this shard of parallel jobs, as index/count (e.g. 3/10)")
+
+	manifestRunCmd.Flags().StringVar(&manifestShard, "shard", "", "Only grade the cases of this shard of parallel jobs, as index/count (e.g. 3/10)")
+	manifestRunCmd.Flags().StringVar(&manifestWorkDir, "work-dir", "", "Directory for the files of every case, kept afterwards (default: temporary, kept only if a case failed)")
+	manifestRunCmd.Flags().BoolVar(&manifestStopOnFailure, "stop-on-failure", false, "Stop at the first case that fails")
+	manifestRunCmd.Flags().BoolVarP(&manifestVerbose, "verbose", "v", false, "Show the outcome of every case on stderr")
+	helpers.SetupProgressFlags(manifestRunCmd, &manifestProgress)
+	helpers.SetupWebhookFlags(manifestRunCmd, &manifestWebhookConfig)
+}
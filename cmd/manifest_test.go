@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/manifest"
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+func TestManifestExpandCommandShard(t *testing.T) {
+	defer func() { manifestShard = "" }()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cases.yaml")
+	content := `defaults:
+  timeout: 2s
+cases:
+  - name: sort
+    command: ["./sort", "{matrix.size}"]
+    input: tests/{matrix.size}.in
+    matrix:
+      size: [small, medium, large, huge]
+  - name: echo
+    command: ["echo"]
+    input: /dev/null
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[string]int{}
+	for index := 1; index <= 3; index++ {
+		manifestShard = strconv.Itoa(index) + "/3"
+		rootCmd.SetArgs([]string{"manifest", "expand", "--shard", manifestShard, path})
+		out, err := captureOutput(rootCmd.Execute)
+		if err != nil {
+			t.Fatalf("manifest expand failed: %v", err)
+		}
+
+		var report manifest.Expansion
+		if err := json.Unmarshal([]byte(out), &report); err != nil {
+			t.Fatalf("Failed to parse report: %v\nOutput: %s", err, out)
+		}
+		if report.Total != 5 || report.Shard != manifestShard {
+			t.Errorf("report = %d cases in shard %q, want 5 in %s", report.Total, report.Shard, manifestShard)
+		}
+		for _, c := range report.Cases {
+			seen[c.Name]++
+			if c.Timeout != "2s" {
+				t.Errorf("case %s timeout = %q, want the default", c.Name, c.Timeout)
+			}
+		}
+	}
+
+	if len(seen) != 5 {
+		t.Errorf("shards covered %d cases, want 5: %v", len(seen), seen)
+	}
+	for name, count := range seen {
+		if count != 1 {
+			t.Errorf("case %s is in %d shards", name, count)
+		}
+	}
+}
+
+func TestManifestExpandCommandErrors(t *testing.T) {
+	defer func() { manifestShard = "" }()
+
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalid, []byte("cases:\n  - name: a\n    matrix:\n      n: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode failure.Code
+	}{
+		{name: "invalid shard", args: []string{"--shard", "4/3", invalid}, wantCode: failure.Usage},
+		{name: "missing manifest", args: []string{filepath.Join(dir, "missing.yaml")}, wantCode: failure.InputNotFound},
+		{name: "invalid manifest", args: []string{invalid}, wantCode: failure.ConfigInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifestShard = ""
+			rootCmd.SetArgs(append([]string{"manifest", "expand"}, tt.args...))
+			_, err := captureOutput(rootCmd.Execute)
+			if err == nil || failure.CodeOf(err) != tt.wantCode {
+				t.Errorf("err = %v, want %s", err, tt.wantCode)
+			}
+		})
+	}
+}
+
+func resetManifestRunFlags() {
+	resetAllFlags(manifestRunCmd)
+}
+
+// writeManifest writes the files of a manifest run into dir
+func writeManifest(t *testing.T, dir string, files map[string]string) string {
+	t.Helper()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "cases.yaml")
+}
+
+func runManifest(t *testing.T, args ...string) output.ManifestRun {
+	t.Helper()
+	rootCmd.SetArgs(append([]string{"manifest", "run"}, args...))
+	out, err := captureOutput(rootCmd.Execute)
+	if err != nil {
+		t.Fatalf("manifest run failed: %v", err)
+	}
+	var report output.ManifestRun
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("Failed to parse report: %v\nOutput: %s", err, out)
+	}
+	return report
+}
+
+func TestManifestRunCommand(t *testing.T) {
+	resetManifestRunFlags()
+	defer resetManifestRunFlags()
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+	path := writeManifest(t, dir, map[string]string{
+		"hello.in":  "hello\n",
+		"spaced.in": "a  b\n",
+		"spaced.ok": "a b\n",
+		"cases.yaml": `defaults:
+  timeout: 2s
+  weight: 2
+cases:
+  - name: echo
+    command: [cat]
+    input: hello.in
+    expected: hello.in
+  - name: whitespace
+    command: [cat]
+    input: spaced.in
+    expected: spaced.ok
+    diff_flags: -w
+    weight: 3
+  - name: strict
+    command: [cat]
+    input: spaced.in
+    expected: spaced.ok
+  - name: exit
+    command: [sh, -c, "exit {matrix.code}"]
+    expect_exit_code: 3
+    context:
+      code: "{matrix.code}"
+    matrix:
+      code: [3, 4]
+  - name: slow
+    command: [sleep, "5"]
+    timeout: 200ms
+`,
+	})
+
+	workDir := filepath.Join(dir, "work")
+	report := runManifest(t, "--work-dir", workDir, path)
+
+	want := []struct {
+		name   string
+		status string
+		score  string
+	}{
+		{"echo", "success", "2"},
+		{"whitespace", "success", "3"},
+		{"strict", "failed", "0"},
+		{"exit (3)", "success", "2"},
+		{"exit (4)", "failed", "0"},
+		{"slow", "timeout", "0"},
+	}
+	if len(report.Cases) != len(want) {
+		t.Fatalf("report has %d cases, want %d: %+v", len(report.Cases), len(want), report.Cases)
+	}
+	for i, w := range want {
+		c := report.Cases[i]
+		if c.Name != w.name || c.Result.Status != w.status || c.Result.Score == nil || c.Result.Score.String() != w.score {
+			t.Errorf("case %d = %s %s score %v, want %s %s score %s", i+1, c.Name, c.Result.Status, c.Result.Score, w.name, w.status, w.score)
+		}
+	}
+	if ctx, _ := report.Cases[3].Result.Context.(map[string]any); ctx["code"] != float64(3) {
+		t.Errorf("exit (3) context = %v, want the matrix value", report.Cases[3].Result.Context)
+	}
+	if timeout := report.Cases[5].Result.Timeout; timeout == nil || *timeout != 200 {
+		t.Errorf("slow timeout = %v, want the case's 200ms", timeout)
+	}
+
+	if report.Status != "failed" || report.Total != 6 || report.Passed != 3 || report.Failed != 3 {
+		t.Errorf("report = %s, %d cases, %d passed, %d failed", report.Status, report.Total, report.Passed, report.Failed)
+	}
+	if report.Score.String() != "7" || report.MaxScore.String() != "13" {
+		t.Errorf("score = %s/%s, want 7/13", report.Score, report.MaxScore)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workDir, "3", "diff.txt")); len(data) == 0 {
+		t.Error("strict case has no diff")
+	}
+}
+
+func TestManifestRunCommandWebhookBreaker(t *testing.T) {
+	resetManifestRunFlags()
+	defer resetManifestRunFlags()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	path := writeManifest(t, dir, map[string]string{"cases.yaml": `cases:
+  - name: case
+    command: ["true"]
+    matrix:
+      n: [1, 2, 3, 4]
+`})
+
+	// The dead endpoint is only tried until the circuit opens
+	report := runManifest(t, "--webhook-url", server.URL, "--webhook-retries", "0", "--webhook-spool-dir", filepath.Join(dir, "spool"),
+		"--webhook-breaker-threshold", "2", "--webhook-breaker-cooldown", "1h", path)
+	if len(report.Cases) != 4 {
+		t.Fatalf("report has %d cases, want 4", len(report.Cases))
+	}
+	for i, c := range report.Cases {
+		open := i >= 2
+		if c.Result.WebhookSpooled != open || c.Result.WebhookSent || c.Result.RunID == "" {
+			t.Errorf("case %s: webhook_spooled=%v webhook_sent=%v run_id=%q", c.Name, c.Result.WebhookSpooled, c.Result.WebhookSent, c.Result.RunID)
+		}
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("requests = %d, want 2 before the circuit opened", got)
+	}
+}
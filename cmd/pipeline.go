@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/config"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/policy"
+	"github.com/zinc-sig/ghost/internal/rubric"
+	"github.com/zinc-sig/ghost/internal/runner"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	// Command-specific I/O flags
+	pipelineInputFile  string
+	pipelineOutputFile string
+	pipelineStderrFile string
+
+	// Policy file restricting which commands may be executed
+	pipelinePolicyFile string
+
+	// Common flag structures
+	pipelineFlags         config.CommonFlags
+	pipelineContextConfig config.ContextConfig
+	pipelineWebhookConfig config.WebhookConfig
+	pipelineFilterConfig  config.FilterConfig
+	pipelineCaptureConfig config.CaptureConfig
+)
+
+var pipelineCmd = &cobra.Command{
+	Use:   "pipeline [flags] -- <command> [args...] '|' <command> [args...]...",
+	Short: "Execute commands connected by pipes with structured output",
+	Long: `Execute a pipeline of commands, connecting the stdout of each step to the stdin
+of the next. The input file feeds the first step, the last step writes the output
+file, and every step shares the stderr file. Results are output as JSON with
+per-step exit codes and timings.
+
+Steps are separated by a '|' argument, which must be quoted so the shell passes
+it to ghost. Like 'set -o pipefail', the pipeline fails if any step fails.`,
+	Example: `  ghost pipeline -i /dev/null -o output.txt -e error.txt -- ./generate '|' ./transform '|' ./validate
+  ghost pipeline -i data.csv -o counts.txt -e errors.log --score 10 -- sort '|' uniq -c`,
+	RunE: pipelineCommand,
+}
+
+func pipelineCommand(cmd *cobra.Command, args []string) error {
+	// Webhook settings are parsed per invocation and passed to every delivery
+	webhookConfig, webhookRetryConfig, err := helpers.ParseWebhookConfigToInternal(&pipelineWebhookConfig)
+	if err != nil {
+		return failure.Wrap(failure.WebhookConfigInvalid, err)
+	}
+
+	// Validate command separator
+	if err := helpers.ValidateCommandSeparator(cmd, args); err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	// Setup tracing, the run ID and the result file for this invocation
+	ctx, exec, err := helpers.StartExecution(cmd, &pipelineFlags, webhookConfig, webhookRetryConfig)
+	if err != nil {
+		return err
+	}
+	defer exec.End()
+
+	// Validate required I/O flags
+	ioFlags := helpers.IOFlags{
+		Input:  pipelineInputFile,
+		Output: pipelineOutputFile,
+		Stderr: pipelineStderrFile,
+	}
+	if err := helpers.ValidateIOFlags(ioFlags, false); err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+	if !pipelineFlags.DryRun {
+		if err := helpers.CheckInputFile(ioFlags.Input); err != nil {
+			return err
+		}
+	}
+
+	steps, err := runner.ParsePipeline(args)
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+	exec.Span.SetAttributes(attribute.Int("ghost.pipeline.steps", len(steps)))
+
+	// Load execution policy if provided
+	var execPolicy *policy.Policy
+	if pipelinePolicyFile != "" {
+		execPolicy, err = policy.Load(pipelinePolicyFile)
+		if err != nil {
+			return failure.Wrap(failure.ConfigInvalid, err)
+		}
+	}
+
+	// Line filters normalise the capture files as they are written
+	stdoutFilter, stderrFilter, err := helpers.ParseOutputFilters(&pipelineFilterConfig)
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	if err := helpers.ValidateCaptureConfig(&pipelineCaptureConfig, pipelineOutputFile, pipelineStderrFile, ""); err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	config := &runner.Config{
+		RunID:       exec.RunID,
+		Command:     steps[0].Command,
+		Args:        steps[0].Args,
+		Pipeline:    steps,
+		InputFile:   pipelineInputFile,
+		OutputFile:  pipelineOutputFile,
+		StderrFile:  pipelineStderrFile,
+		Verbose:     pipelineFlags.Verbose,
+		DryRun:      pipelineFlags.DryRun,
+		Timeout:     pipelineFlags.Timeout,
+		SoftTimeout: pipelineFlags.SoftTimeout,
+
+		StdoutFilter: stdoutFilter,
+		StderrFilter: stderrFilter,
+		Append:       pipelineCaptureConfig.Append,
+		CombinedFile: pipelineCaptureConfig.Combined,
+
+		ExpectExitCode: helpers.ExpectedExitCode(&pipelineFlags),
+		ExpectNonzero:  pipelineFlags.ExpectNonzero,
+
+		Policy: execPolicy,
+	}
+
+	// Build context from all sources
+	if err := exec.BuildContext(&pipelineContextConfig); err != nil {
+		return err
+	}
+
+	// Lifecycle events let dashboards show in-progress executions
+	exec.SendStarted(ctx, config.FullCommand())
+
+	result, err := helpers.ExecuteWithSpan(ctx, config)
+	if err != nil {
+		return failure.Wrap(failure.ExecutionFailed, fmt.Errorf("failed to execute pipeline: %w", err))
+	}
+	exec.SendTimeout(ctx, result)
+
+	var timeoutMs int64
+	if pipelineFlags.Timeout > 0 {
+		timeoutMs = pipelineFlags.Timeout.Milliseconds()
+	}
+	jsonResult := helpers.CreateJSONResult(
+		config.InputFile,
+		config.OutputFile,
+		config.StderrFile,
+		"", // No expected file for pipeline command
+		result,
+		timeoutMs,
+		pipelineFlags.ScoreSet,
+		pipelineFlags.Score,
+		exec.Context,
+	)
+
+	return helpers.FinishExecution(ctx, exec, jsonResult, helpers.FinishOptions{
+		OutputFile: config.OutputFile,
+		StderrFile: config.StderrFile,
+		Plan: &output.Plan{
+			Command: result.Command,
+			Input:   config.InputFile,
+			Output:  config.OutputFile,
+			Stderr:  config.StderrFile,
+		},
+	})
+}
+
+func init() {
+	// Command-specific flags
+	pipelineCmd.Flags().StringVarP(&pipelineInputFile, "input", "i", "", "Input file to redirect to the first step's stdin (required)")
+	pipelineCmd.Flags().StringVarP(&pipelineOutputFile, "output", "o", "", "Output file to capture the last step's stdout (required)")
+	pipelineCmd.Flags().StringVarP(&pipelineStderrFile, "stderr", "e", "", "Error file to capture the stderr of every step (required)")
+	pipelineCmd.Flags().StringVar(&pipelinePolicyFile, "policy-file", "", "Policy file restricting which commands may be executed (checked for every step)")
+
+	// Mark flags as required
+	_ = pipelineCmd.MarkFlagRequired("input")
+	_ = pipelineCmd.MarkFlagRequired("output")
+	_ = pipelineCmd.MarkFlagRequired("stderr")
+
+	// Setup common flags using helper
+	helpers.SetupCommonFlags(pipelineCmd, &pipelineFlags)
+	helpers.SetupExpectFlags(pipelineCmd, &pipelineFlags)
+	helpers.SetupContextFlags(pipelineCmd, &pipelineContextConfig)
+	helpers.SetupWebhookFlags(pipelineCmd, &pipelineWebhookConfig)
+	helpers.SetupFilterFlags(pipelineCmd, &pipelineFilterConfig)
+	helpers.SetupCaptureFlags(pipelineCmd, &pipelineCaptureConfig)
+
+	pipelineCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		pipelineFlags.ScoreSet = cmd.Flags().Changed("score")
+		pipelineFlags.RecordEnvSet = cmd.Flags().Changed("record-env")
+		pipelineFlags.ExpectExitCodeSet = cmd.Flags().Changed("expect-exit-code")
+		if err := helpers.ValidateExpectExitCode(&pipelineFlags); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+
+		// Validate score expression early
+		if pipelineFlags.ScoreExpr != "" {
+			if _, err := helpers.ParseScoreExpression(pipelineFlags.ScoreExpr); err != nil {
+				return failure.Wrap(failure.Usage, err)
+			}
+		}
+		if pipelineFlags.Rubric != "" {
+			if _, err := rubric.Load(pipelineFlags.Rubric); err != nil {
+				return failure.Wrap(failure.ConfigInvalid, err)
+			}
+		}
+
+		// Validate preview sizes
+		if err := helpers.ValidateEmbedFlags(&pipelineFlags); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+
+		// Parse timeout if provided
+		var err error
+		pipelineFlags.Timeout, err = helpers.ParseTimeout(pipelineFlags.TimeoutStr)
+		if err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		pipelineFlags.SoftTimeout, err = helpers.ParseSoftTimeout(pipelineFlags.SoftTimeoutStr, pipelineFlags.Timeout)
+		if err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		pipelineFlags.ResultSchema, err = helpers.ParseResultSchema(pipelineFlags.ResultSchemaStr)
+		return failure.Wrap(failure.Usage, err)
+	}
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// resetPipelineFlags clears the pipeline flags so they don't leak between tests
+func resetPipelineFlags() {
+	resetFlags(pipelineCmd, "input", "output", "stderr", "policy-file", "timeout", "score")
+	pipelineFlags.Timeout = 0
+}
+
+func TestPipelineCommand(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		flags        []string
+		steps        []string
+		wantStatus   string
+		wantExitCode int
+		wantScore    string
+		wantSteps    []string
+		wantOutput   string
+		wantErr      string
+	}{
+		{
+			name:       "three steps",
+			input:      "b\na\nb\n",
+			flags:      []string{"--score", "10"},
+			steps:      []string{"sort", "|", "uniq", "|", "tr", "a-z", "A-Z"},
+			wantStatus: "success",
+			wantScore:  "10",
+			wantSteps:  []string{"success", "success", "success"},
+			wantOutput: "A\nB\n",
+		},
+		{
+			name:         "failing middle step",
+			steps:        []string{"echo", "hi", "|", "sh", "-c", "cat; exit 4", "|", "cat"},
+			wantStatus:   "failed",
+			wantExitCode: 4,
+			wantSteps:    []string{"success", "failed", "success"},
+			wantOutput:   "hi\n",
+		},
+		{
+			name:    "empty step",
+			steps:   []string{"echo", "|", "|", "cat"},
+			wantErr: "pipeline step 2 is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetPipelineFlags()
+			defer resetPipelineFlags()
+
+			dir := t.TempDir()
+			input := filepath.Join(dir, "input.txt")
+			if err := os.WriteFile(input, []byte(tt.input), 0644); err != nil {
+				t.Fatal(err)
+			}
+			outputPath := filepath.Join(dir, "output.txt")
+			args := []string{"pipeline", "-i", input, "-o", outputPath, "-e", filepath.Join(dir, "stderr.txt")}
+			args = append(args, tt.flags...)
+			args = append(append(args, "--"), tt.steps...)
+			rootCmd.SetArgs(args)
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result struct {
+				Command  string `json:"command"`
+				Status   string `json:"status"`
+				ExitCode int    `json:"exit_code"`
+				Score    string `json:"score"`
+				Steps    []struct {
+					Command string `json:"command"`
+					Status  string `json:"status"`
+				} `json:"steps"`
+			}
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if result.Status != tt.wantStatus || result.ExitCode != tt.wantExitCode {
+				t.Errorf("Status = %s (exit code %d), want %s (exit code %d)", result.Status, result.ExitCode, tt.wantStatus, tt.wantExitCode)
+			}
+			if tt.wantScore != "" && result.Score != tt.wantScore {
+				t.Errorf("Score = %s, want %s", result.Score, tt.wantScore)
+			}
+			if !strings.Contains(result.Command, " | ") {
+				t.Errorf("Expected pipeline command, got %q", result.Command)
+			}
+			if len(result.Steps) != len(tt.wantSteps) {
+				t.Fatalf("Got %d steps, want %d", len(result.Steps), len(tt.wantSteps))
+			}
+			for i, step := range result.Steps {
+				if step.Status != tt.wantSteps[i] {
+					t.Errorf("Step %d (%s) status = %s, want %s", i+1, step.Command, step.Status, tt.wantSteps[i])
+				}
+			}
+
+			data, err := os.ReadFile(outputPath)
+			if err != nil || string(data) != tt.wantOutput {
+				t.Errorf("Output file = %q (%v), want %q", data, err, tt.wantOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// resetPolicyFlags clears the policy and scoring flags used by the policy tests
+func resetPolicyFlags() {
+	resetFlags(runCmd, "policy-file")
+	resetScoringFlags()
+}
+
+func TestRunCommandPolicyFile(t *testing.T) {
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.yaml")
+	policy := "allow:\n  - command: echo\n    args: [\"hello\"]\n"
+	if err := os.WriteFile(policyPath, []byte(policy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	invalidPath := filepath.Join(dir, "invalid.yaml")
+	if err := os.WriteFile(invalidPath, []byte("allow:\n  - args: [\"x\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		policyFile    string
+		command       []string
+		wantStatus    string
+		wantViolation string
+		wantOutput    string
+		wantErr       string
+	}{
+		{
+			name:       "allowed command runs",
+			policyFile: policyPath,
+			command:    []string{"echo", "hello"},
+			wantStatus: "success",
+			wantOutput: "hello\n",
+		},
+		{
+			name:          "command not in allowlist",
+			policyFile:    policyPath,
+			command:       []string{"cat", "/etc/passwd"},
+			wantStatus:    "policy_violation",
+			wantViolation: `command "cat" is not in the allowlist`,
+		},
+		{
+			name:          "argument not allowed",
+			policyFile:    policyPath,
+			command:       []string{"echo", "goodbye"},
+			wantStatus:    "policy_violation",
+			wantViolation: `argument "goodbye" of "echo" is not allowed`,
+		},
+		{
+			name:       "invalid policy file",
+			policyFile: invalidPath,
+			command:    []string{"echo", "hello"},
+			wantErr:    "invalid policy file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetPolicyFlags()
+			defer resetPolicyFlags()
+
+			outputPath := filepath.Join(t.TempDir(), "output.txt")
+			args := []string{"run", "-i", "/dev/null", "-o", outputPath, "-e", filepath.Join(t.TempDir(), "stderr.txt"),
+				"--policy-file", tt.policyFile, "--score", "10", "--"}
+			rootCmd.SetArgs(append(args, tt.command...))
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result struct {
+				Status          string `json:"status"`
+				ExitCode        int    `json:"exit_code"`
+				Score           string `json:"score"`
+				PolicyViolation string `json:"policy_violation"`
+			}
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			if !strings.Contains(result.PolicyViolation, tt.wantViolation) || (tt.wantViolation == "") != (result.PolicyViolation == "") {
+				t.Errorf("PolicyViolation = %q, want %q", result.PolicyViolation, tt.wantViolation)
+			}
+
+			content, err := os.ReadFile(outputPath)
+			if err != nil {
+				t.Fatalf("Expected output file to exist: %v", err)
+			}
+			if string(content) != tt.wantOutput {
+				t.Errorf("Output file = %q, want %q", content, tt.wantOutput)
+			}
+
+			if tt.wantStatus == "policy_violation" {
+				if result.ExitCode != -1 {
+					t.Errorf("ExitCode = %d, want -1", result.ExitCode)
+				}
+				if result.Score != "0" {
+					t.Errorf("Score = %s, want 0", result.Score)
+				}
+			}
+		})
+	}
+}
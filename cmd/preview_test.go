@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+// resetPreviewFlags clears the preview sizes so they don't leak between tests
+func resetPreviewFlags() {
+	resetFlags(runCmd, "embed-output-head", "embed-stderr-tail", "stderr-classify")
+}
+
+func TestRunCommandEmbedPreviews(t *testing.T) {
+	tests := []struct {
+		name                string
+		flags               []string
+		wantOutput          string
+		wantOutputTruncated bool
+		wantStderr          string
+		wantStderrTruncated bool
+		wantErr             string
+	}{
+		{name: "off by default"},
+		{
+			name:                "head and tail",
+			flags:               []string{"--embed-output-head", "7", "--embed-stderr-tail", "8"},
+			wantOutput:          "line 1\n",
+			wantOutputTruncated: true,
+			wantStderr:          "failed!\n",
+			wantStderrTruncated: true,
+		},
+		{
+			name:       "larger than the files",
+			flags:      []string{"--embed-output-head", "4096", "--embed-stderr-tail", "4096"},
+			wantOutput: "line 1\nline 2\nline 3\n",
+			wantStderr: "warning\nfailed!\n",
+		},
+		{name: "negative size", flags: []string{"--embed-output-head", "-1"}, wantErr: "--embed-output-head must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTimeoutGlobals()
+			resetPreviewFlags()
+			defer resetPreviewFlags()
+
+			dir := t.TempDir()
+			args := []string{"run", "-i", "/dev/null", "-o", filepath.Join(dir, "output.txt"), "-e", filepath.Join(dir, "stderr.txt")}
+			args = append(args, tt.flags...)
+			rootCmd.SetArgs(append(args, "--", "sh", "-c", `printf 'line 1\nline 2\nline 3\n'; printf 'warning\nfailed!\n' >&2; exit 1`))
+
+			out, err := captureOutput(func() error { return rootCmd.Execute() })
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result output.Result
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, out)
+			}
+			if result.OutputPreview != tt.wantOutput || result.OutputTruncated != tt.wantOutputTruncated {
+				t.Errorf("output_preview = %q (truncated %v), want %q (truncated %v)", result.OutputPreview, result.OutputTruncated, tt.wantOutput, tt.wantOutputTruncated)
+			}
+			if result.StderrPreview != tt.wantStderr || result.StderrTruncated != tt.wantStderrTruncated {
+				t.Errorf("stderr_preview = %q (truncated %v), want %q (truncated %v)", result.StderrPreview, result.StderrTruncated, tt.wantStderr, tt.wantStderrTruncated)
+			}
+		})
+	}
+}
+
+func TestRunCommandStderrClassify(t *testing.T) {
+	tests := []struct {
+		name      string
+		flags     []string
+		want      *output.Diagnostics
+		wantScore string
+		wantErr   string
+	}{
+		{name: "off by default"},
+		{
+			name:  "gcc",
+			flags: []string{"--stderr-classify", "gcc"},
+			want:  &output.Diagnostics{Format: "gcc", Errors: 1, Warnings: 2, FirstError: "main.c:4:1: error: expected ';'"},
+		},
+		{
+			name:      "scored by warnings",
+			flags:     []string{"--stderr-classify", "gcc", "--score-expr", "warnings == 0 ? 10 : 10 - warnings"},
+			want:      &output.Diagnostics{Format: "gcc", Errors: 1, Warnings: 2, FirstError: "main.c:4:1: error: expected ';'"},
+			wantScore: "8",
+		},
+		{name: "unknown format", flags: []string{"--stderr-classify", "rustc"}, wantErr: `invalid --stderr-classify: invalid stderr format "rustc"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTimeoutGlobals()
+			resetScoringFlags()
+			resetPreviewFlags()
+			defer resetPreviewFlags()
+			defer resetScoringFlags()
+
+			dir := t.TempDir()
+			args := []string{"run", "-i", "/dev/null", "-o", filepath.Join(dir, "output.txt"), "-e", filepath.Join(dir, "stderr.txt")}
+			args = append(args, tt.flags...)
+			rootCmd.SetArgs(append(args, "--", "sh", "-c",
+				`printf 'main.c:2:5: warning: unused variable\nmain.c:3:1: warning: implicit declaration\nmain.c:4:1: error: expected ;\n' | sed "s/;/';'/" >&2`))
+
+			out, err := captureOutput(func() error { return rootCmd.Execute() })
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result output.Result
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, out)
+			}
+			if !reflect.DeepEqual(result.Diagnostics, tt.want) {
+				t.Errorf("diagnostics = %+v, want %+v", result.Diagnostics, tt.want)
+			}
+			if tt.wantScore != "" && (result.Score == nil || result.Score.String() != tt.wantScore) {
+				t.Errorf("score = %v, want %s", result.Score, tt.wantScore)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+	"github.com/zinc-sig/ghost/internal/compare"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/replay"
+	"github.com/zinc-sig/ghost/internal/runner"
+)
+
+var (
+	replayStoredOutput string
+	replayStoredStderr string
+	replayDiffFlags    string
+	replayTimeoutStr   string
+	replayWorkDir      string
+	replayVerbose      bool
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay [--stored-output <file>] [--stored-stderr <file>] <result.json>",
+	Short: "Re-run the command of a stored run result and compare the outputs",
+	Long: `Re-execute the command recorded in a result of ghost run with the same input,
+and compare the new output with the stored artifacts, e.g. to investigate a
+grade appeal. The result is read from the given file, or stdin for "-".
+
+The command and its arguments are taken from the result's argv, and the
+environment variables recorded with --record-env are set again. The recorded
+timeout applies unless --timeout is given. Paths in the result are resolved
+against the current directory, so replay from the directory the run was started
+in (recorded in environment.working_dir with --record-env).
+
+The stored output and stderr are the local files named in the result. When they
+were only uploaded, download them and pass --stored-output and --stored-stderr.
+Outputs are compared with the built-in engine; --diff-flags accepts the flags it
+supports (e.g. -w). Stderr is compared only if the stored stderr is available,
+and does not decide whether the run was reproduced.
+
+The new output.txt and stderr.txt and their diffs are written to the work
+directory. Without --work-dir a temporary directory is used, which is removed
+unless the run was not reproduced.
+
+A run is reproduced if the exit code and output match and it timed out exactly
+when the original did. The report is written as JSON; the command exits with
+code 1 if the run was not reproduced.`,
+	Example: `  ghost replay result.json
+  ghost replay --stored-output downloads/out.txt --diff-flags -w --work-dir appeal/ result.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: replayCommand,
+}
+
+func replayCommand(cmd *cobra.Command, args []string) error {
+	textOptions, err := compare.ParseTextOptions(strings.Fields(replayDiffFlags))
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	stored, err := readResult(cmd, args[0])
+	if err != nil {
+		return err
+	}
+	run, err := replay.FromResult(stored)
+	if err != nil {
+		return failure.Wrap(failure.Usage, fmt.Errorf("cannot replay %s: %w", args[0], err))
+	}
+	if replayTimeoutStr != "" {
+		if run.Timeout, err = helpers.ParseTimeout(replayTimeoutStr); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+	}
+
+	uploaded := len(stored.Uploads) > 0
+	storedOutput := replayStoredOutput
+	if storedOutput == "" {
+		storedOutput = storedArtifact(stored.Output, uploaded)
+	}
+	if storedOutput == "" {
+		return failure.Wrap(failure.Usage, fmt.Errorf("the stored output %q is not a local file; pass --stored-output", stored.Output))
+	}
+	if _, err := os.Stat(storedOutput); err != nil {
+		return failure.Wrap(failure.InputNotFound, fmt.Errorf("failed to read stored output: %w", err))
+	}
+	storedStderr := replayStoredStderr
+	if storedStderr == "" {
+		storedStderr = storedArtifact(stored.Stderr, uploaded)
+	}
+
+	workDir := replayWorkDir
+	if workDir == "" {
+		workDir, err = os.MkdirTemp("", "ghost-replay-*")
+		if err != nil {
+			return fmt.Errorf("failed to create work directory: %w", err)
+		}
+	} else if err := os.MkdirAll(workDir, 0755); err != nil {
+		return fmt.Errorf("failed to create work directory: %w", err)
+	}
+
+	config := &runner.Config{
+		RunID:      run.RunID,
+		Command:    run.Argv[0],
+		Args:       run.Argv[1:],
+		InputFile:  run.Input,
+		OutputFile: filepath.Join(workDir, replay.OutputFile),
+		StderrFile: filepath.Join(workDir, replay.StderrFile),
+		Verbose:    replayVerbose,
+		Timeout:    run.Timeout,
+		Env:        run.Env,
+	}
+	ctx := helpers.CommandContext(cmd)
+	result, err := helpers.ExecuteWithSpan(ctx, config)
+	if err != nil {
+		return failure.Wrap(failure.ExecutionFailed, fmt.Errorf("failed to replay command: %w", err))
+	}
+
+	report := &output.ReplayReport{
+		Command:  "replay",
+		Status:   "success",
+		RunID:    run.RunID,
+		Replayed: result.Command,
+		Original: output.ReplayRun{
+			Status:        stored.Status,
+			ExitCode:      stored.ExitCode,
+			ExecutionTime: stored.ExecutionTime,
+			Output:        storedOutput,
+			Stderr:        storedStderr,
+		},
+		Replay: output.ReplayRun{
+			Status:        string(result.Status),
+			ExitCode:      result.ExitCode,
+			ExecutionTime: result.ExecutionTime,
+			Output:        config.OutputFile,
+			Stderr:        config.StderrFile,
+		},
+		ExitCodeMatches: result.ExitCode == stored.ExitCode,
+		WorkDir:         workDir,
+	}
+
+	report.OutputMatches, err = diffReplayFile(ctx, filepath.Join(workDir, replay.OutputDiffFile), config.OutputFile, storedOutput, textOptions)
+	if err != nil {
+		return err
+	}
+	if !fileExists(storedStderr) {
+		report.Original.Stderr = ""
+	} else {
+		matches, err := diffReplayFile(ctx, filepath.Join(workDir, replay.StderrDiffFile), config.StderrFile, storedStderr, textOptions)
+		if err != nil {
+			return err
+		}
+		report.StderrMatches = &matches
+	}
+
+	timedOut := string(runner.StatusTimeout)
+	reproduced := report.ExitCodeMatches && report.OutputMatches && (stored.Status == timedOut) == (report.Replay.Status == timedOut)
+	if !reproduced {
+		report.Status = "failed"
+	} else if replayWorkDir == "" {
+		// Nothing worth inspecting
+		_ = os.RemoveAll(workDir)
+		report.WorkDir = ""
+		report.Replay.Output = ""
+		report.Replay.Stderr = ""
+	}
+
+	if err := helpers.PrintJSON(report); err != nil {
+		return err
+	}
+	if !reproduced {
+		// The report already explains the failure
+		cmd.SilenceUsage = true
+		return failure.Wrap(failure.ReplayMismatch, fmt.Errorf("replay of run %s did not reproduce the stored result", run.RunID))
+	}
+	return nil
+}
+
+// readResult reads a stored result from a file, or stdin for "-"
+func readResult(cmd *cobra.Command, path string) (*output.Result, error) {
+	if path == "-" {
+		result, err := replay.Read(cmd.InOrStdin(), "stdin")
+		return result, failure.Wrap(failure.Usage, err)
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, failure.Wrap(failure.InputNotFound, fmt.Errorf("failed to open result file: %w", err))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open result file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	result, err := replay.Read(file, path)
+	return result, failure.Wrap(failure.Usage, err)
+}
+
+// storedArtifact returns the local file a run wrote for an output path as given
+// to ghost run, or "" if it kept no local copy
+func storedArtifact(path string, uploaded bool) string {
+	if path == runner.StreamPath {
+		return ""
+	}
+	if strings.Contains(path, ":") {
+		local, _ := helpers.ParseOutputPath(path)
+		return local
+	}
+	if uploaded {
+		// Uploads without a local path are written to temporary files
+		return ""
+	}
+	return path
+}
+
+// fileExists reports whether path names an existing file
+func fileExists(path string) bool {
+	if path == "" {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// diffReplayFile compares a replayed file with the stored one, writing the diff to path
+func diffReplayFile(ctx context.Context, path, replayed, stored string, textOptions compare.TextOptions) (bool, error) {
+	diff, err := os.Create(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to create diff file: %w", err)
+	}
+	defer func() { _ = diff.Close() }()
+
+	equal, err := compare.DiffFiles(ctx, diff, replayed, stored, textOptions)
+	if err != nil {
+		return false, fmt.Errorf("failed to compare %s with %s: %w", replayed, stored, err)
+	}
+	return equal, nil
+}
+
+func init() {
+	replayCmd.Flags().StringVar(&replayStoredOutput, "stored-output", "", "Stored output to compare with (default: the local output file named in the result)")
+	replayCmd.Flags().StringVar(&replayStoredStderr, "stored-stderr", "", "Stored stderr to compare with (default: the local stderr file named in the result)")
+	replayCmd.Flags().StringVar(&replayDiffFlags, "diff-flags", "", "Comparison flags of the built-in engine (e.g. \"-w -B\")")
+	replayCmd.Flags().StringVar(&replayTimeoutStr, "timeout", "", "Timeout for the replayed command (default: the recorded timeout)")
+	replayCmd.Flags().StringVar(&replayWorkDir, "work-dir", "", "Directory for the replayed output, stderr and diffs, kept afterwards (default: temporary, kept only if the run was not reproduced)")
+	replayCmd.Flags().BoolVarP(&replayVerbose, "verbose", "v", false, "Show execution details on stderr")
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+func resetReplayFlags() {
+	replayStoredOutput = ""
+	replayStoredStderr = ""
+	replayDiffFlags = ""
+	replayTimeoutStr = ""
+	replayWorkDir = ""
+	replayVerbose = false
+}
+
+// runForReplay runs a command with ghost run and returns the path of its result file
+func runForReplay(t *testing.T, dir string, command ...string) string {
+	t.Helper()
+	resetTimeoutGlobals()
+
+	input := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(input, []byte("b a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"run", "-i", input, "-o", filepath.Join(dir, "output.txt"), "-e", filepath.Join(dir, "stderr.txt"), "--"}
+	rootCmd.SetArgs(append(args, command...))
+	out, err := captureOutput(func() error { return rootCmd.Execute() })
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	resultFile := filepath.Join(dir, "result.json")
+	if err := os.WriteFile(resultFile, []byte(out), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return resultFile
+}
+
+func TestReplayCommand(t *testing.T) {
+	tests := []struct {
+		name           string
+		tamper         string // replaces the stored output
+		flags          []string
+		wantStatus     string
+		wantOutput     bool
+		wantKeptFiles  bool
+		wantStderrNote bool
+	}{
+		{name: "reproduced", wantStatus: "success", wantOutput: true},
+		{name: "output differs", tamper: "a b\n", wantStatus: "failed", wantKeptFiles: true},
+		{name: "diff flags", tamper: "b a  \n", flags: []string{"--diff-flags", "-w"}, wantStatus: "success", wantOutput: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetReplayFlags()
+			defer resetReplayFlags()
+
+			dir := t.TempDir()
+			resultFile := runForReplay(t, dir, "sh", "-c", "read x; echo \"$x\"; echo 'two words' >&2")
+			if tt.tamper != "" {
+				if err := os.WriteFile(filepath.Join(dir, "output.txt"), []byte(tt.tamper), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			resetReplayFlags()
+			rootCmd.SetArgs(append(append([]string{"replay"}, tt.flags...), resultFile))
+			out, err := captureOutput(func() error { return rootCmd.Execute() })
+			if tt.wantStatus == "success" && err != nil {
+				t.Fatalf("replay failed: %v", err)
+			}
+			if tt.wantStatus == "failed" && failure.CodeOf(err) != failure.ReplayMismatch {
+				t.Fatalf("err = %v, want %s", err, failure.ReplayMismatch)
+			}
+
+			var report output.ReplayReport
+			if err := json.Unmarshal([]byte(out), &report); err != nil {
+				t.Fatalf("invalid report: %v\n%s", err, out)
+			}
+			if report.Status != tt.wantStatus || report.OutputMatches != tt.wantOutput || !report.ExitCodeMatches {
+				t.Errorf("status=%q output_matches=%v exit_code_matches=%v", report.Status, report.OutputMatches, report.ExitCodeMatches)
+			}
+			if report.StderrMatches == nil || !*report.StderrMatches {
+				t.Errorf("stderr_matches = %v, want true", report.StderrMatches)
+			}
+			if report.Replayed != "sh -c read x; echo \"$x\"; echo 'two words' >&2" {
+				t.Errorf("replayed = %q", report.Replayed)
+			}
+
+			if tt.wantKeptFiles {
+				defer func() { _ = os.RemoveAll(report.WorkDir) }()
+				diff, err := os.ReadFile(filepath.Join(report.WorkDir, "output.diff"))
+				if err != nil || !strings.Contains(string(diff), "a b") {
+					t.Errorf("output diff = %q, %v", diff, err)
+				}
+			} else if report.WorkDir != "" {
+				t.Errorf("work_dir = %q, want it removed", report.WorkDir)
+			}
+		})
+	}
+}
+
+func TestReplayCommandEnvironment(t *testing.T) {
+	resetReplayFlags()
+	defer resetReplayFlags()
+	defer resetRecordEnvFlags()
+
+	t.Setenv("GHOST_REPLAY_TEST", "recorded")
+	dir := t.TempDir()
+	resetRecordEnvFlags()
+	rootCmd.SetArgs([]string{"run", "-i", "/dev/null", "-o", filepath.Join(dir, "output.txt"), "-e", filepath.Join(dir, "stderr.txt"),
+		"--record-env", "GHOST_REPLAY_", "--", "sh", "-c", "echo $GHOST_REPLAY_TEST"})
+	out, err := captureOutput(func() error { return rootCmd.Execute() })
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	resultFile := filepath.Join(dir, "result.json")
+	if err := os.WriteFile(resultFile, []byte(out), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The recorded variable is set again even though it is gone now
+	t.Setenv("GHOST_REPLAY_TEST", "changed")
+	rootCmd.SetArgs([]string{"replay", resultFile})
+	if out, err := captureOutput(func() error { return rootCmd.Execute() }); err != nil {
+		t.Fatalf("replay failed: %v\n%s", err, out)
+	}
+}
+
+func TestReplayCommandErrors(t *testing.T) {
+	dir := t.TempDir()
+	resultFile := runForReplay(t, dir, "cat")
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode failure.Code
+		wantErr  string
+	}{
+		{"missing result", []string{filepath.Join(dir, "missing.json")}, failure.InputNotFound, "failed to open result file"},
+		{"invalid result", []string{write("bad.json", "{")}, failure.Usage, "failed to parse result"},
+		{"diff result", []string{write("diff.json", `{"command":"diff a b","input":"a","expected":"b"}`)}, failure.Usage, "only run results can be replayed"},
+		{"uploaded output", []string{write("upload.json", `{"command":"cat","input":"in","output":"out.txt","uploads":[{"remote":"out.txt"}]}`)}, failure.Usage, "pass --stored-output"},
+		{"missing stored output", []string{"--stored-output", filepath.Join(dir, "gone.txt"), resultFile}, failure.InputNotFound, "failed to read stored output"},
+		{"invalid diff flags", []string{"--diff-flags", "--bogus", resultFile}, failure.Usage, "--bogus"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetReplayFlags()
+			defer resetReplayFlags()
+
+			rootCmd.SetArgs(append([]string{"replay"}, tt.args...))
+			_, err := captureOutput(func() error { return rootCmd.Execute() })
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || failure.CodeOf(err) != tt.wantCode {
+				t.Errorf("err = %v (%s), want %s containing %q", err, failure.CodeOf(err), tt.wantCode, tt.wantErr)
+			}
+		})
+	}
+}
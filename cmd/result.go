@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/regression"
+)
+
+var (
+	resultTimeTolerance string
+	resultTimeSlack     time.Duration
+	resultIgnore        []string
+)
+
+var resultCmd = &cobra.Command{
+	Use:   "result",
+	Short: "Work with stored ghost results",
+}
+
+var resultCompareCmd = &cobra.Command{
+	Use:   "compare [--time-tolerance <pct>] [--ignore <fields>] <old.json> <new.json>",
+	Short: "Compare two results of the same case to detect grading regressions",
+	Long: `Compare two results of the same case, e.g. graded before and after a
+toolchain upgrade, and report every field that changed. Either result may be
+read from stdin with "-".
+
+The compared fields are status, exit_code, score, execution_time and the SHA-256
+checksums of the output and stderr files. The execution time may differ by
+--time-tolerance of the old time, or by --time-slack, whichever is larger.
+Output and stderr files are resolved against the current directory; they are
+skipped when a result kept no local copy, e.g. because it was only uploaded.
+--ignore leaves fields out, such as stderr when compiler warnings are expected
+to change.
+
+The report is written as JSON; the command exits with code 1 if any compared
+field changed.`,
+	Example: `  ghost result compare baseline/case1.json upgraded/case1.json
+  ghost result compare --time-tolerance 50% --ignore stderr old.json new.json`,
+	Args: cobra.ExactArgs(2),
+	RunE: resultCompareCommand,
+}
+
+func resultCompareCommand(cmd *cobra.Command, args []string) error {
+	tolerance, err := regression.ParseTolerance(resultTimeTolerance)
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+	if resultTimeSlack < 0 {
+		return failure.Wrap(failure.Usage, fmt.Errorf("--time-slack must not be negative"))
+	}
+	if err := regression.ValidateFields(resultIgnore); err != nil {
+		return failure.Wrap(failure.Usage, fmt.Errorf("invalid --ignore: %w", err))
+	}
+	if args[0] == "-" && args[1] == "-" {
+		return failure.Wrap(failure.Usage, fmt.Errorf("only one of the results can be read from stdin"))
+	}
+
+	sides := make([]regression.Side, len(args))
+	for i, path := range args {
+		result, err := readResult(cmd, path)
+		if err != nil {
+			return err
+		}
+		sides[i] = comparedSide(result)
+	}
+
+	deltas, err := regression.Compare(sides[0], sides[1], regression.Options{
+		TimeTolerance: tolerance,
+		TimeSlack:     resultTimeSlack,
+		Ignore:        resultIgnore,
+	})
+	if err != nil {
+		return err
+	}
+
+	report := &output.ResultComparison{
+		Command: "result compare",
+		Status:  "success",
+		Old:     args[0],
+		New:     args[1],
+		Fields:  make([]output.ComparedField, 0, len(deltas)),
+	}
+	for _, delta := range deltas {
+		if !delta.Match && !delta.Skipped {
+			report.Changed++
+		}
+		report.Fields = append(report.Fields, output.ComparedField{
+			Field:   delta.Field,
+			Old:     delta.Old,
+			New:     delta.New,
+			Match:   delta.Match,
+			Skipped: delta.Skipped,
+			Note:    delta.Note,
+		})
+	}
+	if report.Changed > 0 {
+		report.Status = "failed"
+	}
+
+	if err := helpers.PrintJSON(report); err != nil {
+		return err
+	}
+	if report.Changed > 0 {
+		// The report already explains the failure
+		cmd.SilenceUsage = true
+		return failure.Wrap(failure.ResultMismatch, fmt.Errorf("%d of %d compared fields changed", report.Changed, len(deltas)))
+	}
+	return nil
+}
+
+// comparedSide pairs a result with the local copies of its output and stderr files
+func comparedSide(result *output.Result) regression.Side {
+	side := regression.Side{Result: result}
+	uploaded := len(result.Uploads) > 0
+	if path := storedArtifact(result.Output, uploaded); fileExists(path) {
+		side.Output = path
+	}
+	if path := storedArtifact(result.Stderr, uploaded); fileExists(path) {
+		side.Stderr = path
+	}
+	return side
+}
+
+func init() {
+	resultCmd.AddCommand(resultCompareCmd)
+
+	resultCompareCmd.Flags().StringVar(&resultTimeTolerance, "time-tolerance", "20%", "Share of the old execution time the new one may differ by (e.g. 20% or 0.2)")
+	resultCompareCmd.Flags().DurationVar(&resultTimeSlack, "time-slack", 50*time.Millisecond, "Difference in execution time that is always tolerated")
+	resultCompareCmd.Flags().StringSliceVar(&resultIgnore, "ignore", nil, "Fields left out of the comparison: status, exit_code, score, execution_time, output, stderr (comma-separated)")
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// resetResultFileFlags clears the result file flags so they don't leak between tests
+func resetResultFileFlags() {
+	for _, cmd := range []*cobra.Command{runCmd, diffCmd} {
+		resetFlags(cmd, "result-file")
+	}
+	resetRunIDFlags()
+}
+
+func TestRunCommandResultFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		resultFile func(dir string) string
+		flags      []string
+		wantRemote string
+		wantErr    string
+	}{
+		{
+			name:       "local result file",
+			resultFile: func(dir string) string { return filepath.Join(dir, "results", "result.json") },
+		},
+		{
+			name:       "uploaded result file",
+			resultFile: func(dir string) string { return filepath.Join(dir, "result.json") + ":runs/{run_id}/result.json" },
+			flags:      []string{"--upload-provider", "test-flaky", "--run-id", "r1"},
+			wantRemote: "runs/r1/result.json",
+		},
+		{
+			name:       "missing local path",
+			resultFile: func(string) string { return ":result.json" },
+			wantErr:    "local path is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetResultFileFlags()
+			defer resetResultFileFlags()
+			testFlakyProvider.reset(nil)
+
+			dir := t.TempDir()
+			resultFile := tt.resultFile(dir)
+			args := []string{"run", "-i", "/dev/null", "-o", filepath.Join(dir, "output.txt") + ":output.txt",
+				"-e", filepath.Join(dir, "stderr.txt") + ":stderr.txt", "--result-file", resultFile}
+			args = append(args, tt.flags...)
+			args = append(args, "--", "echo", "hi")
+			rootCmd.SetArgs(args)
+
+			stdout, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			local, _, _ := strings.Cut(resultFile, ":")
+			data, err := os.ReadFile(local)
+			if err != nil {
+				t.Fatalf("Failed to read result file: %v", err)
+			}
+			if string(data) != stdout {
+				t.Errorf("Result file = %q, want stdout %q", data, stdout)
+			}
+			var result struct {
+				Status string `json:"status"`
+			}
+			if err := json.Unmarshal(data, &result); err != nil || result.Status != "success" {
+				t.Errorf("Expected successful result, got %q (%v)", data, err)
+			}
+
+			if tt.wantRemote != "" && testFlakyProvider.uploads[tt.wantRemote] != string(data) {
+				t.Errorf("Expected result uploaded to %s, got %v", tt.wantRemote, testFlakyProvider.uploads)
+			}
+		})
+	}
+}
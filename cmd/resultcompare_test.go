@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+func resetResultCompareFlags() {
+	resultTimeTolerance = "20%"
+	resultTimeSlack = 50 * time.Millisecond
+	resetFlags(resultCompareCmd, "ignore")
+}
+
+func TestResultCompareCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     []string
+		flags       []string
+		wantStatus  string
+		wantChanged map[string]bool
+	}{
+		{
+			name:       "same grade",
+			command:    []string{"sh", "-c", "read x; echo \"$x\""},
+			wantStatus: "success",
+		},
+		{
+			name:        "regression",
+			command:     []string{"sh", "-c", "echo changed; echo 'warning: new' >&2; exit 2"},
+			wantStatus:  "failed",
+			wantChanged: map[string]bool{"status": true, "exit_code": true, "output": true, "stderr": true},
+		},
+		{
+			name:        "ignored fields",
+			command:     []string{"sh", "-c", "echo changed; echo 'warning: new' >&2; exit 2"},
+			flags:       []string{"--ignore", "status,exit_code,stderr"},
+			wantStatus:  "failed",
+			wantChanged: map[string]bool{"output": true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetResultCompareFlags()
+			defer resetResultCompareFlags()
+
+			oldResult := runForReplay(t, t.TempDir(), "sh", "-c", "read x; echo \"$x\"")
+			newResult := runForReplay(t, t.TempDir(), tt.command...)
+
+			rootCmd.SetArgs(append(append([]string{"result", "compare", "--time-slack", "10s"}, tt.flags...), oldResult, newResult))
+			out, err := captureOutput(rootCmd.Execute)
+			if tt.wantStatus == "success" && err != nil {
+				t.Fatalf("compare failed: %v", err)
+			}
+			if tt.wantStatus == "failed" && (err == nil || failure.CodeOf(err) != failure.ResultMismatch) {
+				t.Fatalf("err = %v, want %s", err, failure.ResultMismatch)
+			}
+
+			var report output.ResultComparison
+			if err := json.Unmarshal([]byte(out), &report); err != nil {
+				t.Fatalf("Failed to parse report: %v\nOutput: %s", err, out)
+			}
+			if report.Status != tt.wantStatus || report.Changed != len(tt.wantChanged) {
+				t.Errorf("report = %s with %d changed, want %s with %d", report.Status, report.Changed, tt.wantStatus, len(tt.wantChanged))
+			}
+			for _, field := range report.Fields {
+				if field.Skipped {
+					t.Errorf("field %s skipped: %s", field.Field, field.Note)
+				}
+				if !field.Match != tt.wantChanged[field.Field] {
+					t.Errorf("field %s match = %v (%s -> %s)", field.Field, field.Match, field.Old, field.New)
+				}
+			}
+		})
+	}
+}
+
+func TestResultCompareCommandUsage(t *testing.T) {
+	dir := t.TempDir()
+	result := filepath.Join(dir, "result.json")
+	if err := os.WriteFile(result, []byte(`{"status":"success"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode failure.Code
+	}{
+		{name: "invalid tolerance", args: []string{"--time-tolerance", "soon", result, result}, wantCode: failure.Usage},
+		{name: "unknown field", args: []string{"--ignore", "argv", result, result}, wantCode: failure.Usage},
+		{name: "both from stdin", args: []string{"-", "-"}, wantCode: failure.Usage},
+		{name: "missing result", args: []string{result, filepath.Join(dir, "missing.json")}, wantCode: failure.InputNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetResultCompareFlags()
+			defer resetResultCompareFlags()
+
+			rootCmd.SetArgs(append([]string{"result", "compare"}, tt.args...))
+			_, err := captureOutput(rootCmd.Execute)
+			if err == nil || failure.CodeOf(err) != tt.wantCode {
+				t.Errorf("err = %v, want %s", err, tt.wantCode)
+			}
+		})
+	}
+}
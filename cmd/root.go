@@ -1,9 +1,15 @@
 package cmd
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+	"github.com/zinc-sig/ghost/internal/failure"
 )
 
 var rootCmd = &cobra.Command{
@@ -12,17 +18,79 @@ var rootCmd = &cobra.Command{
 	Long: `Ghost is a CLI tool for executing commands while capturing execution metadata.
 It provides structured JSON output with timing information, exit codes, and optional scoring.
 
-Perfect for testing frameworks, CI/CD pipelines, and process automation.`,
+Perfect for testing frameworks, CI/CD pipelines, and process automation.
+
+Defaults for any flag can be set in a YAML config file (~/.ghost.yaml,
+GHOST_CONFIG or --config), and bundled in named profiles selected with
+--profile. Command-line flags and environment variables take precedence
+over the config file.`,
+	PersistentPreRunE: loadConfigFile,
+}
+
+// Path to the ghost config file (--config) and the profile to apply from it (--profile)
+var (
+	configFile    string
+	configProfile string
+)
+
+// loadConfigFile applies config file defaults to the flags of the command being run
+func loadConfigFile(cmd *cobra.Command, args []string) error {
+	path, required := helpers.ResolveConfigFile(configFile)
+	settings, err := helpers.LoadConfigFile(path, required)
+	if err != nil {
+		return failure.Wrap(failure.ConfigInvalid, err)
+	}
+	return failure.Wrap(failure.ConfigInvalid, helpers.ApplyConfigFile(cmd, settings, path, helpers.ResolveProfile(configProfile)))
 }
 
 func Execute() {
-	err := rootCmd.Execute()
+	cmd, err := rootCmd.ExecuteC()
 	if err != nil {
-		os.Exit(1)
+		reportError(os.Stdout, cmd, err, helpers.ResultPrinted())
+		os.Exit(failure.ExitCode(err))
 	}
+	os.Exit(helpers.ExitCode())
+}
+
+// reportError writes the JSON error object for a failed command to w
+// Nothing is written if the command already printed its result, which explains the failure.
+func reportError(w io.Writer, cmd *cobra.Command, err error, printed bool) {
+	if printed {
+		return
+	}
+	command := strings.TrimPrefix(cmd.CommandPath(), rootCmd.Name())
+	jsonOutput, marshalErr := json.Marshal(helpers.NewErrorReport(strings.TrimSpace(command), err))
+	if marshalErr != nil {
+		return
+	}
+	fmt.Fprintln(w, string(jsonOutput))
 }
 
 func init() {
 	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(pipelineCmd)
+	rootCmd.AddCommand(buildRunCmd)
 	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(checksumCmd)
+	rootCmd.AddCommand(scoreCmd)
+	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(judgeCmd)
+	rootCmd.AddCommand(interactiveCmd)
+	rootCmd.AddCommand(stressCmd)
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(resultCmd)
+	rootCmd.AddCommand(manifestCmd)
+	rootCmd.AddCommand(fetchCmd)
+	rootCmd.AddCommand(webhookCmd)
+	rootCmd.AddCommand(schemaCmd)
+	rootCmd.AddCommand(watchCmd)
+
+	// Flag parsing errors are reported as usage errors
+	rootCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return failure.Wrap(failure.Usage, err)
+	})
+
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file (default: $GHOST_CONFIG or ~/.ghost.yaml)")
+	rootCmd.PersistentFlags().StringVar(&configProfile, "profile", "", "Profile of the config file to apply, e.g. course-cs101 (default: $GHOST_PROFILE)")
 }
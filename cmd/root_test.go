@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+	"github.com/zinc-sig/ghost/internal/failure"
+)
+
+// resetErrorTestFlags clears the run flags set by the error tests
+func resetErrorTestFlags() {
+	resetFlags(runCmd, "input", "output", "stderr", "context", "webhook-url", "webhook-timeout", "dry-run")
+	resetUploadGlobals()
+	resetWebhookGlobals()
+}
+
+func TestErrorCodes(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(input, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out.txt")
+	errFile := filepath.Join(dir, "err.txt")
+
+	tests := []struct {
+		name        string
+		args        []string
+		wantCode    failure.Code
+		wantCommand string
+	}{
+		{name: "unknown command", args: []string{"rnu"}, wantCode: failure.Usage},
+		{name: "unknown flag", args: []string{"run", "--bogus", "--", "true"}, wantCode: failure.Usage, wantCommand: "run"},
+		{name: "missing separator", args: []string{"run", "-i", input, "-o", out, "-e", errFile}, wantCode: failure.Usage, wantCommand: "run"},
+		{name: "input not found", args: []string{"run", "-i", filepath.Join(dir, "missing.txt"), "-o", out, "-e", errFile, "--", "true"}, wantCode: failure.InputNotFound, wantCommand: "run"},
+		{name: "invalid context", args: []string{"run", "-i", input, "-o", out, "-e", errFile, "--context", "{", "--", "true"}, wantCode: failure.ContextInvalid, wantCommand: "run"},
+		{name: "unknown upload provider", args: []string{"run", "-i", input, "-o", out, "-e", errFile, "--upload-provider", "nope", "--", "true"}, wantCode: failure.UploadConfigInvalid, wantCommand: "run"},
+		{name: "invalid webhook timeout", args: []string{"run", "-i", input, "-o", out, "-e", errFile, "--webhook-url", "http://127.0.0.1:1", "--webhook-timeout", "soon", "--", "true"}, wantCode: failure.WebhookConfigInvalid, wantCommand: "run"},
+		{name: "missing result file", args: []string{"score", "aggregate", filepath.Join(dir, "missing.json")}, wantCode: failure.InputNotFound, wantCommand: "score aggregate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetErrorTestFlags()
+			defer resetErrorTestFlags()
+
+			rootCmd.SetArgs(tt.args)
+			var cmdErr error
+			var executed string
+			_, _ = captureOutput(func() error {
+				cmd, err := rootCmd.ExecuteC()
+				cmdErr = err
+				if err != nil {
+					var buf bytes.Buffer
+					reportError(&buf, cmd, err, false)
+					executed = buf.String()
+				}
+				return nil
+			})
+			if cmdErr == nil {
+				t.Fatal("Expected an error")
+			}
+
+			var report struct {
+				Command   string `json:"command"`
+				Status    string `json:"status"`
+				ErrorCode string `json:"error_code"`
+				Error     string `json:"error"`
+			}
+			if err := json.Unmarshal([]byte(executed), &report); err != nil {
+				t.Fatalf("Failed to parse error report: %v\nOutput: %s", err, executed)
+			}
+			if report.ErrorCode != string(tt.wantCode) || report.Status != "error" || report.Command != tt.wantCommand {
+				t.Errorf("report = %+v, want error_code %s and command %q", report, tt.wantCode, tt.wantCommand)
+			}
+			if report.Error != cmdErr.Error() {
+				t.Errorf("error = %q, want %q", report.Error, cmdErr.Error())
+			}
+		})
+	}
+}
+
+func TestReportErrorAfterResult(t *testing.T) {
+	var buf bytes.Buffer
+	reportError(&buf, runCmd, failure.Wrap(failure.ChecksumMismatch, os.ErrInvalid), true)
+	if buf.Len() != 0 {
+		t.Errorf("Expected no error report after a printed result, got %q", buf.String())
+	}
+}
+
+// resetPropagateFlags clears --propagate-exit-code and the exit code it set
+func resetPropagateFlags() {
+	for _, cmd := range []*cobra.Command{runCmd, diffCmd} {
+		resetFlags(cmd, "propagate-exit-code")
+	}
+	helpers.ResetExitCode()
+}
+
+func TestPropagateExitCode(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(input, []byte("a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(dir, "other.txt")
+	if err := os.WriteFile(other, []byte("b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(extra ...string) []string {
+		return append([]string{"run", "-i", input, "-o", filepath.Join(dir, "out.txt"), "-e", filepath.Join(dir, "err.txt")}, extra...)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "not propagated", args: run("--", "sh", "-c", "exit 3"), want: 0},
+		{name: "success", args: run("--propagate-exit-code", "--", "true"), want: 0},
+		{name: "command exit code", args: run("--propagate-exit-code", "--", "sh", "-c", "exit 3"), want: 3},
+		{name: "expected exit code", args: run("--propagate-exit-code", "--expect-exit-code", "3", "--", "sh", "-c", "exit 3"), want: 0},
+		{name: "unexpected zero exit code", args: run("--propagate-exit-code", "--expect-exit-code", "3", "--", "true"), want: 1},
+		{name: "timeout", args: run("--propagate-exit-code", "--timeout", "100ms", "--", "sleep", "5"), want: helpers.TimeoutExitCode},
+		{name: "diff differs", args: []string{"diff", "-i", input, "-x", other, "-o", filepath.Join(dir, "diff.txt"), "-e", filepath.Join(dir, "err.txt"), "--propagate-exit-code"}, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetPropagateFlags()
+			resetScoringFlags()
+			resetTimeoutGlobals()
+			resetExpectedFlags()
+			defer resetPropagateFlags()
+			defer resetScoringFlags()
+			defer resetTimeoutGlobals()
+			defer resetExpectedFlags()
+
+			rootCmd.SetArgs(tt.args)
+			if _, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got := helpers.ExitCode(); got != tt.want {
+				t.Errorf("exit code = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
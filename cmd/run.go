@@ -3,12 +3,21 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/zinc-sig/ghost/cmd/config"
 	"github.com/zinc-sig/ghost/cmd/helpers"
-	contextparser "github.com/zinc-sig/ghost/internal/context"
+	"github.com/zinc-sig/ghost/internal/bytesize"
+	"github.com/zinc-sig/ghost/internal/environment"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/policy"
+	"github.com/zinc-sig/ghost/internal/redact"
+	"github.com/zinc-sig/ghost/internal/rubric"
 	"github.com/zinc-sig/ghost/internal/runner"
+	"github.com/zinc-sig/ghost/internal/upload"
 )
 
 var (
@@ -17,11 +26,68 @@ var (
 	outputFile string
 	stderrFile string
 
+	// Interaction script driving stdin/stdout
+	interactScript string
+
+	// Further files mapped to descriptors 3 and up (--fd) and their parsed form
+	descriptorSpecs []string
+	descriptors     []runner.FileDescriptor
+
+	// Policy file restricting which commands may be executed
+	policyFile string
+
+	// Command spec replacing the command after '--' ("-" = stdin)
+	commandFile string
+
+	// Where to copy the command's stdout live, in addition to the output file
+	teeOutputTarget string
+
+	// Maximum number of processes the command may create (0 = unlimited)
+	maxForks int
+
+	// Run the command without network access
+	noNetwork bool
+
+	// How long the command may block on stdin once the input has no more data
+	stdinTimeoutStr string
+	stdinTimeout    time.Duration
+
+	// CPU and I/O scheduling priority of the command and its parsed form
+	niceValue int
+	ioniceStr string
+	priority  *runner.Priority
+
+	// Backend that runs the command (--executor) and its resolved instance
+	executorName string
+	executor     runner.Executor
+
+	// Executor-specific settings
+	executorImage string
+	sshHost       string
+	sshDir        string
+	cgroupMode    string
+
+	// Resource limits enforced by the docker and cgroup executors
+	memoryLimitStr string
+	memoryLimit    int64
+	cpuLimit       float64
+
+	// Attempt number when a caller retries the command (0 = not set)
+	attempt int
+
+	// Grade cache directory and further files its key covers
+	cacheDir      string
+	cacheKeyFiles []string
+
 	// Common flag structures
 	runFlags         config.CommonFlags
 	runContextConfig config.ContextConfig
 	runUploadConfig  config.UploadConfig
 	runWebhookConfig config.WebhookConfig
+	runFilterConfig  config.FilterConfig
+	runCaptureConfig config.CaptureConfig
+
+	runDeterminismConfig config.DeterminismConfig
 )
 
 var runCmd = &cobra.Command{
@@ -30,49 +96,146 @@ var runCmd = &cobra.Command{
 	Long: `Execute a command while capturing execution metadata including exit codes,
 timing information, and optional scoring. Results are output as JSON.
 
-The '--' separator is required to distinguish ghost flags from the target command.`,
+The '--' separator is required to distinguish ghost flags from the target command.
+Alternatively --command-file reads the command, its arguments, environment and
+I/O paths from a JSON or YAML spec ("-" for stdin), so no shell quoting is needed.`,
 	Example: `  ghost run -i input.txt -o output.txt -e error.log -- ./my-command arg1 arg2
   ghost run -i data.csv -o results.txt -e errors.log --score 85 -- python script.py
-  ghost run -i /dev/null -o output.txt -e error.txt -- echo "Hello World"`,
+  ghost run -i /dev/null -o output.txt -e error.txt -- echo "Hello World"
+  ghost run --interact-script session.txt -o output.txt -e error.txt -- python repl.py
+  ghost run --command-file spec.json`,
 	RunE: runCommand,
 }
 
 func runCommand(cmd *cobra.Command, args []string) error {
-	// Validate command separator
-	if err := helpers.ValidateCommandSeparator(cmd, args); err != nil {
+	// Webhook settings are parsed per invocation and passed to every delivery
+	webhookConfig, webhookRetryConfig, err := helpers.ParseWebhookConfigToInternal(&runWebhookConfig)
+	if err != nil {
+		return failure.Wrap(failure.WebhookConfigInvalid, err)
+	}
+
+	// The command comes after '--' or from a command spec, which may also set the I/O paths
+	args, commandSpec, err := helpers.ResolveCommand(cmd, commandFile, args)
+	if err != nil {
 		return err
 	}
+	if commandSpec != nil {
+		commandSpec.FillIO(&inputFile, &outputFile, &stderrFile)
+	}
+
+	// Setup tracing, the run ID and the result file for this invocation
+	ctx, exec, err := helpers.StartExecution(cmd, &runFlags, webhookConfig, webhookRetryConfig)
+	if err != nil {
+		return err
+	}
+	defer exec.End()
 
-	// Validate required I/O flags
+	// Validate required I/O flags (an interaction script replaces the input file)
 	ioFlags := helpers.IOFlags{
 		Input:  inputFile,
 		Output: outputFile,
 		Stderr: stderrFile,
 	}
+	if interactScript != "" {
+		ioFlags.Input = interactScript
+	}
 	if err := helpers.ValidateIOFlags(ioFlags, false); err != nil {
-		return err
+		return failure.Wrap(failure.Usage, err)
+	}
+	if !runFlags.DryRun {
+		if err := helpers.CheckInputFile(ioFlags.Input); err != nil {
+			return err
+		}
+	}
+	if err := runner.ValidateStdinInput(ioFlags.Input, stdinTimeout); err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	// Load interaction script if provided
+	var interaction *runner.InteractionScript
+	if interactScript != "" {
+		interaction, err = runner.LoadInteractionScript(interactScript)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Load execution policy if provided
+	var execPolicy *policy.Policy
+	if policyFile != "" {
+		execPolicy, err = policy.Load(policyFile)
+		if err != nil {
+			return failure.Wrap(failure.ConfigInvalid, err)
+		}
+	}
+
+	// Line filters normalise the capture files as they are written
+	stdoutFilter, stderrFilter, err := helpers.ParseOutputFilters(&runFilterConfig)
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
 	}
 
 	targetCommand := args[0]
 	targetArgs := args[1:]
 
+	// Retries of the same run are told apart by their attempt
+	exec.Attempt = attempt
+	if exec.ResultFile != nil {
+		exec.ResultFile.Remote = helpers.ExpandAttempt(exec.ResultFile.Remote, attempt)
+	}
+
 	// Setup upload provider if configured
 	provider, uploadConf, err := helpers.SetupUploadProvider(&runUploadConfig, runFlags.DryRun)
 	if err != nil {
-		return err
+		return failure.Wrap(failure.UploadConfigInvalid, err)
+	}
+
+	// Parse the retry, encryption, bandwidth and concurrency settings of the uploads
+	uploadOpts, err := helpers.ParseUploadOptions(&runUploadConfig)
+	if err != nil {
+		return failure.Wrap(failure.UploadConfigInvalid, err)
+	}
+	uploadLimits, err := helpers.ParseUploadLimits(&runUploadConfig)
+	if err != nil {
+		return failure.Wrap(failure.UploadConfigInvalid, err)
+	}
+	presignExpiry, err := helpers.ParseUploadPresign(&runUploadConfig, provider, uploadOpts.Encryption)
+	if err != nil {
+		return failure.Wrap(failure.UploadConfigInvalid, err)
+	}
+	if err := helpers.CheckUploadCapabilities(&runUploadConfig, provider); err != nil {
+		return failure.Wrap(failure.UploadConfigInvalid, err)
+	}
+	if runUploadConfig.Streaming && runCaptureConfig.Append {
+		// The stream would only hold the output of this run
+		return failure.Wrap(failure.Usage, fmt.Errorf("--upload-streaming cannot be used with --append"))
 	}
 
 	// Parse additional upload files if specified
 	var additionalFiles map[string]string
+	var fileAttributes map[string]upload.Attributes
 	if len(runUploadConfig.UploadFiles) > 0 {
-		additionalFiles, err = helpers.ParseUploadFiles(runUploadConfig.UploadFiles)
+		additionalFiles, fileAttributes, err = helpers.ParseUploadFiles(runUploadConfig.UploadFiles)
 		if err != nil {
-			return fmt.Errorf("failed to parse upload files: %w", err)
+			return failure.Wrap(failure.UploadConfigInvalid, fmt.Errorf("failed to parse upload files: %w", err))
 		}
+		helpers.ExpandRunIDInFiles(additionalFiles, exec.RunID)
+		helpers.ExpandRunIDInAttributes(fileAttributes, exec.RunID)
+		helpers.ExpandAttemptInFiles(additionalFiles, attempt)
+		helpers.ExpandAttemptInAttributes(fileAttributes, attempt)
 	}
 
 	// Parse output paths to support local:remote syntax
-	outputPaths := helpers.ParseOutputPaths(outputFile, stderrFile)
+	outputPaths := helpers.ParseOutputPaths(outputFile, stderrFile).ExpandRunID(exec.RunID).ExpandAttempt(attempt)
+
+	// Mirror the input next to the output for provenance
+	uploadRoles := helpers.UploadRoles{}
+	if provider != nil && runUploadConfig.FromRemote {
+		additionalFiles, err = helpers.MirrorInputs(additionalFiles, uploadRoles, outputPaths.RemoteOutput, map[string]string{ioFlags.Input: helpers.UploadRoleInput})
+		if err != nil {
+			return failure.Wrap(failure.UploadConfigInvalid, err)
+		}
+	}
 
 	// Determine remote paths for display (what will be uploaded)
 	displayOutputPath := outputFile
@@ -144,105 +307,348 @@ func runCommand(cmd *cobra.Command, args []string) error {
 		defer cleanup()
 	}
 
-	config := &runner.Config{
-		Command:    targetCommand,
-		Args:       targetArgs,
-		InputFile:  inputFile,
-		OutputFile: actualOutputFile,
-		StderrFile: actualStderrFile,
-		Verbose:    runFlags.Verbose,
-		DryRun:     runFlags.DryRun,
-		Timeout:    runFlags.Timeout,
+	// Stream targets cannot be read back for upload
+	if provider != nil {
+		if err := helpers.ValidateUploadTargets(actualOutputFile, actualStderrFile); err != nil {
+			return failure.Wrap(failure.UploadConfigInvalid, err)
+		}
+	}
+
+	if err := helpers.ValidateCaptureConfig(&runCaptureConfig, actualOutputFile, actualStderrFile, cacheDir); err != nil {
+		return failure.Wrap(failure.Usage, err)
 	}
 
-	result, err := runner.Execute(config)
+	// Pinned variables override those of the command spec
+	normalization, err := environment.NewNormalization(runDeterminismConfig.Locale, runDeterminismConfig.Timezone, runDeterminismConfig.SeedEnv)
 	if err != nil {
-		return fmt.Errorf("failed to execute command: %w", err)
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	config := &runner.Config{
+		RunID:        exec.RunID,
+		Command:      targetCommand,
+		Args:         targetArgs,
+		InputFile:    ioFlags.Input,
+		OutputFile:   actualOutputFile,
+		StderrFile:   actualStderrFile,
+		Verbose:      runFlags.Verbose,
+		DryRun:       runFlags.DryRun,
+		Timeout:      runFlags.Timeout,
+		SoftTimeout:  runFlags.SoftTimeout,
+		StdinTimeout: stdinTimeout,
+		Env:          append(commandSpec.Environ(), normalization.Environ()...),
+		TeeOutput:    teeOutputTarget,
+		MaxForks:     maxForks,
+		NoNetwork:    noNetwork,
+		Priority:     priority,
+		Executor:     executor,
+
+		MemoryLimit: memoryLimit,
+		CPULimit:    cpuLimit,
+
+		StdoutFilter: stdoutFilter,
+		StderrFilter: stderrFilter,
+		Append:       runCaptureConfig.Append,
+		CombinedFile: runCaptureConfig.Combined,
+
+		ExpectExitCode: helpers.ExpectedExitCode(&runFlags),
+		ExpectNonzero:  runFlags.ExpectNonzero,
+
+		Descriptors: descriptors,
+		Interaction: interaction,
+		Policy:      execPolicy,
+	}
+
+	// Build context from all sources
+	if err := exec.BuildContext(&runContextConfig); err != nil {
+		return err
+	}
+
+	var timeoutMs int64
+	if runFlags.Timeout > 0 {
+		timeoutMs = runFlags.Timeout.Milliseconds()
+	}
+
+	// An unchanged submission gets its cached grade instead of running again
+	var cacheKey string
+	var jsonResult *output.Result
+	var streamed []output.UploadResult
+	if cacheDir != "" && !runFlags.DryRun {
+		cacheKey, jsonResult = helpers.LookupGrade(cmd, cacheDir, cacheKeyFiles, config, runFlags.Verbose)
+		if jsonResult != nil {
+			// Only the grade is reused; the context belongs to this invocation
+			jsonResult.Context = exec.Context
+		}
+	}
+
+	if jsonResult == nil {
+		// Lifecycle events let dashboards show in-progress executions
+		exec.SendStarted(ctx, config.FullCommand())
+
+		// The output is uploaded while the command writes it
+		var stream *helpers.StreamingUpload
+		if runUploadConfig.Streaming && !runFlags.DryRun {
+			stream = helpers.StartStreamingUpload(ctx, provider, upload.CompressedPath(outputPaths.RemoteOutput, runUploadConfig.Compress), uploadOpts, runFlags.Verbose)
+			config.StreamOutput = stream
+		}
+
+		result, err := helpers.ExecuteWithSpan(ctx, config)
+		if err != nil {
+			if stream != nil {
+				stream.Abort()
+			}
+			return failure.Wrap(failure.ExecutionFailed, fmt.Errorf("failed to execute command: %w", err))
+		}
+		if stream != nil {
+			// A failed stream falls back to uploading the finished file
+			if streamResult := stream.Finish(); streamResult.Success {
+				streamed = append(streamed, streamResult)
+			} else {
+				fmt.Fprintf(redact.Stderr, "[UPLOAD] Warning: streaming upload to %s failed, uploading the finished output instead: %s\n", streamResult.Remote, streamResult.Error)
+			}
+		}
+		exec.SendTimeout(ctx, result)
+
+		jsonResult = helpers.CreateJSONResult(
+			config.InputFile,
+			config.OutputFile,
+			config.StderrFile,
+			"", // No expected file for run command
+			result,
+			timeoutMs,
+			runFlags.ScoreSet,
+			runFlags.Score,
+			exec.Context,
+		)
+	}
+
+	// Map actual files to remote paths
+	uploadFiles := map[string]string{
+		actualOutputFile: outputPaths.RemoteOutput,
+		actualStderrFile: outputPaths.RemoteStderr,
+	}
+	uploadRoles[actualOutputFile] = helpers.UploadRoleOutput
+	uploadRoles[actualStderrFile] = helpers.UploadRoleStderr
+	if len(streamed) > 0 {
+		delete(uploadFiles, actualOutputFile)
 	}
 
 	// Upload files if provider is configured
+	var uploadResults []output.UploadResult
 	if provider != nil {
-		// Validate additional files exist after command execution
+		// Expand and check additional files after command execution
 		if additionalFiles != nil && !runFlags.DryRun {
-			if err := helpers.ValidateUploadFiles(additionalFiles); err != nil {
-				return err
+			additionalFiles, err = helpers.ExpandUploadFiles(additionalFiles, fileAttributes, uploadRoles, uploadLimits)
+			if err != nil {
+				return failure.Wrap(failure.UploadFailed, err)
 			}
 		}
 
-		// Map actual files to remote paths
-		files := map[string]string{
-			actualOutputFile: outputPaths.RemoteOutput,
-			actualStderrFile: outputPaths.RemoteStderr,
+		uploadResults, err = helpers.HandleUploads(ctx, provider, uploadFiles, additionalFiles, fileAttributes, uploadRoles, *uploadOpts, streamed, runFlags.Verbose, runFlags.DryRun)
+		if err == nil {
+			err = helpers.PresignUploads(ctx, provider, uploadResults, presignExpiry, runFlags.Verbose, runFlags.DryRun)
 		}
-		if err := helpers.HandleUploads(provider, files, additionalFiles, runFlags.Verbose, runFlags.DryRun); err != nil {
-			return err
+
+		exec.SendUploadFinished(ctx, jsonResult.Command, uploadResults)
+		if err != nil {
+			return failure.Wrap(failure.UploadFailed, err)
 		}
 	}
 
-	// Build context from all sources
-	ctxData, err := contextparser.BuildContext(runContextConfig.JSON, runContextConfig.KV, runContextConfig.File)
-	if err != nil {
-		return fmt.Errorf("failed to build context: %w", err)
+	jsonResult.Argv = append([]string{targetCommand}, targetArgs...)
+	jsonResult.Descriptors = helpers.RecordDescriptors(descriptors)
+	jsonResult.Determinism = helpers.RecordDeterminism(normalization)
+	jsonResult.Uploads = uploadResults
+
+	opts := helpers.FinishOptions{
+		OutputFile: config.OutputFile,
+		StderrFile: config.StderrFile,
+		Plan: &output.Plan{
+			Command: jsonResult.Command,
+			Input:   config.InputFile,
+			Output:  actualOutputFile,
+			Stderr:  actualStderrFile,
+			Upload:  helpers.PlanUploads(provider, uploadConf, &runUploadConfig, uploadFiles, additionalFiles, fileAttributes, uploadRoles, uploadOpts),
+		},
+		Provider: provider,
+		Upload:   uploadOpts,
 	}
-
-	// Print context info in dry run mode
-	if runFlags.DryRun && ctxData != nil {
-		helpers.PrintContextInfo(ctxData, true)
+	if cacheKey != "" {
+		opts.Graded = func(result *output.Result) {
+			helpers.StoreGrade(cacheDir, cacheKey, result, config.OutputFile, config.StderrFile, runFlags.Verbose)
+		}
 	}
+	return helpers.FinishExecution(ctx, exec, jsonResult, opts)
+}
 
-	// Create JSON result using common function
-	var timeoutMs int64
-	if runFlags.Timeout > 0 {
-		timeoutMs = runFlags.Timeout.Milliseconds()
+// executorFlags maps executor-specific flags to the executor and option they set
+var executorFlags = []struct{ flag, executor, option string }{
+	{"image", "docker", "image"},
+	{"ssh-host", "ssh", "host"},
+	{"ssh-dir", "ssh", "dir"},
+	{"cgroup-mode", "cgroup", "mode"},
+}
+
+// executorOptions collects the options of the selected executor from its flag values
+func executorOptions(executorName string, values map[string]string) (runner.ExecutorOptions, error) {
+	options := runner.ExecutorOptions{}
+	for _, f := range executorFlags {
+		value := values[f.flag]
+		if value == "" {
+			continue
+		}
+		if executorName != f.executor {
+			return nil, fmt.Errorf("--%s requires --executor %s", f.flag, f.executor)
+		}
+		options[f.option] = value
 	}
-	jsonResult := helpers.CreateJSONResult(
-		config.InputFile,
-		config.OutputFile,
-		config.StderrFile,
-		"", // No expected file for run command
-		result,
-		timeoutMs,
-		runFlags.ScoreSet,
-		runFlags.Score,
-		ctxData,
-	)
-
-	// Output JSON and send webhook using common function
-	return helpers.OutputJSONAndWebhook(jsonResult, runFlags.Verbose, runFlags.DryRun)
+	return options, nil
 }
 
 func init() {
 	// Command-specific flags
-	runCmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input file to redirect to command's stdin (required)")
-	runCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file to capture command's stdout (required)")
-	runCmd.Flags().StringVarP(&stderrFile, "stderr", "e", "", "Error file to capture command's stderr (required)")
-
-	// Mark flags as required
-	_ = runCmd.MarkFlagRequired("input")
-	_ = runCmd.MarkFlagRequired("output")
-	_ = runCmd.MarkFlagRequired("stderr")
+	runCmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input file to redirect to command's stdin (required unless --interact-script is used or set in --command-file)")
+	runCmd.Flags().StringVarP(&outputFile, "output", "o", "", "Output file to capture command's stdout (required unless set in --command-file)")
+	runCmd.Flags().StringVarP(&stderrFile, "stderr", "e", "", "Error file to capture command's stderr (required unless set in --command-file)")
+	runCmd.Flags().StringVar(&commandFile, "command-file", "", "JSON or YAML spec of the command, args, env and I/O paths to run instead of the command after '--' (\"-\" = stdin)")
+	runCmd.Flags().StringVar(&interactScript, "interact-script", "", "Script of send/expect steps to drive an interactive command (replaces --input)")
+	runCmd.Flags().StringVar(&policyFile, "policy-file", "", "Policy file restricting which commands may be executed")
+	runCmd.Flags().StringVar(&teeOutputTarget, "tee-output", "", "Also stream the command's stdout live to ghost's stdout or stderr")
+	runCmd.Flags().Lookup("tee-output").NoOptDefVal = runner.TeeStdout
+	runCmd.Flags().IntVar(&maxForks, "max-forks", 0, "Maximum processes the command may run (RLIMIT_NPROC, not enforced for root; 0 = unlimited)")
+	runCmd.Flags().StringVar(&stdinTimeoutStr, "stdin-timeout", "", "Kill the command with status stalled if it blocks reading stdin for this long after the input has no more data, e.g. 2s; the input must be a pipe, FIFO or terminal (Linux only)")
+	runCmd.Flags().BoolVar(&noNetwork, "no-network", false, "Run the command without network access, with only loopback (Linux network namespace, or --executor docker)")
+	runCmd.Flags().IntVar(&niceValue, "nice", 0, "Run the command with this nice value, from -20 to 19 (lowering it requires privileges; 0 = inherited)")
+	runCmd.Flags().StringVar(&ioniceStr, "ionice", "", "Run the command in this I/O scheduling class: idle, best-effort[:0-7] or realtime[:0-7] (Linux only)")
+	runCmd.Flags().StringVar(&executorName, "executor", runner.DefaultExecutor, "Backend that runs the command: "+strings.Join(runner.ExecutorNames(), ", "))
+	runCmd.Flags().StringVar(&executorImage, "image", "", "Container image to run the command in (--executor docker)")
+	runCmd.Flags().StringVar(&sshHost, "ssh-host", "", "Host to run the command on, as [user@]host or an ~/.ssh/config alias (--executor ssh)")
+	runCmd.Flags().StringVar(&sshDir, "ssh-dir", "", "Remote working directory (--executor ssh, default: the login directory)")
+	runCmd.Flags().StringVar(&cgroupMode, "cgroup-mode", "", "How to create the command's cgroup: auto, cgroupfs (delegated cgroup v2) or systemd (--executor cgroup, default: auto)")
+	runCmd.Flags().StringVar(&memoryLimitStr, "memory-limit", "", "Memory limit for the command, e.g. 512MiB (--executor docker or cgroup)")
+	runCmd.Flags().Float64Var(&cpuLimit, "cpu-limit", 0, "CPU cores available to the command, e.g. 1.5 (--executor docker or cgroup; 0 = unlimited)")
+	runCmd.Flags().BoolVar(&runUploadConfig.Streaming, "upload-streaming", false, "Upload the output while the command writes it instead of after it finished (requires --upload-provider)")
+	runCmd.Flags().StringArrayVar(&descriptorSpecs, "fd", nil, "Map a file to a further descriptor of the command as fd=path[:mode], mode r (default), w or a (repeatable, e.g. 3=cmdinput.txt)")
+	runCmd.Flags().IntVar(&attempt, "attempt", 0, "Attempt number when the caller retries this run, recorded in the result and expanded as {attempt} in upload paths (default: not set)")
+	runCmd.Flags().StringVar(&cacheDir, "cache-dir", "", "Cache grades here by the command, input and submission files, and reuse them for unchanged submissions")
+	runCmd.Flags().StringArrayVar(&cacheKeyFiles, "cache-key-file", nil, "Further file or directory whose content the cached grade depends on (repeatable, requires --cache-dir)")
+
+	// I/O flags are checked once a command spec had the chance to set them
+	runCmd.MarkFlagsMutuallyExclusive("input", "interact-script")
+	runCmd.MarkFlagsMutuallyExclusive("stdin-timeout", "interact-script")
 
 	// Setup common flags using helper
 	helpers.SetupCommonFlags(runCmd, &runFlags)
+	helpers.SetupExpectFlags(runCmd, &runFlags)
 	helpers.SetupContextFlags(runCmd, &runContextConfig)
 	helpers.SetupUploadFlags(runCmd, &runUploadConfig)
 	helpers.SetupWebhookFlags(runCmd, &runWebhookConfig)
+	helpers.SetupFilterFlags(runCmd, &runFilterConfig)
+	helpers.SetupCaptureFlags(runCmd, &runCaptureConfig)
+	helpers.SetupDeterminismFlags(runCmd, &runDeterminismConfig)
 
 	runCmd.PreRunE = func(cmd *cobra.Command, args []string) error {
 		runFlags.ScoreSet = cmd.Flags().Changed("score")
+		runFlags.RecordEnvSet = cmd.Flags().Changed("record-env")
+		runFlags.ExpectExitCodeSet = cmd.Flags().Changed("expect-exit-code")
+		if err := helpers.ValidateExpectExitCode(&runFlags); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
 
-		// Parse timeout if provided
-		var err error
-		runFlags.Timeout, err = helpers.ParseTimeout(runFlags.TimeoutStr)
+		// Validate score expression early
+		if runFlags.ScoreExpr != "" {
+			if _, err := helpers.ParseScoreExpression(runFlags.ScoreExpr); err != nil {
+				return failure.Wrap(failure.Usage, err)
+			}
+		}
+		if runFlags.Rubric != "" {
+			if _, err := rubric.Load(runFlags.Rubric); err != nil {
+				return failure.Wrap(failure.ConfigInvalid, err)
+			}
+		}
+
+		if err := runner.ValidateTeeOutput(teeOutputTarget); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		if err := runner.ValidateMaxForks(maxForks); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		options, err := executorOptions(executorName, map[string]string{
+			"image":       executorImage,
+			"ssh-host":    sshHost,
+			"ssh-dir":     sshDir,
+			"cgroup-mode": cgroupMode,
+		})
 		if err != nil {
-			return err
+			return failure.Wrap(failure.Usage, err)
+		}
+		if executor, err = runner.NewExecutor(executorName, options); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		memoryLimit = 0
+		if memoryLimitStr != "" {
+			limit, err := bytesize.Parse(memoryLimitStr)
+			if err != nil {
+				return failure.Wrap(failure.Usage, fmt.Errorf("invalid --memory-limit: %w", err))
+			}
+			memoryLimit = int64(limit)
+		}
+		if err := runner.ValidateResourceLimits(executor, memoryLimit, cpuLimit); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		if err := runner.ValidateNetworkIsolation(executor, noNetwork); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		stdinTimeout = 0
+		if stdinTimeoutStr != "" {
+			if stdinTimeout, err = time.ParseDuration(stdinTimeoutStr); err != nil {
+				return failure.Wrap(failure.Usage, fmt.Errorf("invalid --stdin-timeout: %w", err))
+			}
+		}
+		if err := runner.ValidateStdinTimeout(executor, stdinTimeout); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		descriptors = nil
+		for _, spec := range descriptorSpecs {
+			fd, err := runner.ParseFileDescriptor(spec)
+			if err != nil {
+				return failure.Wrap(failure.Usage, fmt.Errorf("invalid --fd: %w", err))
+			}
+			descriptors = append(descriptors, fd)
+		}
+		if err := runner.ValidateFileDescriptors(executor, descriptors); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		if priority, err = runner.ParsePriority(niceValue, ioniceStr); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		if err := runner.ValidatePriority(executor, priority); err != nil {
+			return failure.Wrap(failure.Usage, err)
 		}
 
-		// Parse webhook configuration
-		if err := helpers.ParseWebhookConfig(&runWebhookConfig, true); err != nil {
-			return err
+		if err := helpers.ValidateAttempt(attempt); err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+
+		if len(cacheKeyFiles) > 0 && cacheDir == "" {
+			return failure.Wrap(failure.Usage, fmt.Errorf("--cache-key-file requires --cache-dir"))
+		}
+
+		// Validate preview sizes
+		if err := helpers.ValidateEmbedFlags(&runFlags); err != nil {
+			return failure.Wrap(failure.Usage, err)
 		}
 
-		return nil
+		// Parse timeout if provided
+		runFlags.Timeout, err = helpers.ParseTimeout(runFlags.TimeoutStr)
+		if err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		runFlags.SoftTimeout, err = helpers.ParseSoftTimeout(runFlags.SoftTimeoutStr, runFlags.Timeout)
+		if err != nil {
+			return failure.Wrap(failure.Usage, err)
+		}
+		runFlags.ResultSchema, err = helpers.ParseResultSchema(runFlags.ResultSchemaStr)
+		return failure.Wrap(failure.Usage, err)
 	}
 }
@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/failure"
+)
+
+// resetRunIDFlags clears the run ID and webhook flags used by the run ID tests
+func resetRunIDFlags() {
+	resetFlags(runCmd, "run-id", "attempt", "webhook-url", "webhook-retries")
+	resetWebhookGlobals()
+	resetUploadGlobals()
+}
+
+func TestRunCommandRunID(t *testing.T) {
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+	tests := []struct {
+		name      string
+		flags     []string
+		wantRunID string // empty means a generated UUID
+		wantErr   string
+	}{
+		{
+			name: "generated run ID",
+		},
+		{
+			name:      "run ID override",
+			flags:     []string{"--run-id", "submission-42.attempt-1"},
+			wantRunID: "submission-42.attempt-1",
+		},
+		{
+			name:    "invalid run ID",
+			flags:   []string{"--run-id", "../escape"},
+			wantErr: "invalid run ID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetRunIDFlags()
+			defer resetRunIDFlags()
+
+			var headers http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				headers = r.Header.Clone()
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+			args := []string{"run", "-i", "/dev/null", "-o", filepath.Join(dir, "output.txt"),
+				"-e", filepath.Join(dir, "stderr.txt"), "--webhook-url", server.URL, "--webhook-retries", "0"}
+			args = append(args, tt.flags...)
+			args = append(args, "--", "true")
+			rootCmd.SetArgs(args)
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result struct {
+				RunID string `json:"run_id"`
+			}
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+
+			if tt.wantRunID == "" {
+				if !uuidPattern.MatchString(result.RunID) {
+					t.Errorf("Expected generated UUID run ID, got %q", result.RunID)
+				}
+			} else if result.RunID != tt.wantRunID {
+				t.Errorf("RunID = %q, want %q", result.RunID, tt.wantRunID)
+			}
+
+			if headers.Get("X-Ghost-Run-ID") != result.RunID {
+				t.Errorf("X-Ghost-Run-ID header = %q, want %q", headers.Get("X-Ghost-Run-ID"), result.RunID)
+			}
+			if headers.Get("Idempotency-Key") != result.RunID {
+				t.Errorf("Idempotency-Key header = %q, want %q", headers.Get("Idempotency-Key"), result.RunID)
+			}
+		})
+	}
+}
+
+func TestRunCommandRunIDUploadPath(t *testing.T) {
+	resetRunIDFlags()
+	defer resetRunIDFlags()
+	testFlakyProvider.reset(nil)
+
+	dir := t.TempDir()
+	extra := filepath.Join(dir, "extra.txt")
+	if err := os.WriteFile(extra, []byte("extra"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd.SetArgs([]string{"run", "-i", "/dev/null",
+		"-o", filepath.Join(dir, "output.txt") + ":runs/{run_id}/output.txt",
+		"-e", filepath.Join(dir, "stderr.txt") + ":runs/{run_id}/stderr.txt",
+		"--upload-provider", "test-flaky",
+		"--upload-files", extra + ":runs/{run_id}/extra.txt",
+		"--run-id", "abc123",
+		"--", "echo", "hi"})
+
+	if _, err := captureOutput(func() error {
+		return rootCmd.Execute()
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, remote := range []string{"runs/abc123/output.txt", "runs/abc123/stderr.txt", "runs/abc123/extra.txt"} {
+		if _, ok := testFlakyProvider.uploads[remote]; !ok {
+			t.Errorf("Expected upload to %s, got %v", remote, testFlakyProvider.uploads)
+		}
+	}
+}
+
+func TestRunCommandAttempt(t *testing.T) {
+	resetRunIDFlags()
+	defer resetRunIDFlags()
+	testFlakyProvider.reset(nil)
+
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Ghost-Attempt") == "" {
+			t.Errorf("Missing X-Ghost-Attempt header")
+		}
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// A failed attempt and its retry keep their artifacts apart
+	dir := t.TempDir()
+	for attempt, command := range []string{"false", "true"} {
+		resetRunIDFlags()
+		rootCmd.SetArgs([]string{"run", "-i", "/dev/null",
+			"-o", filepath.Join(dir, "output.txt") + ":runs/{run_id}/{attempt}/output.txt",
+			"-e", filepath.Join(dir, "stderr.txt") + ":runs/{run_id}/{attempt}/stderr.txt",
+			"--upload-provider", "test-flaky", "--webhook-url", server.URL, "--webhook-retries", "0",
+			"--run-id", "abc123", "--attempt", strconv.Itoa(attempt + 1),
+			"--", command})
+
+		output, err := captureOutput(rootCmd.Execute)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var result struct {
+			Attempt int `json:"attempt"`
+		}
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+		}
+		if result.Attempt != attempt+1 {
+			t.Errorf("Attempt = %d, want %d", result.Attempt, attempt+1)
+		}
+	}
+
+	for _, remote := range []string{"runs/abc123/1/output.txt", "runs/abc123/1/stderr.txt", "runs/abc123/2/output.txt"} {
+		if _, ok := testFlakyProvider.uploads[remote]; !ok {
+			t.Errorf("Expected upload to %s, got %v", remote, testFlakyProvider.uploads)
+		}
+	}
+	if want := []string{"abc123:attempt-1", "abc123:attempt-2"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("Idempotency-Key headers = %q, want %q", keys, want)
+	}
+}
+
+func TestRunCommandAttemptInvalid(t *testing.T) {
+	resetRunIDFlags()
+	defer resetRunIDFlags()
+
+	dir := t.TempDir()
+	rootCmd.SetArgs([]string{"run", "-i", "/dev/null", "-o", filepath.Join(dir, "output.txt"),
+		"-e", filepath.Join(dir, "stderr.txt"), "--attempt", "-1", "--", "true"})
+	_, err := captureOutput(rootCmd.Execute)
+	if err == nil || failure.CodeOf(err) != failure.Usage {
+		t.Errorf("err = %v, want %s", err, failure.Usage)
+	}
+}
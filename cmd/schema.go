@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/schema"
+)
+
+var (
+	schemaFormat       string
+	schemaResultSchema string
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema [--format json-schema|openapi] [--result-schema v1|v2] <result|event|summary>",
+	Short: "Print the JSON Schema of a payload",
+	Long: `Print the JSON Schema of a JSON payload ghost writes and sends to webhooks,
+so consumers can generate clients and validate what they receive.
+
+  result   the result of run, diff, judge, interactive, pipeline and check
+  event    a lifecycle event sent by --webhook-events
+  summary  the summary of score aggregate
+
+With --format openapi the payload and the types it uses are printed as the
+components of an OpenAPI 3.1 document instead. Every payload carries the
+schema_version it conforms to; the version changes when a field is removed or
+changes meaning, while new fields may be added without changing it. Use
+--result-schema to print the schema of results pinned with the same flag.`,
+	Example: `  ghost schema result > result.schema.json
+  ghost schema --result-schema v1 result
+  ghost schema --format openapi event`,
+	Args: cobra.ExactArgs(1),
+	RunE: schemaCommand,
+}
+
+func schemaCommand(cmd *cobra.Command, args []string) error {
+	if !slices.Contains(schema.Formats, schemaFormat) {
+		return failure.Wrap(failure.Usage, fmt.Errorf("invalid --format %q (must be one of %s)", schemaFormat, strings.Join(schema.Formats, ", ")))
+	}
+	version, err := helpers.ParseResultSchema(schemaResultSchema)
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+	document, err := schema.Generate(args[0], schemaFormat, version)
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode schema: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func init() {
+	schemaCmd.Flags().StringVar(&schemaFormat, "format", schema.FormatJSONSchema, "Output format: json-schema or openapi")
+	schemaCmd.Flags().StringVar(&schemaResultSchema, "result-schema", "v"+output.SchemaVersion, "Schema version of results: v1 or v2")
+}
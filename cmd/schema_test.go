@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// resetSchemaFlags clears the schema flags so they don't leak between tests
+func resetSchemaFlags() {
+	resetFlags(schemaCmd, "format", "result-schema")
+}
+
+func TestSchemaCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantKey string // top-level key of the printed document
+		wantErr string
+	}{
+		{
+			name:    "result json schema",
+			args:    []string{"schema", "result"},
+			wantKey: "$defs",
+		},
+		{
+			name:    "event openapi",
+			args:    []string{"schema", "--format", "openapi", "event"},
+			wantKey: "components",
+		},
+		{
+			name:    "summary",
+			args:    []string{"schema", "summary"},
+			wantKey: "$defs",
+		},
+		{
+			name:    "result v1",
+			args:    []string{"schema", "--result-schema", "v1", "result"},
+			wantKey: "$defs",
+		},
+		{
+			name:    "unknown payload",
+			args:    []string{"schema", "report"},
+			wantErr: `unknown payload "report"`,
+		},
+		{
+			name:    "unknown format",
+			args:    []string{"schema", "--format", "yaml", "result"},
+			wantErr: `invalid --format "yaml"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetSchemaFlags()
+			defer resetSchemaFlags()
+			rootCmd.SetArgs(tt.args)
+
+			stdout, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var document map[string]any
+			if err := json.Unmarshal([]byte(stdout), &document); err != nil {
+				t.Fatalf("Failed to parse schema: %v\nOutput: %s", err, stdout)
+			}
+			if _, ok := document[tt.wantKey]; !ok {
+				t.Errorf("Schema has no %q: %s", tt.wantKey, stdout)
+			}
+		})
+	}
+}
+
+func TestRunCommandResultSchema(t *testing.T) {
+	tests := []struct {
+		name        string
+		flags       []string
+		wantVersion string
+		wantArgv    bool
+		wantErr     string
+	}{
+		{name: "latest by default", wantVersion: "2", wantArgv: true},
+		{name: "pinned to v1", flags: []string{"--result-schema", "v1"}, wantVersion: "1"},
+		{name: "unknown version", flags: []string{"--result-schema", "v3"}, wantErr: `invalid --result-schema "v3"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				resetFlags(runCmd, "result-schema")
+			}()
+			args := append([]string{"run", "-i", "/dev/null", "-o", "/dev/null", "-e", "/dev/null"}, tt.flags...)
+			rootCmd.SetArgs(append(args, "--", "true"))
+			stdout, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result map[string]any
+			if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+				t.Fatalf("Failed to parse output: %v\nOutput: %s", err, stdout)
+			}
+			if result["schema_version"] != tt.wantVersion {
+				t.Errorf("schema_version = %v, want %s", result["schema_version"], tt.wantVersion)
+			}
+			if _, ok := result["argv"]; ok != tt.wantArgv {
+				t.Errorf("argv present = %v, want %v", ok, tt.wantArgv)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/config"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+	"github.com/zinc-sig/ghost/internal/aggregate"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/redact"
+)
+
+// Report formats of score aggregate
+const (
+	reportJSON   = "json"
+	reportTAP    = "tap"
+	reportNDJSON = "ndjson"
+	reportCSV    = "csv"
+)
+
+var (
+	scoreWeightsFile string
+	scoreCaseKey     string
+	scoreRunID       string
+	scoreReport      string
+	scoreColumns     []string
+	scoreVerbose     bool
+
+	scoreContextConfig config.ContextConfig
+	scoreWebhookConfig config.WebhookConfig
+)
+
+var scoreCmd = &cobra.Command{
+	Use:   "score",
+	Short: "Work with scores of ghost results",
+}
+
+var scoreAggregateCmd = &cobra.Command{
+	Use:   "aggregate [--weights <file>] [result.json...]",
+	Short: "Combine results into a weighted summary score",
+	Long: `Read ghost results and combine their scores into a single weighted summary.
+
+Results are read from the given files, or from stdin when no files (or "-") are
+given. Each file may contain one JSON result, newline-delimited JSON (NDJSON) or
+concatenated results.
+
+Each result is identified by its case key (the "input" field by default, or any
+dot-notation path via --case-key, e.g. context.case). A weights file maps case
+keys to a weight and an optional maximum score:
+
+  default:
+    weight: 1
+    max: 10
+  cases:
+    tests/hard.in:
+      weight: 2
+
+The summary contains the total weighted score, the maximum and percentage (when
+every case has a maximum) and a per-case breakdown. It can be delivered with the
+same webhook flags as run and diff.
+
+--report tap prints the cases as a TAP (Test Anything Protocol) stream instead of
+JSON, with diagnostic lines for failed cases including the start of their diff
+output. --report ndjson prints every result as read, one per line, and --report
+csv prints one row per case with the columns selected by --columns: case, weight,
+weighted_score, max_score or any dot-notation result path (default: case, status,
+exit_code, score, weighted_score, max_score). The webhook still receives the JSON
+summary.`,
+	Example: `  ghost score aggregate results/*.json
+  cat results.ndjson | ghost score aggregate --weights weights.yaml
+  ghost score aggregate --case-key context.case --webhook-url https://grader.example.com/summary results/*.json
+  ghost score aggregate --report tap results/*.json | tapview
+  ghost score aggregate --report csv --columns context.student,case,status,score results/*.json > grades.csv`,
+	RunE: scoreAggregateCommand,
+}
+
+func scoreAggregateCommand(cmd *cobra.Command, args []string) error {
+	switch scoreReport {
+	case reportJSON, reportTAP, reportNDJSON, reportCSV:
+	default:
+		return failure.Wrap(failure.Usage, fmt.Errorf("invalid report format %q (must be %s, %s, %s or %s)", scoreReport, reportJSON, reportTAP, reportNDJSON, reportCSV))
+	}
+	if cmd.Flags().Changed("columns") && scoreReport != reportCSV {
+		return failure.Wrap(failure.Usage, fmt.Errorf("--columns can only be used with --report %s", reportCSV))
+	}
+	columns := aggregate.DefaultCSVColumns
+	if cmd.Flags().Changed("columns") {
+		columns = scoreColumns
+	}
+	if err := aggregate.ValidateColumns(columns); err != nil {
+		return failure.Wrap(failure.Usage, fmt.Errorf("invalid --columns: %w", err))
+	}
+
+	var weights *aggregate.Weights
+	if scoreWeightsFile != "" {
+		var err error
+		weights, err = aggregate.LoadWeights(scoreWeightsFile)
+		if err != nil {
+			return failure.Wrap(failure.ConfigInvalid, err)
+		}
+	}
+
+	webhookConfig, retryConfig, err := helpers.ParseWebhookConfigToInternal(&scoreWebhookConfig)
+	if err != nil {
+		return failure.Wrap(failure.WebhookConfigInvalid, err)
+	}
+
+	runID, err := helpers.ResolveRunID(scoreRunID)
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	// Read results from files, or stdin when none are given
+	if len(args) == 0 {
+		args = []string{"-"}
+	}
+	var results []aggregate.Result
+	for _, path := range args {
+		fileResults, err := readScoreResults(cmd, path)
+		if err != nil {
+			return err
+		}
+		results = append(results, fileResults...)
+	}
+	if len(results) == 0 {
+		return fmt.Errorf("no results to aggregate")
+	}
+
+	ctxData, err := helpers.BuildContext(&scoreContextConfig)
+	if err != nil {
+		return failure.Wrap(failure.ContextInvalid, fmt.Errorf("failed to build context: %w", err))
+	}
+
+	summary := aggregate.Aggregate(results, weights)
+	report := &output.ScoreSummary{
+		SchemaVersion: output.SchemaVersion,
+		RunID:         runID,
+		Command:       "score aggregate",
+		Status:        "success",
+		Score:         summary.Score,
+		MaxScore:      summary.MaxScore,
+		Percentage:    summary.Percentage,
+		Passed:        summary.Passed,
+		Total:         summary.Total,
+		Cases:         make([]output.ScoreCase, 0, len(summary.Cases)),
+		Context:       ctxData,
+	}
+	if summary.Passed != summary.Total {
+		report.Status = "failed"
+	}
+	for _, c := range summary.Cases {
+		report.Cases = append(report.Cases, output.ScoreCase{
+			Case:          c.Key,
+			Status:        c.Status,
+			Score:         c.Score,
+			Weight:        c.Weight,
+			WeightedScore: c.WeightedScore,
+			MaxScore:      c.MaxScore,
+		})
+	}
+
+	// Send the summary without the local webhook status fields
+	sent, spooled, err := helpers.SendWebhook(helpers.CommandContext(cmd), webhookConfig, retryConfig, runID, *report, scoreVerbose, false)
+	report.WebhookSent = sent
+	report.WebhookSpooled = spooled
+	if err != nil {
+		report.WebhookError = redact.Error(err)
+	}
+
+	switch scoreReport {
+	case reportTAP:
+		helpers.MarkResultPrinted()
+		return aggregate.WriteTAP(os.Stdout, summary)
+	case reportNDJSON:
+		helpers.MarkResultPrinted()
+		return aggregate.WriteNDJSON(os.Stdout, summary)
+	case reportCSV:
+		helpers.MarkResultPrinted()
+		return aggregate.WriteCSV(os.Stdout, summary, columns)
+	}
+	return helpers.PrintJSON(report)
+}
+
+// readScoreResults reads the results in a file, or stdin for "-"
+func readScoreResults(cmd *cobra.Command, path string) ([]aggregate.Result, error) {
+	if path == "-" {
+		return aggregate.ReadResults(cmd.InOrStdin(), "stdin", scoreCaseKey)
+	}
+
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, failure.Wrap(failure.InputNotFound, fmt.Errorf("failed to open result file: %w", err))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open result file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	return aggregate.ReadResults(file, path, scoreCaseKey)
+}
+
+func init() {
+	scoreCmd.AddCommand(scoreAggregateCmd)
+
+	scoreAggregateCmd.Flags().StringVar(&scoreWeightsFile, "weights", "", "Weights file mapping case keys to weight and max score (YAML or JSON)")
+	scoreAggregateCmd.Flags().StringVar(&scoreCaseKey, "case-key", aggregate.DefaultCaseKey, "Dot-notation path of the result field identifying a case")
+	scoreAggregateCmd.Flags().StringVar(&scoreRunID, "run-id", "", "Run ID for the summary (default: generated UUID)")
+	scoreAggregateCmd.Flags().StringVar(&scoreReport, "report", reportJSON, "Output format: json, tap, ndjson or csv")
+	scoreAggregateCmd.Flags().StringSliceVar(&scoreColumns, "columns", nil, "Columns of the csv report: case, weight, weighted_score, max_score or result paths such as context.student (comma-separated)")
+	scoreAggregateCmd.Flags().BoolVarP(&scoreVerbose, "verbose", "v", false, "Show webhook delivery details on stderr")
+
+	helpers.SetupContextFlags(scoreAggregateCmd, &scoreContextConfig)
+	helpers.SetupWebhookFlags(scoreAggregateCmd, &scoreWebhookConfig)
+}
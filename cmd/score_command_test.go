@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// resetContextFlags clears the run context flags so they don't leak between tests
+func resetContextFlags() {
+	resetFlags(runCmd, "context-kv", "context", "context-file", "context-git")
+}
+
+// writeGrader writes an executable grading script and returns its path
+func writeGrader(t *testing.T, dir, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, "grade.sh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunCommandScoreCommand(t *testing.T) {
+	tests := []struct {
+		name         string
+		grader       string
+		flags        []string
+		wantScore    string
+		wantFeedback string
+		wantContext  map[string]any
+		wantErr      string
+	}{
+		{
+			name: "score, feedback and context merged",
+			// The grader sees the result on stdin
+			grader: `if grep -q '"exit_code":0'; then
+  echo '{"score": 7.5, "feedback": "good", "context": {"rubric": "v2"}}'
+fi
+`,
+			flags:        []string{"--context-kv", "student=s1"},
+			wantScore:    "7.5",
+			wantFeedback: "good",
+			wantContext:  map[string]any{"student": "s1", "rubric": "v2"},
+		},
+		{
+			name:         "overrides score expression",
+			grader:       "cat > /dev/null\necho '{\"score\": 3}'\n",
+			flags:        []string{"--score-expr", "10"},
+			wantScore:    "3",
+			wantFeedback: "",
+		},
+		{
+			name:    "grader failure",
+			grader:  "echo 'rubric missing' >&2\nexit 2\n",
+			wantErr: "rubric missing",
+		},
+		{
+			name:    "invalid response",
+			grader:  "echo 'not json'\n",
+			wantErr: "failed to parse score command output",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetScoringFlags()
+			resetContextFlags()
+			defer resetScoringFlags()
+			defer resetContextFlags()
+
+			dir := t.TempDir()
+			args := []string{"run", "-i", "/dev/null", "-o", filepath.Join(dir, "output.txt"),
+				"-e", filepath.Join(dir, "stderr.txt"), "--score-command", writeGrader(t, dir, tt.grader)}
+			args = append(args, tt.flags...)
+			args = append(args, "--", "true")
+			rootCmd.SetArgs(args)
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result struct {
+				Score    string         `json:"score"`
+				Feedback string         `json:"feedback"`
+				Context  map[string]any `json:"context"`
+			}
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if result.Score != tt.wantScore {
+				t.Errorf("Score = %s, want %s", result.Score, tt.wantScore)
+			}
+			if result.Feedback != tt.wantFeedback {
+				t.Errorf("Feedback = %q, want %q", result.Feedback, tt.wantFeedback)
+			}
+			for k, v := range tt.wantContext {
+				if result.Context[k] != v {
+					t.Errorf("Context[%s] = %v, want %v", k, result.Context[k], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// resetScoreFlags clears score aggregate flags so they don't leak between tests
+func resetScoreFlags() {
+	resetFlags(scoreAggregateCmd, "weights", "case-key", "run-id", "report", "webhook-url", "webhook-retries", "columns")
+	rootCmd.SetIn(nil)
+}
+
+func TestScoreAggregateCommand(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.json")
+	_ = os.WriteFile(first, []byte(`{"input": "a.in", "status": "success", "score": "10"}`), 0644)
+	ndjson := filepath.Join(dir, "rest.ndjson")
+	_ = os.WriteFile(ndjson, []byte("{\"input\": \"b.in\", \"status\": \"success\", \"score\": \"4\"}\n{\"input\": \"c.in\", \"status\": \"failed\", \"score\": \"0\"}\n"), 0644)
+	weights := filepath.Join(dir, "weights.yaml")
+	_ = os.WriteFile(weights, []byte("default:\n  max: 10\ncases:\n  b.in:\n    weight: 0.5\n"), 0644)
+
+	tests := []struct {
+		name           string
+		args           []string
+		stdin          string
+		wantStatus     string
+		wantScore      string
+		wantPercentage string
+		wantErr        string
+	}{
+		{
+			name:       "files without weights",
+			args:       []string{first, ndjson},
+			wantStatus: "failed",
+			wantScore:  "14",
+		},
+		{
+			name:           "weighted with percentage",
+			args:           []string{"--weights", weights, first, ndjson},
+			wantStatus:     "failed",
+			wantScore:      "12",
+			wantPercentage: "48",
+		},
+		{
+			name:       "stdin",
+			stdin:      `{"input": "a.in", "status": "success", "score": "10"}`,
+			wantStatus: "success",
+			wantScore:  "10",
+		},
+		{
+			name:    "no results",
+			stdin:   "",
+			wantErr: "no results to aggregate",
+		},
+		{
+			name:    "missing file",
+			args:    []string{filepath.Join(dir, "missing.json")},
+			wantErr: "failed to open result file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetScoreFlags()
+			defer resetScoreFlags()
+
+			rootCmd.SetArgs(append([]string{"score", "aggregate"}, tt.args...))
+			rootCmd.SetIn(strings.NewReader(tt.stdin))
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var summary struct {
+				Status     string  `json:"status"`
+				Score      string  `json:"score"`
+				Percentage *string `json:"percentage"`
+			}
+			if err := json.Unmarshal([]byte(output), &summary); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if summary.Status != tt.wantStatus || summary.Score != tt.wantScore {
+				t.Errorf("Status = %s, score = %s, want %s and %s", summary.Status, summary.Score, tt.wantStatus, tt.wantScore)
+			}
+			if tt.wantPercentage == "" {
+				if summary.Percentage != nil {
+					t.Errorf("Percentage = %s, want none", *summary.Percentage)
+				}
+			} else if summary.Percentage == nil || *summary.Percentage != tt.wantPercentage {
+				t.Errorf("Percentage = %v, want %s", summary.Percentage, tt.wantPercentage)
+			}
+		})
+	}
+}
+
+func TestScoreAggregateWebhook(t *testing.T) {
+	resetScoreFlags()
+	defer resetScoreFlags()
+
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	rootCmd.SetArgs([]string{"score", "aggregate", "--webhook-url", server.URL, "--webhook-retries", "0", "--run-id", "summary-1"})
+	rootCmd.SetIn(strings.NewReader(`{"input": "a.in", "status": "success", "score": "10"}`))
+
+	output, err := captureOutput(func() error {
+		return rootCmd.Execute()
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var local, sent map[string]any
+	if err := json.Unmarshal([]byte(output), &local); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v", err)
+	}
+	if err := json.Unmarshal(body, &sent); err != nil {
+		t.Fatalf("Failed to parse webhook payload: %v\nPayload: %s", err, body)
+	}
+	if local["webhook_sent"] != true {
+		t.Errorf("Expected webhook_sent in local output, got %v", local["webhook_sent"])
+	}
+	if _, ok := sent["webhook_sent"]; ok {
+		t.Error("Webhook payload should not include webhook status fields")
+	}
+	if sent["run_id"] != "summary-1" || sent["score"] != "10" {
+		t.Errorf("Webhook payload = %v", sent)
+	}
+}
+
+func TestScoreAggregateTAPReport(t *testing.T) {
+	dir := t.TempDir()
+	diffFile := filepath.Join(dir, "b.diff")
+	_ = os.WriteFile(diffFile, []byte("1c1\n< 41\n---\n> 42\n"), 0644)
+	results := filepath.Join(dir, "results.ndjson")
+	_ = os.WriteFile(results, []byte(`{"input": "a.in", "status": "success", "score": "10"}
+{"input": "b.in", "expected": "b.out", "output": "`+diffFile+`", "status": "failed", "exit_code": 1, "score": "0"}
+`), 0644)
+
+	tests := []struct {
+		name     string
+		args     []string
+		want     []string
+		wantErr  string
+		wantJSON bool
+	}{
+		{
+			name: "tap",
+			args: []string{"--report", "tap", results},
+			want: []string{
+				"TAP version 13\n1..2\nok 1 - a.in\nnot ok 2 - b.in\n",
+				"# status: failed (exit code 1)\n",
+				"#   > 42\n",
+				"# passed 1 of 2, score 10\n",
+			},
+		},
+		{
+			name: "ndjson",
+			args: []string{"--report", "ndjson", results},
+			want: []string{
+				"{\"input\":\"a.in\",\"score\":\"10\",\"status\":\"success\"}\n{\"exit_code\":1,",
+			},
+		},
+		{
+			name: "csv",
+			args: []string{"--report", "csv", results},
+			want: []string{"case,status,exit_code,score,weighted_score,max_score\na.in,success,,10,10,\nb.in,failed,1,0,0,\n"},
+		},
+		{
+			name: "csv columns",
+			args: []string{"--report", "csv", "--columns", "status,case,expected", results},
+			want: []string{"status,case,expected\nsuccess,a.in,\nfailed,b.in,b.out\n"},
+		},
+		{name: "json by default", args: []string{results}, wantJSON: true},
+		{name: "unknown format", args: []string{"--report", "junit", results}, wantErr: `invalid report format "junit"`},
+		{name: "columns without csv", args: []string{"--columns", "status", results}, wantErr: "--columns can only be used with --report csv"},
+		{name: "invalid column", args: []string{"--report", "csv", "--columns", "status,,case", results}, wantErr: "invalid --columns"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetScoreFlags()
+			defer resetScoreFlags()
+
+			rootCmd.SetArgs(append([]string{"score", "aggregate"}, tt.args...))
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tt.wantJSON && !json.Valid([]byte(output)) {
+				t.Errorf("Output is not JSON: %s", output)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(output, w) {
+					t.Errorf("Output missing %q\nGot:\n%s", w, output)
+				}
+			}
+		})
+	}
+}
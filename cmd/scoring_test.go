@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// resetScoringFlags clears scoring-related run flags so they don't leak between tests
+func resetScoringFlags() {
+	resetFlags(runCmd, "score", "score-expr", "score-command", "rubric", "expect-exit-code", "expect-nonzero")
+	runFlags.Score = ""
+	runFlags.ScoreSet = false
+	runFlags.ScoreExpr = ""
+	runFlags.ScoreCmd = ""
+	runFlags.Rubric = ""
+	runFlags.ExpectExitCode = 0
+	runFlags.ExpectExitCodeSet = false
+	runFlags.ExpectNonzero = false
+}
+
+func TestRunCommandExpectExitCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		flags      []string
+		script     string
+		wantStatus string
+		wantScore  *string
+	}{
+		{
+			name:       "expected exit code earns score",
+			flags:      []string{"--expect-exit-code", "2"},
+			script:     "exit 2",
+			wantStatus: "success",
+			wantScore:  stringPtr("10"),
+		},
+		{
+			name:       "unexpected zero exit code is a failure",
+			flags:      []string{"--expect-exit-code", "2"},
+			script:     "exit 0",
+			wantStatus: "failed",
+			wantScore:  stringPtr("0"),
+		},
+		{
+			name:       "expect nonzero earns score",
+			flags:      []string{"--expect-nonzero"},
+			script:     "exit 7",
+			wantStatus: "success",
+			wantScore:  stringPtr("10"),
+		},
+		{
+			name:       "signalled command is not a nonzero exit",
+			flags:      []string{"--expect-nonzero"},
+			script:     "kill -SEGV $$",
+			wantStatus: "failed",
+			wantScore:  stringPtr("0"),
+		},
+		{
+			name:       "negative expected exit code",
+			flags:      []string{"--expect-exit-code", "-1"},
+			script:     "kill -SEGV $$",
+			wantStatus: "",
+		},
+		{
+			name:       "expected exit code out of range",
+			flags:      []string{"--expect-exit-code", "256"},
+			script:     "exit 0",
+			wantStatus: "",
+		},
+		{
+			name:       "conflicting expectations",
+			flags:      []string{"--expect-exit-code", "1", "--expect-nonzero"},
+			script:     "exit 1",
+			wantStatus: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTimeoutGlobals()
+			resetScoringFlags()
+			defer resetScoringFlags()
+
+			dir := t.TempDir()
+			inputFile := filepath.Join(dir, "input.txt")
+			if err := os.WriteFile(inputFile, []byte(""), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			args := []string{"run", "-i", inputFile, "-o", filepath.Join(dir, "output.txt"),
+				"-e", filepath.Join(dir, "stderr.txt"), "--score", "10"}
+			args = append(args, tt.flags...)
+			args = append(args, "--", "sh", "-c", tt.script)
+			rootCmd.SetArgs(args)
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+
+			if tt.wantStatus == "" {
+				if err == nil {
+					t.Error("Expected error for invalid expectation")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result struct {
+				Status string  `json:"status"`
+				Score  *string `json:"score,omitempty"`
+			}
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			if result.Score == nil || *result.Score != *tt.wantScore {
+				t.Errorf("Score = %v, want %s", result.Score, *tt.wantScore)
+			}
+		})
+	}
+}
+
+func TestRunCommandScoreExpr(t *testing.T) {
+	tests := []struct {
+		name      string
+		flags     []string
+		script    string
+		wantScore string
+		wantErr   bool
+	}{
+		{
+			name:      "partial credit on failure",
+			flags:     []string{"--score-expr", `exit_code == 0 ? 100 : (status == "timeout" ? 0 : 50)`},
+			script:    "exit 1",
+			wantScore: "50",
+		},
+		{
+			name:      "full credit on success",
+			flags:     []string{"--score-expr", `exit_code == 0 ? 100 : 50`},
+			script:    "exit 0",
+			wantScore: "100",
+		},
+		{
+			name:      "uses base score",
+			flags:     []string{"--score", "80", "--score-expr", "status == 'success' ? score : score / 4"},
+			script:    "exit 3",
+			wantScore: "20",
+		},
+		{
+			name:    "invalid expression",
+			flags:   []string{"--score-expr", "exit_code =="},
+			script:  "exit 0",
+			wantErr: true,
+		},
+		{
+			name:    "unknown variable",
+			flags:   []string{"--score-expr", "points * 2"},
+			script:  "exit 0",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTimeoutGlobals()
+			resetScoringFlags()
+			defer resetScoringFlags()
+
+			dir := t.TempDir()
+			inputFile := filepath.Join(dir, "input.txt")
+			if err := os.WriteFile(inputFile, []byte(""), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			args := []string{"run", "-i", inputFile, "-o", filepath.Join(dir, "output.txt"),
+				"-e", filepath.Join(dir, "stderr.txt")}
+			args = append(args, tt.flags...)
+			args = append(args, "--", "sh", "-c", tt.script)
+			rootCmd.SetArgs(args)
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result struct {
+				Score *string `json:"score,omitempty"`
+			}
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if result.Score == nil || *result.Score != tt.wantScore {
+				t.Errorf("Score = %v, want %s", result.Score, tt.wantScore)
+			}
+		})
+	}
+}
+
+func TestRunCommandRubric(t *testing.T) {
+	resetTimeoutGlobals()
+	resetScoringFlags()
+	defer resetScoringFlags()
+
+	dir := t.TempDir()
+	inputFile := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(inputFile, []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rubricFile := filepath.Join(dir, "rubric.yaml")
+	rubricYAML := `criteria:
+  - check: compiles
+    points: 10
+  - check: runs
+    points: 20
+  - check: time_limit
+    limit: 10s
+    points: 5
+    requires: [runs]
+`
+	if err := os.WriteFile(rubricFile, []byte(rubricYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd.SetArgs([]string{"run", "-i", inputFile, "-o", filepath.Join(dir, "output.txt"),
+		"-e", filepath.Join(dir, "stderr.txt"), "--rubric", rubricFile, "--", "sh", "-c", "exit 1"})
+	out, err := captureOutput(func() error { return rootCmd.Execute() })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var result struct {
+		Score  string `json:"score"`
+		Rubric struct {
+			Criteria []struct {
+				Name   string `json:"name"`
+				Passed bool   `json:"passed"`
+				Reason string `json:"reason"`
+			} `json:"criteria"`
+			Total string `json:"total"`
+			Max   string `json:"max"`
+		} `json:"rubric"`
+	}
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, out)
+	}
+	if result.Score != "10" || result.Rubric.Total != "10" || result.Rubric.Max != "35" {
+		t.Errorf("score = %s, rubric total = %s of %s, want 10 of 35", result.Score, result.Rubric.Total, result.Rubric.Max)
+	}
+	if len(result.Rubric.Criteria) != 3 {
+		t.Fatalf("criteria = %+v, want 3", result.Rubric.Criteria)
+	}
+	if c := result.Rubric.Criteria[2]; c.Passed || c.Reason != "requires runs" {
+		t.Errorf("time_limit = %+v, want failed as it requires runs", c)
+	}
+}
@@ -0,0 +1,99 @@
+//go:build linux
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func resetStdinTimeoutFlags() {
+	resetFlags(runCmd, "stdin-timeout", "interact-script", "executor", "image")
+}
+
+func TestRunCommandStdinTimeout(t *testing.T) {
+	resetStdinTimeoutFlags()
+	defer resetStdinTimeoutFlags()
+
+	// The FIFO stays open for writing, so the command never sees EOF
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.fifo")
+	if err := syscall.Mkfifo(input, 0600); err != nil {
+		t.Fatalf("Mkfifo() error = %v", err)
+	}
+	writer, err := os.OpenFile(input, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("Failed to open FIFO: %v", err)
+	}
+	defer func() { _ = writer.Close() }()
+	_, _ = writer.WriteString("3\n")
+
+	rootCmd.SetArgs([]string{"run", "-i", input, "-o", filepath.Join(dir, "output.txt"), "-e", filepath.Join(dir, "stderr.txt"),
+		"--timeout", "10s", "--stdin-timeout", "200ms", "--", "sh", "-c", "read n; echo $n; read more"})
+
+	output, err := captureOutput(func() error {
+		return rootCmd.Execute()
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var result struct {
+		Status        string `json:"status"`
+		ExitCode      int    `json:"exit_code"`
+		ExecutionTime int64  `json:"execution_time"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+	}
+	if result.Status != "stalled" || result.ExitCode != -1 {
+		t.Errorf("Status = %s (exit code %d), want stalled (exit code -1)", result.Status, result.ExitCode)
+	}
+	if result.ExecutionTime >= 5000 {
+		t.Errorf("ExecutionTime = %dms, want the stall reported before the timeout", result.ExecutionTime)
+	}
+}
+
+func TestRunCommandStdinTimeoutInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   []string
+		input   string // "" = /dev/null
+		wantErr string
+	}{
+		{name: "not a duration", flags: []string{"--stdin-timeout", "soon"}, wantErr: "invalid --stdin-timeout"},
+		{name: "negative", flags: []string{"--stdin-timeout", "-1s"}, wantErr: "must be 0 (off) or positive"},
+		{name: "remote executor", flags: []string{"--stdin-timeout", "1s", "--executor", "docker", "--image", "alpine"}, wantErr: "--stdin-timeout is not supported by the docker executor"},
+		// A regular file ends with EOF, so reading it never stalls
+		{name: "regular file input", flags: []string{"--stdin-timeout", "1s"}, input: "input.txt", wantErr: "requires a pipe, FIFO or terminal as input"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetStdinTimeoutFlags()
+			defer resetStdinTimeoutFlags()
+
+			dir := t.TempDir()
+			input := "/dev/null"
+			if tt.input != "" {
+				input = filepath.Join(dir, tt.input)
+				if err := os.WriteFile(input, []byte("3\n"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			rootCmd.SetArgs(append(append([]string{"run", "-i", input, "-o", filepath.Join(dir, "output.txt"),
+				"-e", filepath.Join(dir, "stderr.txt")}, tt.flags...), "--", "true"))
+
+			_, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
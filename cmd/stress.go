@@ -0,0 +1,224 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/config"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+	"github.com/zinc-sig/ghost/internal/compare"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/progress"
+	"github.com/zinc-sig/ghost/internal/redact"
+	"github.com/zinc-sig/ghost/internal/runner"
+	"github.com/zinc-sig/ghost/internal/stress"
+)
+
+var (
+	stressGenerator     string
+	stressSeeds         string
+	stressReference     string
+	stressDiffFlags     string
+	stressTimeoutStr    string
+	stressWorkDir       string
+	stressStopOnFailure bool
+	stressVerbose       bool
+	stressProgress      config.ProgressConfig
+)
+
+var stressCmd = &cobra.Command{
+	Use:   "stress --input-generator <command> --reference <command> [--seeds <list>] -- <command> [args...]",
+	Short: "Stress-test a program against a reference solution on generated inputs",
+	Long: `Generate an input for every seed, run the program and a reference solution on
+it, and compare their outputs.
+
+The input generator is run with {seed} in its arguments replaced by the seed and
+its stdout becomes the input. Seeds are a comma-separated list of seeds and
+inclusive ranges (default: 1..100). The generator and reference command lines are
+split on whitespace without shell interpretation.
+
+Outputs are compared with the built-in engine; --diff-flags accepts the flags it
+supports (e.g. -w or --ignore-trailing-space). Each seed passes if the program
+succeeds and its output matches the reference output.
+
+The files of every seed (input.txt, output.txt, stderr.txt, expected.txt, diff.txt)
+are written to <work dir>/<seed>/. Without --work-dir a temporary directory is
+used, which is removed unless a seed failed.
+
+With --progress json a JSON line is written to stderr (or --progress-fd) before
+the first seed (batch_started), after every seed (case_done) and at the end
+(batch_finished), with the counts so far.
+
+Results are written as JSON with the outcome of every seed.`,
+	Example: `  ghost stress --input-generator './gen.py {seed}' --seeds 1..50 --reference ./brute -- ./solution
+  ghost stress --input-generator 'python3 gen.py --seed {seed} --n 10' --reference 'python3 ref.py' \
+    --stop-on-failure --timeout 2s --work-dir stress/ -- ./solution`,
+	RunE: stressCommand,
+}
+
+func stressCommand(cmd *cobra.Command, args []string) error {
+	if err := helpers.ValidateCommandSeparator(cmd, args); err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	seeds, err := stress.ParseSeeds(stressSeeds)
+	if err != nil {
+		return failure.Wrap(failure.Usage, fmt.Errorf("invalid --seeds: %w", err))
+	}
+	generator := strings.Fields(stressGenerator)
+	if len(generator) == 0 {
+		return failure.Wrap(failure.Usage, fmt.Errorf("--input-generator must not be empty"))
+	}
+	reference := strings.Fields(stressReference)
+	if len(reference) == 0 {
+		return failure.Wrap(failure.Usage, fmt.Errorf("--reference must not be empty"))
+	}
+	timeout, err := helpers.ParseTimeout(stressTimeoutStr)
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+	textOptions, err := compare.ParseTextOptions(strings.Fields(stressDiffFlags))
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+	reporter, err := progress.Open(stressProgress.Format, stressProgress.FD, "stress", len(seeds))
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	workDir := stressWorkDir
+	if workDir == "" {
+		workDir, err = os.MkdirTemp("", "ghost-stress-*")
+		if err != nil {
+			return fmt.Errorf("failed to create work directory: %w", err)
+		}
+	} else if err := os.MkdirAll(workDir, 0755); err != nil {
+		return fmt.Errorf("failed to create work directory: %w", err)
+	}
+
+	report := &output.StressReport{
+		Command: "stress",
+		Status:  "success",
+		WorkDir: workDir,
+		Cases:   make([]output.StressCase, 0, len(seeds)),
+	}
+
+	ctx := helpers.CommandContext(cmd)
+	reporter.Start()
+	for _, seed := range seeds {
+		seedCase, err := runStressSeed(ctx, seed, filepath.Join(workDir, strconv.FormatInt(seed, 10)), generator, reference, args, timeout, textOptions)
+		if err != nil {
+			reporter.Finish("error")
+			return err
+		}
+		report.Cases = append(report.Cases, *seedCase)
+		report.Seeds++
+		if stressVerbose {
+			fmt.Fprintf(redact.Stderr, "[STRESS] Seed %d: %s\n", seed, seedCase.Status)
+		}
+		reporter.CaseDone("seed "+strconv.FormatInt(seed, 10), seedCase.Status, seedCase.Status == stress.CasePassed)
+
+		if seedCase.Status == stress.CasePassed {
+			report.Passed++
+			continue
+		}
+		report.Failed++
+		if stressStopOnFailure {
+			break
+		}
+	}
+
+	if report.Failed > 0 {
+		report.Status = "failed"
+	} else if stressWorkDir == "" {
+		// Nothing worth inspecting
+		_ = os.RemoveAll(workDir)
+		report.WorkDir = ""
+	}
+
+	reporter.Finish(report.Status)
+	return helpers.PrintJSON(report)
+}
+
+// runStressSeed generates the input of one seed and judges the program against the reference on it
+func runStressSeed(ctx context.Context, seed int64, dir string, generator, reference, program []string, timeout time.Duration, textOptions compare.TextOptions) (*output.StressCase, error) {
+	seedCase := &output.StressCase{Seed: seed}
+	input := filepath.Join(dir, stress.InputFile)
+	expected := filepath.Join(dir, stress.ExpectedFile)
+	programOutput := filepath.Join(dir, stress.OutputFile)
+
+	steps := []struct {
+		name    string
+		command []string
+		input   string
+		output  string
+		stderr  string
+		failed  string
+	}{
+		{"input generator", stress.ExpandSeed(generator, seed), os.DevNull, input, stress.GeneratorStderrFile, stress.CaseGeneratorFailed},
+		{"reference", reference, input, expected, stress.ReferenceStderrFile, stress.CaseReferenceFailed},
+		{"command", program, input, programOutput, stress.StderrFile, ""},
+	}
+	for _, step := range steps {
+		result, err := helpers.ExecuteWithSpan(ctx, &runner.Config{
+			Command:    step.command[0],
+			Args:       step.command[1:],
+			InputFile:  step.input,
+			OutputFile: step.output,
+			StderrFile: filepath.Join(dir, step.stderr),
+			Timeout:    timeout,
+		})
+		if err != nil {
+			return nil, failure.Wrap(failure.ExecutionFailed, fmt.Errorf("failed to run %s for seed %d: %w", step.name, seed, err))
+		}
+		if step.failed == "" {
+			seedCase.ExitCode = result.ExitCode
+			seedCase.ExecutionTime = result.ExecutionTime
+		}
+		if result.Status != runner.StatusSuccess {
+			seedCase.Status = step.failed
+			if seedCase.Status == "" {
+				seedCase.Status = string(result.Status)
+			}
+			return seedCase, nil
+		}
+	}
+
+	diff, err := os.Create(filepath.Join(dir, stress.DiffFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create diff file: %w", err)
+	}
+	defer func() { _ = diff.Close() }()
+
+	equal, err := compare.DiffFiles(ctx, diff, programOutput, expected, textOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare outputs for seed %d: %w", seed, err)
+	}
+	seedCase.Status = stress.CaseWrongAnswer
+	if equal {
+		seedCase.Status = stress.CasePassed
+	}
+	return seedCase, nil
+}
+
+func init() {
+	stressCmd.Flags().StringVar(&stressGenerator, "input-generator", "", "Command that prints the input for a seed; {seed} is replaced by the seed (required)")
+	stressCmd.Flags().StringVar(&stressSeeds, "seeds", "1..100", "Seeds to test: comma-separated seeds and inclusive ranges (e.g. 1..50,100)")
+	stressCmd.Flags().StringVar(&stressReference, "reference", "", "Reference solution whose output is expected (required)")
+	stressCmd.Flags().StringVar(&stressDiffFlags, "diff-flags", "", "Comparison flags of the built-in engine (e.g. \"-w -B\")")
+	stressCmd.Flags().StringVar(&stressTimeoutStr, "timeout", "", "Timeout for each generator, reference and program run (e.g. 2s)")
+	stressCmd.Flags().StringVar(&stressWorkDir, "work-dir", "", "Directory for the files of every seed, kept afterwards (default: temporary, kept only if a seed failed)")
+	stressCmd.Flags().BoolVar(&stressStopOnFailure, "stop-on-failure", false, "Stop at the first seed that fails")
+	stressCmd.Flags().BoolVarP(&stressVerbose, "verbose", "v", false, "Show the outcome of every seed on stderr")
+	helpers.SetupProgressFlags(stressCmd, &stressProgress)
+
+	_ = stressCmd.MarkFlagRequired("input-generator")
+	_ = stressCmd.MarkFlagRequired("reference")
+}
@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/zinc-sig/ghost/cmd/config"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/progress"
+	"github.com/zinc-sig/ghost/internal/stress"
+)
+
+func resetStressFlags() {
+	stressGenerator = ""
+	stressSeeds = "1..100"
+	stressReference = ""
+	stressDiffFlags = ""
+	stressTimeoutStr = ""
+	stressWorkDir = ""
+	stressStopOnFailure = false
+	stressVerbose = false
+	stressProgress = config.ProgressConfig{Format: progress.FormatNone, FD: 2}
+}
+
+func TestStressCommand(t *testing.T) {
+	dir := t.TempDir()
+	scripts := map[string]string{
+		"gen.sh":    "echo \"$1 $(($1 * 3))\"\n",
+		"ref.sh":    "read a b; echo $((a + b))\n",
+		"sol.sh":    "read a b; if [ \"$a\" -eq 3 ]; then echo 0; else echo $((a + b)); fi\n",
+		"crash.sh":  "read a b; if [ \"$a\" -eq 2 ]; then exit 3; fi; echo $((a + b))\n",
+		"space.sh":  "read a b; echo \"$((a + b))  \"\n",
+		"badgen.sh": "if [ \"$1\" -eq 2 ]; then exit 1; fi; echo \"$1 1\"\n",
+	}
+	for name, body := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"+body), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	script := func(name string) string { return filepath.Join(dir, name) }
+
+	tests := []struct {
+		name         string
+		generator    string
+		program      string
+		extra        []string
+		wantStatus   string
+		wantPassed   int
+		wantStatuses map[int64]string
+	}{
+		{
+			name:       "all seeds pass",
+			generator:  script("gen.sh") + " {seed}",
+			program:    script("ref.sh"),
+			extra:      []string{"--seeds", "1..5"},
+			wantStatus: "success",
+			wantPassed: 5,
+		},
+		{
+			name:         "wrong answer on one seed",
+			generator:    script("gen.sh") + " {seed}",
+			program:      script("sol.sh"),
+			extra:        []string{"--seeds", "1..4"},
+			wantStatus:   "failed",
+			wantPassed:   3,
+			wantStatuses: map[int64]string{3: stress.CaseWrongAnswer},
+		},
+		{
+			name:         "program failure",
+			generator:    script("gen.sh") + " {seed}",
+			program:      script("crash.sh"),
+			extra:        []string{"--seeds", "1,2,3"},
+			wantStatus:   "failed",
+			wantPassed:   2,
+			wantStatuses: map[int64]string{2: "failed"},
+		},
+		{
+			name:         "generator failure",
+			generator:    script("badgen.sh") + " {seed}",
+			program:      script("ref.sh"),
+			extra:        []string{"--seeds", "1..3"},
+			wantStatus:   "failed",
+			wantPassed:   2,
+			wantStatuses: map[int64]string{2: stress.CaseGeneratorFailed},
+		},
+		{
+			name:         "stop on failure",
+			generator:    script("gen.sh") + " {seed}",
+			program:      script("sol.sh"),
+			extra:        []string{"--seeds", "1..10", "--stop-on-failure"},
+			wantStatus:   "failed",
+			wantPassed:   2,
+			wantStatuses: map[int64]string{3: stress.CaseWrongAnswer},
+		},
+		{
+			name:       "diff flags",
+			generator:  script("gen.sh") + " {seed}",
+			program:    script("space.sh"),
+			extra:      []string{"--seeds", "1..3", "--diff-flags", "--ignore-trailing-space"},
+			wantStatus: "success",
+			wantPassed: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetStressFlags()
+			defer resetStressFlags()
+
+			workDir := filepath.Join(t.TempDir(), "work")
+			args := []string{"stress", "--input-generator", tt.generator, "--reference", script("ref.sh"), "--work-dir", workDir}
+			args = append(args, tt.extra...)
+			rootCmd.SetArgs(append(args, "--", tt.program))
+
+			out, err := captureOutput(func() error { return rootCmd.Execute() })
+			if err != nil {
+				t.Fatalf("stress failed: %v", err)
+			}
+			var report output.StressReport
+			if err := json.Unmarshal([]byte(out), &report); err != nil {
+				t.Fatalf("invalid report: %v\n%s", err, out)
+			}
+
+			if report.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", report.Status, tt.wantStatus)
+			}
+			if report.Passed != tt.wantPassed {
+				t.Errorf("passed = %d, want %d", report.Passed, tt.wantPassed)
+			}
+			if report.Seeds != len(report.Cases) || report.Passed+report.Failed != report.Seeds {
+				t.Errorf("inconsistent counts: seeds=%d passed=%d failed=%d cases=%d", report.Seeds, report.Passed, report.Failed, len(report.Cases))
+			}
+			for _, c := range report.Cases {
+				want, ok := tt.wantStatuses[c.Seed]
+				if !ok {
+					want = stress.CasePassed
+				}
+				if c.Status != want {
+					t.Errorf("seed %d status = %q, want %q", c.Seed, c.Status, want)
+				}
+			}
+
+			// Every seed keeps its generated input in the work directory
+			for _, c := range report.Cases {
+				if _, err := os.Stat(filepath.Join(workDir, strconv.FormatInt(c.Seed, 10), stress.InputFile)); err != nil {
+					t.Errorf("seed %d input not kept: %v", c.Seed, err)
+				}
+			}
+		})
+	}
+}
+
+func TestStressCommandTemporaryWorkDir(t *testing.T) {
+	resetStressFlags()
+	defer resetStressFlags()
+
+	rootCmd.SetArgs([]string{"stress", "--input-generator", "echo {seed}", "--reference", "cat", "--seeds", "1..3", "--", "cat"})
+	out, err := captureOutput(func() error { return rootCmd.Execute() })
+	if err != nil {
+		t.Fatalf("stress failed: %v", err)
+	}
+	var report output.StressReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("invalid report: %v\n%s", err, out)
+	}
+	if report.Status != "success" || report.WorkDir != "" {
+		t.Errorf("status=%q work_dir=%q, want success without a kept work directory", report.Status, report.WorkDir)
+	}
+
+	resetStressFlags()
+	rootCmd.SetArgs([]string{"stress", "--input-generator", "echo {seed}", "--reference", "cat", "--seeds", "1", "--", "echo", "wrong"})
+	out, err = captureOutput(func() error { return rootCmd.Execute() })
+	if err != nil {
+		t.Fatalf("stress failed: %v", err)
+	}
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("invalid report: %v\n%s", err, out)
+	}
+	if report.Status != "failed" || report.WorkDir == "" {
+		t.Fatalf("status=%q work_dir=%q, want failed with the work directory kept", report.Status, report.WorkDir)
+	}
+	defer func() { _ = os.RemoveAll(report.WorkDir) }()
+	if _, err := os.Stat(filepath.Join(report.WorkDir, "1", stress.DiffFile)); err != nil {
+		t.Errorf("diff of the failing seed not kept: %v", err)
+	}
+}
+
+func TestStressCommandProgress(t *testing.T) {
+	resetStressFlags()
+	defer resetStressFlags()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = r.Close() }()
+	fd := strconv.Itoa(int(w.Fd()))
+
+	rootCmd.SetArgs([]string{"stress", "--input-generator", "echo {seed}", "--reference", "cat", "--seeds", "1..3",
+		"--progress", "json", "--progress-fd", fd, "--", "sh", "-c", "read x; if [ $x -eq 2 ]; then echo no; else echo $x; fi"})
+	if _, err := captureOutput(func() error { return rootCmd.Execute() }); err != nil {
+		t.Fatalf("stress failed: %v", err)
+	}
+	_ = w.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	wantEvents := []string{progress.EventStarted, progress.EventCaseDone, progress.EventCaseDone, progress.EventCaseDone, progress.EventFinished}
+	if len(lines) != len(wantEvents) {
+		t.Fatalf("got %d progress lines, want %d:\n%s", len(lines), len(wantEvents), data)
+	}
+	for i, line := range lines {
+		var event progress.Event
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			t.Fatalf("invalid progress line %q: %v", line, err)
+		}
+		if event.Event != wantEvents[i] || event.Total != 3 {
+			t.Errorf("line %d = %+v, want %s of 3", i, event, wantEvents[i])
+		}
+		if i == 2 && (event.Index != 2 || event.Case != "seed 2" || event.Status != stress.CaseWrongAnswer || event.Failed != 1) {
+			t.Errorf("seed 2 event = %+v", event)
+		}
+		if i == 4 && (event.Status != "failed" || event.Passed != 2 || event.Failed != 1) {
+			t.Errorf("finished event = %+v", event)
+		}
+	}
+}
+
+func TestStressCommandValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"missing command", []string{"stress", "--input-generator", "echo {seed}", "--reference", "cat", "--"}},
+		{"missing generator", []string{"stress", "--reference", "cat", "--", "cat"}},
+		{"missing reference", []string{"stress", "--input-generator", "echo {seed}", "--", "cat"}},
+		{"invalid seeds", []string{"stress", "--input-generator", "echo {seed}", "--reference", "cat", "--seeds", "5..1", "--", "cat"}},
+		{"invalid diff flags", []string{"stress", "--input-generator", "echo {seed}", "--reference", "cat", "--diff-flags", "--bogus", "--", "cat"}},
+		{"invalid progress format", []string{"stress", "--input-generator", "echo {seed}", "--reference", "cat", "--progress", "xml", "--", "cat"}},
+		{"progress on stdout", []string{"stress", "--input-generator", "echo {seed}", "--reference", "cat", "--progress", "json", "--progress-fd", "1", "--", "cat"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetStressFlags()
+			defer resetStressFlags()
+
+			rootCmd.SetArgs(tt.args)
+			if _, err := captureOutput(func() error { return rootCmd.Execute() }); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
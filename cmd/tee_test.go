@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// resetTeeFlags clears the tee output flag so it doesn't leak between tests
+func resetTeeFlags() {
+	resetFlags(runCmd, "tee-output")
+}
+
+func TestRunCommandTeeOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		tee        string
+		wantPrefix string // expected stdout before the JSON result
+		wantErr    string
+	}{
+		{name: "stderr keeps stdout parseable", tee: "--tee-output=stderr"},
+		{name: "bare flag streams to stdout", tee: "--tee-output", wantPrefix: "teed\n"},
+		{name: "invalid target", tee: "--tee-output=stdin", wantErr: "invalid tee output"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTeeFlags()
+			defer resetTeeFlags()
+
+			dir := t.TempDir()
+			outputPath := filepath.Join(dir, "output.txt")
+			rootCmd.SetArgs([]string{"run", "-i", "/dev/null", "-o", outputPath,
+				"-e", filepath.Join(dir, "stderr.txt"), tt.tee, "--", "echo", "teed"})
+
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !strings.HasPrefix(output, tt.wantPrefix) {
+				t.Fatalf("Expected stdout to start with %q, got %q", tt.wantPrefix, output)
+			}
+			var result struct {
+				Status string `json:"status"`
+			}
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(output, tt.wantPrefix)), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if result.Status != "success" {
+				t.Errorf("Status = %s, want success", result.Status)
+			}
+
+			data, err := os.ReadFile(outputPath)
+			if err != nil || string(data) != "teed\n" {
+				t.Errorf("Output file = %q (%v), want %q", data, err, "teed\n")
+			}
+		})
+	}
+}
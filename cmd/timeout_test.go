@@ -12,8 +12,12 @@ import (
 func resetTimeoutGlobals() {
 	runFlags.TimeoutStr = ""
 	runFlags.Timeout = 0
+	runFlags.SoftTimeoutStr = ""
+	runFlags.SoftTimeout = 0
 	diffCommonFlags.TimeoutStr = ""
 	diffCommonFlags.Timeout = 0
+	diffCommonFlags.SoftTimeoutStr = ""
+	diffCommonFlags.SoftTimeout = 0
 }
 
 func TestRunCommandTimeout(t *testing.T) {
@@ -23,6 +27,7 @@ func TestRunCommandTimeout(t *testing.T) {
 		args         []string
 		wantStatus   string
 		wantExitCode int
+		wantDeadline string
 		wantErr      bool
 	}{
 		{
@@ -45,6 +50,26 @@ func TestRunCommandTimeout(t *testing.T) {
 			wantExitCode: -1,
 			wantErr:      false,
 		},
+		{
+			name: "command exits on soft timeout",
+			args: []string{
+				"run", "-i", "input.txt", "-o", "output.txt", "-e", "stderr.txt",
+				"--timeout", "2s", "--soft-timeout", "100ms", "--", "sh", "-c", "trap 'exit 0' TERM; sleep 5 & wait",
+			},
+			wantStatus:   "timeout",
+			wantExitCode: -1,
+			wantDeadline: "soft",
+		},
+		{
+			name: "command ignores soft timeout",
+			args: []string{
+				"run", "-i", "input.txt", "-o", "output.txt", "-e", "stderr.txt",
+				"--timeout", "300ms", "--soft-timeout", "100ms", "--", "sh", "-c", "trap '' TERM; sleep 5 & wait",
+			},
+			wantStatus:   "timeout",
+			wantExitCode: -1,
+			wantDeadline: "hard",
+		},
 		{
 			name: "invalid timeout format",
 			args: []string{
@@ -61,12 +86,29 @@ func TestRunCommandTimeout(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "soft timeout without timeout",
+			args: []string{
+				"run", "-i", "input.txt", "-o", "output.txt", "-e", "stderr.txt",
+				"--soft-timeout", "1s", "--", "echo", "hello",
+			},
+			wantErr: true,
+		},
+		{
+			name: "soft timeout not shorter than timeout",
+			args: []string{
+				"run", "-i", "input.txt", "-o", "output.txt", "-e", "stderr.txt",
+				"--timeout", "1s", "--soft-timeout", "1s", "--", "echo", "hello",
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Reset globals before each test
 			resetTimeoutGlobals()
+			resetExpectedFlags()
 			// Also reset webhook globals
 			runWebhookConfig.Timeout = "30s"
 			diffWebhookConfig.Timeout = "30s"
@@ -124,6 +166,10 @@ func TestRunCommandTimeout(t *testing.T) {
 				t.Errorf("ExitCode = %v, want %v", int(exitCode), tt.wantExitCode)
 			}
 
+			if deadline, _ := result["deadline"].(string); deadline != tt.wantDeadline {
+				t.Errorf("Deadline = %q, want %q", deadline, tt.wantDeadline)
+			}
+
 			// Verify timeout field is present when timeout is specified
 			if strings.Contains(strings.Join(tt.args, " "), "--timeout") && !tt.wantErr {
 				if _, ok := result["timeout"]; !ok {
@@ -0,0 +1,1243 @@
+package cmd
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/upload"
+)
+
+// flakyProvider is an upload provider that fails a configurable number of times per remote path
+type flakyProvider struct {
+	mu       sync.Mutex
+	failures map[string]int
+	uploads  map[string]string
+	class    string         // class of the simulated failures, "" for unclassified
+	stalls   map[string]int // uploads per remote path that wait before reading, like a slow network
+}
+
+var testFlakyProvider = &flakyProvider{}
+
+func init() {
+	upload.RegisterProvider("test-flaky", func() upload.Provider {
+		return testFlakyProvider
+	})
+}
+
+func (f *flakyProvider) reset(failures map[string]int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = failures
+	f.uploads = make(map[string]string)
+	f.class = ""
+	f.stalls = nil
+}
+
+func (f *flakyProvider) Name() string                   { return "test-flaky" }
+func (f *flakyProvider) Configure(map[string]any) error { return nil }
+
+func (f *flakyProvider) Upload(ctx context.Context, reader io.Reader, remotePath string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failures[remotePath] != 0 {
+		if f.failures[remotePath] > 0 {
+			f.failures[remotePath]--
+		}
+		return errors.New("simulated upload failure")
+	}
+	if f.stalls[remotePath] > 0 {
+		f.stalls[remotePath]--
+		time.Sleep(300 * time.Millisecond)
+	}
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	f.uploads[remotePath] = string(content)
+	return nil
+}
+
+func (f *flakyProvider) ClassifyError(err error) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.class
+}
+
+func (f *flakyProvider) Download(ctx context.Context, remotePath string, writer io.Writer) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failures[remotePath] != 0 {
+		if f.failures[remotePath] > 0 {
+			f.failures[remotePath]--
+		}
+		return errors.New("simulated download failure")
+	}
+	content, ok := f.uploads[remotePath]
+	if !ok {
+		return fmt.Errorf("%s not found", remotePath)
+	}
+	_, err := io.WriteString(writer, content)
+	return err
+}
+
+func (f *flakyProvider) Stat(ctx context.Context, remotePath string) (upload.ObjectInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	content, ok := f.uploads[remotePath]
+	if !ok {
+		return upload.ObjectInfo{}, fmt.Errorf("%s: %w", remotePath, upload.ErrNotExist)
+	}
+	return upload.ObjectInfo{Size: int64(len(content))}, nil
+}
+
+func (f *flakyProvider) Delete(ctx context.Context, remotePath string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.uploads, remotePath)
+	return nil
+}
+
+func (f *flakyProvider) List(ctx context.Context, remoteDir string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var remotePaths []string
+	for remotePath := range f.uploads {
+		if strings.HasPrefix(remotePath, remoteDir) {
+			remotePaths = append(remotePaths, remotePath)
+		}
+	}
+	sort.Strings(remotePaths)
+	return remotePaths, nil
+}
+
+// resetUploadGlobals resets upload-related run flags between tests
+func resetUploadGlobals() {
+	resetFlags(runCmd, "upload-provider", "upload-retries", "upload-retry-delay", "upload-fail-policy", "upload-compress", "upload-encrypt", "upload-presign", "upload-from-remote", "upload-bandwidth-limit", "upload-concurrency", "upload-if-absent", "upload-streaming", "upload-max-file-size", "upload-max-total-size", "artifact-ttl", "verbose", "dry-run", "upload-files")
+}
+
+func TestRunCommandUploadRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     map[string]int
+		class        string
+		failPolicy   string
+		wantErr      bool
+		wantAttempts map[string]int
+		wantSuccess  map[string]bool
+		wantCode     string
+	}{
+		{
+			name:         "all uploads succeed",
+			failures:     map[string]int{},
+			wantAttempts: map[string]int{"out.txt": 1, "err.txt": 1},
+			wantSuccess:  map[string]bool{"out.txt": true, "err.txt": true},
+		},
+		{
+			name:         "transient failure is retried",
+			failures:     map[string]int{"out.txt": 2},
+			wantAttempts: map[string]int{"out.txt": 3, "err.txt": 1},
+			wantSuccess:  map[string]bool{"out.txt": true, "err.txt": true},
+		},
+		{
+			name:         "persistent failure with warn policy",
+			failures:     map[string]int{"err.txt": -1},
+			failPolicy:   "warn",
+			wantAttempts: map[string]int{"out.txt": 1, "err.txt": 3},
+			wantSuccess:  map[string]bool{"out.txt": true, "err.txt": false},
+			wantCode:     "unknown",
+		},
+		{
+			name:         "throttling is retried",
+			failures:     map[string]int{"err.txt": -1},
+			class:        "throttled",
+			failPolicy:   "warn",
+			wantAttempts: map[string]int{"out.txt": 1, "err.txt": 3},
+			wantSuccess:  map[string]bool{"out.txt": true, "err.txt": false},
+			wantCode:     "throttled",
+		},
+		{
+			name:         "auth failure is not retried",
+			failures:     map[string]int{"err.txt": -1},
+			class:        "auth",
+			failPolicy:   "warn",
+			wantAttempts: map[string]int{"out.txt": 1, "err.txt": 1},
+			wantSuccess:  map[string]bool{"out.txt": true, "err.txt": false},
+			wantCode:     "auth",
+		},
+		{
+			name:     "persistent failure with error policy",
+			failures: map[string]int{"err.txt": -1},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTimeoutGlobals()
+			resetScoringFlags()
+			resetUploadGlobals()
+			defer resetUploadGlobals()
+			testFlakyProvider.reset(tt.failures)
+			testFlakyProvider.class = tt.class
+
+			dir := t.TempDir()
+			inputFile := filepath.Join(dir, "input.txt")
+			if err := os.WriteFile(inputFile, []byte("data\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			args := []string{"run", "-i", inputFile,
+				"-o", filepath.Join(dir, "output.txt") + ":out.txt",
+				"-e", filepath.Join(dir, "stderr.txt") + ":err.txt",
+				"--upload-provider", "test-flaky",
+				"--upload-retries", "2",
+				"--upload-retry-delay", "1ms",
+			}
+			if tt.failPolicy != "" {
+				args = append(args, "--upload-fail-policy", tt.failPolicy)
+			}
+			args = append(args, "--", "cat")
+			rootCmd.SetArgs(args)
+
+			out, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result output.Result
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, out)
+			}
+
+			if len(result.Uploads) != len(tt.wantAttempts) {
+				t.Fatalf("Expected %d upload results, got %d", len(tt.wantAttempts), len(result.Uploads))
+			}
+			for _, u := range result.Uploads {
+				if u.Attempts != tt.wantAttempts[u.Remote] {
+					t.Errorf("%s: attempts = %d, want %d", u.Remote, u.Attempts, tt.wantAttempts[u.Remote])
+				}
+				if u.Success != tt.wantSuccess[u.Remote] {
+					t.Errorf("%s: success = %v, want %v", u.Remote, u.Success, tt.wantSuccess[u.Remote])
+				}
+				if !u.Success && u.Error == "" {
+					t.Errorf("%s: expected error message for failed upload", u.Remote)
+				}
+				wantCode := ""
+				if !u.Success {
+					wantCode = tt.wantCode
+				}
+				if u.ErrorCode != wantCode {
+					t.Errorf("%s: error code = %q, want %q", u.Remote, u.ErrorCode, wantCode)
+				}
+			}
+
+			// Size of the uploaded output file should be recorded
+			for _, u := range result.Uploads {
+				if u.Remote == "out.txt" && u.Size != int64(len("data\n")) {
+					t.Errorf("out.txt: size = %d, want %d", u.Size, len("data\n"))
+				}
+			}
+		})
+	}
+}
+
+func TestRunCommandInvalidUploadFailPolicy(t *testing.T) {
+	resetTimeoutGlobals()
+	resetUploadGlobals()
+	defer resetUploadGlobals()
+
+	dir := t.TempDir()
+	rootCmd.SetArgs([]string{"run", "-i", "/dev/null",
+		"-o", filepath.Join(dir, "output.txt"), "-e", filepath.Join(dir, "stderr.txt"),
+		"--upload-fail-policy", "ignore", "--", "true"})
+
+	if _, err := captureOutput(func() error { return rootCmd.Execute() }); err == nil {
+		t.Error("Expected error for invalid upload fail policy")
+	}
+}
+
+func TestRunCommandUploadIfAbsent(t *testing.T) {
+	resetTimeoutGlobals()
+	resetScoringFlags()
+	resetUploadGlobals()
+	defer resetUploadGlobals()
+	testFlakyProvider.reset(nil)
+	testFlakyProvider.uploads["out.txt"] = "stored before"
+
+	dir := t.TempDir()
+	rootCmd.SetArgs([]string{"run", "-i", "/dev/null",
+		"-o", filepath.Join(dir, "output.txt") + ":out.txt",
+		"-e", filepath.Join(dir, "stderr.txt") + ":err.txt",
+		"--upload-provider", "test-flaky", "--upload-if-absent",
+		"--", "echo", "new"})
+	out, err := captureOutput(func() error { return rootCmd.Execute() })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var result output.Result
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, out)
+	}
+	skipped := map[string]bool{}
+	for _, u := range result.Uploads {
+		if !u.Success {
+			t.Errorf("%s: success = false", u.Remote)
+		}
+		skipped[u.Remote] = u.Skipped
+	}
+	if !reflect.DeepEqual(skipped, map[string]bool{"out.txt": true, "err.txt": false}) {
+		t.Errorf("skipped = %v, want only out.txt", skipped)
+	}
+	if got := testFlakyProvider.uploads["out.txt"]; got != "stored before" {
+		t.Errorf("out.txt = %q, want the stored file kept", got)
+	}
+	if _, ok := testFlakyProvider.uploads["err.txt"]; !ok {
+		t.Error("err.txt was not uploaded")
+	}
+}
+
+func TestRunCommandUploadFailPolicyCleanup(t *testing.T) {
+	resetTimeoutGlobals()
+	resetScoringFlags()
+	resetUploadGlobals()
+	defer resetUploadGlobals()
+	// The output is uploaded first, then the stderr fails
+	testFlakyProvider.reset(map[string]int{"err.txt": -1})
+	testFlakyProvider.uploads["results/input/in.txt"] = "stored before"
+
+	dir := t.TempDir()
+	inputFile := filepath.Join(dir, "in.txt")
+	if err := os.WriteFile(inputFile, []byte("data\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rootCmd.SetArgs([]string{"run", "-i", inputFile,
+		"-o", filepath.Join(dir, "output.txt") + ":out.txt",
+		"-e", filepath.Join(dir, "stderr.txt") + ":err.txt",
+		"--upload-files", inputFile + ":results/input/in.txt",
+		"--upload-provider", "test-flaky", "--upload-retries", "0",
+		"--upload-if-absent", "--upload-fail-policy", "cleanup",
+		"--", "cat"})
+	_, err := captureOutput(func() error { return rootCmd.Execute() })
+	if err == nil || failure.CodeOf(err) != failure.UploadFailed {
+		t.Fatalf("err = %v, want %s", err, failure.UploadFailed)
+	}
+
+	want := map[string]string{"results/input/in.txt": "stored before"}
+	if !reflect.DeepEqual(testFlakyProvider.uploads, want) {
+		t.Errorf("stored files = %v, want %v (uploads of the run removed, older files kept)", testFlakyProvider.uploads, want)
+	}
+}
+
+func TestRunCommandUploadCapabilities(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "if-absent without stat", args: []string{"--upload-provider", "test-attributes", "--upload-if-absent"}},
+		{name: "if-absent without provider", args: []string{"--upload-if-absent"}},
+		{name: "cleanup without delete", args: []string{"--upload-provider", "test-attributes", "--upload-fail-policy", "cleanup"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTimeoutGlobals()
+			resetUploadGlobals()
+			defer resetUploadGlobals()
+
+			dir := t.TempDir()
+			args := append([]string{"run", "-i", "/dev/null",
+				"-o", filepath.Join(dir, "output.txt") + ":out.txt", "-e", filepath.Join(dir, "stderr.txt")}, tt.args...)
+			rootCmd.SetArgs(append(args, "--", "true"))
+			_, err := captureOutput(func() error { return rootCmd.Execute() })
+			if err == nil || failure.CodeOf(err) != failure.UploadConfigInvalid {
+				t.Errorf("err = %v, want %s", err, failure.UploadConfigInvalid)
+			}
+		})
+	}
+}
+
+func TestRunCommandUploadCompress(t *testing.T) {
+	resetTimeoutGlobals()
+	resetScoringFlags()
+	resetUploadGlobals()
+	defer resetUploadGlobals()
+	testFlakyProvider.reset(nil)
+
+	dir := t.TempDir()
+	inputFile := filepath.Join(dir, "input.txt")
+	content := strings.Repeat("compressible stderr line\n", 200)
+	if err := os.WriteFile(inputFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	extra := filepath.Join(dir, "extra.txt")
+	if err := os.WriteFile(extra, []byte("extra"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd.SetArgs([]string{"run", "-i", inputFile,
+		"-o", filepath.Join(dir, "output.txt") + ":out.txt",
+		"-e", filepath.Join(dir, "stderr.txt") + ":err.txt",
+		"--upload-provider", "test-flaky",
+		"--upload-files", extra + ":extra.txt",
+		"--upload-compress", "gzip",
+		"--", "cat"})
+
+	out, err := captureOutput(func() error {
+		return rootCmd.Execute()
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// Standard files are compressed with a .gz suffix, additional files are not
+	gz, ok := testFlakyProvider.uploads["out.txt.gz"]
+	if !ok {
+		t.Fatalf("Expected upload to out.txt.gz, got %v", testFlakyProvider.uploads)
+	}
+	reader, err := gzip.NewReader(strings.NewReader(gz))
+	if err != nil {
+		t.Fatalf("Uploaded output is not gzip: %v", err)
+	}
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to decompress upload: %v", err)
+	}
+	if string(decompressed) != content {
+		t.Errorf("Decompressed output does not match original")
+	}
+	if _, ok := testFlakyProvider.uploads["err.txt.gz"]; !ok {
+		t.Errorf("Expected upload to err.txt.gz, got %v", testFlakyProvider.uploads)
+	}
+	if testFlakyProvider.uploads["extra.txt"] != "extra" {
+		t.Errorf("Expected additional file uploaded uncompressed, got %v", testFlakyProvider.uploads)
+	}
+
+	var result output.Result
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, out)
+	}
+	for _, u := range result.Uploads {
+		if u.Remote != "out.txt.gz" {
+			continue
+		}
+		if u.Compression != "gzip" || u.Size != int64(len(content)) {
+			t.Errorf("out.txt.gz: compression = %q, size = %d, want gzip and %d", u.Compression, u.Size, len(content))
+		}
+		if u.CompressedSize != int64(len(gz)) {
+			t.Errorf("out.txt.gz: compressed size = %d, want %d", u.CompressedSize, len(gz))
+		}
+	}
+}
+
+func TestRunCommandUploadStreaming(t *testing.T) {
+	content := strings.Repeat("streamed output line\n", 20000)
+	// More than the stream queues for an upload that does not keep up
+	large := strings.Repeat("streamed output line\n", 600000)
+	tests := []struct {
+		name         string
+		flags        []string
+		content      string
+		failures     map[string]int
+		stalls       map[string]int
+		wantRemote   string
+		wantStreamed bool
+	}{
+		{name: "streamed", wantRemote: "out.txt", wantStreamed: true},
+		{name: "streamed compressed", flags: []string{"--upload-compress", "gzip"}, wantRemote: "out.txt.gz", wantStreamed: true},
+		// The stream fails, so the finished file is uploaded instead
+		{name: "failed stream falls back", failures: map[string]int{"out.txt": 1}, wantRemote: "out.txt"},
+		// The command does not wait for a stalled stream, which falls back once it overflows
+		{name: "stalled stream falls back", content: large, stalls: map[string]int{"out.txt": 1}, wantRemote: "out.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTimeoutGlobals()
+			resetScoringFlags()
+			resetUploadGlobals()
+			defer resetUploadGlobals()
+			testFlakyProvider.reset(tt.failures)
+			testFlakyProvider.stalls = tt.stalls
+			content := content
+			if tt.content != "" {
+				content = tt.content
+			}
+
+			dir := t.TempDir()
+			inputFile := filepath.Join(dir, "input.txt")
+			if err := os.WriteFile(inputFile, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			outputFile := filepath.Join(dir, "output.txt")
+			args := append([]string{"run", "-i", inputFile,
+				"-o", outputFile + ":out.txt",
+				"-e", filepath.Join(dir, "stderr.txt") + ":err.txt",
+				"--upload-provider", "test-flaky",
+				"--upload-retry-delay", "1ms",
+				"--upload-streaming"}, tt.flags...)
+			rootCmd.SetArgs(append(args, "--", "cat"))
+
+			out, err := captureOutput(rootCmd.Execute)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			var result output.Result
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, out)
+			}
+
+			uploaded := testFlakyProvider.uploads[tt.wantRemote]
+			if strings.HasSuffix(tt.wantRemote, ".gz") {
+				reader, err := gzip.NewReader(strings.NewReader(uploaded))
+				if err != nil {
+					t.Fatalf("Uploaded output is not gzip: %v", err)
+				}
+				data, _ := io.ReadAll(reader)
+				uploaded = string(data)
+			}
+			if uploaded != content {
+				t.Errorf("Uploaded %d bytes to %s, want %d", len(uploaded), tt.wantRemote, len(content))
+			}
+			if data, _ := os.ReadFile(outputFile); string(data) != content {
+				t.Errorf("Output file has %d bytes, want %d", len(data), len(content))
+			}
+
+			if len(result.Uploads) != 2 {
+				t.Fatalf("Expected 2 upload results, got %+v", result.Uploads)
+			}
+			// The streamed output is reported first
+			u := result.Uploads[0]
+			if u.Remote != tt.wantRemote || u.Role != "output" || !u.Success || u.Streamed != tt.wantStreamed {
+				t.Errorf("Output upload = %+v, want %s streamed=%v", u, tt.wantRemote, tt.wantStreamed)
+			}
+			if u.Size != int64(len(content)) {
+				t.Errorf("Output upload size = %d, want %d", u.Size, len(content))
+			}
+		})
+	}
+}
+
+func TestRunCommandUploadStreamingValidation(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []string
+	}{
+		{name: "without provider", flags: []string{"--upload-streaming"}},
+		{name: "with if absent", flags: []string{"--upload-provider", "test-flaky", "--upload-streaming", "--upload-if-absent"}},
+		{name: "with append", flags: []string{"--upload-provider", "test-flaky", "--upload-streaming", "--append"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTimeoutGlobals()
+			resetUploadGlobals()
+			resetCaptureFlags()
+			defer resetUploadGlobals()
+			defer resetCaptureFlags()
+			testFlakyProvider.reset(nil)
+
+			dir := t.TempDir()
+			args := append([]string{"run", "-i", "/dev/null",
+				"-o", filepath.Join(dir, "output.txt") + ":out.txt", "-e", filepath.Join(dir, "stderr.txt") + ":err.txt"}, tt.flags...)
+			rootCmd.SetArgs(append(args, "--", "true"))
+			if _, err := captureOutput(rootCmd.Execute); err == nil {
+				t.Error("Expected error for invalid --upload-streaming")
+			}
+			if len(testFlakyProvider.uploads) != 0 {
+				t.Errorf("Uploaded %v although the flags are invalid", testFlakyProvider.uploads)
+			}
+		})
+	}
+}
+
+func TestRunCommandInvalidUploadCompress(t *testing.T) {
+	resetTimeoutGlobals()
+	resetUploadGlobals()
+	defer resetUploadGlobals()
+
+	dir := t.TempDir()
+	rootCmd.SetArgs([]string{"run", "-i", "/dev/null",
+		"-o", filepath.Join(dir, "output.txt"), "-e", filepath.Join(dir, "stderr.txt"),
+		"--upload-compress", "zstd", "--", "true"})
+
+	_, err := captureOutput(func() error { return rootCmd.Execute() })
+	if err == nil || !strings.Contains(err.Error(), "unsupported upload compression") {
+		t.Errorf("Error = %v, want unsupported upload compression", err)
+	}
+}
+
+// attributesProvider records the content type and metadata of each upload
+type attributesProvider struct {
+	mu      sync.Mutex
+	options map[string]upload.Options
+}
+
+var testAttributesProvider = &attributesProvider{}
+
+func init() {
+	upload.RegisterProvider("test-attributes", func() upload.Provider {
+		return testAttributesProvider
+	})
+}
+
+func (p *attributesProvider) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.options = make(map[string]upload.Options)
+}
+
+func (p *attributesProvider) Name() string                   { return "test-attributes" }
+func (p *attributesProvider) Configure(map[string]any) error { return nil }
+
+func (p *attributesProvider) Upload(ctx context.Context, reader io.Reader, remotePath string) error {
+	return p.UploadWithOptions(ctx, reader, remotePath, upload.Options{})
+}
+
+func (p *attributesProvider) UploadWithOptions(ctx context.Context, reader io.Reader, remotePath string, opts upload.Options) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return err
+	}
+	p.options[remotePath] = opts
+	return nil
+}
+
+func (p *attributesProvider) Download(context.Context, string, io.Writer) error {
+	return errors.New("not supported")
+}
+
+func (p *attributesProvider) PresignGet(ctx context.Context, remotePath string, expiry time.Duration) (string, error) {
+	return fmt.Sprintf("https://presigned.test/%s?expires=%d", remotePath, int(expiry.Seconds())), nil
+}
+
+func TestRunCommandUploadAttributes(t *testing.T) {
+	resetTimeoutGlobals()
+	resetScoringFlags()
+	resetUploadGlobals()
+	defer resetUploadGlobals()
+	testAttributesProvider.reset()
+
+	dir := t.TempDir()
+	inputFile := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(inputFile, []byte("plain text output\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	grades := filepath.Join(dir, "grades")
+	if err := os.WriteFile(grades, []byte("id,score\n1,100\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report := filepath.Join(dir, "report.json")
+	if err := os.WriteFile(report, []byte(`{"ok":true}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd.SetArgs([]string{"run", "-i", inputFile,
+		"--run-id", "run-7",
+		"-o", filepath.Join(dir, "output") + ":out",
+		"-e", filepath.Join(dir, "stderr.txt") + ":err.txt",
+		"--upload-provider", "test-attributes",
+		"--upload-files", grades + ":runs/{run_id}/grades.csv:type=text/csv:meta.student_id=s123:meta.run_id={run_id}",
+		"--upload-files", report,
+		"--", "cat"})
+
+	out, err := captureOutput(func() error { return rootCmd.Execute() })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	gradesOpts, ok := testAttributesProvider.options["runs/run-7/grades.csv"]
+	if !ok {
+		t.Fatalf("Expected upload to runs/run-7/grades.csv, got %v", testAttributesProvider.options)
+	}
+	if gradesOpts.ContentType != "text/csv" {
+		t.Errorf("grades content type = %q, want text/csv", gradesOpts.ContentType)
+	}
+	wantMetadata := map[string]string{"student_id": "s123", "run_id": "run-7"}
+	if !reflect.DeepEqual(gradesOpts.Metadata, wantMetadata) {
+		t.Errorf("grades metadata = %v, want %v", gradesOpts.Metadata, wantMetadata)
+	}
+
+	// Files without an explicit type get one from their name or content
+	if got := testAttributesProvider.options[report].ContentType; got != "application/json" {
+		t.Errorf("report content type = %q, want application/json", got)
+	}
+	if got := testAttributesProvider.options["out"].ContentType; got != "text/plain; charset=utf-8" {
+		t.Errorf("output content type = %q, want text/plain; charset=utf-8", got)
+	}
+
+	var result output.Result
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, out)
+	}
+	for _, u := range result.Uploads {
+		if u.Remote == "runs/run-7/grades.csv" && u.ContentType != "text/csv" {
+			t.Errorf("uploads entry content_type = %q, want text/csv", u.ContentType)
+		}
+	}
+}
+
+func TestRunCommandUploadFromRemote(t *testing.T) {
+	resetTimeoutGlobals()
+	resetScoringFlags()
+	resetUploadGlobals()
+	defer resetUploadGlobals()
+	testAttributesProvider.reset()
+
+	dir := t.TempDir()
+	inputFile := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(inputFile, []byte("1 2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd.SetArgs([]string{"run", "-i", inputFile,
+		"-o", filepath.Join(dir, "output") + ":results/out.txt",
+		"-e", filepath.Join(dir, "stderr.txt") + ":results/err.txt",
+		"--upload-provider", "test-attributes",
+		"--upload-from-remote",
+		"--", "cat"})
+
+	out, err := captureOutput(func() error { return rootCmd.Execute() })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := testAttributesProvider.options["results/input/input.txt"]; !ok {
+		t.Fatalf("Expected input upload to results/input/input.txt, got %v", testAttributesProvider.options)
+	}
+
+	var result output.Result
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, out)
+	}
+	roles := make(map[string]string)
+	for _, u := range result.Uploads {
+		roles[u.Remote] = u.Role
+	}
+	want := map[string]string{
+		"results/out.txt":         "output",
+		"results/err.txt":         "stderr",
+		"results/input/input.txt": "input",
+	}
+	if !reflect.DeepEqual(roles, want) {
+		t.Errorf("upload roles = %v, want %v", roles, want)
+	}
+}
+
+func TestRunCommandInvalidUploadFileOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr string
+	}{
+		{name: "invalid type", spec: "a.txt:b.txt:type=csv", wantErr: `invalid content type "csv"`},
+		{name: "invalid metadata key", spec: "a.txt:b.txt:meta.student id=1", wantErr: "invalid metadata key"},
+		{name: "duplicate type", spec: "a.txt:type=text/csv:type=text/plain", wantErr: "duplicate type option"},
+		{name: "metadata without value", spec: "a.txt:meta.course", wantErr: "expected meta.<key>=<value>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTimeoutGlobals()
+			resetUploadGlobals()
+			defer resetUploadGlobals()
+
+			dir := t.TempDir()
+			rootCmd.SetArgs([]string{"run", "-i", "/dev/null",
+				"-o", filepath.Join(dir, "output.txt"), "-e", filepath.Join(dir, "stderr.txt"),
+				"--upload-provider", "test-attributes",
+				"--upload-files", tt.spec, "--", "true"})
+
+			_, err := captureOutput(func() error { return rootCmd.Execute() })
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunCommandArtifactTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantTTL string
+		wantErr string
+	}{
+		{name: "flag", args: []string{"--upload-provider", "test-attributes", "--artifact-ttl", "7d"}, wantTTL: "7d"},
+		{name: "config key", args: []string{"--upload-provider", "test-attributes", "--upload-config", `{"artifact_ttl": "30d"}`}, wantTTL: "30d"},
+		{name: "flag overrides config", args: []string{"--upload-provider", "test-attributes", "--upload-config", `{"artifact_ttl": "30d"}`, "--artifact-ttl", "48h"}, wantTTL: "48h"},
+		{name: "invalid", args: []string{"--upload-provider", "test-attributes", "--artifact-ttl", "0d"}, wantErr: "artifact TTL must be positive"},
+		{name: "invalid config key", args: []string{"--upload-provider", "test-attributes", "--upload-config", `{"artifact_ttl": "soon"}`}, wantErr: `invalid artifact TTL "soon"`},
+		{name: "without provider", args: []string{"--artifact-ttl", "7d"}, wantErr: "--artifact-ttl requires --upload-provider"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTimeoutGlobals()
+			resetUploadGlobals()
+			defer resetUploadGlobals()
+			defer func() { _ = runCmd.Flags().Set("upload-config", "") }()
+
+			dir := t.TempDir()
+			args := []string{"run", "--dry-run", "-i", "/dev/null",
+				"-o", filepath.Join(dir, "output.txt") + ":out.txt", "-e", filepath.Join(dir, "stderr.txt") + ":err.txt"}
+			rootCmd.SetArgs(append(append(args, tt.args...), "--", "true"))
+
+			out, err := captureOutput(func() error { return rootCmd.Execute() })
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result output.Result
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, out)
+			}
+			if result.Plan == nil || result.Plan.Upload == nil || result.Plan.Upload.Config["artifact_ttl"] != tt.wantTTL {
+				t.Errorf("Planned upload = %+v, want artifact_ttl %s", result.Plan, tt.wantTTL)
+			}
+		})
+	}
+}
+
+func TestRunCommandUploadPresign(t *testing.T) {
+	resetTimeoutGlobals()
+	resetScoringFlags()
+	resetUploadGlobals()
+	defer resetUploadGlobals()
+	testAttributesProvider.reset()
+
+	dir := t.TempDir()
+	inputFile := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(inputFile, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rootCmd.SetArgs([]string{"run", "-i", inputFile,
+		"-o", filepath.Join(dir, "output.txt") + ":out.txt", "-e", filepath.Join(dir, "stderr.txt") + ":err.txt",
+		"--upload-provider", "test-attributes", "--upload-presign", "24h", "--", "cat"})
+
+	before := time.Now()
+	out, err := captureOutput(func() error { return rootCmd.Execute() })
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var result output.Result
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, out)
+	}
+	if len(result.Uploads) != 2 {
+		t.Fatalf("uploads = %+v, want 2", result.Uploads)
+	}
+	for _, u := range result.Uploads {
+		if want := "https://presigned.test/" + u.Remote + "?expires=86400"; u.URL != want {
+			t.Errorf("%s url = %q, want %q", u.Remote, u.URL, want)
+		}
+		expires, err := time.Parse(time.RFC3339, u.URLExpires)
+		if err != nil {
+			t.Fatalf("%s url_expires = %q: %v", u.Remote, u.URLExpires, err)
+		}
+		if d := expires.Sub(before); d < 23*time.Hour || d > 25*time.Hour {
+			t.Errorf("%s url_expires = %s, want in about 24h", u.Remote, u.URLExpires)
+		}
+	}
+}
+
+func TestRunCommandUploadPresignValidation(t *testing.T) {
+	t.Setenv("GHOST_TEST_PRESIGN_KEY", strings.Repeat("k", 32))
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "without provider", args: []string{"--upload-presign", "1h"}, wantErr: "--upload-presign requires --upload-provider"},
+		{name: "invalid duration", args: []string{"--upload-provider", "test-attributes", "--upload-presign", "1d"}, wantErr: `invalid presign expiry "1d"`},
+		{name: "too long", args: []string{"--upload-provider", "test-attributes", "--upload-presign", "200h"}, wantErr: "presign expiry must be between 1s and 168h"},
+		{name: "unsupported provider", args: []string{"--upload-provider", "test-flaky", "--upload-presign", "1h"}, wantErr: "does not support presigned URLs"},
+		{name: "with encryption", args: []string{"--upload-provider", "test-attributes", "--upload-encrypt", "sse-c:env:GHOST_TEST_PRESIGN_KEY", "--upload-presign", "1h"}, wantErr: "cannot be used with --upload-encrypt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTimeoutGlobals()
+			resetUploadGlobals()
+			defer resetUploadGlobals()
+
+			dir := t.TempDir()
+			args := []string{"run", "--dry-run", "-i", "/dev/null",
+				"-o", filepath.Join(dir, "output.txt") + ":out.txt", "-e", filepath.Join(dir, "stderr.txt") + ":err.txt"}
+			rootCmd.SetArgs(append(append(args, tt.args...), "--", "true"))
+
+			_, err := captureOutput(func() error { return rootCmd.Execute() })
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunCommandUploadBandwidthLimit(t *testing.T) {
+	resetTimeoutGlobals()
+	resetScoringFlags()
+	resetUploadGlobals()
+	defer resetUploadGlobals()
+	testFlakyProvider.reset(nil)
+
+	dir := t.TempDir()
+	inputFile := filepath.Join(dir, "input.txt")
+	content := strings.Repeat("x", 2500)
+	if err := os.WriteFile(inputFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rootCmd.SetArgs([]string{"run", "-v", "-i", inputFile,
+		"-o", filepath.Join(dir, "output.txt") + ":out.txt", "-e", filepath.Join(dir, "stderr.txt") + ":err.txt",
+		"--upload-provider", "test-flaky", "--upload-bandwidth-limit", "1KB/s", "--", "cat"})
+
+	oldStderr := os.Stderr
+	rErr, wErr, _ := os.Pipe()
+	os.Stderr = wErr
+	start := time.Now()
+	_, err := captureOutput(func() error { return rootCmd.Execute() })
+	elapsed := time.Since(start)
+	_ = wErr.Close()
+	os.Stderr = oldStderr
+	stderr, _ := io.ReadAll(rErr)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The first second's worth is sent at once, the rest at 1 KB/s
+	if elapsed < time.Second {
+		t.Errorf("Upload of 2.5 KB at 1 KB/s took %v, want at least 1s", elapsed)
+	}
+	if testFlakyProvider.uploads["out.txt"] != content {
+		t.Errorf("Uploaded %d bytes, want %d", len(testFlakyProvider.uploads["out.txt"]), len(content))
+	}
+	if want := "[UPLOAD] out.txt: 2.5 KB / 2.5 KB (100%) at "; !strings.Contains(string(stderr), want) {
+		t.Errorf("Stderr does not report the progress %q:\n%s", want, stderr)
+	}
+}
+
+func TestRunCommandUploadBandwidthLimitValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "without provider", args: []string{"--upload-bandwidth-limit", "10MB/s"}, wantErr: "--upload-bandwidth-limit requires --upload-provider"},
+		{name: "invalid rate", args: []string{"--upload-provider", "test-flaky", "--upload-bandwidth-limit", "fast"}, wantErr: `invalid bandwidth limit "fast"`},
+		{name: "zero", args: []string{"--upload-provider", "test-flaky", "--upload-bandwidth-limit", "0/s"}, wantErr: `invalid bandwidth limit "0/s"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTimeoutGlobals()
+			resetUploadGlobals()
+			defer resetUploadGlobals()
+
+			dir := t.TempDir()
+			args := []string{"run", "--dry-run", "-i", "/dev/null",
+				"-o", filepath.Join(dir, "output.txt") + ":out.txt", "-e", filepath.Join(dir, "stderr.txt") + ":err.txt"}
+			rootCmd.SetArgs(append(append(args, tt.args...), "--", "true"))
+
+			_, err := captureOutput(func() error { return rootCmd.Execute() })
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunCommandUploadFileLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      []string
+		args       []string
+		wantCode   failure.Code
+		wantErr    string
+		wantStored []string
+	}{
+		{
+			name:       "glob under remote directory",
+			files:      []string{"artifacts/*.png:results/plots"},
+			wantStored: []string{"err.txt", "out.txt", "results/plots/a.png", "results/plots/b.png"},
+		},
+		{
+			name:       "glob without remote",
+			files:      []string{"artifacts/a.*"},
+			wantStored: []string{"artifacts/a.png", "err.txt", "out.txt"},
+		},
+		{
+			name:       "within limits",
+			files:      []string{"artifacts/*.png:plots", "big.txt"},
+			args:       []string{"--upload-max-file-size", "1KB", "--upload-max-total-size", "2KB"},
+			wantStored: []string{"big.txt", "err.txt", "out.txt", "plots/a.png", "plots/b.png"},
+		},
+		{
+			name:     "file over limit",
+			files:    []string{"big.txt"},
+			args:     []string{"--upload-max-file-size", "100B"},
+			wantCode: failure.UploadFailed,
+			wantErr:  "upload file big.txt is 1.0 KB, over the limit of 100 B per file",
+		},
+		{
+			name:     "total over limit",
+			files:    []string{"artifacts/*.png", "big.txt"},
+			args:     []string{"--upload-max-total-size", "500B"},
+			wantCode: failure.UploadFailed,
+			wantErr:  "3 upload files total 1.0 KB, over the limit of 500 B",
+		},
+		{
+			name:     "symbolic link",
+			files:    []string{"link.txt"},
+			wantCode: failure.UploadFailed,
+			wantErr:  "upload file link.txt is a symbolic link",
+		},
+		{
+			name:     "invalid limit",
+			files:    []string{"big.txt"},
+			args:     []string{"--upload-max-file-size", "huge"},
+			wantCode: failure.UploadConfigInvalid,
+			wantErr:  `invalid upload max file size "huge"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTimeoutGlobals()
+			resetScoringFlags()
+			resetUploadGlobals()
+			defer resetUploadGlobals()
+			testFlakyProvider.reset(nil)
+
+			dir := t.TempDir()
+			t.Chdir(dir)
+			_ = os.Mkdir("artifacts", 0755)
+			_ = os.WriteFile("artifacts/a.png", []byte("plot a"), 0644)
+			_ = os.WriteFile("artifacts/b.png", []byte("plot"), 0644)
+			_ = os.Symlink("/etc/passwd", "artifacts/c.png")
+			_ = os.Mkdir("artifacts/d.png", 0755)
+			_ = os.WriteFile("big.txt", []byte(strings.Repeat("x", 1000)), 0644)
+			_ = os.Symlink("/etc/passwd", "link.txt")
+
+			args := []string{"run", "-i", "/dev/null", "-o", "output.txt:out.txt", "-e", "stderr.txt:err.txt",
+				"--upload-provider", "test-flaky"}
+			for _, file := range tt.files {
+				args = append(args, "--upload-files", file)
+			}
+			rootCmd.SetArgs(append(append(args, tt.args...), "--", "true"))
+			_, err := captureOutput(func() error { return rootCmd.Execute() })
+
+			if tt.wantErr != "" {
+				if err == nil || failure.CodeOf(err) != tt.wantCode || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %s containing %q", err, tt.wantCode, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var stored []string
+			for remote := range testFlakyProvider.uploads {
+				stored = append(stored, remote)
+			}
+			sort.Strings(stored)
+			if !reflect.DeepEqual(stored, tt.wantStored) {
+				t.Errorf("stored = %v, want %v", stored, tt.wantStored)
+			}
+		})
+	}
+}
+
+// slowProvider is an upload provider whose uploads take a while, recording how
+// many run at once and failing the remote paths in failing
+type slowProvider struct {
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	failing  map[string]bool
+}
+
+var testSlowProvider = &slowProvider{}
+
+func init() {
+	upload.RegisterProvider("test-slow", func() upload.Provider {
+		return testSlowProvider
+	})
+}
+
+func (p *slowProvider) reset(failing map[string]bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inFlight, p.peak = 0, 0
+	p.failing = failing
+}
+
+func (p *slowProvider) Name() string                   { return "test-slow" }
+func (p *slowProvider) Configure(map[string]any) error { return nil }
+
+func (p *slowProvider) Upload(ctx context.Context, reader io.Reader, remotePath string) error {
+	p.mu.Lock()
+	p.inFlight++
+	p.peak = max(p.peak, p.inFlight)
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.inFlight--
+		p.mu.Unlock()
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return err
+	}
+	if p.failing[remotePath] {
+		return errors.New("simulated upload failure")
+	}
+	return nil
+}
+
+func (p *slowProvider) Download(context.Context, string, io.Writer) error {
+	return errors.New("not supported")
+}
+
+func TestRunCommandUploadConcurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency string
+		failing     map[string]bool
+		failPolicy  string
+		wantPeak    int
+		wantErrs    []string
+	}{
+		{name: "one after another", concurrency: "1", wantPeak: 1},
+		{name: "several at once", concurrency: "3", wantPeak: 3},
+		{name: "failures recorded with warn policy", concurrency: "6", failing: map[string]bool{"files/b.txt": true, "files/d.txt": true}, failPolicy: "warn", wantPeak: 6},
+		{name: "failures aggregated", concurrency: "6", failing: map[string]bool{"files/b.txt": true, "files/d.txt": true}, wantPeak: 6,
+			wantErrs: []string{"failed to upload to files/b.txt", "failed to upload to files/d.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTimeoutGlobals()
+			resetScoringFlags()
+			resetUploadGlobals()
+			defer resetUploadGlobals()
+			testSlowProvider.reset(tt.failing)
+
+			dir := t.TempDir()
+			args := []string{"run", "-i", "/dev/null",
+				"-o", filepath.Join(dir, "output.txt") + ":out.txt", "-e", filepath.Join(dir, "stderr.txt") + ":err.txt",
+				"--upload-provider", "test-slow", "--upload-retries", "0", "--upload-concurrency", tt.concurrency}
+			// Listed out of order; the results follow the local paths
+			for _, name := range []string{"d", "a", "c", "b"} {
+				local := filepath.Join(dir, name+".txt")
+				if err := os.WriteFile(local, []byte(name), 0644); err != nil {
+					t.Fatal(err)
+				}
+				args = append(args, "--upload-files", local+":files/"+name+".txt")
+			}
+			if tt.failPolicy != "" {
+				args = append(args, "--upload-fail-policy", tt.failPolicy)
+			}
+			rootCmd.SetArgs(append(args, "--", "true"))
+
+			out, err := captureOutput(func() error { return rootCmd.Execute() })
+			if testSlowProvider.peak != tt.wantPeak {
+				t.Errorf("Uploads at once = %d, want %d", testSlowProvider.peak, tt.wantPeak)
+			}
+			if len(tt.wantErrs) > 0 {
+				if failure.CodeOf(err) != failure.UploadFailed {
+					t.Fatalf("err = %v, want %s", err, failure.UploadFailed)
+				}
+				for _, want := range tt.wantErrs {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("Error = %v, want containing %q", err, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var result output.Result
+			if err := json.Unmarshal([]byte(out), &result); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, out)
+			}
+			var remotes []string
+			for _, u := range result.Uploads {
+				remotes = append(remotes, u.Remote)
+				if u.Success == tt.failing[u.Remote] {
+					t.Errorf("%s: success = %v, want %v", u.Remote, u.Success, !tt.failing[u.Remote])
+				}
+			}
+			want := []string{"out.txt", "err.txt", "files/a.txt", "files/b.txt", "files/c.txt", "files/d.txt"}
+			if !reflect.DeepEqual(remotes, want) {
+				t.Errorf("Upload results = %v, want %v", remotes, want)
+			}
+		})
+	}
+}
+
+func TestRunCommandUploadConcurrencyValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "without provider", args: []string{"--upload-concurrency", "4"}, wantErr: "--upload-concurrency requires --upload-provider"},
+		{name: "zero", args: []string{"--upload-provider", "test-flaky", "--upload-concurrency", "0"}, wantErr: "--upload-concurrency must be between 1 and 64, got 0"},
+		{name: "too many", args: []string{"--upload-provider", "test-flaky", "--upload-concurrency", "65"}, wantErr: "--upload-concurrency must be between 1 and 64, got 65"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetTimeoutGlobals()
+			resetUploadGlobals()
+			defer resetUploadGlobals()
+
+			dir := t.TempDir()
+			args := []string{"run", "--dry-run", "-i", "/dev/null",
+				"-o", filepath.Join(dir, "output.txt") + ":out.txt", "-e", filepath.Join(dir, "stderr.txt") + ":err.txt"}
+			rootCmd.SetArgs(append(append(args, tt.args...), "--", "true"))
+
+			_, err := captureOutput(func() error { return rootCmd.Execute() })
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || failure.CodeOf(err) != failure.UploadConfigInvalid {
+				t.Errorf("Error = %v, want %s containing %q", err, failure.UploadConfigInvalid, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/manifest"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/validate"
+)
+
+var (
+	validateManifests      []string
+	validateUploadConfigs  []string
+	validateUploadProvider string
+	validateWebhookConfigs []string
+	validateContextFiles   []string
+)
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [--config <file>] [--manifest <file>]... [--upload-config-file <file>]... [--webhook-config-file <file>]... [--context-file <file>]...",
+	Short: "Validate manifests and config files without executing anything",
+	Long: `Validate case manifests, ghost config files, upload and webhook config files,
+and context files without executing or uploading anything.
+
+Every problem in every file is reported at once, with the line and column
+where it can be determined. --config is checked against the flags of all
+commands instead of being applied. Upload config files are checked against
+--upload-provider (default: minio) without connecting to it.
+
+Results are written as JSON; the command exits with code 1 if any file is invalid.`,
+	Example: `  ghost validate --manifest cases.yaml
+  ghost validate --config ci/ghost.yaml --webhook-config-file ci/webhook.json
+  ghost validate --upload-config-file ci/minio.json --context-file ci/context.json`,
+	Args: cobra.NoArgs,
+	// The config file is validated, not applied
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE:              validateCommand,
+}
+
+func validateCommand(cmd *cobra.Command, args []string) error {
+	report := &output.ValidationReport{
+		Command: "validate",
+		Status:  "success",
+		Files:   []output.ValidatedFile{},
+	}
+	add := func(path, kind string, problems []validate.Problem) {
+		file := output.ValidatedFile{Path: path, Kind: kind, Status: "valid"}
+		for _, p := range problems {
+			file.Errors = append(file.Errors, output.ValidationError{Line: p.Line, Column: p.Column, Message: p.Message})
+		}
+		if len(problems) > 0 {
+			file.Status = "invalid"
+		}
+		report.Files = append(report.Files, file)
+	}
+
+	if configFile != "" {
+		add(configFile, "config", helpers.ValidateConfigFile(rootCmd, configFile))
+	}
+	for _, path := range validateManifests {
+		add(path, "manifest", manifest.Validate(path))
+	}
+	for _, path := range validateUploadConfigs {
+		add(path, "upload_config", validateUploadConfigFile(path))
+	}
+	for _, path := range validateWebhookConfigs {
+		add(path, "webhook_config", validateWebhookConfigFile(path))
+	}
+	for _, path := range validateContextFiles {
+		_, problems := validate.ReadJSONFile(path)
+		add(path, "context", problems)
+	}
+
+	if len(report.Files) == 0 {
+		return failure.Wrap(failure.Usage, fmt.Errorf("nothing to validate (pass --config, --manifest, --upload-config-file, --webhook-config-file or --context-file)"))
+	}
+
+	invalid := 0
+	for _, file := range report.Files {
+		if file.Status == "invalid" {
+			invalid++
+		}
+	}
+	if invalid > 0 {
+		report.Status = "failed"
+	}
+
+	if err := helpers.PrintJSON(report); err != nil {
+		return err
+	}
+
+	if invalid > 0 {
+		// The report already explains the failure
+		cmd.SilenceUsage = true
+		return failure.Wrap(failure.ValidationFailed, fmt.Errorf("validation failed for %d of %d files", invalid, len(report.Files)))
+	}
+	return nil
+}
+
+// validateUploadConfigFile checks an upload config file against --upload-provider
+func validateUploadConfigFile(path string) []validate.Problem {
+	value, problems := validate.ReadJSONFile(path)
+	if problems != nil {
+		return problems
+	}
+	uploadConf, ok := value.(map[string]any)
+	if !ok {
+		return []validate.Problem{{Message: "upload config must be an object"}}
+	}
+	if err := helpers.ValidateUploadConfig(validateUploadProvider, uploadConf); err != nil {
+		return []validate.Problem{{Message: err.Error()}}
+	}
+	return nil
+}
+
+// validateWebhookConfigFile checks every setting of a webhook config file
+func validateWebhookConfigFile(path string) []validate.Problem {
+	value, problems := validate.ReadJSONFile(path)
+	if problems != nil {
+		return problems
+	}
+	webhookConf, ok := value.(map[string]any)
+	if !ok {
+		return []validate.Problem{{Message: "webhook config must be an object"}}
+	}
+	for _, err := range helpers.ValidateWebhookConfig(webhookConf) {
+		problems = append(problems, validate.Problem{Message: err.Error()})
+	}
+	return problems
+}
+
+func init() {
+	validateCmd.Flags().StringArrayVar(&validateManifests, "manifest", nil, "Case manifest (YAML or JSON) to validate (can be used multiple times)")
+	validateCmd.Flags().StringArrayVar(&validateUploadConfigs, "upload-config-file", nil, "Upload config JSON file to validate (can be used multiple times)")
+	validateCmd.Flags().StringVar(&validateUploadProvider, "upload-provider", "minio", "Provider to validate upload config files against")
+	validateCmd.Flags().StringArrayVar(&validateWebhookConfigs, "webhook-config-file", nil, "Webhook config JSON file to validate (can be used multiple times)")
+	validateCmd.Flags().StringArrayVar(&validateContextFiles, "context-file", nil, "Context JSON file to validate (can be used multiple times)")
+}
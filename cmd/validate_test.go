@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// resetValidateFlags clears validate flags so they don't leak between tests
+func resetValidateFlags() {
+	resetAllFlags(validateCmd)
+	resetConfigFileFlags()
+}
+
+func TestValidateCommand(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	goodManifest := write("good.yaml", "cases:\n  - name: hello\n    command: [./hello]\n")
+	badManifest := write("bad.yaml", "cases:\n  - name: hello\n    command: [./hello]\n  - name: hello\n    comand: [./x]\n")
+	goodConfig := write("ghost.yaml", "timeout: 5s\nwebhook:\n  retries: 2\nrun:\n  verbose: true\nprofiles:\n  cs101:\n    score: 10\n    run:\n      timeout: 1s\n")
+	badConfig := write("bad-ghost.yaml", "verbose: maybe\nwebhook:\n  urll: x\nprofiles:\n  cs101:\n    timeoutt: 1s\n  cs102: 5\n")
+	goodWebhook := write("webhook.json", `{"url": "https://example.com/hook", "timeout": "10s"}`)
+	badWebhook := write("bad-webhook.json", `{"timeout": "soon", "extra": true}`)
+	badContext := write("context.json", "{\n  \"a\": 1,\n  \"b\": }\n")
+	badUpload := write("upload.json", `{"endpoint": "localhost:9000"}`)
+
+	type problem struct {
+		Line    int    `json:"line"`
+		Column  int    `json:"column"`
+		Message string `json:"message"`
+	}
+	type file struct {
+		Kind   string    `json:"kind"`
+		Status string    `json:"status"`
+		Errors []problem `json:"errors"`
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantStatus string
+		wantFiles  []string   // expected per-file statuses
+		wantErrors [][]string // expected per-file error substrings, in order
+		wantLine   int        // expected line of the first error of the first file
+		wantErr    string
+	}{
+		{
+			name:       "valid files",
+			args:       []string{"--config", goodConfig, "--manifest", goodManifest, "--webhook-config-file", goodWebhook},
+			wantStatus: "success",
+			wantFiles:  []string{"valid", "valid", "valid"},
+		},
+		{
+			name:       "manifest problems are all listed",
+			args:       []string{"--manifest", badManifest},
+			wantStatus: "failed",
+			wantFiles:  []string{"invalid"},
+			wantErrors: [][]string{{`unknown key "comand"`, "command is required", `duplicate case name "hello"`}},
+			wantLine:   5,
+			wantErr:    "validation failed for 1 of 1 files",
+		},
+		{
+			name:       "config file is checked against flags",
+			args:       []string{"--config", badConfig},
+			wantStatus: "failed",
+			wantFiles:  []string{"invalid"},
+			wantErrors: [][]string{{`invalid value for "verbose"`, `unknown key "webhook-urll"`, `unknown key "timeoutt"`, `profile "cs102" must be a map`}},
+			wantLine:   1,
+			wantErr:    "validation failed for 1 of 1 files",
+		},
+		{
+			name:       "webhook config",
+			args:       []string{"--webhook-config-file", badWebhook},
+			wantStatus: "failed",
+			wantFiles:  []string{"invalid"},
+			wantErrors: [][]string{{`unknown webhook config key "extra"`, "webhook url is required", "invalid webhook timeout duration"}},
+			wantErr:    "validation failed for 1 of 1 files",
+		},
+		{
+			name:       "context syntax error position",
+			args:       []string{"--context-file", badContext, "--manifest", goodManifest},
+			wantStatus: "failed",
+			wantFiles:  []string{"valid", "invalid"},
+			wantErr:    "validation failed for 1 of 2 files",
+		},
+		{
+			name:       "upload config",
+			args:       []string{"--upload-config-file", badUpload},
+			wantStatus: "failed",
+			wantFiles:  []string{"invalid"},
+			wantErrors: [][]string{{"access_key is required"}},
+			wantErr:    "validation failed for 1 of 1 files",
+		},
+		{
+			name:    "nothing to validate",
+			wantErr: "nothing to validate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetValidateFlags()
+			defer resetValidateFlags()
+
+			rootCmd.SetArgs(append([]string{"validate"}, tt.args...))
+			output, err := captureOutput(func() error {
+				return rootCmd.Execute()
+			})
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tt.wantStatus == "" {
+				return
+			}
+
+			var report struct {
+				Status string `json:"status"`
+				Files  []file `json:"files"`
+			}
+			if err := json.Unmarshal([]byte(output), &report); err != nil {
+				t.Fatalf("Failed to parse JSON output: %v\nOutput: %s", err, output)
+			}
+			if report.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", report.Status, tt.wantStatus)
+			}
+			if len(report.Files) != len(tt.wantFiles) {
+				t.Fatalf("Got %d files, want %d", len(report.Files), len(tt.wantFiles))
+			}
+			for i, f := range report.Files {
+				if f.Status != tt.wantFiles[i] {
+					t.Errorf("File %d status = %s, want %s (errors: %+v)", i, f.Status, tt.wantFiles[i], f.Errors)
+				}
+			}
+			for i, want := range tt.wantErrors {
+				got := report.Files[i].Errors
+				if len(got) != len(want) {
+					t.Fatalf("File %d errors = %+v, want %d errors", i, got, len(want))
+				}
+				for j, substr := range want {
+					if !strings.Contains(got[j].Message, substr) {
+						t.Errorf("File %d error %d = %q, want it to contain %q", i, j, got[j].Message, substr)
+					}
+				}
+			}
+			if tt.wantLine > 0 && report.Files[0].Errors[0].Line != tt.wantLine {
+				t.Errorf("Line = %d, want %d", report.Files[0].Errors[0].Line, tt.wantLine)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+	"github.com/zinc-sig/ghost/internal/compare"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/human"
+	"github.com/zinc-sig/ghost/internal/runner"
+	"github.com/zinc-sig/ghost/internal/watch"
+)
+
+// Files of a watch run in the work directory
+const (
+	watchOutputFile = "output.txt"
+	watchStderrFile = "stderr.txt"
+	watchDiffFile   = "diff.txt"
+)
+
+// watchDiffLines is how much of a wrong answer's diff is shown
+const watchDiffLines = 10
+
+var (
+	watchInputFile    string
+	watchExpectedFile string
+	watchPaths        []string
+	watchIntervalStr  string
+	watchTimeoutStr   string
+	watchDiffFlags    string
+	watchWorkDir      string
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch -i <input> -x <expected> [flags] -- <command> [args...]",
+	Short: "Re-judge a command against an expected output whenever its files change",
+	Long: `Run the command on the input, compare its output with the expected output and
+print a short summary, then do it again whenever a watched file changes, until
+interrupted.
+
+The command (when it names a file, such as ./a.out), its arguments naming files
+(such as solution.py), the input and the expected file are watched, along with
+every --watch file or directory. A run starts once the changed files have stayed
+the same for one --interval, so a binary is not run while it is being written.
+
+Outputs are compared with the built-in engine; --diff-flags accepts the flags it
+supports (e.g. -w or --ignore-trailing-space). The command passes if it succeeds
+and its output matches; otherwise the start of the diff is shown.
+
+The output, stderr and diff of the latest run are kept in --work-dir as
+output.txt, stderr.txt and diff.txt; without it a temporary directory is used
+and removed on exit. Colors honor NO_COLOR and TERM.`,
+	Example: `  ghost watch -i in.txt -x expected.txt -- ./a.out
+  ghost watch -i in.txt -x expected.txt --watch src/ --diff-flags -w -- python3 solution.py`,
+	RunE: watchCommand,
+}
+
+func watchCommand(cmd *cobra.Command, args []string) error {
+	if err := helpers.ValidateCommandSeparator(cmd, args); err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+	if err := helpers.CheckInputFile(watchInputFile); err != nil {
+		return err
+	}
+	if err := helpers.CheckInputFile(watchExpectedFile); err != nil {
+		return err
+	}
+	interval, err := time.ParseDuration(watchIntervalStr)
+	if err != nil || interval <= 0 {
+		return failure.Wrap(failure.Usage, fmt.Errorf("invalid --interval %q (e.g. 500ms, 2s)", watchIntervalStr))
+	}
+	timeout, err := helpers.ParseTimeout(watchTimeoutStr)
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+	textOptions, err := compare.ParseTextOptions(strings.Fields(watchDiffFlags))
+	if err != nil {
+		return failure.Wrap(failure.Usage, err)
+	}
+
+	workDir := watchWorkDir
+	if workDir == "" {
+		workDir, err = os.MkdirTemp("", "ghost-watch-*")
+		if err != nil {
+			return fmt.Errorf("failed to create work directory: %w", err)
+		}
+		defer func() { _ = os.RemoveAll(workDir) }()
+	} else if err := os.MkdirAll(workDir, 0755); err != nil {
+		return fmt.Errorf("failed to create work directory: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(helpers.CommandContext(cmd), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	paths := watchedPaths(args)
+	color := human.ColorEnabled(os.Stdout)
+	snapshot := watch.Take(paths)
+	for {
+		if err := runWatchCase(ctx, args, workDir, timeout, textOptions, color); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		var changed []string
+		snapshot, changed, err = watch.Wait(ctx, paths, snapshot, interval)
+		if err != nil {
+			// Interrupted
+			return nil
+		}
+		fmt.Fprintf(os.Stdout, "\n%s changed\n", strings.Join(changed, ", "))
+	}
+}
+
+// watchedPaths returns the files a watch re-runs on: the command and arguments
+// naming files, the input, the expected output and the --watch paths
+func watchedPaths(program []string) []string {
+	var paths []string
+	for _, arg := range program {
+		if info, err := os.Stat(arg); err == nil && !info.IsDir() {
+			paths = append(paths, arg)
+		}
+	}
+	paths = append(paths, watchInputFile, watchExpectedFile)
+	return append(paths, watchPaths...)
+}
+
+// runWatchCase judges the program once and prints its summary
+func runWatchCase(ctx context.Context, program []string, dir string, timeout time.Duration, textOptions compare.TextOptions, color bool) error {
+	outputPath := filepath.Join(dir, watchOutputFile)
+	stderrPath := filepath.Join(dir, watchStderrFile)
+
+	result, err := helpers.ExecuteWithSpan(ctx, &runner.Config{
+		Command:    program[0],
+		Args:       program[1:],
+		InputFile:  watchInputFile,
+		OutputFile: outputPath,
+		StderrFile: stderrPath,
+		Timeout:    timeout,
+	})
+	if err != nil {
+		// The binary may be missing or half-written until the next build; keep watching
+		fmt.Fprintf(os.Stdout, "ERROR  failed to execute command: %v\n", err)
+		return nil
+	}
+	jsonResult := helpers.CreateJSONResult(watchInputFile, outputPath, stderrPath, watchExpectedFile, result, timeout.Milliseconds(), false, "", nil)
+
+	// Only a successful run is worth comparing
+	var diff bytes.Buffer
+	if result.Status == runner.StatusSuccess {
+		equal, err := compare.DiffFiles(ctx, &diff, outputPath, watchExpectedFile, textOptions)
+		if err != nil {
+			return fmt.Errorf("failed to compare outputs: %w", err)
+		}
+		if !equal {
+			jsonResult.Status = "failed"
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, watchDiffFile), diff.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write diff file: %w", err)
+	}
+
+	if err := human.WriteResult(os.Stdout, jsonResult, color); err != nil {
+		return err
+	}
+	if diff.Len() > 0 {
+		return human.WriteDiff(os.Stdout, diff.String(), watchDiffLines, color)
+	}
+	return nil
+}
+
+func init() {
+	watchCmd.Flags().StringVarP(&watchInputFile, "input", "i", "", "Input file to redirect to the command's stdin (required)")
+	watchCmd.Flags().StringVarP(&watchExpectedFile, "expected", "x", "", "Expected output of the command (required)")
+	watchCmd.Flags().StringArrayVar(&watchPaths, "watch", nil, "Further file or directory to watch, e.g. the sources of the command (repeatable)")
+	watchCmd.Flags().StringVar(&watchIntervalStr, "interval", "500ms", "How often to check the watched files for changes")
+	watchCmd.Flags().StringVar(&watchTimeoutStr, "timeout", "", "Timeout for each run of the command (e.g. 2s)")
+	watchCmd.Flags().StringVar(&watchDiffFlags, "diff-flags", "", "Comparison flags of the built-in engine (e.g. \"-w -B\")")
+	watchCmd.Flags().StringVar(&watchWorkDir, "work-dir", "", "Directory for the output, stderr and diff of the latest run (default: temporary)")
+
+	_ = watchCmd.MarkFlagRequired("input")
+	_ = watchCmd.MarkFlagRequired("expected")
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func resetWatchFlags() {
+	watchInputFile = ""
+	watchExpectedFile = ""
+	watchPaths = nil
+	watchIntervalStr = "500ms"
+	watchTimeoutStr = ""
+	watchDiffFlags = ""
+	watchWorkDir = ""
+	// Cobra keeps the context of a command's first execution
+	watchCmd.SetContext(context.Background())
+}
+
+func TestWatchCommand(t *testing.T) {
+	resetWatchFlags()
+	defer resetWatchFlags()
+	t.Setenv("NO_COLOR", "1")
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.txt")
+	expected := filepath.Join(dir, "expected.txt")
+	solution := filepath.Join(dir, "sol.sh")
+	runs := filepath.Join(dir, "runs.txt")
+	workDir := filepath.Join(dir, "work")
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(input, "1 2\n")
+	write(expected, "3\n")
+	write(solution, "#!/bin/sh\necho run >> "+runs+"\nread a b; echo $((a * b))\n")
+
+	waitForRuns := func(n int) {
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			data, _ := os.ReadFile(runs)
+			if strings.Count(string(data), "run") >= n {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Errorf("Command did not run %d times", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		// Fix the solution once it has been judged, then stop after the re-run
+		waitForRuns(1)
+		time.Sleep(50 * time.Millisecond)
+		write(solution, "#!/bin/sh\necho run >> "+runs+"\nread a b; echo $((a + b))\n")
+		waitForRuns(2)
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	watchCmd.SetContext(ctx)
+	rootCmd.SetArgs([]string{"watch", "-i", input, "-x", expected, "--interval", "20ms", "--work-dir", workDir, "--", solution})
+	stdout, err := captureOutput(func() error {
+		return rootCmd.Execute()
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	runsOutput := strings.Split(stdout, solution+" changed\n")
+	if len(runsOutput) != 2 {
+		t.Fatalf("Output has %d runs, want 2:\n%s", len(runsOutput), stdout)
+	}
+	if !strings.HasPrefix(runsOutput[0], "FAIL  "+solution) || !strings.Contains(runsOutput[0], "    -2\n    +3\n") {
+		t.Errorf("First run = %q, want a failure with its diff", runsOutput[0])
+	}
+	if !strings.HasPrefix(runsOutput[1], "PASS  "+solution) {
+		t.Errorf("Second run = %q, want a pass", runsOutput[1])
+	}
+	if data, err := os.ReadFile(filepath.Join(workDir, watchOutputFile)); err != nil || string(data) != "3\n" {
+		t.Errorf("Output of the latest run = %q, %v, want %q", data, err, "3\n")
+	}
+}
+
+func TestWatchCommandInvalidInterval(t *testing.T) {
+	resetWatchFlags()
+	defer resetWatchFlags()
+
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.txt")
+	if err := os.WriteFile(input, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	rootCmd.SetArgs([]string{"watch", "-i", input, "-x", input, "--interval", "0s", "--", "true"})
+	_, err := captureOutput(func() error {
+		return rootCmd.Execute()
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid --interval") {
+		t.Errorf("Error = %v, want invalid --interval", err)
+	}
+}
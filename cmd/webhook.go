@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+	"github.com/zinc-sig/ghost/internal/failure"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/webhook"
+)
+
+// defaultFlushMaxAttempts is how many flushes a delivery gets before it is moved to failed/
+const defaultFlushMaxAttempts = 10
+
+var (
+	flushSpoolDir    string
+	flushMaxAttempts int
+	flushInterval    time.Duration
+	flushVerbose     bool
+)
+
+var webhookCmd = &cobra.Command{
+	Use:   "webhook",
+	Short: "Work with webhook deliveries",
+}
+
+var webhookFlushCmd = &cobra.Command{
+	Use:   "flush [--spool-dir <dir>] [--interval <duration>]",
+	Short: "Deliver webhooks spooled by --webhook-async",
+	Long: `Deliver the webhooks spooled by --webhook-async, oldest first.
+
+Each delivery is sent with the URL, headers, authentication and retry settings
+it was spooled with. Delivered webhooks are removed from the spool; failed ones
+stay for the next flush, and are moved to the failed/ subdirectory once they
+failed --max-attempts flushes. Deliveries whose URL has an open circuit breaker
+(--webhook-breaker-threshold) are left for a later flush without counting as an
+attempt. Several flushes may run against the same spool at once; every delivery
+is sent by only one of them.
+
+Without --interval the spool is flushed once. With --interval ghost keeps
+flushing until interrupted (SIGINT or SIGTERM), printing one JSON report per pass.`,
+	Example: `  ghost webhook flush
+  ghost webhook flush --spool-dir /var/spool/ghost --max-attempts 5
+  ghost webhook flush --interval 30s`,
+	Args: cobra.NoArgs,
+	RunE: webhookFlushCommand,
+}
+
+func webhookFlushCommand(cmd *cobra.Command, args []string) error {
+	if flushMaxAttempts < 0 {
+		return failure.Wrap(failure.Usage, fmt.Errorf("--max-attempts must not be negative"))
+	}
+	if flushInterval < 0 {
+		return failure.Wrap(failure.Usage, fmt.Errorf("--interval must not be negative"))
+	}
+	dir := flushSpoolDir
+	if dir == "" {
+		dir = webhook.DefaultSpoolDir()
+	}
+
+	ctx, stop := signal.NotifyContext(helpers.CommandContext(cmd), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	for {
+		if err := flushPass(ctx, dir); err != nil {
+			return err
+		}
+		if flushInterval == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(flushInterval):
+		}
+	}
+}
+
+// flushPass flushes the spool once and prints its report
+func flushPass(ctx context.Context, dir string) error {
+	flushed, err := webhook.FlushSpool(ctx, dir, flushMaxAttempts, flushVerbose)
+	if err != nil {
+		return err
+	}
+	pending, err := webhook.PendingDeliveries(dir)
+	if err != nil {
+		return err
+	}
+
+	report := &output.WebhookFlushReport{
+		Command:    "webhook flush",
+		Status:     "success",
+		Pending:    len(pending),
+		Deliveries: make([]output.FlushedDelivery, 0, len(flushed)),
+	}
+	for _, entry := range flushed {
+		switch entry.Status {
+		case webhook.FlushDelivered:
+			report.Delivered++
+		case webhook.FlushRetry:
+			report.Retrying++
+		case webhook.FlushFailed:
+			report.Failed++
+		}
+		report.Deliveries = append(report.Deliveries, output.FlushedDelivery{
+			ID:       entry.ID,
+			Status:   entry.Status,
+			Attempts: entry.Attempts,
+			Error:    entry.Error,
+		})
+	}
+	if report.Retrying+report.Failed > 0 {
+		report.Status = "failed"
+	}
+	return helpers.PrintJSON(report)
+}
+
+func init() {
+	webhookCmd.AddCommand(webhookFlushCmd)
+
+	webhookFlushCmd.Flags().StringVar(&flushSpoolDir, "spool-dir", "", "Spool directory to flush (default: <user cache dir>/ghost/webhook-spool)")
+	webhookFlushCmd.Flags().IntVar(&flushMaxAttempts, "max-attempts", defaultFlushMaxAttempts, "Flushes a delivery may fail before it is moved to failed/ (0 = retry forever)")
+	webhookFlushCmd.Flags().DurationVar(&flushInterval, "interval", 0, "Keep flushing at this interval until interrupted (0 = flush once)")
+	webhookFlushCmd.Flags().BoolVarP(&flushVerbose, "verbose", "v", false, "Show delivery details on stderr")
+}
@@ -3,12 +3,14 @@ package cmd
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 
@@ -16,6 +18,8 @@ import (
 	"github.com/zinc-sig/ghost/cmd/config"
 	"github.com/zinc-sig/ghost/cmd/helpers"
 	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/redact"
+	"github.com/zinc-sig/ghost/internal/webhook"
 )
 
 // resetWebhookGlobals resets all webhook-related global variables
@@ -38,9 +42,6 @@ func resetWebhookGlobals() {
 		RetryDelay: "1s",
 	}
 
-	// Reset the global webhook configs in helpers package
-	helpers.ResetWebhookConfigs()
-
 	// Reset timeout-related variables
 	runFlags.Timeout = 0
 	runFlags.TimeoutStr = ""
@@ -159,11 +160,13 @@ func TestRunCommand_WithWebhookAuth(t *testing.T) {
 	if err := os.WriteFile(inputFile, []byte(""), 0644); err != nil {
 		t.Fatal(err)
 	}
+	t.Setenv("GHOST_TEST_WEBHOOK_TOKEN", "resolved-token")
 
 	tests := []struct {
 		name           string
 		authType       string
 		authToken      string
+		authHeader     string
 		expectedHeader string
 		expectedValue  string
 	}{
@@ -181,6 +184,28 @@ func TestRunCommand_WithWebhookAuth(t *testing.T) {
 			expectedHeader: "X-API-Key",
 			expectedValue:  "test-api-key",
 		},
+		{
+			name:           "basic auth",
+			authType:       "basic",
+			authToken:      "grader:secret",
+			expectedHeader: "Authorization",
+			expectedValue:  "Basic Z3JhZGVyOnNlY3JldA==",
+		},
+		{
+			name:           "header auth",
+			authType:       "header",
+			authToken:      "test-service-token",
+			authHeader:     "X-Service-Token",
+			expectedHeader: "X-Service-Token",
+			expectedValue:  "test-service-token",
+		},
+		{
+			name:           "token from secret reference",
+			authType:       "bearer",
+			authToken:      "env://GHOST_TEST_WEBHOOK_TOKEN",
+			expectedHeader: "Authorization",
+			expectedValue:  "Bearer resolved-token",
+		},
 	}
 
 	for _, tt := range tests {
@@ -215,6 +240,7 @@ func TestRunCommand_WithWebhookAuth(t *testing.T) {
 				"--webhook-url", server.URL,
 				"--webhook-auth-type", tt.authType,
 				"--webhook-auth-token", tt.authToken,
+				"--webhook-auth-header", tt.authHeader,
 				"--webhook-retries", "0",
 				"--",
 				"true",
@@ -303,6 +329,20 @@ func TestRunCommand_WebhookRetry(t *testing.T) {
 	if finalAttempts != 3 {
 		t.Errorf("Expected 3 attempts (initial + 2 retries), got %d", finalAttempts)
 	}
+
+	// Every attempt leaves a receipt in the local result
+	if len(result.WebhookAttempts) != 3 {
+		t.Fatalf("webhook_attempts = %+v, want 3 receipts", result.WebhookAttempts)
+	}
+	for i, attempt := range result.WebhookAttempts {
+		wantStatus, wantDelivered := http.StatusServiceUnavailable, false
+		if i == 2 {
+			wantStatus, wantDelivered = http.StatusOK, true
+		}
+		if attempt.Attempt != i+1 || attempt.StatusCode != wantStatus || attempt.Delivered != wantDelivered || attempt.StartedAt == "" {
+			t.Errorf("webhook_attempts[%d] = %+v, want status %d delivered %v", i, attempt, wantStatus, wantDelivered)
+		}
+	}
 }
 
 func TestRunCommand_WebhookFailure(t *testing.T) {
@@ -345,6 +385,7 @@ func TestRunCommand_WebhookFailure(t *testing.T) {
 		"-e", stderrFile,
 		"--webhook-url", server.URL,
 		"--webhook-retries", "0",
+		"--webhook-spool-dir", tmpDir,
 		"--",
 		"true",
 	}
@@ -383,10 +424,97 @@ func TestRunCommand_WebhookFailure(t *testing.T) {
 	if !strings.Contains(stderrContent, "[WEBHOOK] Error:") {
 		t.Error("Expected webhook error to be logged to stderr")
 	}
+
+	// The failed delivery is kept with its receipts after ghost exits
+	recorded, err := filepath.Glob(filepath.Join(tmpDir, webhook.SpoolFailedDir, "*.json"))
+	if err != nil || len(recorded) != 1 {
+		t.Fatalf("Expected one recorded failed delivery, got %v (%v)", recorded, err)
+	}
+	data, err := os.ReadFile(recorded[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry webhook.SpoolEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatalf("Failed to parse recorded delivery: %v", err)
+	}
+	if len(entry.Receipts) != 1 || entry.Receipts[0].StatusCode != http.StatusInternalServerError || entry.Receipts[0].Delivered {
+		t.Errorf("Receipts = %+v, want one failed attempt with status 500", entry.Receipts)
+	}
+	if entry.LastError != result.WebhookError {
+		t.Errorf("LastError = %q, want the result's webhook_error %q", entry.LastError, result.WebhookError)
+	}
+}
+
+func TestRunCommand_WebhookErrorRedacted(t *testing.T) {
+	resetWebhookGlobals()
+	defer redact.Reset()
+	tmpDir := t.TempDir()
+	inputFile := filepath.Join(tmpDir, "input.txt")
+	if err := os.WriteFile(inputFile, []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing listens on the URL, so the error names it
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	webhookURL := server.URL + "/hook?token=query-secret-123"
+	server.Close()
+
+	oldStdout := os.Stdout
+	oldStderr := os.Stderr
+	defer func() {
+		os.Stdout = oldStdout
+		os.Stderr = oldStderr
+	}()
+	rOut, wOut, _ := os.Pipe()
+	rErr, wErr, _ := os.Pipe()
+	os.Stdout = wOut
+	os.Stderr = wErr
+
+	rootCmd := &cobra.Command{}
+	rootCmd.AddCommand(runCmd)
+	rootCmd.SetArgs([]string{
+		"run",
+		"-i", inputFile,
+		"-o", filepath.Join(tmpDir, "output.txt"),
+		"-e", filepath.Join(tmpDir, "stderr.txt"),
+		"--webhook-url", webhookURL,
+		"--webhook-auth-type", "bearer",
+		"--webhook-auth-token", "bearer-secret-456",
+		"--webhook-retries", "0",
+		"--webhook-spool-dir", tmpDir,
+		"--verbose",
+		"--",
+		"true",
+	})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Command should not fail due to webhook error: %v", err)
+	}
+
+	_ = wOut.Close()
+	_ = wErr.Close()
+	var bufOut, bufErr bytes.Buffer
+	_, _ = io.Copy(&bufOut, rOut)
+	_, _ = io.Copy(&bufErr, rErr)
+
+	var result output.Result
+	if err := json.Unmarshal(bufOut.Bytes(), &result); err != nil {
+		t.Fatalf("Failed to parse JSON: %v", err)
+	}
+	if result.WebhookError == "" || !strings.Contains(result.WebhookError, redact.Mask) {
+		t.Errorf("Expected a redacted webhook_error, got %q", result.WebhookError)
+	}
+	for _, secret := range []string{"query-secret-123", "bearer-secret-456"} {
+		if strings.Contains(bufOut.String(), secret) || strings.Contains(bufErr.String(), secret) {
+			t.Errorf("Secret %q leaked:\nstdout: %s\nstderr: %s", secret, bufOut.String(), bufErr.String())
+		}
+	}
 }
 
 func TestDiffCommand_WithWebhook(t *testing.T) {
 	resetWebhookGlobals()
+	resetExpectedFlags()
+	defer resetExpectedFlags()
 	tmpDir := t.TempDir()
 	inputFile := filepath.Join(tmpDir, "actual.txt")
 	expectedFile := filepath.Join(tmpDir, "expected.txt")
@@ -460,3 +588,314 @@ func TestDiffCommand_WithWebhook(t *testing.T) {
 		t.Error("Expected score to be 100 for matching files")
 	}
 }
+
+func TestRunCommand_WebhookExcludeFields(t *testing.T) {
+	resetWebhookGlobals()
+	defer resetWebhookGlobals()
+
+	tmpDir := t.TempDir()
+	inputFile := filepath.Join(tmpDir, "input.txt")
+	outputFile := filepath.Join(tmpDir, "output.txt")
+	stderrFile := filepath.Join(tmpDir, "stderr.txt")
+	if err := os.WriteFile(inputFile, []byte("test input\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var receivedPayload map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &receivedPayload)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	oldStdout := os.Stdout
+	defer func() { os.Stdout = oldStdout }()
+
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	rootCmd := &cobra.Command{}
+	rootCmd.AddCommand(runCmd)
+
+	rootCmd.SetArgs([]string{
+		"run",
+		"-i", inputFile,
+		"-o", outputFile,
+		"-e", stderrFile,
+		"--context", `{"user": {"id": 42, "email": "student@example.com"}, "token": "secret"}`,
+		"--webhook-url", server.URL,
+		"--webhook-retries", "0",
+		"--webhook-exclude-fields", "context.user.email,context.token",
+		"--",
+		"echo", "test output",
+	})
+
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("Command failed: %v", err)
+	}
+
+	_ = w.Close()
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+
+	// Local output keeps the full context
+	var stdoutResult map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &stdoutResult); err != nil {
+		t.Fatalf("Failed to parse stdout JSON: %v", err)
+	}
+	stdoutContext, _ := stdoutResult["context"].(map[string]any)
+	if stdoutContext["token"] != "secret" {
+		t.Errorf("Expected stdout context to keep token, got %v", stdoutContext)
+	}
+
+	// Webhook payload has the sensitive fields stripped
+	webhookContext, ok := receivedPayload["context"].(map[string]any)
+	if !ok {
+		t.Fatalf("Expected context in webhook payload, got %v", receivedPayload)
+	}
+	if _, ok := webhookContext["token"]; ok {
+		t.Error("Expected context.token to be stripped from webhook payload")
+	}
+	user, _ := webhookContext["user"].(map[string]any)
+	if _, ok := user["email"]; ok {
+		t.Error("Expected context.user.email to be stripped from webhook payload")
+	}
+	if user["id"] != float64(42) {
+		t.Errorf("Expected context.user.id to be kept, got %v", user["id"])
+	}
+	if receivedPayload["command"] != "echo test output" {
+		t.Errorf("Expected command to be kept, got %v", receivedPayload["command"])
+	}
+}
+
+func TestRunCommand_WebhookResponse(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		args         []string
+		wantSent     bool
+		wantError    string
+		wantResponse *output.WebhookResponse
+	}{
+		{
+			name:         "captured token",
+			status:       http.StatusOK,
+			args:         []string{"--webhook-capture-response"},
+			wantSent:     true,
+			wantResponse: &output.WebhookResponse{StatusCode: 200, Body: `{"token":"t-42"}`},
+		},
+		{
+			name:     "not captured by default",
+			status:   http.StatusOK,
+			wantSent: true,
+		},
+		{
+			name:         "unexpected status",
+			status:       http.StatusAccepted,
+			args:         []string{"--webhook-capture-response", "--webhook-expect-status", "200"},
+			wantError:    "unexpected status 202",
+			wantResponse: &output.WebhookResponse{StatusCode: 202, Body: `{"token":"t-42"}`},
+		},
+		{
+			name:     "expected non-default status",
+			status:   http.StatusAccepted,
+			args:     []string{"--webhook-expect-status", "200,202"},
+			wantSent: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetWebhookGlobals()
+			defer resetWebhookGlobals()
+
+			tmpDir := t.TempDir()
+			inputFile := filepath.Join(tmpDir, "input.txt")
+			if err := os.WriteFile(inputFile, []byte(""), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = io.WriteString(w, `{"token":"t-42"}`)
+			}))
+			defer server.Close()
+
+			oldStdout := os.Stdout
+			defer func() { os.Stdout = oldStdout }()
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			rootCmd := &cobra.Command{}
+			rootCmd.AddCommand(runCmd)
+
+			args := []string{
+				"run",
+				"-i", inputFile,
+				"-o", filepath.Join(tmpDir, "output.txt"),
+				"-e", filepath.Join(tmpDir, "stderr.txt"),
+				"--webhook-url", server.URL,
+				"--webhook-retries", "0",
+				"--webhook-spool-dir", tmpDir,
+			}
+			args = append(args, tt.args...)
+			rootCmd.SetArgs(append(args, "--", "true"))
+
+			if err := rootCmd.Execute(); err != nil {
+				t.Fatalf("Command failed: %v", err)
+			}
+
+			_ = w.Close()
+			var buf bytes.Buffer
+			_, _ = io.Copy(&buf, r)
+
+			var result output.Result
+			if err := json.Unmarshal(buf.Bytes(), &result); err != nil {
+				t.Fatalf("Failed to parse JSON: %v", err)
+			}
+			if result.WebhookSent != tt.wantSent {
+				t.Errorf("webhook_sent = %v, want %v", result.WebhookSent, tt.wantSent)
+			}
+			if tt.wantError == "" && result.WebhookError != "" {
+				t.Errorf("unexpected webhook_error %q", result.WebhookError)
+			}
+			if !strings.Contains(result.WebhookError, tt.wantError) {
+				t.Errorf("webhook_error = %q, want containing %q", result.WebhookError, tt.wantError)
+			}
+			switch {
+			case tt.wantResponse == nil && result.WebhookResponse != nil:
+				t.Errorf("unexpected webhook_response %+v", result.WebhookResponse)
+			case tt.wantResponse != nil && (result.WebhookResponse == nil || *result.WebhookResponse != *tt.wantResponse):
+				t.Errorf("webhook_response = %+v, want %+v", result.WebhookResponse, tt.wantResponse)
+			}
+		})
+	}
+}
+
+func TestWebhookConfigPerInvocation(t *testing.T) {
+	// Concurrent invocations each deliver to their own webhook
+	const invocations = 8
+	var wg sync.WaitGroup
+	for i := 0; i < invocations; i++ {
+		var received atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var event output.Event
+			_ = json.NewDecoder(r.Body).Decode(&event)
+			if event.RunID != r.Header.Get("X-Ghost-Run-Id") {
+				t.Errorf("event for run %s delivered with header %s", event.RunID, r.Header.Get("X-Ghost-Run-Id"))
+			}
+			received.Add(1)
+		}))
+		defer server.Close()
+
+		webhookConfig, retryConfig, err := helpers.ParseWebhookConfigToInternal(&config.WebhookConfig{
+			URL:     server.URL,
+			Timeout: "5s",
+			Retries: 0,
+			Events:  []string{"started"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wg.Add(1)
+		go func(runID string) {
+			defer wg.Done()
+			for j := 0; j < 3; j++ {
+				helpers.SendEvent(t.Context(), webhookConfig, retryConfig, helpers.NewEvent("started", runID, "true", nil), false, false)
+			}
+			if got := received.Load(); got != 3 {
+				t.Errorf("run %s: webhook received %d events, want 3", runID, got)
+			}
+		}(fmt.Sprintf("run-%d", i))
+	}
+	wg.Wait()
+}
+
+func TestRunCommand_WebhookHeaders(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		wantHeaders   map[string]string
+		wantUserAgent string // "" for the default
+	}{
+		{
+			name: "custom headers",
+			args: []string{"--webhook-header", "X-Course: cs101", "--webhook-header", "x-section:  A, B "},
+			wantHeaders: map[string]string{
+				"X-Course":  "cs101",
+				"X-Section": "A, B",
+			},
+		},
+		{
+			name: "flags override configured headers",
+			args: []string{"--webhook-config", `{"headers": {"x-course": "cs100", "X-Term": "fall"}}`, "--webhook-header", "X-Course: cs101"},
+			wantHeaders: map[string]string{
+				"X-Course": "cs101",
+				"X-Term":   "fall",
+			},
+		},
+		{
+			name:          "user agent override",
+			args:          []string{"--webhook-header", "User-Agent: grader/2"},
+			wantUserAgent: "grader/2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetWebhookGlobals()
+			defer resetWebhookGlobals()
+
+			var received http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r.Header.Clone()
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+			args := append([]string{"run", "-i", "/dev/null",
+				"-o", filepath.Join(dir, "output.txt"), "-e", filepath.Join(dir, "stderr.txt"),
+				"--run-id", "run-42", "--webhook-url", server.URL, "--webhook-retries", "0"}, tt.args...)
+			rootCmd.SetArgs(append(args, "--", "true"))
+			if _, err := captureOutput(rootCmd.Execute); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			if received == nil {
+				t.Fatal("webhook was not delivered")
+			}
+			for name, want := range tt.wantHeaders {
+				if got := received.Get(name); got != want {
+					t.Errorf("%s header = %q, want %q", name, got, want)
+				}
+			}
+			wantUserAgent := tt.wantUserAgent
+			if wantUserAgent == "" {
+				wantUserAgent = "ghost/dev (run_id run-42)"
+			}
+			if got := received.Get("User-Agent"); got != wantUserAgent {
+				t.Errorf("User-Agent = %q, want %q", got, wantUserAgent)
+			}
+		})
+	}
+}
+
+func TestRunCommand_WebhookHeaderInvalid(t *testing.T) {
+	for _, header := range []string{"X-Course", "X Course: cs101", ": cs101"} {
+		t.Run(header, func(t *testing.T) {
+			resetWebhookGlobals()
+			defer resetWebhookGlobals()
+
+			dir := t.TempDir()
+			rootCmd.SetArgs([]string{"run", "-i", "/dev/null",
+				"-o", filepath.Join(dir, "output.txt"), "-e", filepath.Join(dir, "stderr.txt"),
+				"--webhook-url", "http://127.0.0.1:1", "--webhook-header", header, "--", "true"})
+			if _, err := captureOutput(rootCmd.Execute); err == nil || !strings.Contains(err.Error(), "webhook header") {
+				t.Errorf("err = %v, want an invalid webhook header error", err)
+			}
+		})
+	}
+}
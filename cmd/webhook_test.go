@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/webhook"
+)
+
+func resetFlushFlags() {
+	flushSpoolDir = ""
+	flushMaxAttempts = defaultFlushMaxAttempts
+	flushInterval = 0
+	flushVerbose = false
+}
+
+func TestWebhookAsyncAndFlush(t *testing.T) {
+	resetWebhookGlobals()
+	resetFlushFlags()
+	defer resetWebhookGlobals()
+	defer resetFlushFlags()
+
+	var mu sync.Mutex
+	var received []output.Result
+	var fail bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var result output.Result
+		if err := json.Unmarshal(body, &result); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		if r.Header.Get("X-Ghost-Run-Id") != result.RunID {
+			t.Errorf("run ID header = %q, want %q", r.Header.Get("X-Ghost-Run-Id"), result.RunID)
+		}
+		received = append(received, result)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	spool := filepath.Join(dir, "spool")
+	input := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(input, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootCmd.SetArgs([]string{"run", "-i", input, "-o", filepath.Join(dir, "out.txt"), "-e", filepath.Join(dir, "err.txt"),
+		"--webhook-url", server.URL, "--webhook-retries", "0", "--webhook-async", "--webhook-spool-dir", spool, "--", "cat"})
+	out, err := captureOutput(func() error { return rootCmd.Execute() })
+	if err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	var result output.Result
+	if err := json.Unmarshal([]byte(out), &result); err != nil {
+		t.Fatalf("invalid result: %v\n%s", err, out)
+	}
+	if !result.WebhookSpooled || result.WebhookSent || result.WebhookError != "" {
+		t.Errorf("webhook_spooled=%v webhook_sent=%v webhook_error=%q, want only spooled", result.WebhookSpooled, result.WebhookSent, result.WebhookError)
+	}
+	if len(received) != 0 {
+		t.Fatalf("async webhook was sent immediately")
+	}
+	if ids, _ := webhook.PendingDeliveries(spool); len(ids) != 1 {
+		t.Fatalf("spool holds %v, want one delivery", ids)
+	}
+
+	flush := func() output.WebhookFlushReport {
+		t.Helper()
+		resetFlushFlags()
+		rootCmd.SetArgs([]string{"webhook", "flush", "--spool-dir", spool, "--max-attempts", "2"})
+		out, err := captureOutput(func() error { return rootCmd.Execute() })
+		if err != nil {
+			t.Fatalf("flush failed: %v", err)
+		}
+		var report output.WebhookFlushReport
+		if err := json.Unmarshal([]byte(out), &report); err != nil {
+			t.Fatalf("invalid flush report: %v\n%s", err, out)
+		}
+		return report
+	}
+
+	// A failed delivery stays in the spool
+	fail = true
+	report := flush()
+	if report.Status != "failed" || report.Retrying != 1 || report.Pending != 1 {
+		t.Errorf("failed flush report = %+v", report)
+	}
+
+	fail = false
+	report = flush()
+	if report.Status != "success" || report.Delivered != 1 || report.Pending != 0 {
+		t.Errorf("flush report = %+v", report)
+	}
+	if len(received) != 1 || received[0].RunID != result.RunID || received[0].WebhookSpooled {
+		t.Fatalf("received = %+v, want the spooled result", received)
+	}
+
+	// Nothing left to deliver
+	report = flush()
+	if report.Status != "success" || len(report.Deliveries) != 0 {
+		t.Errorf("empty flush report = %+v", report)
+	}
+}
+
+func TestWebhookCircuitBreaker(t *testing.T) {
+	resetWebhookGlobals()
+	resetFlushFlags()
+	defer resetWebhookGlobals()
+	defer resetFlushFlags()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	spool := filepath.Join(dir, "spool")
+	input := filepath.Join(dir, "input.txt")
+	if err := os.WriteFile(input, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each case of a batch is a separate run sharing the breaker state in the spool
+	for i := 1; i <= 4; i++ {
+		resetWebhookGlobals()
+		rootCmd.SetArgs([]string{"run", "-i", input, "-o", filepath.Join(dir, "out.txt"), "-e", filepath.Join(dir, "err.txt"),
+			"--webhook-url", server.URL, "--webhook-retries", "0", "--webhook-spool-dir", spool,
+			"--webhook-breaker-threshold", "2", "--webhook-breaker-cooldown", "1h", "--", "cat"})
+		out, err := captureOutput(func() error { return rootCmd.Execute() })
+		if err != nil {
+			t.Fatalf("run %d failed: %v", i, err)
+		}
+		var result output.Result
+		if err := json.Unmarshal([]byte(out), &result); err != nil {
+			t.Fatalf("invalid result: %v\n%s", err, out)
+		}
+		open := i > 2
+		if result.WebhookSpooled != open || (result.WebhookError == "") == !open || result.WebhookSent {
+			t.Errorf("run %d: webhook_spooled=%v webhook_error=%q webhook_sent=%v", i, result.WebhookSpooled, result.WebhookError, result.WebhookSent)
+		}
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("requests = %d, want 2 before the circuit opened", got)
+	}
+	if ids, _ := webhook.PendingDeliveries(spool); len(ids) != 2 {
+		t.Fatalf("spool holds %v, want the 2 deliveries made while open", ids)
+	}
+
+	// Flushing respects the open circuit without using up attempts
+	rootCmd.SetArgs([]string{"webhook", "flush", "--spool-dir", spool, "--max-attempts", "1"})
+	out, err := captureOutput(func() error { return rootCmd.Execute() })
+	if err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	var report output.WebhookFlushReport
+	if err := json.Unmarshal([]byte(out), &report); err != nil {
+		t.Fatalf("invalid flush report: %v\n%s", err, out)
+	}
+	if report.Retrying != 2 || report.Failed != 0 || report.Pending != 2 || requests.Load() != 2 {
+		t.Errorf("flush report = %+v, requests = %d", report, requests.Load())
+	}
+}
+
+func TestWebhookFlushInterval(t *testing.T) {
+	resetFlushFlags()
+	defer resetFlushFlags()
+
+	// The interval loop stops on cancellation, as on SIGINT or SIGTERM
+	rootCmd.SetArgs([]string{"webhook", "flush", "--spool-dir", t.TempDir(), "--interval", "1h"})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	webhookFlushCmd.SetContext(ctx)
+	defer webhookFlushCmd.SetContext(context.Background())
+	out, err := captureOutput(func() error { return rootCmd.Execute() })
+	if err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	if n := strings.Count(strings.TrimSpace(out), "\n") + 1; n != 1 {
+		t.Errorf("got %d reports, want 1:\n%s", n, out)
+	}
+}
+
+func TestWebhookAsyncConfig(t *testing.T) {
+	resetWebhookGlobals()
+	defer resetWebhookGlobals()
+
+	rootCmd.SetArgs([]string{"run", "-i", "in", "-o", "out", "-e", "err", "--webhook-url", "http://localhost",
+		"--webhook-async", "--webhook-capture-response", "--", "true"})
+	_, err := captureOutput(func() error { return rootCmd.Execute() })
+	if err == nil || !strings.Contains(err.Error(), "capture_response cannot be used with async") {
+		t.Errorf("err = %v, want async and capture_response rejected", err)
+	}
+}
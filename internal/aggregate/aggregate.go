@@ -0,0 +1,234 @@
+// Package aggregate combines ghost results into a weighted summary score
+package aggregate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultCaseKey identifies a case by the input file of its result
+const DefaultCaseKey = "input"
+
+// CaseWeight configures how a case contributes to the summary
+type CaseWeight struct {
+	Weight *float64 `yaml:"weight"` // Multiplier for the case score (default 1)
+	Max    *float64 `yaml:"max"`    // Maximum unweighted score of the case, for percentages
+}
+
+// Weights maps case keys to their weights, with a default for unlisted cases
+type Weights struct {
+	Default CaseWeight            `yaml:"default"`
+	Cases   map[string]CaseWeight `yaml:"cases"`
+}
+
+// LoadWeights reads a weights file (YAML or JSON)
+func LoadWeights(path string) (*Weights, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read weights file: %w", err)
+	}
+
+	var weights Weights
+	if err := yaml.Unmarshal(data, &weights); err != nil {
+		return nil, fmt.Errorf("failed to parse weights file %s: %w", path, err)
+	}
+	for key, w := range weights.Cases {
+		if w.Weight != nil && *w.Weight < 0 {
+			return nil, fmt.Errorf("weight of case %q must not be negative", key)
+		}
+	}
+	if weights.Default.Weight != nil && *weights.Default.Weight < 0 {
+		return nil, fmt.Errorf("default weight must not be negative")
+	}
+	return &weights, nil
+}
+
+// lookup returns the weight and maximum score for a case, falling back to the defaults
+func (w *Weights) lookup(key string) (decimal.Decimal, *decimal.Decimal) {
+	weight := decimal.NewFromInt(1)
+	var maxScore *decimal.Decimal
+	apply := func(cw CaseWeight) {
+		if cw.Weight != nil {
+			weight = decimal.NewFromFloat(*cw.Weight)
+		}
+		if cw.Max != nil {
+			m := decimal.NewFromFloat(*cw.Max)
+			maxScore = &m
+		}
+	}
+
+	if w != nil {
+		apply(w.Default)
+		if cw, ok := w.Cases[key]; ok {
+			apply(cw)
+		}
+	}
+	return weight, maxScore
+}
+
+// Result is a single ghost result read for aggregation
+type Result struct {
+	Key    string
+	Status string
+	Score  *decimal.Decimal
+
+	// Details reported for failed cases
+	ExitCode *int
+	Feedback string
+	DiffFile string // diff output of a diff result ("" for run results)
+
+	// Raw is the result as read, for reports that print its fields
+	Raw map[string]any
+}
+
+// ReadResults reads ghost results from r, which may hold a single JSON object,
+// newline-delimited JSON or concatenated objects
+// The case key is looked up with a dot-notation path such as "context.case".
+func ReadResults(r io.Reader, source, keyPath string) ([]Result, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	var results []Result
+	for n := 1; ; n++ {
+		var raw map[string]any
+		if err := decoder.Decode(&raw); err != nil {
+			if errors.Is(err, io.EOF) {
+				return results, nil
+			}
+			return nil, fmt.Errorf("failed to parse result %d in %s: %w", n, source, err)
+		}
+
+		result, err := toResult(raw, keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("result %d in %s: %w", n, source, err)
+		}
+		results = append(results, result)
+	}
+}
+
+func toResult(raw map[string]any, keyPath string) (Result, error) {
+	key, ok := lookupPath(raw, keyPath)
+	if !ok || key == nil {
+		return Result{}, fmt.Errorf("case key %q not found", keyPath)
+	}
+
+	result := Result{Key: fmt.Sprint(key), Raw: raw}
+	result.Status, _ = raw["status"].(string)
+	result.Feedback, _ = raw["feedback"].(string)
+	if code, ok := raw["exit_code"].(json.Number); ok {
+		if n, err := code.Int64(); err == nil {
+			exitCode := int(n)
+			result.ExitCode = &exitCode
+		}
+	}
+	// Only diff results have an expected file; their output is the diff
+	if _, ok := raw["expected"]; ok {
+		result.DiffFile, _ = raw["output"].(string)
+	}
+
+	// Scores are written as JSON strings by ghost, but accept numbers too
+	if value, ok := raw["score"]; ok && value != nil {
+		score, err := decimal.NewFromString(fmt.Sprint(value))
+		if err != nil {
+			return Result{}, fmt.Errorf("invalid score %v: %w", value, err)
+		}
+		result.Score = &score
+	}
+	return result, nil
+}
+
+// lookupPath returns the value at a dot-notation path in a decoded JSON object
+func lookupPath(value any, path string) (any, bool) {
+	for _, part := range strings.Split(path, ".") {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// Case is the contribution of one result to the summary
+type Case struct {
+	Key           string
+	Status        string
+	Score         *decimal.Decimal // nil if the result had no score
+	Weight        decimal.Decimal
+	WeightedScore decimal.Decimal
+	MaxScore      *decimal.Decimal // weighted maximum, nil if unknown
+
+	ExitCode *int
+	Feedback string
+	DiffFile string
+
+	Raw map[string]any
+}
+
+// Summary is the aggregated score over all results
+type Summary struct {
+	Score      decimal.Decimal
+	MaxScore   *decimal.Decimal // only if every case has a maximum
+	Percentage *decimal.Decimal // score / max_score * 100, two decimals
+	Passed     int
+	Total      int
+	Cases      []Case
+}
+
+// Aggregate applies the weights to the results and sums them up
+// Results without a score count as zero.
+func Aggregate(results []Result, weights *Weights) *Summary {
+	summary := &Summary{Score: decimal.Zero, Total: len(results), Cases: make([]Case, 0, len(results))}
+
+	maxTotal := decimal.Zero
+	allMax := len(results) > 0
+	for _, result := range results {
+		weight, maxScore := weights.lookup(result.Key)
+
+		c := Case{
+			Key:           result.Key,
+			Status:        result.Status,
+			Score:         result.Score,
+			Weight:        weight,
+			WeightedScore: decimal.Zero,
+			ExitCode:      result.ExitCode,
+			Feedback:      result.Feedback,
+			DiffFile:      result.DiffFile,
+			Raw:           result.Raw,
+		}
+		if result.Score != nil {
+			c.WeightedScore = result.Score.Mul(weight)
+		}
+		if maxScore != nil {
+			weighted := maxScore.Mul(weight)
+			c.MaxScore = &weighted
+			maxTotal = maxTotal.Add(weighted)
+		} else {
+			allMax = false
+		}
+
+		if result.Status == "success" {
+			summary.Passed++
+		}
+		summary.Score = summary.Score.Add(c.WeightedScore)
+		summary.Cases = append(summary.Cases, c)
+	}
+
+	if allMax {
+		summary.MaxScore = &maxTotal
+		if maxTotal.IsPositive() {
+			percentage := summary.Score.Mul(decimal.NewFromInt(100)).DivRound(maxTotal, 2)
+			summary.Percentage = &percentage
+		}
+	}
+	return summary
+}
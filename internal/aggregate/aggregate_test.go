@@ -0,0 +1,168 @@
+package aggregate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadResults(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		keyPath  string
+		wantKeys []string
+		wantErr  string
+	}{
+		{
+			name:     "single object",
+			input:    `{"input": "a.in", "status": "success", "score": "10"}`,
+			keyPath:  "input",
+			wantKeys: []string{"a.in"},
+		},
+		{
+			name:     "ndjson",
+			input:    "{\"input\": \"a.in\", \"score\": \"10\"}\n{\"input\": \"b.in\", \"score\": 5}\n",
+			keyPath:  "input",
+			wantKeys: []string{"a.in", "b.in"},
+		},
+		{
+			name:     "nested key",
+			input:    `{"context": {"case": 3}, "status": "failed"}`,
+			keyPath:  "context.case",
+			wantKeys: []string{"3"},
+		},
+		{
+			name:    "missing key",
+			input:   `{"status": "success"}`,
+			keyPath: "context.case",
+			wantErr: `result 1 in test: case key "context.case" not found`,
+		},
+		{
+			name:    "invalid json",
+			input:   `{"input": `,
+			keyPath: "input",
+			wantErr: "failed to parse result 1 in test",
+		},
+		{
+			name:    "invalid score",
+			input:   `{"input": "a.in", "score": "lots"}`,
+			keyPath: "input",
+			wantErr: "invalid score",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := ReadResults(strings.NewReader(tt.input), "test", tt.keyPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ReadResults() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadResults() error = %v", err)
+			}
+			if len(results) != len(tt.wantKeys) {
+				t.Fatalf("Got %d results, want %d", len(results), len(tt.wantKeys))
+			}
+			for i, result := range results {
+				if result.Key != tt.wantKeys[i] {
+					t.Errorf("Result %d key = %q, want %q", i, result.Key, tt.wantKeys[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weights.yaml")
+	content := "default:\n  weight: 1\n  max: 10\ncases:\n  hard.in:\n    weight: 2\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	weights, err := LoadWeights(path)
+	if err != nil {
+		t.Fatalf("LoadWeights() error = %v", err)
+	}
+
+	input := `{"input": "easy.in", "status": "success", "score": "10"}
+{"input": "hard.in", "status": "success", "score": "7.5"}
+{"input": "crash.in", "status": "failed"}`
+	results, err := ReadResults(strings.NewReader(input), "test", DefaultCaseKey)
+	if err != nil {
+		t.Fatalf("ReadResults() error = %v", err)
+	}
+
+	summary := Aggregate(results, weights)
+	if summary.Score.String() != "25" {
+		t.Errorf("Score = %s, want 25", summary.Score)
+	}
+	if summary.MaxScore == nil || summary.MaxScore.String() != "40" {
+		t.Errorf("MaxScore = %v, want 40", summary.MaxScore)
+	}
+	if summary.Percentage == nil || summary.Percentage.String() != "62.5" {
+		t.Errorf("Percentage = %v, want 62.5", summary.Percentage)
+	}
+	if summary.Passed != 2 || summary.Total != 3 {
+		t.Errorf("Passed/Total = %d/%d, want 2/3", summary.Passed, summary.Total)
+	}
+
+	hard := summary.Cases[1]
+	if hard.Weight.String() != "2" || hard.WeightedScore.String() != "15" || hard.MaxScore.String() != "20" {
+		t.Errorf("hard.in = weight %s, weighted %s, max %s", hard.Weight, hard.WeightedScore, hard.MaxScore)
+	}
+	if summary.Cases[2].Score != nil || !summary.Cases[2].WeightedScore.IsZero() {
+		t.Errorf("Unscored case should count as zero, got %+v", summary.Cases[2])
+	}
+}
+
+func TestAggregateWithoutWeights(t *testing.T) {
+	results, err := ReadResults(strings.NewReader(`{"input": "a.in", "status": "success", "score": "3"}`), "test", DefaultCaseKey)
+	if err != nil {
+		t.Fatalf("ReadResults() error = %v", err)
+	}
+
+	summary := Aggregate(results, nil)
+	if summary.Score.String() != "3" {
+		t.Errorf("Score = %s, want 3", summary.Score)
+	}
+	if summary.MaxScore != nil || summary.Percentage != nil {
+		t.Error("Expected no maximum or percentage without max scores")
+	}
+}
+
+func TestLoadWeightsNegative(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weights.yaml")
+	if err := os.WriteFile(path, []byte("cases:\n  a.in:\n    weight: -1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadWeights(path); err == nil || !strings.Contains(err.Error(), "must not be negative") {
+		t.Errorf("LoadWeights() error = %v, want negative weight error", err)
+	}
+}
+
+func TestReadResultsDetails(t *testing.T) {
+	input := `{"input": "a.in", "expected": "a.out", "output": "a.diff", "status": "failed", "exit_code": 1, "feedback": "off by one"}
+{"input": "b.in", "output": "b.out", "status": "timeout", "exit_code": -1}
+`
+	results, err := ReadResults(strings.NewReader(input), "test", DefaultCaseKey)
+	if err != nil {
+		t.Fatalf("ReadResults() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Got %d results, want 2", len(results))
+	}
+
+	diff := results[0]
+	if diff.ExitCode == nil || *diff.ExitCode != 1 || diff.Feedback != "off by one" || diff.DiffFile != "a.diff" {
+		t.Errorf("Diff result = %+v", diff)
+	}
+	// Run results have no diff output
+	run := results[1]
+	if run.ExitCode == nil || *run.ExitCode != -1 || run.DiffFile != "" {
+		t.Errorf("Run result = %+v", run)
+	}
+}
@@ -0,0 +1,111 @@
+package aggregate
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Columns computed by the aggregation rather than read from the result
+const (
+	ColumnCase          = "case"
+	ColumnWeight        = "weight"
+	ColumnWeightedScore = "weighted_score"
+	ColumnMaxScore      = "max_score"
+)
+
+// DefaultCSVColumns are the CSV report columns used when none are selected
+var DefaultCSVColumns = []string{ColumnCase, "status", "exit_code", "score", ColumnWeightedScore, ColumnMaxScore}
+
+// WriteNDJSON writes every case's result as read, one JSON object per line
+func WriteNDJSON(w io.Writer, summary *Summary) error {
+	bw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bw)
+	encoder.SetEscapeHTML(false)
+	for _, c := range summary.Cases {
+		if err := encoder.Encode(c.Raw); err != nil {
+			return fmt.Errorf("failed to write result of case %s: %w", c.Key, err)
+		}
+	}
+	return bw.Flush()
+}
+
+// ValidateColumns checks that every CSV column names a field
+func ValidateColumns(columns []string) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("no columns selected")
+	}
+	for _, column := range columns {
+		if column == "" || strings.HasPrefix(column, ".") || strings.HasSuffix(column, ".") || strings.Contains(column, "..") {
+			return fmt.Errorf("invalid column %q", column)
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes a header row and one row per case with the selected columns
+// A column is one of the aggregated columns (case, weight, weighted_score,
+// max_score) or a dot-notation path into the result, such as context.student_id.
+// Missing fields are empty; objects and arrays are written as JSON.
+func WriteCSV(w io.Writer, summary *Summary, columns []string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return fmt.Errorf("failed to write CSV report: %w", err)
+	}
+	row := make([]string, len(columns))
+	for _, c := range summary.Cases {
+		for i, column := range columns {
+			row[i] = c.column(column)
+		}
+		if err := cw.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV report: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV report: %w", err)
+	}
+	return nil
+}
+
+// column returns the value of a CSV column for the case
+func (c *Case) column(name string) string {
+	switch name {
+	case ColumnCase:
+		return c.Key
+	case ColumnWeight:
+		return c.Weight.String()
+	case ColumnWeightedScore:
+		return c.WeightedScore.String()
+	case ColumnMaxScore:
+		if c.MaxScore == nil {
+			return ""
+		}
+		return c.MaxScore.String()
+	}
+
+	value, ok := lookupPath(c.Raw, name)
+	if !ok {
+		return ""
+	}
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
@@ -0,0 +1,88 @@
+package aggregate
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const reportResults = `{"input": "tests/a.in", "status": "success", "exit_code": 0, "score": "10", "context": {"student": "s1", "tags": ["x", "y"]}}
+{"input": "tests/b,c.in", "status": "failed", "exit_code": 1, "score": "0", "feedback": "line one\nline two"}
+`
+
+func reportSummary(t *testing.T) *Summary {
+	t.Helper()
+	results, err := ReadResults(strings.NewReader(reportResults), "test", DefaultCaseKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	maxScore := 10.0
+	weight := 2.0
+	return Aggregate(results, &Weights{Default: CaseWeight{Max: &maxScore}, Cases: map[string]CaseWeight{"tests/a.in": {Weight: &weight}}})
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteNDJSON(&buf, reportSummary(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	var first map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("invalid line %q: %v", lines[0], err)
+	}
+	if first["input"] != "tests/a.in" || first["exit_code"] != float64(0) || first["score"] != "10" {
+		t.Errorf("first line = %v, want the result as read", first)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []string
+		want    string
+	}{
+		{
+			name:    "default columns",
+			columns: DefaultCSVColumns,
+			want: "case,status,exit_code,score,weighted_score,max_score\n" +
+				"tests/a.in,success,0,10,20,20\n" +
+				"\"tests/b,c.in\",failed,1,0,0,10\n",
+		},
+		{
+			name:    "result paths",
+			columns: []string{"context.student", "context.tags", "feedback", "weight", "missing.field"},
+			want: "context.student,context.tags,feedback,weight,missing.field\n" +
+				"s1,\"[\"\"x\"\",\"\"y\"\"]\",,2,\n" +
+				",,\"line one\nline two\",1,\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteCSV(&buf, reportSummary(t), tt.columns); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("CSV =\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateColumns(t *testing.T) {
+	if err := ValidateColumns([]string{"case", "context.student"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, columns := range [][]string{nil, {"status", ""}, {"context."}, {"a..b"}} {
+		if err := ValidateColumns(columns); err == nil {
+			t.Errorf("ValidateColumns(%q) succeeded, want an error", columns)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package aggregate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// TAPDiffLines is how many lines of a failed case's diff output are shown in TAP diagnostics
+var TAPDiffLines = 20
+
+// WriteTAP writes the summary as a TAP version 13 stream with one test point per case
+// Cases with status "success" are ok. Failed cases are followed by diagnostic lines
+// with their status, exit code, score, feedback and the start of their diff output.
+func WriteTAP(w io.Writer, summary *Summary) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "TAP version 13")
+	fmt.Fprintf(bw, "1..%d\n", len(summary.Cases))
+
+	for i, c := range summary.Cases {
+		if c.Status == "success" {
+			fmt.Fprintf(bw, "ok %d - %s\n", i+1, tapDescription(c.Key))
+			continue
+		}
+		fmt.Fprintf(bw, "not ok %d - %s\n", i+1, tapDescription(c.Key))
+		for _, line := range c.diagnostics() {
+			fmt.Fprintf(bw, "# %s\n", line)
+		}
+	}
+
+	score := summary.Score.String()
+	if summary.MaxScore != nil {
+		score += " / " + summary.MaxScore.String()
+	}
+	if summary.Percentage != nil {
+		score += " (" + summary.Percentage.String() + "%)"
+	}
+	fmt.Fprintf(bw, "# passed %d of %d, score %s\n", summary.Passed, summary.Total, score)
+	return bw.Flush()
+}
+
+// diagnostics returns the diagnostic lines of a failed case
+func (c *Case) diagnostics() []string {
+	status := c.Status
+	if status == "" {
+		status = "unknown"
+	}
+	if c.ExitCode != nil {
+		status += fmt.Sprintf(" (exit code %d)", *c.ExitCode)
+	}
+	lines := []string{"status: " + status}
+
+	// Scores are shown weighted, like the maximum
+	if c.Score != nil || c.MaxScore != nil {
+		score := c.WeightedScore.String()
+		if c.MaxScore != nil {
+			score += " / " + c.MaxScore.String()
+		}
+		lines = append(lines, "score: "+score)
+	}
+	for _, line := range strings.Split(strings.TrimRight(c.Feedback, "\n"), "\n") {
+		if line != "" {
+			lines = append(lines, "feedback: "+line)
+		}
+	}
+	if c.DiffFile != "" {
+		lines = append(lines, diffSnippet(c.DiffFile, TAPDiffLines)...)
+	}
+	return lines
+}
+
+// diffSnippet returns the first lines of a diff output file, indented for diagnostics
+// Nothing is returned if the file cannot be read, e.g. because it was uploaded only.
+func diffSnippet(path string, maxLines int) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = file.Close() }()
+
+	var lines []string
+	more := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(lines) < maxLines {
+			lines = append(lines, "  "+scanner.Text())
+		} else {
+			more++
+		}
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	snippet := append([]string{"diff (" + path + "):"}, lines...)
+	if more > 0 {
+		snippet = append(snippet, fmt.Sprintf("  ... %d more lines", more))
+	}
+	return snippet
+}
+
+// tapDescription makes a case key safe to use as a test point description
+// A "#" would start a directive such as SKIP, and line breaks would end the line.
+func tapDescription(key string) string {
+	return strings.NewReplacer("\\", "\\\\", "#", "\\#", "\r", " ", "\n", " ").Replace(key)
+}
@@ -0,0 +1,68 @@
+package aggregate
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestWriteTAP(t *testing.T) {
+	dir := t.TempDir()
+	shortDiff := filepath.Join(dir, "short.diff")
+	if err := os.WriteFile(shortDiff, []byte("1c1\n< 41\n---\n> 42\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var long strings.Builder
+	for i := 1; i <= TAPDiffLines+5; i++ {
+		fmt.Fprintf(&long, "line %d\n", i)
+	}
+	longDiff := filepath.Join(dir, "long.diff")
+	if err := os.WriteFile(longDiff, []byte(long.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ten := decimal.NewFromInt(10)
+	zero := decimal.Zero
+	exitCode := 1
+	maxScore := decimal.NewFromInt(20)
+	summary := &Summary{
+		Score:    decimal.NewFromInt(10),
+		MaxScore: &maxScore,
+		Passed:   1,
+		Total:    4,
+		Cases: []Case{
+			{Key: "tests/a.in", Status: "success", Score: &ten, WeightedScore: ten},
+			{Key: "tests/b.in", Status: "failed", Score: &zero, WeightedScore: zero, MaxScore: &ten, ExitCode: &exitCode, Feedback: "wrong answer", DiffFile: shortDiff},
+			{Key: "case #3", Status: "timeout", DiffFile: longDiff},
+			{Key: "tests/d.in", Status: "failed", DiffFile: filepath.Join(dir, "uploaded-only.diff")},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTAP(&buf, summary); err != nil {
+		t.Fatalf("WriteTAP() error = %v", err)
+	}
+	got := buf.String()
+
+	want := []string{
+		"TAP version 13\n1..4\n",
+		"ok 1 - tests/a.in\n",
+		"not ok 2 - tests/b.in\n# status: failed (exit code 1)\n# score: 0 / 10\n# feedback: wrong answer\n# diff (" + shortDiff + "):\n#   1c1\n#   < 41\n#   ---\n#   > 42\n",
+		"not ok 3 - case \\#3\n# status: timeout\n",
+		fmt.Sprintf("#   line %d\n#   ... 5 more lines\n", TAPDiffLines),
+		"not ok 4 - tests/d.in\n# status: failed\n# passed 1 of 4, score 10 / 20\n",
+	}
+	for _, w := range want {
+		if !strings.Contains(got, w) {
+			t.Errorf("WriteTAP() output missing %q\nGot:\n%s", w, got)
+		}
+	}
+	if strings.Contains(got, fmt.Sprintf("line %d\n", TAPDiffLines+1)) {
+		t.Errorf("WriteTAP() output has more than %d diff lines\nGot:\n%s", TAPDiffLines, got)
+	}
+}
@@ -0,0 +1,64 @@
+// Package bytesize parses byte counts such as "16MiB" or "100MB" from config values
+package bytesize
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// units are matched in order, so longer suffixes come first
+var units = []struct {
+	suffix     string
+	multiplier uint64
+}{
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30},
+	{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9}, {"B", 1},
+}
+
+// Parse parses a byte count given as a number or a string with an optional
+// binary (KiB, MiB, GiB) or decimal (KB, MB, GB) unit
+func Parse(val any) (uint64, error) {
+	switch v := val.(type) {
+	case int:
+		if v >= 0 {
+			return uint64(v), nil
+		}
+	case int64:
+		if v >= 0 {
+			return uint64(v), nil
+		}
+	case float64:
+		if v >= 0 && v == float64(uint64(v)) {
+			return uint64(v), nil
+		}
+	case string:
+		s := strings.TrimSpace(v)
+		multiplier := uint64(1)
+		for _, unit := range units {
+			if strings.HasSuffix(s, unit.suffix) {
+				s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+				multiplier = unit.multiplier
+				break
+			}
+		}
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err == nil {
+			return n * multiplier, nil
+		}
+	}
+	return 0, fmt.Errorf("%v is not a byte size", val)
+}
+
+// Format renders a byte count with a decimal unit, e.g. 10.5 MB
+func Format(n int64) string {
+	switch {
+	case n >= 1e9:
+		return fmt.Sprintf("%.1f GB", float64(n)/1e9)
+	case n >= 1e6:
+		return fmt.Sprintf("%.1f MB", float64(n)/1e6)
+	case n >= 1e3:
+		return fmt.Sprintf("%.1f KB", float64(n)/1e3)
+	}
+	return fmt.Sprintf("%d B", n)
+}
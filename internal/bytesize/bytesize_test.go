@@ -0,0 +1,55 @@
+package bytesize
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value   any
+		want    uint64
+		wantErr bool
+	}{
+		{value: 1024, want: 1024},
+		{value: int64(5), want: 5},
+		{value: float64(2048), want: 2048},
+		{value: "512", want: 512},
+		{value: "64MiB", want: 64 << 20},
+		{value: "1 GiB", want: 1 << 30},
+		{value: "100MB", want: 100e6},
+		{value: "3KB", want: 3000},
+		{value: "7B", want: 7},
+		{value: -1, wantErr: true},
+		{value: 1.5, wantErr: true},
+		{value: "big", wantErr: true},
+		{value: "1.5GiB", wantErr: true},
+		{value: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%v) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%v) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{999, "999 B"},
+		{1500, "1.5 KB"},
+		{10_500_000, "10.5 MB"},
+		{2e9, "2.0 GB"},
+	}
+	for _, tt := range tests {
+		if got := Format(tt.n); got != tt.want {
+			t.Errorf("Format(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
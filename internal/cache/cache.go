@@ -0,0 +1,197 @@
+// Package cache stores graded results with their artifacts under a content hash
+// of everything that determines the grade, so unchanged submissions are not re-run
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+// Entry layout: <dir>/<key[:2]>/<key>/result.json, with one file per artifact
+// next to it. Bump keyVersion when the key or the layout changes.
+const (
+	keyVersion = "1"
+	resultName = "result.json"
+)
+
+// Key accumulates the parts of a cache key
+// Every part is written with its name and length, so no two different sets of
+// parts hash the same.
+type Key struct {
+	h hash.Hash
+}
+
+// NewKey returns an empty key
+func NewKey() *Key {
+	k := &Key{h: sha256.New()}
+	k.Add("version", keyVersion)
+	return k
+}
+
+// Add adds a named list of values
+func (k *Key) Add(name string, values ...string) {
+	k.write(name)
+	k.write(strconv.Itoa(len(values)))
+	for _, v := range values {
+		k.write(v)
+	}
+}
+
+// AddFile adds the content of a file, or of every file under a directory
+// Files are identified by their path relative to path and hashed in path order;
+// a missing path is recorded as missing.
+func (k *Key) AddFile(name, path string) error {
+	k.write(name)
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		k.write("missing")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	if !info.IsDir() {
+		k.write("file")
+		return k.addContent(path)
+	}
+
+	k.write("dir")
+	return filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to hash %s: %w", file, err)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(path, file)
+		if err != nil {
+			return err
+		}
+		k.write(filepath.ToSlash(rel))
+		return k.addContent(file)
+	})
+}
+
+// Sum returns the key as a hex digest
+func (k *Key) Sum() string {
+	return hex.EncodeToString(k.h.Sum(nil))
+}
+
+func (k *Key) write(s string) {
+	_, _ = fmt.Fprintf(k.h, "%d:%s", len(s), s)
+}
+
+func (k *Key) addContent(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	k.write(hex.EncodeToString(h.Sum(nil)))
+	return nil
+}
+
+// entryDir returns the directory of a key's entry
+func entryDir(dir, key string) string {
+	return filepath.Join(dir, key[:2], key)
+}
+
+// Load returns the result stored under key and copies its artifacts to their
+// targets, given as artifact name to path. It returns nil without an error when
+// nothing is stored under key.
+func Load(dir, key string, artifacts map[string]string) (*output.Result, error) {
+	entry := entryDir(dir, key)
+	data, err := os.ReadFile(filepath.Join(entry, resultName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached result: %w", err)
+	}
+
+	var result output.Result
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse cached result %s: %w", filepath.Join(entry, resultName), err)
+	}
+	for name, target := range artifacts {
+		if err := copyFile(filepath.Join(entry, name), target); err != nil {
+			return nil, fmt.Errorf("failed to restore cached %s: %w", name, err)
+		}
+	}
+	return &result, nil
+}
+
+// Store saves a result and its artifacts, given as artifact name to path, under key
+// The entry is written to a temporary directory first and renamed into place, so
+// concurrent runs never see a partial entry.
+func Store(dir, key string, result *output.Result, artifacts map[string]string) error {
+	entry := entryDir(dir, key)
+	if err := os.MkdirAll(filepath.Dir(entry), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(entry), key+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	for name, source := range artifacts {
+		if err := copyFile(source, filepath.Join(tmp, name)); err != nil {
+			return fmt.Errorf("failed to cache %s: %w", name, err)
+		}
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, resultName), data, 0644); err != nil {
+		return fmt.Errorf("failed to cache result: %w", err)
+	}
+
+	// Another run may have stored the same grade in the meantime; either entry will do
+	if err := os.Rename(tmp, entry); err != nil {
+		if _, statErr := os.Stat(filepath.Join(entry, resultName)); statErr == nil {
+			return nil
+		}
+		return fmt.Errorf("failed to store cache entry: %w", err)
+	}
+	return nil
+}
+
+// copyFile copies source to target, creating the target's directory
+func copyFile(source, target string) error {
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	out, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
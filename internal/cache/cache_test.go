@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestKey(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.txt"), "hello")
+	writeFile(t, filepath.Join(dir, "src", "main.c"), "int main;")
+
+	sum := func(build func(k *Key) error) string {
+		t.Helper()
+		k := NewKey()
+		if err := build(k); err != nil {
+			t.Fatal(err)
+		}
+		return k.Sum()
+	}
+	base := sum(func(k *Key) error {
+		k.Add("argv", "./a.out", "1")
+		return k.AddFile("input", filepath.Join(dir, "a.txt"))
+	})
+
+	if again := sum(func(k *Key) error {
+		k.Add("argv", "./a.out", "1")
+		return k.AddFile("input", filepath.Join(dir, "a.txt"))
+	}); again != base {
+		t.Errorf("Key of the same parts changed: %s != %s", again, base)
+	}
+
+	others := map[string]func(k *Key) error{
+		"different argument": func(k *Key) error {
+			k.Add("argv", "./a.out", "2")
+			return k.AddFile("input", filepath.Join(dir, "a.txt"))
+		},
+		"arguments joined": func(k *Key) error {
+			k.Add("argv", "./a.out1")
+			return k.AddFile("input", filepath.Join(dir, "a.txt"))
+		},
+		"missing file": func(k *Key) error {
+			k.Add("argv", "./a.out", "1")
+			return k.AddFile("input", filepath.Join(dir, "missing.txt"))
+		},
+	}
+	for name, build := range others {
+		if got := sum(build); got == base {
+			t.Errorf("%s: key did not change", name)
+		}
+	}
+
+	// Directories are keyed by the paths and content of their files
+	src := func() string {
+		return sum(func(k *Key) error { return k.AddFile("src", filepath.Join(dir, "src")) })
+	}
+	before := src()
+	writeFile(t, filepath.Join(dir, "src", "main.c"), "int main();")
+	if src() == before {
+		t.Error("Key did not change with a file's content")
+	}
+	before = src()
+	if err := os.Rename(filepath.Join(dir, "src", "main.c"), filepath.Join(dir, "src", "lib.c")); err != nil {
+		t.Fatal(err)
+	}
+	if src() == before {
+		t.Error("Key did not change with a file's name")
+	}
+}
+
+func TestStoreLoad(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := filepath.Join(dir, "cache")
+	key := NewKey().Sum()
+
+	result, err := Load(cacheDir, key, nil)
+	if err != nil || result != nil {
+		t.Fatalf("Load() of empty cache = %v, %v, want nil, nil", result, err)
+	}
+
+	writeFile(t, filepath.Join(dir, "out.txt"), "42\n")
+	stored := &output.Result{Command: "./a.out", Status: "success", ExitCode: 0, ExecutionTime: 12}
+	if err := Store(cacheDir, key, stored, map[string]string{"output": filepath.Join(dir, "out.txt")}); err != nil {
+		t.Fatalf("Store() error = %v", err)
+	}
+	// Storing the same key again keeps the first entry
+	if err := Store(cacheDir, key, stored, map[string]string{"output": filepath.Join(dir, "out.txt")}); err != nil {
+		t.Fatalf("Store() of an existing key error = %v", err)
+	}
+
+	restored := filepath.Join(dir, "other", "out.txt")
+	result, err = Load(cacheDir, key, map[string]string{"output": restored})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if result == nil || result.Command != "./a.out" || result.ExecutionTime != 12 {
+		t.Errorf("Load() = %+v, want the stored result", result)
+	}
+	if data, err := os.ReadFile(restored); err != nil || string(data) != "42\n" {
+		t.Errorf("Restored output = %q, %v, want %q", data, err, "42\n")
+	}
+
+	// An artifact the entry doesn't have is an error
+	if _, err := Load(cacheDir, key, map[string]string{"stderr": filepath.Join(dir, "err.txt")}); err == nil {
+		t.Error("Load() of a missing artifact succeeded")
+	}
+}
@@ -0,0 +1,199 @@
+package checksum
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Supported algorithms
+const (
+	SHA256 = "sha256"
+	SHA512 = "sha512"
+)
+
+// Verification statuses
+const (
+	StatusMatch    = "match"    // Checksum equals the expected value
+	StatusMismatch = "mismatch" // Checksum differs from the expected value
+	StatusMissing  = "missing"  // File could not be read
+	StatusComputed = "computed" // No expected value was given
+)
+
+// Entry is an expected checksum for a single file
+type Entry struct {
+	Path      string
+	Algorithm string
+	Expected  string // Lowercase hex digest, empty to only compute
+}
+
+// Result is the outcome of checking a single file
+type Result struct {
+	Path      string
+	Algorithm string
+	Checksum  string
+	Expected  string
+	Status    string
+	Error     string
+}
+
+// ValidateAlgorithm checks that the algorithm is supported
+func ValidateAlgorithm(algorithm string) error {
+	switch algorithm {
+	case SHA256, SHA512:
+		return nil
+	default:
+		return fmt.Errorf("unsupported checksum algorithm %q (supported: %s, %s)", algorithm, SHA256, SHA512)
+	}
+}
+
+func newHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case SHA256:
+		return sha256.New(), nil
+	case SHA512:
+		return sha512.New(), nil
+	default:
+		return nil, ValidateAlgorithm(algorithm)
+	}
+}
+
+// File computes the hex digest of a file
+func File(path, algorithm string) (string, error) {
+	h, err := newHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ParseExpected parses an expected checksum in "<hex>" or "<algorithm>:<hex>" form
+// The algorithm is taken from the prefix if present, otherwise defaultAlgorithm is
+// used; when that is empty the algorithm is inferred from the digest length.
+func ParseExpected(value, defaultAlgorithm string) (algorithm, digest string, err error) {
+	algorithm = defaultAlgorithm
+	digest = strings.ToLower(strings.TrimSpace(value))
+	if prefix, rest, ok := strings.Cut(digest, ":"); ok {
+		algorithm, digest = prefix, rest
+	} else if defaultAlgorithm == "" {
+		algorithm = algorithmForLength(len(digest))
+	}
+
+	if algorithm == "" {
+		return "", "", fmt.Errorf("invalid checksum %q", value)
+	}
+
+	if err := ValidateAlgorithm(algorithm); err != nil {
+		return "", "", err
+	}
+	if _, err := hex.DecodeString(digest); err != nil || len(digest) != expectedLength(algorithm) {
+		return "", "", fmt.Errorf("invalid %s checksum %q", algorithm, value)
+	}
+	return algorithm, digest, nil
+}
+
+func algorithmForLength(length int) string {
+	switch length {
+	case sha256.Size * 2:
+		return SHA256
+	case sha512.Size * 2:
+		return SHA512
+	default:
+		return ""
+	}
+}
+
+func expectedLength(algorithm string) int {
+	if algorithm == SHA512 {
+		return sha512.Size * 2
+	}
+	return sha256.Size * 2
+}
+
+// LoadManifest reads a manifest file in sha256sum/sha512sum format
+// Each line is "<digest>  <path>" (a '*' before the path marks binary mode and is
+// ignored); the digest may carry an "<algorithm>:" prefix. Relative paths are resolved
+// against the manifest's directory. Blank lines and lines starting with '#' are skipped.
+func LoadManifest(path, defaultAlgorithm string) ([]Entry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	baseDir := filepath.Dir(path)
+	var entries []Entry
+
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		digest, name, ok := strings.Cut(line, " ")
+		name = strings.TrimPrefix(strings.TrimLeft(name, " "), "*")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid manifest %s: line %d: expected \"<checksum>  <path>\"", path, lineNum)
+		}
+
+		algorithm, digest, err := ParseExpected(digest, defaultAlgorithm)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest %s: line %d: %w", path, lineNum, err)
+		}
+
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(baseDir, name)
+		}
+		entries = append(entries, Entry{Path: name, Algorithm: algorithm, Expected: digest})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Verify computes the checksum of each entry and compares it with the expected value
+func Verify(entries []Entry) []Result {
+	results := make([]Result, 0, len(entries))
+	for _, entry := range entries {
+		result := Result{Path: entry.Path, Algorithm: entry.Algorithm, Expected: entry.Expected}
+
+		sum, err := File(entry.Path, entry.Algorithm)
+		switch {
+		case err != nil:
+			result.Status = StatusMissing
+			result.Error = err.Error()
+		case entry.Expected == "":
+			result.Status = StatusComputed
+		case sum == entry.Expected:
+			result.Status = StatusMatch
+		default:
+			result.Status = StatusMismatch
+		}
+		result.Checksum = sum
+
+		results = append(results, result)
+	}
+	return results
+}
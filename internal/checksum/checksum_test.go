@@ -0,0 +1,137 @@
+package checksum
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Digests of the string "test"
+const (
+	testSHA256 = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	testSHA512 = "ee26b0dd4af7e749aa1a8ee3c10ae9923f618980772e473f8819a5d4940e0db27ac185f8a0e1d5f84f88bc887fd67b143732c304cc5fa9ad8e6f57f50028a8ff"
+)
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.txt")
+	if err := os.WriteFile(path, []byte("test"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		algorithm string
+		want      string
+		wantErr   bool
+	}{
+		{SHA256, testSHA256, false},
+		{SHA512, testSHA512, false},
+		{"md5", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			got, err := File(path, tt.algorithm)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("File() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("File() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExpected(t *testing.T) {
+	tests := []struct {
+		name             string
+		value            string
+		defaultAlgorithm string
+		wantAlgorithm    string
+		wantErr          bool
+	}{
+		{"inferred sha256", testSHA256, "", SHA256, false},
+		{"inferred sha512", testSHA512, "", SHA512, false},
+		{"uppercase hex", strings.ToUpper(testSHA256), "", SHA256, false},
+		{"prefixed", "sha512:" + testSHA512, SHA256, SHA512, false},
+		{"default algorithm length mismatch", testSHA512, SHA256, "", true},
+		{"unknown length", "abcd", "", "", true},
+		{"not hex", strings.Repeat("z", 64), "", "", true},
+		{"unsupported prefix", "md5:" + testSHA256, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			algorithm, digest, err := ParseExpected(tt.value, tt.defaultAlgorithm)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseExpected() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if algorithm != tt.wantAlgorithm {
+				t.Errorf("algorithm = %s, want %s", algorithm, tt.wantAlgorithm)
+			}
+			if digest != strings.ToLower(digest) {
+				t.Errorf("digest should be lowercase, got %s", digest)
+			}
+		})
+	}
+}
+
+func TestLoadManifestAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(dir, "good.txt"), []byte("test"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "bad.txt"), []byte("changed"), 0644)
+
+	manifest := filepath.Join(dir, "SHA256SUMS")
+	content := "# recorded checksums\n" +
+		testSHA256 + "  good.txt\n" +
+		testSHA256 + " *bad.txt\n" +
+		"sha512:" + testSHA512 + "  missing.txt\n"
+	if err := os.WriteFile(manifest, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := LoadManifest(manifest, "")
+	if err != nil {
+		t.Fatalf("LoadManifest() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("LoadManifest() returned %d entries, want 3", len(entries))
+	}
+	if entries[0].Path != filepath.Join(dir, "good.txt") {
+		t.Errorf("Path should be resolved against the manifest directory, got %s", entries[0].Path)
+	}
+
+	results := Verify(entries)
+	wantStatus := []string{StatusMatch, StatusMismatch, StatusMissing}
+	for i, result := range results {
+		if result.Status != wantStatus[i] {
+			t.Errorf("%s: status = %s, want %s", result.Path, result.Status, wantStatus[i])
+		}
+	}
+	if results[2].Algorithm != SHA512 || results[2].Error == "" {
+		t.Errorf("Missing file should keep its algorithm and report an error, got %+v", results[2])
+	}
+}
+
+func TestLoadManifestInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"missing path", testSHA256 + "\n"},
+		{"invalid checksum", "xyz  file.txt\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest := filepath.Join(t.TempDir(), "SUMS")
+			_ = os.WriteFile(manifest, []byte(tt.content), 0644)
+			if _, err := LoadManifest(manifest, ""); err == nil {
+				t.Error("Expected error for invalid manifest")
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package commandspec
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec describes the command to run, as an alternative to the arguments after '--'
+// Arguments are passed as-is, so they may contain '--' or anything a shell
+// would interpret.
+type Spec struct {
+	Command string            `yaml:"command"`
+	Args    []string          `yaml:"args"`
+	Env     map[string]string `yaml:"env"` // Added to ghost's environment
+	Input   string            `yaml:"input"`
+	Output  string            `yaml:"output"`
+	Stderr  string            `yaml:"stderr"`
+}
+
+// Load reads and validates a command spec file (JSON or YAML)
+func Load(path string) (*Spec, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read command file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	return Read(f, path)
+}
+
+// Read reads and validates a command spec; name is used in error messages
+func Read(r io.Reader, name string) (*Spec, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read command file: %w", err)
+	}
+
+	var spec Spec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse command file %s: %w", name, err)
+	}
+	if err := spec.validate(); err != nil {
+		return nil, fmt.Errorf("invalid command file %s: %w", name, err)
+	}
+	return &spec, nil
+}
+
+func (s *Spec) validate() error {
+	if s.Command == "" {
+		return fmt.Errorf("command is required")
+	}
+	for key := range s.Env {
+		if key == "" || strings.ContainsAny(key, "=\x00") {
+			return fmt.Errorf("invalid environment variable name %q", key)
+		}
+	}
+	return nil
+}
+
+// Argv returns the command followed by its arguments
+func (s *Spec) Argv() []string {
+	return append([]string{s.Command}, s.Args...)
+}
+
+// Environ returns the environment as sorted KEY=VALUE pairs (nil for a nil spec)
+func (s *Spec) Environ() []string {
+	if s == nil || len(s.Env) == 0 {
+		return nil
+	}
+	env := make([]string, 0, len(s.Env))
+	for key, value := range s.Env {
+		env = append(env, key+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// FillIO sets the I/O paths that are still empty from the spec
+// Paths given on the command line take precedence.
+func (s *Spec) FillIO(input, output, stderr *string) {
+	for _, p := range []struct {
+		dst *string
+		src string
+	}{{input, s.Input}, {output, s.Output}, {stderr, s.Stderr}} {
+		if *p.dst == "" {
+			*p.dst = p.src
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package commandspec
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantArgv []string
+		wantEnv  []string
+		wantErr  string
+	}{
+		{
+			name:     "json",
+			data:     `{"command": "python3", "args": ["main.py", "--", "-x"], "env": {"B": "2", "A": "1=1"}}`,
+			wantArgv: []string{"python3", "main.py", "--", "-x"},
+			wantEnv:  []string{"A=1=1", "B=2"},
+		},
+		{
+			name:     "yaml",
+			data:     "command: ./solution\nargs: [\"a b\", \"$HOME\"]\n",
+			wantArgv: []string{"./solution", "a b", "$HOME"},
+		},
+		{name: "missing command", data: `{"args": ["x"]}`, wantErr: "command is required"},
+		{name: "invalid env name", data: `{"command": "true", "env": {"A=B": "1"}}`, wantErr: `invalid environment variable name "A=B"`},
+		{name: "invalid syntax", data: `{"command": `, wantErr: "failed to parse command file spec.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := Read(strings.NewReader(tt.data), "spec.json")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Read() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if got := spec.Argv(); !reflect.DeepEqual(got, tt.wantArgv) {
+				t.Errorf("Argv() = %q, want %q", got, tt.wantArgv)
+			}
+			if got := spec.Environ(); !reflect.DeepEqual(got, tt.wantEnv) {
+				t.Errorf("Environ() = %q, want %q", got, tt.wantEnv)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spec.json")
+	if err := os.WriteFile(path, []byte(`{"command": "cat", "input": "in.txt", "output": "out.txt", "stderr": "err.txt"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	spec, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Paths already set are kept
+	input, output, stderr := "", "mine.txt", ""
+	spec.FillIO(&input, &output, &stderr)
+	if input != "in.txt" || output != "mine.txt" || stderr != "err.txt" {
+		t.Errorf("FillIO() = %q, %q, %q", input, output, stderr)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Load() of a missing file succeeded")
+	}
+}
@@ -0,0 +1,140 @@
+package compare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Comparison modes of ghost diff
+const (
+	ModeDiff    = "diff"    // line diff with the selected engine
+	ModeJSON    = "json"    // JSON documents equal regardless of formatting and key order
+	ModeNumeric = "numeric" // tokens equal, numbers within a tolerance
+	ModeRegex   = "regex"   // every expected line is a pattern the actual line must match
+	ModeCSV     = "csv"     // CSV cells equal, optionally in selected columns, any row order or within tolerances
+	ModeImage   = "image"   // images similar by pixels or perceptual hash
+)
+
+// DefaultTolerance is the numeric tolerance used unless one is given
+const DefaultTolerance = 1e-6
+
+// DefaultImageThreshold is the image similarity required unless one is given
+const DefaultImageThreshold = 1.0
+
+// Comparator decides whether an actual output matches the expected one
+// Differences are explained on w, in a human-readable form except for the diff
+// image of ModeImage. Only the diff comparator accepts directories.
+type Comparator interface {
+	Compare(ctx context.Context, w io.Writer, actual, expected string) (bool, error)
+}
+
+// Options configure the comparator of a mode
+type Options struct {
+	Engine    string       // ModeDiff: EngineExternal or EngineInternal
+	DiffFlags []string     // ModeDiff: flags of the engine
+	Tolerance float64      // ModeNumeric: absolute or relative tolerance
+	CSV       CSVOptions   // ModeCSV
+	Image     ImageOptions // ModeImage
+}
+
+// comparators builds the comparator of each mode from its options
+var comparators = map[string]func(Options) (Comparator, error){
+	ModeDiff:    newDiffComparator,
+	ModeJSON:    func(Options) (Comparator, error) { return jsonComparator{}, nil },
+	ModeNumeric: newNumericComparator,
+	ModeRegex:   func(Options) (Comparator, error) { return regexComparator{}, nil },
+	ModeCSV:     newCSVComparator,
+	ModeImage:   newImageComparator,
+}
+
+// Modes returns the names of the comparison modes in sorted order
+func Modes() []string {
+	modes := make([]string, 0, len(comparators))
+	for mode := range comparators {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+	return modes
+}
+
+// ValidateMode checks a comparison mode name
+func ValidateMode(mode string) error {
+	if _, ok := comparators[mode]; !ok {
+		return fmt.Errorf("invalid comparison mode %q (must be one of %s)", mode, strings.Join(Modes(), ", "))
+	}
+	return nil
+}
+
+// NewComparator returns the comparator of a mode
+func NewComparator(mode string, opts Options) (Comparator, error) {
+	if err := ValidateMode(mode); err != nil {
+		return nil, err
+	}
+	return comparators[mode](opts)
+}
+
+// Equal adapts a comparator to an EqualFunc, discarding the explanation
+func Equal(c Comparator) EqualFunc {
+	return func(ctx context.Context, a, b string) (bool, error) {
+		return c.Compare(ctx, io.Discard, a, b)
+	}
+}
+
+// diffComparator compares files or directories line by line
+type diffComparator struct {
+	engine string
+	args   []string    // external engine
+	opts   TextOptions // internal engine
+}
+
+func newDiffComparator(opts Options) (Comparator, error) {
+	c := &diffComparator{engine: opts.Engine, args: opts.DiffFlags}
+	switch opts.Engine {
+	case EngineExternal:
+	case EngineInternal:
+		textOptions, err := ParseTextOptions(opts.DiffFlags)
+		if err != nil {
+			return nil, err
+		}
+		c.opts = textOptions
+	default:
+		return nil, fmt.Errorf("invalid diff engine %q (must be %s or %s)", opts.Engine, EngineExternal, EngineInternal)
+	}
+	return c, nil
+}
+
+func (c *diffComparator) Compare(ctx context.Context, w io.Writer, actual, expected string) (bool, error) {
+	if c.engine == EngineExternal {
+		return externalDiff(ctx, w, actual, expected, c.args)
+	}
+	if info, err := os.Stat(actual); err == nil && info.IsDir() {
+		return DiffDirs(ctx, w, actual, expected, c.opts)
+	}
+	return DiffFiles(ctx, w, actual, expected, c.opts)
+}
+
+// externalDiff runs the diff binary with diffArgs, writing its output to w
+func externalDiff(ctx context.Context, w io.Writer, a, b string, diffArgs []string) (bool, error) {
+	args := append([]string{}, diffArgs...)
+	if w == io.Discard {
+		args = append([]string{"-q"}, args...)
+	}
+	cmd := exec.CommandContext(ctx, "diff", append(args, a, b)...)
+	cmd.Stdout = w
+	err := cmd.Run()
+	if err == nil {
+		return true, nil
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to compare %s and %s: %w", a, b, err)
+}
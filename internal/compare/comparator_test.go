@@ -0,0 +1,155 @@
+package compare
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestComparators(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		opts      Options
+		actual    string
+		expected  string
+		wantEqual bool
+		wantDiff  string // substring of the explanation
+		wantErr   string
+	}{
+		{name: "diff equal", mode: ModeDiff, opts: Options{Engine: EngineInternal}, actual: "a\n", expected: "a\n", wantEqual: true},
+		{name: "diff differs", mode: ModeDiff, opts: Options{Engine: EngineInternal}, actual: "a\n", expected: "b\n", wantDiff: "-a\n+b"},
+		{name: "diff flags", mode: ModeDiff, opts: Options{Engine: EngineInternal, DiffFlags: []string{"-w"}}, actual: "a b\n", expected: "a  b\n", wantEqual: true},
+
+		{name: "json formatting and key order", mode: ModeJSON, actual: `{"b": [1, 2.0], "a": "x"}`, expected: "{\n  \"a\": \"x\",\n  \"b\": [1, 2]\n}\n", wantEqual: true},
+		{name: "json value differs", mode: ModeJSON, actual: `{"a": {"b": 1}}`, expected: `{"a": {"b": 2}}`, wantDiff: "$.a.b: got 1, expected 2"},
+		{name: "json missing key", mode: ModeJSON, actual: `{}`, expected: `{"a": true}`, wantDiff: "$.a: missing, expected true"},
+		{name: "json extra key", mode: ModeJSON, actual: `{"a": null, "z": 1}`, expected: `{"a": null}`, wantDiff: "$.z: unexpected 1"},
+		{name: "json array length", mode: ModeJSON, actual: `[1]`, expected: `[1, 2]`, wantDiff: "$: got 1 elements, expected 2"},
+		{name: "json type differs", mode: ModeJSON, actual: `"1"`, expected: `1`, wantDiff: `$: got "1", expected 1`},
+		{name: "json invalid actual", mode: ModeJSON, actual: `{"a":`, expected: `{}`, wantDiff: "invalid JSON"},
+		{name: "json trailing data", mode: ModeJSON, actual: `{} {}`, expected: `{}`, wantDiff: "unexpected data"},
+		{name: "json invalid expected", mode: ModeJSON, actual: `{}`, expected: `{`, wantErr: "invalid expected JSON"},
+
+		{name: "numeric within tolerance", mode: ModeNumeric, opts: Options{Tolerance: 1e-3}, actual: "3.1416 ok\n2\n", expected: "3.1415  ok 2.0000\n", wantEqual: true},
+		{name: "numeric relative tolerance", mode: ModeNumeric, opts: Options{Tolerance: 1e-6}, actual: "1000000.5\n", expected: "1000000\n", wantEqual: true},
+		{name: "numeric outside tolerance", mode: ModeNumeric, opts: Options{Tolerance: 1e-6}, actual: "1\n3.15\n", expected: "1\n3.14\n", wantDiff: `token 2 (line 2): got "3.15", expected "3.14"`},
+		{name: "numeric words must be equal", mode: ModeNumeric, opts: Options{Tolerance: 1}, actual: "yes\n", expected: "YES\n", wantDiff: `got "yes", expected "YES"`},
+		{name: "numeric token count", mode: ModeNumeric, opts: Options{Tolerance: 1}, actual: "1 2\n", expected: "1\n", wantDiff: "got 2 tokens, expected 1"},
+		{name: "numeric negative tolerance", mode: ModeNumeric, opts: Options{Tolerance: -1}, wantErr: "tolerance"},
+
+		{name: "regex matches", mode: ModeRegex, actual: "took 12ms\nok\n", expected: `took \d+ms` + "\nok|done\n", wantEqual: true},
+		{name: "regex whole line", mode: ModeRegex, actual: "ok!\n", expected: "ok\n", wantDiff: `line 1: "ok!" does not match /ok/`},
+		{name: "regex line count", mode: ModeRegex, actual: "a\n", expected: "a\nb\n", wantDiff: "got 1 lines, expected 2"},
+		{name: "regex invalid pattern", mode: ModeRegex, actual: "a\n", expected: "(\n", wantErr: "invalid pattern on line 1"},
+
+		{name: "csv equal", mode: ModeCSV, actual: "a,b\n1,\"x, y\"\n", expected: "a,b\n1,\"x, y\"\n", wantEqual: true},
+		{name: "csv cell differs", mode: ModeCSV, actual: "a,b\n1,2\n", expected: "a,b\n1,3\n", wantDiff: `line 2, column 2: got "2", expected "3"`},
+		{name: "csv header named", mode: ModeCSV, opts: Options{CSV: CSVOptions{IgnoreHeader: true}}, actual: "A,B\n1,2\n", expected: "a,b\n1,3\n", wantDiff: `line 2, column "b": got "2", expected "3"`},
+		{name: "csv header ignored", mode: ModeCSV, opts: Options{CSV: CSVOptions{IgnoreHeader: true}}, actual: "x,y\n1,2\n", expected: "a,b\n1,2\n", wantEqual: true},
+		{name: "csv column subset", mode: ModeCSV, opts: Options{CSV: CSVOptions{Columns: []string{"id", "3"}}}, actual: "id,t,n\n7,0.12s,x\n", expected: "id,t,n\n7,0.09s,x\n", wantEqual: true},
+		{name: "csv column missing", mode: ModeCSV, opts: Options{CSV: CSVOptions{Columns: []string{"2"}}}, actual: "1\n", expected: "1,2\n", wantDiff: `line 1, column 2: missing, expected "2"`},
+		{name: "csv column count", mode: ModeCSV, actual: "1,2,3\n", expected: "1,2\n", wantDiff: "line 1: got 3 columns, expected 2"},
+		{name: "csv row count", mode: ModeCSV, actual: "1\n", expected: "1\n2\n", wantDiff: "got 1 rows, expected 2"},
+		{name: "csv column tolerance", mode: ModeCSV, opts: Options{CSV: CSVOptions{IgnoreHeader: true, Tolerances: map[string]float64{"mean": 0.01}}}, actual: "k,mean\na,1.004\n", expected: "k,mean\na,1\n", wantEqual: true},
+		{name: "csv tolerance only in its column", mode: ModeCSV, opts: Options{CSV: CSVOptions{Tolerances: map[string]float64{"2": 0.01}}}, actual: "1.004,1.004\n", expected: "1,1\n", wantDiff: `column 1: got "1.004", expected "1"`},
+		{name: "csv default tolerance", mode: ModeCSV, opts: Options{CSV: CSVOptions{Tolerances: map[string]float64{"": 0.01}}}, actual: "1.004,2\n", expected: "1,2.0\n", wantEqual: true},
+		{name: "csv unordered", mode: ModeCSV, opts: Options{CSV: CSVOptions{Unordered: true, Tolerances: map[string]float64{"": 0.1}}}, actual: "b,2.05\na,1\na,1\n", expected: "a,1\nb,2\na,1\n", wantEqual: true},
+		{name: "csv unordered missing and extra", mode: ModeCSV, opts: Options{CSV: CSVOptions{Unordered: true}}, actual: "a,1\nc,3\n", expected: "b,2\na,1\n", wantDiff: "missing row (line 1 of expected): b,2\nunexpected row (line 2): c,3\n"},
+		{name: "csv invalid actual", mode: ModeCSV, actual: "\"a\n", expected: "a\n", wantDiff: "invalid CSV"},
+		{name: "csv unknown column", mode: ModeCSV, opts: Options{CSV: CSVOptions{Columns: []string{"z"}}}, actual: "a\n", expected: "a\n", wantErr: `column "z" not found`},
+		{name: "csv negative tolerance", mode: ModeCSV, opts: Options{CSV: CSVOptions{Tolerances: map[string]float64{"a": -1}}}, wantErr: "tolerance"},
+
+		{name: "unknown mode", mode: "audio", wantErr: `invalid comparison mode "audio" (must be one of csv, diff, image, json, numeric, regex)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			actual := filepath.Join(dir, "actual")
+			expected := filepath.Join(dir, "expected")
+			_ = os.WriteFile(actual, []byte(tt.actual), 0644)
+			_ = os.WriteFile(expected, []byte(tt.expected), 0644)
+
+			comparator, err := NewComparator(tt.mode, tt.opts)
+			var equal bool
+			var buf bytes.Buffer
+			if err == nil {
+				equal, err = comparator.Compare(context.Background(), &buf, actual, expected)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Compare() error = %v", err)
+			}
+			if equal != tt.wantEqual {
+				t.Errorf("Compare() = %v, want %v\n%s", equal, tt.wantEqual, buf.String())
+			}
+			if tt.wantEqual && buf.Len() > 0 {
+				t.Errorf("explanation of equal files = %q", buf.String())
+			}
+			if !strings.Contains(buf.String(), tt.wantDiff) {
+				t.Errorf("explanation = %q, want %q", buf.String(), tt.wantDiff)
+			}
+		})
+	}
+}
+
+func TestEqualDiscardsExplanation(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a"), filepath.Join(dir, "b")
+	_ = os.WriteFile(a, []byte("1.0\n"), 0644)
+	_ = os.WriteFile(b, []byte("1\n"), 0644)
+
+	comparator, err := NewComparator(ModeNumeric, Options{Tolerance: DefaultTolerance})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if equal, err := Equal(comparator)(context.Background(), a, b); !equal || err != nil {
+		t.Errorf("Equal() = %v, %v", equal, err)
+	}
+}
+
+func TestWriteDifferencesCap(t *testing.T) {
+	var diffs []string
+	for i := 0; i < maxReportedDifferences+5; i++ {
+		diffs = append(diffs, "difference")
+	}
+	var buf bytes.Buffer
+	writeDifferences(&buf, diffs)
+	if lines := strings.Count(buf.String(), "\n"); lines != maxReportedDifferences+1 {
+		t.Errorf("got %d lines, want %d", lines, maxReportedDifferences+1)
+	}
+	if !strings.HasSuffix(buf.String(), "... and 5 more differences\n") {
+		t.Errorf("explanation = %q", buf.String())
+	}
+}
+
+func TestParseCSVTolerances(t *testing.T) {
+	tolerances, err := ParseCSVTolerances([]string{"0.5", "price=0.01", "3 = 1e-3"})
+	if err != nil {
+		t.Fatalf("ParseCSVTolerances() error = %v", err)
+	}
+	want := map[string]float64{"": 0.5, "price": 0.01, "3": 1e-3}
+	if len(tolerances) != len(want) {
+		t.Fatalf("tolerances = %v, want %v", tolerances, want)
+	}
+	for column, tolerance := range want {
+		if tolerances[column] != tolerance {
+			t.Errorf("tolerance of %q = %v, want %v", column, tolerances[column], tolerance)
+		}
+	}
+
+	for _, value := range []string{"=0.1", "price=", "price=-1", "price=NaN", "x"} {
+		if _, err := ParseCSVTolerances([]string{value}); err == nil {
+			t.Errorf("ParseCSVTolerances(%q) succeeded, want an error", value)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package compare
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CSVOptions configure the csv comparison mode
+type CSVOptions struct {
+	Columns      []string           // columns to compare, by header name or 1-based index; all if empty
+	IgnoreHeader bool               // the first row of both files is a header and is not compared
+	Unordered    bool               // rows may appear in any order
+	Tolerances   map[string]float64 // numeric tolerance by column name or 1-based index; "" applies to all columns
+}
+
+// ParseCSVTolerances parses tolerances given as "column=tolerance", or just
+// "tolerance" for all columns
+func ParseCSVTolerances(values []string) (map[string]float64, error) {
+	tolerances := make(map[string]float64, len(values))
+	for _, value := range values {
+		column, number := "", value
+		if i := strings.LastIndex(value, "="); i >= 0 {
+			column, number = strings.TrimSpace(value[:i]), value[i+1:]
+			if column == "" {
+				return nil, fmt.Errorf("invalid CSV tolerance %q: missing column", value)
+			}
+		}
+		tolerance, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil || tolerance < 0 || math.IsNaN(tolerance) || math.IsInf(tolerance, 0) {
+			return nil, fmt.Errorf("invalid CSV tolerance %q: must be a finite number that is not negative", value)
+		}
+		tolerances[column] = tolerance
+	}
+	return tolerances, nil
+}
+
+// csvComparator compares CSV files cell by cell
+// Cells are compared as text unless their column has a tolerance, in which case
+// numbers within it are equal. Actual output that is not valid CSV differs; an
+// invalid expected file is an error.
+type csvComparator struct {
+	opts CSVOptions
+}
+
+func newCSVComparator(opts Options) (Comparator, error) {
+	for column, tolerance := range opts.CSV.Tolerances {
+		if tolerance < 0 || math.IsNaN(tolerance) || math.IsInf(tolerance, 0) {
+			return nil, fmt.Errorf("tolerance of column %q must be a finite number that is not negative", column)
+		}
+	}
+	return csvComparator{opts: opts.CSV}, nil
+}
+
+// csvRow is a record and the line it starts on
+type csvRow struct {
+	cells []string
+	line  int
+}
+
+// csvColumns are the resolved columns of a comparison
+type csvColumns struct {
+	selected   []int          // 0-based indexes; nil compares every column
+	labels     map[int]string // header names for reports
+	tolerances map[int]float64
+	fallback   float64 // tolerance of columns without their own; -1 compares them as text
+}
+
+func (c csvComparator) Compare(ctx context.Context, w io.Writer, actual, expected string) (bool, error) {
+	want, err := readCSV(expected)
+	if err != nil {
+		return false, fmt.Errorf("invalid expected CSV %s: %w", expected, err)
+	}
+	got, err := readCSV(actual)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, err
+		}
+		_, _ = fmt.Fprintf(w, "%s: invalid CSV: %v\n", actual, err)
+		return false, nil
+	}
+
+	var header []string
+	if len(want) > 0 {
+		header = want[0].cells
+	}
+	columns, err := c.resolveColumns(header)
+	if err != nil {
+		return false, fmt.Errorf("%w in the header of %s", err, expected)
+	}
+	if c.opts.IgnoreHeader {
+		want, got = dropFirst(want), dropFirst(got)
+	}
+
+	var diffs []string
+	if c.opts.Unordered {
+		diffs, err = unorderedCSVDiff(ctx, got, want, columns)
+	} else {
+		diffs, err = orderedCSVDiff(ctx, got, want, columns)
+	}
+	if err != nil {
+		return false, err
+	}
+	writeDifferences(w, diffs)
+	return len(diffs) == 0, nil
+}
+
+// resolveColumns looks up the selected columns and tolerances, naming columns
+// by the expected header
+func (c csvComparator) resolveColumns(header []string) (*csvColumns, error) {
+	columns := &csvColumns{labels: map[int]string{}, tolerances: map[int]float64{}, fallback: -1}
+	if c.opts.IgnoreHeader {
+		for i, name := range header {
+			columns.labels[i] = name
+		}
+	}
+
+	for _, column := range c.opts.Columns {
+		index, err := columnIndex(column, header)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(columns.selected, index) {
+			columns.selected = append(columns.selected, index)
+		}
+	}
+	for column, tolerance := range c.opts.Tolerances {
+		if column == "" {
+			columns.fallback = tolerance
+			continue
+		}
+		index, err := columnIndex(column, header)
+		if err != nil {
+			return nil, err
+		}
+		columns.tolerances[index] = tolerance
+	}
+	return columns, nil
+}
+
+// columnIndex resolves a 1-based column number or a header name
+func columnIndex(column string, header []string) (int, error) {
+	if n, err := strconv.Atoi(column); err == nil {
+		if n < 1 {
+			return 0, fmt.Errorf("invalid column %d (columns are numbered from 1)", n)
+		}
+		return n - 1, nil
+	}
+	if i := slices.Index(header, column); i >= 0 {
+		return i, nil
+	}
+	return 0, fmt.Errorf("column %q not found", column)
+}
+
+// label names a column in a difference
+func (c *csvColumns) label(index int) string {
+	if name := c.labels[index]; name != "" {
+		return strconv.Quote(name)
+	}
+	return strconv.Itoa(index + 1)
+}
+
+// cellsEqual compares two cells of a column
+func (c *csvColumns) cellsEqual(index int, got, want string) bool {
+	tolerance, ok := c.tolerances[index]
+	if !ok {
+		tolerance = c.fallback
+	}
+	if tolerance < 0 {
+		return got == want
+	}
+	return numbersWithin(strings.TrimSpace(got), strings.TrimSpace(want), tolerance)
+}
+
+// rowDiff lists the differences between two rows
+func (c *csvColumns) rowDiff(got, want csvRow) []string {
+	var diffs []string
+	indexes := c.selected
+	if indexes == nil {
+		if len(got.cells) != len(want.cells) {
+			diffs = append(diffs, fmt.Sprintf("line %d: got %d columns, expected %d", got.line, len(got.cells), len(want.cells)))
+		}
+		for i := 0; i < len(got.cells) && i < len(want.cells); i++ {
+			indexes = append(indexes, i)
+		}
+	}
+
+	for _, i := range indexes {
+		switch {
+		case i >= len(want.cells):
+			// Short expected rows only constrain the columns they have
+		case i >= len(got.cells):
+			diffs = append(diffs, fmt.Sprintf("line %d, column %s: missing, expected %q", got.line, c.label(i), want.cells[i]))
+		case !c.cellsEqual(i, got.cells[i], want.cells[i]):
+			diffs = append(diffs, fmt.Sprintf("line %d, column %s: got %q, expected %q", got.line, c.label(i), got.cells[i], want.cells[i]))
+		}
+	}
+	return diffs
+}
+
+// key is the compared cells of a row, for matching rows exactly
+func (c *csvColumns) key(row csvRow) string {
+	if c.selected == nil {
+		return strings.Join(row.cells, "\x00")
+	}
+	cells := make([]string, len(c.selected))
+	for i, index := range c.selected {
+		if index < len(row.cells) {
+			cells[i] = row.cells[index]
+		}
+	}
+	return strings.Join(cells, "\x00")
+}
+
+// orderedCSVDiff compares the rows in order
+func orderedCSVDiff(ctx context.Context, got, want []csvRow, columns *csvColumns) ([]string, error) {
+	var diffs []string
+	for i := 0; i < len(want) && i < len(got); i++ {
+		if i%4096 == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		diffs = append(diffs, columns.rowDiff(got[i], want[i])...)
+	}
+	if len(got) != len(want) {
+		diffs = append(diffs, fmt.Sprintf("got %d rows, expected %d", len(got), len(want)))
+	}
+	return diffs, nil
+}
+
+// unorderedCSVDiff matches every expected row with a distinct actual row
+// Identical rows are paired first; the rest are compared pairwise so numbers
+// within a tolerance still match.
+func unorderedCSVDiff(ctx context.Context, got, want []csvRow, columns *csvColumns) ([]string, error) {
+	byKey := make(map[string][]int, len(got))
+	for i, row := range got {
+		key := columns.key(row)
+		byKey[key] = append(byKey[key], i)
+	}
+	used := make([]bool, len(got))
+	var unmatched []csvRow
+	for _, row := range want {
+		key := columns.key(row)
+		if candidates := byKey[key]; len(candidates) > 0 {
+			used[candidates[0]] = true
+			byKey[key] = candidates[1:]
+			continue
+		}
+		unmatched = append(unmatched, row)
+	}
+
+	var diffs []string
+	for n, row := range unmatched {
+		if n%64 == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		match := -1
+		for i, candidate := range got {
+			if !used[i] && len(columns.rowDiff(candidate, row)) == 0 {
+				match = i
+				break
+			}
+		}
+		if match >= 0 {
+			used[match] = true
+			continue
+		}
+		diffs = append(diffs, fmt.Sprintf("missing row (line %d of expected): %s", row.line, abbreviate(strings.Join(row.cells, ","))))
+	}
+	for i, row := range got {
+		if !used[i] {
+			diffs = append(diffs, fmt.Sprintf("unexpected row (line %d): %s", row.line, abbreviate(strings.Join(row.cells, ","))))
+		}
+	}
+	return diffs, nil
+}
+
+// readCSV reads the records of a CSV file, which may have rows of any length
+func readCSV(path string) ([]csvRow, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	var rows []csvRow
+	for {
+		cells, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, csvRow{cells: cells, line: line})
+	}
+}
+
+// dropFirst removes the header row
+func dropFirst(rows []csvRow) []csvRow {
+	if len(rows) == 0 {
+		return rows
+	}
+	return rows[1:]
+}
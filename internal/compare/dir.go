@@ -0,0 +1,157 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+)
+
+// File comparison statuses
+const (
+	StatusMatch   = "match"   // Present in both directories with equal contents
+	StatusDiffer  = "differ"  // Present in both directories with different contents
+	StatusMissing = "missing" // Present only in the expected directory
+	StatusExtra   = "extra"   // Present only in the input directory
+)
+
+// FileResult records the comparison outcome of a single file
+type FileResult struct {
+	Path   string // Path relative to the compared directories, using forward slashes
+	Status string
+}
+
+// DirResult holds the outcome of comparing two directories
+type DirResult struct {
+	Files   []FileResult
+	Matched int
+}
+
+// Total returns the number of files compared
+func (r *DirResult) Total() int {
+	return len(r.Files)
+}
+
+// EqualFunc reports whether two files are considered equal
+type EqualFunc func(ctx context.Context, a, b string) (bool, error)
+
+// ExternalEqual compares files with `diff -q` using diffArgs, so flags such as
+// --ignore-all-space apply per file
+func ExternalEqual(diffArgs []string) EqualFunc {
+	return Equal(&diffComparator{engine: EngineExternal, args: diffArgs})
+}
+
+// InternalEqual compares files with the internal engine
+func InternalEqual(opts TextOptions) EqualFunc {
+	return func(ctx context.Context, a, b string) (bool, error) {
+		return DiffFiles(ctx, io.Discard, a, b, opts)
+	}
+}
+
+// Dirs compares every file under inputDir with its counterpart under expectedDir
+// Files present in both trees are compared with equal. Results are sorted by path.
+func Dirs(ctx context.Context, inputDir, expectedDir string, equal EqualFunc) (*DirResult, error) {
+	inputFiles, err := listFiles(inputDir)
+	if err != nil {
+		return nil, err
+	}
+	expectedFiles, err := listFiles(expectedDir)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]struct{}, len(inputFiles)+len(expectedFiles))
+	for path := range inputFiles {
+		paths[path] = struct{}{}
+	}
+	for path := range expectedFiles {
+		paths[path] = struct{}{}
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	result := &DirResult{Files: make([]FileResult, 0, len(sorted))}
+	for _, path := range sorted {
+		_, inInput := inputFiles[path]
+		_, inExpected := expectedFiles[path]
+
+		status := StatusMatch
+		switch {
+		case !inInput:
+			status = StatusMissing
+		case !inExpected:
+			status = StatusExtra
+		default:
+			same, err := equal(ctx, filepath.Join(inputDir, path), filepath.Join(expectedDir, path))
+			if err != nil {
+				return nil, err
+			}
+			if !same {
+				status = StatusDiffer
+			}
+		}
+
+		if status == StatusMatch {
+			result.Matched++
+		}
+		result.Files = append(result.Files, FileResult{Path: filepath.ToSlash(path), Status: status})
+	}
+
+	return result, nil
+}
+
+// DiffDirs writes a unified diff of two directory trees to w using the internal engine
+// Files present in only one tree are reported as "Only in <dir>: <path>" like diff -r.
+// Reports whether the trees are equal.
+func DiffDirs(ctx context.Context, w io.Writer, inputDir, expectedDir string, opts TextOptions) (bool, error) {
+	result, err := Dirs(ctx, inputDir, expectedDir, func(ctx context.Context, a, b string) (bool, error) {
+		return DiffFiles(ctx, w, a, b, opts)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	for _, file := range result.Files {
+		var dir string
+		switch file.Status {
+		case StatusExtra:
+			dir = inputDir
+		case StatusMissing:
+			dir = expectedDir
+		default:
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "Only in %s: %s\n", dir, file.Path); err != nil {
+			return false, err
+		}
+	}
+	return result.Matched == result.Total(), nil
+}
+
+// listFiles returns the set of non-directory entries under root, relative to root
+func listFiles(root string) (map[string]struct{}, error) {
+	files := make(map[string]struct{})
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files[rel] = struct{}{}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list directory %s: %w", root, err)
+	}
+	return files, nil
+}
@@ -0,0 +1,131 @@
+package compare
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+}
+
+func TestDirs(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       map[string]string
+		expected    map[string]string
+		diffArgs    []string
+		wantFiles   []FileResult
+		wantMatched int
+	}{
+		{
+			name:        "identical trees",
+			input:       map[string]string{"a.txt": "a\n", "sub/b.txt": "b\n"},
+			expected:    map[string]string{"a.txt": "a\n", "sub/b.txt": "b\n"},
+			wantFiles:   []FileResult{{"a.txt", StatusMatch}, {"sub/b.txt", StatusMatch}},
+			wantMatched: 2,
+		},
+		{
+			name:     "differing, missing and extra files",
+			input:    map[string]string{"a.txt": "a\n", "b.txt": "wrong\n", "extra.txt": "x\n"},
+			expected: map[string]string{"a.txt": "a\n", "b.txt": "b\n", "sub/c.txt": "c\n"},
+			wantFiles: []FileResult{
+				{"a.txt", StatusMatch},
+				{"b.txt", StatusDiffer},
+				{"extra.txt", StatusExtra},
+				{"sub/c.txt", StatusMissing},
+			},
+			wantMatched: 1,
+		},
+		{
+			name:        "diff args apply per file",
+			input:       map[string]string{"a.txt": "a  b\n"},
+			expected:    map[string]string{"a.txt": "a b\n"},
+			diffArgs:    []string{"-w"},
+			wantFiles:   []FileResult{{"a.txt", StatusMatch}},
+			wantMatched: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputDir := t.TempDir()
+			expectedDir := t.TempDir()
+			writeTree(t, inputDir, tt.input)
+			writeTree(t, expectedDir, tt.expected)
+
+			opts, err := ParseTextOptions(tt.diffArgs)
+			if err != nil {
+				t.Fatalf("ParseTextOptions() error = %v", err)
+			}
+
+			// Both engines must agree on per-file status
+			engines := map[string]EqualFunc{
+				"external": ExternalEqual(tt.diffArgs),
+				"internal": InternalEqual(opts),
+			}
+			for engine, equal := range engines {
+				result, err := Dirs(context.Background(), inputDir, expectedDir, equal)
+				if err != nil {
+					t.Fatalf("%s: Dirs() error = %v", engine, err)
+				}
+
+				if !reflect.DeepEqual(result.Files, tt.wantFiles) {
+					t.Errorf("%s: Files = %v, want %v", engine, result.Files, tt.wantFiles)
+				}
+				if result.Matched != tt.wantMatched {
+					t.Errorf("%s: Matched = %d, want %d", engine, result.Matched, tt.wantMatched)
+				}
+				if result.Total() != len(tt.wantFiles) {
+					t.Errorf("%s: Total() = %d, want %d", engine, result.Total(), len(tt.wantFiles))
+				}
+			}
+		})
+	}
+}
+
+func TestDirsMissingDirectory(t *testing.T) {
+	_, err := Dirs(context.Background(), filepath.Join(t.TempDir(), "missing"), t.TempDir(), ExternalEqual(nil))
+	if err == nil {
+		t.Fatal("Expected error for missing directory")
+	}
+}
+
+func TestDiffDirs(t *testing.T) {
+	inputDir := t.TempDir()
+	expectedDir := t.TempDir()
+	writeTree(t, inputDir, map[string]string{"a.txt": "a\n", "b.txt": "wrong\n", "extra.txt": "x\n"})
+	writeTree(t, expectedDir, map[string]string{"a.txt": "a\n", "b.txt": "b\n", "sub/c.txt": "c\n"})
+
+	var out strings.Builder
+	equal, err := DiffDirs(context.Background(), &out, inputDir, expectedDir, TextOptions{Context: DefaultContextLines})
+	if err != nil {
+		t.Fatalf("DiffDirs() error = %v", err)
+	}
+	if equal {
+		t.Error("Expected trees to differ")
+	}
+
+	for _, want := range []string{
+		"-wrong\n+b\n",
+		"Only in " + inputDir + ": extra.txt\n",
+		"Only in " + expectedDir + ": sub/c.txt\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Output missing %q:\n%s", want, out.String())
+		}
+	}
+}
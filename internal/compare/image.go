@@ -0,0 +1,220 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"math/bits"
+	"os"
+	"sort"
+
+	// Decoders of the supported image formats
+	_ "image/gif"
+	_ "image/jpeg"
+)
+
+// Image comparison methods
+const (
+	ImageMethodPixel = "pixel" // share of identical pixels
+	ImageMethodPHash = "phash" // similarity of perceptual hashes
+)
+
+// ImageOptions configure the image comparison mode
+type ImageOptions struct {
+	Method    string  // ImageMethodPixel or ImageMethodPHash
+	Threshold float64 // minimum similarity from 0 to 1
+}
+
+// imageComparator compares two PNG, JPEG or GIF images
+// The images match if their similarity reaches the threshold. The output is a
+// PNG of the expected image, faded, with the pixels that differ in red. Actual
+// output that is not an image differs; an invalid expected image is an error.
+type imageComparator struct {
+	opts ImageOptions
+}
+
+func newImageComparator(opts Options) (Comparator, error) {
+	switch opts.Image.Method {
+	case ImageMethodPixel, ImageMethodPHash:
+	default:
+		return nil, fmt.Errorf("invalid image method %q (must be %s or %s)", opts.Image.Method, ImageMethodPixel, ImageMethodPHash)
+	}
+	if opts.Image.Threshold < 0 || opts.Image.Threshold > 1 || math.IsNaN(opts.Image.Threshold) {
+		return nil, fmt.Errorf("image threshold must be between 0 and 1")
+	}
+	return imageComparator{opts: opts.Image}, nil
+}
+
+func (c imageComparator) Compare(ctx context.Context, w io.Writer, actual, expected string) (bool, error) {
+	want, err := readImage(expected)
+	if err != nil {
+		return false, fmt.Errorf("invalid expected image %s: %w", expected, err)
+	}
+	got, err := readImage(actual)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, err
+		}
+		_, _ = fmt.Fprintf(w, "%s: invalid image: %v\n", actual, err)
+		return false, nil
+	}
+	if ctx.Err() != nil {
+		return false, ctx.Err()
+	}
+
+	diff, pixelSimilarity := pixelDiff(got, want)
+	similarity := pixelSimilarity
+	if c.opts.Method == ImageMethodPHash {
+		similarity = 1 - float64(bits.OnesCount64(perceptualHash(got)^perceptualHash(want)))/64
+	}
+
+	if w != io.Discard {
+		if err := png.Encode(w, diff); err != nil {
+			return false, fmt.Errorf("failed to write diff image: %w", err)
+		}
+	}
+	return similarity >= c.opts.Threshold, nil
+}
+
+// readImage decodes an image file in any registered format
+func readImage(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	img, _, err := image.Decode(file)
+	return img, err
+}
+
+// pixelDiff draws the differences between two images and returns the share of
+// identical pixels
+// Images of different sizes are compared on the larger canvas, where pixels
+// outside either image differ.
+func pixelDiff(got, want image.Image) (*image.NRGBA, float64) {
+	gb, wb := got.Bounds(), want.Bounds()
+	width := max(gb.Dx(), wb.Dx())
+	height := max(gb.Dy(), wb.Dy())
+	diff := image.NewNRGBA(image.Rect(0, 0, width, height))
+	if width == 0 || height == 0 {
+		return diff, 1
+	}
+
+	same := 0
+	red := color.NRGBA{R: 255, A: 255}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			inGot := x < gb.Dx() && y < gb.Dy()
+			inWant := x < wb.Dx() && y < wb.Dy()
+			var g, v color.NRGBA
+			if inGot {
+				g = color.NRGBAModel.Convert(got.At(gb.Min.X+x, gb.Min.Y+y)).(color.NRGBA)
+			}
+			if inWant {
+				v = color.NRGBAModel.Convert(want.At(wb.Min.X+x, wb.Min.Y+y)).(color.NRGBA)
+			}
+			if inGot && inWant && g == v {
+				same++
+				// Faded grey keeps the picture recognisable behind the differences
+				grey := uint8(191 + int(luminance(v))/4)
+				diff.SetNRGBA(x, y, color.NRGBA{R: grey, G: grey, B: grey, A: 255})
+				continue
+			}
+			diff.SetNRGBA(x, y, red)
+		}
+	}
+	return diff, float64(same) / float64(width*height)
+}
+
+// resampleWeight is the share of a target pixel covered by a source pixel
+type resampleWeight struct {
+	target int
+	weight float64
+}
+
+// resampleWeights maps each of n source pixels to the targets it covers when
+// scaled to size pixels
+func resampleWeights(n, size int) [][]resampleWeight {
+	weights := make([][]resampleWeight, n)
+	scale := float64(size) / float64(n)
+	for i := range weights {
+		// Source pixel i spans [start, end) in target coordinates
+		start, end := float64(i)*scale, float64(i+1)*scale
+		for target := int(start); target < size && float64(target) < end; target++ {
+			overlap := math.Min(end, float64(target+1)) - math.Max(start, float64(target))
+			if overlap > 0 {
+				// Normalised by the source pixels per target pixel
+				weights[i] = append(weights[i], resampleWeight{target: target, weight: overlap / scale})
+			}
+		}
+	}
+	return weights
+}
+
+// luminance returns the brightness of a colour from 0 to 255
+func luminance(c color.NRGBA) float64 {
+	return 0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B)
+}
+
+// perceptualHash computes the 64-bit DCT hash of an image
+// The image is scaled to 32x32 grey pixels; each bit tells whether one of the
+// lowest 8x8 frequencies is above their median.
+func perceptualHash(img image.Image) uint64 {
+	const size, low = 32, 8
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return 0
+	}
+
+	// Average the image down to size x size, weighting source pixels by the
+	// area they cover
+	columns, rows := resampleWeights(bounds.Dx(), size), resampleWeights(bounds.Dy(), size)
+	var pixels [size][size]float64
+	for sy := 0; sy < bounds.Dy(); sy++ {
+		for sx := 0; sx < bounds.Dx(); sx++ {
+			grey := luminance(color.NRGBAModel.Convert(img.At(bounds.Min.X+sx, bounds.Min.Y+sy)).(color.NRGBA))
+			for _, row := range rows[sy] {
+				for _, column := range columns[sx] {
+					pixels[row.target][column.target] += grey * row.weight * column.weight
+				}
+			}
+		}
+	}
+
+	// Two-dimensional DCT-II of the lowest frequencies
+	var cosines [low][size]float64
+	for u := 0; u < low; u++ {
+		for x := 0; x < size; x++ {
+			cosines[u][x] = math.Cos(float64(2*x+1) * float64(u) * math.Pi / (2 * size))
+		}
+	}
+	coefficients := make([]float64, 0, low*low)
+	for v := 0; v < low; v++ {
+		for u := 0; u < low; u++ {
+			var sum float64
+			for y := 0; y < size; y++ {
+				for x := 0; x < size; x++ {
+					sum += pixels[y][x] * cosines[u][x] * cosines[v][y]
+				}
+			}
+			coefficients = append(coefficients, sum)
+		}
+	}
+
+	// The DC term only reflects the overall brightness
+	sorted := append([]float64{}, coefficients[1:]...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+	var hash uint64
+	for i, coefficient := range coefficients {
+		if coefficient > median {
+			hash |= 1 << i
+		}
+	}
+	return hash
+}
@@ -0,0 +1,144 @@
+package compare
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestImage writes a width x height PNG of a checkerboard, gradient and
+// disc scaled to the size, with the given pixels painted black
+func writeTestImage(t *testing.T, path string, width, height int, black ...image.Point) {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBA{G: uint8(y * 255 / height), A: 255}
+			if (x*4/width+y*4/height)%2 == 0 {
+				c.R = 255
+			}
+			dx, dy := float64(x)/float64(width)-0.6, float64(y)/float64(height)-0.4
+			if dx*dx+dy*dy < 0.09 {
+				c.B = 255
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	for _, p := range black {
+		img.SetNRGBA(p.X, p.Y, color.NRGBA{A: 255})
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = file.Close() }()
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestImageComparator(t *testing.T) {
+	dir := t.TempDir()
+	expected := filepath.Join(dir, "expected.png")
+	writeTestImage(t, expected, 40, 40)
+	same := filepath.Join(dir, "same.png")
+	writeTestImage(t, same, 40, 40)
+	twoPixels := filepath.Join(dir, "two-pixels.png")
+	writeTestImage(t, twoPixels, 40, 40, image.Pt(1, 1), image.Pt(25, 5))
+	scaled := filepath.Join(dir, "scaled.png")
+	writeTestImage(t, scaled, 80, 80)
+	text := filepath.Join(dir, "text.png")
+	_ = os.WriteFile(text, []byte("not an image"), 0644)
+
+	tests := []struct {
+		name      string
+		method    string
+		threshold float64
+		actual    string
+		wantEqual bool
+		wantRed   int // differing pixels drawn in the diff image, -1 to skip
+	}{
+		{name: "identical", method: ImageMethodPixel, threshold: 1, actual: same, wantEqual: true},
+		{name: "two pixels differ", method: ImageMethodPixel, threshold: 1, actual: twoPixels, wantRed: 2},
+		{name: "within threshold", method: ImageMethodPixel, threshold: 0.998, actual: twoPixels, wantEqual: true, wantRed: 2},
+		{name: "different size", method: ImageMethodPixel, threshold: 0.5, actual: scaled, wantRed: -1},
+		{name: "phash ignores scale", method: ImageMethodPHash, threshold: 0.9, actual: scaled, wantEqual: true, wantRed: -1},
+		{name: "phash tolerates small changes", method: ImageMethodPHash, threshold: 0.9, actual: twoPixels, wantEqual: true, wantRed: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comparator, err := NewComparator(ModeImage, Options{Image: ImageOptions{Method: tt.method, Threshold: tt.threshold}})
+			if err != nil {
+				t.Fatalf("NewComparator() error = %v", err)
+			}
+			var out bytes.Buffer
+			equal, err := comparator.Compare(context.Background(), &out, tt.actual, expected)
+			if err != nil {
+				t.Fatalf("Compare() error = %v", err)
+			}
+			if equal != tt.wantEqual {
+				t.Errorf("Compare() = %v, want %v", equal, tt.wantEqual)
+			}
+
+			diff, err := png.Decode(&out)
+			if err != nil {
+				t.Fatalf("diff is not a PNG: %v", err)
+			}
+			red := 0
+			bounds := diff.Bounds()
+			for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+				for x := bounds.Min.X; x < bounds.Max.X; x++ {
+					if color.NRGBAModel.Convert(diff.At(x, y)) == (color.NRGBA{R: 255, A: 255}) {
+						red++
+					}
+				}
+			}
+			if tt.wantRed >= 0 && red != tt.wantRed {
+				t.Errorf("diff has %d red pixels, want %d", red, tt.wantRed)
+			}
+		})
+	}
+
+	t.Run("invalid actual", func(t *testing.T) {
+		comparator, _ := NewComparator(ModeImage, Options{Image: ImageOptions{Method: ImageMethodPixel, Threshold: 1}})
+		var out bytes.Buffer
+		equal, err := comparator.Compare(context.Background(), &out, text, expected)
+		if err != nil || equal || !strings.Contains(out.String(), "invalid image") {
+			t.Errorf("Compare() = %v, %v with %q, want a difference", equal, err, out.String())
+		}
+	})
+
+	t.Run("invalid expected", func(t *testing.T) {
+		comparator, _ := NewComparator(ModeImage, Options{Image: ImageOptions{Method: ImageMethodPixel, Threshold: 1}})
+		if _, err := comparator.Compare(context.Background(), io.Discard, same, text); err == nil {
+			t.Error("Compare() succeeded, want an error")
+		}
+	})
+}
+
+func TestNewImageComparatorValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ImageOptions
+		want string
+	}{
+		{name: "unknown method", opts: ImageOptions{Method: "ssim", Threshold: 1}, want: `invalid image method "ssim"`},
+		{name: "threshold above 1", opts: ImageOptions{Method: ImageMethodPixel, Threshold: 1.5}, want: "between 0 and 1"},
+		{name: "negative threshold", opts: ImageOptions{Method: ImageMethodPHash, Threshold: -0.1}, want: "between 0 and 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewComparator(ModeImage, Options{Image: tt.opts}); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("NewComparator() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
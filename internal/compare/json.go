@@ -0,0 +1,155 @@
+package compare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/shopspring/decimal"
+)
+
+// maxReportedDifferences caps the differences listed by the structural comparators
+const maxReportedDifferences = 20
+
+// jsonComparator compares two JSON documents by value
+// Formatting and object key order are ignored and numbers are compared by value,
+// so 1 equals 1.0. Actual output that is not valid JSON differs; an invalid
+// expected file is an error.
+type jsonComparator struct{}
+
+func (jsonComparator) Compare(ctx context.Context, w io.Writer, actual, expected string) (bool, error) {
+	want, err := readJSON(expected)
+	if err != nil {
+		return false, fmt.Errorf("invalid expected JSON %s: %w", expected, err)
+	}
+	got, err := readJSON(actual)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, err
+		}
+		_, _ = fmt.Fprintf(w, "%s: invalid JSON: %v\n", actual, err)
+		return false, nil
+	}
+
+	var diffs []string
+	jsonDiff("$", got, want, &diffs)
+	writeDifferences(w, diffs)
+	return len(diffs) == 0, nil
+}
+
+// readJSON decodes the single JSON document in a file, keeping numbers exact
+func readJSON(path string) (any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after the JSON document")
+	}
+	return value, nil
+}
+
+// jsonDiff appends the differences between got and want under path
+func jsonDiff(path string, got, want any, diffs *[]string) {
+	switch want := want.(type) {
+	case map[string]any:
+		gotMap, ok := got.(map[string]any)
+		if !ok {
+			*diffs = append(*diffs, fmt.Sprintf("%s: got %s, expected an object", path, jsonString(got)))
+			return
+		}
+		keys := make([]string, 0, len(want)+len(gotMap))
+		for key := range want {
+			keys = append(keys, key)
+		}
+		for key := range gotMap {
+			if _, ok := want[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			keyPath := path + "." + key
+			gotValue, inGot := gotMap[key]
+			wantValue, inWant := want[key]
+			switch {
+			case !inGot:
+				*diffs = append(*diffs, fmt.Sprintf("%s: missing, expected %s", keyPath, jsonString(wantValue)))
+			case !inWant:
+				*diffs = append(*diffs, fmt.Sprintf("%s: unexpected %s", keyPath, jsonString(gotValue)))
+			default:
+				jsonDiff(keyPath, gotValue, wantValue, diffs)
+			}
+		}
+	case []any:
+		gotSlice, ok := got.([]any)
+		if !ok {
+			*diffs = append(*diffs, fmt.Sprintf("%s: got %s, expected an array", path, jsonString(got)))
+			return
+		}
+		if len(gotSlice) != len(want) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: got %d elements, expected %d", path, len(gotSlice), len(want)))
+		}
+		for i := 0; i < len(want) && i < len(gotSlice); i++ {
+			jsonDiff(path+"["+strconv.Itoa(i)+"]", gotSlice[i], want[i], diffs)
+		}
+	case json.Number:
+		if gotNumber, ok := got.(json.Number); ok && numbersEqual(gotNumber, want) {
+			return
+		}
+		*diffs = append(*diffs, fmt.Sprintf("%s: got %s, expected %s", path, jsonString(got), want))
+	default:
+		if got != want {
+			*diffs = append(*diffs, fmt.Sprintf("%s: got %s, expected %s", path, jsonString(got), jsonString(want)))
+		}
+	}
+}
+
+// numbersEqual compares JSON numbers by value
+func numbersEqual(a, b json.Number) bool {
+	x, errA := decimal.NewFromString(a.String())
+	y, errB := decimal.NewFromString(b.String())
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return x.Equal(y)
+}
+
+// jsonString formats a decoded value for a difference report
+func jsonString(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return abbreviate(string(data))
+}
+
+// abbreviate shortens a value quoted in a difference report to 60 characters
+func abbreviate(s string) string {
+	if runes := []rune(s); len(runes) > 60 {
+		return string(runes[:57]) + "..."
+	}
+	return s
+}
+
+// writeDifferences lists differences on w, one per line, up to maxReportedDifferences
+func writeDifferences(w io.Writer, diffs []string) {
+	for i, diff := range diffs {
+		if i == maxReportedDifferences {
+			_, _ = fmt.Fprintf(w, "... and %d more differences\n", len(diffs)-i)
+			return
+		}
+		_, _ = fmt.Fprintln(w, diff)
+	}
+}
@@ -0,0 +1,149 @@
+package compare
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Source encodings understood by Normalization
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF16   = "utf-16" // byte order from the BOM, little-endian without one
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+	EncodingLatin1  = "latin1"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// Normalization converts files to comparable UTF-8 text before they are diffed
+// A UTF-8 byte order mark is always stripped.
+type Normalization struct {
+	Encoding          string // source encoding ("" = compare bytes as-is)
+	IgnoreLineEndings bool   // convert CRLF and CR line endings to LF
+}
+
+// ParseEncoding validates an encoding name and returns its canonical form
+// Names are case-insensitive; "utf8", "iso-8859-1" and similar aliases are accepted.
+func ParseEncoding(name string) (string, error) {
+	switch strings.ToLower(strings.ReplaceAll(name, "_", "-")) {
+	case "":
+		return "", nil
+	case "utf-8", "utf8":
+		return EncodingUTF8, nil
+	case "utf-16", "utf16":
+		return EncodingUTF16, nil
+	case "utf-16le", "utf16le":
+		return EncodingUTF16LE, nil
+	case "utf-16be", "utf16be":
+		return EncodingUTF16BE, nil
+	case "latin1", "latin-1", "iso-8859-1", "iso8859-1":
+		return EncodingLatin1, nil
+	default:
+		return "", fmt.Errorf("unsupported encoding %q (must be utf-8, utf-16, utf-16le, utf-16be or latin1)", name)
+	}
+}
+
+// Enabled reports whether files need to be normalised at all
+func (n Normalization) Enabled() bool {
+	return n.Encoding != "" || n.IgnoreLineEndings
+}
+
+// Apply returns data decoded to UTF-8 with the BOM stripped and line endings normalised
+func (n Normalization) Apply(data []byte) ([]byte, error) {
+	var err error
+	switch n.Encoding {
+	case EncodingUTF16, EncodingUTF16LE, EncodingUTF16BE:
+		data, err = decodeUTF16(data, n.Encoding)
+		if err != nil {
+			return nil, err
+		}
+	case EncodingLatin1:
+		data = decodeLatin1(data)
+	}
+	data = bytes.TrimPrefix(data, utf8BOM)
+
+	if n.IgnoreLineEndings {
+		data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+		data = bytes.ReplaceAll(data, []byte("\r"), []byte("\n"))
+	}
+	return data, nil
+}
+
+// CopyFile writes the normalised content of src to dst
+func (n Normalization) CopyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", src, err)
+	}
+	normalized, err := n.Apply(data)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", src, err)
+	}
+	if err := os.WriteFile(dst, normalized, 0600); err != nil {
+		return fmt.Errorf("failed to write normalised copy of %s: %w", src, err)
+	}
+	return nil
+}
+
+// CopyTree writes normalised copies of every file under src to the same relative paths under dst
+func (n Normalization) CopyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0700)
+		}
+		return n.CopyFile(path, target)
+	})
+}
+
+// decodeUTF16 converts UTF-16 text to UTF-8, honouring and removing a BOM
+func decodeUTF16(data []byte, encoding string) ([]byte, error) {
+	var order binary.ByteOrder = binary.LittleEndian
+	if encoding == EncodingUTF16BE {
+		order = binary.BigEndian
+	}
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}) && encoding != EncodingUTF16BE:
+		order, data = binary.LittleEndian, data[2:]
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}) && encoding != EncodingUTF16LE:
+		order, data = binary.BigEndian, data[2:]
+	}
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("invalid UTF-16: odd number of bytes")
+	}
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	var out bytes.Buffer
+	out.Grow(len(units))
+	for _, r := range utf16.Decode(units) {
+		out.WriteRune(r)
+	}
+	return out.Bytes(), nil
+}
+
+// decodeLatin1 converts ISO-8859-1 text to UTF-8; every byte is a code point
+func decodeLatin1(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for _, b := range data {
+		out = utf8.AppendRune(out, rune(b))
+	}
+	return out
+}
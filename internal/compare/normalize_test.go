@@ -0,0 +1,95 @@
+package compare
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseEncoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "", want: ""},
+		{name: "UTF8", want: EncodingUTF8},
+		{name: "utf-16", want: EncodingUTF16},
+		{name: "UTF_16LE", want: EncodingUTF16LE},
+		{name: "utf16be", want: EncodingUTF16BE},
+		{name: "ISO-8859-1", want: EncodingLatin1},
+		{name: "shift-jis", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEncoding(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEncoding(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseEncoding(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizationApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		n       Normalization
+		data    string
+		want    string
+		wantErr string
+	}{
+		{name: "crlf kept by default", n: Normalization{Encoding: EncodingUTF8}, data: "a\r\nb\r\n", want: "a\r\nb\r\n"},
+		{name: "crlf and cr", n: Normalization{IgnoreLineEndings: true}, data: "a\r\nb\rc\n", want: "a\nb\nc\n"},
+		{name: "utf-8 bom", n: Normalization{IgnoreLineEndings: true}, data: "\xEF\xBB\xBFa\n", want: "a\n"},
+		{name: "utf-16 le bom", n: Normalization{Encoding: EncodingUTF16}, data: "\xFF\xFEa\x00\xE9\x00\n\x00", want: "aé\n"},
+		{name: "utf-16 be bom", n: Normalization{Encoding: EncodingUTF16}, data: "\xFE\xFF\x00a\x00\xE9\x00\n", want: "aé\n"},
+		{name: "utf-16 without bom", n: Normalization{Encoding: EncodingUTF16}, data: "a\x00\n\x00", want: "a\n"},
+		{name: "utf-16be surrogate pair", n: Normalization{Encoding: EncodingUTF16BE}, data: "\xD8\x3D\xDE\x00", want: "😀"},
+		{name: "utf-16 odd length", n: Normalization{Encoding: EncodingUTF16LE}, data: "a\x00b", wantErr: "odd number of bytes"},
+		{name: "latin1", n: Normalization{Encoding: EncodingLatin1}, data: "caf\xE9\r\n", want: "café\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.n.Apply([]byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Apply() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Apply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizationCopyTree(t *testing.T) {
+	src := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "sub", "a.txt"), []byte("x\r\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(t.TempDir(), "copy")
+	n := Normalization{IgnoreLineEndings: true}
+	if err := n.CopyTree(src, dst); err != nil {
+		t.Fatalf("CopyTree() error = %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dst, "sub", "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "x\n" {
+		t.Errorf("copied content = %q, want %q", got, "x\n")
+	}
+}
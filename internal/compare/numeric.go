@@ -0,0 +1,95 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// numericComparator compares whitespace-separated tokens
+// A token that is a number in the expected output matches an actual number
+// whose absolute or relative error is within the tolerance; other tokens must
+// be equal. Line breaks and the amount of white space are ignored.
+type numericComparator struct {
+	tolerance float64
+}
+
+func newNumericComparator(opts Options) (Comparator, error) {
+	if opts.Tolerance < 0 || math.IsNaN(opts.Tolerance) || math.IsInf(opts.Tolerance, 0) {
+		return nil, fmt.Errorf("tolerance must be a finite number that is not negative")
+	}
+	return numericComparator{tolerance: opts.Tolerance}, nil
+}
+
+// token is a whitespace-separated word and the line it is on
+type token struct {
+	text string
+	line int
+}
+
+func (c numericComparator) Compare(ctx context.Context, w io.Writer, actual, expected string) (bool, error) {
+	got, err := readTokens(actual)
+	if err != nil {
+		return false, err
+	}
+	want, err := readTokens(expected)
+	if err != nil {
+		return false, err
+	}
+
+	var diffs []string
+	for i := 0; i < len(want) && i < len(got); i++ {
+		if i%4096 == 0 && ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		if !c.tokensEqual(got[i].text, want[i].text) {
+			diffs = append(diffs, fmt.Sprintf("token %d (line %d): got %q, expected %q", i+1, got[i].line, got[i].text, want[i].text))
+		}
+	}
+	if len(got) != len(want) {
+		diffs = append(diffs, fmt.Sprintf("got %d tokens, expected %d", len(got), len(want)))
+	}
+	writeDifferences(w, diffs)
+	return len(diffs) == 0, nil
+}
+
+// tokensEqual compares numbers within the tolerance and other tokens exactly
+func (c numericComparator) tokensEqual(got, want string) bool {
+	return numbersWithin(got, want, c.tolerance)
+}
+
+// numbersWithin reports whether got equals want, or both are numbers whose
+// absolute or relative error is within the tolerance
+func numbersWithin(got, want string, tolerance float64) bool {
+	if got == want {
+		return true
+	}
+	wantNumber, err := strconv.ParseFloat(want, 64)
+	if err != nil || math.IsNaN(wantNumber) || math.IsInf(wantNumber, 0) {
+		return false
+	}
+	gotNumber, err := strconv.ParseFloat(got, 64)
+	if err != nil || math.IsNaN(gotNumber) {
+		return false
+	}
+	diff := math.Abs(gotNumber - wantNumber)
+	return diff <= tolerance || diff <= tolerance*math.Abs(wantNumber)
+}
+
+// readTokens splits a file into whitespace-separated tokens
+func readTokens(path string) ([]token, error) {
+	lines, err := readLines(path, TextOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var tokens []token
+	for _, l := range lines {
+		for _, field := range strings.Fields(l.text) {
+			tokens = append(tokens, token{text: field, line: l.number})
+		}
+	}
+	return tokens, nil
+}
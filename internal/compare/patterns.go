@@ -0,0 +1,110 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Pattern is a regular expression an output must contain on some line, or must
+// not contain on any line when Forbidden
+type Pattern struct {
+	Expr      string
+	Forbidden bool
+	re        *regexp.Regexp
+}
+
+// NewPattern compiles a pattern (RE2 syntax); it matches anywhere in a line
+// unless anchored with ^ and $
+func NewPattern(expr string, forbidden bool) (Pattern, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return Pattern{}, fmt.Errorf("invalid pattern %q: %w", expr, err)
+	}
+	return Pattern{Expr: expr, Forbidden: forbidden, re: re}, nil
+}
+
+// ReadPatterns reads a pattern file with one pattern per line
+// Lines starting with "!" are forbidden patterns; blank lines and lines
+// starting with "#" are skipped. Use "\!" and "\#" for patterns starting with
+// those characters.
+func ReadPatterns(path string) ([]Pattern, error) {
+	lines, err := readLines(path, TextOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pattern file: %w", err)
+	}
+
+	var patterns []Pattern
+	for _, l := range lines {
+		text := strings.TrimSuffix(l.text, "\r")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		forbidden := strings.HasPrefix(text, "!")
+		pattern, err := NewPattern(strings.TrimPrefix(text, "!"), forbidden)
+		if err != nil {
+			return nil, fmt.Errorf("line %d of %s: %w", l.number, path, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// PatternResult is the outcome of checking a single pattern
+type PatternResult struct {
+	Pattern   string
+	Forbidden bool
+	Passed    bool
+	Line      int // first matching line, 0 if none
+}
+
+// CheckPatterns searches the lines of a file for the patterns
+// A required pattern passes if some line matches it, a forbidden one if no line
+// does. One report line per pattern is written to w.
+func CheckPatterns(ctx context.Context, w io.Writer, path string, patterns []Pattern) ([]PatternResult, error) {
+	lines, err := readLines(path, TextOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]PatternResult, len(patterns))
+	for i, pattern := range patterns {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		results[i] = PatternResult{Pattern: pattern.Expr, Forbidden: pattern.Forbidden}
+		var matched string
+		for _, l := range lines {
+			if pattern.re.MatchString(l.text) {
+				results[i].Line, matched = l.number, l.text
+				break
+			}
+		}
+		results[i].Passed = (results[i].Line > 0) != pattern.Forbidden
+		writePatternResult(w, results[i], matched)
+	}
+	return results, nil
+}
+
+// writePatternResult writes the report line of a pattern and the line it matched
+func writePatternResult(w io.Writer, result PatternResult, matched string) {
+	verdict := "FAIL"
+	if result.Passed {
+		verdict = "PASS"
+	}
+	kind := "required"
+	if result.Forbidden {
+		kind = "forbidden"
+	}
+
+	switch {
+	case result.Line > 0:
+		_, _ = fmt.Fprintf(w, "%s %s /%s/: line %d: %q\n", verdict, kind, result.Pattern, result.Line, matched)
+	case result.Forbidden:
+		_, _ = fmt.Fprintf(w, "%s %s /%s/: no line matches\n", verdict, kind, result.Pattern)
+	default:
+		_, _ = fmt.Fprintf(w, "%s %s /%s/: not found\n", verdict, kind, result.Pattern)
+	}
+}
@@ -0,0 +1,131 @@
+package compare
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckPatterns(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		required   []string
+		forbidden  []string
+		wantPassed []bool
+		wantLines  []int
+		wantReport string
+	}{
+		{
+			name:       "required found on first matching line",
+			content:    "a\nsum = 3\nsum = 4\n",
+			required:   []string{`sum = \d`},
+			wantPassed: []bool{true},
+			wantLines:  []int{2},
+			wantReport: "PASS required /sum = \\d/: line 2: \"sum = 3\"\n",
+		},
+		{
+			name:       "required missing",
+			content:    "a\n",
+			required:   []string{"^b$"},
+			wantPassed: []bool{false},
+			wantLines:  []int{0},
+			wantReport: "FAIL required /^b$/: not found\n",
+		},
+		{
+			name:       "forbidden absent",
+			content:    "ok\n",
+			forbidden:  []string{"panic"},
+			wantPassed: []bool{true},
+			wantLines:  []int{0},
+			wantReport: "PASS forbidden /panic/: no line matches\n",
+		},
+		{
+			name:       "forbidden present",
+			content:    "ok\npanic: boom",
+			forbidden:  []string{"panic"},
+			wantPassed: []bool{false},
+			wantLines:  []int{2},
+			wantReport: "FAIL forbidden /panic/: line 2: \"panic: boom\"\n",
+		},
+		{
+			name:       "anchors apply per line",
+			content:    "x 42\n42\n",
+			required:   []string{"^42$"},
+			wantPassed: []bool{true},
+			wantLines:  []int{2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "output.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			var patterns []Pattern
+			for _, expr := range tt.required {
+				p, err := NewPattern(expr, false)
+				if err != nil {
+					t.Fatal(err)
+				}
+				patterns = append(patterns, p)
+			}
+			for _, expr := range tt.forbidden {
+				p, err := NewPattern(expr, true)
+				if err != nil {
+					t.Fatal(err)
+				}
+				patterns = append(patterns, p)
+			}
+
+			var report strings.Builder
+			results, err := CheckPatterns(context.Background(), &report, path, patterns)
+			if err != nil {
+				t.Fatalf("CheckPatterns() error = %v", err)
+			}
+			for i, result := range results {
+				if result.Passed != tt.wantPassed[i] || result.Line != tt.wantLines[i] {
+					t.Errorf("result %d = %+v, want passed %v on line %d", i, result, tt.wantPassed[i], tt.wantLines[i])
+				}
+			}
+			if tt.wantReport != "" && report.String() != tt.wantReport {
+				t.Errorf("report = %q, want %q", report.String(), tt.wantReport)
+			}
+		})
+	}
+}
+
+func TestReadPatterns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patterns.txt")
+	content := "# rubric\r\n^Total: \\d+$\r\n\r\n!Traceback\n\\!important\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	patterns, err := ReadPatterns(path)
+	if err != nil {
+		t.Fatalf("ReadPatterns() error = %v", err)
+	}
+	want := []struct {
+		expr      string
+		forbidden bool
+	}{{`^Total: \d+$`, false}, {"Traceback", true}, {`\!important`, false}}
+	if len(patterns) != len(want) {
+		t.Fatalf("got %d patterns, want %d", len(patterns), len(want))
+	}
+	for i, w := range want {
+		if patterns[i].Expr != w.expr || patterns[i].Forbidden != w.forbidden {
+			t.Errorf("pattern %d = %q (forbidden %v), want %q (forbidden %v)", i, patterns[i].Expr, patterns[i].Forbidden, w.expr, w.forbidden)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("ok\n!(\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadPatterns(path); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("ReadPatterns() error = %v, want an error on line 2", err)
+	}
+}
@@ -0,0 +1,47 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// regexComparator matches every actual line against the pattern on the same
+// line of the expected file
+// Patterns must match the whole line (RE2 syntax) and both files must have the
+// same number of lines. An invalid pattern is an error.
+type regexComparator struct{}
+
+func (regexComparator) Compare(ctx context.Context, w io.Writer, actual, expected string) (bool, error) {
+	patternLines, err := readLines(expected, TextOptions{})
+	if err != nil {
+		return false, err
+	}
+	patterns := make([]*regexp.Regexp, len(patternLines))
+	for i, l := range patternLines {
+		patterns[i], err = regexp.Compile(`^(?:` + l.text + `)$`)
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern on line %d of %s: %w", l.number, expected, err)
+		}
+	}
+	got, err := readLines(actual, TextOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	var diffs []string
+	for i := 0; i < len(patterns) && i < len(got); i++ {
+		if i%4096 == 0 && ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		if !patterns[i].MatchString(got[i].text) {
+			diffs = append(diffs, fmt.Sprintf("line %d: %q does not match /%s/", got[i].number, got[i].text, patternLines[i].text))
+		}
+	}
+	if len(got) != len(patterns) {
+		diffs = append(diffs, fmt.Sprintf("got %d lines, expected %d", len(got), len(patterns)))
+	}
+	writeDifferences(w, diffs)
+	return len(diffs) == 0, nil
+}
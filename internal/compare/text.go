@@ -0,0 +1,529 @@
+package compare
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultContextLines is the number of unchanged lines shown around each hunk
+const DefaultContextLines = 3
+
+// TextOptions controls how the internal engine compares lines
+// The fields mirror the GNU diff flags commonly used for grading.
+type TextOptions struct {
+	IgnoreCase          bool // -i, --ignore-case
+	IgnoreTrailingSpace bool // -Z, --ignore-trailing-space
+	IgnoreSpaceChange   bool // -b, --ignore-space-change
+	IgnoreAllSpace      bool // -w, --ignore-all-space
+	IgnoreBlankLines    bool // -B, --ignore-blank-lines
+	Context             int  // -U, --unified lines of context
+}
+
+// ParseTextOptions converts diff flags to options for the internal engine
+// Flags the internal engine cannot honour are rejected rather than silently ignored.
+func ParseTextOptions(flags []string) (TextOptions, error) {
+	opts := TextOptions{Context: DefaultContextLines}
+	for i := 0; i < len(flags); i++ {
+		flag := flags[i]
+		switch {
+		case flag == "--ignore-case":
+			opts.IgnoreCase = true
+		case flag == "--ignore-trailing-space":
+			opts.IgnoreTrailingSpace = true
+		case flag == "--ignore-space-change":
+			opts.IgnoreSpaceChange = true
+		case flag == "--ignore-all-space":
+			opts.IgnoreAllSpace = true
+		case flag == "--ignore-blank-lines":
+			opts.IgnoreBlankLines = true
+		case flag == "--recursive", flag == "--unified":
+			// Directories are always compared recursively and output is always unified
+		case strings.HasPrefix(flag, "--unified="):
+			n, err := strconv.Atoi(strings.TrimPrefix(flag, "--unified="))
+			if err != nil || n < 0 {
+				return opts, fmt.Errorf("invalid context length in %s", flag)
+			}
+			opts.Context = n
+		case flag == "-U" || (strings.HasPrefix(flag, "-U") && !strings.HasPrefix(flag, "--")):
+			value := strings.TrimPrefix(flag, "-U")
+			if value == "" {
+				if i+1 >= len(flags) {
+					return opts, fmt.Errorf("-U requires a context length")
+				}
+				i++
+				value = flags[i]
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return opts, fmt.Errorf("invalid context length %q for -U", value)
+			}
+			opts.Context = n
+		case len(flag) > 1 && flag[0] == '-' && flag[1] != '-':
+			// Clusters of single-letter flags, e.g. -wB
+			for _, letter := range flag[1:] {
+				switch letter {
+				case 'i':
+					opts.IgnoreCase = true
+				case 'Z':
+					opts.IgnoreTrailingSpace = true
+				case 'b':
+					opts.IgnoreSpaceChange = true
+				case 'w':
+					opts.IgnoreAllSpace = true
+				case 'B':
+					opts.IgnoreBlankLines = true
+				case 'r', 'u':
+				default:
+					return opts, fmt.Errorf("diff flag -%c is not supported by the internal engine", letter)
+				}
+			}
+		default:
+			return opts, fmt.Errorf("diff flag %s is not supported by the internal engine", flag)
+		}
+	}
+	return opts, nil
+}
+
+// normalize returns the comparison key for a line under the options
+func (o TextOptions) normalize(line string) string {
+	switch {
+	case o.IgnoreAllSpace:
+		line = strings.Join(strings.Fields(line), "")
+	case o.IgnoreSpaceChange:
+		line = strings.Join(strings.Fields(line), " ")
+		// -b treats leading white space as significant only when present
+		if trimmed := strings.TrimLeft(line, " \t"); len(trimmed) != len(line) {
+			line = " " + trimmed
+		}
+	case o.IgnoreTrailingSpace:
+		line = strings.TrimRight(line, " \t")
+	}
+	if o.IgnoreCase {
+		line = strings.ToLower(line)
+	}
+	return line
+}
+
+// line is a line of a compared file with its 1-based line number
+type line struct {
+	text    string // without the trailing newline
+	number  int
+	noEOL   bool // last line of a file without a trailing newline
+	compare string
+}
+
+// readLines reads a file into lines, dropping blank lines from the comparison if requested
+func readLines(path string, opts TextOptions) ([]line, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var lines []line
+	reader := bufio.NewReader(file)
+	for number := 1; ; number++ {
+		text, err := reader.ReadString('\n')
+		if text == "" && err == io.EOF {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		l := line{text: strings.TrimSuffix(text, "\n"), number: number, noEOL: !strings.HasSuffix(text, "\n")}
+		l.compare = opts.normalize(l.text)
+		if l.noEOL {
+			// A missing final newline is a difference unless white space is ignored
+			if !opts.IgnoreAllSpace && !opts.IgnoreSpaceChange && !opts.IgnoreTrailingSpace {
+				l.compare += "\x00noeol"
+			}
+		}
+		if opts.IgnoreBlankLines && strings.TrimSpace(l.text) == "" {
+			continue
+		}
+		lines = append(lines, l)
+	}
+	return lines, nil
+}
+
+// editKind is the operation of an edit script entry
+type editKind int
+
+const (
+	editEqual editKind = iota
+	editDelete
+	editInsert
+)
+
+// edit is one entry of the edit script turning a into b
+type edit struct {
+	kind editKind
+	a, b int // indexes into a and b (the one not used by the kind is unspecified)
+}
+
+// diffLines computes a shortest edit script between a and b using Myers' LCS algorithm
+func diffLines(ctx context.Context, a, b []line) ([]edit, error) {
+	// Common prefix and suffix never need the quadratic search
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix].compare == b[prefix].compare {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix].compare == b[len(b)-1-suffix].compare {
+		suffix++
+	}
+
+	var edits []edit
+	for i := 0; i < prefix; i++ {
+		edits = append(edits, edit{kind: editEqual, a: i, b: i})
+	}
+	middle, err := myers(ctx, a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range middle {
+		e.a += prefix
+		e.b += prefix
+		edits = append(edits, e)
+	}
+	for i := 0; i < suffix; i++ {
+		edits = append(edits, edit{kind: editEqual, a: len(a) - suffix + i, b: len(b) - suffix + i})
+	}
+	return edits, nil
+}
+
+// myers returns the edit script for a and b, checking ctx for cancellation between passes
+// It uses the linear space refinement of Myers' algorithm: the middle snake of
+// the shortest edit path splits the problem in two, so only the current
+// frontiers are kept and memory stays O(N+M) whatever the edit distance.
+func myers(ctx context.Context, a, b []line) ([]edit, error) {
+	edits := make([]edit, 0, len(a)+len(b))
+	if err := myersRange(ctx, a, b, 0, len(a), 0, len(b), &edits); err != nil {
+		return nil, err
+	}
+	groupChanges(edits)
+	return edits, nil
+}
+
+// myersRange appends the edit script turning a[aLo:aHi] into b[bLo:bHi]
+func myersRange(ctx context.Context, a, b []line, aLo, aHi, bLo, bHi int, edits *[]edit) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for aLo < aHi && bLo < bHi && a[aLo].compare == b[bLo].compare {
+		*edits = append(*edits, edit{kind: editEqual, a: aLo, b: bLo})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && a[aHi-1-suffix].compare == b[bHi-1-suffix].compare {
+		suffix++
+	}
+	aHi -= suffix
+	bHi -= suffix
+
+	switch {
+	case aLo == aHi:
+		for y := bLo; y < bHi; y++ {
+			*edits = append(*edits, edit{kind: editInsert, a: aLo, b: y})
+		}
+	case bLo == bHi:
+		for x := aLo; x < aHi; x++ {
+			*edits = append(*edits, edit{kind: editDelete, a: x, b: bLo})
+		}
+	default:
+		x, y, err := middleSnake(ctx, a[aLo:aHi], b[bLo:bHi])
+		if err != nil {
+			return err
+		}
+		if err := myersRange(ctx, a, b, aLo, aLo+x, bLo, bLo+y, edits); err != nil {
+			return err
+		}
+		if err := myersRange(ctx, a, b, aLo+x, aHi, bLo+y, bHi, edits); err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < suffix; i++ {
+		*edits = append(*edits, edit{kind: editEqual, a: aHi + i, b: bHi + i})
+	}
+	return nil
+}
+
+// middleSnake finds where the forward and reverse searches of a shortest edit
+// path from a to b overlap, returning the point at which to split them
+// a and b must both be non-empty and differ in their first and last lines.
+func middleSnake(ctx context.Context, a, b []line) (int, int, error) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	size := 2*maxD + 2
+	forward := make([]int, size)
+	reverse := make([]int, size)
+	for i := range forward {
+		forward[i] = -1
+		reverse[i] = -1
+	}
+	forward[offset+1] = 0
+	reverse[offset+1] = 0
+
+	delta := n - m
+	// With an odd delta the paths overlap in the forward pass, otherwise in the reverse one
+	odd := delta%2 != 0
+	// Diagonals that ran off the edit graph are not extended again
+	var fStart, fEnd, rStart, rEnd int
+
+	for d := 0; d < maxD; d++ {
+		if err := ctx.Err(); err != nil {
+			return 0, 0, err
+		}
+
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && forward[i-1] < forward[i+1]) {
+				x = forward[i+1]
+			} else {
+				x = forward[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x].compare == b[y].compare {
+				x++
+				y++
+			}
+			forward[i] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				if j := offset + delta - k; j >= 0 && j < size && reverse[j] != -1 && x >= n-reverse[j] {
+					return x, y, nil
+				}
+			}
+		}
+
+		for k := -d + rStart; k <= d-rEnd; k += 2 {
+			i := offset + k
+			var x int
+			if k == -d || (k != d && reverse[i-1] < reverse[i+1]) {
+				x = reverse[i+1]
+			} else {
+				x = reverse[i-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-x-1].compare == b[m-y-1].compare {
+				x++
+				y++
+			}
+			reverse[i] = x
+			switch {
+			case x > n:
+				rEnd += 2
+			case y > m:
+				rStart += 2
+			case !odd:
+				if j := offset + delta - k; j >= 0 && j < size && forward[j] != -1 {
+					fx := forward[j]
+					if fx >= n-x {
+						return fx, offset + fx - j, nil
+					}
+				}
+			}
+		}
+	}
+
+	// The paths only meet past maxD when a and b have no line in common
+	return n, 0, nil
+}
+
+// groupChanges orders each run of changes as its deletions followed by its
+// insertions, the way unified diffs show them
+func groupChanges(edits []edit) {
+	for start := 0; start < len(edits); {
+		if edits[start].kind == editEqual {
+			start++
+			continue
+		}
+		end := start
+		for end < len(edits) && edits[end].kind != editEqual {
+			end++
+		}
+		sort.SliceStable(edits[start:end], func(i, j int) bool {
+			return edits[start+i].kind == editDelete && edits[start+j].kind == editInsert
+		})
+		start = end
+	}
+}
+
+// DiffFiles compares two text files and writes a unified diff to w if they differ
+// Reports whether the files are equal under the options.
+func DiffFiles(ctx context.Context, w io.Writer, pathA, pathB string, opts TextOptions) (bool, error) {
+	a, err := readLines(pathA, opts)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", pathA, err)
+	}
+	b, err := readLines(pathB, opts)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", pathB, err)
+	}
+
+	edits, err := diffLines(ctx, a, b)
+	if err != nil {
+		return false, err
+	}
+
+	equal := true
+	for _, e := range edits {
+		if e.kind != editEqual {
+			equal = false
+			break
+		}
+	}
+	if equal {
+		return true, nil
+	}
+
+	if _, err := fmt.Fprintf(w, "--- %s\n+++ %s\n", pathA, pathB); err != nil {
+		return false, err
+	}
+	return false, writeHunks(w, a, b, edits, opts.Context)
+}
+
+// writeHunks writes the edit script as unified diff hunks with the given context
+func writeHunks(w io.Writer, a, b []line, edits []edit, context int) error {
+	for start := 0; start < len(edits); {
+		// Find the next change
+		for start < len(edits) && edits[start].kind == editEqual {
+			start++
+		}
+		if start == len(edits) {
+			return nil
+		}
+
+		// Extend the hunk until a run of more than 2*context equal lines
+		end := start
+		for end < len(edits) {
+			if edits[end].kind != editEqual {
+				end++
+				continue
+			}
+			run := end
+			for run < len(edits) && edits[run].kind == editEqual {
+				run++
+			}
+			if run == len(edits) || run-end > 2*context {
+				break
+			}
+			end = run
+		}
+
+		first := max(start-context, 0)
+		last := min(end+context, len(edits))
+		if err := writeHunk(w, a, b, edits[first:last]); err != nil {
+			return err
+		}
+		start = last
+	}
+	return nil
+}
+
+// writeHunk writes a single hunk header and its lines
+func writeHunk(w io.Writer, a, b []line, hunk []edit) error {
+	aStart, aCount, bStart, bCount := -1, 0, -1, 0
+	for _, e := range hunk {
+		if e.kind != editInsert {
+			if aStart < 0 {
+				aStart = a[e.a].number
+			}
+			aCount++
+		}
+		if e.kind != editDelete {
+			if bStart < 0 {
+				bStart = b[e.b].number
+			}
+			bCount++
+		}
+	}
+	// Empty ranges start at the line before the hunk
+	if aStart < 0 {
+		aStart = lineBefore(a, hunk[0].a)
+	}
+	if bStart < 0 {
+		bStart = lineBefore(b, hunk[0].b)
+	}
+
+	if _, err := fmt.Fprintf(w, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount)); err != nil {
+		return err
+	}
+	for _, e := range hunk {
+		var prefix string
+		var l line
+		switch e.kind {
+		case editEqual:
+			prefix, l = " ", b[e.b]
+		case editDelete:
+			prefix, l = "-", a[e.a]
+		case editInsert:
+			prefix, l = "+", b[e.b]
+		}
+		if _, err := fmt.Fprintf(w, "%s%s\n", prefix, l.text); err != nil {
+			return err
+		}
+		if l.noEOL {
+			if _, err := fmt.Fprintln(w, `\ No newline at end of file`); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// lineBefore returns the line number preceding index i of lines (0 at the start)
+func lineBefore(lines []line, i int) int {
+	if i == 0 || len(lines) == 0 {
+		return 0
+	}
+	return lines[i-1].number
+}
+
+// hunkRange formats a unified diff range, omitting the count when it is one
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return strconv.Itoa(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// Comparison engines for ghost diff
+const (
+	EngineAuto     = "auto"     // external if a diff binary is available, internal otherwise
+	EngineExternal = "external" // the diff binary on PATH
+	EngineInternal = "internal" // the built-in unified diff
+)
+
+// ResolveEngine validates an engine name and resolves auto to a concrete engine
+func ResolveEngine(name string) (string, error) {
+	switch name {
+	case EngineExternal, EngineInternal:
+		return name, nil
+	case "", EngineAuto:
+		if _, err := exec.LookPath("diff"); err != nil {
+			return EngineInternal, nil
+		}
+		return EngineExternal, nil
+	default:
+		return "", fmt.Errorf("invalid diff engine %q (must be %s, %s or %s)", name, EngineAuto, EngineExternal, EngineInternal)
+	}
+}
@@ -0,0 +1,299 @@
+package compare
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseTextOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   []string
+		want    TextOptions
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			want: TextOptions{Context: DefaultContextLines},
+		},
+		{
+			name:  "long flags",
+			flags: []string{"--ignore-trailing-space", "--ignore-blank-lines", "--ignore-case", "--unified=1"},
+			want:  TextOptions{IgnoreTrailingSpace: true, IgnoreBlankLines: true, IgnoreCase: true, Context: 1},
+		},
+		{
+			name:  "short flag cluster",
+			flags: []string{"-wB", "-r", "-U", "0"},
+			want:  TextOptions{IgnoreAllSpace: true, IgnoreBlankLines: true, Context: 0},
+		},
+		{
+			name:  "attached context length",
+			flags: []string{"-b", "-U5"},
+			want:  TextOptions{IgnoreSpaceChange: true, Context: 5},
+		},
+		{
+			name:    "unsupported long flag",
+			flags:   []string{"--strip-trailing-cr"},
+			wantErr: "--strip-trailing-cr is not supported",
+		},
+		{
+			name:    "unsupported short flag",
+			flags:   []string{"-wy"},
+			wantErr: "-y is not supported",
+		},
+		{
+			name:    "missing context length",
+			flags:   []string{"-U"},
+			wantErr: "-U requires a context length",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTextOptions(tt.flags)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseTextOptions() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseTextOptions() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseTextOptions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      string
+		opts      TextOptions
+		wantEqual bool
+		wantHunks string // output after the ---/+++ header
+	}{
+		{
+			name:      "identical",
+			a:         "one\ntwo\n",
+			b:         "one\ntwo\n",
+			wantEqual: true,
+		},
+		{
+			name:      "changed line",
+			a:         "1\n2\n3\n4\n5\n",
+			b:         "1\n2\nthree\n4\n5\n",
+			wantHunks: "@@ -1,5 +1,5 @@\n 1\n 2\n-3\n+three\n 4\n 5\n",
+		},
+		{
+			name:      "separate hunks",
+			a:         "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\n",
+			b:         "A\nb\nc\nd\ne\nf\ng\nh\ni\nJ\n",
+			opts:      TextOptions{Context: 1},
+			wantHunks: "@@ -1,2 +1,2 @@\n-a\n+A\n b\n@@ -9,2 +9,2 @@\n i\n-j\n+J\n",
+		},
+		{
+			name:      "insert into empty file",
+			a:         "",
+			b:         "new\n",
+			wantHunks: "@@ -0,0 +1 @@\n+new\n",
+		},
+		{
+			name:      "missing final newline",
+			a:         "x\n",
+			b:         "x",
+			wantHunks: "@@ -1 +1 @@\n-x\n+x\n\\ No newline at end of file\n",
+		},
+		{
+			name:      "ignore trailing space",
+			a:         "result: 42   \n",
+			b:         "result: 42\n",
+			opts:      TextOptions{IgnoreTrailingSpace: true},
+			wantEqual: true,
+		},
+		{
+			name:      "ignore space change",
+			a:         "a   b\tc\n",
+			b:         "a b c\n",
+			opts:      TextOptions{IgnoreSpaceChange: true},
+			wantEqual: true,
+		},
+		{
+			name:      "ignore all space",
+			a:         "a b c\n",
+			b:         "abc\n",
+			opts:      TextOptions{IgnoreAllSpace: true},
+			wantEqual: true,
+		},
+		{
+			name:      "ignore blank lines",
+			a:         "a\n\nb\n",
+			b:         "a\nb\n\n\n",
+			opts:      TextOptions{IgnoreBlankLines: true},
+			wantEqual: true,
+		},
+		{
+			name:      "ignore case",
+			a:         "Hello\n",
+			b:         "hello\n",
+			opts:      TextOptions{IgnoreCase: true},
+			wantEqual: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			pathA := filepath.Join(dir, "a.txt")
+			pathB := filepath.Join(dir, "b.txt")
+			if err := os.WriteFile(pathA, []byte(tt.a), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(pathB, []byte(tt.b), 0644); err != nil {
+				t.Fatal(err)
+			}
+			// Cases that don't set a context use the default
+			if tt.opts.Context == 0 {
+				tt.opts.Context = DefaultContextLines
+			}
+
+			var out strings.Builder
+			equal, err := DiffFiles(context.Background(), &out, pathA, pathB, tt.opts)
+			if err != nil {
+				t.Fatalf("DiffFiles() error = %v", err)
+			}
+			if equal != tt.wantEqual {
+				t.Errorf("equal = %v, want %v", equal, tt.wantEqual)
+			}
+			if tt.wantEqual {
+				if out.Len() != 0 {
+					t.Errorf("Expected no output for equal files, got:\n%s", out.String())
+				}
+				return
+			}
+
+			header := "--- " + pathA + "\n+++ " + pathB + "\n"
+			if out.String() != header+tt.wantHunks {
+				t.Errorf("Output =\n%s\nwant\n%s", out.String(), header+tt.wantHunks)
+			}
+		})
+	}
+}
+
+func TestDiffFilesMissing(t *testing.T) {
+	_, err := DiffFiles(context.Background(), &strings.Builder{}, filepath.Join(t.TempDir(), "missing"), "/dev/null", TextOptions{})
+	if err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
+
+// lcsLength computes the LCS length by dynamic programming as a reference
+func lcsLength(a, b []line) int {
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i].compare == b[j].compare {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+	return table[0][0]
+}
+
+func TestDiffLinesMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomLines := func() []line {
+		lines := make([]line, rng.Intn(12))
+		for i := range lines {
+			lines[i] = line{compare: string(rune('a' + rng.Intn(4)))}
+		}
+		return lines
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := randomLines(), randomLines()
+		edits, err := diffLines(context.Background(), a, b)
+		if err != nil {
+			t.Fatalf("diffLines() error = %v", err)
+		}
+
+		// Replaying the script must turn a into b
+		var gotA, gotB []string
+		equal := 0
+		for _, e := range edits {
+			switch e.kind {
+			case editEqual:
+				gotA = append(gotA, a[e.a].compare)
+				gotB = append(gotB, b[e.b].compare)
+				equal++
+			case editDelete:
+				gotA = append(gotA, a[e.a].compare)
+			case editInsert:
+				gotB = append(gotB, b[e.b].compare)
+			}
+		}
+		if !reflect.DeepEqual(gotA, compareKeys(a)) || !reflect.DeepEqual(gotB, compareKeys(b)) {
+			t.Fatalf("Edit script does not reproduce inputs: a=%v b=%v", compareKeys(a), compareKeys(b))
+		}
+		if want := lcsLength(a, b); equal != want {
+			t.Fatalf("Edit script keeps %d lines, LCS is %d: a=%v b=%v", equal, want, compareKeys(a), compareKeys(b))
+		}
+	}
+}
+
+func compareKeys(lines []line) []string {
+	var keys []string
+	for _, l := range lines {
+		keys = append(keys, l.compare)
+	}
+	return keys
+}
+
+func TestDiffLinesCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a := []line{{compare: "a"}, {compare: "b"}}
+	b := []line{{compare: "c"}}
+	if _, err := diffLines(ctx, a, b); err == nil {
+		t.Error("Expected error from cancelled context")
+	}
+}
+
+func TestDiffLinesLinearSpace(t *testing.T) {
+	// Files differing on every line need the largest edit distance
+	a := make([]line, 3000)
+	b := make([]line, 3000)
+	for i := range a {
+		a[i] = line{compare: fmt.Sprintf("a%d", i)}
+		b[i] = line{compare: fmt.Sprintf("b%d", i)}
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	edits, err := diffLines(context.Background(), a, b)
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		t.Fatalf("diffLines() error = %v", err)
+	}
+	if len(edits) != len(a)+len(b) {
+		t.Errorf("Got %d edits, want %d", len(edits), len(a)+len(b))
+	}
+	// Keeping a frontier per edit step would allocate hundreds of megabytes
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 16<<20 {
+		t.Errorf("diffLines() allocated %d bytes, want O(N+M)", allocated)
+	}
+}
@@ -23,6 +23,13 @@ func ParseKV(kvPair string) (string, any, error) {
 
 	valueStr := strings.TrimSpace(parts[1])
 
+	// A double-quoted value stays a string, e.g. id="007"
+	if len(valueStr) >= 2 && strings.HasPrefix(valueStr, `"`) && strings.HasSuffix(valueStr, `"`) {
+		if unquoted, err := strconv.Unquote(valueStr); err == nil {
+			return key, unquoted, nil
+		}
+	}
+
 	// Try to parse as integer first (to avoid "1" being parsed as boolean true)
 	if intVal, err := strconv.Atoi(valueStr); err == nil {
 		return key, intVal, nil
@@ -43,6 +50,59 @@ func ParseKV(kvPair string) (string, any, error) {
 	return key, valueStr, nil
 }
 
+// SplitKey splits a dotted key such as student.meta.section into its path
+// A dot escaped with a backslash is part of the key name, e.g. file\.name.
+func SplitKey(key string) ([]string, error) {
+	var path []string
+	var segment strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch {
+		case key[i] == '\\' && i+1 < len(key) && key[i+1] == '.':
+			segment.WriteByte('.')
+			i++
+		case key[i] == '.':
+			path = append(path, segment.String())
+			segment.Reset()
+		default:
+			segment.WriteByte(key[i])
+		}
+	}
+	path = append(path, segment.String())
+
+	for _, name := range path {
+		if strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("empty segment in key %q", key)
+		}
+	}
+	return path, nil
+}
+
+// SetPath sets the value at a path of keys, creating the nested objects on the way
+// It fails if a key on the way already holds a value that is not an object.
+func SetPath(m map[string]any, path []string, value any) error {
+	for i, name := range path[:len(path)-1] {
+		next, exists := m[name]
+		if !exists {
+			child := make(map[string]any)
+			m[name] = child
+			m = child
+			continue
+		}
+		child, ok := next.(map[string]any)
+		if !ok {
+			return fmt.Errorf("cannot set %s: %s is not an object", strings.Join(path, "."), strings.Join(path[:i+1], "."))
+		}
+		m = child
+	}
+
+	last := path[len(path)-1]
+	if _, isObject := m[last].(map[string]any); isObject {
+		return fmt.Errorf("cannot set %s: it is an object", strings.Join(path, "."))
+	}
+	m[last] = value
+	return nil
+}
+
 // ParseJSON parses a JSON string into a map or other structure
 func ParseJSON(jsonStr string) (any, error) {
 	var result any
@@ -107,7 +167,7 @@ func ParseEnvWithPrefix(prefix string) map[string]any {
 }
 
 // MergeContexts merges multiple context sources with proper precedence
-// Later sources override earlier ones
+// Later sources override earlier ones; nested objects are merged key by key.
 func MergeContexts(contexts ...any) any {
 	result := make(map[string]any)
 
@@ -118,7 +178,7 @@ func MergeContexts(contexts ...any) any {
 
 		switch v := ctx.(type) {
 		case map[string]any:
-			maps.Copy(result, v)
+			mergeInto(result, v)
 		default:
 			// If it's not a map, return it as-is (could be array or primitive)
 			// This handles cases where --context provides a non-object JSON
@@ -134,6 +194,23 @@ func MergeContexts(contexts ...any) any {
 	return result
 }
 
+// mergeInto copies src into dst, merging objects present in both
+// Merged objects are copies, so neither source is modified.
+func mergeInto(dst, src map[string]any) {
+	for key, value := range src {
+		srcObject, srcIsObject := value.(map[string]any)
+		dstObject, dstIsObject := dst[key].(map[string]any)
+		if srcIsObject && dstIsObject {
+			merged := make(map[string]any, len(dstObject)+len(srcObject))
+			mergeInto(merged, dstObject)
+			mergeInto(merged, srcObject)
+			dst[key] = merged
+			continue
+		}
+		dst[key] = value
+	}
+}
+
 // BuildContext builds the final context from all sources
 func BuildContext(jsonStr string, kvPairs []string, filePath string) (any, error) {
 	return BuildContextWithPrefix("GHOST_CONTEXT", jsonStr, kvPairs, filePath)
@@ -166,7 +243,7 @@ func BuildContextWithPrefix(envPrefix, jsonStr string, kvPairs []string, filePat
 		contexts = append(contexts, jsonCtx)
 	}
 
-	// 4. Key-value pairs (highest priority)
+	// 4. Key-value pairs (highest priority); dotted keys build nested objects
 	if len(kvPairs) > 0 {
 		kvCtx := make(map[string]any)
 		for _, kv := range kvPairs {
@@ -174,7 +251,13 @@ func BuildContextWithPrefix(envPrefix, jsonStr string, kvPairs []string, filePat
 			if err != nil {
 				return nil, err
 			}
-			kvCtx[key] = value
+			path, err := SplitKey(key)
+			if err != nil {
+				return nil, err
+			}
+			if err := SetPath(kvCtx, path, value); err != nil {
+				return nil, err
+			}
 		}
 		contexts = append(contexts, kvCtx)
 	}
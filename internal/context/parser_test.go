@@ -102,6 +102,24 @@ func TestParseKV(t *testing.T) {
 			wantValue: "123abc",
 			wantErr:   false,
 		},
+		{
+			name:      "quoted number stays a string",
+			input:     `id="007"`,
+			wantKey:   "id",
+			wantValue: "007",
+		},
+		{
+			name:      "quoted boolean stays a string",
+			input:     `flag="true"`,
+			wantKey:   "flag",
+			wantValue: "true",
+		},
+		{
+			name:      "dotted key is kept whole",
+			input:     "student.meta.section=3",
+			wantKey:   "student.meta.section",
+			wantValue: 3,
+		},
 	}
 
 	for _, tt := range tests {
@@ -124,6 +142,55 @@ func TestParseKV(t *testing.T) {
 	}
 }
 
+func TestSplitKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		want    []string
+		wantErr bool
+	}{
+		{key: "name", want: []string{"name"}},
+		{key: "student.meta.section", want: []string{"student", "meta", "section"}},
+		{key: `file\.name`, want: []string{"file.name"}},
+		{key: `meta.file\.name`, want: []string{"meta", "file.name"}},
+		{key: `a\b`, want: []string{`a\b`}},
+		{key: "a..b", wantErr: true},
+		{key: ".a", wantErr: true},
+		{key: "a.", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := SplitKey(tt.key)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SplitKey(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSetPath(t *testing.T) {
+	m := map[string]any{}
+	if err := SetPath(m, []string{"student", "meta", "section"}, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetPath(m, []string{"student", "name"}, "Alice"); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"student": map[string]any{"meta": map[string]any{"section": 3}, "name": "Alice"}}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("SetPath() = %v, want %v", m, want)
+	}
+
+	if err := SetPath(m, []string{"student", "name", "first"}, "A"); err == nil {
+		t.Error("Expected an error setting a key below a value")
+	}
+	if err := SetPath(m, []string{"student", "meta"}, 1); err == nil {
+		t.Error("Expected an error replacing an object with a value")
+	}
+}
+
 func TestParseJSON(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -424,6 +491,22 @@ func TestMergeContexts(t *testing.T) {
 			},
 			want: "string value",
 		},
+		{
+			name: "nested objects merged",
+			contexts: []any{
+				map[string]any{"student": map[string]any{"name": "Alice", "meta": map[string]any{"section": 1}}},
+				map[string]any{"student": map[string]any{"meta": map[string]any{"section": 3}}},
+			},
+			want: map[string]any{"student": map[string]any{"name": "Alice", "meta": map[string]any{"section": 3}}},
+		},
+		{
+			name: "value replaces object",
+			contexts: []any{
+				map[string]any{"student": map[string]any{"name": "Alice"}},
+				map[string]any{"student": "Bob"},
+			},
+			want: map[string]any{"student": "Bob"},
+		},
 		{
 			name: "non-map ignored if maps present",
 			contexts: []any{
@@ -525,6 +608,27 @@ func TestBuildContext(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:    "dotted KV keys build nested objects",
+			kvPairs: []string{"student.meta.section=3", "student.name=Alice", `file\.name=a.txt`},
+			want: map[string]any{
+				"student":   map[string]any{"meta": map[string]any{"section": 3}, "name": "Alice"},
+				"file.name": "a.txt",
+			},
+		},
+		{
+			name:    "dotted KV keys merge into JSON",
+			jsonStr: `{"student": {"name": "Alice", "meta": {"section": 1, "room": "A"}}}`,
+			kvPairs: []string{"student.meta.section=3"},
+			want: map[string]any{
+				"student": map[string]any{"name": "Alice", "meta": map[string]any{"section": 3, "room": "A"}},
+			},
+		},
+		{
+			name:    "conflicting KV keys",
+			kvPairs: []string{"student=Alice", "student.name=Bob"},
+			wantErr: true,
+		},
 		{
 			name:    "invalid KV pair",
 			kvPairs: []string{"invalid"},
@@ -0,0 +1,155 @@
+// Package diagnostics classifies the messages a compiler or interpreter writes
+// on stderr, counting errors and warnings
+package diagnostics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Formats of stderr that can be classified
+const (
+	FormatGCC    = "gcc"    // gcc and clang, including the linker
+	FormatJavac  = "javac"  // javac
+	FormatPython = "python" // Python tracebacks and warnings
+)
+
+// Formats lists the supported formats
+var Formats = []string{FormatGCC, FormatJavac, FormatPython}
+
+// maxLineSize bounds the length of a line; longer lines are classified by their start
+const maxLineSize = 64 * 1024
+
+// Summary counts the diagnostics of a stderr file
+type Summary struct {
+	Errors     int
+	Warnings   int
+	FirstError string // the first error line, as printed
+}
+
+// Kinds of line a classifier recognizes
+const (
+	kindOther = iota
+	kindError
+	kindWarning
+)
+
+// classifier recognizes the error and warning lines of a format
+type classifier func(line string) int
+
+var (
+	// file:line:col: error: msg, or tool: error: msg for driver and linker errors
+	gccDiagnostic = regexp.MustCompile(`^(?:[^:\s][^:]*:(?:\d+:){0,2} )?(fatal error|error|warning): `)
+	// main.c:(.text+0x5): undefined reference to `f', reported by the linker
+	gccLinkError = regexp.MustCompile(`: undefined reference to `)
+
+	// File.java:3: error: msg, or warning: [options] msg
+	javacDiagnostic = regexp.MustCompile(`^(?:[^:\s][^:]*\.java:\d+: )?(error|warning): `)
+
+	// ZeroDivisionError: division by zero, as the last line of a traceback
+	pythonException = regexp.MustCompile(`^[A-Za-z_][\w.]*(?:Error|Exception|Interrupt|Exit)(?::|$)`)
+	// file.py:3: DeprecationWarning: msg
+	pythonWarning = regexp.MustCompile(`^[^:\s][^:]*:\d+: \w*Warning: `)
+)
+
+var classifiers = map[string]classifier{
+	FormatGCC: func(line string) int {
+		if gccLinkError.MatchString(line) {
+			return kindError
+		}
+		return severity(gccDiagnostic.FindStringSubmatch(line))
+	},
+	FormatJavac: func(line string) int {
+		return severity(javacDiagnostic.FindStringSubmatch(line))
+	},
+	FormatPython: func(line string) int {
+		switch {
+		case pythonWarning.MatchString(line):
+			return kindWarning
+		case pythonException.MatchString(line):
+			return kindError
+		}
+		return kindOther
+	},
+}
+
+// severity returns the kind of a diagnostic whose severity is the first submatch
+func severity(match []string) int {
+	switch {
+	case match == nil:
+		return kindOther
+	case match[1] == "warning":
+		return kindWarning
+	}
+	return kindError
+}
+
+// Validate checks that a format is supported
+func Validate(format string) error {
+	if !slices.Contains(Formats, format) {
+		return fmt.Errorf("invalid stderr format %q (must be one of %s)", format, strings.Join(Formats, ", "))
+	}
+	return nil
+}
+
+// Classify counts the errors and warnings in stderr output of a format
+func Classify(r io.Reader, format string) (*Summary, error) {
+	if err := Validate(format); err != nil {
+		return nil, err
+	}
+	classify := classifiers[format]
+
+	summary := &Summary{}
+	reader := bufio.NewReaderSize(r, maxLineSize)
+	for {
+		line, err := readLine(reader)
+		if line != "" {
+			switch classify(line) {
+			case kindError:
+				if summary.Errors == 0 {
+					summary.FirstError = line
+				}
+				summary.Errors++
+			case kindWarning:
+				summary.Warnings++
+			}
+		}
+		if err == io.EOF {
+			return summary, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ClassifyFile counts the errors and warnings in a stderr file
+func ClassifyFile(path, format string) (*Summary, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	summary, err := Classify(f, format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to classify %s: %w", path, err)
+	}
+	return summary, nil
+}
+
+// readLine returns the next line without its line ending, cut at maxLineSize
+// The rest of a longer line is skipped.
+func readLine(r *bufio.Reader) (string, error) {
+	line, isPrefix, err := r.ReadLine()
+	text := string(line)
+	for isPrefix && err == nil {
+		_, isPrefix, err = r.ReadLine()
+	}
+	return strings.TrimRight(text, "\r"), err
+}
@@ -0,0 +1,108 @@
+package diagnostics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		stderr string
+		want   Summary
+	}{
+		{
+			name:   "gcc",
+			format: FormatGCC,
+			stderr: `main.c: In function 'main':
+main.c:3:5: warning: unused variable 'x' [-Wunused-variable]
+    3 |     int x;
+      |     ^
+main.c:4:12: error: expected ';' before '}' token
+main.c:2:1: note: declared here
+main.c:7: warning: no column
+`,
+			want: Summary{Errors: 1, Warnings: 2, FirstError: "main.c:4:12: error: expected ';' before '}' token"},
+		},
+		{
+			name:   "gcc linker",
+			format: FormatGCC,
+			stderr: "/usr/bin/ld: /tmp/cc.o: in function `main':\r\nmain.c:(.text+0x5): undefined reference to `f'\r\ncollect2: error: ld returned 1 exit status\r\n",
+			want:   Summary{Errors: 2, FirstError: "main.c:(.text+0x5): undefined reference to `f'"},
+		},
+		{
+			name:   "gcc driver",
+			format: FormatGCC,
+			stderr: "cc1: fatal error: missing.c: No such file or directory",
+			want:   Summary{Errors: 1, FirstError: "cc1: fatal error: missing.c: No such file or directory"},
+		},
+		{
+			name:   "clean",
+			format: FormatGCC,
+			stderr: "",
+		},
+		{
+			name:   "javac",
+			format: FormatJavac,
+			stderr: `warning: [options] bootstrap class path not set in conjunction with -source 8
+Main.java:3: error: cannot find symbol
+        foo();
+        ^
+  symbol:   method foo()
+Main.java:5: warning: [deprecation] stop() in Thread has been deprecated
+Main.java:9: error: ';' expected
+2 errors
+2 warnings
+`,
+			want: Summary{Errors: 2, Warnings: 2, FirstError: "Main.java:3: error: cannot find symbol"},
+		},
+		{
+			name:   "python",
+			format: FormatPython,
+			stderr: `/app/main.py:3: DeprecationWarning: the imp module is deprecated
+  import imp
+Traceback (most recent call last):
+  File "/app/main.py", line 7, in <module>
+    print(1 / 0)
+ZeroDivisionError: division by zero
+`,
+			want: Summary{Errors: 1, Warnings: 1, FirstError: "ZeroDivisionError: division by zero"},
+		},
+		{
+			name:   "python interrupted",
+			format: FormatPython,
+			stderr: "Traceback (most recent call last):\n  File \"x.py\", line 1\nKeyboardInterrupt\n",
+			want:   Summary{Errors: 1, FirstError: "KeyboardInterrupt"},
+		},
+		{
+			name:   "long line",
+			format: FormatGCC,
+			stderr: "a.c:1:1: error: " + strings.Repeat("x", 2*maxLineSize) + "\nb.c:1:1: warning: w\n",
+			want:   Summary{Errors: 1, Warnings: 1, FirstError: "a.c:1:1: error: " + strings.Repeat("x", maxLineSize-len("a.c:1:1: error: "))},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Classify(strings.NewReader(tt.stderr), tt.format)
+			if err != nil {
+				t.Fatalf("Classify() error = %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("Classify() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, format := range Formats {
+		if err := Validate(format); err != nil {
+			t.Errorf("Validate(%q) error = %v", format, err)
+		}
+	}
+	if err := Validate("rustc"); err == nil || !strings.Contains(err.Error(), "must be one of gcc, javac, python") {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package environment
+
+import (
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Info describes the environment a command ran in
+type Info struct {
+	Variables  map[string]string // Selected environment variables
+	OS         string
+	Arch       string
+	Kernel     string // Kernel name and release (uname -sr), empty if unavailable
+	Machine    string // Hardware name (uname -m), empty if unavailable
+	Hostname   string
+	WorkingDir string
+}
+
+// Capture records the host details and the environment variables whose names
+// start with one of the prefixes
+// The prefix "*" selects every variable. No variables are recorded without prefixes.
+func Capture(prefixes []string) *Info {
+	info := &Info{
+		Variables: Variables(os.Environ(), prefixes),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+	info.Kernel, info.Machine = uname()
+	info.Hostname, _ = os.Hostname()
+	info.WorkingDir, _ = os.Getwd()
+	return info
+}
+
+// Variables selects the entries of environ (in "KEY=value" form) whose names
+// start with one of the prefixes
+func Variables(environ []string, prefixes []string) map[string]string {
+	selected := make(map[string]string)
+	for _, entry := range environ {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || name == "" {
+			continue
+		}
+		if matchesPrefix(name, prefixes) {
+			selected[name] = value
+		}
+	}
+	return selected
+}
+
+func matchesPrefix(name string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSpace(prefix)
+		if prefix == "" {
+			continue
+		}
+		if prefix == "*" || strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package environment
+
+import (
+	"os"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestVariables(t *testing.T) {
+	environ := []string{"CI=true", "CI_JOB_ID=42", "GHOST_MODE=strict", "HOME=/root", "EMPTY=", "=C:=C:\\"}
+
+	tests := []struct {
+		name     string
+		prefixes []string
+		want     map[string]string
+	}{
+		{
+			name:     "no prefixes",
+			prefixes: nil,
+			want:     map[string]string{},
+		},
+		{
+			name:     "prefix match",
+			prefixes: []string{"CI"},
+			want:     map[string]string{"CI": "true", "CI_JOB_ID": "42"},
+		},
+		{
+			name:     "multiple prefixes",
+			prefixes: []string{"GHOST_", " EMPTY"},
+			want:     map[string]string{"GHOST_MODE": "strict", "EMPTY": ""},
+		},
+		{
+			name:     "all variables",
+			prefixes: []string{"*"},
+			want:     map[string]string{"CI": "true", "CI_JOB_ID": "42", "GHOST_MODE": "strict", "HOME": "/root", "EMPTY": ""},
+		},
+		{
+			name:     "empty prefix ignored",
+			prefixes: []string{""},
+			want:     map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Variables(environ, tt.prefixes)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Variables() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCapture(t *testing.T) {
+	t.Setenv("GHOST_TEST_RECORD", "yes")
+
+	info := Capture([]string{"GHOST_TEST_"})
+	if info.Variables["GHOST_TEST_RECORD"] != "yes" || len(info.Variables) != 1 {
+		t.Errorf("Variables = %v, want only GHOST_TEST_RECORD", info.Variables)
+	}
+	if info.OS != runtime.GOOS || info.Arch != runtime.GOARCH {
+		t.Errorf("OS/Arch = %s/%s, want %s/%s", info.OS, info.Arch, runtime.GOOS, runtime.GOARCH)
+	}
+	if wd, _ := os.Getwd(); info.WorkingDir != wd {
+		t.Errorf("WorkingDir = %q, want %q", info.WorkingDir, wd)
+	}
+	if runtime.GOOS == "linux" && info.Kernel == "" {
+		t.Error("Expected kernel to be recorded on Linux")
+	}
+}
@@ -0,0 +1,68 @@
+package environment
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// variableName matches portable environment variable names
+var variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Normalization pins the parts of a command's environment that make its output
+// differ between hosts, such as the locale, timezone and random seeds
+type Normalization struct {
+	Locale   string            // LANG and LC_ALL ("" = inherited)
+	Timezone string            // TZ ("" = inherited)
+	Seeds    map[string]string // Further variables, e.g. RANDOM_SEED=42
+}
+
+// NewNormalization validates the locale, timezone and KEY=VALUE seed variables
+// It returns nil if nothing is normalized.
+func NewNormalization(locale, timezone string, seeds []string) (*Normalization, error) {
+	if locale == "" && timezone == "" && len(seeds) == 0 {
+		return nil, nil
+	}
+	for flag, value := range map[string]string{"locale": locale, "timezone": timezone} {
+		if strings.ContainsAny(value, "= \t\n") {
+			return nil, fmt.Errorf("invalid %s %q", flag, value)
+		}
+	}
+	n := &Normalization{Locale: locale, Timezone: timezone}
+	for _, entry := range seeds {
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok || !variableName.MatchString(name) {
+			return nil, fmt.Errorf("invalid seed variable %q: want NAME=VALUE", entry)
+		}
+		if n.Seeds == nil {
+			n.Seeds = make(map[string]string)
+		}
+		n.Seeds[name] = value
+	}
+	return n, nil
+}
+
+// Environ returns the variables to add to the command's environment, in
+// "KEY=value" form and a stable order
+func (n *Normalization) Environ() []string {
+	if n == nil {
+		return nil
+	}
+	var env []string
+	if n.Locale != "" {
+		env = append(env, "LANG="+n.Locale, "LC_ALL="+n.Locale)
+	}
+	if n.Timezone != "" {
+		env = append(env, "TZ="+n.Timezone)
+	}
+	names := make([]string, 0, len(n.Seeds))
+	for name := range n.Seeds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		env = append(env, name+"="+n.Seeds[name])
+	}
+	return env
+}
@@ -0,0 +1,51 @@
+package environment
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewNormalization(t *testing.T) {
+	tests := []struct {
+		name     string
+		locale   string
+		timezone string
+		seeds    []string
+		want     []string
+		wantErr  string
+	}{
+		{name: "nothing normalized"},
+		{
+			name:     "locale, timezone and seeds",
+			locale:   "C",
+			timezone: "UTC",
+			seeds:    []string{"RANDOM_SEED=42", "PYTHONHASHSEED=0"},
+			want:     []string{"LANG=C", "LC_ALL=C", "TZ=UTC", "PYTHONHASHSEED=0", "RANDOM_SEED=42"},
+		},
+		{name: "empty seed value", seeds: []string{"SEED="}, want: []string{"SEED="}},
+		{name: "last seed wins", seeds: []string{"SEED=1", "SEED=2"}, want: []string{"SEED=2"}},
+		{name: "seed without value", seeds: []string{"RANDOM_SEED"}, wantErr: `invalid seed variable "RANDOM_SEED"`},
+		{name: "invalid seed name", seeds: []string{"1SEED=4"}, wantErr: "want NAME=VALUE"},
+		{name: "invalid locale", locale: "LANG=C", wantErr: `invalid locale "LANG=C"`},
+		{name: "invalid timezone", timezone: "Europe/Some Where", wantErr: "invalid timezone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := NewNormalization(tt.locale, tt.timezone, tt.seeds)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("NewNormalization() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewNormalization() error = %v", err)
+			}
+			if got := n.Environ(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Environ() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
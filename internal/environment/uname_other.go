@@ -0,0 +1,8 @@
+//go:build !unix
+
+package environment
+
+// uname is not available on this platform
+func uname() (kernel, machine string) {
+	return "", ""
+}
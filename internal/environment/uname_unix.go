@@ -0,0 +1,15 @@
+//go:build unix
+
+package environment
+
+import "golang.org/x/sys/unix"
+
+// uname returns the kernel name and release and the hardware name
+func uname() (kernel, machine string) {
+	var buf unix.Utsname
+	if err := unix.Uname(&buf); err != nil {
+		return "", ""
+	}
+	return unix.ByteSliceToString(buf.Sysname[:]) + " " + unix.ByteSliceToString(buf.Release[:]),
+		unix.ByteSliceToString(buf.Machine[:])
+}
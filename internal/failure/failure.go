@@ -0,0 +1,131 @@
+package failure
+
+import (
+	"errors"
+	"strings"
+)
+
+// Code classifies why ghost itself failed, as reported in error_code
+type Code string
+
+// Error codes reported in the JSON error object
+const (
+	Usage                Code = "USAGE_ERROR"            // unknown command, unknown or invalid flag, missing required flag
+	ConfigInvalid        Code = "CONFIG_INVALID"         // --config, --policy, --weights or --rubric cannot be read or is invalid
+	InputNotFound        Code = "INPUT_NOT_FOUND"        // the input, result or manifest file to read does not exist
+	ContextInvalid       Code = "CONTEXT_INVALID"        // --context, --context-kv or --context-file
+	UploadConfigInvalid  Code = "UPLOAD_CONFIG_INVALID"  // upload provider, config, retry, encryption or file settings
+	UploadFailed         Code = "UPLOAD_FAILED"          // an upload failed with --upload-fail-policy error
+	DownloadFailed       Code = "DOWNLOAD_FAILED"        // ghost fetch could not download a file (the report is printed instead)
+	WebhookConfigInvalid Code = "WEBHOOK_CONFIG_INVALID" // webhook flags or config
+	ExecutionFailed      Code = "EXECUTION_FAILED"       // the command could not be started or supervised
+	ScoreFailed          Code = "SCORE_FAILED"           // --score-expr or --score-command
+	ResultFileFailed     Code = "RESULT_FILE_FAILED"     // --result-file could not be written or uploaded
+	ValidationFailed     Code = "VALIDATION_FAILED"      // ghost validate found invalid files
+	ChecksumMismatch     Code = "CHECKSUM_MISMATCH"      // ghost checksum found mismatched or missing files
+	ReplayMismatch       Code = "REPLAY_MISMATCH"        // ghost replay did not reproduce the stored result
+	ResultMismatch       Code = "RESULT_MISMATCH"        // ghost result compare found changed fields
+	Internal             Code = "INTERNAL_ERROR"         // anything not classified above
+)
+
+// Exit codes of ghost itself, taken from sysexits.h so they stay clear of the
+// exit codes most commands use
+const (
+	ExitFailed   = 1  // validate, checksum, replay or result compare found problems (their report says which)
+	ExitUsage    = 64 // EX_USAGE
+	ExitNoInput  = 66 // EX_NOINPUT
+	ExitSoftware = 70 // EX_SOFTWARE
+	ExitOSError  = 71 // EX_OSERR
+	ExitIOError  = 74 // EX_IOERR
+	ExitConfig   = 78 // EX_CONFIG
+)
+
+// exitCodes maps error codes to the exit code ghost terminates with
+var exitCodes = map[Code]int{
+	Usage:                ExitUsage,
+	ConfigInvalid:        ExitConfig,
+	InputNotFound:        ExitNoInput,
+	ContextInvalid:       ExitConfig,
+	UploadConfigInvalid:  ExitConfig,
+	UploadFailed:         ExitIOError,
+	DownloadFailed:       ExitIOError,
+	WebhookConfigInvalid: ExitConfig,
+	ExecutionFailed:      ExitOSError,
+	ScoreFailed:          ExitSoftware,
+	ResultFileFailed:     ExitIOError,
+	ValidationFailed:     ExitFailed,
+	ChecksumMismatch:     ExitFailed,
+	ReplayMismatch:       ExitFailed,
+	ResultMismatch:       ExitFailed,
+	Internal:             ExitSoftware,
+}
+
+// ExitCode returns the exit code for a failed ghost invocation
+func ExitCode(err error) int {
+	return exitCodes[CodeOf(err)]
+}
+
+// Error is an error with the code it is reported under
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Wrap attaches a code to err; nil stays nil
+// An error that already has a code keeps it, since the innermost code is the most specific.
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	var coded *Error
+	if errors.As(err, &coded) {
+		return err
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// CodeOf returns the code attached to err, or Internal if there is none
+func CodeOf(err error) Code {
+	var coded *Error
+	if errors.As(err, &coded) {
+		return coded.Code
+	}
+	if isUsageError(err) {
+		return Usage
+	}
+	return Internal
+}
+
+// Prefixes of the errors cobra returns for invalid command lines
+var usagePrefixes = []string{
+	"unknown command",
+	"unknown flag",
+	"unknown shorthand flag",
+	"flag needs an argument",
+	"invalid argument",
+	"required flag(s)",
+	"if any flags in the group",
+	"at least one of the flags in the group",
+	"accepts ",
+	"requires at least",
+	"requires at most",
+}
+
+// isUsageError recognises cobra's command line errors, which cannot be wrapped
+func isUsageError(err error) bool {
+	msg := err.Error()
+	for _, prefix := range usagePrefixes {
+		if strings.HasPrefix(msg, prefix) {
+			return true
+		}
+	}
+	return false
+}
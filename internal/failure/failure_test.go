@@ -0,0 +1,76 @@
+package failure
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWrap(t *testing.T) {
+	if Wrap(Usage, nil) != nil {
+		t.Error("Wrap(nil) should return nil")
+	}
+
+	base := errors.New("boom")
+	err := Wrap(UploadFailed, base)
+	if err.Error() != "boom" {
+		t.Errorf("Error() = %q, want the wrapped message", err.Error())
+	}
+	if !errors.Is(err, base) {
+		t.Error("wrapped error should unwrap to the original")
+	}
+
+	// The innermost code is kept
+	outer := Wrap(Internal, fmt.Errorf("context: %w", Wrap(InputNotFound, base)))
+	if got := CodeOf(outer); got != InputNotFound {
+		t.Errorf("CodeOf(rewrapped) = %s, want %s", got, InputNotFound)
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{name: "wrapped", err: Wrap(ContextInvalid, errors.New("bad json")), want: ContextInvalid},
+		{name: "wrapped with fmt", err: fmt.Errorf("outer: %w", Wrap(ScoreFailed, errors.New("x"))), want: ScoreFailed},
+		{name: "cobra unknown command", err: errors.New(`unknown command "rn" for "ghost"`), want: Usage},
+		{name: "cobra required flag", err: errors.New(`required flag(s) "input" not set`), want: Usage},
+		{name: "cobra flag group", err: errors.New("if any flags in the group [a b] are set none of the others can be; [a b] were all set"), want: Usage},
+		{name: "unclassified", err: errors.New("something else"), want: Internal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeOf(tt.err); got != tt.want {
+				t.Errorf("CodeOf() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{err: errors.New(`unknown command "rn" for "ghost"`), want: ExitUsage},
+		{err: Wrap(InputNotFound, errors.New("x")), want: ExitNoInput},
+		{err: Wrap(WebhookConfigInvalid, errors.New("x")), want: ExitConfig},
+		{err: Wrap(ChecksumMismatch, errors.New("x")), want: ExitFailed},
+		{err: errors.New("anything"), want: ExitSoftware},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+
+	// Every code has an exit code
+	for _, code := range []Code{Usage, ConfigInvalid, InputNotFound, ContextInvalid, UploadConfigInvalid, UploadFailed, DownloadFailed,
+		WebhookConfigInvalid, ExecutionFailed, ScoreFailed, ResultFileFailed, ValidationFailed, ChecksumMismatch, ReplayMismatch, Internal} {
+		if exitCodes[code] == 0 {
+			t.Errorf("no exit code for %s", code)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Package human renders results as short summaries for people at a terminal,
+// colored unless NO_COLOR or the terminal says otherwise
+package human
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+// ANSI escape sequences
+const (
+	reset  = "\033[0m"
+	bold   = "\033[1m"
+	dim    = "\033[2m"
+	red    = "\033[31m"
+	green  = "\033[32m"
+	yellow = "\033[33m"
+)
+
+// ColorEnabled reports whether colors should be written to f
+// Colors are off if NO_COLOR is set to anything (https://no-color.org), TERM is
+// unset or dumb, or f is not a terminal.
+func ColorEnabled(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if term := os.Getenv("TERM"); term == "" || term == "dumb" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// painter wraps text in escape sequences when colors are enabled
+type painter bool
+
+func (p painter) paint(style, text string) string {
+	if !p {
+		return text
+	}
+	return style + text + reset
+}
+
+// mark returns a colored check mark or cross
+func (p painter) mark(passed bool) string {
+	if passed {
+		return p.paint(green, "✓")
+	}
+	return p.paint(red, "✗")
+}
+
+// WriteResult writes a summary of a result: the outcome with the command and
+// its execution time, then the details that are present, one per line
+func WriteResult(w io.Writer, result *output.Result, color bool) error {
+	p := painter(color)
+	var b strings.Builder
+
+	label, style := statusLabel(result)
+	fmt.Fprintf(&b, "%s  %s  %s\n", p.paint(bold+style, label), result.Command, p.paint(dim, formatMillis(result.ExecutionTime)))
+
+	field := func(name, format string, args ...any) {
+		fmt.Fprintf(&b, "  %s %s\n", p.paint(dim, fmt.Sprintf("%-9s", name)), fmt.Sprintf(format, args...))
+	}
+
+	if result.Score != nil {
+		score := result.Score.String()
+		if result.Rubric != nil {
+			score += " / " + result.Rubric.Max.String()
+		}
+		field("score", "%s", p.paint(bold, score))
+	}
+	if result.Feedback != "" {
+		field("feedback", "%s", result.Feedback)
+	}
+	if result.ExitCode != 0 {
+		field("exit code", "%s", exitDetails(result))
+	}
+	if result.Timeout != nil {
+		field("timeout", "%s", formatMillis(*result.Timeout))
+	}
+	if result.PolicyViolation != "" {
+		field("policy", "%s", result.PolicyViolation)
+	}
+	if result.ResourceExceeded != "" {
+		field("limit", "%s exceeded", result.ResourceExceeded)
+	}
+	if result.Expected != nil {
+		expected := *result.Expected
+		if result.MatchedExpected != "" {
+			expected = result.MatchedExpected
+		}
+		field("expected", "%s", expected)
+	}
+	field("output", "%s", result.Output)
+	field("stderr", "%s", result.Stderr)
+
+	if result.Rubric != nil {
+		for _, c := range result.Rubric.Criteria {
+			line := fmt.Sprintf("%s %s %s/%s", p.mark(c.Passed), c.Name, c.Points, c.Max)
+			if c.Reason != "" {
+				line += p.paint(dim, " ("+c.Reason+")")
+			}
+			field("criterion", "%s", line)
+		}
+	}
+	for _, step := range result.Steps {
+		field("step", "%s %s %s", p.mark(step.Status == "success"), step.Command, p.paint(dim, step.Status+", "+formatMillis(step.ExecutionTime)))
+	}
+	for _, file := range result.Files {
+		if file.Status != "match" {
+			field("file", "%s %s %s", p.mark(false), file.Path, p.paint(dim, file.Status))
+		}
+	}
+	for _, pattern := range result.Patterns {
+		field(pattern.Kind, "%s %s", p.mark(pattern.Passed), pattern.Pattern)
+	}
+
+	if len(result.Uploads) > 0 {
+		uploaded := 0
+		for _, u := range result.Uploads {
+			if u.Success {
+				uploaded++
+			}
+		}
+		field("uploads", "%s %d of %d files uploaded", p.mark(uploaded == len(result.Uploads)), uploaded, len(result.Uploads))
+	}
+	switch {
+	case result.WebhookError != "":
+		field("webhook", "%s %s", p.mark(false), result.WebhookError)
+	case result.WebhookSpooled:
+		field("webhook", "spooled")
+	case result.WebhookSent:
+		field("webhook", "%s sent", p.mark(true))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// statusLabel returns the headline of a result and its color
+func statusLabel(result *output.Result) (string, string) {
+	switch {
+	case result.Plan != nil:
+		return "DRY RUN", yellow
+	case result.Status == "success":
+		return "PASS", green
+	case result.Status == "failed":
+		return "FAIL", red
+	case result.Status == "timeout":
+		return "TIMEOUT", yellow
+	}
+	return strings.ToUpper(strings.ReplaceAll(result.Status, "_", " ")), red
+}
+
+// exitDetails describes how the command exited
+func exitDetails(result *output.Result) string {
+	details := fmt.Sprint(result.ExitCode)
+	if result.Signal != "" {
+		details += ", killed by " + result.Signal
+	}
+	if result.CoreDumped {
+		details += " (core dumped)"
+	}
+	if result.OOMKilled {
+		details += ", out of memory"
+	}
+	if result.Deadline != "" {
+		details += ", " + result.Deadline + " deadline"
+	}
+	return details
+}
+
+// formatMillis renders a duration given in milliseconds, e.g. 850ms or 1.25s
+func formatMillis(ms int64) string {
+	if ms < 1000 {
+		return fmt.Sprintf("%dms", ms)
+	}
+	return (time.Duration(ms) * time.Millisecond).Round(10 * time.Millisecond).String()
+}
+
+// WriteDiff writes the first maxLines lines of a unified diff, indented, with
+// added lines in green and removed ones in red
+func WriteDiff(w io.Writer, diff string, maxLines int, color bool) error {
+	p := painter(color)
+	var b strings.Builder
+
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	for i, line := range lines {
+		if i == maxLines {
+			fmt.Fprintf(&b, "    %s\n", p.paint(dim, fmt.Sprintf("... %d more lines", len(lines)-maxLines)))
+			break
+		}
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"), strings.HasPrefix(line, "@@"):
+			line = p.paint(dim, line)
+		case strings.HasPrefix(line, "+"):
+			line = p.paint(green, line)
+		case strings.HasPrefix(line, "-"):
+			line = p.paint(red, line)
+		}
+		fmt.Fprintf(&b, "    %s\n", line)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
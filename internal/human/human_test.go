@@ -0,0 +1,165 @@
+package human
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+func TestWriteResult(t *testing.T) {
+	score := decimal.NewFromInt(5)
+	expected := "expected.txt"
+	timeout := int64(2000)
+
+	tests := []struct {
+		name   string
+		result output.Result
+		want   string
+	}{
+		{
+			name: "pass with score",
+			result: output.Result{
+				Command: "./solution", Status: "success", ExecutionTime: 12,
+				Output: "out.txt", Stderr: "err.txt", Score: &score, WebhookSent: true,
+			},
+			want: `PASS  ./solution  12ms
+  score     5
+  output    out.txt
+  stderr    err.txt
+  webhook   ✓ sent
+`,
+		},
+		{
+			name: "failed diff",
+			result: output.Result{
+				Command: "diff out.txt expected.txt", Status: "failed", ExitCode: 1, ExecutionTime: 1500,
+				Expected: &expected, Output: "diff.txt", Stderr: "err.txt",
+				Uploads: []output.UploadResult{{Remote: "a", Success: true}, {Remote: "b"}},
+			},
+			want: `FAIL  diff out.txt expected.txt  1.5s
+  exit code 1
+  expected  expected.txt
+  output    diff.txt
+  stderr    err.txt
+  uploads   ✗ 1 of 2 files uploaded
+`,
+		},
+		{
+			name: "crash with rubric",
+			result: output.Result{
+				Command: "./crash", Status: "failed", ExitCode: -1, Signal: "SIGSEGV", CoreDumped: true,
+				Timeout: &timeout, Output: "out.txt", Stderr: "err.txt", Score: &score,
+				Rubric: &output.RubricResult{
+					Max: decimal.NewFromInt(10),
+					Criteria: []output.CriterionResult{
+						{Name: "compiles", Passed: true, Points: decimal.NewFromInt(5), Max: decimal.NewFromInt(5)},
+						{Name: "output", Points: decimal.Zero, Max: decimal.NewFromInt(5), Reason: "exit code -1"},
+					},
+				},
+			},
+			want: `FAIL  ./crash  0ms
+  score     5 / 10
+  exit code -1, killed by SIGSEGV (core dumped)
+  timeout   2s
+  output    out.txt
+  stderr    err.txt
+  criterion ✓ compiles 5/5
+  criterion ✗ output 0/5 (exit code -1)
+`,
+		},
+		{
+			name: "policy violation",
+			result: output.Result{
+				Command: "rm -rf /", Status: "policy_violation", ExitCode: -1,
+				PolicyViolation: "rm is not allowed", Output: "out.txt", Stderr: "err.txt",
+			},
+			want: `POLICY VIOLATION  rm -rf /  0ms
+  exit code -1
+  policy    rm is not allowed
+  output    out.txt
+  stderr    err.txt
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := WriteResult(&b, &tt.result, false); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("WriteResult() =\n%s\nwant:\n%s", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteResultColor(t *testing.T) {
+	var b strings.Builder
+	result := output.Result{Command: "true", Status: "success"}
+	if err := WriteResult(&b, &result, true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(b.String(), bold+green+"PASS"+reset) {
+		t.Errorf("WriteResult() = %q, want a green PASS", b.String())
+	}
+}
+
+func TestColorEnabled(t *testing.T) {
+	// A regular file is never a terminal
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm-256color")
+	if ColorEnabled(f) {
+		t.Error("ColorEnabled() = true for a regular file")
+	}
+
+	// Colors are off before the terminal is checked
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		t.Skip("no terminal")
+	}
+	defer func() { _ = tty.Close() }()
+	if !ColorEnabled(tty) {
+		t.Error("ColorEnabled() = false for a terminal")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if ColorEnabled(tty) {
+		t.Error("ColorEnabled() = true with NO_COLOR set")
+	}
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "dumb")
+	if ColorEnabled(tty) {
+		t.Error("ColorEnabled() = true with TERM=dumb")
+	}
+}
+
+func TestWriteDiff(t *testing.T) {
+	diff := "--- output.txt\n+++ expected.txt\n@@ -1,3 +1,3 @@\n 1\n-2\n+3\n 4\n"
+
+	var b strings.Builder
+	if err := WriteDiff(&b, diff, 5, false); err != nil {
+		t.Fatal(err)
+	}
+	want := "    --- output.txt\n    +++ expected.txt\n    @@ -1,3 +1,3 @@\n     1\n    -2\n    ... 2 more lines\n"
+	if b.String() != want {
+		t.Errorf("WriteDiff() =\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	b.Reset()
+	if err := WriteDiff(&b, diff, 10, true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), red+"-2"+reset) || !strings.Contains(b.String(), green+"+3"+reset) {
+		t.Errorf("WriteDiff() = %q, want colored changes", b.String())
+	}
+}
@@ -0,0 +1,148 @@
+package linefilter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Filter actions
+const (
+	ActionDrop   = "drop"   // Remove the whole line
+	ActionRedact = "redact" // Replace each match with Redacted
+	ActionStrip  = "strip"  // Remove each match
+)
+
+// Redacted replaces matches of redact rules
+const Redacted = "***REDACTED***"
+
+// maxLineLength bounds the bytes buffered while waiting for a newline
+// Longer lines are filtered in chunks of this size.
+const maxLineLength = 1 << 20
+
+// Rule is a single filter applied to each line
+type Rule struct {
+	Action  string
+	Pattern *regexp.Regexp
+}
+
+// Parse parses a rule of the form action:regex
+func Parse(spec string) (Rule, error) {
+	action, pattern, ok := strings.Cut(spec, ":")
+	if !ok || pattern == "" {
+		return Rule{}, fmt.Errorf("invalid filter %q: expected action:regex", spec)
+	}
+	switch action {
+	case ActionDrop, ActionRedact, ActionStrip:
+	default:
+		return Rule{}, fmt.Errorf("invalid filter action %q: must be %s, %s or %s", action, ActionDrop, ActionRedact, ActionStrip)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid filter regex %q: %w", pattern, err)
+	}
+	return Rule{Action: action, Pattern: re}, nil
+}
+
+// ParseAll parses a list of rules, applied in order
+func ParseAll(specs []string) ([]Rule, error) {
+	rules := make([]Rule, 0, len(specs))
+	for _, spec := range specs {
+		rule, err := Parse(spec)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Apply filters a single line without its newline
+// It returns false if a drop rule matched, otherwise the redacted and stripped line.
+func Apply(rules []Rule, line []byte) ([]byte, bool) {
+	for _, rule := range rules {
+		switch rule.Action {
+		case ActionDrop:
+			if rule.Pattern.Match(line) {
+				return nil, false
+			}
+		case ActionRedact:
+			line = rule.Pattern.ReplaceAllLiteral(line, []byte(Redacted))
+		case ActionStrip:
+			line = rule.Pattern.ReplaceAllLiteral(line, nil)
+		}
+	}
+	return line, true
+}
+
+// Writer applies rules line by line before writing to the underlying writer
+// Partial lines are buffered until their newline arrives; call Flush once the
+// last write is done to filter and write any trailing partial line. A Writer is
+// safe for concurrent use, e.g. as the shared stderr of pipeline steps.
+type Writer struct {
+	mu      sync.Mutex
+	w       io.Writer
+	rules   []Rule
+	pending []byte
+}
+
+// NewWriter wraps w so everything written is filtered by rules
+func NewWriter(w io.Writer, rules []Rule) *Writer {
+	return &Writer{w: w, rules: rules}
+}
+
+// Write filters every complete line in p
+func (f *Writer) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.pending = append(f.pending, p...)
+	for {
+		i := bytes.IndexByte(f.pending, '\n')
+		if i < 0 {
+			break
+		}
+		if err := f.emit(f.pending[:i], true); err != nil {
+			return 0, err
+		}
+		f.pending = f.pending[i+1:]
+	}
+
+	for len(f.pending) >= maxLineLength {
+		if err := f.emit(f.pending[:maxLineLength], false); err != nil {
+			return 0, err
+		}
+		f.pending = f.pending[maxLineLength:]
+	}
+	return len(p), nil
+}
+
+// Flush filters and writes a trailing line without a newline
+func (f *Writer) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.pending) == 0 {
+		return nil
+	}
+	err := f.emit(f.pending, false)
+	f.pending = nil
+	return err
+}
+
+func (f *Writer) emit(line []byte, newline bool) error {
+	filtered, keep := Apply(f.rules, line)
+	if !keep {
+		return nil
+	}
+	if newline {
+		// Copy rather than append into the pending buffer the line may alias
+		filtered = append(filtered[:len(filtered):len(filtered)], '\n')
+	}
+	_, err := f.w.Write(filtered)
+	return err
+}
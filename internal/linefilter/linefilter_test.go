@@ -0,0 +1,133 @@
+package linefilter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       string
+		wantAction string
+		wantErr    string
+	}{
+		{name: "drop", spec: "drop:^DEBUG", wantAction: ActionDrop},
+		{name: "redact", spec: "redact:0x[0-9a-f]+", wantAction: ActionRedact},
+		{name: "strip with colon in regex", spec: `strip:\d{2}:\d{2}`, wantAction: ActionStrip},
+		{name: "missing action", spec: "^DEBUG", wantErr: "expected action:regex"},
+		{name: "empty regex", spec: "drop:", wantErr: "expected action:regex"},
+		{name: "unknown action", spec: "keep:x", wantErr: "invalid filter action"},
+		{name: "bad regex", spec: "drop:(", wantErr: "invalid filter regex"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Parse() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if rule.Action != tt.wantAction {
+				t.Errorf("Action = %s, want %s", rule.Action, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  []string
+		writes []string
+		want   string
+	}{
+		{
+			name:   "no rules",
+			writes: []string{"a\nb"},
+			want:   "a\nb",
+		},
+		{
+			name:   "drop lines",
+			rules:  []string{"drop:^DEBUG"},
+			writes: []string{"DEBUG start\nresult 1\nDEBUG end\n"},
+			want:   "result 1\n",
+		},
+		{
+			name:   "redact addresses",
+			rules:  []string{"redact:0x[0-9a-f]+"},
+			writes: []string{"ptr=0x7ffd1234 ok\n"},
+			want:   "ptr=***REDACTED*** ok\n",
+		},
+		{
+			name:   "strip ANSI colors",
+			rules:  []string{`strip:\x1b\[[0-9;]*m`},
+			writes: []string{"\x1b[32mPASS\x1b[0m\n"},
+			want:   "PASS\n",
+		},
+		{
+			name:   "lines split across writes",
+			rules:  []string{"drop:secret", "redact:[0-9]{4}"},
+			writes: []string{"ye", "ar 20", "24\nsec", "ret line\ntail 1999"},
+			want:   "year ***REDACTED***\ntail ***REDACTED***",
+		},
+		{
+			name:   "rules apply in order",
+			rules:  []string{"strip:[0-9]+", "drop:^id=$"},
+			writes: []string{"id=42\nname=x\n"},
+			want:   "name=x\n",
+		},
+		{
+			name:   "empty lines are kept",
+			rules:  []string{"drop:^#"},
+			writes: []string{"\n# comment\n\n"},
+			want:   "\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ParseAll(tt.rules)
+			if err != nil {
+				t.Fatalf("ParseAll() error = %v", err)
+			}
+			var buf bytes.Buffer
+			w := NewWriter(&buf, rules)
+			for _, s := range tt.writes {
+				n, err := w.Write([]byte(s))
+				if err != nil || n != len(s) {
+					t.Fatalf("Write(%q) = %d, %v", s, n, err)
+				}
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatalf("Flush() error = %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("Output = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriterLongLine(t *testing.T) {
+	rules, _ := ParseAll([]string{"redact:x"})
+	var buf bytes.Buffer
+	w := NewWriter(&buf, rules)
+
+	long := strings.Repeat("a", maxLineLength+10)
+	_, _ = w.Write([]byte(long))
+	// The first chunk is written without waiting for the newline
+	if buf.Len() != maxLineLength {
+		t.Errorf("Buffered %d bytes were written, want %d", buf.Len(), maxLineLength)
+	}
+	_, _ = w.Write([]byte("x\n"))
+	if got := buf.String(); got != long+Redacted+"\n" {
+		t.Errorf("Output has length %d, want %d", len(got), len(long)+len(Redacted)+1)
+	}
+}
@@ -0,0 +1,66 @@
+//go:build linux
+
+package logsink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const journalSupported = true
+
+// journalSocket is where journald receives entries in its native protocol
+var journalSocket = "/run/systemd/journal/socket"
+
+// logJournal sends the entry as one datagram of journal fields: MESSAGE,
+// PRIORITY, SYSLOG_IDENTIFIER, a GHOST_<NAME> field per result field and the
+// JSON result as GHOST_RESULT
+// A result too large for a datagram is logged without GHOST_RESULT.
+func logJournal(e *entry) error {
+	conn, err := net.Dial("unixgram", journalSocket)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the journal: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	_, err = conn.Write(journalDatagram(e, true))
+	if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS) {
+		_, err = conn.Write(journalDatagram(e, false))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to log to the journal: %w", err)
+	}
+	return nil
+}
+
+func journalDatagram(e *entry, withResult bool) []byte {
+	var buf bytes.Buffer
+	writeJournalField(&buf, "MESSAGE", e.message())
+	writeJournalField(&buf, "PRIORITY", strconv.Itoa(e.severity))
+	writeJournalField(&buf, "SYSLOG_IDENTIFIER", Identifier)
+	for _, f := range e.fields {
+		writeJournalField(&buf, "GHOST_"+strings.ToUpper(f.name), f.value)
+	}
+	if withResult {
+		writeJournalField(&buf, "GHOST_RESULT", e.result)
+	}
+	return buf.Bytes()
+}
+
+// writeJournalField writes NAME=value, or for values with newlines the name,
+// the value's little-endian 64-bit length and the value
+func writeJournalField(buf *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(buf, "%s=%s\n", name, value)
+		return
+	}
+	buf.WriteString(name + "\n")
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value + "\n")
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package logsink
+
+import "errors"
+
+const journalSupported = false
+
+// logJournal is not supported on this platform
+func logJournal(*entry) error {
+	return errors.New("the journal is only available on Linux")
+}
@@ -0,0 +1,114 @@
+// Package logsink logs results to the systemd journal or syslog, so graders
+// without an HTTP endpoint can collect them with their existing log shipping.
+package logsink
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/redact"
+)
+
+// Sinks a result can be logged to
+const (
+	Journal = "journal" // the systemd journal, with a GHOST_* field per result field (Linux only)
+	Syslog  = "syslog"  // the local syslog daemon, as a line of key=value pairs
+)
+
+// Sinks lists every supported sink
+var Sinks = []string{Journal, Syslog}
+
+// Identifier is the syslog identifier (tag) entries are logged with
+const Identifier = "ghost"
+
+// Severities of logged results, as in syslog
+const (
+	severityWarning = 4 // the command did not succeed
+	severityInfo    = 6 // the command succeeded
+)
+
+// Validate checks that every sink is supported on this platform
+func Validate(sinks []string) error {
+	for _, sink := range sinks {
+		switch {
+		case !slices.Contains(Sinks, sink):
+			return fmt.Errorf("unknown log sink %q (available: %s)", sink, strings.Join(Sinks, ", "))
+		case sink == Journal && !journalSupported, sink == Syslog && !syslogSupported:
+			return fmt.Errorf("log sink %s is not supported on %s", sink, runtime.GOOS)
+		}
+	}
+	return nil
+}
+
+// Log writes the result to the sink
+// Secrets registered with the redact package are masked in the entry.
+func Log(sink string, result *output.Result) error {
+	entry, err := newEntry(result)
+	if err != nil {
+		return err
+	}
+	switch sink {
+	case Journal:
+		return logJournal(entry)
+	case Syslog:
+		return logSyslog(entry)
+	}
+	return fmt.Errorf("unknown log sink %q", sink)
+}
+
+// field is a structured field of an entry, named like the result's JSON field
+type field struct {
+	name  string
+	value string
+}
+
+// entry is a result prepared for logging
+type entry struct {
+	severity int
+	fields   []field
+	result   string // the JSON result (journal only)
+}
+
+func newEntry(result *output.Result) (*entry, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+	e := &entry{severity: severityWarning, result: redact.String(string(data))}
+	if result.Status == "success" {
+		e.severity = severityInfo
+	}
+	add := func(name, value string) {
+		e.fields = append(e.fields, field{name, redact.String(value)})
+	}
+	add("run_id", result.RunID)
+	if result.Attempt > 0 {
+		add("attempt", strconv.Itoa(result.Attempt))
+	}
+	add("status", result.Status)
+	add("exit_code", strconv.Itoa(result.ExitCode))
+	add("execution_time", strconv.FormatInt(result.ExecutionTime, 10))
+	if result.Score != nil {
+		add("score", result.Score.String())
+	}
+	add("command", result.Command)
+	return e, nil
+}
+
+// message formats the fields as key=value pairs, quoting values that need it
+func (e *entry) message() string {
+	parts := make([]string, len(e.fields))
+	for i, f := range e.fields {
+		value := f.value
+		if value == "" || strings.ContainsAny(value, " \t\n\"=\\") {
+			value = strconv.Quote(value)
+		}
+		parts[i] = f.name + "=" + value
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,127 @@
+//go:build linux
+
+package logsink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+// listen receives one datagram on a socket in a temporary directory
+func listen(t *testing.T) (string, <-chan []byte) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "log.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	received := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, 1<<16)
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, err := conn.Read(buf)
+		if err == nil {
+			received <- buf[:n]
+		}
+		close(received)
+	}()
+	return path, received
+}
+
+func testResult() *output.Result {
+	score := decimal.NewFromInt(10)
+	return &output.Result{
+		SchemaVersion: output.SchemaVersion,
+		RunID:         "run-1",
+		Command:       "python3 solution.py",
+		Status:        "failed",
+		ExitCode:      1,
+		ExecutionTime: 42,
+		Score:         &score,
+		Feedback:      "line 1\nline 2",
+	}
+}
+
+func TestLogJournal(t *testing.T) {
+	path, received := listen(t)
+	journalSocket = path
+	defer func() { journalSocket = "/run/systemd/journal/socket" }()
+
+	if err := Log(Journal, testResult()); err != nil {
+		t.Fatalf("Log() error = %v", err)
+	}
+	datagram := <-received
+
+	for _, want := range []string{
+		"MESSAGE=run_id=run-1 status=failed exit_code=1 execution_time=42 score=10 command=\"python3 solution.py\"\n",
+		"PRIORITY=4\n",
+		"SYSLOG_IDENTIFIER=ghost\n",
+		"GHOST_RUN_ID=run-1\n",
+		"GHOST_STATUS=failed\n",
+		"GHOST_SCORE=10\n",
+		"GHOST_COMMAND=python3 solution.py\n",
+	} {
+		if !bytes.Contains(datagram, []byte(want)) {
+			t.Errorf("Datagram %q does not contain %q", datagram, want)
+		}
+	}
+
+	// The JSON result holds an escaped newline, so only a raw one needs the binary form
+	_, result, ok := bytes.Cut(datagram, []byte("GHOST_RESULT="))
+	if !ok || !bytes.Contains(result, []byte(`"feedback":"line 1\nline 2"`)) {
+		t.Errorf("Datagram %q does not contain the JSON result", datagram)
+	}
+}
+
+func TestWriteJournalFieldMultiline(t *testing.T) {
+	var buf bytes.Buffer
+	writeJournalField(&buf, "MESSAGE", "a\nb")
+	var want bytes.Buffer
+	want.WriteString("MESSAGE\n")
+	_ = binary.Write(&want, binary.LittleEndian, uint64(3))
+	want.WriteString("a\nb\n")
+	if !bytes.Equal(buf.Bytes(), want.Bytes()) {
+		t.Errorf("writeJournalField() = %q, want %q", buf.Bytes(), want.Bytes())
+	}
+}
+
+func TestLogSyslog(t *testing.T) {
+	path, received := listen(t)
+	syslogNetwork, syslogAddress = "unixgram", path
+	defer func() { syslogNetwork, syslogAddress = "", "" }()
+
+	result := testResult()
+	result.Status = "success"
+	result.ExitCode = 0
+	if err := Log(Syslog, result); err != nil {
+		t.Fatalf("Log() error = %v", err)
+	}
+	line := string(<-received)
+
+	// user.info is priority 14
+	if !strings.HasPrefix(line, "<14>") || !strings.Contains(line, " ghost[") {
+		t.Errorf("Line %q does not have priority user.info and tag ghost", line)
+	}
+	want := `run_id=run-1 status=success exit_code=0 execution_time=42 score=10 command="python3 solution.py"`
+	if !strings.HasSuffix(strings.TrimSpace(line), want) {
+		t.Errorf("Line %q does not end with %q", line, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate([]string{Journal, Syslog}); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if err := Validate([]string{"kafka"}); err == nil || !strings.Contains(err.Error(), `unknown log sink "kafka"`) {
+		t.Errorf("Validate() error = %v, want unknown log sink", err)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build windows || plan9
+
+package logsink
+
+import "errors"
+
+const syslogSupported = false
+
+// logSyslog is not supported on this platform
+func logSyslog(*entry) error {
+	return errors.New("syslog is not available on this platform")
+}
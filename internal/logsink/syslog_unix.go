@@ -0,0 +1,32 @@
+//go:build !windows && !plan9
+
+package logsink
+
+import (
+	"fmt"
+	"log/syslog"
+)
+
+const syslogSupported = true
+
+// Where syslog entries are sent ("" = the local daemon's socket)
+var syslogNetwork, syslogAddress = "", ""
+
+// logSyslog sends the entry's key=value message with the user facility
+func logSyslog(e *entry) error {
+	writer, err := syslog.Dial(syslogNetwork, syslogAddress, syslog.LOG_USER, Identifier)
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	defer func() { _ = writer.Close() }()
+
+	if e.severity == severityInfo {
+		err = writer.Info(e.message())
+	} else {
+		err = writer.Warning(e.message())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to log to syslog: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+// Package manifest defines the case manifest format for batch grading runs
+// A manifest lists cases to execute; a case with a matrix expands into one
+// concrete case per combination of matrix values. Execution settings such as the
+// timeout default to the manifest's defaults unless a case overrides them.
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zinc-sig/ghost/internal/compare"
+	"gopkg.in/yaml.v3"
+)
+
+// MaxExpandedCases bounds matrix expansion so a typo cannot produce millions of cases
+const MaxExpandedCases = 10000
+
+// Settings are the execution settings of a case
+// Unset settings fall back to the manifest's defaults.
+type Settings struct {
+	Timeout        string   `yaml:"timeout,omitempty" json:"timeout,omitempty"`                   // e.g. 2s
+	Weight         *float64 `yaml:"weight,omitempty" json:"weight,omitempty"`                     // Multiplier for the case score (default 1)
+	DiffFlags      string   `yaml:"diff_flags,omitempty" json:"diff_flags,omitempty"`             // Normalisation of the comparison, e.g. "-w -B"
+	ExpectExitCode *int     `yaml:"expect_exit_code,omitempty" json:"expect_exit_code,omitempty"` // Exit code that counts as success (default 0)
+}
+
+// settingKeys are the manifest keys of Settings
+var settingKeys = map[string]bool{"timeout": true, "weight": true, "diff_flags": true, "expect_exit_code": true}
+
+// SettingError is an invalid setting, keyed by its name in the manifest
+type SettingError struct {
+	Key string
+	Err error
+}
+
+func (e *SettingError) Error() string {
+	return e.Key + ": " + e.Err.Error()
+}
+
+func (e *SettingError) Unwrap() error {
+	return e.Err
+}
+
+// withDefaults returns the settings with unset values taken from defaults
+func (s Settings) withDefaults(defaults Settings) Settings {
+	if s.Timeout == "" {
+		s.Timeout = defaults.Timeout
+	}
+	if s.Weight == nil {
+		s.Weight = defaults.Weight
+	}
+	if s.DiffFlags == "" {
+		s.DiffFlags = defaults.DiffFlags
+	}
+	if s.ExpectExitCode == nil {
+		s.ExpectExitCode = defaults.ExpectExitCode
+	}
+	return s
+}
+
+// Check validates the settings, reporting every invalid setting
+func (s Settings) Check() error {
+	var errs []error
+	for _, err := range s.settingErrors() {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// settingErrors returns one error per invalid setting
+func (s Settings) settingErrors() []*SettingError {
+	var errs []*SettingError
+	if s.Timeout != "" {
+		if timeout, err := time.ParseDuration(s.Timeout); err != nil {
+			errs = append(errs, &SettingError{"timeout", fmt.Errorf("invalid duration %q", s.Timeout)})
+		} else if timeout <= 0 {
+			errs = append(errs, &SettingError{"timeout", fmt.Errorf("must be positive")})
+		}
+	}
+	if s.Weight != nil && *s.Weight < 0 {
+		errs = append(errs, &SettingError{"weight", fmt.Errorf("must not be negative")})
+	}
+	if s.DiffFlags != "" {
+		if _, err := compare.ParseTextOptions(strings.Fields(s.DiffFlags)); err != nil {
+			errs = append(errs, &SettingError{"diff_flags", err})
+		}
+	}
+	if s.ExpectExitCode != nil && (*s.ExpectExitCode < 0 || *s.ExpectExitCode > 255) {
+		errs = append(errs, &SettingError{"expect_exit_code", fmt.Errorf("must be between 0 and 255")})
+	}
+	return errs
+}
+
+// Case describes a single grading case
+// String fields, command arguments and context values may reference matrix
+// variables as {matrix.<name>}.
+type Case struct {
+	Name     string         `yaml:"name" json:"name"`
+	Command  []string       `yaml:"command" json:"command"`
+	Input    string         `yaml:"input" json:"input"`
+	Expected string         `yaml:"expected,omitempty" json:"expected,omitempty"`
+	Context  map[string]any `yaml:"context,omitempty" json:"context,omitempty"`
+	Settings `yaml:",inline"`
+
+	// Matrix maps variable names to the values to expand over
+	Matrix map[string][]any `yaml:"matrix,omitempty" json:"matrix,omitempty"`
+	// Exclude drops combinations whose values match every key of an entry
+	Exclude []map[string]any `yaml:"exclude,omitempty" json:"exclude,omitempty"`
+}
+
+// Manifest is a list of cases loaded from a YAML or JSON file
+type Manifest struct {
+	Path     string   `yaml:"-" json:"-"`
+	Defaults Settings `yaml:"defaults,omitempty" json:"defaults,omitempty"`
+	Cases    []Case   `yaml:"cases" json:"cases"`
+}
+
+// Expansion is the JSON output of the manifest expand command
+type Expansion struct {
+	Command  string `json:"command"`
+	Manifest string `json:"manifest"`
+	Shard    string `json:"shard,omitempty"` // e.g. "3/10" with --shard
+	Total    int    `json:"total"`           // cases of the whole manifest
+	Cases    []Case `json:"cases"`           // cases of the shard, in manifest order
+}
+
+var placeholderPattern = regexp.MustCompile(`\{matrix\.([A-Za-z0-9_-]+)\}`)
+
+// Load reads a manifest file (YAML or JSON)
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	var m Manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %w", path, err)
+	}
+	m.Path = path
+	return &m, nil
+}
+
+// Expand returns the concrete cases of the manifest with every matrix expanded
+// and the defaults applied to settings the cases do not override.
+func (m *Manifest) Expand() ([]Case, error) {
+	if err := m.Defaults.Check(); err != nil {
+		return nil, fmt.Errorf("defaults: %w", err)
+	}
+
+	var cases []Case
+	for i, c := range m.Cases {
+		expanded, err := c.Expand()
+		if err != nil {
+			return nil, fmt.Errorf("case %d (%s): %w", i+1, c.Name, err)
+		}
+		for j := range expanded {
+			expanded[j].Settings = expanded[j].Settings.withDefaults(m.Defaults)
+			if err := expanded[j].Settings.Check(); err != nil {
+				return nil, fmt.Errorf("case %d (%s): %w", i+1, expanded[j].Name, err)
+			}
+		}
+		cases = append(cases, expanded...)
+		if len(cases) > MaxExpandedCases {
+			return nil, fmt.Errorf("manifest expands to more than %d cases", MaxExpandedCases)
+		}
+	}
+	return cases, nil
+}
+
+// Expand returns one case per matrix combination, or the case itself without a matrix
+// Combinations are produced in a stable order: matrix variables sorted by name,
+// values in the order given. Unless the name references a matrix variable, the
+// combination's values are appended to it, e.g. "sort (O2, large)".
+func (c Case) Expand() ([]Case, error) {
+	keys := make([]string, 0, len(c.Matrix))
+	total := 1
+	for key, values := range c.Matrix {
+		if len(values) == 0 {
+			return nil, fmt.Errorf("matrix variable %q has no values", key)
+		}
+		keys = append(keys, key)
+		total *= len(values)
+		if total > MaxExpandedCases {
+			return nil, fmt.Errorf("matrix expands to more than %d cases", MaxExpandedCases)
+		}
+	}
+	sort.Strings(keys)
+
+	var cases []Case
+	for n := 0; n < total; n++ {
+		// Decode n into one value index per key, last key varying fastest
+		values := make(map[string]any, len(keys))
+		rem := n
+		for i := len(keys) - 1; i >= 0; i-- {
+			options := c.Matrix[keys[i]]
+			values[keys[i]] = options[rem%len(options)]
+			rem /= len(options)
+		}
+		if c.excluded(values) {
+			continue
+		}
+
+		expanded, err := c.apply(keys, values)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, expanded)
+	}
+	return cases, nil
+}
+
+// excluded reports whether a combination matches one of the exclude entries
+func (c Case) excluded(values map[string]any) bool {
+	for _, exclude := range c.Exclude {
+		matched := len(exclude) > 0
+		for key, want := range exclude {
+			if fmt.Sprint(values[key]) != fmt.Sprint(want) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// apply substitutes one combination of matrix values into a copy of the case
+func (c Case) apply(keys []string, values map[string]any) (Case, error) {
+	t := &templater{values: values}
+
+	expanded := Case{
+		Name:     t.string(c.Name),
+		Input:    t.string(c.Input),
+		Expected: t.string(c.Expected),
+		Settings: Settings{
+			Timeout:        t.string(c.Timeout),
+			Weight:         c.Weight,
+			DiffFlags:      t.string(c.DiffFlags),
+			ExpectExitCode: c.ExpectExitCode,
+		},
+	}
+	for _, arg := range c.Command {
+		expanded.Command = append(expanded.Command, t.string(arg))
+	}
+	if c.Context != nil {
+		expanded.Context = t.value(c.Context).(map[string]any)
+	}
+	if t.err != nil {
+		return Case{}, t.err
+	}
+
+	if len(keys) > 0 && !placeholderPattern.MatchString(c.Name) {
+		parts := make([]string, len(keys))
+		for i, key := range keys {
+			parts[i] = fmt.Sprint(values[key])
+		}
+		expanded.Name = fmt.Sprintf("%s (%s)", c.Name, strings.Join(parts, ", "))
+	}
+	return expanded, nil
+}
+
+// templater substitutes {matrix.<name>} placeholders, recording the first undefined variable
+type templater struct {
+	values map[string]any
+	err    error
+}
+
+func (t *templater) string(s string) string {
+	return placeholderPattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+		key := placeholderPattern.FindStringSubmatch(placeholder)[1]
+		value, ok := t.values[key]
+		if !ok {
+			if t.err == nil {
+				t.err = fmt.Errorf("undefined matrix variable %q", key)
+			}
+			return placeholder
+		}
+		return fmt.Sprint(value)
+	})
+}
+
+// value substitutes placeholders in context values, keeping the matrix value's
+// type when a string consists of a single placeholder
+func (t *templater) value(v any) any {
+	switch v := v.(type) {
+	case string:
+		if match := placeholderPattern.FindStringSubmatch(v); match != nil && match[0] == v {
+			if value, ok := t.values[match[1]]; ok {
+				return value
+			}
+		}
+		return t.string(v)
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = t.value(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = t.value(item)
+		}
+		return result
+	default:
+		return v
+	}
+}
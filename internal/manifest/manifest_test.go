@@ -0,0 +1,227 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadAndExpand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cases.yaml")
+	content := `cases:
+  - name: hello
+    command: ["./hello"]
+    input: tests/hello.in
+    expected: tests/hello.out
+  - name: sort
+    command: ["./sort", "-{matrix.opt}"]
+    input: "tests/{matrix.size}.in"
+    expected: "tests/{matrix.size}.out"
+    context:
+      level: "{matrix.opt}"
+      label: "sort-{matrix.size}"
+      points: "{matrix.points}"
+    matrix:
+      opt: [O0, O2]
+      size: [small, large]
+      points: [5]
+    exclude:
+      - opt: O0
+        size: large
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	cases, err := m.Expand()
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+
+	var names []string
+	for _, c := range cases {
+		names = append(names, c.Name)
+	}
+	wantNames := []string{"hello", "sort (O0, 5, small)", "sort (O2, 5, small)", "sort (O2, 5, large)"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Fatalf("Names = %v, want %v", names, wantNames)
+	}
+
+	large := cases[3]
+	if !reflect.DeepEqual(large.Command, []string{"./sort", "-O2"}) {
+		t.Errorf("Command = %v", large.Command)
+	}
+	if large.Input != "tests/large.in" || large.Expected != "tests/large.out" {
+		t.Errorf("Input = %s, Expected = %s", large.Input, large.Expected)
+	}
+	wantContext := map[string]any{"level": "O2", "label": "sort-large", "points": 5}
+	if !reflect.DeepEqual(large.Context, wantContext) {
+		t.Errorf("Context = %v, want %v", large.Context, wantContext)
+	}
+	if large.Matrix != nil || large.Exclude != nil {
+		t.Error("Expanded cases should not carry the matrix")
+	}
+}
+
+func TestCaseExpand(t *testing.T) {
+	tests := []struct {
+		name      string
+		c         Case
+		wantNames []string
+		wantErr   string
+	}{
+		{
+			name:      "templated name",
+			c:         Case{Name: "run-{matrix.n}", Matrix: map[string][]any{"n": {1, 2, 3}}},
+			wantNames: []string{"run-1", "run-2", "run-3"},
+		},
+		{
+			name:      "no matrix",
+			c:         Case{Name: "single"},
+			wantNames: []string{"single"},
+		},
+		{
+			name:      "all combinations excluded",
+			c:         Case{Name: "x", Matrix: map[string][]any{"a": {1}}, Exclude: []map[string]any{{"a": 1}}},
+			wantNames: nil,
+		},
+		{
+			name:    "undefined variable",
+			c:       Case{Name: "x", Input: "{matrix.missing}.in", Matrix: map[string][]any{"a": {1}}},
+			wantErr: `undefined matrix variable "missing"`,
+		},
+		{
+			name:    "empty values",
+			c:       Case{Name: "x", Matrix: map[string][]any{"a": {}}},
+			wantErr: `matrix variable "a" has no values`,
+		},
+		{
+			name: "too many cases",
+			c: Case{Name: "x", Matrix: map[string][]any{
+				"a": make([]any, 200), "b": make([]any, 200),
+			}},
+			wantErr: "more than",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cases, err := tt.c.Expand()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expand() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expand() error = %v", err)
+			}
+			var names []string
+			for _, c := range cases {
+				names = append(names, c.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("Names = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+func TestExpandReportsCase(t *testing.T) {
+	m := &Manifest{Cases: []Case{{Name: "ok"}, {Name: "bad", Input: "{matrix.x}"}}}
+	_, err := m.Expand()
+	if err == nil || !strings.Contains(err.Error(), "case 2 (bad)") {
+		t.Errorf("Expand() error = %v, want error naming case 2", err)
+	}
+}
+
+func TestExpandAppliesDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cases.yaml")
+	content := `defaults:
+  timeout: 2s
+  weight: 1
+  diff_flags: -w
+cases:
+  - name: hello
+    command: ["./hello"]
+  - name: slow
+    command: ["./slow"]
+    timeout: "{matrix.t}"
+    weight: 0.5
+    expect_exit_code: 3
+    diff_flags: -B
+    matrix:
+      t: [5s, 10s]
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	cases, err := m.Expand()
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+
+	weight, half, exitCode := 1.0, 0.5, 3
+	want := []Settings{
+		{Timeout: "2s", Weight: &weight, DiffFlags: "-w"},
+		{Timeout: "5s", Weight: &half, DiffFlags: "-B", ExpectExitCode: &exitCode},
+		{Timeout: "10s", Weight: &half, DiffFlags: "-B", ExpectExitCode: &exitCode},
+	}
+	if len(cases) != len(want) {
+		t.Fatalf("got %d cases, want %d", len(cases), len(want))
+	}
+	for i, c := range cases {
+		if !reflect.DeepEqual(c.Settings, want[i]) {
+			t.Errorf("case %s settings = %+v, want %+v", c.Name, c.Settings, want[i])
+		}
+	}
+}
+
+func TestExpandReportsInvalidSettings(t *testing.T) {
+	negative := -1.0
+	tests := []struct {
+		name     string
+		manifest *Manifest
+		want     string
+	}{
+		{
+			name:     "invalid default",
+			manifest: &Manifest{Defaults: Settings{Timeout: "soon"}, Cases: []Case{{Name: "a"}}},
+			want:     `defaults: timeout: invalid duration "soon"`,
+		},
+		{
+			name:     "invalid case setting",
+			manifest: &Manifest{Cases: []Case{{Name: "a"}, {Name: "b", Settings: Settings{Weight: &negative}}}},
+			want:     "case 2 (b): weight: must not be negative",
+		},
+		{
+			name: "templated setting",
+			manifest: &Manifest{Cases: []Case{{
+				Name:     "c",
+				Settings: Settings{Timeout: "{matrix.t}"},
+				Matrix:   map[string][]any{"t": {"1s", "0s"}},
+			}}},
+			want: "case 1 (c (0s)): timeout: must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.manifest.Expand()
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Expand() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package manifest
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+// Shard selects the cases one of several parallel jobs grades, such as 3/10
+// for the third of ten jobs
+// Cases are assigned by a stable hash of their name, so every job selects its
+// cases without a coordinator, and adding a case does not move the others.
+type Shard struct {
+	Index int // 1-based
+	Count int
+}
+
+// ParseShard parses a shard given as index/count, e.g. 3/10
+func ParseShard(value string) (Shard, error) {
+	index, count, ok := strings.Cut(strings.TrimSpace(value), "/")
+	i, errIndex := strconv.Atoi(index)
+	n, errCount := strconv.Atoi(count)
+	if !ok || errIndex != nil || errCount != nil || n < 1 || i < 1 || i > n {
+		return Shard{}, fmt.Errorf("invalid shard %q (want index/count with 1 <= index <= count, e.g. 3/10)", value)
+	}
+	return Shard{Index: i, Count: n}, nil
+}
+
+// String formats the shard as index/count
+func (s Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Count)
+}
+
+// Contains reports whether the case with this name belongs to the shard
+func (s Shard) Contains(name string) bool {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return h.Sum64()%uint64(s.Count) == uint64(s.Index-1)
+}
+
+// Select returns the cases that belong to the shard, in manifest order
+func (s Shard) Select(cases []Case) []Case {
+	selected := []Case{}
+	for _, c := range cases {
+		if s.Contains(c.Name) {
+			selected = append(selected, c)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,64 @@
+package manifest
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseShard(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Shard
+		wantErr bool
+	}{
+		{value: "3/10", want: Shard{Index: 3, Count: 10}},
+		{value: "1/1", want: Shard{Index: 1, Count: 1}},
+		{value: " 2/2 ", want: Shard{Index: 2, Count: 2}},
+		{value: "0/10", wantErr: true},
+		{value: "11/10", wantErr: true},
+		{value: "3", wantErr: true},
+		{value: "a/b", wantErr: true},
+		{value: "1/0", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseShard(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseShard(%q) = %v, %v, want %v (error: %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestShardSelect(t *testing.T) {
+	var cases []Case
+	for i := range 200 {
+		cases = append(cases, Case{Name: fmt.Sprintf("case-%d", i)})
+	}
+
+	// Every case belongs to exactly one shard
+	seen := map[string]int{}
+	for index := 1; index <= 4; index++ {
+		selected := Shard{Index: index, Count: 4}.Select(cases)
+		if len(selected) < 25 {
+			t.Errorf("shard %d/4 selected only %d of 200 cases", index, len(selected))
+		}
+		for _, c := range selected {
+			seen[c.Name]++
+		}
+	}
+	for _, c := range cases {
+		if seen[c.Name] != 1 {
+			t.Errorf("case %s is in %d shards", c.Name, seen[c.Name])
+		}
+	}
+
+	// The assignment depends only on the name, not the other cases
+	shard := Shard{Index: 2, Count: 4}
+	for _, c := range shard.Select(cases) {
+		if len(shard.Select([]Case{c})) != 1 {
+			t.Errorf("case %s left the shard on its own", c.Name)
+		}
+	}
+	if got := (Shard{Index: 1, Count: 1}).Select(cases); len(got) != len(cases) {
+		t.Errorf("shard 1/1 selected %d of %d cases", len(got), len(cases))
+	}
+}
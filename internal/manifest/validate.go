@@ -0,0 +1,176 @@
+package manifest
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/zinc-sig/ghost/internal/validate"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	manifestKeys = map[string]bool{"defaults": true, "cases": true}
+	caseKeys     = map[string]bool{
+		"name": true, "command": true, "input": true, "expected": true,
+		"context": true, "matrix": true, "exclude": true,
+		"timeout": true, "weight": true, "diff_flags": true, "expect_exit_code": true,
+	}
+)
+
+// Validate checks a manifest file without executing anything
+// Every problem found is returned rather than stopping at the first, each
+// positioned at the offending key or case.
+func Validate(path string) []validate.Problem {
+	root, problems := validate.ReadYAMLFile(path)
+	if root == nil {
+		return problems
+	}
+	if root.Kind != yaml.MappingNode {
+		return []validate.Problem{validate.At(root, "manifest must be a map with a cases list")}
+	}
+
+	var cases *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		switch {
+		case !manifestKeys[key.Value]:
+			problems = append(problems, validate.At(key, "unknown key %q", key.Value))
+		case key.Value == "defaults":
+			problems = append(problems, validateDefaults(value)...)
+		default:
+			cases = value
+		}
+	}
+	if cases == nil || cases.Kind != yaml.SequenceNode || len(cases.Content) == 0 {
+		return append(problems, validate.At(cases, "cases must be a non-empty list"))
+	}
+
+	names := map[string]*yaml.Node{}
+	total := 0
+	for i, node := range cases.Content {
+		caseProblems, expanded := validateCase(i, node)
+		problems = append(problems, caseProblems...)
+
+		for _, c := range expanded {
+			if c.Name == "" {
+				continue
+			}
+			if first, ok := names[c.Name]; ok {
+				problems = append(problems, validate.At(node, "case %d: duplicate case name %q (first defined on line %d)", i+1, c.Name, first.Line))
+				continue
+			}
+			names[c.Name] = node
+		}
+		total += len(expanded)
+	}
+	if total > MaxExpandedCases {
+		problems = append(problems, validate.At(cases, "manifest expands to more than %d cases", MaxExpandedCases))
+	}
+	return problems
+}
+
+// validateCase checks a single case node and returns its expanded cases
+func validateCase(index int, node *yaml.Node) ([]validate.Problem, []Case) {
+	if node.Kind != yaml.MappingNode {
+		return []validate.Problem{validate.At(node, "case %d: must be a map", index+1)}, nil
+	}
+
+	var problems []validate.Problem
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if key := node.Content[i]; !caseKeys[key.Value] {
+			problems = append(problems, validate.At(key, "case %d: unknown key %q", index+1, key.Value))
+		}
+	}
+
+	label := fmt.Sprintf("case %d", index+1)
+	var c Case
+	if err := node.Decode(&c); err != nil {
+		for _, p := range validate.FromError(err, nil) {
+			p.Message = label + ": " + p.Message
+			problems = append(problems, p)
+		}
+		return problems, nil
+	}
+
+	if c.Name == "" {
+		problems = append(problems, validate.At(node, "%s: name is required", label))
+	} else {
+		label += " (" + c.Name + ")"
+	}
+	if len(c.Command) == 0 {
+		problems = append(problems, validate.At(node, "%s: command is required", label))
+	}
+	for _, exclude := range c.Exclude {
+		keys := make([]string, 0, len(exclude))
+		for key := range exclude {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if _, ok := c.Matrix[key]; !ok {
+				problems = append(problems, validate.At(node, "%s: exclude references unknown matrix variable %q", label, key))
+			}
+		}
+	}
+
+	expanded, err := c.Expand()
+	if err != nil {
+		return append(problems, validate.At(node, "%s: %v", label, err)), nil
+	}
+
+	// Settings may be templated, so each expanded case is checked; a setting
+	// that is invalid in every combination is reported once
+	seen := map[string]bool{}
+	for _, e := range expanded {
+		caseLabel := fmt.Sprintf("case %d (%s)", index+1, e.Name)
+		if e.Name == "" {
+			caseLabel = label
+		}
+		for _, p := range settingProblems(node, caseLabel, e.Settings) {
+			if !seen[p.Message] {
+				seen[p.Message] = true
+				problems = append(problems, p)
+			}
+		}
+	}
+	return problems, expanded
+}
+
+// validateDefaults checks the manifest's default settings
+func validateDefaults(node *yaml.Node) []validate.Problem {
+	if node.Kind != yaml.MappingNode {
+		return []validate.Problem{validate.At(node, "defaults: must be a map")}
+	}
+
+	var problems []validate.Problem
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if key := node.Content[i]; !settingKeys[key.Value] {
+			problems = append(problems, validate.At(key, "defaults: unknown key %q", key.Value))
+		}
+	}
+
+	var defaults Settings
+	if err := node.Decode(&defaults); err != nil {
+		for _, p := range validate.FromError(err, nil) {
+			p.Message = "defaults: " + p.Message
+			problems = append(problems, p)
+		}
+		return problems
+	}
+	return append(problems, settingProblems(node, "defaults", defaults)...)
+}
+
+// settingProblems reports each invalid setting at its key in node
+func settingProblems(node *yaml.Node, label string, settings Settings) []validate.Problem {
+	var problems []validate.Problem
+	for _, settingErr := range settings.settingErrors() {
+		at := node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == settingErr.Key {
+				at = node.Content[i+1]
+			}
+		}
+		problems = append(problems, validate.At(at, "%s: %v", label, settingErr))
+	}
+	return problems
+}
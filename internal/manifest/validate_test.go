@@ -0,0 +1,106 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string // expected problems, formatted as "line L, column C: message"
+	}{
+		{
+			name:    "valid",
+			content: "cases:\n  - name: sort\n    command: [./sort, \"-{matrix.opt}\"]\n    matrix:\n      opt: [O0, O2]\n",
+		},
+		{
+			name:    "not a map",
+			content: "- name: hello\n",
+			want:    []string{"line 1, column 1: manifest must be a map with a cases list"},
+		},
+		{
+			name:    "no cases",
+			content: "case: []\n",
+			want:    []string{`line 1, column 1: unknown key "case"`, "cases must be a non-empty list"},
+		},
+		{
+			name: "every case problem is reported",
+			content: `cases:
+  - name: hello
+    command: [./hello]
+  - name: hello
+    command: [./hello]
+  - command: "./x"
+    inputs: a.in
+  - name: matrix
+    command: ["{matrix.missing}"]
+    matrix:
+      opt: [O0]
+    exclude:
+      - size: large
+`,
+			want: []string{
+				`line 4, column 5: case 2: duplicate case name "hello" (first defined on line 2)`,
+				`line 7, column 5: case 3: unknown key "inputs"`,
+				"line 6: case 3: cannot unmarshal !!str `./x` into []string",
+				`line 8, column 5: case 4 (matrix): exclude references unknown matrix variable "size"`,
+				`line 8, column 5: case 4 (matrix): undefined matrix variable "missing"`,
+			},
+		},
+		{
+			name: "invalid settings are reported per case",
+			content: `defaults:
+  timeout: 0s
+  retries: 2
+cases:
+  - name: a
+    command: [./a]
+    weight: -1
+    diff_flags: --bogus
+  - name: b
+    command: [./b]
+    expect_exit_code: 300
+  - name: c
+    command: [./c]
+    timeout: "{matrix.t}"
+    matrix:
+      t: [1s, forever]
+`,
+			want: []string{
+				`line 3, column 3: defaults: unknown key "retries"`,
+				"line 2, column 12: defaults: timeout: must be positive",
+				"line 7, column 13: case 1 (a): weight: must not be negative",
+				"line 8, column 17: case 1 (a): diff_flags: diff flag --bogus is not supported by the internal engine",
+				"line 11, column 23: case 2 (b): expect_exit_code: must be between 0 and 255",
+				`line 14, column 14: case 3 (c (forever)): timeout: invalid duration "forever"`,
+			},
+		},
+		{
+			name:    "syntax error",
+			content: "cases:\n  - name: a\n   command: b\n",
+			want:    []string{"line 1: did not find expected '-' indicator"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cases.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			problems := Validate(path)
+			var got []string
+			for _, p := range problems {
+				got = append(got, p.Error())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("Validate() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
@@ -4,20 +4,529 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// Versions of the JSON payloads, sent as schema_version
+// A version changes when a field is removed or changes meaning; fields may be
+// added without changing it. Result fields tagged since:"2" are left out of v1
+// results (--result-schema v1), so consumers validating v1 payloads strictly
+// don't break when ghost adds capabilities. See ghost schema.
+const (
+	SchemaV1 = "1"
+	SchemaV2 = "2"
+
+	// SchemaVersion is the latest version, used by default
+	SchemaVersion = SchemaV2
+)
+
+// SchemaVersions lists the supported versions, oldest first
+var SchemaVersions = []string{SchemaV1, SchemaV2}
+
 type Result struct {
+	SchemaVersion    string           `json:"schema_version"`
+	Event            string           `json:"event,omitempty"` // "completed" when webhook events are enabled
+	RunID            string           `json:"run_id"`
+	Attempt          int              `json:"attempt,omitempty" since:"2"` // run --attempt only
+	Command          string           `json:"command"`
+	Argv             []string         `json:"argv,omitempty" since:"2"` // run only: the command and its arguments
+	Status           string           `json:"status"`
+	Input            string           `json:"input"`
+	Expected         *string          `json:"expected,omitempty"`
+	MatchedExpected  string           `json:"matched_expected,omitempty" since:"2"` // diff with several expected outputs
+	Reference        string           `json:"reference,omitempty" since:"2"`        // judge only: the reference command
+	Output           string           `json:"output"`
+	Stderr           string           `json:"stderr"`
+	Descriptors      []FileDescriptor `json:"fds,omitempty" since:"2"`            // run --fd only
+	OutputPreview    string           `json:"output_preview,omitempty" since:"2"` // --embed-output-head only
+	OutputTruncated  bool             `json:"output_preview_truncated,omitempty" since:"2"`
+	StderrPreview    string           `json:"stderr_preview,omitempty" since:"2"` // --embed-stderr-tail only
+	StderrTruncated  bool             `json:"stderr_preview_truncated,omitempty" since:"2"`
+	ExitCode         int              `json:"exit_code"`
+	ExecutionTime    int64            `json:"execution_time"`
+	MaxRSSKB         int64            `json:"max_rss_kb,omitempty" since:"2"`
+	Timeout          *int64           `json:"timeout,omitempty"` // in milliseconds
+	Score            *decimal.Decimal `json:"score,omitempty"`
+	Feedback         string           `json:"feedback,omitempty" since:"2"`
+	Rubric           *RubricResult    `json:"rubric,omitempty" since:"2"` // --rubric only
+	Context          any              `json:"context,omitempty"`
+	Environment      *Environment     `json:"environment,omitempty" since:"2"`
+	Determinism      *Determinism     `json:"determinism,omitempty" since:"2"` // --set-locale, --set-tz and --set-seed-env only
+	Interaction      *Interaction     `json:"interaction,omitempty" since:"2"`
+	Steps            []StepResult     `json:"steps,omitempty" since:"2"`
+	Grader           *GraderResult    `json:"grader,omitempty" since:"2"`      // interactive only
+	Build            *BuildResult     `json:"build,omitempty" since:"2"`       // build-run only
+	Diagnostics      *Diagnostics     `json:"diagnostics,omitempty" since:"2"` // --stderr-classify only
+	PolicyViolation  string           `json:"policy_violation,omitempty" since:"2"`
+	ResourceExceeded string           `json:"resource_exceeded,omitempty" since:"2"`
+	OOMKilled        bool             `json:"oom_killed,omitempty" since:"2"`
+	Signal           string           `json:"signal,omitempty" since:"2"` // e.g. "SIGSEGV" when a signal killed the command
+	CoreDumped       bool             `json:"core_dumped,omitempty" since:"2"`
+	Deadline         string           `json:"deadline,omitempty" since:"2"`         // "soft" or "hard" when --soft-timeout is set and one fired
+	NetworkIsolated  bool             `json:"network_isolated,omitempty" since:"2"` // --no-network only
+	Files            []FileResult     `json:"files,omitempty" since:"2"`
+	Patterns         []PatternResult  `json:"patterns,omitempty" since:"2"` // check only
+	Uploads          []UploadResult   `json:"uploads,omitempty" since:"2"`
+	Plan             *Plan            `json:"plan,omitempty" since:"2"`   // --dry-run only
+	Cached           bool             `json:"cached,omitempty" since:"2"` // --cache-dir only: the grade of an unchanged submission
+
+	// Webhook status (only in local output, not sent to webhook)
+	WebhookSent     bool             `json:"webhook_sent,omitempty"`
+	WebhookError    string           `json:"webhook_error,omitempty"`
+	WebhookResponse *WebhookResponse `json:"webhook_response,omitempty" since:"2"` // --webhook-capture-response only
+	WebhookSpooled  bool             `json:"webhook_spooled,omitempty" since:"2"`  // --webhook-async only
+	WebhookAttempts []WebhookAttempt `json:"webhook_attempts,omitempty" since:"2"`
+}
+
+// WebhookResponse is the receiver's reply to the final result delivery
+type WebhookResponse struct {
+	StatusCode    int    `json:"status_code"`
+	Body          string `json:"body,omitempty"`
+	BodyTruncated bool   `json:"body_truncated,omitempty"`
+}
+
+// WebhookAttempt is the receipt of one attempt to deliver the final result
+type WebhookAttempt struct {
+	Attempt    int    `json:"attempt"`
+	StartedAt  string `json:"started_at"`            // RFC 3339, UTC
+	Duration   int64  `json:"duration"`              // milliseconds
+	StatusCode int    `json:"status_code,omitempty"` // absent if no response was received
+	Delivered  bool   `json:"delivered"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Event is a webhook payload for a lifecycle event before the final result
+type Event struct {
+	SchemaVersion string         `json:"schema_version"`
+	Event         string         `json:"event"`
+	RunID         string         `json:"run_id"`
+	Attempt       int            `json:"attempt,omitempty"` // run --attempt only
+	Command       string         `json:"command"`
+	Timestamp     string         `json:"timestamp"` // RFC 3339, UTC
+	Context       any            `json:"context,omitempty"`
+	Status        string         `json:"status,omitempty"`  // timeout events only
+	Timeout       *int64         `json:"timeout,omitempty"` // timeout events only, in milliseconds
+	Uploads       []UploadResult `json:"uploads,omitempty"` // upload_finished events only
+}
+
+// FileDescriptor records a file mapped to a further descriptor of the command
+type FileDescriptor struct {
+	FD   int    `json:"fd"`
+	Path string `json:"path"`
+	Mode string `json:"mode"` // r, w or a
+}
+
+// Environment records where a command ran, for reproducing it later
+type Environment struct {
+	Variables  map[string]string `json:"variables"`
+	OS         string            `json:"os"`
+	Arch       string            `json:"arch"`
+	Kernel     string            `json:"kernel,omitempty"`
+	Machine    string            `json:"machine,omitempty"`
+	Hostname   string            `json:"hostname,omitempty"`
+	WorkingDir string            `json:"working_dir,omitempty"`
+}
+
+// Determinism records the environment variables ghost pinned for the command
+type Determinism struct {
+	Locale   string            `json:"locale,omitempty"`   // LANG and LC_ALL
+	Timezone string            `json:"timezone,omitempty"` // TZ
+	Seeds    map[string]string `json:"seeds,omitempty"`
+}
+
+// UploadResult records the outcome of uploading a single file
+type UploadResult struct {
+	Remote    string `json:"remote"`
+	Role      string `json:"role,omitempty"` // output, stderr, input, expected, additional or result
+	Size      int64  `json:"size"`           // uncompressed size in bytes
+	Duration  int64  `json:"duration"`       // milliseconds
+	Attempts  int    `json:"attempts"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+	ErrorCode string `json:"error_code,omitempty"` // class of the last error: network, throttled, server, auth, bucket_missing, quota, invalid or unknown
+	Skipped   bool   `json:"skipped,omitempty"`    // --upload-if-absent only: already stored, not uploaded again
+	Deleted   bool   `json:"deleted,omitempty"`    // --upload-fail-policy cleanup only: removed after a later upload failed
+	Streamed  bool   `json:"streamed,omitempty"`   // --upload-streaming only: uploaded while the command ran
+
+	ContentType string `json:"content_type,omitempty"`
+
+	// Compression details (only when --upload-compress is used)
+	Compression    string `json:"compression,omitempty"`
+	CompressedSize int64  `json:"compressed_size,omitempty"`
+
+	// Encryption details (only when --upload-encrypt is used)
+	Encryption     string `json:"encryption,omitempty"`
+	KeyFingerprint string `json:"key_fingerprint,omitempty"` // sha256 of the key, never the key itself
+
+	// Temporary download link (only when --upload-presign is used)
+	URL        string `json:"url,omitempty"`
+	URLExpires string `json:"url_expires,omitempty"` // RFC 3339, UTC
+}
+
+// RubricResult is the score breakdown of a --rubric
+type RubricResult struct {
+	Criteria []CriterionResult `json:"criteria"`
+	Total    decimal.Decimal   `json:"total"`
+	Max      decimal.Decimal   `json:"max"`
+}
+
+// CriterionResult records whether one rubric criterion passed
+type CriterionResult struct {
+	Name   string          `json:"name"`
+	Check  string          `json:"check"`
+	Passed bool            `json:"passed"`
+	Points decimal.Decimal `json:"points"` // awarded
+	Max    decimal.Decimal `json:"max"`
+	Reason string          `json:"reason,omitempty"` // why it did not pass
+}
+
+// Plan describes what a --dry-run would do, with secrets redacted
+type Plan struct {
+	Command    string       `json:"command"`
+	Input      string       `json:"input"`
+	Output     string       `json:"output"` // local paths actually written
+	Stderr     string       `json:"stderr"`
+	Expected   string       `json:"expected,omitempty"`
+	Timeout    *int64       `json:"timeout,omitempty"` // in milliseconds
+	ResultFile *PlannedFile `json:"result_file,omitempty"`
+	Upload     *UploadPlan  `json:"upload,omitempty"`
+	Webhook    *WebhookPlan `json:"webhook,omitempty"`
+}
+
+// PlannedFile is a local file and where it would be uploaded
+type PlannedFile struct {
+	Local       string            `json:"local"`
+	Remote      string            `json:"remote,omitempty"`
+	Role        string            `json:"role,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+}
+
+// UploadPlan describes the uploads of a dry run
+type UploadPlan struct {
+	Provider       string         `json:"provider"`
+	Config         map[string]any `json:"config"`
+	Files          []PlannedFile  `json:"files"`
+	Retries        int            `json:"retries"`
+	FailPolicy     string         `json:"fail_policy"`
+	IfAbsent       bool           `json:"if_absent,omitempty"`
+	Compression    string         `json:"compression,omitempty"`
+	Encryption     string         `json:"encryption,omitempty"`
+	KeyFingerprint string         `json:"key_fingerprint,omitempty"`
+	Presign        string         `json:"presign,omitempty"`
+	BandwidthLimit string         `json:"bandwidth_limit,omitempty"`
+	Concurrency    int            `json:"concurrency,omitempty"` // omitted when files upload one after another
+}
+
+// WebhookPlan describes the webhook delivery of a dry run
+type WebhookPlan struct {
+	URL           string            `json:"url"`
+	Method        string            `json:"method"`
+	AuthType      string            `json:"auth_type"`
+	AuthToken     string            `json:"auth_token,omitempty"` // always redacted
+	AuthHeader    string            `json:"auth_header,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	Timeout       string            `json:"timeout"`
+	Retries       int               `json:"retries"`
+	RateLimit     float64           `json:"rate_limit,omitempty"`
+	Proxy         string            `json:"proxy,omitempty"`
+	Events        []string          `json:"events,omitempty"`
+	IncludeFields []string          `json:"include_fields,omitempty"`
+	ExcludeFields []string          `json:"exclude_fields,omitempty"`
+
+	ExpectStatus    []int `json:"expect_status,omitempty"`
+	CaptureResponse bool  `json:"capture_response,omitempty"`
+
+	Async    bool   `json:"async,omitempty"`
+	SpoolDir string `json:"spool_dir,omitempty"`
+
+	BreakerThreshold int    `json:"breaker_threshold,omitempty"`
+	BreakerCooldown  string `json:"breaker_cooldown,omitempty"`
+}
+
+// StepResult records the outcome of a single pipeline step
+type StepResult struct {
+	Command       string `json:"command"`
+	Status        string `json:"status"`
+	ExitCode      int    `json:"exit_code"`
+	ExecutionTime int64  `json:"execution_time"` // milliseconds
+}
+
+// GraderResult records how the grader of ghost interactive judged the command
+type GraderResult struct {
+	Command       string `json:"command"`
+	Status        string `json:"status"` // success (accepted), failed (rejected) or timeout
+	ExitCode      int    `json:"exit_code"`
+	ExecutionTime int64  `json:"execution_time"`    // milliseconds
+	Verdict       string `json:"verdict,omitempty"` // last line of the grader's stderr
+}
+
+// BuildResult records the build phase of ghost build-run
+type BuildResult struct {
+	Command       string `json:"command"`
+	Status        string `json:"status"` // success, failed or timeout
+	ExitCode      int    `json:"exit_code"`
+	ExecutionTime int64  `json:"execution_time"`    // milliseconds
+	Timeout       *int64 `json:"timeout,omitempty"` // in milliseconds
+	Error         string `json:"error,omitempty"`   // why a build that exited successfully failed
+	Log           string `json:"log,omitempty"`     // --build-log only
+	Artifact      string `json:"artifact,omitempty"`
+	ArtifactSize  int64  `json:"artifact_size,omitempty"`
+
+	Diagnostics *Diagnostics `json:"diagnostics,omitempty"` // --stderr-classify only: of the build log
+}
+
+// Diagnostics counts the compiler or interpreter messages classified in a stderr file
+type Diagnostics struct {
+	Format     string `json:"format"` // gcc, javac or python
+	Errors     int    `json:"errors"`
+	Warnings   int    `json:"warnings"`
+	FirstError string `json:"first_error,omitempty"`
+}
+
+// CountDiagnostics adds up the errors and warnings classified in the stderr file
+// and the build log, and reports whether either was classified
+func (r *Result) CountDiagnostics() (errors, warnings int, classified bool) {
+	for _, d := range []*Diagnostics{r.Diagnostics, r.buildDiagnostics()} {
+		if d != nil {
+			errors += d.Errors
+			warnings += d.Warnings
+			classified = true
+		}
+	}
+	return errors, warnings, classified
+}
+
+func (r *Result) buildDiagnostics() *Diagnostics {
+	if r.Build == nil {
+		return nil
+	}
+	return r.Build.Diagnostics
+}
+
+// FileResult records the comparison status of a single file in a directory diff
+type FileResult struct {
+	Path   string `json:"path"`
+	Status string `json:"status"` // match, differ, missing or extra
+}
+
+// PatternResult records whether an output satisfied a single pattern of ghost check
+type PatternResult struct {
+	Pattern string `json:"pattern"`
+	Kind    string `json:"kind"` // required or forbidden
+	Passed  bool   `json:"passed"`
+	Line    int    `json:"line,omitempty"` // first matching line
+}
+
+// Interaction records the outcome of an interaction script
+type Interaction struct {
+	Steps      int               `json:"steps"`
+	Completed  int               `json:"completed"`
+	Error      string            `json:"error,omitempty"`
+	Transcript []TranscriptEntry `json:"transcript"`
+}
+
+// TranscriptEntry records a single interaction step
+type TranscriptEntry struct {
+	Step    int    `json:"step"`
+	Kind    string `json:"kind"`
+	Text    string `json:"text,omitempty"`
+	Output  string `json:"output,omitempty"`
+	Elapsed int64  `json:"elapsed"` // milliseconds since start
+}
+
+// ChecksumReport is the JSON output of the checksum command
+type ChecksumReport struct {
+	Command string         `json:"command"`
+	Status  string         `json:"status"` // success if every file matched, failed otherwise
+	Files   []ChecksumFile `json:"files"`
+}
+
+// ChecksumFile records the checksum verification of a single file
+type ChecksumFile struct {
+	Path      string `json:"path"`
+	Algorithm string `json:"algorithm"`
+	Checksum  string `json:"checksum,omitempty"`
+	Expected  string `json:"expected,omitempty"`
+	Status    string `json:"status"` // match, mismatch, missing or computed
+	Error     string `json:"error,omitempty"`
+}
+
+// WebhookFlushReport is the JSON output of one pass of the webhook flush command
+type WebhookFlushReport struct {
+	Command    string            `json:"command"`
+	Status     string            `json:"status"` // success if every flushed delivery was delivered, failed otherwise
+	Delivered  int               `json:"delivered"`
+	Retrying   int               `json:"retrying"`
+	Failed     int               `json:"failed"`
+	Pending    int               `json:"pending"` // deliveries left in the spool after the pass
+	Deliveries []FlushedDelivery `json:"deliveries"`
+}
+
+// FlushedDelivery records what a flush did with a single spooled delivery
+type FlushedDelivery struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"` // delivered, retry or failed
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
+
+// FetchReport is the JSON output of the fetch command
+type FetchReport struct {
+	Command  string        `json:"command"`
+	Status   string        `json:"status"` // success if every file was downloaded, failed otherwise
+	Provider string        `json:"provider"`
+	Files    []FetchedFile `json:"files"`
+}
+
+// FetchedFile is the outcome of downloading one remote file
+type FetchedFile struct {
+	Remote   string `json:"remote"`
+	Local    string `json:"local"`
+	Size     int64  `json:"size"`     // bytes
+	Duration int64  `json:"duration"` // milliseconds
+	Attempts int    `json:"attempts"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ReplayReport is the JSON output of the replay command
+type ReplayReport struct {
+	Command         string    `json:"command"`
+	Status          string    `json:"status"` // success if the run was reproduced, failed otherwise
+	RunID           string    `json:"run_id"` // of the replayed result
+	Replayed        string    `json:"replayed"`
+	Original        ReplayRun `json:"original"`
+	Replay          ReplayRun `json:"replay"`
+	ExitCodeMatches bool      `json:"exit_code_matches"`
+	OutputMatches   bool      `json:"output_matches"`
+	StderrMatches   *bool     `json:"stderr_matches,omitempty"` // omitted if the stored stderr is unavailable
+	WorkDir         string    `json:"work_dir,omitempty"`       // replay files, kept with --work-dir or when the run was not reproduced
+}
+
+// ReplayRun describes one execution compared by the replay command
+type ReplayRun struct {
+	Status        string `json:"status"`
+	ExitCode      int    `json:"exit_code"`
+	ExecutionTime int64  `json:"execution_time"`   // milliseconds
+	Output        string `json:"output,omitempty"` // omitted for a replay whose files were removed
+	Stderr        string `json:"stderr,omitempty"`
+}
+
+// ResultComparison is the JSON output of the result compare command
+type ResultComparison struct {
+	Command string          `json:"command"`
+	Status  string          `json:"status"` // success if no compared field changed, failed otherwise
+	Old     string          `json:"old"`    // result files as given
+	New     string          `json:"new"`
+	Changed int             `json:"changed"` // fields that changed beyond the tolerance
+	Fields  []ComparedField `json:"fields"`
+}
+
+// ComparedField records how one field differs between two results
+type ComparedField struct {
+	Field   string `json:"field"`
+	Old     string `json:"old"`
+	New     string `json:"new"`
+	Match   bool   `json:"match"`
+	Skipped bool   `json:"skipped,omitempty"` // the field could not be compared
+	Note    string `json:"note,omitempty"`
+}
+
+// ManifestRun is the JSON output of the manifest run command
+type ManifestRun struct {
+	Command  string           `json:"command"`
+	Manifest string           `json:"manifest"`
+	Shard    string           `json:"shard,omitempty"` // e.g. "3/10" with --shard
+	Status   string           `json:"status"`          // success if every case passed, failed otherwise
+	Total    int              `json:"total"`           // cases of the whole manifest
+	Passed   int              `json:"passed"`
+	Failed   int              `json:"failed"`
+	Score    decimal.Decimal  `json:"score"`              // sum of the case scores
+	MaxScore decimal.Decimal  `json:"max_score"`          // sum of the case weights
+	WorkDir  string           `json:"work_dir,omitempty"` // per-case files, kept with --work-dir or when a case failed
+	Cases    []ManifestResult `json:"cases"`              // cases run, in manifest order
+}
+
+// ManifestResult is the result of one case of a manifest run
+type ManifestResult struct {
+	Name   string  `json:"name"`
+	Weight float64 `json:"weight"`
+	Result *Result `json:"result"`
+}
+
+// StressReport is the JSON output of the stress command
+type StressReport struct {
+	Command string       `json:"command"`
+	Status  string       `json:"status"` // success if the program passed every seed, failed otherwise
+	Seeds   int          `json:"seeds"`  // seeds tested
+	Passed  int          `json:"passed"`
+	Failed  int          `json:"failed"`
+	WorkDir string       `json:"work_dir,omitempty"` // per-seed files, kept with --work-dir or when a seed failed
+	Cases   []StressCase `json:"cases"`
+}
+
+// StressCase records the outcome of a single seed
+type StressCase struct {
+	Seed          int64  `json:"seed"`
+	Status        string `json:"status"`         // passed, wrong_answer, generator_failed, reference_failed or the program's status
+	ExitCode      int    `json:"exit_code"`      // program only
+	ExecutionTime int64  `json:"execution_time"` // program only, milliseconds
+}
+
+// ScoreSummary is the JSON output of the score aggregate command
+type ScoreSummary struct {
+	SchemaVersion string           `json:"schema_version"`
+	RunID         string           `json:"run_id"`
 	Command       string           `json:"command"`
-	Status        string           `json:"status"`
-	Input         string           `json:"input"`
-	Expected      *string          `json:"expected,omitempty"`
-	Output        string           `json:"output"`
-	Stderr        string           `json:"stderr"`
-	ExitCode      int              `json:"exit_code"`
-	ExecutionTime int64            `json:"execution_time"`
-	Timeout       *int64           `json:"timeout,omitempty"` // in milliseconds
-	Score         *decimal.Decimal `json:"score,omitempty"`
+	Status        string           `json:"status"` // success if every case succeeded, failed otherwise
+	Score         decimal.Decimal  `json:"score"`
+	MaxScore      *decimal.Decimal `json:"max_score,omitempty"`
+	Percentage    *decimal.Decimal `json:"percentage,omitempty"`
+	Passed        int              `json:"passed"`
+	Total         int              `json:"total"`
+	Cases         []ScoreCase      `json:"cases"`
 	Context       any              `json:"context,omitempty"`
 
 	// Webhook status (only in local output, not sent to webhook)
-	WebhookSent  bool   `json:"webhook_sent,omitempty"`
-	WebhookError string `json:"webhook_error,omitempty"`
+	WebhookSent    bool   `json:"webhook_sent,omitempty"`
+	WebhookError   string `json:"webhook_error,omitempty"`
+	WebhookSpooled bool   `json:"webhook_spooled,omitempty"` // async webhook only
+}
+
+// ScoreCase records the contribution of a single result to a score summary
+type ScoreCase struct {
+	Case          string           `json:"case"`
+	Status        string           `json:"status"`
+	Score         *decimal.Decimal `json:"score"`
+	Weight        decimal.Decimal  `json:"weight"`
+	WeightedScore decimal.Decimal  `json:"weighted_score"`
+	MaxScore      *decimal.Decimal `json:"max_score,omitempty"` // weighted maximum
+}
+
+// ValidationReport is the JSON output of the validate command
+type ValidationReport struct {
+	Command string          `json:"command"`
+	Status  string          `json:"status"` // success if every file is valid, failed otherwise
+	Files   []ValidatedFile `json:"files"`
+}
+
+// ValidatedFile records the problems found in a single file
+type ValidatedFile struct {
+	Path   string            `json:"path"`
+	Kind   string            `json:"kind"`   // manifest, config, upload_config, webhook_config or context
+	Status string            `json:"status"` // valid or invalid
+	Errors []ValidationError `json:"errors,omitempty"`
+}
+
+// ValidationError is a single problem, positioned in the file when known
+type ValidationError struct {
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ErrorReport is the JSON output when ghost itself fails before producing a result
+type ErrorReport struct {
+	Command   string `json:"command,omitempty"`
+	Status    string `json:"status"` // always "error"
+	ErrorCode string `json:"error_code"`
+	Error     string `json:"error"`
 }
@@ -0,0 +1,32 @@
+package output
+
+import (
+	"reflect"
+	"strconv"
+)
+
+// InSchema reports whether a struct field is part of the given schema version
+// Fields without a since tag have been part of every version.
+func InSchema(field reflect.StructField, version string) bool {
+	since, ok := field.Tag.Lookup("since")
+	if !ok {
+		return true
+	}
+	want, err := strconv.Atoi(version)
+	if err != nil {
+		return true
+	}
+	introduced, err := strconv.Atoi(since)
+	return err != nil || introduced <= want
+}
+
+// Restrict clears the fields that are not part of the result's SchemaVersion
+// All such fields are omitted when empty, so they disappear from the JSON.
+func (r *Result) Restrict() {
+	value := reflect.ValueOf(r).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		if !InSchema(value.Type().Field(i), r.SchemaVersion) {
+			value.Field(i).SetZero()
+		}
+	}
+}
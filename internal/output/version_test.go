@@ -0,0 +1,53 @@
+package output
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestInSchema(t *testing.T) {
+	resultType := reflect.TypeOf(Result{})
+	tests := []struct {
+		field   string
+		version string
+		want    bool
+	}{
+		{"RunID", SchemaV1, true},
+		{"Uploads", SchemaV1, false},
+		{"Uploads", SchemaV2, true},
+		{"Rubric", SchemaV1, false},
+	}
+	for _, tt := range tests {
+		field, _ := resultType.FieldByName(tt.field)
+		if got := InSchema(field, tt.version); got != tt.want {
+			t.Errorf("InSchema(%s, %s) = %v, want %v", tt.field, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestRestrict(t *testing.T) {
+	score := decimal.NewFromInt(10)
+	result := Result{
+		SchemaVersion: SchemaV1,
+		RunID:         "run-1",
+		Score:         &score,
+		Signal:        "SIGSEGV",
+		Rubric:        &RubricResult{},
+		Uploads:       []UploadResult{{Remote: "out.txt", Attempts: 2}},
+	}
+	result.Restrict()
+	if result.Signal != "" || result.Rubric != nil || result.Uploads != nil {
+		t.Errorf("Restrict() kept v2 fields: %+v", result)
+	}
+	if result.RunID != "run-1" || result.Score == nil {
+		t.Errorf("Restrict() cleared v1 fields: %+v", result)
+	}
+
+	result = Result{SchemaVersion: SchemaV2, Signal: "SIGSEGV"}
+	result.Restrict()
+	if result.Signal != "SIGSEGV" {
+		t.Error("Restrict() cleared a field of the latest version")
+	}
+}
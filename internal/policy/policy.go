@@ -0,0 +1,190 @@
+package policy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Rule matches a command and, optionally, its arguments
+// Command patterns are matched against both the command as given and its base
+// name, so "python3" also matches "/usr/bin/python3".
+type Rule struct {
+	Command      string   `yaml:"command"`       // Glob pattern for the command
+	CommandRegex string   `yaml:"command_regex"` // Regular expression for the command
+	Args         []string `yaml:"args"`          // Glob patterns for arguments
+	ArgsRegex    []string `yaml:"args_regex"`    // Regular expressions for arguments
+	MaxArgs      *int     `yaml:"max_args"`      // Maximum number of arguments (allow rules only)
+
+	commandRegex *regexp.Regexp
+	argsRegex    []*regexp.Regexp
+}
+
+// Policy restricts which commands may be executed
+// A command is refused if it matches any deny rule, or if allow rules are
+// present and it matches none of them.
+type Policy struct {
+	Path  string `yaml:"-"`
+	Allow []Rule `yaml:"allow"`
+	Deny  []Rule `yaml:"deny"`
+}
+
+// Violation describes why a command was refused
+type Violation struct {
+	Reason string
+}
+
+func (v *Violation) Error() string {
+	return "policy violation: " + v.Reason
+}
+
+// Load reads and validates a policy file (YAML or JSON)
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file %s: %w", path, err)
+	}
+	if err := policy.compile(); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %w", path, err)
+	}
+	policy.Path = path
+	return &policy, nil
+}
+
+func (p *Policy) compile() error {
+	for i := range p.Allow {
+		if err := p.Allow[i].compile(); err != nil {
+			return fmt.Errorf("allow rule %d: %w", i+1, err)
+		}
+	}
+	for i := range p.Deny {
+		if err := p.Deny[i].compile(); err != nil {
+			return fmt.Errorf("deny rule %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) compile() error {
+	if r.Command == "" && r.CommandRegex == "" {
+		return fmt.Errorf("command or command_regex is required")
+	}
+	if r.Command != "" {
+		if _, err := filepath.Match(r.Command, ""); err != nil {
+			return fmt.Errorf("invalid command pattern %q: %w", r.Command, err)
+		}
+	}
+	for _, pattern := range r.Args {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid argument pattern %q: %w", pattern, err)
+		}
+	}
+
+	var err error
+	if r.CommandRegex != "" {
+		if r.commandRegex, err = regexp.Compile(r.CommandRegex); err != nil {
+			return fmt.Errorf("invalid command_regex: %w", err)
+		}
+	}
+	for _, pattern := range r.ArgsRegex {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid args_regex: %w", err)
+		}
+		r.argsRegex = append(r.argsRegex, re)
+	}
+	return nil
+}
+
+// Check returns a *Violation if the policy does not allow the command
+func (p *Policy) Check(command string, args []string) error {
+	for i, rule := range p.Deny {
+		if !rule.matchesCommand(command) {
+			continue
+		}
+		// Without argument patterns a deny rule refuses the command outright
+		if !rule.hasArgPatterns() {
+			return &Violation{Reason: fmt.Sprintf("command %q is denied by deny rule %d", command, i+1)}
+		}
+		for _, arg := range args {
+			if rule.matchesArg(arg) {
+				return &Violation{Reason: fmt.Sprintf("argument %q of %q is denied by deny rule %d", arg, command, i+1)}
+			}
+		}
+	}
+
+	if len(p.Allow) == 0 {
+		return nil
+	}
+
+	reason := fmt.Sprintf("command %q is not in the allowlist", command)
+	for _, rule := range p.Allow {
+		if !rule.matchesCommand(command) {
+			continue
+		}
+		if rule.MaxArgs != nil && len(args) > *rule.MaxArgs {
+			reason = fmt.Sprintf("command %q allows at most %d arguments, got %d", command, *rule.MaxArgs, len(args))
+			continue
+		}
+		if arg, ok := rule.firstUnmatchedArg(args); !ok {
+			reason = fmt.Sprintf("argument %q of %q is not allowed", arg, command)
+			continue
+		}
+		return nil
+	}
+	return &Violation{Reason: reason}
+}
+
+func (r *Rule) matchesCommand(command string) bool {
+	for _, candidate := range []string{command, filepath.Base(command)} {
+		if r.Command != "" {
+			if ok, _ := filepath.Match(r.Command, candidate); ok {
+				return true
+			}
+		}
+		if r.commandRegex != nil && r.commandRegex.MatchString(candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) hasArgPatterns() bool {
+	return len(r.Args) > 0 || len(r.argsRegex) > 0
+}
+
+func (r *Rule) matchesArg(arg string) bool {
+	for _, pattern := range r.Args {
+		if ok, _ := filepath.Match(pattern, arg); ok {
+			return true
+		}
+	}
+	for _, re := range r.argsRegex {
+		if re.MatchString(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstUnmatchedArg returns the first argument no pattern allows
+// Rules without argument patterns allow any arguments.
+func (r *Rule) firstUnmatchedArg(args []string) (string, bool) {
+	if !r.hasArgPatterns() {
+		return "", true
+	}
+	for _, arg := range args {
+		if !r.matchesArg(arg) {
+			return arg, false
+		}
+	}
+	return "", true
+}
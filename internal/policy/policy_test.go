@@ -0,0 +1,138 @@
+package policy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writePolicy(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "yaml",
+			content: "allow:\n  - command: python3\n    args: [\"*.py\"]\ndeny:\n  - command: rm\n",
+		},
+		{
+			name:    "json",
+			content: `{"allow": [{"command_regex": "^(gcc|g\\+\\+)$", "max_args": 4}]}`,
+		},
+		{
+			name:    "missing command",
+			content: "allow:\n  - args: [\"*.py\"]\n",
+			wantErr: "allow rule 1: command or command_regex is required",
+		},
+		{
+			name:    "invalid regex",
+			content: "deny:\n  - command_regex: \"(\"\n",
+			wantErr: "deny rule 1: invalid command_regex",
+		},
+		{
+			name:    "invalid glob",
+			content: "allow:\n  - command: \"[\"\n",
+			wantErr: "invalid command pattern",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writePolicy(t, tt.content))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Expected error for missing policy file")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	path := writePolicy(t, `allow:
+  - command: python3
+    args: ["*.py", "-u"]
+  - command_regex: "^(gcc|g\\+\\+)$"
+    max_args: 2
+  - command: echo
+deny:
+  - command: rm
+  - command: echo
+    args_regex: ["^/etc/"]
+`)
+	policy, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		args    []string
+		wantErr string
+	}{
+		{name: "allowed glob args", command: "python3", args: []string{"-u", "main.py"}},
+		{name: "allowed by base name", command: "/usr/bin/python3", args: []string{"main.py"}},
+		{name: "allowed regex", command: "g++", args: []string{"main.cpp"}},
+		{name: "allowed any args", command: "echo", args: []string{"hello", "world"}},
+		{name: "not in allowlist", command: "bash", args: []string{"-c", "true"}, wantErr: `command "bash" is not in the allowlist`},
+		{name: "argument not allowed", command: "python3", args: []string{"-c", "print(1)"}, wantErr: `argument "-c" of "python3" is not allowed`},
+		{name: "too many arguments", command: "gcc", args: []string{"a.c", "b.c", "c.c"}, wantErr: "allows at most 2 arguments, got 3"},
+		{name: "denied command", command: "/bin/rm", args: []string{"-rf", "out"}, wantErr: "denied by deny rule 1"},
+		{name: "denied argument", command: "echo", args: []string{"/etc/passwd"}, wantErr: `argument "/etc/passwd" of "echo" is denied by deny rule 2`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.command, tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Check() error = %v", err)
+				}
+				return
+			}
+			var violation *Violation
+			if !errors.As(err, &violation) {
+				t.Fatalf("Check() error = %v, want *Violation", err)
+			}
+			if !strings.Contains(violation.Reason, tt.wantErr) {
+				t.Errorf("Reason = %q, want containing %q", violation.Reason, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckDenyOnly(t *testing.T) {
+	policy, err := Load(writePolicy(t, "deny:\n  - command: curl\n"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if err := policy.Check("echo", []string{"hi"}); err != nil {
+		t.Errorf("Expected commands outside the denylist to be allowed, got %v", err)
+	}
+	if err := policy.Check("curl", nil); err == nil {
+		t.Error("Expected curl to be denied")
+	}
+}
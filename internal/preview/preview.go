@@ -0,0 +1,99 @@
+// Package preview reads bounded snippets of captured output files
+package preview
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+)
+
+// Head returns the first n bytes of a file and whether the file is longer
+// An incomplete UTF-8 sequence at the cut is dropped.
+func Head(path string, n int) (string, bool, error) {
+	f, size, err := open(path)
+	if err != nil {
+		return "", false, err
+	}
+	defer func() { _ = f.Close() }()
+
+	b, err := readN(f, n)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	truncated := size > int64(len(b))
+	if truncated {
+		b = trimIncompleteEnd(b)
+	}
+	return string(b), truncated, nil
+}
+
+// Tail returns the last n bytes of a file and whether the file is longer
+// An incomplete UTF-8 sequence at the cut is dropped.
+func Tail(path string, n int) (string, bool, error) {
+	f, size, err := open(path)
+	if err != nil {
+		return "", false, err
+	}
+	defer func() { _ = f.Close() }()
+
+	truncated := size > int64(n)
+	if truncated {
+		if _, err := f.Seek(size-int64(n), io.SeekStart); err != nil {
+			return "", false, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+	}
+	b, err := readN(f, n)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if truncated {
+		b = trimIncompleteStart(b)
+	}
+	return string(b), truncated, nil
+}
+
+func open(path string) (*os.File, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, 0, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	return f, info.Size(), nil
+}
+
+func readN(r io.Reader, n int) ([]byte, error) {
+	b := make([]byte, n)
+	read, err := io.ReadFull(r, b)
+	if err == io.ErrUnexpectedEOF || err == io.EOF {
+		err = nil
+	}
+	return b[:read], err
+}
+
+// trimIncompleteEnd drops a rune cut off at the end
+func trimIncompleteEnd(b []byte) []byte {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return b[:i]
+			}
+			break
+		}
+	}
+	return b
+}
+
+// trimIncompleteStart drops the continuation bytes of a rune cut off at the start
+func trimIncompleteStart(b []byte) []byte {
+	for i := 0; i < len(b) && i < utf8.UTFMax; i++ {
+		if utf8.RuneStart(b[i]) {
+			return b[i:]
+		}
+	}
+	return b
+}
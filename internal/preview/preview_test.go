@@ -0,0 +1,58 @@
+package preview
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHeadAndTail(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	short := write("short.txt", "hello\n")
+	long := write("long.txt", "line 1\nline 2\nline 3\n")
+	unicode := write("unicode.txt", "héllo wörld") // é and ö are two bytes
+	empty := write("empty.txt", "")
+
+	tests := []struct {
+		name          string
+		read          func(string, int) (string, bool, error)
+		path          string
+		n             int
+		want          string
+		wantTruncated bool
+	}{
+		{name: "head of short file", read: Head, path: short, n: 100, want: "hello\n"},
+		{name: "head exactly the size", read: Head, path: short, n: 6, want: "hello\n"},
+		{name: "head of long file", read: Head, path: long, n: 7, want: "line 1\n", wantTruncated: true},
+		{name: "tail of short file", read: Tail, path: short, n: 100, want: "hello\n"},
+		{name: "tail of long file", read: Tail, path: long, n: 7, want: "line 3\n", wantTruncated: true},
+		{name: "head drops a cut rune", read: Head, path: unicode, n: 2, want: "h", wantTruncated: true},
+		{name: "head keeps a whole rune", read: Head, path: unicode, n: 3, want: "hé", wantTruncated: true},
+		{name: "tail drops a cut rune", read: Tail, path: unicode, n: 4, want: "rld", wantTruncated: true},
+		{name: "tail keeps a whole rune", read: Tail, path: unicode, n: 5, want: "örld", wantTruncated: true},
+		{name: "empty file", read: Tail, path: empty, n: 10, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated, err := tt.read(tt.path, tt.n)
+			if err != nil {
+				t.Fatalf("error = %v", err)
+			}
+			if got != tt.want || truncated != tt.wantTruncated {
+				t.Errorf("got %q (truncated %v), want %q (truncated %v)", got, truncated, tt.want, tt.wantTruncated)
+			}
+		})
+	}
+
+	if _, _, err := Head(filepath.Join(dir, "missing.txt"), 10); err == nil {
+		t.Error("Head() of a missing file succeeded")
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !linux && !darwin
+
+package progress
+
+import (
+	"fmt"
+	"os"
+)
+
+// openFD returns a file for fd
+func openFD(fd int) (*os.File, error) {
+	file := os.NewFile(uintptr(fd), fmt.Sprintf("progress-fd-%d", fd))
+	if file == nil {
+		return nil, fmt.Errorf("progress fd %d is not open", fd)
+	}
+	if _, err := file.Stat(); err != nil {
+		return nil, fmt.Errorf("progress fd %d is not open", fd)
+	}
+	return file, nil
+}
@@ -0,0 +1,20 @@
+//go:build linux || darwin
+
+package progress
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// openFD returns a duplicate of fd, so closing it leaves the caller's descriptor open
+func openFD(fd int) (*os.File, error) {
+	dup, err := unix.Dup(fd)
+	if err != nil {
+		return nil, fmt.Errorf("progress fd %d is not open", fd)
+	}
+	unix.CloseOnExec(dup)
+	return os.NewFile(uintptr(dup), fmt.Sprintf("progress-fd-%d", fd)), nil
+}
@@ -0,0 +1,153 @@
+// Package progress reports the progress of commands that run many cases as
+// JSON lines, so wrapping UIs can render progress bars.
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Progress formats
+const (
+	FormatNone = "none"
+	FormatJSON = "json"
+)
+
+// Progress events, in the order they are emitted
+const (
+	EventStarted  = "batch_started"  // once, before the first case
+	EventCaseDone = "case_done"      // after every case
+	EventFinished = "batch_finished" // once, after the last case
+)
+
+// Event is a single progress line
+type Event struct {
+	Event     string `json:"event"`
+	Command   string `json:"command"`
+	Index     int    `json:"index,omitempty"` // case_done only: 1-based position of the case
+	Total     int    `json:"total"`           // cases planned
+	Case      string `json:"case,omitempty"`  // case_done only
+	Status    string `json:"status,omitempty"`
+	Passed    int    `json:"passed"`
+	Failed    int    `json:"failed"`
+	Elapsed   int64  `json:"elapsed"`   // milliseconds since the batch started
+	Timestamp string `json:"timestamp"` // RFC 3339, UTC
+}
+
+// ValidateFormat checks a --progress value
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatNone, FormatJSON:
+		return nil
+	}
+	return fmt.Errorf("invalid progress format %q (must be none or json)", format)
+}
+
+// Reporter writes progress events; a nil Reporter reports nothing
+// Write errors are ignored, since progress must never fail the batch.
+type Reporter struct {
+	mu      sync.Mutex
+	w       io.Writer
+	closer  io.Closer
+	command string
+	total   int
+	index   int
+	passed  int
+	failed  int
+	start   time.Time
+}
+
+// New returns a reporter writing events for a batch of total cases to w
+func New(w io.Writer, command string, total int) *Reporter {
+	return &Reporter{w: w, command: command, total: total, start: time.Now()}
+}
+
+// Open returns a reporter for the format writing to file descriptor fd, or nil
+// for FormatNone
+// fd 2 is stderr; fd 1 is refused since stdout carries the command's report.
+// On Linux and macOS the reporter writes to a duplicate of fd, closed by Finish.
+func Open(format string, fd int, command string, total int) (*Reporter, error) {
+	if err := ValidateFormat(format); err != nil {
+		return nil, err
+	}
+	if format == FormatNone {
+		return nil, nil
+	}
+
+	switch fd {
+	case 1:
+		return nil, fmt.Errorf("progress cannot be written to fd 1, which carries the report")
+	case 2:
+		return New(os.Stderr, command, total), nil
+	}
+	if fd < 0 {
+		return nil, fmt.Errorf("invalid progress fd %d", fd)
+	}
+	file, err := openFD(fd)
+	if err != nil {
+		return nil, err
+	}
+	r := New(file, command, total)
+	r.closer = file
+	return r, nil
+}
+
+// Start reports the start of the batch
+func (r *Reporter) Start() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.start = time.Now()
+	r.emit(Event{Event: EventStarted})
+}
+
+// CaseDone reports a finished case
+func (r *Reporter) CaseDone(name, status string, passed bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.index++
+	if passed {
+		r.passed++
+	} else {
+		r.failed++
+	}
+	r.emit(Event{Event: EventCaseDone, Index: r.index, Case: name, Status: status})
+}
+
+// Finish reports the end of the batch with its overall status
+func (r *Reporter) Finish(status string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.emit(Event{Event: EventFinished, Status: status})
+	if r.closer != nil {
+		_ = r.closer.Close()
+	}
+}
+
+// emit fills in the batch counters and writes the event as one line
+func (r *Reporter) emit(event Event) {
+	event.Command = r.command
+	event.Total = r.total
+	event.Passed = r.passed
+	event.Failed = r.failed
+	event.Elapsed = time.Since(r.start).Milliseconds()
+	event.Timestamp = time.Now().UTC().Format(time.RFC3339)
+
+	line, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	_, _ = r.w.Write(append(line, '\n'))
+}
@@ -0,0 +1,87 @@
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestReporter(t *testing.T) {
+	var buf bytes.Buffer
+	r := New(&buf, "stress", 3)
+	r.Start()
+	r.CaseDone("seed 1", "passed", true)
+	r.CaseDone("seed 2", "wrong_answer", false)
+	r.Finish("failed")
+
+	var events []Event
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e Event
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("invalid event %q: %v", line, err)
+		}
+		events = append(events, e)
+	}
+
+	want := []Event{
+		{Event: EventStarted, Command: "stress", Total: 3},
+		{Event: EventCaseDone, Command: "stress", Index: 1, Total: 3, Case: "seed 1", Status: "passed", Passed: 1},
+		{Event: EventCaseDone, Command: "stress", Index: 2, Total: 3, Case: "seed 2", Status: "wrong_answer", Passed: 1, Failed: 1},
+		{Event: EventFinished, Command: "stress", Total: 3, Status: "failed", Passed: 1, Failed: 1},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d:\n%s", len(events), len(want), buf.String())
+	}
+	for i, e := range events {
+		if e.Timestamp == "" {
+			t.Errorf("event %d has no timestamp", i)
+		}
+		e.Timestamp, e.Elapsed = "", 0
+		if e != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, e, want[i])
+		}
+	}
+}
+
+func TestNilReporter(t *testing.T) {
+	var r *Reporter
+	r.Start()
+	r.CaseDone("a", "passed", true)
+	r.Finish("success")
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		fd      int
+		wantNil bool
+		wantErr string
+	}{
+		{name: "none", format: FormatNone, fd: 2, wantNil: true},
+		{name: "stderr", format: FormatJSON, fd: 2},
+		{name: "invalid format", format: "xml", fd: 2, wantErr: `invalid progress format "xml"`},
+		{name: "stdout", format: FormatJSON, fd: 1, wantErr: "carries the report"},
+		{name: "negative fd", format: FormatJSON, fd: -1, wantErr: "invalid progress fd"},
+		{name: "closed fd", format: FormatJSON, fd: 987, wantErr: "progress fd 987 is not open"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := Open(tt.format, tt.fd, "stress", 1)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Open() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			if (r == nil) != tt.wantNil {
+				t.Errorf("Open() = %v, want nil: %v", r, tt.wantNil)
+			}
+		})
+	}
+}
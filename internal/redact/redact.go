@@ -0,0 +1,191 @@
+// Package redact masks secrets in everything ghost prints
+// Values of sensitive config keys are registered once they are known; String,
+// Error and Stderr then replace every occurrence with Mask.
+package redact
+
+import (
+	"io"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask replaces secrets
+const Mask = "***REDACTED***"
+
+// minSecretLength keeps short values such as "1" or "yes" from masking
+// unrelated text
+const minSecretLength = 4
+
+// sensitiveKeyParts mark config keys, header names and query parameters whose
+// values are secrets
+var sensitiveKeyParts = []string{"secret", "password", "token", "key", "credential", "authorization", "cookie", "signature"}
+
+// IsSensitiveKey reports whether the value of a config key is a secret
+func IsSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range sensitiveKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	mu      sync.RWMutex
+	secrets []string // longest first, so overlapping secrets are masked whole
+)
+
+// Add registers secret values to mask
+func Add(values ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, value := range values {
+		if len(value) < minSecretLength || slices.Contains(secrets, value) {
+			continue
+		}
+		secrets = append(secrets, value)
+	}
+	sort.SliceStable(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+}
+
+// AddConfig registers the values of sensitive keys in a config map, and the
+// passwords of URLs anywhere in it
+func AddConfig(cfg map[string]any) {
+	for key, value := range cfg {
+		addValue(value, IsSensitiveKey(key))
+	}
+}
+
+func addValue(value any, sensitive bool) {
+	switch v := value.(type) {
+	case string:
+		if sensitive {
+			Add(v)
+			// Basic credentials are user:password
+			if _, password, ok := strings.Cut(v, ":"); ok {
+				Add(password)
+			}
+		}
+		AddURL(v)
+	case map[string]any:
+		AddConfig(v)
+	case []any:
+		for _, item := range v {
+			addValue(item, sensitive)
+		}
+	case []string:
+		for _, item := range v {
+			addValue(item, sensitive)
+		}
+	case map[string]string:
+		for key, item := range v {
+			addValue(item, sensitive || IsSensitiveKey(key))
+		}
+	}
+}
+
+// AddURL registers the password and sensitive query parameters of a URL
+func AddURL(raw string) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" {
+		return
+	}
+	if password, ok := u.User.Password(); ok {
+		Add(password)
+	}
+	for name, values := range u.Query() {
+		if IsSensitiveKey(name) {
+			Add(values...)
+		}
+	}
+}
+
+// Reset forgets all registered secrets
+func Reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	secrets = nil
+}
+
+// String masks the registered secrets in s
+func String(s string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, secret := range secrets {
+		s = strings.ReplaceAll(s, secret, Mask)
+	}
+	return s
+}
+
+// Error returns the message of err with the registered secrets masked
+func Error(err error) string {
+	if err == nil {
+		return ""
+	}
+	return String(err.Error())
+}
+
+// Config copies a config map with the values of sensitive keys masked,
+// recursively
+func Config(cfg map[string]any) map[string]any {
+	masked := make(map[string]any, len(cfg))
+	for k, v := range cfg {
+		nested, isMap := v.(map[string]any)
+		switch {
+		case IsSensitiveKey(k):
+			masked[k] = Mask
+		case isMap:
+			masked[k] = Config(nested)
+		default:
+			masked[k] = v
+		}
+	}
+	return masked
+}
+
+// URL hides the password and sensitive query parameters of a URL for display
+func URL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return String(raw)
+	}
+	query := u.Query()
+	masked := false
+	for name := range query {
+		if IsSensitiveKey(name) {
+			query.Set(name, Mask)
+			masked = true
+		}
+	}
+	if masked {
+		u.RawQuery = strings.ReplaceAll(query.Encode(), url.QueryEscape(Mask), Mask)
+	}
+	return String(u.Redacted())
+}
+
+// Writer masks the registered secrets in everything written to it
+// Each write is masked on its own, which suits the whole lines written by
+// fmt.Fprintf.
+type Writer struct {
+	w func() io.Writer
+}
+
+// NewWriter returns a writer that masks secrets before writing to w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: func() io.Writer { return w }}
+}
+
+// Stderr masks secrets before writing to the current os.Stderr
+var Stderr io.Writer = &Writer{w: func() io.Writer { return os.Stderr }}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w(), String(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,162 @@
+// Package regression compares two results of the same case, e.g. graded before
+// and after a toolchain upgrade, to detect changes in grading
+package regression
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zinc-sig/ghost/internal/checksum"
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+// Fields that are compared
+const (
+	FieldStatus        = "status"
+	FieldExitCode      = "exit_code"
+	FieldScore         = "score"
+	FieldExecutionTime = "execution_time"
+	FieldOutput        = "output" // checksum of the output file
+	FieldStderr        = "stderr" // checksum of the stderr file
+)
+
+// Fields lists the compared fields in report order
+var Fields = []string{FieldStatus, FieldExitCode, FieldScore, FieldExecutionTime, FieldOutput, FieldStderr}
+
+// Options controls how strictly two results are compared
+type Options struct {
+	TimeTolerance float64       // fraction of the old execution time the new one may differ by
+	TimeSlack     time.Duration // difference in execution time that is always tolerated
+	Ignore        []string      // fields left out of the comparison
+}
+
+// Side is one of the compared results with the local files of its captured streams
+type Side struct {
+	Result *output.Result
+	Output string // "" if the output file is not available locally
+	Stderr string // "" if the stderr file is not available locally
+}
+
+// Delta is the comparison of one field
+type Delta struct {
+	Field   string
+	Old     string
+	New     string
+	Match   bool
+	Skipped bool   // the field could not be compared
+	Note    string // why it was skipped, or how a time difference compares to the tolerance
+}
+
+// ParseTolerance parses a time tolerance given as a percentage such as 20% or
+// a fraction such as 0.2
+func ParseTolerance(value string) (float64, error) {
+	s := strings.TrimSpace(value)
+	percent := strings.HasSuffix(s, "%")
+	tolerance, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil || tolerance < 0 {
+		return 0, fmt.Errorf("invalid time tolerance %q (e.g. 20%% or 0.2)", value)
+	}
+	if percent {
+		tolerance /= 100
+	}
+	return tolerance, nil
+}
+
+// ValidateFields checks that every field is a compared field
+func ValidateFields(fields []string) error {
+	for _, field := range fields {
+		if !slices.Contains(Fields, field) {
+			return fmt.Errorf("unknown field %q (must be one of %s)", field, strings.Join(Fields, ", "))
+		}
+	}
+	return nil
+}
+
+// Compare compares the fields of two results that are not ignored, in report order
+func Compare(before, after Side, opts Options) ([]Delta, error) {
+	var deltas []Delta
+	for _, field := range Fields {
+		if slices.Contains(opts.Ignore, field) {
+			continue
+		}
+
+		var delta Delta
+		var err error
+		switch field {
+		case FieldStatus:
+			delta = equal(before.Result.Status, after.Result.Status)
+		case FieldExitCode:
+			delta = equal(strconv.Itoa(before.Result.ExitCode), strconv.Itoa(after.Result.ExitCode))
+		case FieldScore:
+			delta = equal(score(before.Result), score(after.Result))
+		case FieldExecutionTime:
+			delta = compareTime(before.Result.ExecutionTime, after.Result.ExecutionTime, opts)
+		case FieldOutput:
+			delta, err = compareFiles(before.Output, after.Output)
+		case FieldStderr:
+			delta, err = compareFiles(before.Stderr, after.Stderr)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare %s: %w", field, err)
+		}
+		delta.Field = field
+		deltas = append(deltas, delta)
+	}
+	return deltas, nil
+}
+
+// equal compares two field values exactly
+func equal(before, after string) Delta {
+	return Delta{Old: before, New: after, Match: before == after}
+}
+
+// score formats the score of a result, "" if it has none
+func score(result *output.Result) string {
+	if result.Score == nil {
+		return ""
+	}
+	return result.Score.String()
+}
+
+// compareTime checks that two execution times in milliseconds differ by no more
+// than the tolerance allows
+func compareTime(before, after int64, opts Options) Delta {
+	allowed := max(float64(before)*opts.TimeTolerance, float64(opts.TimeSlack.Milliseconds()))
+	diff := after - before
+	delta := Delta{
+		Old:   strconv.FormatInt(before, 10),
+		New:   strconv.FormatInt(after, 10),
+		Match: float64(max(diff, -diff)) <= allowed,
+	}
+	if diff != 0 {
+		delta.Note = fmt.Sprintf("%+dms, tolerance %.0fms", diff, allowed)
+	}
+	return delta
+}
+
+// compareFiles compares the SHA-256 checksums of two captured files
+// Files that are not available locally, such as uploads without a local copy,
+// are skipped rather than reported as changed.
+func compareFiles(before, after string) (Delta, error) {
+	switch {
+	case before == "" && after == "":
+		return Delta{Skipped: true, Note: "not available locally in either result"}, nil
+	case before == "":
+		return Delta{Skipped: true, Note: "not available locally in the old result"}, nil
+	case after == "":
+		return Delta{Skipped: true, Note: "not available locally in the new result"}, nil
+	}
+
+	oldSum, err := checksum.File(before, checksum.SHA256)
+	if err != nil {
+		return Delta{}, err
+	}
+	newSum, err := checksum.File(after, checksum.SHA256)
+	if err != nil {
+		return Delta{}, err
+	}
+	return equal(checksum.SHA256+":"+oldSum, checksum.SHA256+":"+newSum), nil
+}
@@ -0,0 +1,143 @@
+package regression
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+func TestParseTolerance(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{value: "20%", want: 0.2},
+		{value: "0.5", want: 0.5},
+		{value: " 0% ", want: 0},
+		{value: "-10%", wantErr: true},
+		{value: "fast", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTolerance(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseTolerance(%q) = %v, %v, want %v (error: %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateFields(t *testing.T) {
+	if err := ValidateFields([]string{"stderr", "execution_time"}); err != nil {
+		t.Errorf("ValidateFields() error = %v", err)
+	}
+	if err := ValidateFields([]string{"argv"}); err == nil || !strings.Contains(err.Error(), `unknown field "argv"`) {
+		t.Errorf("ValidateFields() error = %v, want unknown field", err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	outA := write("a.txt", "42\n")
+	outB := write("b.txt", "42\n")
+	outC := write("c.txt", "43\n")
+
+	score := decimal.NewFromInt(10)
+	base := output.Result{Status: "success", ExitCode: 0, ExecutionTime: 1000, Score: &score}
+	opts := Options{TimeTolerance: 0.2, TimeSlack: 50 * time.Millisecond}
+
+	tests := []struct {
+		name        string
+		change      func(r *output.Result)
+		newOutput   string
+		opts        Options
+		wantChanged []string
+		wantSkipped []string
+	}{
+		{name: "unchanged", newOutput: outB, wantSkipped: []string{FieldStderr}},
+		{name: "slower within tolerance", change: func(r *output.Result) { r.ExecutionTime = 1200 }, newOutput: outB, wantSkipped: []string{FieldStderr}},
+		{
+			name:        "slower beyond tolerance",
+			change:      func(r *output.Result) { r.ExecutionTime = 1300 },
+			newOutput:   outB,
+			wantChanged: []string{FieldExecutionTime},
+			wantSkipped: []string{FieldStderr},
+		},
+		{
+			name: "failed with a different output",
+			change: func(r *output.Result) {
+				r.Status = "failed"
+				r.ExitCode = 1
+				r.Score = nil
+			},
+			newOutput:   outC,
+			wantChanged: []string{FieldStatus, FieldExitCode, FieldScore, FieldOutput},
+			wantSkipped: []string{FieldStderr},
+		},
+		{
+			name:        "output only uploaded",
+			wantSkipped: []string{FieldOutput, FieldStderr},
+		},
+		{
+			name:      "ignored fields",
+			change:    func(r *output.Result) { r.ExecutionTime = 5000 },
+			newOutput: outC,
+			opts:      Options{Ignore: []string{FieldExecutionTime, FieldOutput, FieldStderr}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := base
+			if tt.change != nil {
+				tt.change(&after)
+			}
+			o := opts
+			if tt.opts.Ignore != nil {
+				o.Ignore = tt.opts.Ignore
+			}
+
+			deltas, err := Compare(Side{Result: &base, Output: outA}, Side{Result: &after, Output: tt.newOutput}, o)
+			if err != nil {
+				t.Fatalf("Compare() error = %v", err)
+			}
+			if want := len(Fields) - len(o.Ignore); len(deltas) != want {
+				t.Errorf("compared %d fields, want %d", len(deltas), want)
+			}
+			var changed, skipped []string
+			for _, d := range deltas {
+				switch {
+				case d.Skipped:
+					skipped = append(skipped, d.Field)
+				case !d.Match:
+					changed = append(changed, d.Field)
+				}
+			}
+			if strings.Join(changed, ",") != strings.Join(tt.wantChanged, ",") {
+				t.Errorf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if strings.Join(skipped, ",") != strings.Join(tt.wantSkipped, ",") {
+				t.Errorf("skipped = %v, want %v", skipped, tt.wantSkipped)
+			}
+		})
+	}
+}
+
+func TestCompareTimeSlack(t *testing.T) {
+	// Fast programs vary by more than the tolerance share of their time
+	delta := compareTime(2, 30, Options{TimeTolerance: 0.2, TimeSlack: 50 * time.Millisecond})
+	if !delta.Match || delta.Note != "+28ms, tolerance 50ms" {
+		t.Errorf("compareTime() = %+v, want a match within the slack", delta)
+	}
+}
@@ -0,0 +1,85 @@
+// Package replay reconstructs the execution recorded in a run result, so it can
+// be run again and its output compared with the stored artifacts.
+package replay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/runner"
+)
+
+// Files written to the work directory of a replay
+const (
+	OutputFile     = "output.txt"
+	StderrFile     = "stderr.txt"
+	OutputDiffFile = "output.diff"
+	StderrDiffFile = "stderr.diff"
+)
+
+// Run is the execution recorded in a result
+type Run struct {
+	RunID   string
+	Argv    []string      // command and arguments
+	Env     []string      // recorded environment variables, as KEY=VALUE
+	Input   string        // file the command read as stdin
+	Timeout time.Duration // 0 means no timeout
+}
+
+// Read parses a result JSON document; name is used in errors
+func Read(r io.Reader, name string) (*output.Result, error) {
+	var result output.Result
+	decoder := json.NewDecoder(r)
+	if err := decoder.Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse result %s: %w", name, err)
+	}
+	return &result, nil
+}
+
+// FromResult reconstructs the execution of a run result
+// Results of other commands, dry runs and runs whose input cannot be read
+// again are rejected. Results recorded before argv was added fall back to
+// splitting the command line on whitespace.
+func FromResult(result *output.Result) (*Run, error) {
+	switch {
+	case result.Plan != nil:
+		return nil, fmt.Errorf("result is from a dry run, nothing was executed")
+	case result.Expected != nil:
+		return nil, fmt.Errorf("only run results can be replayed, not diff or judge results")
+	case len(result.Steps) > 0:
+		return nil, fmt.Errorf("pipeline results cannot be replayed")
+	case result.Interaction != nil:
+		return nil, fmt.Errorf("results of interactive runs cannot be replayed")
+	case result.Input == "" || result.Input == runner.StreamPath:
+		return nil, fmt.Errorf("the input was not read from a file")
+	}
+
+	argv := result.Argv
+	if len(argv) == 0 {
+		argv = strings.Fields(result.Command)
+	}
+	if len(argv) == 0 {
+		return nil, fmt.Errorf("result does not record a command")
+	}
+
+	run := &Run{
+		RunID: result.RunID,
+		Argv:  argv,
+		Input: result.Input,
+	}
+	if result.Timeout != nil {
+		run.Timeout = time.Duration(*result.Timeout) * time.Millisecond
+	}
+	if result.Environment != nil {
+		for name, value := range result.Environment.Variables {
+			run.Env = append(run.Env, name+"="+value)
+		}
+		sort.Strings(run.Env)
+	}
+	return run, nil
+}
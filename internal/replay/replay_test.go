@@ -0,0 +1,79 @@
+package replay
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+func TestFromResult(t *testing.T) {
+	timeout := int64(1500)
+	expected := "expected.txt"
+
+	tests := []struct {
+		name    string
+		result  output.Result
+		want    *Run
+		wantErr string
+	}{
+		{
+			name: "argv and environment",
+			result: output.Result{
+				RunID:       "r1",
+				Command:     "sh -c echo hi",
+				Argv:        []string{"sh", "-c", "echo hi"},
+				Input:       "in.txt",
+				Timeout:     &timeout,
+				Environment: &output.Environment{Variables: map[string]string{"SEED": "7", "LANG": "C"}},
+			},
+			want: &Run{
+				RunID:   "r1",
+				Argv:    []string{"sh", "-c", "echo hi"},
+				Env:     []string{"LANG=C", "SEED=7"},
+				Input:   "in.txt",
+				Timeout: 1500 * time.Millisecond,
+			},
+		},
+		{
+			name:   "command line without argv",
+			result: output.Result{RunID: "r2", Command: "./sort -n", Input: "in.txt"},
+			want:   &Run{RunID: "r2", Argv: []string{"./sort", "-n"}, Input: "in.txt"},
+		},
+		{name: "dry run", result: output.Result{Command: "cat", Input: "in.txt", Plan: &output.Plan{}}, wantErr: "dry run"},
+		{name: "diff result", result: output.Result{Command: "diff a b", Input: "a", Expected: &expected}, wantErr: "only run results"},
+		{name: "pipeline", result: output.Result{Command: "a | b", Input: "in.txt", Steps: []output.StepResult{{}}}, wantErr: "pipeline"},
+		{name: "stdin input", result: output.Result{Command: "cat", Input: "-"}, wantErr: "input was not read from a file"},
+		{name: "no command", result: output.Result{Input: "in.txt"}, wantErr: "does not record a command"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromResult(&tt.result)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("FromResult() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FromResult() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FromResult() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	result, err := Read(strings.NewReader(`{"run_id":"r1","command":"cat","argv":["cat"]}`), "result.json")
+	if err != nil || result.RunID != "r1" || !reflect.DeepEqual(result.Argv, []string{"cat"}) {
+		t.Errorf("Read() = %+v, %v", result, err)
+	}
+	if _, err := Read(strings.NewReader("{"), "result.json"); err == nil || !strings.Contains(err.Error(), "result.json") {
+		t.Errorf("Read() error = %v, want it to name the file", err)
+	}
+}
@@ -0,0 +1,108 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Config holds retry configuration
+type Config struct {
+	MaxRetries   int           // Maximum retry attempts (default: 3)
+	InitialDelay time.Duration // Initial delay between retries (default: 1s)
+	MaxDelay     time.Duration // Maximum delay (default: 30s)
+	Multiplier   float64       // Backoff multiplier (default: 2.0)
+}
+
+// DefaultConfig returns default retry configuration
+func DefaultConfig() *Config {
+	return &Config{
+		MaxRetries:   3,
+		InitialDelay: 1 * time.Second,
+		MaxDelay:     30 * time.Second,
+		Multiplier:   2.0,
+	}
+}
+
+// Backoff calculates the backoff duration for a given retry attempt
+func Backoff(attempt int, config *Config) time.Duration {
+	if attempt <= 0 {
+		return 0
+	}
+
+	// Exponential: delay = initialDelay * (multiplier ^ (attempt-1))
+	delay := float64(config.InitialDelay) * math.Pow(config.Multiplier, float64(attempt-1))
+
+	// Cap at maximum
+	if delay > float64(config.MaxDelay) {
+		delay = float64(config.MaxDelay)
+	}
+
+	// Add small jitter (±10%) to prevent thundering herd
+	jitter := delay * 0.1
+	delay = delay + (rand.Float64()*2-1)*jitter
+
+	return time.Duration(delay)
+}
+
+// permanentError marks an error that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err so that Do returns it without retrying
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked by Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// Do runs op until it succeeds, fails permanently or the retry budget is exhausted
+// onRetry, if not nil, is called before each retry with the attempt number and delay.
+// Returns the number of attempts made and the last error.
+func Do(ctx context.Context, config *Config, op func() error, onRetry func(attempt int, delay time.Duration, err error)) (int, error) {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
+		// Add backoff delay (skip on first attempt)
+		if attempt > 0 {
+			delay := Backoff(attempt, config)
+			if onRetry != nil {
+				onRetry(attempt, delay, lastErr)
+			}
+
+			select {
+			case <-time.After(delay):
+				// Continue after delay
+			case <-ctx.Done():
+				return attempt, fmt.Errorf("retry aborted after %d attempts: %w", attempt, ctx.Err())
+			}
+		}
+
+		if lastErr = op(); lastErr == nil {
+			return attempt + 1, nil
+		}
+		var permanent *permanentError
+		if errors.As(lastErr, &permanent) {
+			return attempt + 1, permanent.err
+		}
+	}
+
+	return config.MaxRetries + 1, lastErr
+}
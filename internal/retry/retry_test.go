@@ -0,0 +1,122 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	config := &Config{
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     300 * time.Millisecond,
+		Multiplier:   2.0,
+	}
+
+	if delay := Backoff(0, config); delay != 0 {
+		t.Errorf("Expected no delay for attempt 0, got %v", delay)
+	}
+	if delay := Backoff(2, config); delay < 180*time.Millisecond || delay > 220*time.Millisecond {
+		t.Errorf("Expected delay around 200ms for attempt 2, got %v", delay)
+	}
+	if delay := Backoff(5, config); delay < 270*time.Millisecond || delay > 330*time.Millisecond {
+		t.Errorf("Expected delay capped around 300ms for attempt 5, got %v", delay)
+	}
+}
+
+func TestDo(t *testing.T) {
+	config := &Config{
+		MaxRetries:   3,
+		InitialDelay: 1 * time.Millisecond,
+		MaxDelay:     5 * time.Millisecond,
+		Multiplier:   2.0,
+	}
+
+	tests := []struct {
+		name         string
+		failures     int
+		wantAttempts int
+		wantRetries  int
+		wantErr      bool
+	}{
+		{name: "succeeds first time", failures: 0, wantAttempts: 1, wantRetries: 0},
+		{name: "succeeds after retries", failures: 2, wantAttempts: 3, wantRetries: 2},
+		{name: "exhausts retries", failures: 10, wantAttempts: 4, wantRetries: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			retries := 0
+			attempts, err := Do(context.Background(), config, func() error {
+				calls++
+				if calls <= tt.failures {
+					return errors.New("transient failure")
+				}
+				return nil
+			}, func(attempt int, delay time.Duration, err error) {
+				retries++
+				if err == nil {
+					t.Error("onRetry should receive the previous error")
+				}
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if attempts != tt.wantAttempts || calls != tt.wantAttempts {
+				t.Errorf("attempts = %d, calls = %d, want %d", attempts, calls, tt.wantAttempts)
+			}
+			if retries != tt.wantRetries {
+				t.Errorf("retries = %d, want %d", retries, tt.wantRetries)
+			}
+		})
+	}
+}
+
+func TestDoPermanent(t *testing.T) {
+	config := &Config{MaxRetries: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, Multiplier: 1.0}
+	cause := errors.New("access denied")
+
+	calls := 0
+	attempts, err := Do(context.Background(), config, func() error {
+		calls++
+		return Permanent(fmt.Errorf("upload failed: %w", cause))
+	}, nil)
+
+	if attempts != 1 || calls != 1 {
+		t.Errorf("attempts = %d, calls = %d, want 1", attempts, calls)
+	}
+	if !errors.Is(err, cause) || IsPermanent(err) {
+		t.Errorf("Do() error = %v, want the unmarked cause", err)
+	}
+	if Permanent(nil) != nil {
+		t.Error("Permanent(nil) should be nil")
+	}
+}
+
+func TestDoContextCancellation(t *testing.T) {
+	config := &Config{
+		MaxRetries:   5,
+		InitialDelay: 1 * time.Second,
+		MaxDelay:     1 * time.Second,
+		Multiplier:   1.0,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := Do(ctx, config, func() error {
+		return errors.New("always fails")
+	}, nil)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Do() did not stop on context cancellation, took %v", elapsed)
+	}
+}
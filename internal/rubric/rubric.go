@@ -0,0 +1,243 @@
+// Package rubric scores a result against a rubric of criteria, each worth a
+// number of points, such as "compiles", "matches" or "within the time limit"
+package rubric
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/zinc-sig/ghost/internal/bytesize"
+	"github.com/zinc-sig/ghost/internal/output"
+	"github.com/zinc-sig/ghost/internal/runner"
+	"gopkg.in/yaml.v3"
+)
+
+// Checks a criterion can make
+const (
+	CheckCompiles    = "compiles"     // the command could be executed
+	CheckRuns        = "runs"         // it exited normally with the expected exit code
+	CheckMatches     = "matches"      // the result succeeded, including its output comparison
+	CheckTimeLimit   = "time_limit"   // it finished within limit (a duration)
+	CheckMemoryLimit = "memory_limit" // its peak memory stayed within limit (a byte size)
+	CheckNoWarnings  = "no_warnings"  // --stderr-classify found no warnings
+)
+
+// Checks lists the checks in the order they are documented
+var Checks = []string{CheckCompiles, CheckRuns, CheckMatches, CheckTimeLimit, CheckMemoryLimit, CheckNoWarnings}
+
+// Exit codes of a shell for a command that is not executable or not found
+const (
+	exitNotExecutable = 126
+	exitNotFound      = 127
+)
+
+// Criterion is one line of a rubric
+type Criterion struct {
+	Name     string   `yaml:"name"`      // defaults to the check
+	Check    string   `yaml:"check"`     // one of Checks
+	Points   float64  `yaml:"points"`    // awarded if the check passes
+	Limit    string   `yaml:"limit"`     // time_limit and memory_limit only
+	ExitCode *int     `yaml:"exit_code"` // runs only; default 0
+	Requires []string `yaml:"requires"`  // earlier criteria that must pass too
+
+	timeLimit   time.Duration
+	memoryLimit uint64 // bytes
+}
+
+// Rubric is a list of criteria whose points add up to the score
+type Rubric struct {
+	Path     string      `yaml:"-"`
+	Criteria []Criterion `yaml:"criteria"`
+}
+
+// Load reads and validates a rubric file (YAML or JSON)
+func Load(path string) (*Rubric, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rubric file: %w", err)
+	}
+
+	var rubric Rubric
+	if err := yaml.Unmarshal(data, &rubric); err != nil {
+		return nil, fmt.Errorf("failed to parse rubric file %s: %w", path, err)
+	}
+	if err := rubric.compile(); err != nil {
+		return nil, fmt.Errorf("invalid rubric file %s: %w", path, err)
+	}
+	rubric.Path = path
+	return &rubric, nil
+}
+
+func (r *Rubric) compile() error {
+	if len(r.Criteria) == 0 {
+		return fmt.Errorf("no criteria")
+	}
+	seen := make(map[string]bool, len(r.Criteria))
+	for i := range r.Criteria {
+		c := &r.Criteria[i]
+		if c.Name == "" {
+			c.Name = c.Check
+		}
+		if err := c.compile(seen); err != nil {
+			return fmt.Errorf("criterion %d (%s): %w", i+1, c.Name, err)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("criterion %d: duplicate name %q", i+1, c.Name)
+		}
+		seen[c.Name] = true
+	}
+	return nil
+}
+
+// compile validates a criterion; earlier holds the names of the criteria before it
+func (c *Criterion) compile(earlier map[string]bool) error {
+	if !slices.Contains(Checks, c.Check) {
+		return fmt.Errorf("unknown check %q (must be one of %s)", c.Check, strings.Join(Checks, ", "))
+	}
+	if c.Points < 0 || math.IsNaN(c.Points) || math.IsInf(c.Points, 0) {
+		return fmt.Errorf("points must be a finite number that is not negative")
+	}
+	if c.ExitCode != nil && c.Check != CheckRuns {
+		return fmt.Errorf("exit_code only applies to the %s check", CheckRuns)
+	}
+
+	switch c.Check {
+	case CheckTimeLimit:
+		limit, err := time.ParseDuration(c.Limit)
+		if err != nil || limit <= 0 {
+			return fmt.Errorf("limit must be a positive duration such as 2s, got %q", c.Limit)
+		}
+		c.timeLimit = limit
+	case CheckMemoryLimit:
+		limit, err := bytesize.Parse(c.Limit)
+		if c.Limit == "" || err != nil || limit == 0 {
+			return fmt.Errorf("limit must be a positive size such as 256MiB, got %q", c.Limit)
+		}
+		c.memoryLimit = limit
+	default:
+		if c.Limit != "" {
+			return fmt.Errorf("limit only applies to the %s and %s checks", CheckTimeLimit, CheckMemoryLimit)
+		}
+	}
+
+	for _, name := range c.Requires {
+		if !earlier[name] {
+			return fmt.Errorf("requires %q, which is not an earlier criterion", name)
+		}
+	}
+	return nil
+}
+
+// Max returns the points of all criteria
+func (r *Rubric) Max() decimal.Decimal {
+	total := decimal.Zero
+	for _, c := range r.Criteria {
+		total = total.Add(decimal.NewFromFloat(c.Points))
+	}
+	return total
+}
+
+// Evaluate checks every criterion against a result
+func (r *Rubric) Evaluate(result *output.Result) *output.RubricResult {
+	breakdown := &output.RubricResult{Total: decimal.Zero, Max: r.Max()}
+	passed := make(map[string]bool, len(r.Criteria))
+	for _, c := range r.Criteria {
+		points := decimal.NewFromFloat(c.Points)
+		entry := output.CriterionResult{Name: c.Name, Check: c.Check, Points: decimal.Zero, Max: points}
+
+		entry.Reason = c.evaluate(result)
+		for _, name := range c.Requires {
+			if !passed[name] {
+				entry.Reason = "requires " + name
+				break
+			}
+		}
+		if entry.Reason == "" {
+			entry.Passed = true
+			entry.Points = points
+			breakdown.Total = breakdown.Total.Add(points)
+		}
+		passed[c.Name] = entry.Passed
+		breakdown.Criteria = append(breakdown.Criteria, entry)
+	}
+	return breakdown
+}
+
+// evaluate returns why the criterion's check fails ("" if it passes)
+func (c *Criterion) evaluate(result *output.Result) string {
+	switch c.Check {
+	case CheckCompiles:
+		switch {
+		case result.PolicyViolation != "":
+			return "refused by the execution policy"
+		case result.ExitCode == exitNotFound:
+			return "command not found (exit code 127)"
+		case result.ExitCode == exitNotExecutable:
+			return "command not executable (exit code 126)"
+		}
+	case CheckRuns:
+		if reason := abnormalExit(result); reason != "" {
+			return reason
+		}
+		want := 0
+		if c.ExitCode != nil {
+			want = *c.ExitCode
+		}
+		if result.ExitCode != want {
+			return fmt.Sprintf("exit code %d, expected %d", result.ExitCode, want)
+		}
+	case CheckMatches:
+		if result.Status != string(runner.StatusSuccess) {
+			return "status " + result.Status
+		}
+	case CheckTimeLimit:
+		if result.Status == string(runner.StatusTimeout) {
+			return "timed out"
+		}
+		if limit := c.timeLimit.Milliseconds(); result.ExecutionTime > limit {
+			return fmt.Sprintf("took %dms, limit %s", result.ExecutionTime, c.Limit)
+		}
+	case CheckMemoryLimit:
+		switch {
+		case result.OOMKilled || result.ResourceExceeded != "":
+			return "resource limit reached"
+		case result.MaxRSSKB == 0:
+			return "peak memory was not measured"
+		case uint64(result.MaxRSSKB)*1024 > c.memoryLimit:
+			return fmt.Sprintf("used %d KiB, limit %s", result.MaxRSSKB, c.Limit)
+		}
+	case CheckNoWarnings:
+		_, warnings, classified := result.CountDiagnostics()
+		switch {
+		case !classified:
+			return "stderr was not classified (--stderr-classify)"
+		case warnings == 1:
+			return "1 warning"
+		case warnings > 1:
+			return fmt.Sprintf("%d warnings", warnings)
+		}
+	}
+	return ""
+}
+
+// abnormalExit describes how a command failed to exit on its own ("" if it did)
+func abnormalExit(result *output.Result) string {
+	switch {
+	case result.PolicyViolation != "":
+		return "refused by the execution policy"
+	case result.Status == string(runner.StatusTimeout):
+		return "timed out"
+	case result.Status == string(runner.StatusStalled):
+		return "stalled waiting for input"
+	case result.Signal != "":
+		return "killed by " + result.Signal
+	case result.ResourceExceeded != "":
+		return "resource limit reached: " + result.ResourceExceeded
+	}
+	return ""
+}
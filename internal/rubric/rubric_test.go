@@ -0,0 +1,219 @@
+package rubric
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+func writeRubric(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rubric.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "yaml",
+			content: "criteria:\n  - check: compiles\n    points: 10\n  - check: memory_limit\n    limit: 64MiB\n    points: 5\n    requires: [compiles]\n",
+		},
+		{
+			name:    "json",
+			content: `{"criteria": [{"name": "fast", "check": "time_limit", "limit": "2s", "points": 2.5}]}`,
+		},
+		{
+			name:    "no criteria",
+			content: "criteria: []\n",
+			wantErr: "no criteria",
+		},
+		{
+			name:    "unknown check",
+			content: "criteria:\n  - check: style\n    points: 1\n",
+			wantErr: `criterion 1 (style): unknown check "style"`,
+		},
+		{
+			name:    "negative points",
+			content: "criteria:\n  - check: runs\n    points: -1\n",
+			wantErr: "points must be a finite number",
+		},
+		{
+			name:    "missing time limit",
+			content: "criteria:\n  - check: time_limit\n    points: 1\n",
+			wantErr: "limit must be a positive duration",
+		},
+		{
+			name:    "invalid memory limit",
+			content: "criteria:\n  - check: memory_limit\n    limit: lots\n    points: 1\n",
+			wantErr: "limit must be a positive size",
+		},
+		{
+			name:    "limit on another check",
+			content: "criteria:\n  - check: matches\n    limit: 2s\n    points: 1\n",
+			wantErr: "limit only applies",
+		},
+		{
+			name:    "exit code on another check",
+			content: "criteria:\n  - check: matches\n    exit_code: 1\n    points: 1\n",
+			wantErr: "exit_code only applies",
+		},
+		{
+			name:    "duplicate name",
+			content: "criteria:\n  - check: runs\n    points: 1\n  - check: runs\n    points: 1\n",
+			wantErr: `criterion 2: duplicate name "runs"`,
+		},
+		{
+			name:    "requires a later criterion",
+			content: "criteria:\n  - check: time_limit\n    limit: 1s\n    points: 1\n    requires: [matches]\n  - check: matches\n    points: 1\n",
+			wantErr: `requires "matches", which is not an earlier criterion`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(writeRubric(t, tt.content))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	rubric, err := Load(writeRubric(t, `criteria:
+  - check: compiles
+    points: 10
+  - check: runs
+    points: 20
+  - name: correct
+    check: matches
+    points: 50
+  - check: time_limit
+    limit: 1s
+    points: 10
+    requires: [correct]
+  - check: memory_limit
+    limit: 1MiB
+    points: 10
+    requires: [correct]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		result     output.Result
+		wantTotal  string
+		wantReason map[string]string // failed criteria
+	}{
+		{
+			name:      "full marks",
+			result:    output.Result{Status: "success", ExecutionTime: 300, MaxRSSKB: 512},
+			wantTotal: "100",
+		},
+		{
+			name:      "wrong answer",
+			result:    output.Result{Status: "failed", ExecutionTime: 300, MaxRSSKB: 512},
+			wantTotal: "30",
+			wantReason: map[string]string{
+				"correct":      "status failed",
+				"time_limit":   "requires correct",
+				"memory_limit": "requires correct",
+			},
+		},
+		{
+			name:      "slow and memory not measured",
+			result:    output.Result{Status: "success", ExecutionTime: 1500},
+			wantTotal: "80",
+			wantReason: map[string]string{
+				"time_limit":   "took 1500ms, limit 1s",
+				"memory_limit": "peak memory was not measured",
+			},
+		},
+		{
+			name:      "crash",
+			result:    output.Result{Status: "failed", ExitCode: -1, Signal: "SIGSEGV"},
+			wantTotal: "10",
+			wantReason: map[string]string{
+				"runs":         "killed by SIGSEGV",
+				"correct":      "status failed",
+				"time_limit":   "requires correct",
+				"memory_limit": "requires correct",
+			},
+		},
+		{
+			name:      "command not found",
+			result:    output.Result{Status: "failed", ExitCode: 127},
+			wantTotal: "0",
+			wantReason: map[string]string{
+				"compiles":     "command not found (exit code 127)",
+				"runs":         "exit code 127, expected 0",
+				"correct":      "status failed",
+				"time_limit":   "requires correct",
+				"memory_limit": "requires correct",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breakdown := rubric.Evaluate(&tt.result)
+			if breakdown.Total.String() != tt.wantTotal || breakdown.Max.String() != "100" {
+				t.Errorf("total = %s of %s, want %s of 100", breakdown.Total, breakdown.Max, tt.wantTotal)
+			}
+			for _, c := range breakdown.Criteria {
+				want := tt.wantReason[c.Name]
+				if c.Reason != want || c.Passed != (want == "") {
+					t.Errorf("%s: passed = %v, reason = %q, want reason %q", c.Name, c.Passed, c.Reason, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEvaluateNoWarnings(t *testing.T) {
+	rubric, err := Load(writeRubric(t, "criteria:\n  - check: no_warnings\n    points: 5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		result     output.Result
+		wantReason string
+	}{
+		{name: "clean", result: output.Result{Diagnostics: &output.Diagnostics{Format: "gcc", Errors: 1}}},
+		{name: "warnings", result: output.Result{Diagnostics: &output.Diagnostics{Format: "gcc", Warnings: 2}}, wantReason: "2 warnings"},
+		{
+			name:       "build log warning",
+			result:     output.Result{Diagnostics: &output.Diagnostics{Format: "gcc"}, Build: &output.BuildResult{Diagnostics: &output.Diagnostics{Format: "gcc", Warnings: 1}}},
+			wantReason: "1 warning",
+		},
+		{name: "not classified", wantReason: "stderr was not classified (--stderr-classify)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := rubric.Evaluate(&tt.result).Criteria[0]
+			if c.Reason != tt.wantReason || c.Passed != (tt.wantReason == "") {
+				t.Errorf("passed = %v, reason = %q, want reason %q", c.Passed, c.Reason, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultExecutor is the executor used when none is configured
+const DefaultExecutor = "local"
+
+// Executor runs a single command on an execution backend
+// Execute handles policy checks, dry runs, pipelines, builtins and reporting;
+// an executor only runs Command with Args reading InputFile and writing
+// OutputFile and StderrFile (honouring the filters and tee target), enforces
+// the timeout and reports how the command finished. Exit code expectations are
+// applied by Execute.
+type Executor interface {
+	// Name returns the name the executor is selected by (--executor)
+	Name() string
+
+	// Run executes config.Command and waits for it to finish
+	// An error means the command could not be run at all.
+	Run(config *Config, verbose bool) (*Execution, error)
+}
+
+// Execution describes how a command run by an Executor finished
+type Execution struct {
+	ExitCode      int
+	TimedOut      bool
+	ExecutionTime int64 // milliseconds
+	MaxRSSKB      int64 // peak resident set size in kilobytes (0 if unavailable)
+	Interaction   *InteractionResult
+
+	// OOMKilled reports that the command was killed for exceeding its memory limit
+	OOMKilled bool
+
+	// Signal names the signal that killed the command (e.g. SIGSEGV, "" = none)
+	Signal     string
+	CoreDumped bool
+
+	// Deadline names the timeout that stopped the command when TimedOut:
+	// DeadlineSoft (it exited after SIGTERM) or DeadlineHard (it was killed)
+	Deadline string
+
+	// Stalled reports that the command was killed for blocking on stdin
+	// longer than Config.StdinTimeout
+	Stalled bool
+
+	// LimitReached describes a resource limit the command reached ("" = none)
+	// A run that does not succeed is then reported as resource_exceeded.
+	LimitReached string
+}
+
+// ExecutorOptions holds backend-specific settings such as a container image
+type ExecutorOptions map[string]string
+
+// ExecutorFactory creates an executor from its options, validating them
+type ExecutorFactory func(options ExecutorOptions) (Executor, error)
+
+// executors holds all available executors by name
+var executors = make(map[string]ExecutorFactory)
+
+// RegisterExecutor registers an execution backend
+func RegisterExecutor(name string, factory ExecutorFactory) {
+	executors[name] = factory
+}
+
+// NewExecutor creates an executor by name ("" = DefaultExecutor)
+func NewExecutor(name string, options ExecutorOptions) (Executor, error) {
+	if name == "" {
+		name = DefaultExecutor
+	}
+	factory, ok := executors[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown executor %q (available: %s)", name, strings.Join(ExecutorNames(), ", "))
+	}
+	return factory(options)
+}
+
+// ExecutorNames returns the names of all registered executors in sorted order
+func ExecutorNames() []string {
+	names := make([]string, 0, len(executors))
+	for name := range executors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateResourceLimits checks that the executor can enforce the memory and CPU limits
+func ValidateResourceLimits(executor Executor, memoryLimit int64, cpuLimit float64) error {
+	if memoryLimit < 0 {
+		return fmt.Errorf("invalid memory limit %d: must be 0 (unlimited) or positive", memoryLimit)
+	}
+	if cpuLimit < 0 {
+		return fmt.Errorf("invalid CPU limit %v: must be 0 (unlimited) or positive", cpuLimit)
+	}
+	if _, ok := executor.(resourceLimiter); !ok && (memoryLimit > 0 || cpuLimit > 0) {
+		return fmt.Errorf("--memory-limit and --cpu-limit are not enforced by the %s executor (use --executor docker or cgroup)", executor.Name())
+	}
+	return nil
+}
+
+// resourceLimiter is implemented by executors that enforce MemoryLimit and CPULimit
+type resourceLimiter interface {
+	enforcesResourceLimits()
+}
+
+// classify applies the exit code expectation and resource limits to an execution
+func (e *Execution) classify(config *Config) (Status, int, string) {
+	status, exitCode := StatusFailed, e.ExitCode
+	switch {
+	case e.TimedOut:
+		// Timeouts are never successful
+		status, exitCode = StatusTimeout, -1
+	case e.Stalled:
+		status, exitCode = StatusStalled, -1
+	case e.Signal == "" && isExpectedExitCode(config, e.ExitCode):
+		status = StatusSuccess
+	}
+
+	// A failed interaction script fails the run regardless of exit code
+	if e.Interaction != nil && e.Interaction.Error != "" && status == StatusSuccess {
+		status = StatusFailed
+	}
+
+	if e.LimitReached != "" && status != StatusSuccess {
+		return StatusResourceExceeded, exitCode, e.LimitReached
+	}
+	return status, exitCode, ""
+}
+
+func init() {
+	RegisterExecutor(DefaultExecutor, func(ExecutorOptions) (Executor, error) { return LocalExecutor{}, nil })
+}
@@ -0,0 +1,153 @@
+package runner
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeExecutor reports a canned execution instead of running anything
+type fakeExecutor struct {
+	execution Execution
+	ran       *Config
+}
+
+func (f *fakeExecutor) Name() string { return "fake" }
+
+func (f *fakeExecutor) Run(config *Config, verbose bool) (*Execution, error) {
+	f.ran = config
+	execution := f.execution
+	return &execution, nil
+}
+
+func TestNewExecutor(t *testing.T) {
+	for _, name := range []string{"", DefaultExecutor} {
+		executor, err := NewExecutor(name, nil)
+		if err != nil {
+			t.Fatalf("NewExecutor(%q) error = %v", name, err)
+		}
+		if executor.Name() != DefaultExecutor {
+			t.Errorf("NewExecutor(%q).Name() = %q, want %q", name, executor.Name(), DefaultExecutor)
+		}
+	}
+
+	// The cgroup backend is only available on Linux
+	_, err := NewExecutor("nope", nil)
+	if err == nil || !strings.Contains(err.Error(), `unknown executor "nope" (available: `) || !strings.Contains(err.Error(), "docker, local, ssh)") {
+		t.Errorf("NewExecutor(nope) error = %v", err)
+	}
+}
+
+func TestExecuteWithExecutor(t *testing.T) {
+	exitCode := 3
+	tests := []struct {
+		name          string
+		execution     Execution
+		expectExit    *int
+		expectNonzero bool
+		wantStatus    Status
+		wantExitCode  int
+		wantExceeded  bool
+		wantExecution int64
+	}{
+		{
+			name:          "success",
+			execution:     Execution{ExecutionTime: 42, MaxRSSKB: 1024},
+			wantStatus:    StatusSuccess,
+			wantExecution: 42,
+		},
+		{
+			name:         "non-zero exit",
+			execution:    Execution{ExitCode: 1},
+			wantStatus:   StatusFailed,
+			wantExitCode: 1,
+		},
+		{
+			name:         "expected exit code",
+			execution:    Execution{ExitCode: 3},
+			expectExit:   &exitCode,
+			wantStatus:   StatusSuccess,
+			wantExitCode: 3,
+		},
+		{
+			name:          "signal is not a nonzero exit",
+			execution:     Execution{ExitCode: -1, Signal: "SIGSEGV"},
+			expectNonzero: true,
+			wantStatus:    StatusFailed,
+			wantExitCode:  -1,
+		},
+		{
+			name:         "timeout",
+			execution:    Execution{ExitCode: 137, TimedOut: true},
+			wantStatus:   StatusTimeout,
+			wantExitCode: -1,
+		},
+		{
+			name:         "limit reached on failure",
+			execution:    Execution{ExitCode: 137, LimitReached: "memory limit reached"},
+			wantStatus:   StatusResourceExceeded,
+			wantExitCode: 137,
+			wantExceeded: true,
+		},
+		{
+			name:       "limit reached on success",
+			execution:  Execution{LimitReached: "memory limit reached"},
+			wantStatus: StatusSuccess,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			executor := &fakeExecutor{execution: tt.execution}
+			config := &Config{
+				Command:        "echo",
+				Args:           []string{"hello"},
+				InputFile:      "/dev/null",
+				OutputFile:     filepath.Join(dir, "output.txt"),
+				StderrFile:     filepath.Join(dir, "stderr.txt"),
+				ExpectExitCode: tt.expectExit,
+				ExpectNonzero:  tt.expectNonzero,
+				Executor:       executor,
+			}
+
+			result, err := Execute(config)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if executor.ran != config {
+				t.Fatal("Executor was not run")
+			}
+			if result.Status != tt.wantStatus || result.ExitCode != tt.wantExitCode {
+				t.Errorf("Result = %s/%d, want %s/%d", result.Status, result.ExitCode, tt.wantStatus, tt.wantExitCode)
+			}
+			if (result.ResourceExceeded != "") != tt.wantExceeded {
+				t.Errorf("ResourceExceeded = %q, want set: %v", result.ResourceExceeded, tt.wantExceeded)
+			}
+			if result.ExecutionTime != tt.wantExecution || result.MaxRSSKB != tt.execution.MaxRSSKB {
+				t.Errorf("ExecutionTime/MaxRSSKB = %d/%d", result.ExecutionTime, result.MaxRSSKB)
+			}
+			if result.Command != "echo hello" {
+				t.Errorf("Command = %q", result.Command)
+			}
+		})
+	}
+}
+
+func TestExecuteDryRunSkipsExecutor(t *testing.T) {
+	executor := &fakeExecutor{}
+	_, err := Execute(&Config{
+		Command:    "echo",
+		InputFile:  "/dev/null",
+		OutputFile: "out.txt",
+		StderrFile: "err.txt",
+		DryRun:     true,
+		Executor:   executor,
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if executor.ran != nil {
+		t.Error("Dry run should not run the executor")
+	}
+}
@@ -0,0 +1,407 @@
+//go:build linux
+
+package runner
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/zinc-sig/ghost/internal/redact"
+)
+
+// Ways the cgroup executor can place the command in its own cgroup
+const (
+	CgroupModeAuto     = "auto"     // cgroupfs when delegated, else systemd
+	CgroupModeCgroupfs = "cgroupfs" // a child of ghost's own delegated cgroup v2
+	CgroupModeSystemd  = "systemd"  // a transient scope created with systemd-run
+)
+
+// cgroupEnv tells a re-executed ghost to move itself into a cgroup before
+// running the command
+const cgroupEnv = "GHOST_CGROUP"
+
+// cpuMaxPeriod is the cpu.max period in microseconds
+const cpuMaxPeriod = 100000
+
+// CgroupExecutor runs commands locally in a cgroup of their own, so the memory
+// and CPU limits are enforced by the kernel without ghost running as root
+// If ghost's own cgroup v2 is delegated to its user (its children get the
+// memory and cpu controllers), the command runs in a new child cgroup created
+// through cgroupfs. Otherwise it runs in a transient scope of the systemd user
+// session (the system instance for root), created with systemd-run. The cgroup
+// is removed once the command has finished, with anything left running in it.
+type CgroupExecutor struct {
+	Mode       string // CgroupModeAuto, CgroupModeCgroupfs or CgroupModeSystemd
+	Root       string // cgroup v2 mount point
+	SelfCgroup string // cgroup membership file of ghost's own process
+	SystemdRun string // systemd-run binary to invoke
+	Systemctl  string // systemctl binary to invoke
+}
+
+// newCgroupExecutor creates a cgroup executor from the mode option
+func newCgroupExecutor(options ExecutorOptions) (Executor, error) {
+	mode := options["mode"]
+	switch mode {
+	case "":
+		mode = CgroupModeAuto
+	case CgroupModeAuto, CgroupModeCgroupfs, CgroupModeSystemd:
+	default:
+		return nil, fmt.Errorf("invalid cgroup mode %q: must be %s, %s or %s", mode, CgroupModeAuto, CgroupModeCgroupfs, CgroupModeSystemd)
+	}
+	return &CgroupExecutor{
+		Mode:       mode,
+		Root:       "/sys/fs/cgroup",
+		SelfCgroup: "/proc/self/cgroup",
+		SystemdRun: "systemd-run",
+		Systemctl:  "systemctl",
+	}, nil
+}
+
+// Name returns the executor name
+func (c *CgroupExecutor) Name() string {
+	return "cgroup"
+}
+
+// String describes the executor with its mode
+func (c *CgroupExecutor) String() string {
+	return fmt.Sprintf("cgroup (%s)", c.Mode)
+}
+
+// enforcesResourceLimits marks the memory and CPU limits as enforced by the cgroup
+func (c *CgroupExecutor) enforcesResourceLimits() {}
+
+// setsPriority marks the priority as applied like the local executor's
+func (c *CgroupExecutor) setsPriority(priority *Priority) error {
+	return LocalExecutor{}.setsPriority(priority)
+}
+
+// passesDescriptors marks ExtraFiles as inherited through the local executor
+func (c *CgroupExecutor) passesDescriptors() {}
+
+// watchesStdin marks StdinTimeout as detected through the local executor
+func (c *CgroupExecutor) watchesStdin() {}
+
+// Run places the command in a new cgroup with the limits and waits for it to finish
+func (c *CgroupExecutor) Run(config *Config, verbose bool) (*Execution, error) {
+	parent, delegateErr := "", errors.New("not tried")
+	if c.Mode != CgroupModeSystemd {
+		parent, delegateErr = delegatedCgroup(c.Root, c.SelfCgroup, requiredControllers(config))
+		if delegateErr == nil {
+			return c.runCgroupfs(config, parent, verbose)
+		}
+		if c.Mode == CgroupModeCgroupfs {
+			return nil, fmt.Errorf("failed to use a delegated cgroup: %w", delegateErr)
+		}
+	}
+
+	if err := c.systemdAvailable(); err != nil {
+		if c.Mode == CgroupModeAuto {
+			return nil, fmt.Errorf("no delegated cgroup (%v) and no systemd session (%v)", delegateErr, err)
+		}
+		return nil, fmt.Errorf("failed to use systemd: %w", err)
+	}
+	return c.runSystemd(config, verbose)
+}
+
+// runCgroupfs runs the command in a new child of the delegated cgroup parent
+func (c *CgroupExecutor) runCgroupfs(config *Config, parent string, verbose bool) (*Execution, error) {
+	// A command whose lookup fails is run as is so the local executor reports it
+	path, err := exec.LookPath(config.Command)
+	if err != nil {
+		return LocalExecutor{}.Run(config, verbose)
+	}
+
+	name, err := cgroupName()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(parent, name)
+	if err := createCgroup(dir, config.MemoryLimit, config.CPULimit); err != nil {
+		return nil, err
+	}
+	defer removeCgroup(dir)
+
+	// ghost re-executes itself to join the cgroup before exec'ing the command
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate ghost executable: %w", err)
+	}
+	client := *config
+	client.Command = self
+	client.Args = append([]string{path, config.Command}, config.Args...)
+	client.Env = append(append([]string(nil), config.Env...), cgroupEnv+"="+dir)
+	client.Executor = nil
+
+	execution, err := LocalExecutor{}.Run(&client, verbose)
+	if err != nil {
+		return nil, err
+	}
+	if kills, err := oomKills(filepath.Join(dir, "memory.events")); err == nil && kills > 0 {
+		execution.OOMKilled = true
+		execution.LimitReached = memoryLimitReached(config.MemoryLimit)
+	}
+	return execution, nil
+}
+
+// runSystemd runs the command in a transient systemd scope
+func (c *CgroupExecutor) runSystemd(config *Config, verbose bool) (*Execution, error) {
+	name, err := cgroupName()
+	if err != nil {
+		return nil, err
+	}
+	unit := name + ".scope"
+
+	// systemd-run execs the command in the scope, so timeouts reach it directly
+	client := *config
+	client.Command = c.SystemdRun
+	client.Args = append(c.systemdRunArgs(config, unit), config.Args...)
+	client.Executor = nil
+
+	execution, err := LocalExecutor{}.Run(&client, verbose)
+	if err != nil {
+		return nil, err
+	}
+
+	// A scope that failed stays loaded until it is reset, one that succeeded is
+	// collected at once
+	if result, err := c.systemctl("show", "--property=Result", unit); err == nil &&
+		strings.TrimSpace(result) == "Result=oom-kill" {
+		execution.OOMKilled = true
+		execution.LimitReached = memoryLimitReached(config.MemoryLimit)
+	}
+	_, _ = c.systemctl("reset-failed", unit)
+	return execution, nil
+}
+
+// systemdRunArgs builds the systemd-run arguments up to and including the command
+func (c *CgroupExecutor) systemdRunArgs(config *Config, unit string) []string {
+	var args []string
+	if os.Geteuid() != 0 {
+		args = append(args, "--user")
+	}
+	args = append(args, "--scope", "--quiet", "--unit", unit)
+	if config.MemoryLimit > 0 {
+		// No swap, so the limit is a hard cap
+		args = append(args, "--property", "MemoryMax="+strconv.FormatInt(config.MemoryLimit, 10),
+			"--property", "MemorySwapMax=0")
+	}
+	if config.CPULimit > 0 {
+		args = append(args, "--property", "CPUQuota="+strconv.FormatFloat(config.CPULimit*100, 'f', -1, 64)+"%")
+	}
+	return append(args, "--", config.Command)
+}
+
+// systemdAvailable checks that systemd-run exists and its service manager answers
+func (c *CgroupExecutor) systemdAvailable() error {
+	if _, err := exec.LookPath(c.SystemdRun); err != nil {
+		return err
+	}
+	_, err := c.systemctl("show", "--property=Version")
+	return err
+}
+
+// systemctl runs a systemctl command against the manager systemd-run uses
+func (c *CgroupExecutor) systemctl(args ...string) (string, error) {
+	if os.Geteuid() != 0 {
+		args = append([]string{"--user"}, args...)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(c.Systemctl, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %w: %s", c.Systemctl, strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// requiredControllers lists the cgroup controllers the limits of config need
+func requiredControllers(config *Config) []string {
+	var controllers []string
+	if config.MemoryLimit > 0 {
+		controllers = append(controllers, "memory")
+	}
+	if config.CPULimit > 0 {
+		controllers = append(controllers, "cpu")
+	}
+	return controllers
+}
+
+// delegatedCgroup returns the cgroup v2 directory of ghost's own process if
+// ghost may create children in it with the given controllers enabled
+// Controllers not yet enabled for its children are enabled if possible.
+func delegatedCgroup(root, selfCgroup string, controllers []string) (string, error) {
+	data, err := os.ReadFile(selfCgroup)
+	if err != nil {
+		return "", fmt.Errorf("failed to read cgroup membership: %w", err)
+	}
+	path, ok := cgroupV2Path(data)
+	if !ok {
+		return "", errors.New("ghost is not in a cgroup v2 hierarchy")
+	}
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err != nil {
+		return "", fmt.Errorf("cgroup v2 is not mounted at %s", root)
+	}
+	dir := filepath.Join(root, path)
+
+	enabled, err := os.ReadFile(filepath.Join(dir, "cgroup.subtree_control"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read controllers of %s: %w", dir, err)
+	}
+	var missing []string
+	for _, controller := range controllers {
+		if !containsField(string(enabled), controller) {
+			missing = append(missing, "+"+controller)
+		}
+	}
+	if len(missing) > 0 {
+		control := filepath.Join(dir, "cgroup.subtree_control")
+		if err := os.WriteFile(control, []byte(strings.Join(missing, " ")), 0644); err != nil {
+			return "", fmt.Errorf("cgroup %s does not delegate %s: %w", dir, strings.Join(missing, " "), err)
+		}
+	}
+	if err := unix.Access(dir, unix.W_OK); err != nil {
+		return "", fmt.Errorf("cgroup %s is not delegated: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// cgroupV2Path returns the cgroup v2 path in /proc/<pid>/cgroup content
+func cgroupV2Path(data []byte) (string, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if path, ok := strings.CutPrefix(scanner.Text(), "0::"); ok && path != "" {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// containsField reports whether the space-separated list s contains field
+func containsField(s, field string) bool {
+	for _, f := range strings.Fields(s) {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// createCgroup creates the cgroup dir with the memory (bytes) and CPU (cores) limits
+func createCgroup(dir string, memoryLimit int64, cpuLimit float64) error {
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cgroup: %w", err)
+	}
+	limits := map[string]string{}
+	if memoryLimit > 0 {
+		limits["memory.max"] = strconv.FormatInt(memoryLimit, 10)
+		// No swap, so the limit is a hard cap
+		if _, err := os.Stat(filepath.Join(dir, "memory.swap.max")); err == nil {
+			limits["memory.swap.max"] = "0"
+		}
+	}
+	if cpuLimit > 0 {
+		quota := max(int64(cpuLimit*cpuMaxPeriod), 1000)
+		limits["cpu.max"] = fmt.Sprintf("%d %d", quota, cpuMaxPeriod)
+	}
+	for file, value := range limits {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(value), 0644); err != nil {
+			removeCgroup(dir)
+			return fmt.Errorf("failed to set %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// removeCgroup kills whatever the command left in the cgroup dir and removes it
+func removeCgroup(dir string) {
+	_ = os.WriteFile(filepath.Join(dir, "cgroup.kill"), []byte("1"), 0644)
+	// Killed processes leave the cgroup asynchronously
+	for range 50 {
+		if err := os.Remove(dir); err == nil || errors.Is(err, os.ErrNotExist) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// oomKills returns the number of processes the OOM killer killed according
+// to a memory.events file
+func oomKills(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "oom_kill "); ok {
+			return strconv.Atoi(value)
+		}
+	}
+	return 0, nil
+}
+
+// memoryLimitReached describes the memory limit a command was killed for
+func memoryLimitReached(memoryLimit int64) string {
+	if memoryLimit > 0 {
+		return fmt.Sprintf("memory limit of %d bytes reached (--memory-limit)", memoryLimit)
+	}
+	return "cgroup ran out of memory"
+}
+
+// cgroupName returns a unique name for a ghost cgroup
+func cgroupName() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate cgroup name: %w", err)
+	}
+	return "ghost-" + hex.EncodeToString(b), nil
+}
+
+func init() {
+	RegisterExecutor("cgroup", newCgroupExecutor)
+
+	// The fork limit, network isolation and priority shims run first and re-execute this one
+	if _, ok := os.LookupEnv(forkLimitEnv); ok {
+		return
+	}
+	if _, ok := os.LookupEnv(priorityEnv); ok {
+		return
+	}
+	if _, ok := os.LookupEnv(noNetworkEnv); ok {
+		return
+	}
+	if dir, ok := os.LookupEnv(cgroupEnv); ok {
+		runCgroupShim(dir)
+	}
+}
+
+// runCgroupShim moves this process into the cgroup dir and replaces it with the command
+// Go cannot run code between fork and exec, so ghost re-executes itself as
+// "ghost <path> <argv...>" with cgroupEnv set; the command and everything it
+// starts then belong to the cgroup.
+func runCgroupShim(dir string) {
+	_ = os.Unsetenv(cgroupEnv)
+
+	err := errors.New("missing command")
+	if len(os.Args) >= 3 {
+		err = os.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte(strconv.Itoa(os.Getpid())), 0644)
+		if err == nil {
+			err = unix.Exec(os.Args[1], os.Args[2:], os.Environ())
+		}
+	}
+	fmt.Fprintf(redact.Stderr, "ghost: failed to join cgroup: %v\n", err)
+	os.Exit(127)
+}
@@ -0,0 +1,277 @@
+//go:build linux
+
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeSystemdRun emulates systemd-run --scope: it records its arguments and
+// execs the command after "--"
+const fakeSystemdRun = `#!/bin/sh
+echo "$@" > "$FAKE_SYSTEMD_DIR/run-args"
+while [ "$1" != "--" ]; do shift; done
+shift
+exec "$@"
+`
+
+// fakeSystemctl answers show with the scope result in $FAKE_SCOPE_RESULT and
+// records reset-failed
+const fakeSystemctl = `#!/bin/sh
+[ "$1" = "--user" ] && shift
+case "$1" in
+show)
+	case "$2" in
+	--property=Result) echo "Result=${FAKE_SCOPE_RESULT:-success}" ;;
+	*) echo "Version=255" ;;
+	esac ;;
+reset-failed)
+	echo "$2" >> "$FAKE_SYSTEMD_DIR/reset" ;;
+esac
+`
+
+func newFakeSystemd(t *testing.T, mode string) (*CgroupExecutor, string) {
+	t.Helper()
+	dir := t.TempDir()
+	systemdRun := filepath.Join(dir, "systemd-run")
+	systemctl := filepath.Join(dir, "systemctl")
+	if err := os.WriteFile(systemdRun, []byte(fakeSystemdRun), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(systemctl, []byte(fakeSystemctl), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FAKE_SYSTEMD_DIR", dir)
+
+	// An empty root has no cgroup v2 hierarchy, so auto mode falls back to systemd
+	selfCgroup := filepath.Join(dir, "cgroup")
+	_ = os.WriteFile(selfCgroup, []byte("0::/user.slice\n"), 0644)
+	return &CgroupExecutor{
+		Mode:       mode,
+		Root:       t.TempDir(),
+		SelfCgroup: selfCgroup,
+		SystemdRun: systemdRun,
+		Systemctl:  systemctl,
+	}, dir
+}
+
+func TestNewCgroupExecutor(t *testing.T) {
+	executor, err := NewExecutor("cgroup", nil)
+	if err != nil {
+		t.Fatalf("NewExecutor(cgroup) error = %v", err)
+	}
+	if got := executor.(*CgroupExecutor).String(); got != "cgroup (auto)" {
+		t.Errorf("String() = %q", got)
+	}
+	if _, err := NewExecutor("cgroup", ExecutorOptions{"mode": "v1"}); err == nil || !strings.Contains(err.Error(), `invalid cgroup mode "v1"`) {
+		t.Errorf("NewExecutor(cgroup, mode=v1) error = %v", err)
+	}
+	if err := ValidateResourceLimits(executor, 1<<30, 2); err != nil {
+		t.Errorf("ValidateResourceLimits() error = %v", err)
+	}
+}
+
+func TestCgroupV2Path(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		want   string
+		wantOK bool
+	}{
+		{name: "unified", data: "0::/user.slice/user-1000.slice/session-2.scope\n", want: "/user.slice/user-1000.slice/session-2.scope", wantOK: true},
+		{name: "hybrid", data: "4:memory:/grading\n1:name=systemd:/\n0::/grading\n", want: "/grading", wantOK: true},
+		{name: "legacy only", data: "4:memory:/grading\n1:name=systemd:/\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := cgroupV2Path([]byte(tt.data))
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("cgroupV2Path() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDelegatedCgroup(t *testing.T) {
+	// A fake cgroupfs whose files are plain files
+	newRoot := func(t *testing.T, mounted bool, subtree string) (string, string) {
+		root := t.TempDir()
+		if mounted {
+			_ = os.WriteFile(filepath.Join(root, "cgroup.controllers"), []byte("cpu memory pids\n"), 0644)
+		}
+		dir := filepath.Join(root, "user.slice", "grader.scope")
+		_ = os.MkdirAll(dir, 0755)
+		_ = os.WriteFile(filepath.Join(dir, "cgroup.subtree_control"), []byte(subtree), 0644)
+		self := filepath.Join(t.TempDir(), "cgroup")
+		_ = os.WriteFile(self, []byte("0::/user.slice/grader.scope\n"), 0644)
+		return root, self
+	}
+
+	t.Run("controllers enabled", func(t *testing.T) {
+		root, self := newRoot(t, true, "memory\n")
+		dir, err := delegatedCgroup(root, self, []string{"memory", "cpu"})
+		if err != nil {
+			t.Fatalf("delegatedCgroup() error = %v", err)
+		}
+		if want := filepath.Join(root, "user.slice", "grader.scope"); dir != want {
+			t.Errorf("dir = %q, want %q", dir, want)
+		}
+		// Only the missing controller is enabled
+		if data, _ := os.ReadFile(filepath.Join(dir, "cgroup.subtree_control")); string(data) != "+cpu" {
+			t.Errorf("subtree_control = %q, want %q", data, "+cpu")
+		}
+	})
+
+	t.Run("not mounted", func(t *testing.T) {
+		root, self := newRoot(t, false, "")
+		if _, err := delegatedCgroup(root, self, nil); err == nil || !strings.Contains(err.Error(), "cgroup v2 is not mounted") {
+			t.Errorf("delegatedCgroup() error = %v", err)
+		}
+	})
+
+	t.Run("legacy hierarchy", func(t *testing.T) {
+		root, self := newRoot(t, true, "")
+		_ = os.WriteFile(self, []byte("4:memory:/grading\n"), 0644)
+		if _, err := delegatedCgroup(root, self, nil); err == nil || !strings.Contains(err.Error(), "not in a cgroup v2 hierarchy") {
+			t.Errorf("delegatedCgroup() error = %v", err)
+		}
+	})
+}
+
+func TestCreateCgroup(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ghost-test")
+	if err := createCgroup(dir, 64<<20, 1.5); err != nil {
+		t.Fatalf("createCgroup() error = %v", err)
+	}
+	want := map[string]string{"memory.max": "67108864", "cpu.max": "150000 100000"}
+	for file, value := range want {
+		if data, _ := os.ReadFile(filepath.Join(dir, file)); string(data) != value {
+			t.Errorf("%s = %q, want %q", file, data, value)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "memory.swap.max")); err == nil {
+		t.Error("memory.swap.max written although the cgroup has none")
+	}
+	if err := createCgroup(dir, 0, 0); err == nil {
+		t.Error("createCgroup() of an existing cgroup succeeded")
+	}
+}
+
+func TestOOMKills(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.events")
+	_ = os.WriteFile(path, []byte("low 0\nhigh 0\nmax 12\noom 1\noom_kill 1\noom_group_kill 0\n"), 0644)
+	if kills, err := oomKills(path); err != nil || kills != 1 {
+		t.Errorf("oomKills() = %d, %v, want 1", kills, err)
+	}
+	if _, err := oomKills(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("oomKills() of a missing file succeeded")
+	}
+}
+
+func TestCgroupExecutorSystemd(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         string
+		command      string
+		scopeResult  string
+		wantStatus   Status
+		wantExitCode int
+		wantExceeded string
+	}{
+		{
+			name:       "auto falls back to systemd",
+			mode:       CgroupModeAuto,
+			command:    "cat; echo done",
+			wantStatus: StatusSuccess,
+		},
+		{
+			name:         "exit code",
+			mode:         CgroupModeSystemd,
+			command:      "exit 3",
+			wantStatus:   StatusFailed,
+			wantExitCode: 3,
+		},
+		{
+			name:         "out of memory",
+			mode:         CgroupModeSystemd,
+			command:      "kill -9 $$",
+			scopeResult:  "oom-kill",
+			wantStatus:   StatusResourceExceeded,
+			wantExitCode: -1,
+			wantExceeded: "memory limit of 67108864 bytes reached (--memory-limit)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor, systemdDir := newFakeSystemd(t, tt.mode)
+			t.Setenv("FAKE_SCOPE_RESULT", tt.scopeResult)
+
+			dir := t.TempDir()
+			inputFile := filepath.Join(dir, "input.txt")
+			outputFile := filepath.Join(dir, "output.txt")
+			_ = os.WriteFile(inputFile, []byte("hello scope\n"), 0644)
+
+			result, err := Execute(&Config{
+				Command:     "sh",
+				Args:        []string{"-c", tt.command},
+				InputFile:   inputFile,
+				OutputFile:  outputFile,
+				StderrFile:  filepath.Join(dir, "stderr.txt"),
+				MemoryLimit: 64 << 20,
+				CPULimit:    1.5,
+				Executor:    executor,
+			})
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			if result.Status != tt.wantStatus || result.ExitCode != tt.wantExitCode {
+				t.Errorf("Result = %s/%d, want %s/%d", result.Status, result.ExitCode, tt.wantStatus, tt.wantExitCode)
+			}
+			if result.OOMKilled != (tt.wantExceeded != "") || result.ResourceExceeded != tt.wantExceeded {
+				t.Errorf("OOMKilled/ResourceExceeded = %v/%q, want %q", result.OOMKilled, result.ResourceExceeded, tt.wantExceeded)
+			}
+			if tt.wantStatus == StatusSuccess {
+				if data, _ := os.ReadFile(outputFile); string(data) != "hello scope\ndone\n" {
+					t.Errorf("Output = %q", data)
+				}
+			}
+
+			runArgs, _ := os.ReadFile(filepath.Join(systemdDir, "run-args"))
+			for _, want := range []string{"--scope", "MemoryMax=67108864", "MemorySwapMax=0", "CPUQuota=150%", "-- sh -c"} {
+				if !strings.Contains(string(runArgs), want) {
+					t.Errorf("systemd-run args %q do not contain %q", runArgs, want)
+				}
+			}
+			if reset, _ := os.ReadFile(filepath.Join(systemdDir, "reset")); !strings.HasPrefix(string(reset), "ghost-") {
+				t.Errorf("Scope was not reset: %q", reset)
+			}
+		})
+	}
+}
+
+func TestCgroupExecutorUnavailable(t *testing.T) {
+	executor, _ := newFakeSystemd(t, CgroupModeAuto)
+	executor.SystemdRun = filepath.Join(t.TempDir(), "missing")
+
+	dir := t.TempDir()
+	config := &Config{
+		Command:    "true",
+		InputFile:  "/dev/null",
+		OutputFile: filepath.Join(dir, "output.txt"),
+		StderrFile: filepath.Join(dir, "stderr.txt"),
+		Executor:   executor,
+	}
+	if _, err := Execute(config); err == nil || !strings.Contains(err.Error(), "no delegated cgroup") {
+		t.Errorf("Execute() error = %v", err)
+	}
+
+	executor.Mode = CgroupModeCgroupfs
+	if _, err := Execute(config); err == nil || !strings.Contains(err.Error(), "failed to use a delegated cgroup") {
+		t.Errorf("Execute() error = %v", err)
+	}
+}
@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Streams tagged in a combined capture file
+const (
+	CombinedStdout = "stdout"
+	CombinedStderr = "stderr"
+)
+
+// combinedCapture writes the stdout and stderr of a command to one file,
+// interleaved line by line in the order they are read, each line prefixed with
+// the tag of its stream, e.g. "[stderr] warning"
+// A nil combinedCapture captures nothing.
+type combinedCapture struct {
+	mu      sync.Mutex
+	w       io.Writer
+	partial map[string][]byte // incomplete last line of each stream
+}
+
+// openCombinedCapture opens the combined capture file of a command, if it has one
+func openCombinedCapture(config *Config) (*combinedCapture, func(), error) {
+	if config.CombinedFile == "" {
+		return nil, func() {}, nil
+	}
+	file, closeFile, err := openOutputFile(config.CombinedFile, os.Stdout, config.Append)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create combined capture file: %w", err)
+	}
+	return &combinedCapture{w: file, partial: map[string][]byte{}}, closeFile, nil
+}
+
+// tag also copies what is written to w into the combined file, tagged as stream
+func (c *combinedCapture) tag(w io.Writer, stream string) io.Writer {
+	if c == nil {
+		return w
+	}
+	return io.MultiWriter(w, &combinedStream{capture: c, stream: stream})
+}
+
+// write adds the complete lines of p to the combined file
+func (c *combinedCapture) write(stream string, p []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	buf := append(c.partial[stream], p...)
+	for {
+		i := bytes.IndexByte(buf, '\n')
+		if i < 0 {
+			break
+		}
+		if _, err := fmt.Fprintf(c.w, "[%s] %s", stream, buf[:i+1]); err != nil {
+			return err
+		}
+		buf = buf[i+1:]
+	}
+	c.partial[stream] = append([]byte(nil), buf...)
+	return nil
+}
+
+// flush writes the incomplete last lines once the command has finished
+func (c *combinedCapture) flush() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, stream := range []string{CombinedStdout, CombinedStderr} {
+		if len(c.partial[stream]) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(c.w, "[%s] %s\n", stream, c.partial[stream]); err != nil {
+			return fmt.Errorf("failed to write combined capture file: %w", err)
+		}
+		c.partial[stream] = nil
+	}
+	return nil
+}
+
+// combinedStream is the writer of one stream into a combined capture
+type combinedStream struct {
+	capture *combinedCapture
+	stream  string
+}
+
+func (s *combinedStream) Write(p []byte) (int, error) {
+	if err := s.capture.write(s.stream, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,101 @@
+//go:build linux || darwin
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCombinedCapture(t *testing.T) {
+	var buf bytes.Buffer
+	combined := &combinedCapture{w: &buf, partial: map[string][]byte{}}
+	stdout := combined.tag(io.Discard, CombinedStdout)
+	stderr := combined.tag(io.Discard, CombinedStderr)
+
+	_, _ = io.WriteString(stdout, "progress: ")
+	_, _ = io.WriteString(stderr, "warning\nsecond ")
+	_, _ = io.WriteString(stdout, "50%\n100%")
+	_, _ = io.WriteString(stderr, "warning\n")
+	if err := combined.flush(); err != nil {
+		t.Fatalf("flush() error = %v", err)
+	}
+
+	want := "[stderr] warning\n[stdout] progress: 50%\n[stderr] second warning\n[stdout] 100%\n"
+	if buf.String() != want {
+		t.Errorf("combined = %q, want %q", buf.String(), want)
+	}
+
+	// Without a combined file writers are left alone
+	var none *combinedCapture
+	if w := none.tag(&buf, CombinedStdout); w != &buf {
+		t.Error("tag() of a nil capture wrapped the writer")
+	}
+}
+
+func TestExecuteAppendAndCombined(t *testing.T) {
+	tmpDir := t.TempDir()
+	config := Config{
+		Command:      "sh",
+		Args:         []string{"-c", "echo out; sleep 0.05; echo DEBUG err >&2; sleep 0.05; echo err >&2"},
+		InputFile:    "/dev/null",
+		OutputFile:   filepath.Join(tmpDir, "output.txt"),
+		StderrFile:   filepath.Join(tmpDir, "stderr.txt"),
+		CombinedFile: filepath.Join(tmpDir, "logs", "combined.log"),
+		StderrFilter: mustRules(t, "drop:^DEBUG"),
+	}
+
+	// The first run truncates what was there, the second appends
+	_ = os.WriteFile(config.OutputFile, []byte("stale\n"), 0644)
+	for _, appendMode := range []bool{false, true} {
+		config.Append = appendMode
+		result, err := Execute(&config)
+		if err != nil {
+			t.Fatalf("Execute() error = %v", err)
+		}
+		if result.Status != StatusSuccess {
+			t.Fatalf("Status = %s, want success", result.Status)
+		}
+	}
+
+	want := map[string]string{
+		config.OutputFile:   "out\nout\n",
+		config.StderrFile:   "err\nerr\n",
+		config.CombinedFile: "[stdout] out\n[stderr] err\n[stdout] out\n[stderr] err\n",
+	}
+	for path, content := range want {
+		got, _ := os.ReadFile(path)
+		if string(got) != content {
+			t.Errorf("%s = %q, want %q", filepath.Base(path), got, content)
+		}
+	}
+}
+
+func TestExecuteCombinedBuiltin(t *testing.T) {
+	tmpDir := t.TempDir()
+	config := Config{
+		Command: "builtin",
+		Builtin: func(ctx context.Context, stdout, stderr io.Writer) int {
+			_, _ = fmt.Fprint(stdout, "a\n")
+			_, _ = fmt.Fprint(stderr, "b\n")
+			_, _ = fmt.Fprint(stdout, "c")
+			return 0
+		},
+		InputFile:    "/dev/null",
+		OutputFile:   filepath.Join(tmpDir, "output.txt"),
+		StderrFile:   filepath.Join(tmpDir, "stderr.txt"),
+		CombinedFile: filepath.Join(tmpDir, "combined.log"),
+	}
+	if _, err := Execute(&config); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	got, _ := os.ReadFile(config.CombinedFile)
+	if want := "[stdout] a\n[stderr] b\n[stdout] c\n"; string(got) != want {
+		t.Errorf("combined = %q, want %q", got, want)
+	}
+}
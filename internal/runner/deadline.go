@@ -0,0 +1,58 @@
+package runner
+
+import (
+	"sync"
+	"time"
+)
+
+// Deadlines that stop a command, reported when a soft timeout is set
+const (
+	DeadlineSoft = "soft" // SIGTERM at the soft timeout; the command exited on its own
+	DeadlineHard = "hard" // SIGKILL at the timeout
+)
+
+// softTimer asks the command to terminate once the soft timeout expires
+type softTimer struct {
+	timer *time.Timer
+
+	// mu is held while terminating, so fired is set before the command can
+	// exit in response
+	mu    sync.Mutex
+	fired bool
+}
+
+// startSoftTimer calls terminate after the soft timeout (nil if none is set)
+func startSoftTimer(timeout time.Duration, terminate func() error) *softTimer {
+	if timeout <= 0 {
+		return nil
+	}
+	t := &softTimer{}
+	t.timer = time.AfterFunc(timeout, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.fired = terminate() == nil
+	})
+	return t
+}
+
+// stop cancels the timer and reports whether it terminated the command
+func (t *softTimer) stop() bool {
+	if t == nil {
+		return false
+	}
+	t.timer.Stop()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.fired
+}
+
+// deadline names the deadline that stopped a command ("" if none did)
+func deadline(hard, soft bool) string {
+	switch {
+	case hard:
+		return DeadlineHard
+	case soft:
+		return DeadlineSoft
+	}
+	return ""
+}
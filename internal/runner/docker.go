@@ -0,0 +1,198 @@
+package runner
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// DockerExecutor runs commands in a fresh Docker container
+// The working directory is bind-mounted at the same path and used as the
+// container's working directory, so relative paths in the command resolve as
+// they do locally. The input file is streamed to the container's stdin and its
+// stdout and stderr are captured by ghost. The timeout, --max-forks and the
+// memory and CPU limits are enforced by Docker, and the container's own exit
+// code, OOM flag and run time are reported.
+type DockerExecutor struct {
+	Binary string // Docker CLI to invoke
+	Image  string
+}
+
+// newDockerExecutor creates a Docker executor from the image option
+func newDockerExecutor(options ExecutorOptions) (Executor, error) {
+	image := options["image"]
+	if image == "" {
+		return nil, fmt.Errorf("the docker executor requires an image (--image)")
+	}
+	return &DockerExecutor{Binary: "docker", Image: image}, nil
+}
+
+// Name returns the executor name
+func (d *DockerExecutor) Name() string {
+	return "docker"
+}
+
+// String describes the executor with its image
+func (d *DockerExecutor) String() string {
+	return fmt.Sprintf("docker (%s)", d.Image)
+}
+
+// Run starts a container for the command and waits for it to finish
+func (d *DockerExecutor) Run(config *Config, verbose bool) (*Execution, error) {
+	workdir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+	name, err := containerName()
+	if err != nil {
+		return nil, err
+	}
+
+	// The container is kept until its state has been inspected
+	defer func() { _ = d.docker("rm", "--force", name) }()
+
+	// The Docker CLI itself runs locally, streaming the container's stdio;
+	// the timeout stops the container rather than the CLI
+	client := *config
+	client.Command = d.Binary
+	client.Args = append(d.runArgs(config, name, workdir), config.Args...)
+	client.Timeout = 0
+	client.SoftTimeout = 0
+	client.MaxForks = 0
+	client.Priority = nil
+	client.Executor = nil
+	// The CLI needs the Docker daemon; the container itself runs with --network none
+	client.NoNetwork = false
+
+	var timedOut atomic.Bool
+	var timer *time.Timer
+	if config.Timeout > 0 {
+		timer = time.AfterFunc(config.Timeout, func() {
+			timedOut.Store(true)
+			_ = d.docker("kill", name)
+		})
+	}
+	soft := startSoftTimer(config.SoftTimeout, func() error {
+		return d.docker("kill", "--signal", "TERM", name)
+	})
+	execution, err := LocalExecutor{}.Run(&client, verbose)
+	if timer != nil {
+		timer.Stop()
+	}
+	softFired := soft.stop()
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := d.inspect(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run container (%s exited with code %d, see the stderr file): %w", d.Binary, execution.ExitCode, err)
+	}
+
+	execution.ExitCode = state.ExitCode
+	execution.Deadline = deadline(timedOut.Load(), softFired)
+	execution.TimedOut = execution.Deadline != ""
+	execution.OOMKilled = state.OOMKilled
+	// Memory sampling measured the Docker CLI, not the container
+	execution.MaxRSSKB = 0
+	if ms, ok := state.duration(); ok {
+		execution.ExecutionTime = ms
+	}
+	if state.OOMKilled {
+		execution.LimitReached = "container ran out of memory"
+		if config.MemoryLimit > 0 {
+			execution.LimitReached = fmt.Sprintf("memory limit of %d bytes reached (--memory-limit)", config.MemoryLimit)
+		}
+	}
+	return execution, nil
+}
+
+// enforcesResourceLimits marks the memory and CPU limits as enforced by Docker
+func (d *DockerExecutor) enforcesResourceLimits() {}
+
+// runArgs builds the docker run arguments up to and including the command
+func (d *DockerExecutor) runArgs(config *Config, name, workdir string) []string {
+	args := []string{"run", "--name", name, "--interactive",
+		"--volume", workdir + ":" + workdir, "--workdir", workdir}
+	if config.NoNetwork {
+		args = append(args, "--network", "none")
+	}
+	for _, kv := range config.Env {
+		args = append(args, "--env", kv)
+	}
+	if config.MaxForks > 0 {
+		args = append(args, "--pids-limit", strconv.Itoa(config.MaxForks))
+	}
+	if config.MemoryLimit > 0 {
+		// Equal swap and memory limits disable swap, so the limit is a hard cap
+		memory := strconv.FormatInt(config.MemoryLimit, 10)
+		args = append(args, "--memory", memory, "--memory-swap", memory)
+	}
+	if config.CPULimit > 0 {
+		args = append(args, "--cpus", strconv.FormatFloat(config.CPULimit, 'f', -1, 64))
+	}
+	return append(args, d.Image, config.Command)
+}
+
+// containerState is the part of `docker inspect` State that ghost reports
+type containerState struct {
+	ExitCode   int
+	OOMKilled  bool
+	StartedAt  string
+	FinishedAt string
+}
+
+// duration returns how long the container ran in milliseconds
+func (s *containerState) duration() (int64, bool) {
+	started, err := time.Parse(time.RFC3339Nano, s.StartedAt)
+	if err != nil {
+		return 0, false
+	}
+	finished, err := time.Parse(time.RFC3339Nano, s.FinishedAt)
+	if err != nil || finished.Before(started) {
+		return 0, false
+	}
+	return finished.Sub(started).Milliseconds(), true
+}
+
+// inspect returns the state of a finished container
+func (d *DockerExecutor) inspect(name string) (*containerState, error) {
+	out, err := exec.Command(d.Binary, "inspect", "--format", "{{json .State}}", name).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	var state containerState
+	if err := json.Unmarshal(out, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse container state: %w", err)
+	}
+	return &state, nil
+}
+
+// docker runs a Docker CLI command, discarding its output
+func (d *DockerExecutor) docker(args ...string) error {
+	out, err := exec.Command(d.Binary, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s: %w: %s", d.Binary, args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// containerName returns a unique name for a ghost container
+func containerName() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate container name: %w", err)
+	}
+	return "ghost-" + hex.EncodeToString(b), nil
+}
+
+func init() {
+	RegisterExecutor("docker", newDockerExecutor)
+}
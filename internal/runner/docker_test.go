@@ -0,0 +1,252 @@
+//go:build linux || darwin
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeDocker emulates the docker CLI: run executes the command locally and
+// records the container state for inspect, kill stops it
+const fakeDocker = `#!/bin/sh
+dir="$FAKE_DOCKER_DIR"
+cmd="$1"; shift
+case "$cmd" in
+run)
+	echo "$@" > "$dir/run-args"
+	cut -d: -f1 /proc/net/dev > "$dir/client-net" 2>/dev/null
+	while [ $# -gt 0 ]; do
+		case "$1" in
+		--name) name="$2"; shift 2 ;;
+		--interactive) shift ;;
+		--*) shift 2 ;;
+		*) break ;;
+		esac
+	done
+	shift # image
+	exec 3<&0 # background jobs otherwise read /dev/null
+	"$@" <&3 &
+	echo $! > "$dir/$name.pid"
+	wait $!
+	code=$?
+	echo "{\"ExitCode\":$code,\"OOMKilled\":${FAKE_OOM:-false},\"StartedAt\":\"2024-01-01T00:00:00Z\",\"FinishedAt\":\"2024-01-01T00:00:01.5Z\"}" > "$dir/$name.state"
+	exit $code ;;
+inspect)
+	eval "name=\${$#}"
+	cat "$dir/$name.state" ;;
+kill)
+	kill -9 "$(cat "$dir/$1.pid")" ;;
+rm)
+	rm -f "$dir/$2.state" && echo "$2" >> "$dir/removed" ;;
+esac
+`
+
+func newFakeDocker(t *testing.T) (*DockerExecutor, string) {
+	t.Helper()
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "docker")
+	if err := os.WriteFile(binary, []byte(fakeDocker), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FAKE_DOCKER_DIR", dir)
+	return &DockerExecutor{Binary: binary, Image: "golang:1.22"}, dir
+}
+
+func TestNewDockerExecutor(t *testing.T) {
+	if _, err := NewExecutor("docker", nil); err == nil || !strings.Contains(err.Error(), "requires an image") {
+		t.Errorf("NewExecutor(docker) without image error = %v", err)
+	}
+	executor, err := NewExecutor("docker", ExecutorOptions{"image": "golang:1.22"})
+	if err != nil {
+		t.Fatalf("NewExecutor(docker) error = %v", err)
+	}
+	if got := executor.(*DockerExecutor).String(); got != "docker (golang:1.22)" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestDockerExecutor(t *testing.T) {
+	tests := []struct {
+		name         string
+		command      string
+		timeout      time.Duration
+		memoryLimit  int64
+		oom          bool
+		wantStatus   Status
+		wantExitCode int
+		wantOutput   string
+		wantOOM      bool
+		wantExceeded string
+	}{
+		{
+			name:       "stdin streamed and output captured",
+			command:    "cat; echo done",
+			wantStatus: StatusSuccess,
+			wantOutput: "hello container\ndone\n",
+		},
+		{
+			name:         "container exit code",
+			command:      "exit 3",
+			wantStatus:   StatusFailed,
+			wantExitCode: 3,
+		},
+		{
+			name:         "timeout kills the container",
+			command:      "sleep 5",
+			timeout:      100 * time.Millisecond,
+			wantStatus:   StatusTimeout,
+			wantExitCode: -1,
+		},
+		{
+			name:         "out of memory",
+			command:      "exit 137",
+			memoryLimit:  64 << 20,
+			oom:          true,
+			wantStatus:   StatusResourceExceeded,
+			wantExitCode: 137,
+			wantOOM:      true,
+			wantExceeded: "memory limit of 67108864 bytes reached (--memory-limit)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor, dockerDir := newFakeDocker(t)
+			if tt.oom {
+				t.Setenv("FAKE_OOM", "true")
+			}
+
+			dir := t.TempDir()
+			inputFile := filepath.Join(dir, "input.txt")
+			outputFile := filepath.Join(dir, "output.txt")
+			_ = os.WriteFile(inputFile, []byte("hello container\n"), 0644)
+
+			result, err := Execute(&Config{
+				Command:     "sh",
+				Args:        []string{"-c", tt.command},
+				InputFile:   inputFile,
+				OutputFile:  outputFile,
+				StderrFile:  filepath.Join(dir, "stderr.txt"),
+				Timeout:     tt.timeout,
+				MaxForks:    32,
+				MemoryLimit: tt.memoryLimit,
+				CPULimit:    1.5,
+				Env:         []string{"LANG=C.UTF-8"},
+				NoNetwork:   true,
+				Executor:    executor,
+			})
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			if result.Status != tt.wantStatus || result.ExitCode != tt.wantExitCode {
+				t.Errorf("Result = %s/%d, want %s/%d", result.Status, result.ExitCode, tt.wantStatus, tt.wantExitCode)
+			}
+			if result.OOMKilled != tt.wantOOM || result.ResourceExceeded != tt.wantExceeded {
+				t.Errorf("OOMKilled/ResourceExceeded = %v/%q, want %v/%q", result.OOMKilled, result.ResourceExceeded, tt.wantOOM, tt.wantExceeded)
+			}
+			if result.Command != "sh -c "+tt.command {
+				t.Errorf("Command = %q", result.Command)
+			}
+			if tt.wantStatus != StatusTimeout && result.ExecutionTime != 1500 {
+				t.Errorf("ExecutionTime = %d, want the container's 1500", result.ExecutionTime)
+			}
+			if tt.wantOutput != "" {
+				if data, _ := os.ReadFile(outputFile); string(data) != tt.wantOutput {
+					t.Errorf("Output = %q, want %q", data, tt.wantOutput)
+				}
+			}
+
+			runArgs, _ := os.ReadFile(filepath.Join(dockerDir, "run-args"))
+			for _, want := range []string{"--network none", "--env LANG=C.UTF-8", "--pids-limit 32", "--cpus 1.5", "golang:1.22 sh -c"} {
+				if !strings.Contains(string(runArgs), want) {
+					t.Errorf("docker run args %q do not contain %q", runArgs, want)
+				}
+			}
+			if removed, _ := os.ReadFile(filepath.Join(dockerDir, "removed")); len(removed) == 0 {
+				t.Error("Container was not removed")
+			}
+		})
+	}
+}
+
+func TestDockerExecutorClientKeepsNetwork(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("network namespaces are only supported on linux")
+	}
+	executor, dockerDir := newFakeDocker(t)
+
+	dir := t.TempDir()
+	_, err := Execute(&Config{
+		Command:    "true",
+		InputFile:  "/dev/null",
+		OutputFile: filepath.Join(dir, "output.txt"),
+		StderrFile: filepath.Join(dir, "stderr.txt"),
+		NoNetwork:  true,
+		Executor:   executor,
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	// The CLI must reach the daemon, so it sees the same interfaces as ghost
+	want, err := exec.Command("cut", "-d:", "-f1", "/proc/net/dev").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dockerDir, "client-net")); string(got) != string(want) {
+		t.Errorf("docker CLI saw interfaces %q, want ghost's %q", got, want)
+	}
+}
+
+func TestDockerExecutorMissingContainer(t *testing.T) {
+	executor, _ := newFakeDocker(t)
+	executor.Binary = "false"
+
+	dir := t.TempDir()
+	_, err := Execute(&Config{
+		Command:    "true",
+		InputFile:  "/dev/null",
+		OutputFile: filepath.Join(dir, "output.txt"),
+		StderrFile: filepath.Join(dir, "stderr.txt"),
+		Executor:   executor,
+	})
+	if err == nil || !strings.Contains(err.Error(), "failed to run container (false exited with code 1") {
+		t.Errorf("Execute() error = %v", err)
+	}
+}
+
+func TestValidateResourceLimits(t *testing.T) {
+	docker := &DockerExecutor{Image: "alpine"}
+	tests := []struct {
+		name     string
+		executor Executor
+		memory   int64
+		cpus     float64
+		wantErr  string
+	}{
+		{name: "no limits locally", executor: LocalExecutor{}},
+		{name: "limits in a container", executor: docker, memory: 1 << 30, cpus: 2},
+		{name: "limits locally", executor: LocalExecutor{}, memory: 1 << 30, wantErr: "not enforced by the local executor"},
+		{name: "negative cpus", executor: docker, cpus: -1, wantErr: "invalid CPU limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateResourceLimits(tt.executor, tt.memory, tt.cpus)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
-	"syscall"
 	"time"
+
+	"github.com/zinc-sig/ghost/internal/linefilter"
+	"github.com/zinc-sig/ghost/internal/policy"
+	"github.com/zinc-sig/ghost/internal/redact"
 )
 
 // Status represents the execution status of a command
@@ -19,9 +21,29 @@ const (
 	StatusSuccess Status = "success"
 	StatusFailed  Status = "failed"
 	StatusTimeout Status = "timeout"
+
+	// StatusPolicyViolation means the command was refused by the policy and not executed
+	StatusPolicyViolation Status = "policy_violation"
+
+	// StatusResourceExceeded means the command failed after reaching a resource limit
+	StatusResourceExceeded Status = "resource_exceeded"
+
+	// StatusStalled means the command was killed after blocking on its exhausted
+	// input for longer than Config.StdinTimeout
+	StatusStalled Status = "stalled"
+
+	// StatusCompileError means the build of ghost build-run failed and the command was not run
+	StatusCompileError Status = "compile_error"
+)
+
+// Tee targets for the command's stdout
+const (
+	TeeStdout = "stdout"
+	TeeStderr = "stderr"
 )
 
 type Config struct {
+	RunID      string // Unique ID of this execution (display only)
 	Command    string
 	Args       []string
 	InputFile  string
@@ -30,6 +52,80 @@ type Config struct {
 	Verbose    bool
 	DryRun     bool
 	Timeout    time.Duration // 0 means no timeout
+
+	// SoftTimeout sends SIGTERM before Timeout kills the command (0 = none)
+	// It must be shorter than Timeout, letting the command flush its output.
+	SoftTimeout time.Duration
+
+	// StdinTimeout kills a command that stays blocked reading stdin once the
+	// input has no more data for this long, reporting StatusStalled (0 = off)
+	StdinTimeout time.Duration
+
+	// Env holds KEY=VALUE pairs added to the command's environment
+	Env []string
+
+	// TeeOutput also copies the command's stdout to ghost's stdout or stderr ("" = off)
+	TeeOutput string
+
+	// StreamOutput, if set, receives the output file's content as it is written,
+	// e.g. to upload it while the command runs (nil = off)
+	StreamOutput io.Writer
+
+	// Append adds to existing output, stderr and combined files instead of truncating them
+	Append bool
+
+	// CombinedFile, if set, also captures stdout and stderr interleaved in one
+	// file, each line tagged with its stream ("" = off)
+	CombinedFile string
+
+	// Line filters applied while writing the output and stderr files
+	StdoutFilter []linefilter.Rule
+	StderrFilter []linefilter.Rule
+
+	// NoNetwork runs the command without network access, with only loopback
+	NoNetwork bool
+
+	// MaxForks caps the processes the command may create (RLIMIT_NPROC, 0 = unlimited)
+	// The command runs in its own process group, which is killed when it finishes.
+	MaxForks int
+
+	// Priority sets the nice value and I/O class of the command (nil = inherited)
+	Priority *Priority
+
+	// Exit code expectations; by default only exit code 0 counts as success
+	ExpectExitCode *int // Exact exit code that counts as success
+	ExpectNonzero  bool // Any non-zero exit code counts as success
+
+	// Descriptors maps further files to descriptors 3 and up (local and cgroup executors)
+	Descriptors []FileDescriptor
+
+	// Interaction drives stdin/stdout with a script instead of redirecting InputFile
+	Interaction *InteractionScript
+
+	// Policy, if set, restricts which commands may be executed
+	Policy *policy.Policy
+
+	// Pipeline, if set, runs these steps with each step's stdout piped to the
+	// next step's stdin instead of Command
+	Pipeline []Step
+
+	// Grader, if set, runs alongside Command with their stdin and stdout connected
+	// to each other, and judges it (see runGrader)
+	Grader *Step
+
+	// GraderStderrFile, if set, captures the grader's stderr ("" = only its verdict is kept)
+	GraderStderrFile string
+
+	// Executor runs Command (nil = LocalExecutor)
+	Executor Executor
+
+	// Resource limits enforced by the docker and cgroup executors (0 = unlimited)
+	MemoryLimit int64   // bytes
+	CPULimit    float64 // CPU cores
+
+	// Builtin, if set, runs in-process instead of Command and returns the exit code
+	// Command and Args are still used for display and the result.
+	Builtin func(ctx context.Context, stdout, stderr io.Writer) int
 }
 
 type Result struct {
@@ -37,27 +133,247 @@ type Result struct {
 	Status        Status
 	ExitCode      int
 	ExecutionTime int64 // milliseconds
+	MaxRSSKB      int64 // peak resident set size in kilobytes (0 if unavailable)
+	Interaction   *InteractionResult
+	Steps         []StepResult  // per-step results of a pipeline
+	Grader        *GraderResult // grader of an interactive run
+
+	PolicyViolation  string // reason the policy refused the command, if it did
+	ResourceExceeded string // resource limit the command reached, if it did
+	OOMKilled        bool   // the command was killed for exceeding its memory limit
+	Signal           string // signal that killed the command (e.g. SIGSEGV), if one did
+	CoreDumped       bool   // the signalled command dumped core
+	Deadline         string // DeadlineSoft or DeadlineHard when a soft timeout was set and one fired
+	NetworkIsolated  bool   // the command ran without network access
+}
+
+// isExpectedExitCode reports whether the exit code satisfies the configured expectation
+// A command killed by a signal has no exit code (-1) and never satisfies one.
+func isExpectedExitCode(config *Config, exitCode int) bool {
+	switch {
+	case exitCode < 0:
+		return false
+	case config.ExpectExitCode != nil:
+		return exitCode == *config.ExpectExitCode
+	case config.ExpectNonzero:
+		return exitCode != 0
+	default:
+		return exitCode == 0
+	}
 }
 
 // createFileWithDir creates a file and any necessary parent directories
-func createFileWithDir(path string) (*os.File, error) {
+// With appendMode an existing file is appended to instead of truncated.
+func createFileWithDir(path string, appendMode bool) (*os.File, error) {
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
 	}
-	file, err := os.Create(path)
+	flags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	if appendMode {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	file, err := os.OpenFile(path, flags, 0666)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create file %s: %w", path, err)
 	}
 	return file, nil
 }
 
+// StreamPath is the output target that refers to ghost's own stdout or stderr
+const StreamPath = "-"
+
+// IsStreamTarget reports whether path is "-" or an existing non-regular file
+// such as a FIFO, process substitution (/dev/fd/N) or character device
+func IsStreamTarget(path string) bool {
+	if path == StreamPath {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && !info.Mode().IsRegular() && !info.IsDir()
+}
+
+// openOutputFile opens an output target for writing
+// "-" maps to std (ghost's own stdout or stderr). Stream targets are opened for
+// writing without truncation or directory creation, so FIFOs block until a reader
+// connects; regular paths are created with createFileWithDir, appending to them
+// with appendMode. The returned close function never closes std.
+func openOutputFile(path string, std *os.File, appendMode bool) (*os.File, func(), error) {
+	if path == StreamPath {
+		return std, func() {}, nil
+	}
+
+	var file *os.File
+	var err error
+	if IsStreamTarget(path) {
+		file, err = os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			err = fmt.Errorf("failed to open %s: %w", path, err)
+		}
+	} else {
+		file, err = createFileWithDir(path, appendMode)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, func() { _ = file.Close() }, nil
+}
+
+// ValidateTeeOutput checks a --tee-output target
+func ValidateTeeOutput(target string) error {
+	switch target {
+	case "", TeeStdout, TeeStderr:
+		return nil
+	default:
+		return fmt.Errorf("invalid tee output %q: must be %s or %s", target, TeeStdout, TeeStderr)
+	}
+}
+
+// teeOutput wraps the output capture so the command's stdout is also copied to
+// the configured tee target, unless the output file already is that target
+// The tee target receives the unfiltered output.
+func teeOutput(config *Config, outputFile *os.File, capture io.Writer) io.Writer {
+	var tee *os.File
+	switch config.TeeOutput {
+	case TeeStdout:
+		tee = os.Stdout
+	case TeeStderr:
+		tee = os.Stderr
+	default:
+		return capture
+	}
+	if outputFile == tee {
+		return capture
+	}
+	return io.MultiWriter(capture, tee)
+}
+
+// streamOutput also passes what is written to the output file to config.StreamOutput
+func streamOutput(config *Config, outputFile io.Writer) io.Writer {
+	if config.StreamOutput == nil {
+		return outputFile
+	}
+	return io.MultiWriter(outputFile, config.StreamOutput)
+}
+
+// filterCapture wraps a capture file with line filters
+// The returned flush writes a trailing partial line and must be called once the
+// command has finished writing. Without rules a file is returned as is, so the
+// command writes to it directly.
+func filterCapture(w io.Writer, rules []linefilter.Rule) (io.Writer, func() error) {
+	if len(rules) == 0 {
+		return w, func() error { return nil }
+	}
+	filtered := linefilter.NewWriter(w, rules)
+	return filtered, filtered.Flush
+}
+
+// flushCaptures flushes the filtered output and stderr captures, then the
+// combined capture they feed
+func flushCaptures(flushOutput, flushStderr func() error, combined *combinedCapture) error {
+	if err := flushOutput(); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := flushStderr(); err != nil {
+		return fmt.Errorf("failed to write stderr file: %w", err)
+	}
+	return combined.flush()
+}
+
+// refuseExecution builds the result for a command refused by the policy
+// Empty output and stderr files are still created so downstream steps find them.
+func refuseExecution(config *Config, fullCommand string, violation error, verbose bool) (*Result, error) {
+	if !config.DryRun {
+		for _, path := range []string{config.OutputFile, config.StderrFile, config.CombinedFile} {
+			if path == "" || path == StreamPath {
+				continue
+			}
+			_, closeFile, err := openOutputFile(path, nil, config.Append)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create output file: %w", err)
+			}
+			closeFile()
+		}
+	}
+
+	if verbose {
+		fmt.Fprintf(redact.Stderr, "[POLICY] %v\n", violation)
+		PrintPostExecution(StatusPolicyViolation, -1, 0, 0, config.DryRun)
+	}
+
+	return &Result{
+		Command:         fullCommand,
+		Status:          StatusPolicyViolation,
+		ExitCode:        -1,
+		PolicyViolation: violation.Error(),
+	}, nil
+}
+
+// runBuiltin runs an in-process command with the same output files, timeout and
+// exit code expectations as an external command
+func runBuiltin(config *Config, verbose bool) (Status, int, int64, error) {
+	ctx := context.Background()
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+
+	outputFile, closeOutput, err := openOutputFile(config.OutputFile, os.Stdout, config.Append)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer closeOutput()
+
+	stderrFile, closeStderr, err := openOutputFile(config.StderrFile, os.Stderr, config.Append)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("failed to create stderr file: %w", err)
+	}
+	defer closeStderr()
+
+	combined, closeCombined, err := openCombinedCapture(config)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	defer closeCombined()
+
+	capture, flushOutput := filterCapture(combined.tag(streamOutput(config, outputFile), CombinedStdout), config.StdoutFilter)
+	stderrCapture, flushStderr := filterCapture(combined.tag(stderrFile, CombinedStderr), config.StderrFilter)
+	stderr := stderrCapture
+	if verbose && config.StderrFile != StreamPath {
+		stderr = io.MultiWriter(stderrCapture, os.Stderr)
+	}
+
+	startTime := time.Now()
+	exitCode := config.Builtin(ctx, teeOutput(config, outputFile, capture), stderr)
+	executionTime := time.Since(startTime).Milliseconds()
+	if err := flushCaptures(flushOutput, flushStderr, combined); err != nil {
+		return "", 0, 0, err
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return StatusTimeout, -1, executionTime, nil
+	}
+	if isExpectedExitCode(config, exitCode) {
+		return StatusSuccess, exitCode, executionTime, nil
+	}
+	return StatusFailed, exitCode, executionTime, nil
+}
+
+// FullCommand returns the command line reported in the result
+func (c *Config) FullCommand() string {
+	if len(c.Pipeline) > 0 {
+		return PipelineCommand(c.Pipeline)
+	}
+	if len(c.Args) > 0 {
+		return c.Command + " " + strings.Join(c.Args, " ")
+	}
+	return c.Command
+}
+
 func Execute(config *Config) (*Result, error) {
 	// Build the full command string for the result
-	fullCommand := config.Command
-	if len(config.Args) > 0 {
-		fullCommand = fullCommand + " " + strings.Join(config.Args, " ")
-	}
+	fullCommand := config.FullCommand()
 
 	// Force verbose when in dry run mode
 	verbose := config.Verbose || config.DryRun
@@ -67,93 +383,124 @@ func Execute(config *Config) (*Result, error) {
 		PrintPreExecution(fullCommand, config)
 	}
 
+	// Refuse commands the policy does not allow before anything runs
+	if config.Policy != nil {
+		checked := config.Pipeline
+		if len(checked) == 0 {
+			checked = []Step{{Command: config.Command, Args: config.Args}}
+		}
+		for _, step := range checked {
+			if err := config.Policy.Check(step.Command, step.Args); err != nil {
+				return refuseExecution(config, fullCommand, err, verbose)
+			}
+		}
+	}
+
 	var executionTime int64
 	var status Status
 	var exitCode int
+	var interaction *InteractionResult
+	var maxRSSKB int64
+	var steps []StepResult
+	var grader *GraderResult
+	var resourceExceeded string
+	var oomKilled bool
+	var signal string
+	var coreDumped bool
+	var networkIsolated bool
+	var stoppedBy string
 
 	if config.DryRun {
 		// Simulate successful execution for dry run
 		executionTime = 0
 		status = StatusSuccess
 		exitCode = 0
-	} else {
-		// Create command with or without timeout
-		var cmd *exec.Cmd
-		var ctx context.Context
-		var cancel context.CancelFunc
-
-		if config.Timeout > 0 {
-			ctx, cancel = context.WithTimeout(context.Background(), config.Timeout)
-			defer cancel()
-			cmd = exec.CommandContext(ctx, config.Command, config.Args...)
-		} else {
-			cmd = exec.Command(config.Command, config.Args...)
-		}
-
-		inputFile, err := os.Open(config.InputFile)
+	} else if len(config.Pipeline) > 0 {
+		var err error
+		status, exitCode, executionTime, steps, stoppedBy, err = runPipeline(config, verbose)
 		if err != nil {
-			return nil, fmt.Errorf("failed to open input file %s: %w", config.InputFile, err)
+			return nil, err
 		}
-		defer func() { _ = inputFile.Close() }()
-		cmd.Stdin = inputFile
-
-		outputFile, err := createFileWithDir(config.OutputFile)
+	} else if config.Grader != nil {
+		var err error
+		status, exitCode, executionTime, grader, stoppedBy, err = runGrader(config, verbose)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create output file: %w", err)
+			return nil, err
 		}
-		defer func() { _ = outputFile.Close() }()
-		cmd.Stdout = outputFile
-
-		stderrFile, err := createFileWithDir(config.StderrFile)
+	} else if config.Builtin != nil {
+		var err error
+		status, exitCode, executionTime, err = runBuiltin(config, verbose)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create stderr file: %w", err)
+			return nil, err
 		}
-		defer func() { _ = stderrFile.Close() }()
-
-		// If verbose mode is enabled, pipe stderr to both file and terminal
-		if verbose {
-			cmd.Stderr = io.MultiWriter(stderrFile, os.Stderr)
-		} else {
-			cmd.Stderr = stderrFile
+	} else {
+		executor := config.Executor
+		if executor == nil {
+			executor = LocalExecutor{}
 		}
-
-		startTime := time.Now()
-		err = cmd.Run()
-		endTime := time.Now()
-
-		executionTime = endTime.Sub(startTime).Milliseconds()
-
-		// Determine status and exit code based on error
-		status = StatusSuccess
-		exitCode = 0
-
+		execution, err := executor.Run(config, verbose)
 		if err != nil {
-			// Check for timeout - need to check context directly since exec.ExitError can mask it
-			if ctx != nil && ctx.Err() == context.DeadlineExceeded {
-				status = StatusTimeout
-				exitCode = -1 // Standard exit code for killed process
-			} else if exitError, ok := err.(*exec.ExitError); ok {
-				status = StatusFailed
-				if sysStatus, ok := exitError.Sys().(syscall.WaitStatus); ok {
-					exitCode = sysStatus.ExitStatus()
-				} else {
-					exitCode = 1
-				}
-			} else {
-				return nil, fmt.Errorf("failed to start command: %w", err)
-			}
+			return nil, err
 		}
+		executionTime = execution.ExecutionTime
+		maxRSSKB = execution.MaxRSSKB
+		interaction = execution.Interaction
+		oomKilled = execution.OOMKilled
+		signal, coreDumped = execution.Signal, execution.CoreDumped
+		stoppedBy = execution.Deadline
+		status, exitCode, resourceExceeded = execution.classify(config)
+		networkIsolated = config.NoNetwork
 	}
 
 	// Print post-execution status
 	if verbose {
-		PrintPostExecution(status, exitCode, executionTime, config.DryRun)
+		if interaction != nil {
+			PrintInteractionSummary(interaction)
+		}
+		if steps != nil {
+			PrintPipelineSummary(steps)
+		}
+		if grader != nil {
+			PrintGraderSummary(grader)
+		}
+		if resourceExceeded != "" {
+			fmt.Fprintln(redact.Stderr, "----------------------------------------")
+			fmt.Fprintf(redact.Stderr, "Resource Limit: %s\n", resourceExceeded)
+		}
+		if config.SoftTimeout > 0 && stoppedBy != "" {
+			fmt.Fprintln(redact.Stderr, "----------------------------------------")
+			fmt.Fprintf(redact.Stderr, "Deadline: %s\n", stoppedBy)
+		}
+		if signal != "" {
+			fmt.Fprintln(redact.Stderr, "----------------------------------------")
+			if coreDumped {
+				fmt.Fprintf(redact.Stderr, "Signal: %s (core dumped)\n", signal)
+			} else {
+				fmt.Fprintf(redact.Stderr, "Signal: %s\n", signal)
+			}
+		}
+		PrintPostExecution(status, exitCode, executionTime, maxRSSKB, config.DryRun)
 	}
 
-	return &Result{
+	result := &Result{
 		Command:       fullCommand,
 		Status:        status,
 		ExitCode:      exitCode,
 		ExecutionTime: executionTime,
-	}, nil
+		MaxRSSKB:      maxRSSKB,
+		Interaction:   interaction,
+		Steps:         steps,
+		Grader:        grader,
+
+		ResourceExceeded: resourceExceeded,
+		OOMKilled:        oomKilled,
+		Signal:           signal,
+		CoreDumped:       coreDumped,
+		NetworkIsolated:  networkIsolated,
+	}
+	// Which deadline fired only matters when there are two
+	if config.SoftTimeout > 0 {
+		result.Deadline = stoppedBy
+	}
+	return result, nil
 }
@@ -1,11 +1,15 @@
 package runner
 
 import (
+	"context"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/zinc-sig/ghost/internal/linefilter"
 )
 
 // Helper functions
@@ -36,6 +40,15 @@ func assertFileContains(t *testing.T, path, expected string) {
 	}
 }
 
+func mustRules(t *testing.T, specs ...string) []linefilter.Rule {
+	t.Helper()
+	rules, err := linefilter.ParseAll(specs)
+	if err != nil {
+		t.Fatalf("ParseAll() error = %v", err)
+	}
+	return rules
+}
+
 func TestExecute(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -83,6 +96,24 @@ func TestExecute(t *testing.T) {
 				assertFileContains(t, filepath.Join(tmpDir, "stderr.txt"), "")
 			},
 		},
+		{
+			name: "environment is added",
+			setupConfig: func(t *testing.T, tmpDir string) *Config {
+				inputFile := createTempFile(t, tmpDir, "input.txt", "")
+				return &Config{
+					Command:    "sh",
+					Args:       []string{"-c", `echo "$GHOST_TEST_ENV ${PATH:+path kept}"`},
+					Env:        []string{"GHOST_TEST_ENV=from spec"},
+					InputFile:  inputFile,
+					OutputFile: filepath.Join(tmpDir, "output.txt"),
+					StderrFile: filepath.Join(tmpDir, "stderr.txt"),
+				}
+			},
+			wantExitCode: 0,
+			checkOutput: func(t *testing.T, tmpDir string) {
+				assertFileContains(t, filepath.Join(tmpDir, "output.txt"), "from spec path kept\n")
+			},
+		},
 		{
 			name: "command with non-zero exit code",
 			setupConfig: func(t *testing.T, tmpDir string) *Config {
@@ -316,6 +347,80 @@ func TestExecute(t *testing.T) {
 	}
 }
 
+func TestExecuteExitCodeExpectation(t *testing.T) {
+	intPtr := func(i int) *int { return &i }
+
+	tests := []struct {
+		name           string
+		args           []string
+		expectExitCode *int
+		expectNonzero  bool
+		wantStatus     Status
+		wantExitCode   int
+	}{
+		{
+			name:         "default expects zero",
+			args:         []string{"-c", "exit 0"},
+			wantStatus:   StatusSuccess,
+			wantExitCode: 0,
+		},
+		{
+			name:           "matching expected exit code",
+			args:           []string{"-c", "exit 3"},
+			expectExitCode: intPtr(3),
+			wantStatus:     StatusSuccess,
+			wantExitCode:   3,
+		},
+		{
+			name:           "mismatching expected exit code",
+			args:           []string{"-c", "exit 0"},
+			expectExitCode: intPtr(3),
+			wantStatus:     StatusFailed,
+			wantExitCode:   0,
+		},
+		{
+			name:          "expect nonzero with failure",
+			args:          []string{"-c", "exit 1"},
+			expectNonzero: true,
+			wantStatus:    StatusSuccess,
+			wantExitCode:  1,
+		},
+		{
+			name:          "expect nonzero with success",
+			args:          []string{"-c", "exit 0"},
+			expectNonzero: true,
+			wantStatus:    StatusFailed,
+			wantExitCode:  0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			config := &Config{
+				Command:        "sh",
+				Args:           tt.args,
+				InputFile:      createTempFile(t, tmpDir, "input.txt", ""),
+				OutputFile:     filepath.Join(tmpDir, "output.txt"),
+				StderrFile:     filepath.Join(tmpDir, "stderr.txt"),
+				ExpectExitCode: tt.expectExitCode,
+				ExpectNonzero:  tt.expectNonzero,
+			}
+
+			result, err := Execute(config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			if result.ExitCode != tt.wantExitCode {
+				t.Errorf("exit code = %d, want %d", result.ExitCode, tt.wantExitCode)
+			}
+		})
+	}
+}
+
 func TestExecutionTime(t *testing.T) {
 	tmpDir := t.TempDir()
 	inputFile := createTempFile(t, tmpDir, "input.txt", "")
@@ -405,3 +510,76 @@ func BenchmarkExecute(b *testing.B) {
 		}
 	}
 }
+
+func TestExecuteBuiltin(t *testing.T) {
+	tests := []struct {
+		name         string
+		builtin      func(ctx context.Context, stdout, stderr io.Writer) int
+		timeout      time.Duration
+		wantStatus   Status
+		wantExitCode int
+		wantOutput   string
+		wantStderr   string
+	}{
+		{
+			name: "success",
+			builtin: func(ctx context.Context, stdout, stderr io.Writer) int {
+				_, _ = io.WriteString(stdout, "out\n")
+				return 0
+			},
+			wantStatus: StatusSuccess,
+			wantOutput: "out\n",
+		},
+		{
+			name: "failure",
+			builtin: func(ctx context.Context, stdout, stderr io.Writer) int {
+				_, _ = io.WriteString(stderr, "err\n")
+				return 2
+			},
+			wantStatus:   StatusFailed,
+			wantExitCode: 2,
+			wantStderr:   "err\n",
+		},
+		{
+			name: "timeout",
+			builtin: func(ctx context.Context, stdout, stderr io.Writer) int {
+				<-ctx.Done()
+				return 2
+			},
+			timeout:      50 * time.Millisecond,
+			wantStatus:   StatusTimeout,
+			wantExitCode: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			config := &Config{
+				Command:    "builtin",
+				InputFile:  "/dev/null",
+				OutputFile: filepath.Join(dir, "output.txt"),
+				StderrFile: filepath.Join(dir, "stderr.txt"),
+				Timeout:    tt.timeout,
+				Builtin:    tt.builtin,
+			}
+
+			result, err := Execute(config)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if result.Status != tt.wantStatus || result.ExitCode != tt.wantExitCode {
+				t.Errorf("Status = %s, ExitCode = %d, want %s and %d", result.Status, result.ExitCode, tt.wantStatus, tt.wantExitCode)
+			}
+
+			output, _ := os.ReadFile(config.OutputFile)
+			if string(output) != tt.wantOutput {
+				t.Errorf("Output = %q, want %q", output, tt.wantOutput)
+			}
+			stderr, _ := os.ReadFile(config.StderrFile)
+			if string(stderr) != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
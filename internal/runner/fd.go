@@ -0,0 +1,125 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Modes of a file descriptor mapped with --fd
+const (
+	FDRead   = "r" // the file is opened for reading and must exist
+	FDWrite  = "w" // the file is created or truncated
+	FDAppend = "a" // the file is created or appended to
+)
+
+// FDModes lists the modes of --fd
+var FDModes = []string{FDRead, FDWrite, FDAppend}
+
+// Descriptors --fd can map; 0 to 2 are stdin, stdout and stderr
+const (
+	MinExtraFD = 3
+	MaxExtraFD = 63
+)
+
+// FileDescriptor maps a file to a descriptor the command inherits besides
+// stdin, stdout and stderr, e.g. a channel of a judging protocol on fd 3
+type FileDescriptor struct {
+	FD   int
+	Path string
+	Mode string
+}
+
+// ParseFileDescriptor parses an --fd value of the form fd=path[:mode]
+// The mode defaults to r; a path ending in a colon that is not a mode keeps it.
+func ParseFileDescriptor(spec string) (FileDescriptor, error) {
+	fdStr, path, ok := strings.Cut(spec, "=")
+	if !ok {
+		return FileDescriptor{}, fmt.Errorf("invalid file descriptor %q (expected fd=path[:mode])", spec)
+	}
+	fd, err := strconv.Atoi(strings.TrimSpace(fdStr))
+	if err != nil || fd < MinExtraFD || fd > MaxExtraFD {
+		return FileDescriptor{}, fmt.Errorf("invalid file descriptor %q: must be a number from %d to %d", fdStr, MinExtraFD, MaxExtraFD)
+	}
+	mode := FDRead
+	if i := strings.LastIndex(path, ":"); i >= 0 && slices.Contains(FDModes, path[i+1:]) {
+		path, mode = path[:i], path[i+1:]
+	}
+	if path == "" || path == StreamPath {
+		return FileDescriptor{}, fmt.Errorf("invalid file descriptor %q: a file path is required", spec)
+	}
+	return FileDescriptor{FD: fd, Path: path, Mode: mode}, nil
+}
+
+// ValidateFileDescriptors checks that no descriptor is mapped twice and that the
+// executor can pass the descriptors to the command
+func ValidateFileDescriptors(executor Executor, fds []FileDescriptor) error {
+	if len(fds) == 0 {
+		return nil
+	}
+	seen := make(map[int]bool)
+	for _, fd := range fds {
+		if seen[fd.FD] {
+			return fmt.Errorf("file descriptor %d is mapped more than once", fd.FD)
+		}
+		seen[fd.FD] = true
+	}
+	if _, ok := executor.(descriptorPasser); !ok {
+		return fmt.Errorf("--fd is not supported by the %s executor", executor.Name())
+	}
+	if runtime.GOOS == "windows" {
+		return fmt.Errorf("--fd is not supported on %s", runtime.GOOS)
+	}
+	return nil
+}
+
+// descriptorPasser is implemented by executors that honour ExtraFiles
+type descriptorPasser interface {
+	passesDescriptors()
+}
+
+// passesDescriptors marks ExtraFiles as inherited by the local child process
+func (LocalExecutor) passesDescriptors() {}
+
+// openExtraFiles opens the files of the mapped descriptors, indexed as
+// exec.Cmd.ExtraFiles expects: entry i becomes descriptor 3+i, and unmapped
+// descriptors below the highest mapped one are left closed
+// Written files are created with their directories like the output file; stream
+// targets such as FIFOs are opened as they are. The returned close function
+// closes every opened file.
+func openExtraFiles(fds []FileDescriptor) ([]*os.File, func(), error) {
+	var files []*os.File
+	var opened []*os.File
+	closeAll := func() {
+		for _, file := range opened {
+			_ = file.Close()
+		}
+	}
+	for _, fd := range fds {
+		var file *os.File
+		var err error
+		switch {
+		case fd.Mode == FDRead:
+			file, err = os.Open(fd.Path)
+		case IsStreamTarget(fd.Path):
+			file, err = os.OpenFile(fd.Path, os.O_WRONLY, 0)
+		default:
+			file, err = createFileWithDir(fd.Path, fd.Mode == FDAppend)
+		}
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("failed to open file descriptor %d: %w", fd.FD, err)
+		}
+		opened = append(opened, file)
+
+		index := fd.FD - MinExtraFD
+		if index >= len(files) {
+			files = append(files, make([]*os.File, index-len(files)+1)...)
+		}
+		files[index] = file
+	}
+	return files, closeAll, nil
+}
@@ -0,0 +1,115 @@
+//go:build !windows
+
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFileDescriptor(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    FileDescriptor
+		wantErr string
+	}{
+		{spec: "3=cmdinput.txt", want: FileDescriptor{FD: 3, Path: "cmdinput.txt", Mode: FDRead}},
+		{spec: "4=trace.out:w", want: FileDescriptor{FD: 4, Path: "trace.out", Mode: FDWrite}},
+		{spec: "5=log/run.log:a", want: FileDescriptor{FD: 5, Path: "log/run.log", Mode: FDAppend}},
+		{spec: "3=a:b.txt", want: FileDescriptor{FD: 3, Path: "a:b.txt", Mode: FDRead}},
+		{spec: "3=a:b.txt:w", want: FileDescriptor{FD: 3, Path: "a:b.txt", Mode: FDWrite}},
+		{spec: "cmdinput.txt", wantErr: "expected fd=path[:mode]"},
+		{spec: "2=stderr.txt", wantErr: "must be a number from 3 to 63"},
+		{spec: "64=file", wantErr: "must be a number from 3 to 63"},
+		{spec: "x=file", wantErr: "must be a number from 3 to 63"},
+		{spec: "3=:w", wantErr: "a file path is required"},
+		{spec: "3=-", wantErr: "a file path is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseFileDescriptor(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseFileDescriptor(%q) error = %v, want %q", tt.spec, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFileDescriptor(%q) error = %v", tt.spec, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFileDescriptor(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateFileDescriptors(t *testing.T) {
+	fds := []FileDescriptor{{FD: 3, Path: "a", Mode: FDRead}}
+	if err := ValidateFileDescriptors(LocalExecutor{}, fds); err != nil {
+		t.Errorf("local executor: %v", err)
+	}
+	if err := ValidateFileDescriptors(&DockerExecutor{}, fds); err == nil || !strings.Contains(err.Error(), "not supported by the docker executor") {
+		t.Errorf("docker executor: error = %v", err)
+	}
+	duplicate := append(fds, FileDescriptor{FD: 3, Path: "b", Mode: FDWrite})
+	if err := ValidateFileDescriptors(LocalExecutor{}, duplicate); err == nil || !strings.Contains(err.Error(), "mapped more than once") {
+		t.Errorf("duplicate descriptor: error = %v", err)
+	}
+}
+
+func TestExecuteFileDescriptors(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputFile := filepath.Join(tmpDir, "output.txt")
+	traceFile := filepath.Join(tmpDir, "trace", "trace.out")
+	appendFile := createTempFile(t, tmpDir, "append.log", "old\n")
+
+	// fd 4 is left unmapped, so it is closed in the command
+	config := &Config{
+		Command:    "sh",
+		Args:       []string{"-c", "cat <&3; echo trace >&5; echo new >&6; echo open >&4 || echo closed"},
+		InputFile:  createTempFile(t, tmpDir, "input.txt", ""),
+		OutputFile: outputFile,
+		StderrFile: filepath.Join(tmpDir, "stderr.txt"),
+		Descriptors: []FileDescriptor{
+			{FD: 3, Path: createTempFile(t, tmpDir, "cmdinput.txt", "from fd 3\n"), Mode: FDRead},
+			{FD: 5, Path: traceFile, Mode: FDWrite},
+			{FD: 6, Path: appendFile, Mode: FDAppend},
+		},
+	}
+	result, err := Execute(config)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.Status != StatusSuccess {
+		t.Fatalf("Status = %s, want success", result.Status)
+	}
+
+	for path, want := range map[string]string{
+		outputFile: "from fd 3\nclosed\n",
+		traceFile:  "trace\n",
+		appendFile: "old\nnew\n",
+	} {
+		got, _ := os.ReadFile(path)
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(path), got, want)
+		}
+	}
+}
+
+func TestExecuteFileDescriptorsMissingInput(t *testing.T) {
+	tmpDir := t.TempDir()
+	config := &Config{
+		Command:     "true",
+		InputFile:   createTempFile(t, tmpDir, "input.txt", ""),
+		OutputFile:  filepath.Join(tmpDir, "output.txt"),
+		StderrFile:  filepath.Join(tmpDir, "stderr.txt"),
+		Descriptors: []FileDescriptor{{FD: 3, Path: filepath.Join(tmpDir, "missing.txt"), Mode: FDRead}},
+	}
+	if _, err := Execute(config); err == nil || !strings.Contains(err.Error(), "failed to open file descriptor 3") {
+		t.Errorf("Execute() error = %v, want the descriptor reported", err)
+	}
+}
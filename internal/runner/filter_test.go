@@ -0,0 +1,124 @@
+//go:build linux || darwin
+
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExecuteOutputFilters(t *testing.T) {
+	script := `printf 'ts=12:00:01 value 1\nDEBUG noise\nvalue 2'; printf 'warn at 0x7ffd\nfatal\n' >&2`
+
+	tests := []struct {
+		name       string
+		config     Config
+		wantOutput string
+		wantStderr string
+	}{
+		{
+			name:       "unfiltered",
+			config:     Config{Command: "sh", Args: []string{"-c", script}},
+			wantOutput: "ts=12:00:01 value 1\nDEBUG noise\nvalue 2",
+			wantStderr: "warn at 0x7ffd\nfatal\n",
+		},
+		{
+			name: "external command",
+			config: Config{
+				Command:      "sh",
+				Args:         []string{"-c", script},
+				StdoutFilter: mustRules(t, "drop:^DEBUG", `strip:ts=[0-9:]+ `),
+				StderrFilter: mustRules(t, "redact:0x[0-9a-f]+"),
+			},
+			wantOutput: "value 1\nvalue 2",
+			wantStderr: "warn at ***REDACTED***\nfatal\n",
+		},
+		{
+			name: "pipeline",
+			config: Config{
+				Pipeline:     []Step{{Command: "sh", Args: []string{"-c", script}}, {Command: "cat"}},
+				StdoutFilter: mustRules(t, "drop:^DEBUG"),
+				StderrFilter: mustRules(t, "drop:^fatal"),
+			},
+			wantOutput: "ts=12:00:01 value 1\nvalue 2",
+			wantStderr: "warn at 0x7ffd\n",
+		},
+		{
+			name: "builtin",
+			config: Config{
+				Command: "builtin",
+				Builtin: func(ctx context.Context, stdout, stderr io.Writer) int {
+					_, _ = fmt.Fprint(stdout, "keep\nDEBUG drop\n")
+					return 0
+				},
+				StdoutFilter: mustRules(t, "drop:^DEBUG"),
+			},
+			wantOutput: "keep\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			config := tt.config
+			config.InputFile = "/dev/null"
+			config.OutputFile = filepath.Join(tmpDir, "output.txt")
+			config.StderrFile = filepath.Join(tmpDir, "stderr.txt")
+
+			result, err := Execute(&config)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if result.Status != StatusSuccess {
+				t.Errorf("Status = %s, want success", result.Status)
+			}
+
+			output, _ := os.ReadFile(config.OutputFile)
+			if string(output) != tt.wantOutput {
+				t.Errorf("Output = %q, want %q", output, tt.wantOutput)
+			}
+			stderr, _ := os.ReadFile(config.StderrFile)
+			if string(stderr) != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestExecuteOutputFilterTeeIsUnfiltered(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	oldStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	defer func() { os.Stderr = oldStderr }()
+
+	config := &Config{
+		Command:      "sh",
+		Args:         []string{"-c", "echo DEBUG x; echo result"},
+		InputFile:    "/dev/null",
+		OutputFile:   filepath.Join(tmpDir, "output.txt"),
+		StderrFile:   filepath.Join(tmpDir, "stderr.txt"),
+		TeeOutput:    TeeStderr,
+		StdoutFilter: mustRules(t, "drop:^DEBUG"),
+	}
+	_, err := Execute(config)
+	_ = w.Close()
+	os.Stderr = oldStderr
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	teed, _ := io.ReadAll(r)
+	if string(teed) != "DEBUG x\nresult\n" {
+		t.Errorf("Tee = %q, want the unfiltered output", teed)
+	}
+	output, _ := os.ReadFile(config.OutputFile)
+	if string(output) != "result\n" {
+		t.Errorf("Output = %q, want %q", output, "result\n")
+	}
+}
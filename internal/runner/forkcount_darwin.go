@@ -0,0 +1,32 @@
+//go:build darwin
+
+package runner
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// processTreeCount counts the command's process and its descendants
+// RLIMIT_NPROC is checked against every process of the user, but only the
+// command's own tree says whether it was the command that reached the limit.
+func processTreeCount(pid int) (int, bool) {
+	procs, err := unix.SysctlKinfoProcSlice("kern.proc.all")
+	if err != nil {
+		return 0, false
+	}
+
+	children := make(map[int32][]int32, len(procs))
+	for _, proc := range procs {
+		children[proc.Eproc.Ppid] = append(children[proc.Eproc.Ppid], proc.Proc.P_pid)
+	}
+
+	count := 0
+	queue := []int32{int32(pid)}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		count++
+		queue = append(queue, children[next]...)
+	}
+	return count, true
+}
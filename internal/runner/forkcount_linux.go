@@ -0,0 +1,10 @@
+//go:build linux
+
+package runner
+
+// processTreeCount counts the command's process and its descendants
+// RLIMIT_NPROC is checked against every process of the user, but only the
+// command's own tree says whether it was the command that reached the limit.
+func processTreeCount(pid int) (int, bool) {
+	return 1 + len(descendants(pid)), true
+}
@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// forkSampleInterval is how often the number of processes is polled under --max-forks
+var forkSampleInterval = 50 * time.Millisecond
+
+// ValidateMaxForks checks a --max-forks value (0 = unlimited)
+func ValidateMaxForks(maxForks int) error {
+	if maxForks < 0 {
+		return fmt.Errorf("invalid max forks %d: must be 0 (unlimited) or positive", maxForks)
+	}
+	if maxForks > 0 && !forkLimitSupported {
+		return fmt.Errorf("--max-forks is not supported on %s", runtime.GOOS)
+	}
+	return nil
+}
+
+// forkSampler polls how many processes count against the fork limit while the
+// command runs, so hitting the limit can be reported instead of a bare failure
+type forkSampler struct {
+	mu     sync.Mutex
+	peak   int
+	stopCh chan struct{}
+	done   chan struct{}
+}
+
+// start begins polling the processes in the command's tree rooted at pid
+func (s *forkSampler) start(pid int) {
+	s.stopCh = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(forkSampleInterval)
+		defer ticker.Stop()
+
+		for {
+			if count, ok := processTreeCount(pid); ok {
+				s.mu.Lock()
+				s.peak = max(s.peak, count)
+				s.mu.Unlock()
+			}
+			select {
+			case <-ticker.C:
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// stop ends polling and returns the highest observed process count
+func (s *forkSampler) stop() int {
+	if s.stopCh != nil {
+		close(s.stopCh)
+		<-s.done
+		s.stopCh = nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.peak
+}
+
+// forkLimitReached describes the process limit if the run reached it
+// An unsuccessful run that did is reported as resource_exceeded, since its
+// failures are most likely refused forks.
+func forkLimitReached(peak, maxForks int) string {
+	if maxForks <= 0 || peak < maxForks {
+		return ""
+	}
+	return fmt.Sprintf("process limit of %d reached (--max-forks)", maxForks)
+}
+
+// limitForks prepares cmd to run in its own process group with RLIMIT_NPROC set
+// A command whose lookup already failed is left alone so Start reports the error.
+func limitForks(cmd *exec.Cmd, maxForks int) error {
+	if cmd.Err != nil {
+		return nil
+	}
+	return applyForkLimit(cmd, maxForks)
+}
@@ -0,0 +1,30 @@
+//go:build !linux && !darwin
+
+package runner
+
+import (
+	"errors"
+	"os/exec"
+)
+
+const forkLimitSupported = false
+
+// applyForkLimit is not supported on this platform
+func applyForkLimit(cmd *exec.Cmd, maxForks int) error {
+	return errors.New("--max-forks is not supported on this platform")
+}
+
+// terminateProcessGroup is not supported on this platform
+func terminateProcessGroup(pid int) error {
+	return errors.ErrUnsupported
+}
+
+// killProcessGroup is not supported on this platform
+func killProcessGroup(pid int) error {
+	return nil
+}
+
+// processTreeCount is not supported on this platform
+func processTreeCount(pid int) (int, bool) {
+	return 0, false
+}
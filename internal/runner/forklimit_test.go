@@ -0,0 +1,196 @@
+//go:build linux || darwin
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestValidateMaxForks(t *testing.T) {
+	for _, n := range []int{0, 1, 64} {
+		if err := ValidateMaxForks(n); err != nil {
+			t.Errorf("ValidateMaxForks(%d) error = %v", n, err)
+		}
+	}
+	if err := ValidateMaxForks(-1); err == nil || !strings.Contains(err.Error(), "must be 0 (unlimited) or positive") {
+		t.Errorf("ValidateMaxForks(-1) error = %v", err)
+	}
+}
+
+func TestForkLimitClassification(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     Status
+		peak       int
+		maxForks   int
+		wantStatus Status
+		wantDetail bool
+	}{
+		{name: "no limit", status: StatusFailed, peak: 500, wantStatus: StatusFailed},
+		{name: "success at the limit", status: StatusSuccess, peak: 10, maxForks: 10, wantStatus: StatusSuccess},
+		{name: "failure below the limit", status: StatusFailed, peak: 9, maxForks: 10, wantStatus: StatusFailed},
+		{name: "failure at the limit", status: StatusFailed, peak: 10, maxForks: 10, wantStatus: StatusResourceExceeded, wantDetail: true},
+		{name: "timeout at the limit", status: StatusTimeout, peak: 12, maxForks: 10, wantStatus: StatusResourceExceeded, wantDetail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			execution := &Execution{
+				TimedOut:     tt.status == StatusTimeout,
+				LimitReached: forkLimitReached(tt.peak, tt.maxForks),
+			}
+			if tt.status == StatusFailed {
+				execution.ExitCode = 1
+			}
+			status, _, detail := execution.classify(&Config{})
+			if status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", status, tt.wantStatus)
+			}
+			if (detail != "") != tt.wantDetail {
+				t.Errorf("Detail = %q, want detail: %v", detail, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestProcessTreeCount(t *testing.T) {
+	// An unrelated process of the same user is not part of the command's tree
+	other := exec.Command("sleep", "30")
+	if err := other.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = other.Process.Kill(); _ = other.Wait() }()
+
+	cmd := exec.Command("sh", "-c", "sleep 30 & sleep 30 & wait")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); _ = cmd.Wait() }()
+
+	count := 0
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		n, ok := processTreeCount(cmd.Process.Pid)
+		if !ok {
+			t.Fatal("processTreeCount() not supported")
+		}
+		if count = n; count == 3 {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Errorf("processTreeCount() = %d, want 3", count)
+}
+
+func TestExecuteMaxForksSetsLimit(t *testing.T) {
+	dir := t.TempDir()
+	outputFile := filepath.Join(dir, "output.txt")
+
+	result, err := Execute(&Config{
+		Command:    "sh",
+		Args:       []string{"-c", "(ulimit -u || ulimit -p) 2>/dev/null; echo $0"},
+		InputFile:  "/dev/null",
+		OutputFile: outputFile,
+		StderrFile: filepath.Join(dir, "stderr.txt"),
+		MaxForks:   37,
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.Status != StatusSuccess {
+		t.Fatalf("Status = %s, want success", result.Status)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Fields(string(data))
+	if len(lines) != 2 || lines[0] != "37" {
+		t.Errorf("Process limit = %q, want 37", data)
+	}
+	// The command keeps its own argv[0] behind the shim
+	if lines[len(lines)-1] != "sh" {
+		t.Errorf("argv[0] = %q, want sh", lines[len(lines)-1])
+	}
+	if !strings.HasPrefix(result.Command, "sh -c ") {
+		t.Errorf("Command = %q", result.Command)
+	}
+}
+
+func TestExecuteMaxForksKillsProcessGroup(t *testing.T) {
+	dir := t.TempDir()
+	outputFile := filepath.Join(dir, "output.txt")
+
+	result, err := Execute(&Config{
+		Command:    "sh",
+		Args:       []string{"-c", "sleep 30 & echo $!"},
+		InputFile:  "/dev/null",
+		OutputFile: outputFile,
+		StderrFile: filepath.Join(dir, "stderr.txt"),
+		Timeout:    10 * time.Second,
+		MaxForks:   1000,
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.Status != StatusSuccess {
+		t.Fatalf("Status = %s, want success", result.Status)
+	}
+
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatalf("Unexpected output %q", data)
+	}
+
+	// The background sleep is killed with the group (it may linger as a zombie
+	// until its new parent reaps it)
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if !processRunning(pid) {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	_ = syscall.Kill(pid, syscall.SIGKILL)
+	t.Errorf("Background process %d survived the command", pid)
+}
+
+func TestExecuteMaxForksMissingCommand(t *testing.T) {
+	dir := t.TempDir()
+	_, err := Execute(&Config{
+		Command:    "ghost-no-such-command",
+		InputFile:  "/dev/null",
+		OutputFile: filepath.Join(dir, "output.txt"),
+		StderrFile: filepath.Join(dir, "stderr.txt"),
+		MaxForks:   10,
+	})
+	if err == nil || !strings.Contains(err.Error(), "failed to start command") {
+		t.Errorf("Execute() error = %v, want start failure", err)
+	}
+}
+
+// processRunning reports whether pid exists and is not a zombie
+func processRunning(pid int) bool {
+	if err := syscall.Kill(pid, 0); err != nil {
+		return false
+	}
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return true // no /proc (darwin): signal 0 succeeded
+	}
+	fields := strings.Fields(string(data))
+	return len(fields) < 3 || fields[2] != "Z"
+}
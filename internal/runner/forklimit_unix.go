@@ -0,0 +1,108 @@
+//go:build linux || darwin
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/zinc-sig/ghost/internal/redact"
+)
+
+const forkLimitSupported = true
+
+// forkLimitEnv tells a re-executed ghost to act as the fork limit shim
+const forkLimitEnv = "GHOST_FORK_LIMIT"
+
+func init() {
+	// With --nice or --ionice the priority shim runs first and re-executes this one
+	if _, ok := os.LookupEnv(priorityEnv); ok {
+		return
+	}
+	if limit, ok := os.LookupEnv(forkLimitEnv); ok {
+		runForkLimitShim(limit)
+	}
+}
+
+// runForkLimitShim applies RLIMIT_NPROC and replaces this process with the command
+// Go cannot run code between fork and exec, so ghost re-executes itself as
+// "ghost <path> <argv...>" with forkLimitEnv set to get a pre-exec hook.
+func runForkLimitShim(limit string) {
+	_ = os.Unsetenv(forkLimitEnv)
+
+	err := errors.New("missing command")
+	if len(os.Args) >= 3 {
+		err = setProcessLimit(limit)
+		if err == nil {
+			err = unix.Exec(os.Args[1], os.Args[2:], os.Environ())
+		}
+	}
+	fmt.Fprintf(redact.Stderr, "ghost: failed to apply --max-forks: %v\n", err)
+	os.Exit(127)
+}
+
+// setProcessLimit lowers RLIMIT_NPROC, never above the current hard limit
+func setProcessLimit(limit string) error {
+	n, err := strconv.ParseUint(limit, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid limit %q: %w", limit, err)
+	}
+	var rlimit unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_NPROC, &rlimit); err != nil {
+		return fmt.Errorf("failed to read process limit: %w", err)
+	}
+	rlimit.Cur = min(n, rlimit.Max)
+	rlimit.Max = rlimit.Cur
+	if err := unix.Setrlimit(unix.RLIMIT_NPROC, &rlimit); err != nil {
+		return fmt.Errorf("failed to set process limit: %w", err)
+	}
+	return nil
+}
+
+// applyForkLimit runs cmd through the fork limit shim in a new process group
+func applyForkLimit(cmd *exec.Cmd, maxForks int) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate ghost executable: %w", err)
+	}
+
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	filtered := make([]string, 0, len(env)+1)
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, forkLimitEnv+"=") {
+			filtered = append(filtered, kv)
+		}
+	}
+
+	cmd.Args = append([]string{self, cmd.Path}, cmd.Args...)
+	cmd.Path = self
+	cmd.Env = append(filtered, fmt.Sprintf("%s=%d", forkLimitEnv, maxForks))
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+	return nil
+}
+
+// terminateProcessGroup sends SIGTERM to every process in the command's process group
+func terminateProcessGroup(pid int) error {
+	return syscall.Kill(-pid, syscall.SIGTERM)
+}
+
+// killProcessGroup kills every process left in the command's process group
+func killProcessGroup(pid int) error {
+	if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
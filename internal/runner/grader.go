@@ -0,0 +1,243 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxVerdictBytes bounds how much of the grader's stderr is kept for its verdict
+const maxVerdictBytes = 4096
+
+// GraderResult records how the grader of an interactive run finished
+// The grader accepts the command by exiting with 0, so its status is success
+// only then; the verdict is the last line it wrote to stderr.
+type GraderResult struct {
+	Command       string
+	Status        Status
+	ExitCode      int
+	ExecutionTime int64 // milliseconds
+	Verdict       string
+}
+
+// verdictWriter keeps the end of the grader's stderr for its verdict
+type verdictWriter struct {
+	mu   sync.Mutex
+	tail []byte
+}
+
+func (v *verdictWriter) Write(p []byte) (int, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.tail = append(v.tail, p...)
+	if len(v.tail) > maxVerdictBytes {
+		v.tail = v.tail[len(v.tail)-maxVerdictBytes:]
+	}
+	return len(p), nil
+}
+
+// verdict returns the last non-empty line written
+func (v *verdictWriter) verdict() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	lines := strings.Split(strings.TrimSpace(string(bytes.ToValidUTF8(v.tail, nil))), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// runGrader runs the command and config.Grader concurrently, connecting the
+// command's stdout to the grader's stdin and the grader's stdout to the
+// command's stdin
+// The grader reads the input file on descriptor 3. What the command writes is
+// also captured in the output file, its stderr in the stderr file and the
+// grader's stderr in GraderStderrFile if set. Both share the timeout. The run
+// succeeds only if the command's exit code is expected and the grader accepted it.
+func runGrader(config *Config, verbose bool) (Status, int, int64, *GraderResult, string, error) {
+	ctx := context.Background()
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+
+	inputFile, err := os.Open(config.InputFile)
+	if err != nil {
+		return "", 0, 0, nil, "", fmt.Errorf("failed to open input file %s: %w", config.InputFile, err)
+	}
+	defer func() { _ = inputFile.Close() }()
+
+	outputFile, closeOutput, err := openOutputFile(config.OutputFile, os.Stdout, config.Append)
+	if err != nil {
+		return "", 0, 0, nil, "", fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer closeOutput()
+
+	stderrFile, closeStderr, err := openOutputFile(config.StderrFile, os.Stderr, config.Append)
+	if err != nil {
+		return "", 0, 0, nil, "", fmt.Errorf("failed to create stderr file: %w", err)
+	}
+	defer closeStderr()
+
+	verdict := &verdictWriter{}
+	var graderStderr io.Writer = verdict
+	if config.GraderStderrFile != "" {
+		file, closeGraderStderr, err := openOutputFile(config.GraderStderrFile, os.Stderr, config.Append)
+		if err != nil {
+			return "", 0, 0, nil, "", fmt.Errorf("failed to create grader stderr file: %w", err)
+		}
+		defer closeGraderStderr()
+		graderStderr = io.MultiWriter(file, verdict)
+	}
+
+	combined, closeCombined, err := openCombinedCapture(config)
+	if err != nil {
+		return "", 0, 0, nil, "", err
+	}
+	defer closeCombined()
+
+	capture, flushOutput := filterCapture(combined.tag(streamOutput(config, outputFile), CombinedStdout), config.StdoutFilter)
+	stderrCapture, flushStderr := filterCapture(combined.tag(stderrFile, CombinedStderr), config.StderrFilter)
+	stderr := stderrCapture
+	if verbose && config.StderrFile != StreamPath {
+		stderr = io.MultiWriter(stderrCapture, os.Stderr)
+	}
+
+	newCmd := func(step Step) *exec.Cmd {
+		if config.Timeout > 0 {
+			return exec.CommandContext(ctx, step.Command, step.Args...)
+		}
+		return exec.Command(step.Command, step.Args...)
+	}
+	solution := newCmd(Step{Command: config.Command, Args: config.Args})
+	grader := newCmd(*config.Grader)
+	if len(config.Env) > 0 {
+		solution.Env = append(os.Environ(), config.Env...)
+	}
+
+	// The grader's stdout feeds the command directly; the command's stdout is
+	// copied to the grader and the capture, so the output file records its side
+	toSolution, fromGrader, err := os.Pipe()
+	if err != nil {
+		return "", 0, 0, nil, "", fmt.Errorf("failed to create pipe: %w", err)
+	}
+	toGrader, fromSolution, err := os.Pipe()
+	if err != nil {
+		_ = toSolution.Close()
+		_ = fromGrader.Close()
+		return "", 0, 0, nil, "", fmt.Errorf("failed to create pipe: %w", err)
+	}
+	parentEnds := []*os.File{toSolution, fromGrader, toGrader}
+	closeParentEnds := func() {
+		for _, f := range parentEnds {
+			_ = f.Close()
+		}
+		parentEnds = nil
+	}
+	defer closeParentEnds()
+	defer func() { _ = fromSolution.Close() }()
+
+	solution.Stdin = toSolution
+	solution.Stdout = io.MultiWriter(capture, fromSolution)
+	solution.Stderr = stderr
+	grader.Stdin = toGrader
+	grader.Stdout = fromGrader
+	grader.Stderr = graderStderr
+	grader.ExtraFiles = []*os.File{inputFile}
+
+	cmds := []*exec.Cmd{solution, grader}
+	trees := make([]*processTree, len(cmds))
+	for i, cmd := range cmds {
+		trees[i] = newProcessTree(cmd)
+		defer trees[i].release()
+		if config.Timeout > 0 {
+			cmd.Cancel = trees[i].kill
+		}
+	}
+
+	startTime := time.Now()
+	if err := grader.Start(); err != nil {
+		return "", 0, 0, nil, "", fmt.Errorf("failed to start grader %s: %w", config.Grader.Command, err)
+	}
+	trees[1].attach(grader.Process)
+	if err := solution.Start(); err != nil {
+		_ = grader.Process.Kill()
+		closeParentEnds()
+		_ = grader.Wait()
+		return "", 0, 0, nil, "", fmt.Errorf("failed to start command: %w", err)
+	}
+	trees[0].attach(solution.Process)
+	closeParentEnds()
+
+	// The soft timeout asks both processes to exit
+	soft := startSoftTimer(config.SoftTimeout, func() error {
+		solutionErr := terminate(solution.Process)
+		graderErr := terminate(grader.Process)
+		if solutionErr != nil && graderErr != nil {
+			return solutionErr
+		}
+		return nil
+	})
+
+	// The grader sees EOF once the command has exited and its output is copied
+	var solutionErr, graderErr error
+	var executionTime, graderTime int64
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		solutionErr = solution.Wait()
+		executionTime = time.Since(startTime).Milliseconds()
+		_ = fromSolution.Close()
+	}()
+	go func() {
+		defer wg.Done()
+		graderErr = grader.Wait()
+		graderTime = time.Since(startTime).Milliseconds()
+	}()
+	wg.Wait()
+	softFired := soft.stop()
+	if err := flushCaptures(flushOutput, flushStderr, combined); err != nil {
+		return "", 0, 0, nil, "", err
+	}
+	stoppedBy := deadline(ctx.Err() == context.DeadlineExceeded, softFired)
+
+	graderResult := &GraderResult{
+		Command:       config.Grader.String(),
+		Status:        StatusSuccess,
+		ExecutionTime: graderTime,
+		Verdict:       verdict.verdict(),
+	}
+	if graderErr != nil {
+		exitError, ok := graderErr.(*exec.ExitError)
+		if !ok {
+			return "", 0, 0, nil, "", fmt.Errorf("grader %s failed: %w", config.Grader.Command, graderErr)
+		}
+		graderResult.Status = StatusFailed
+		graderResult.ExitCode = exitError.ExitCode()
+	}
+
+	// A command killed by the broken pipe after the grader exited still has an exit code
+	exitCode := 0
+	if solutionErr != nil {
+		exitError, ok := solutionErr.(*exec.ExitError)
+		if !ok {
+			return "", 0, 0, nil, "", fmt.Errorf("command failed: %w", solutionErr)
+		}
+		exitCode = exitError.ExitCode()
+	}
+
+	if stoppedBy != "" {
+		graderResult.Status = StatusTimeout
+		graderResult.ExitCode = -1
+		return StatusTimeout, -1, executionTime, graderResult, stoppedBy, nil
+	}
+	if isExpectedExitCode(config, exitCode) && graderResult.Status == StatusSuccess {
+		return StatusSuccess, exitCode, executionTime, graderResult, "", nil
+	}
+	return StatusFailed, exitCode, executionTime, graderResult, "", nil
+}
@@ -0,0 +1,89 @@
+//go:build !windows
+
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// doublingGrader sends the number read on descriptor 3 and accepts twice its value
+const doublingGrader = `read n <&3; echo "$n"; read answer
+if [ "$answer" = "$((n * 2))" ]; then echo "ok" >&2; exit 0; fi
+echo "debug output" >&2; echo "wrong answer: got $answer, expected $((n * 2))" >&2; exit 1`
+
+func TestExecuteGrader(t *testing.T) {
+	tests := []struct {
+		name        string
+		solution    string
+		timeout     time.Duration
+		wantStatus  Status
+		wantExit    int
+		wantGrader  Status
+		wantCode    int
+		wantVerdict string
+		wantOutput  string
+	}{
+		{name: "accepted", solution: `read n; echo $((n * 2))`, wantStatus: StatusSuccess, wantGrader: StatusSuccess, wantVerdict: "ok", wantOutput: "42\n"},
+		{name: "rejected", solution: `read n; echo $((n + 1))`, wantStatus: StatusFailed, wantGrader: StatusFailed, wantCode: 1, wantVerdict: "wrong answer: got 22, expected 42", wantOutput: "22\n"},
+		{name: "accepted but crashed", solution: `read n; echo $((n * 2)); exit 3`, wantStatus: StatusFailed, wantExit: 3, wantGrader: StatusSuccess, wantVerdict: "ok", wantOutput: "42\n"},
+		{name: "deadlock", solution: `read n; read more`, timeout: 300 * time.Millisecond, wantStatus: StatusTimeout, wantExit: -1, wantGrader: StatusTimeout, wantCode: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			outputFile := filepath.Join(tmpDir, "output.txt")
+			config := &Config{
+				Command:    "sh",
+				Args:       []string{"-c", tt.solution},
+				Grader:     &Step{Command: "sh", Args: []string{"-c", doublingGrader}},
+				InputFile:  createTempFile(t, tmpDir, "input.txt", "21\n"),
+				OutputFile: outputFile,
+				StderrFile: filepath.Join(tmpDir, "stderr.txt"),
+				Timeout:    tt.timeout,
+			}
+
+			result, err := Execute(config)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if result.Status != tt.wantStatus || result.ExitCode != tt.wantExit {
+				t.Errorf("Status = %s (exit code %d), want %s (exit code %d)", result.Status, result.ExitCode, tt.wantStatus, tt.wantExit)
+			}
+			grader := result.Grader
+			if grader == nil {
+				t.Fatal("Grader result missing")
+			}
+			if grader.Status != tt.wantGrader || grader.ExitCode != tt.wantCode || grader.Verdict != tt.wantVerdict {
+				t.Errorf("Grader = %+v, want %s (exit code %d) with verdict %q", grader, tt.wantGrader, tt.wantCode, tt.wantVerdict)
+			}
+			if output, _ := os.ReadFile(outputFile); string(output) != tt.wantOutput {
+				t.Errorf("Output = %q, want %q", output, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestExecuteGraderStderrFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	graderStderr := filepath.Join(tmpDir, "grader.log")
+	config := &Config{
+		Command:          "sh",
+		Args:             []string{"-c", `read n; echo 0`},
+		Grader:           &Step{Command: "sh", Args: []string{"-c", doublingGrader}},
+		GraderStderrFile: graderStderr,
+		InputFile:        createTempFile(t, tmpDir, "input.txt", "5\n"),
+		OutputFile:       filepath.Join(tmpDir, "output.txt"),
+		StderrFile:       filepath.Join(tmpDir, "stderr.txt"),
+	}
+	if _, err := Execute(config); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	want := "debug output\nwrong answer: got 0, expected 10\n"
+	if got, _ := os.ReadFile(graderStderr); string(got) != want {
+		t.Errorf("Grader stderr = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,318 @@
+package runner
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Interaction step kinds
+const (
+	StepSend   = "send"
+	StepExpect = "expect"
+	StepClose  = "close"
+)
+
+// DefaultExpectTimeout is the default time to wait for an expect pattern
+const DefaultExpectTimeout = 10 * time.Second
+
+// InteractionStep is a single step of an interaction script
+type InteractionStep struct {
+	Kind    string
+	Text    string         // Line to send (send) or pattern source (expect)
+	Pattern *regexp.Regexp // Compiled pattern (expect only)
+	Timeout time.Duration  // Maximum wait for the pattern (expect) or for stdin to take the line (send)
+	Line    int            // Line number in the script file
+}
+
+// InteractionScript describes how to drive an interactive program
+type InteractionScript struct {
+	Path  string
+	Steps []InteractionStep
+}
+
+// TranscriptEntry records a completed or failed interaction step
+type TranscriptEntry struct {
+	Step    int
+	Kind    string
+	Text    string // Sent line or expected pattern
+	Output  string // Output consumed while waiting for an expect pattern
+	Elapsed int64  // Milliseconds since the command started
+}
+
+// InteractionResult holds the outcome of running an interaction script
+type InteractionResult struct {
+	TotalSteps     int
+	CompletedSteps int
+	Error          string
+	Transcript     []TranscriptEntry
+}
+
+// LoadInteractionScript reads and parses an interaction script file
+func LoadInteractionScript(path string) (*InteractionScript, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open interaction script: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	script, err := ParseInteractionScript(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid interaction script %s: %w", path, err)
+	}
+	script.Path = path
+	return script, nil
+}
+
+// ParseInteractionScript parses an interaction script
+//
+// Each non-empty line holds one directive; lines starting with '#' are comments:
+//
+//	send <text>        write text followed by a newline to stdin
+//	expect <regex>     wait until stdout matches the regular expression
+//	timeout <duration> set the expect and send timeout for the following steps
+//	close              close stdin
+//
+// The argument of send and expect may be a double-quoted Go string to include
+// escapes or leading/trailing spaces.
+func ParseInteractionScript(r io.Reader) (*InteractionScript, error) {
+	script := &InteractionScript{}
+	expectTimeout := DefaultExpectTimeout
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		directive, arg, _ := strings.Cut(line, " ")
+		arg = strings.TrimSpace(arg)
+		if strings.HasPrefix(arg, `"`) {
+			unquoted, err := strconv.Unquote(arg)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid quoted string: %w", lineNum, err)
+			}
+			arg = unquoted
+		}
+
+		switch directive {
+		case StepSend:
+			script.Steps = append(script.Steps, InteractionStep{Kind: StepSend, Text: arg, Timeout: expectTimeout, Line: lineNum})
+		case StepExpect:
+			if arg == "" {
+				return nil, fmt.Errorf("line %d: expect requires a pattern", lineNum)
+			}
+			pattern, err := regexp.Compile(arg)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid pattern: %w", lineNum, err)
+			}
+			script.Steps = append(script.Steps, InteractionStep{
+				Kind: StepExpect, Text: arg, Pattern: pattern, Timeout: expectTimeout, Line: lineNum,
+			})
+		case "timeout":
+			d, err := time.ParseDuration(arg)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("line %d: invalid timeout %q", lineNum, arg)
+			}
+			expectTimeout = d
+		case StepClose:
+			script.Steps = append(script.Steps, InteractionStep{Kind: StepClose, Line: lineNum})
+		default:
+			return nil, fmt.Errorf("line %d: unknown directive %q", lineNum, directive)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return script, nil
+}
+
+// outputMatcher buffers stdout and lets the script wait for patterns
+type outputMatcher struct {
+	mu     sync.Mutex
+	buf    []byte
+	cursor int
+	closed bool
+	notify chan struct{}
+}
+
+func newOutputMatcher() *outputMatcher {
+	return &outputMatcher{notify: make(chan struct{}, 1)}
+}
+
+func (m *outputMatcher) Write(p []byte) (int, error) {
+	m.mu.Lock()
+	m.buf = append(m.buf, p...)
+	m.mu.Unlock()
+	m.signal()
+	return len(p), nil
+}
+
+func (m *outputMatcher) close() {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+	m.signal()
+}
+
+func (m *outputMatcher) signal() {
+	select {
+	case m.notify <- struct{}{}:
+	default:
+	}
+}
+
+// expect waits until unread output matches the pattern and returns the consumed output
+func (m *outputMatcher) expect(ctx context.Context, pattern *regexp.Regexp, timeout time.Duration) (string, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		m.mu.Lock()
+		unread := m.buf[m.cursor:]
+		if loc := pattern.FindIndex(unread); loc != nil {
+			consumed := string(unread[:loc[1]])
+			m.cursor += loc[1]
+			m.mu.Unlock()
+			return consumed, nil
+		}
+		closed := m.closed
+		pending := string(unread)
+		m.mu.Unlock()
+
+		if closed {
+			return pending, fmt.Errorf("output closed before pattern %q matched", pattern.String())
+		}
+
+		select {
+		case <-m.notify:
+		case <-timer.C:
+			return pending, fmt.Errorf("timed out after %s waiting for pattern %q", timeout, pattern.String())
+		case <-ctx.Done():
+			return pending, ctx.Err()
+		}
+	}
+}
+
+// sendLine writes a line to the command's stdin, giving up after timeout
+// A command that stops reading fills the pipe and would block the write until
+// the wall-clock timeout; the pending write fails once runInteractive kills the
+// command and closes stdin.
+func sendLine(ctx context.Context, stdin io.Writer, line string, timeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.WriteString(stdin, line)
+		done <- err
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("timed out after %s writing to stdin (the command stopped reading input)", timeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runInteractive starts the command with piped stdin/stdout and drives it with the script
+// Stdout is still written to outputFile in full. onStart, if not nil, is called with the
+// pid once the command has started. The returned error is the command's start or wait
+// error, matching the semantics of cmd.Run().
+func runInteractive(ctx context.Context, cmd *exec.Cmd, script *InteractionScript, outputFile io.Writer, startTime time.Time, onStart func(pid int)) (*InteractionResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	stdinR, stdinW, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdin pipe: %w", err)
+	}
+	stdoutR, stdoutW, err := os.Pipe()
+	if err != nil {
+		_ = stdinR.Close()
+		_ = stdinW.Close()
+		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+	defer func() { _ = stdoutR.Close() }()
+	defer func() { _ = stdinW.Close() }()
+
+	cmd.Stdin = stdinR
+	cmd.Stdout = stdoutW
+
+	err = cmd.Start()
+	// The child holds its own copies of these ends
+	_ = stdinR.Close()
+	_ = stdoutW.Close()
+	if err != nil {
+		return nil, err
+	}
+	if onStart != nil {
+		onStart(cmd.Process.Pid)
+	}
+
+	matcher := newOutputMatcher()
+	copyDone := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.MultiWriter(outputFile, matcher), stdoutR)
+		matcher.close()
+		close(copyDone)
+	}()
+
+	result := runScript(ctx, script, stdinW, matcher, startTime)
+	_ = stdinW.Close()
+
+	// A failed interaction usually leaves the program waiting for input
+	if result.Error != "" && cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+
+	err = cmd.Wait()
+	<-copyDone
+	return result, err
+}
+
+// runScript executes the script steps in order, stopping at the first failure
+func runScript(ctx context.Context, script *InteractionScript, stdin io.WriteCloser, matcher *outputMatcher, startTime time.Time) *InteractionResult {
+	result := &InteractionResult{TotalSteps: len(script.Steps)}
+
+	for i, step := range script.Steps {
+		entry := TranscriptEntry{Step: i + 1, Kind: step.Kind, Text: step.Text}
+
+		var err error
+		switch step.Kind {
+		case StepSend:
+			err = sendLine(ctx, stdin, step.Text+"\n", step.Timeout)
+		case StepExpect:
+			entry.Output, err = matcher.expect(ctx, step.Pattern, step.Timeout)
+		case StepClose:
+			err = stdin.Close()
+		}
+
+		entry.Elapsed = time.Since(startTime).Milliseconds()
+		result.Transcript = append(result.Transcript, entry)
+
+		if err != nil {
+			result.Error = fmt.Sprintf("step %d (line %d, %s): %v", i+1, step.Line, step.Kind, err)
+			return result
+		}
+		result.CompletedSteps++
+	}
+
+	return result
+}
@@ -0,0 +1,214 @@
+package runner
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseInteractionScript(t *testing.T) {
+	tests := []struct {
+		name      string
+		script    string
+		wantSteps []string
+		wantErr   string
+	}{
+		{
+			name: "all directives",
+			script: `# greet the program
+timeout 2s
+expect ^name\?
+send Alice
+expect "Hello, Alice\n"
+close
+`,
+			wantSteps: []string{"expect", "send", "expect", "close"},
+		},
+		{
+			name:      "blank lines and comments",
+			script:    "\n\n# only a comment\nsend 1\n",
+			wantSteps: []string{"send"},
+		},
+		{name: "unknown directive", script: "type hello", wantErr: "unknown directive"},
+		{name: "invalid pattern", script: "expect (", wantErr: "invalid pattern"},
+		{name: "missing pattern", script: "expect", wantErr: "requires a pattern"},
+		{name: "invalid timeout", script: "timeout soon", wantErr: "invalid timeout"},
+		{name: "invalid quoted string", script: `send "unterminated`, wantErr: "invalid quoted string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := ParseInteractionScript(strings.NewReader(tt.script))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var kinds []string
+			for _, step := range script.Steps {
+				kinds = append(kinds, step.Kind)
+			}
+			if strings.Join(kinds, ",") != strings.Join(tt.wantSteps, ",") {
+				t.Errorf("steps = %v, want %v", kinds, tt.wantSteps)
+			}
+		})
+	}
+}
+
+func TestParseInteractionScriptTimeoutAndQuoting(t *testing.T) {
+	script, err := ParseInteractionScript(strings.NewReader("expect a\ntimeout 250ms\nexpect b\nsend \"  padded\\t\"\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if script.Steps[0].Timeout != DefaultExpectTimeout {
+		t.Errorf("first expect timeout = %v, want default %v", script.Steps[0].Timeout, DefaultExpectTimeout)
+	}
+	if script.Steps[1].Timeout != 250*time.Millisecond {
+		t.Errorf("second expect timeout = %v, want 250ms", script.Steps[1].Timeout)
+	}
+	if script.Steps[2].Text != "  padded\t" {
+		t.Errorf("send text = %q, want %q", script.Steps[2].Text, "  padded\t")
+	}
+}
+
+func TestExecuteWithInteraction(t *testing.T) {
+	// A tiny REPL: prompts, echoes each line back, exits on "quit"
+	repl := `printf '> '; while read line; do [ "$line" = quit ] && exit 0; echo "echo: $line"; printf '> '; done`
+
+	tests := []struct {
+		name          string
+		script        string
+		wantStatus    Status
+		wantCompleted int
+		wantError     string
+	}{
+		{
+			name:          "successful session",
+			script:        "expect \"> \"\nsend hello\nexpect echo: hello\nexpect \"> \"\nsend quit\n",
+			wantStatus:    StatusSuccess,
+			wantCompleted: 5,
+		},
+		{
+			name:          "pattern never appears",
+			script:        "timeout 200ms\nexpect \"> \"\nsend hello\nexpect goodbye\n",
+			wantStatus:    StatusFailed,
+			wantCompleted: 2,
+			wantError:     "timed out",
+		},
+		{
+			name:          "output closes before match",
+			script:        "close\nexpect never\n",
+			wantStatus:    StatusFailed,
+			wantCompleted: 1,
+			wantError:     "output closed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			script, err := ParseInteractionScript(strings.NewReader(tt.script))
+			if err != nil {
+				t.Fatalf("failed to parse script: %v", err)
+			}
+
+			config := &Config{
+				Command:     "sh",
+				Args:        []string{"-c", repl},
+				InputFile:   "session.txt",
+				OutputFile:  filepath.Join(tmpDir, "output.txt"),
+				StderrFile:  filepath.Join(tmpDir, "stderr.txt"),
+				Timeout:     5 * time.Second,
+				Interaction: script,
+			}
+
+			result, err := Execute(config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", result.Status, tt.wantStatus)
+			}
+			if result.Interaction == nil {
+				t.Fatal("expected interaction result")
+			}
+			if result.Interaction.CompletedSteps != tt.wantCompleted {
+				t.Errorf("completed steps = %d, want %d", result.Interaction.CompletedSteps, tt.wantCompleted)
+			}
+			if tt.wantError == "" && result.Interaction.Error != "" {
+				t.Errorf("unexpected interaction error: %s", result.Interaction.Error)
+			}
+			if tt.wantError != "" && !strings.Contains(result.Interaction.Error, tt.wantError) {
+				t.Errorf("interaction error = %q, want containing %q", result.Interaction.Error, tt.wantError)
+			}
+			if len(result.Interaction.Transcript) == 0 {
+				t.Error("expected non-empty transcript")
+			}
+		})
+	}
+}
+
+func TestExecuteWithInteractionSendTimeout(t *testing.T) {
+	// Lines larger than the pipe buffer block once the command stops reading
+	line := strings.Repeat("x", 32<<10)
+	script, err := ParseInteractionScript(strings.NewReader("timeout 200ms\nexpect ready\n" + strings.Repeat("send "+line+"\n", 8)))
+	if err != nil {
+		t.Fatalf("failed to parse script: %v", err)
+	}
+
+	tmpDir := t.TempDir()
+	start := time.Now()
+	result, err := Execute(&Config{
+		Command:     "sh",
+		Args:        []string{"-c", "echo ready; exec sleep 30"},
+		InputFile:   "session.txt",
+		OutputFile:  filepath.Join(tmpDir, "output.txt"),
+		StderrFile:  filepath.Join(tmpDir, "stderr.txt"),
+		Interaction: script,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("interaction took %v, want the send step to time out", elapsed)
+	}
+	if result.Status != StatusFailed {
+		t.Errorf("status = %s, want failed", result.Status)
+	}
+	if !strings.Contains(result.Interaction.Error, "send): timed out after 200ms writing to stdin") {
+		t.Errorf("interaction error = %q", result.Interaction.Error)
+	}
+}
+
+func TestExecuteWithInteractionWritesOutputFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	script, err := ParseInteractionScript(strings.NewReader("send one\nsend two\nexpect TWO\n"))
+	if err != nil {
+		t.Fatalf("failed to parse script: %v", err)
+	}
+
+	config := &Config{
+		Command:     "sh",
+		Args:        []string{"-c", `while read line; do echo "$line" | tr a-z A-Z; done`},
+		InputFile:   "session.txt",
+		OutputFile:  filepath.Join(tmpDir, "output.txt"),
+		StderrFile:  filepath.Join(tmpDir, "stderr.txt"),
+		Interaction: script,
+	}
+
+	result, err := Execute(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Status != StatusSuccess {
+		t.Errorf("status = %s, want success (error: %s)", result.Status, result.Interaction.Error)
+	}
+	assertFileContains(t, config.OutputFile, "ONE\nTWO\n")
+}
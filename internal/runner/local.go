@@ -0,0 +1,190 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// LocalExecutor runs commands as child processes of ghost (the default executor)
+type LocalExecutor struct{}
+
+// Name returns the executor name
+func (LocalExecutor) Name() string {
+	return DefaultExecutor
+}
+
+// Run starts the command with os/exec and waits for it to finish
+func (LocalExecutor) Run(config *Config, verbose bool) (*Execution, error) {
+	// Create command with or without timeout
+	var cmd *exec.Cmd
+	var ctx context.Context
+	var cancel context.CancelFunc
+
+	if config.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), config.Timeout)
+		defer cancel()
+		cmd = exec.CommandContext(ctx, config.Command, config.Args...)
+	} else {
+		cmd = exec.Command(config.Command, config.Args...)
+	}
+
+	// Timeouts kill the whole process tree (a job object on Windows)
+	tree := newProcessTree(cmd)
+	defer tree.release()
+	if ctx != nil {
+		cmd.Cancel = tree.kill
+	}
+
+	if len(config.Env) > 0 {
+		cmd.Env = append(os.Environ(), config.Env...)
+	}
+
+	// Without network access the command runs in its own network namespace
+	if config.NoNetwork {
+		if err := isolateNetwork(cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	// A fork limit also isolates the command in its own process group, so
+	// timeouts and cleanup reach every process it forked
+	if config.MaxForks > 0 {
+		if err := limitForks(cmd, config.MaxForks); err != nil {
+			return nil, err
+		}
+		if ctx != nil {
+			cmd.Cancel = func() error { return killProcessGroup(cmd.Process.Pid) }
+		}
+	}
+
+	// The priority shim runs first, so every other shim inherits the priority
+	if config.Priority != nil {
+		if err := applyPriority(cmd, config.Priority); err != nil {
+			return nil, err
+		}
+	}
+
+	// In interaction mode stdin/stdout are piped by runInteractive
+	var input os.FileInfo
+	if config.Interaction == nil {
+		inputFile, err := os.Open(config.InputFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open input file %s: %w", config.InputFile, err)
+		}
+		defer func() { _ = inputFile.Close() }()
+		cmd.Stdin = inputFile
+		if config.StdinTimeout > 0 {
+			if input, err = inputFile.Stat(); err != nil {
+				return nil, fmt.Errorf("failed to stat input file %s: %w", config.InputFile, err)
+			}
+		}
+	}
+
+	extraFiles, closeExtraFiles, err := openExtraFiles(config.Descriptors)
+	if err != nil {
+		return nil, err
+	}
+	defer closeExtraFiles()
+	cmd.ExtraFiles = extraFiles
+
+	outputFile, closeOutput, err := openOutputFile(config.OutputFile, os.Stdout, config.Append)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer closeOutput()
+	combined, closeCombined, err := openCombinedCapture(config)
+	if err != nil {
+		return nil, err
+	}
+	defer closeCombined()
+	capture, flushOutput := filterCapture(combined.tag(streamOutput(config, outputFile), CombinedStdout), config.StdoutFilter)
+	stdout := teeOutput(config, outputFile, capture)
+	cmd.Stdout = stdout
+
+	stderrFile, closeStderr, err := openOutputFile(config.StderrFile, os.Stderr, config.Append)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stderr file: %w", err)
+	}
+	defer closeStderr()
+
+	// If verbose mode is enabled, pipe stderr to both file and terminal
+	// (unless stderr already goes to the terminal)
+	stderrCapture, flushStderr := filterCapture(combined.tag(stderrFile, CombinedStderr), config.StderrFilter)
+	if verbose && config.StderrFile != StreamPath {
+		cmd.Stderr = io.MultiWriter(stderrCapture, os.Stderr)
+	} else {
+		cmd.Stderr = stderrCapture
+	}
+
+	// The soft timeout asks the command (or its process group) to exit
+	// before the hard timeout kills it
+	terminateCommand := func() error { return terminate(cmd.Process) }
+	killCommand := tree.kill
+	if config.MaxForks > 0 {
+		terminateCommand = func() error { return terminateProcessGroup(cmd.Process.Pid) }
+		killCommand = func() error { return killProcessGroup(cmd.Process.Pid) }
+	}
+
+	sampler := newMemorySampler()
+	forks := &forkSampler{}
+	var soft *softTimer
+	var stall *stdinWatch
+	onStart := func(pid int) {
+		tree.attach(cmd.Process)
+		sampler.start(pid)
+		if config.MaxForks > 0 {
+			forks.start(pid)
+		}
+		soft = startSoftTimer(config.SoftTimeout, terminateCommand)
+		if input != nil {
+			stall = startStdinWatch(pid, input, config.StdinTimeout, killCommand)
+		}
+	}
+
+	execution := &Execution{}
+	startTime := time.Now()
+	if config.Interaction != nil {
+		execution.Interaction, err = runInteractive(ctx, cmd, config.Interaction, stdout, startTime, onStart)
+	} else {
+		if err = cmd.Start(); err == nil {
+			onStart(cmd.Process.Pid)
+			err = cmd.Wait()
+		}
+	}
+	endTime := time.Now()
+	softFired := soft.stop()
+	execution.Stalled = stall.stop()
+	execution.MaxRSSKB = peakMemoryKB(sampler.stop(), cmd.ProcessState)
+	peakProcesses := forks.stop()
+	if config.MaxForks > 0 && cmd.Process != nil {
+		_ = killProcessGroup(cmd.Process.Pid)
+	}
+	if flushErr := flushCaptures(flushOutput, flushStderr, combined); flushErr != nil {
+		return nil, flushErr
+	}
+
+	execution.ExecutionTime = endTime.Sub(startTime).Milliseconds()
+	execution.LimitReached = forkLimitReached(peakProcesses, config.MaxForks)
+
+	// Check for timeout - need to check context directly since exec.ExitError can mask it
+	hardFired := err != nil && ctx != nil && ctx.Err() == context.DeadlineExceeded
+	execution.Deadline = deadline(hardFired, softFired)
+	execution.TimedOut = execution.Deadline != ""
+
+	if err != nil && !hardFired && !execution.Stalled {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			// ExitCode is portable: the exit status on Unix (-1 if signalled)
+			// and the process exit code on Windows
+			execution.ExitCode = exitError.ExitCode()
+			execution.Signal, execution.CoreDumped = exitSignal(exitError.ProcessState)
+		} else {
+			return nil, fmt.Errorf("failed to start command: %w", err)
+		}
+	}
+
+	return execution, nil
+}
@@ -0,0 +1,79 @@
+package runner
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// memorySampleInterval is how often the child's memory usage is polled while it runs
+var memorySampleInterval = 50 * time.Millisecond
+
+// memorySampler polls a running process for its peak resident set size
+// Unlike rusage, the polled VmHWM only covers the command after exec.
+type memorySampler struct {
+	mu     sync.Mutex
+	peakKB int64
+	stopCh chan struct{}
+	done   chan struct{}
+}
+
+func newMemorySampler() *memorySampler {
+	return &memorySampler{}
+}
+
+// start begins polling the process with the given pid
+func (s *memorySampler) start(pid int) {
+	s.stopCh = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(memorySampleInterval)
+		defer ticker.Stop()
+
+		for {
+			s.sample(pid)
+			select {
+			case <-ticker.C:
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (s *memorySampler) sample(pid int) {
+	kb, ok := processPeakRSSKB(pid)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	if kb > s.peakKB {
+		s.peakKB = kb
+	}
+	s.mu.Unlock()
+}
+
+// stop ends polling and returns the highest observed peak in kilobytes
+func (s *memorySampler) stop() int64 {
+	if s.stopCh != nil {
+		close(s.stopCh)
+		<-s.done
+		s.stopCh = nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.peakKB
+}
+
+// peakMemoryKB returns the polled peak, falling back to the rusage reported
+// after wait only where the process cannot be polled
+// Where polling works, rusage is not trusted: the child inherits ghost's own
+// high-water mark across fork, so even a trivial command reports ghost's size.
+func peakMemoryKB(sampledKB int64, state *os.ProcessState) int64 {
+	if sampledKB > 0 || state == nil {
+		return sampledKB
+	}
+	return rusageMaxRSSKB(state)
+}
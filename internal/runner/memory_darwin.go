@@ -0,0 +1,21 @@
+//go:build darwin
+
+package runner
+
+import (
+	"os"
+	"syscall"
+)
+
+// processPeakRSSKB is not supported without /proc; rusage is used instead
+func processPeakRSSKB(pid int) (int64, bool) {
+	return 0, false
+}
+
+// rusageMaxRSSKB returns the max RSS from rusage (reported in bytes on macOS)
+func rusageMaxRSSKB(state *os.ProcessState) int64 {
+	if rusage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		return rusage.Maxrss / 1024
+	}
+	return 0
+}
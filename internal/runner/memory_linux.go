@@ -0,0 +1,44 @@
+//go:build linux
+
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// processPeakRSSKB reads the peak resident set size (VmHWM) from /proc
+func processPeakRSSKB(pid int) (int64, bool) {
+	file, err := os.Open(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return 0, false
+	}
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "VmHWM:") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "VmHWM:"))
+		if len(fields) == 0 {
+			return 0, false
+		}
+		kb, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return kb, true
+	}
+	return 0, false
+}
+
+// rusageMaxRSSKB is not used on Linux: ru_maxrss includes the high-water mark
+// the child inherited from ghost, so only the polled VmHWM is reported
+func rusageMaxRSSKB(state *os.ProcessState) int64 {
+	return 0
+}
@@ -0,0 +1,15 @@
+//go:build !linux && !darwin
+
+package runner
+
+import "os"
+
+// processPeakRSSKB is not supported on this platform
+func processPeakRSSKB(pid int) (int64, bool) {
+	return 0, false
+}
+
+// rusageMaxRSSKB is not supported on this platform
+func rusageMaxRSSKB(state *os.ProcessState) int64 {
+	return 0
+}
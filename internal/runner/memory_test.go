@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestExecuteReportsPeakMemory(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		t.Skip("peak memory reporting is only supported on linux and darwin")
+	}
+
+	tmpDir := t.TempDir()
+	config := &Config{
+		Command:    "sh",
+		Args:       []string{"-c", "sleep 0.2"},
+		InputFile:  createTempFile(t, tmpDir, "input.txt", ""),
+		OutputFile: filepath.Join(tmpDir, "output.txt"),
+		StderrFile: filepath.Join(tmpDir, "stderr.txt"),
+	}
+
+	result, err := Execute(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.MaxRSSKB <= 0 {
+		t.Errorf("expected positive peak memory, got %d KB", result.MaxRSSKB)
+	}
+}
+
+func TestMemorySampler(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("/proc polling is only supported on linux")
+	}
+
+	sampler := newMemorySampler()
+	sampler.start(os.Getpid())
+	peak := sampler.stop()
+
+	if peak <= 0 {
+		t.Errorf("expected sampler to observe own process memory, got %d KB", peak)
+	}
+
+	// Stopping twice must be safe
+	if again := sampler.stop(); again != peak {
+		t.Errorf("second stop returned %d, want %d", again, peak)
+	}
+}
+
+func TestPeakMemoryKB(t *testing.T) {
+	if got := peakMemoryKB(1234, nil); got != 1234 {
+		t.Errorf("peakMemoryKB without process state = %d, want 1234", got)
+	}
+}
+
+func TestExecutePeakMemoryExcludesGhost(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("/proc polling is only supported on linux")
+	}
+	// Grow this process well past what the command needs
+	ballast := make([]byte, 64<<20)
+	for i := range ballast {
+		ballast[i] = 1
+	}
+	own, ok := processPeakRSSKB(os.Getpid())
+	if !ok {
+		t.Fatal("cannot read own peak memory")
+	}
+
+	tmpDir := t.TempDir()
+	config := &Config{
+		Command:    "sh",
+		Args:       []string{"-c", "sleep 0.2"},
+		InputFile:  createTempFile(t, tmpDir, "input.txt", ""),
+		OutputFile: filepath.Join(tmpDir, "output.txt"),
+		StderrFile: filepath.Join(tmpDir, "stderr.txt"),
+	}
+	result, err := Execute(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	runtime.KeepAlive(ballast)
+	if result.MaxRSSKB <= 0 || result.MaxRSSKB >= own/2 {
+		t.Errorf("MaxRSSKB = %d, want the command's own peak, well below ghost's %d KB", result.MaxRSSKB, own)
+	}
+}
@@ -0,0 +1,37 @@
+package runner
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// ValidateNetworkIsolation checks that the executor can run the command without network access
+func ValidateNetworkIsolation(executor Executor, noNetwork bool) error {
+	if !noNetwork {
+		return nil
+	}
+	isolator, ok := executor.(networkIsolator)
+	if !ok {
+		return fmt.Errorf("--no-network is not supported by the %s executor", executor.Name())
+	}
+	return isolator.isolatesNetwork()
+}
+
+// networkIsolator is implemented by executors that honour NoNetwork
+type networkIsolator interface {
+	// isolatesNetwork returns an error if the network cannot be isolated here
+	isolatesNetwork() error
+}
+
+// isolatesNetwork reports whether network namespaces are available (Linux only)
+func (LocalExecutor) isolatesNetwork() error {
+	if !networkIsolationSupported {
+		return fmt.Errorf("--no-network is not supported on %s", runtime.GOOS)
+	}
+	return nil
+}
+
+// isolatesNetwork marks NoNetwork as enforced by Docker (--network none)
+func (d *DockerExecutor) isolatesNetwork() error {
+	return nil
+}
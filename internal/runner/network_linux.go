@@ -0,0 +1,117 @@
+//go:build linux
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/zinc-sig/ghost/internal/redact"
+)
+
+const networkIsolationSupported = true
+
+// noNetworkEnv tells a re-executed ghost to act as the network isolation shim
+const noNetworkEnv = "GHOST_NO_NETWORK"
+
+func init() {
+	// With --max-forks, --nice or --ionice those shims run first and re-execute this shim
+	if _, ok := os.LookupEnv(forkLimitEnv); ok {
+		return
+	}
+	if _, ok := os.LookupEnv(priorityEnv); ok {
+		return
+	}
+	if _, ok := os.LookupEnv(noNetworkEnv); ok {
+		runNoNetworkShim()
+	}
+}
+
+// runNoNetworkShim brings up loopback in the new network namespace and replaces
+// this process with the command
+// A new network namespace only has a loopback interface, which starts down.
+func runNoNetworkShim() {
+	_ = os.Unsetenv(noNetworkEnv)
+
+	err := errors.New("missing command")
+	if len(os.Args) >= 3 {
+		err = bringUpLoopback()
+		if err == nil {
+			// The command itself gets no capabilities in the namespace
+			err = unix.Prctl(unix.PR_CAP_AMBIENT, unix.PR_CAP_AMBIENT_CLEAR_ALL, 0, 0, 0)
+		}
+		if err == nil {
+			err = unix.Exec(os.Args[1], os.Args[2:], os.Environ())
+		}
+	}
+	fmt.Fprintf(redact.Stderr, "ghost: failed to apply --no-network: %v\n", err)
+	os.Exit(127)
+}
+
+// bringUpLoopback sets the loopback interface up
+func bringUpLoopback() error {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open socket: %w", err)
+	}
+	defer func() { _ = unix.Close(fd) }()
+
+	ifr, err := unix.NewIfreq("lo")
+	if err != nil {
+		return err
+	}
+	if err := unix.IoctlIfreq(fd, unix.SIOCGIFFLAGS, ifr); err != nil {
+		return fmt.Errorf("failed to read loopback flags: %w", err)
+	}
+	ifr.SetUint16(ifr.Uint16() | unix.IFF_UP)
+	if err := unix.IoctlIfreq(fd, unix.SIOCSIFFLAGS, ifr); err != nil {
+		return fmt.Errorf("failed to bring up loopback: %w", err)
+	}
+	return nil
+}
+
+// isolateNetwork runs cmd through the network isolation shim in a new network namespace
+// Without root a user namespace mapping only the current user is created too,
+// and CAP_NET_ADMIN is passed to the shim as an ambient capability so it can
+// bring up loopback. A command whose lookup already failed is left alone.
+func isolateNetwork(cmd *exec.Cmd) error {
+	if cmd.Err != nil {
+		return nil
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate ghost executable: %w", err)
+	}
+
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	filtered := make([]string, 0, len(env)+1)
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, noNetworkEnv+"=") {
+			filtered = append(filtered, kv)
+		}
+	}
+
+	cmd.Args = append([]string{self, cmd.Path}, cmd.Args...)
+	cmd.Path = self
+	cmd.Env = append(filtered, noNetworkEnv+"=1")
+
+	attr := &syscall.SysProcAttr{Cloneflags: syscall.CLONE_NEWNET}
+	if uid := os.Geteuid(); uid != 0 {
+		gid := os.Getegid()
+		attr.Cloneflags |= syscall.CLONE_NEWUSER
+		attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: uid, HostID: uid, Size: 1}}
+		attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: gid, HostID: gid, Size: 1}}
+		attr.AmbientCaps = []uintptr{unix.CAP_NET_ADMIN}
+	}
+	cmd.SysProcAttr = attr
+	return nil
+}
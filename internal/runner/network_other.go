@@ -0,0 +1,15 @@
+//go:build !linux
+
+package runner
+
+import (
+	"errors"
+	"os/exec"
+)
+
+const networkIsolationSupported = false
+
+// isolateNetwork is not supported on this platform
+func isolateNetwork(cmd *exec.Cmd) error {
+	return errors.New("--no-network is not supported on this platform")
+}
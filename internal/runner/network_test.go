@@ -0,0 +1,104 @@
+//go:build linux
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// networkProbeEnv makes the test binary report its network instead of running tests
+const networkProbeEnv = "GHOST_TEST_NETWORK_PROBE"
+
+func TestNetworkProbe(t *testing.T) {
+	if os.Getenv(networkProbeEnv) == "" {
+		t.Skip("helper process for TestExecuteNoNetwork")
+	}
+	var names []string
+	up := false
+	ifaces, _ := net.Interfaces()
+	for _, iface := range ifaces {
+		names = append(names, iface.Name)
+		if iface.Name == "lo" {
+			up = iface.Flags&net.FlagUp != 0
+		}
+	}
+	loopback := "ok"
+	if l, err := net.Listen("tcp", "127.0.0.1:0"); err != nil {
+		loopback = err.Error()
+	} else {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			loopback = err.Error()
+		} else {
+			_ = conn.Close()
+		}
+		_ = l.Close()
+	}
+	external := "reachable"
+	if _, err := net.DialTimeout("udp", "192.0.2.1:53", time.Second); errors.Is(err, syscall.ENETUNREACH) {
+		external = "unreachable"
+	}
+	fmt.Printf("interfaces=%s up=%v loopback=%s external=%s\n", strings.Join(names, ","), up, loopback, external)
+}
+
+func TestExecuteNoNetwork(t *testing.T) {
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	outputFile := filepath.Join(dir, "output.txt")
+
+	for _, maxForks := range []int{0, 64} {
+		t.Run(fmt.Sprintf("max forks %d", maxForks), func(t *testing.T) {
+			result, err := Execute(&Config{
+				Command:    self,
+				Args:       []string{"-test.run=^TestNetworkProbe$"},
+				Env:        []string{networkProbeEnv + "=1"},
+				InputFile:  "/dev/null",
+				OutputFile: outputFile,
+				StderrFile: filepath.Join(dir, "stderr.txt"),
+				NoNetwork:  true,
+				MaxForks:   maxForks,
+			})
+			if err != nil {
+				if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EINVAL) {
+					t.Skipf("network namespaces unavailable: %v", err)
+				}
+				t.Fatalf("Execute() error = %v", err)
+			}
+			output, _ := os.ReadFile(outputFile)
+			if result.Status != StatusSuccess || !result.NetworkIsolated {
+				stderr, _ := os.ReadFile(filepath.Join(dir, "stderr.txt"))
+				t.Fatalf("Status = %s, NetworkIsolated = %v\n%s%s", result.Status, result.NetworkIsolated, output, stderr)
+			}
+			if want := "interfaces=lo up=true loopback=ok external=unreachable"; !strings.Contains(string(output), want) {
+				t.Errorf("output = %q, want %q", output, want)
+			}
+		})
+	}
+}
+
+func TestValidateNetworkIsolation(t *testing.T) {
+	if err := ValidateNetworkIsolation(LocalExecutor{}, true); err != nil {
+		t.Errorf("local executor: %v", err)
+	}
+	if err := ValidateNetworkIsolation(&DockerExecutor{}, true); err != nil {
+		t.Errorf("docker executor: %v", err)
+	}
+	err := ValidateNetworkIsolation(&SSHExecutor{}, true)
+	if err == nil || !strings.Contains(err.Error(), "not supported by the ssh executor") {
+		t.Errorf("ssh executor error = %v", err)
+	}
+	if err := ValidateNetworkIsolation(&SSHExecutor{}, false); err != nil {
+		t.Errorf("without --no-network: %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PipelineSeparator separates the steps of a pipeline on the command line
+const PipelineSeparator = "|"
+
+// Step is a single command in a pipeline
+type Step struct {
+	Command string
+	Args    []string
+}
+
+// String returns the step as a command line
+func (s Step) String() string {
+	if len(s.Args) == 0 {
+		return s.Command
+	}
+	return s.Command + " " + strings.Join(s.Args, " ")
+}
+
+// StepResult records the outcome of a single pipeline step
+// Exit code expectations apply to the pipeline as a whole, so a step's status is
+// success only if it exited with 0.
+type StepResult struct {
+	Command       string
+	Status        Status
+	ExitCode      int
+	ExecutionTime int64 // milliseconds
+}
+
+// ParsePipeline splits command line arguments into steps at each "|" argument
+func ParsePipeline(args []string) ([]Step, error) {
+	var steps []Step
+	var current []string
+	for i := 0; i <= len(args); i++ {
+		if i < len(args) && args[i] != PipelineSeparator {
+			current = append(current, args[i])
+			continue
+		}
+		if len(current) == 0 {
+			return nil, fmt.Errorf("pipeline step %d is empty", len(steps)+1)
+		}
+		steps = append(steps, Step{Command: current[0], Args: current[1:]})
+		current = nil
+	}
+	return steps, nil
+}
+
+// PipelineCommand returns the pipeline as a shell-style command line
+func PipelineCommand(steps []Step) string {
+	parts := make([]string, len(steps))
+	for i, step := range steps {
+		parts[i] = step.String()
+	}
+	return strings.Join(parts, " "+PipelineSeparator+" ")
+}
+
+// runPipeline runs the steps concurrently, connecting the stdout of each step to
+// the stdin of the next
+// The input file feeds the first step and the last step writes the output file;
+// every step shares the stderr file. Like `set -o pipefail`, the pipeline's exit
+// code is that of the last step that failed, or 0 if every step succeeded.
+func runPipeline(config *Config, verbose bool) (Status, int, int64, []StepResult, string, error) {
+	ctx := context.Background()
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+
+	inputFile, err := os.Open(config.InputFile)
+	if err != nil {
+		return "", 0, 0, nil, "", fmt.Errorf("failed to open input file %s: %w", config.InputFile, err)
+	}
+	defer func() { _ = inputFile.Close() }()
+
+	outputFile, closeOutput, err := openOutputFile(config.OutputFile, os.Stdout, config.Append)
+	if err != nil {
+		return "", 0, 0, nil, "", fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer closeOutput()
+
+	stderrFile, closeStderr, err := openOutputFile(config.StderrFile, os.Stderr, config.Append)
+	if err != nil {
+		return "", 0, 0, nil, "", fmt.Errorf("failed to create stderr file: %w", err)
+	}
+	defer closeStderr()
+
+	combined, closeCombined, err := openCombinedCapture(config)
+	if err != nil {
+		return "", 0, 0, nil, "", err
+	}
+	defer closeCombined()
+
+	capture, flushOutput := filterCapture(combined.tag(streamOutput(config, outputFile), CombinedStdout), config.StdoutFilter)
+	stderrCapture, flushStderr := filterCapture(combined.tag(stderrFile, CombinedStderr), config.StderrFilter)
+	stderr := stderrCapture
+	if verbose && config.StderrFile != StreamPath {
+		stderr = io.MultiWriter(stderrCapture, os.Stderr)
+	}
+
+	cmds := make([]*exec.Cmd, len(config.Pipeline))
+	for i, step := range config.Pipeline {
+		if config.Timeout > 0 {
+			cmds[i] = exec.CommandContext(ctx, step.Command, step.Args...)
+		} else {
+			cmds[i] = exec.Command(step.Command, step.Args...)
+		}
+		cmds[i].Stderr = stderr
+	}
+	cmds[0].Stdin = inputFile
+	cmds[len(cmds)-1].Stdout = teeOutput(config, outputFile, capture)
+
+	// The parent's copies of the pipe ends are closed once the child has its own,
+	// so each step sees EOF (or EPIPE) when its neighbour exits
+	var parentEnds []*os.File
+	defer func() {
+		for _, f := range parentEnds {
+			_ = f.Close()
+		}
+	}()
+	for i := 0; i < len(cmds)-1; i++ {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return "", 0, 0, nil, "", fmt.Errorf("failed to create pipe: %w", err)
+		}
+		cmds[i].Stdout = w
+		cmds[i+1].Stdin = r
+		parentEnds = append(parentEnds, r, w)
+	}
+
+	trees := make([]*processTree, len(cmds))
+	for i, cmd := range cmds {
+		trees[i] = newProcessTree(cmd)
+		defer trees[i].release()
+		if config.Timeout > 0 {
+			cmd.Cancel = trees[i].kill
+		}
+	}
+
+	startTime := time.Now()
+	for i, cmd := range cmds {
+		if err := cmd.Start(); err != nil {
+			// Stop the steps that already started
+			for _, started := range cmds[:i] {
+				_ = started.Process.Kill()
+			}
+			for _, f := range parentEnds {
+				_ = f.Close()
+			}
+			parentEnds = nil
+			for _, started := range cmds[:i] {
+				_ = started.Wait()
+			}
+			return "", 0, 0, nil, "", fmt.Errorf("failed to start pipeline step %d (%s): %w", i+1, config.Pipeline[i].Command, err)
+		}
+		trees[i].attach(cmd.Process)
+	}
+	for _, f := range parentEnds {
+		_ = f.Close()
+	}
+	parentEnds = nil
+
+	// The soft timeout asks every step still running to exit
+	soft := startSoftTimer(config.SoftTimeout, func() error {
+		var err error
+		signalled := false
+		for _, cmd := range cmds {
+			if termErr := terminate(cmd.Process); termErr != nil {
+				err = termErr
+			} else {
+				signalled = true
+			}
+		}
+		if signalled {
+			return nil
+		}
+		return err
+	})
+
+	// Wait concurrently so each step's execution time ends when it exits
+	steps := make([]StepResult, len(cmds))
+	waitErrs := make([]error, len(cmds))
+	var wg sync.WaitGroup
+	for i, cmd := range cmds {
+		wg.Add(1)
+		go func(i int, cmd *exec.Cmd) {
+			defer wg.Done()
+			waitErrs[i] = cmd.Wait()
+			steps[i].ExecutionTime = time.Since(startTime).Milliseconds()
+		}(i, cmd)
+	}
+	wg.Wait()
+	executionTime := time.Since(startTime).Milliseconds()
+	softFired := soft.stop()
+	if err := flushCaptures(flushOutput, flushStderr, combined); err != nil {
+		return "", 0, 0, nil, "", err
+	}
+	stoppedBy := deadline(ctx.Err() == context.DeadlineExceeded, softFired)
+	timedOut := stoppedBy != ""
+
+	exitCode := 0
+	for i, err := range waitErrs {
+		steps[i].Command = config.Pipeline[i].String()
+		steps[i].Status = StatusSuccess
+
+		if err != nil {
+			exitError, ok := err.(*exec.ExitError)
+			if !ok {
+				return "", 0, 0, nil, "", fmt.Errorf("pipeline step %d (%s) failed: %w", i+1, config.Pipeline[i].Command, err)
+			}
+			steps[i].Status = StatusFailed
+			steps[i].ExitCode = exitError.ExitCode()
+			if timedOut {
+				steps[i].Status = StatusTimeout
+				steps[i].ExitCode = -1
+			}
+		}
+		if steps[i].ExitCode != 0 {
+			exitCode = steps[i].ExitCode
+		}
+	}
+
+	if timedOut {
+		return StatusTimeout, -1, executionTime, steps, stoppedBy, nil
+	}
+	if isExpectedExitCode(config, exitCode) {
+		return StatusSuccess, exitCode, executionTime, steps, "", nil
+	}
+	return StatusFailed, exitCode, executionTime, steps, "", nil
+}
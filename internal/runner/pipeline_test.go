@@ -0,0 +1,173 @@
+//go:build linux || darwin
+
+package runner
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePipeline(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []Step
+		wantErr string
+	}{
+		{
+			name: "single step",
+			args: []string{"echo", "hi"},
+			want: []Step{{Command: "echo", Args: []string{"hi"}}},
+		},
+		{
+			name: "three steps",
+			args: []string{"generate", "-n", "5", "|", "sort", "|", "uniq", "-c"},
+			want: []Step{
+				{Command: "generate", Args: []string{"-n", "5"}},
+				{Command: "sort", Args: []string{}},
+				{Command: "uniq", Args: []string{"-c"}},
+			},
+		},
+		{
+			name:    "empty step",
+			args:    []string{"echo", "|", "|", "cat"},
+			wantErr: "pipeline step 2 is empty",
+		},
+		{
+			name:    "trailing separator",
+			args:    []string{"echo", "|"},
+			wantErr: "pipeline step 2 is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePipeline(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParsePipeline() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePipeline() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePipeline() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecutePipeline(t *testing.T) {
+	tests := []struct {
+		name         string
+		steps        []Step
+		input        string
+		timeout      time.Duration
+		wantStatus   Status
+		wantExitCode int
+		wantSteps    []Status
+		wantOutput   string
+	}{
+		{
+			name: "output flows through every step",
+			steps: []Step{
+				{Command: "sort"},
+				{Command: "tr", Args: []string{"a-z", "A-Z"}},
+			},
+			input:      "b\na\n",
+			wantStatus: StatusSuccess,
+			wantSteps:  []Status{StatusSuccess, StatusSuccess},
+			wantOutput: "A\nB\n",
+		},
+		{
+			name: "failing step fails the pipeline",
+			steps: []Step{
+				{Command: "sh", Args: []string{"-c", "echo partial; exit 3"}},
+				{Command: "cat"},
+			},
+			wantStatus:   StatusFailed,
+			wantExitCode: 3,
+			wantSteps:    []Status{StatusFailed, StatusSuccess},
+			wantOutput:   "partial\n",
+		},
+		{
+			name: "timeout kills every step",
+			steps: []Step{
+				{Command: "sleep", Args: []string{"5"}},
+				{Command: "cat"},
+			},
+			timeout:      100 * time.Millisecond,
+			wantStatus:   StatusTimeout,
+			wantExitCode: -1,
+			wantSteps:    []Status{StatusTimeout, StatusTimeout},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			outputFile := filepath.Join(tmpDir, "output.txt")
+			config := &Config{
+				Command:    tt.steps[0].Command,
+				Args:       tt.steps[0].Args,
+				Pipeline:   tt.steps,
+				InputFile:  createTempFile(t, tmpDir, "input.txt", tt.input),
+				OutputFile: outputFile,
+				StderrFile: filepath.Join(tmpDir, "stderr.txt"),
+				Timeout:    tt.timeout,
+			}
+
+			start := time.Now()
+			result, err := Execute(config)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if tt.timeout > 0 && time.Since(start) > 2*time.Second {
+				t.Errorf("Expected pipeline to stop at the timeout, took %v", time.Since(start))
+			}
+
+			if result.Status != tt.wantStatus || result.ExitCode != tt.wantExitCode {
+				t.Errorf("Status = %s (exit code %d), want %s (exit code %d)", result.Status, result.ExitCode, tt.wantStatus, tt.wantExitCode)
+			}
+			if result.Command != PipelineCommand(tt.steps) {
+				t.Errorf("Command = %q, want %q", result.Command, PipelineCommand(tt.steps))
+			}
+			if len(result.Steps) != len(tt.wantSteps) {
+				t.Fatalf("Got %d step results, want %d", len(result.Steps), len(tt.wantSteps))
+			}
+			for i, step := range result.Steps {
+				if step.Status != tt.wantSteps[i] {
+					t.Errorf("Step %d status = %s, want %s", i+1, step.Status, tt.wantSteps[i])
+				}
+			}
+			if got := readFile(t, outputFile); got != tt.wantOutput {
+				t.Errorf("Output = %q, want %q", got, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestExecutePipelineStartFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	config := &Config{
+		Command:    "sleep",
+		Pipeline:   []Step{{Command: "sleep", Args: []string{"5"}}, {Command: "ghost-no-such-command"}},
+		InputFile:  createTempFile(t, tmpDir, "input.txt", ""),
+		OutputFile: filepath.Join(tmpDir, "output.txt"),
+		StderrFile: filepath.Join(tmpDir, "stderr.txt"),
+	}
+
+	start := time.Now()
+	_, err := Execute(config)
+	if err == nil || !strings.Contains(err.Error(), "failed to start pipeline step 2") {
+		t.Errorf("Execute() error = %v, want start failure of step 2", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("Expected started steps to be stopped, took %v", time.Since(start))
+	}
+}
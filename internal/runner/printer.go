@@ -2,7 +2,8 @@ package runner
 
 import (
 	"fmt"
-	"os"
+
+	"github.com/zinc-sig/ghost/internal/redact"
 )
 
 // PrintPreExecution prints command details before execution
@@ -12,40 +13,105 @@ func PrintPreExecution(fullCommand string, config *Config) {
 		header = "Ghost Command Execution Details (DRY RUN)"
 	}
 
-	fmt.Fprintln(os.Stderr, "========================================")
-	fmt.Fprintln(os.Stderr, header)
-	fmt.Fprintln(os.Stderr, "========================================")
-	fmt.Fprintf(os.Stderr, "Command: %s\n", fullCommand)
-	fmt.Fprintf(os.Stderr, "Input:   %s\n", config.InputFile)
-	fmt.Fprintf(os.Stderr, "Output:  %s\n", config.OutputFile)
-	fmt.Fprintf(os.Stderr, "Stderr:  %s\n", config.StderrFile)
-	if config.Timeout > 0 {
-		fmt.Fprintf(os.Stderr, "Timeout: %s\n", config.Timeout)
-	}
-	fmt.Fprintln(os.Stderr, "----------------------------------------")
+	fmt.Fprintln(redact.Stderr, "========================================")
+	fmt.Fprintln(redact.Stderr, header)
+	fmt.Fprintln(redact.Stderr, "========================================")
+	if config.RunID != "" {
+		fmt.Fprintf(redact.Stderr, "Run ID:  %s\n", config.RunID)
+	}
+	fmt.Fprintf(redact.Stderr, "Command: %s\n", fullCommand)
+	if config.Interaction != nil {
+		fmt.Fprintf(redact.Stderr, "Input:   %s (interaction script, %d steps)\n", config.InputFile, len(config.Interaction.Steps))
+	} else {
+		fmt.Fprintf(redact.Stderr, "Input:   %s\n", config.InputFile)
+	}
+	fmt.Fprintf(redact.Stderr, "Output:  %s\n", config.OutputFile)
+	fmt.Fprintf(redact.Stderr, "Stderr:  %s\n", config.StderrFile)
+	for _, fd := range config.Descriptors {
+		fmt.Fprintf(redact.Stderr, "%-9s%s (%s)\n", fmt.Sprintf("FD %d:", fd.FD), fd.Path, fd.Mode)
+	}
+	if config.SoftTimeout > 0 {
+		fmt.Fprintf(redact.Stderr, "Timeout: %s (SIGTERM at %s)\n", config.Timeout, config.SoftTimeout)
+	} else if config.Timeout > 0 {
+		fmt.Fprintf(redact.Stderr, "Timeout: %s\n", config.Timeout)
+	}
+	if config.MaxForks > 0 {
+		fmt.Fprintf(redact.Stderr, "Forks:   at most %d processes\n", config.MaxForks)
+	}
+	if config.Priority != nil {
+		fmt.Fprintf(redact.Stderr, "Prio:    %s\n", config.Priority)
+	}
+	if config.Executor != nil && config.Executor.Name() != DefaultExecutor {
+		backend := config.Executor.Name()
+		if described, ok := config.Executor.(fmt.Stringer); ok {
+			backend = described.String()
+		}
+		fmt.Fprintf(redact.Stderr, "Backend: %s\n", backend)
+	}
+	if config.MemoryLimit > 0 {
+		fmt.Fprintf(redact.Stderr, "Memory:  at most %d bytes\n", config.MemoryLimit)
+	}
+	if config.CPULimit > 0 {
+		fmt.Fprintf(redact.Stderr, "CPUs:    at most %g cores\n", config.CPULimit)
+	}
+	if config.ExpectExitCode != nil {
+		fmt.Fprintf(redact.Stderr, "Expect:  exit code %d\n", *config.ExpectExitCode)
+	} else if config.ExpectNonzero {
+		fmt.Fprintln(redact.Stderr, "Expect:  non-zero exit code")
+	}
+	fmt.Fprintln(redact.Stderr, "----------------------------------------")
 
 	if config.DryRun {
-		fmt.Fprintln(os.Stderr, "[DRY RUN] Command would be executed here")
-		fmt.Fprintln(os.Stderr, "----------------------------------------")
+		fmt.Fprintln(redact.Stderr, "[DRY RUN] Command would be executed here")
+		fmt.Fprintln(redact.Stderr, "----------------------------------------")
 	} else {
-		fmt.Fprintln(os.Stderr, "Command Output:")
-		fmt.Fprintln(os.Stderr, "----------------------------------------")
+		fmt.Fprintln(redact.Stderr, "Command Output:")
+		fmt.Fprintln(redact.Stderr, "----------------------------------------")
 	}
 }
 
 // PrintPostExecution prints execution results after command completion
-func PrintPostExecution(status Status, exitCode int, executionTime int64, dryRun bool) {
-	fmt.Fprintln(os.Stderr, "----------------------------------------")
+func PrintPostExecution(status Status, exitCode int, executionTime int64, maxRSSKB int64, dryRun bool) {
+	fmt.Fprintln(redact.Stderr, "----------------------------------------")
 	if dryRun {
-		fmt.Fprintln(os.Stderr, "Execution Results (DRY RUN - Simulated):")
+		fmt.Fprintln(redact.Stderr, "Execution Results (DRY RUN - Simulated):")
 	} else {
-		fmt.Fprintln(os.Stderr, "Execution Results:")
+		fmt.Fprintln(redact.Stderr, "Execution Results:")
+	}
+	fmt.Fprintln(redact.Stderr, "----------------------------------------")
+	fmt.Fprintf(redact.Stderr, "Status:         %s\n", status)
+	fmt.Fprintf(redact.Stderr, "Exit Code:      %d\n", exitCode)
+	fmt.Fprintf(redact.Stderr, "Execution Time: %d ms\n", executionTime)
+	if maxRSSKB > 0 {
+		fmt.Fprintf(redact.Stderr, "Peak Memory:    %d KB\n", maxRSSKB)
+	}
+	fmt.Fprintln(redact.Stderr, "========================================")
+}
+
+// PrintInteractionSummary prints the outcome of an interaction script
+func PrintInteractionSummary(interaction *InteractionResult) {
+	fmt.Fprintln(redact.Stderr, "----------------------------------------")
+	fmt.Fprintf(redact.Stderr, "Interaction:    %d/%d steps completed\n", interaction.CompletedSteps, interaction.TotalSteps)
+	if interaction.Error != "" {
+		fmt.Fprintf(redact.Stderr, "Interaction Error: %s\n", interaction.Error)
+	}
+}
+
+// PrintPipelineSummary prints the outcome of each pipeline step
+func PrintPipelineSummary(steps []StepResult) {
+	fmt.Fprintln(redact.Stderr, "----------------------------------------")
+	for i, step := range steps {
+		fmt.Fprintf(redact.Stderr, "Step %d:         %s (exit code %d, %d ms) %s\n", i+1, step.Status, step.ExitCode, step.ExecutionTime, step.Command)
+	}
+}
+
+// PrintGraderSummary prints how the grader of an interactive run finished
+func PrintGraderSummary(grader *GraderResult) {
+	fmt.Fprintln(redact.Stderr, "----------------------------------------")
+	fmt.Fprintf(redact.Stderr, "Grader:         %s (exit code %d, %d ms) %s\n", grader.Status, grader.ExitCode, grader.ExecutionTime, grader.Command)
+	if grader.Verdict != "" {
+		fmt.Fprintf(redact.Stderr, "Verdict:        %s\n", grader.Verdict)
 	}
-	fmt.Fprintln(os.Stderr, "----------------------------------------")
-	fmt.Fprintf(os.Stderr, "Status:         %s\n", status)
-	fmt.Fprintf(os.Stderr, "Exit Code:      %d\n", exitCode)
-	fmt.Fprintf(os.Stderr, "Execution Time: %d ms\n", executionTime)
-	fmt.Fprintln(os.Stderr, "========================================")
 }
 
 // ExecutionDetails holds the information for execution printing
@@ -55,6 +121,7 @@ type ExecutionDetails struct {
 	Status        Status
 	ExitCode      int
 	ExecutionTime int64
+	MaxRSSKB      int64
 }
 
 // PrintExecutionSummary prints a complete execution summary (alternative approach)
@@ -64,6 +131,6 @@ func PrintExecutionSummary(details *ExecutionDetails, phase string) {
 	case "pre":
 		PrintPreExecution(details.FullCommand, details.Config)
 	case "post":
-		PrintPostExecution(details.Status, details.ExitCode, details.ExecutionTime, details.Config.DryRun)
+		PrintPostExecution(details.Status, details.ExitCode, details.ExecutionTime, details.MaxRSSKB, details.Config.DryRun)
 	}
 }
@@ -0,0 +1,161 @@
+package runner
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// I/O scheduling classes of --ionice
+const (
+	IOClassRealtime   = "realtime"
+	IOClassBestEffort = "best-effort"
+	IOClassIdle       = "idle"
+)
+
+// IOClasses lists the I/O scheduling classes
+var IOClasses = []string{IOClassRealtime, IOClassBestEffort, IOClassIdle}
+
+// ioClassNumbers maps I/O classes to their values in ioprio_set(2) and ionice -c
+var ioClassNumbers = map[string]int{
+	IOClassRealtime:   1,
+	IOClassBestEffort: 2,
+	IOClassIdle:       3,
+}
+
+// Nice values and I/O priority levels, highest priority first
+const (
+	minNice    = -20
+	maxNice    = 19
+	maxIOLevel = 7
+)
+
+// Priority is the CPU and I/O scheduling priority the command runs with
+type Priority struct {
+	Nice    int    // nice value from -20 to 19 (0 = inherited)
+	IOClass string // I/O scheduling class ("" = inherited)
+	IOLevel int    // 0 (highest) to 7 within the realtime and best-effort classes
+}
+
+// ParsePriority validates --nice and an --ionice value of the form class[:level]
+// It returns nil if neither changes the priority.
+func ParsePriority(nice int, ionice string) (*Priority, error) {
+	if nice < minNice || nice > maxNice {
+		return nil, fmt.Errorf("invalid nice value %d: must be between %d and %d", nice, minNice, maxNice)
+	}
+	p := &Priority{Nice: nice}
+	if ionice != "" {
+		class, level, hasLevel := strings.Cut(ionice, ":")
+		switch class {
+		case IOClassRealtime, IOClassBestEffort:
+			p.IOLevel = 4 // the kernel's default level
+		case IOClassIdle:
+			if hasLevel {
+				return nil, fmt.Errorf("invalid I/O priority %q: the %s class has no level", ionice, IOClassIdle)
+			}
+		default:
+			return nil, fmt.Errorf("invalid I/O class %q (must be %s)", class, strings.Join(IOClasses, ", "))
+		}
+		if hasLevel {
+			n, err := strconv.Atoi(level)
+			if err != nil || n < 0 || n > maxIOLevel {
+				return nil, fmt.Errorf("invalid I/O priority level %q: must be between 0 and %d", level, maxIOLevel)
+			}
+			p.IOLevel = n
+		}
+		p.IOClass = class
+	}
+	if p.Nice == 0 && p.IOClass == "" {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// String describes the priority, e.g. "nice 10, I/O best-effort:7"
+func (p *Priority) String() string {
+	var parts []string
+	if p.Nice != 0 {
+		parts = append(parts, fmt.Sprintf("nice %d", p.Nice))
+	}
+	switch p.IOClass {
+	case "":
+	case IOClassIdle:
+		parts = append(parts, "I/O "+p.IOClass)
+	default:
+		parts = append(parts, fmt.Sprintf("I/O %s:%d", p.IOClass, p.IOLevel))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ValidatePriority checks that the executor can run the command with the priority
+func ValidatePriority(executor Executor, priority *Priority) error {
+	if priority == nil {
+		return nil
+	}
+	setter, ok := executor.(prioritySetter)
+	if !ok {
+		return fmt.Errorf("--nice and --ionice are not supported by the %s executor", executor.Name())
+	}
+	return setter.setsPriority(priority)
+}
+
+// prioritySetter is implemented by executors that honour Priority
+type prioritySetter interface {
+	// setsPriority returns an error if the priority cannot be applied here
+	setsPriority(priority *Priority) error
+}
+
+// setsPriority reports whether the priority can be set on this platform
+// Nice values are supported on Linux and macOS, I/O classes on Linux only.
+func (LocalExecutor) setsPriority(priority *Priority) error {
+	if !prioritySupported {
+		return fmt.Errorf("--nice and --ionice are not supported on %s", runtime.GOOS)
+	}
+	if priority.IOClass != "" && !ioPrioritySupported {
+		return fmt.Errorf("--ionice is not supported on %s", runtime.GOOS)
+	}
+	return nil
+}
+
+// setsPriority marks the priority as applied with nice and ionice on the remote host
+func (s *SSHExecutor) setsPriority(priority *Priority) error {
+	return nil
+}
+
+// command returns the nice and ionice commands that apply the priority in a shell
+func (p *Priority) command() []string {
+	var args []string
+	if p.Nice != 0 {
+		args = append(args, "nice", "-n", strconv.Itoa(p.Nice))
+	}
+	if p.IOClass != "" {
+		args = append(args, "ionice", "-c", strconv.Itoa(ioClassNumbers[p.IOClass]))
+		if p.IOClass != IOClassIdle {
+			args = append(args, "-n", strconv.Itoa(p.IOLevel))
+		}
+	}
+	return args
+}
+
+// priorityEnvValue encodes a priority for the priority shim
+func priorityEnvValue(p *Priority) string {
+	return fmt.Sprintf("%d,%s,%d", p.Nice, p.IOClass, p.IOLevel)
+}
+
+// parsePriorityEnv decodes a priority encoded by priorityEnvValue
+func parsePriorityEnv(value string) (*Priority, error) {
+	fields := strings.Split(value, ",")
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("invalid priority %q", value)
+	}
+	nice, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid priority %q", value)
+	}
+	level, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid priority %q", value)
+	}
+	return &Priority{Nice: nice, IOClass: fields[1], IOLevel: level}, nil
+}
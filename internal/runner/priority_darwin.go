@@ -0,0 +1,12 @@
+//go:build darwin
+
+package runner
+
+import "errors"
+
+const ioPrioritySupported = false
+
+// setIOPriority is not supported on macOS
+func setIOPriority(class string, level int) error {
+	return errors.New("I/O priorities are not supported on macOS")
+}
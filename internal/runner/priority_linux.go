@@ -0,0 +1,24 @@
+//go:build linux
+
+package runner
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+const ioPrioritySupported = true
+
+// ioprio_set(2) constants, see linux/ioprio.h
+const (
+	ioprioWhoProcess = 1
+	ioprioClassShift = 13
+)
+
+// setIOPriority sets the I/O scheduling class and level of the calling thread
+func setIOPriority(class string, level int) error {
+	prio := ioClassNumbers[class]<<ioprioClassShift | level
+	if _, _, errno := unix.Syscall(unix.SYS_IOPRIO_SET, ioprioWhoProcess, 0, uintptr(prio)); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+//go:build linux
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalExecutorPriority(t *testing.T) {
+	if _, err := exec.LookPath("ionice"); err != nil {
+		t.Skip("ionice not installed")
+	}
+
+	tests := []struct {
+		name     string
+		config   Config
+		priority *Priority
+		script   string
+		want     string
+	}{
+		{name: "nice and idle", priority: &Priority{Nice: 7, IOClass: IOClassIdle}, script: "nice; ionice", want: "7\nidle\n"},
+		{name: "best-effort", priority: &Priority{IOClass: IOClassBestEffort, IOLevel: 6}, script: "ionice", want: "best-effort: prio 6\n"},
+		// The priority shim runs before the fork limit shim
+		{name: "with fork limit", config: Config{MaxForks: 64}, priority: &Priority{Nice: 3}, script: "nice", want: "3\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			config := tt.config
+			config.Command = "sh"
+			config.Args = []string{"-c", tt.script}
+			config.InputFile = os.DevNull
+			config.OutputFile = filepath.Join(dir, "output.txt")
+			config.StderrFile = filepath.Join(dir, "stderr.txt")
+			config.Priority = tt.priority
+
+			execution, err := LocalExecutor{}.Run(&config, false)
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			stderr, _ := os.ReadFile(config.StderrFile)
+			if execution.ExitCode != 0 {
+				t.Fatalf("exit code = %d, stderr: %s", execution.ExitCode, stderr)
+			}
+			output, _ := os.ReadFile(config.OutputFile)
+			if got := string(output); got != tt.want {
+				t.Errorf("output = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
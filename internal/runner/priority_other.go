@@ -0,0 +1,18 @@
+//go:build !linux && !darwin
+
+package runner
+
+import (
+	"errors"
+	"os/exec"
+)
+
+const (
+	prioritySupported   = false
+	ioPrioritySupported = false
+)
+
+// applyPriority is not supported on this platform
+func applyPriority(cmd *exec.Cmd, priority *Priority) error {
+	return errors.New("--nice and --ionice are not supported on this platform")
+}
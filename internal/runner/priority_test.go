@@ -0,0 +1,74 @@
+package runner
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePriority(t *testing.T) {
+	tests := []struct {
+		name     string
+		nice     int
+		ionice   string
+		want     *Priority
+		wantText string
+		wantErr  string
+	}{
+		{name: "inherited"},
+		{name: "nice", nice: 10, want: &Priority{Nice: 10}, wantText: "nice 10"},
+		{name: "idle", ionice: "idle", want: &Priority{IOClass: IOClassIdle}, wantText: "I/O idle"},
+		{name: "default level", ionice: "best-effort", want: &Priority{IOClass: IOClassBestEffort, IOLevel: 4}, wantText: "I/O best-effort:4"},
+		{name: "nice and level", nice: 19, ionice: "best-effort:7", want: &Priority{Nice: 19, IOClass: IOClassBestEffort, IOLevel: 7}, wantText: "nice 19, I/O best-effort:7"},
+		{name: "realtime", ionice: "realtime:0", want: &Priority{IOClass: IOClassRealtime}, wantText: "I/O realtime:0"},
+		{name: "nice too high", nice: 20, wantErr: "must be between -20 and 19"},
+		{name: "unknown class", ionice: "low", wantErr: `invalid I/O class "low"`},
+		{name: "level too high", ionice: "best-effort:8", wantErr: `invalid I/O priority level "8"`},
+		{name: "idle level", ionice: "idle:3", wantErr: "the idle class has no level"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePriority(tt.nice, tt.ionice)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParsePriority() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParsePriority() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePriority() = %+v, want %+v", got, tt.want)
+			}
+			if got != nil && got.String() != tt.wantText {
+				t.Errorf("String() = %q, want %q", got.String(), tt.wantText)
+			}
+		})
+	}
+}
+
+func TestPriorityEnvValue(t *testing.T) {
+	p := &Priority{Nice: -5, IOClass: IOClassBestEffort, IOLevel: 2}
+	got, err := parsePriorityEnv(priorityEnvValue(p))
+	if err != nil || !reflect.DeepEqual(got, p) {
+		t.Errorf("parsePriorityEnv() = %+v, %v, want %+v", got, err, p)
+	}
+	if _, err := parsePriorityEnv("10"); err == nil {
+		t.Error("parsePriorityEnv() accepted a malformed value")
+	}
+}
+
+func TestValidatePriority(t *testing.T) {
+	p := &Priority{Nice: 10}
+	if err := ValidatePriority(&DockerExecutor{}, p); err == nil || !strings.Contains(err.Error(), "not supported by the docker executor") {
+		t.Errorf("ValidatePriority(docker) error = %v", err)
+	}
+	if err := ValidatePriority(&SSHExecutor{}, p); err != nil {
+		t.Errorf("ValidatePriority(ssh) error = %v", err)
+	}
+	if err := ValidatePriority(&DockerExecutor{}, nil); err != nil {
+		t.Errorf("ValidatePriority(nil) error = %v", err)
+	}
+}
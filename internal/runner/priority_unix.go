@@ -0,0 +1,95 @@
+//go:build linux || darwin
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/zinc-sig/ghost/internal/redact"
+)
+
+const prioritySupported = true
+
+// priorityEnv tells a re-executed ghost to act as the priority shim
+const priorityEnv = "GHOST_PRIORITY"
+
+func init() {
+	if value, ok := os.LookupEnv(priorityEnv); ok {
+		runPriorityShim(value)
+	}
+}
+
+// runPriorityShim sets the nice value and I/O priority and replaces this
+// process with the command
+// Go cannot run code between fork and exec, so ghost re-executes itself as
+// "ghost <path> <argv...>" with priorityEnv set. The priorities are set on the
+// calling thread, which becomes the command's, and inherited by its children.
+func runPriorityShim(value string) {
+	_ = os.Unsetenv(priorityEnv)
+	runtime.LockOSThread()
+
+	err := errors.New("missing command")
+	if len(os.Args) >= 3 {
+		err = setPriority(value)
+		if err == nil {
+			err = unix.Exec(os.Args[1], os.Args[2:], os.Environ())
+		}
+	}
+	fmt.Fprintf(redact.Stderr, "ghost: failed to apply --nice/--ionice: %v\n", err)
+	os.Exit(127)
+}
+
+// setPriority applies an encoded priority to the calling thread
+func setPriority(value string) error {
+	p, err := parsePriorityEnv(value)
+	if err != nil {
+		return err
+	}
+	if p.Nice != 0 {
+		if err := unix.Setpriority(unix.PRIO_PROCESS, 0, p.Nice); err != nil {
+			return fmt.Errorf("failed to set nice value %d: %w", p.Nice, err)
+		}
+	}
+	if p.IOClass != "" {
+		if err := setIOPriority(p.IOClass, p.IOLevel); err != nil {
+			return fmt.Errorf("failed to set I/O priority %s: %w", p.IOClass, err)
+		}
+	}
+	return nil
+}
+
+// applyPriority runs cmd through the priority shim
+// It wraps the other shims, so it must be applied last. A command whose lookup
+// already failed is left alone so Start reports the error.
+func applyPriority(cmd *exec.Cmd, priority *Priority) error {
+	if cmd.Err != nil {
+		return nil
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate ghost executable: %w", err)
+	}
+
+	env := cmd.Env
+	if env == nil {
+		env = os.Environ()
+	}
+	filtered := make([]string, 0, len(env)+1)
+	for _, kv := range env {
+		if !strings.HasPrefix(kv, priorityEnv+"=") {
+			filtered = append(filtered, kv)
+		}
+	}
+
+	cmd.Args = append([]string{self, cmd.Path}, cmd.Args...)
+	cmd.Path = self
+	cmd.Env = append(filtered, priorityEnv+"="+priorityEnvValue(priority))
+	return nil
+}
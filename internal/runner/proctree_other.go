@@ -0,0 +1,30 @@
+//go:build !windows
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+)
+
+// processTree kills a command on timeout
+// On Unix the command is killed directly, matching exec.CommandContext.
+type processTree struct {
+	cmd *exec.Cmd
+}
+
+func newProcessTree(cmd *exec.Cmd) *processTree {
+	return &processTree{cmd: cmd}
+}
+
+func (t *processTree) attach(*os.Process) {}
+
+// kill terminates the command
+func (t *processTree) kill() error {
+	if t.cmd.Process != nil {
+		return t.cmd.Process.Kill()
+	}
+	return nil
+}
+
+func (t *processTree) release() {}
@@ -0,0 +1,126 @@
+//go:build windows
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// processTree tracks a command and its descendants with a Windows job object
+// Killing the job terminates every process the command spawned, which
+// Process.Kill alone does not do on Windows.
+type processTree struct {
+	cmd *exec.Cmd
+	job windows.Handle
+}
+
+// newProcessTree makes cmd start suspended, so attach can assign it to the job
+// before it runs and nothing it spawns escapes the job
+func newProcessTree(cmd *exec.Cmd) *processTree {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.CreationFlags |= windows.CREATE_SUSPENDED
+	return &processTree{cmd: cmd}
+}
+
+// attach assigns the started process to a new job object and resumes it
+// Failures fall back to killing only the process itself. A process that cannot
+// be resumed is killed rather than left suspended until the timeout.
+func (t *processTree) attach(process *os.Process) {
+	t.assign(process)
+	if err := resumeProcess(process.Pid); err != nil {
+		_ = t.kill()
+	}
+}
+
+// assign puts the process in a new job object that kills it when closed
+func (t *processTree) assign(process *os.Process) {
+	job, err := windows.CreateJobObject(nil, nil)
+	if err != nil {
+		return
+	}
+
+	// Closing the last job handle kills any process still in the job
+	info := windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION{
+		BasicLimitInformation: windows.JOBOBJECT_BASIC_LIMIT_INFORMATION{
+			LimitFlags: windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE,
+		},
+	}
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation,
+		uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		_ = windows.CloseHandle(job)
+		return
+	}
+
+	handle, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(process.Pid))
+	if err != nil {
+		_ = windows.CloseHandle(job)
+		return
+	}
+	defer func() { _ = windows.CloseHandle(handle) }()
+
+	if err := windows.AssignProcessToJobObject(job, handle); err != nil {
+		_ = windows.CloseHandle(job)
+		return
+	}
+	t.job = job
+}
+
+// resumeProcess resumes the threads of a process started with CREATE_SUSPENDED
+// os.Process does not expose the main thread handle, so the process's threads
+// are found in a toolhelp snapshot; a suspended process has only its main thread.
+func resumeProcess(pid int) error {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPTHREAD, 0)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = windows.CloseHandle(snapshot) }()
+
+	entry := windows.ThreadEntry32{Size: uint32(unsafe.Sizeof(windows.ThreadEntry32{}))}
+	resumed := false
+	for err = windows.Thread32First(snapshot, &entry); err == nil; err = windows.Thread32Next(snapshot, &entry) {
+		if entry.OwnerProcessID != uint32(pid) {
+			continue
+		}
+		thread, err := windows.OpenThread(windows.THREAD_SUSPEND_RESUME, false, entry.ThreadID)
+		if err != nil {
+			return err
+		}
+		_, err = windows.ResumeThread(thread)
+		_ = windows.CloseHandle(thread)
+		if err != nil {
+			return err
+		}
+		resumed = true
+	}
+	if !resumed {
+		return fmt.Errorf("no threads found for process %d", pid)
+	}
+	return nil
+}
+
+// kill terminates the whole process tree
+func (t *processTree) kill() error {
+	if t.job != 0 {
+		return windows.TerminateJobObject(t.job, 1)
+	}
+	if t.cmd.Process != nil {
+		return t.cmd.Process.Kill()
+	}
+	return nil
+}
+
+// release closes the job object, killing any processes left behind
+func (t *processTree) release() {
+	if t.job != 0 {
+		_ = windows.CloseHandle(t.job)
+		t.job = 0
+	}
+}
@@ -0,0 +1,87 @@
+//go:build windows
+
+package runner
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExecuteTimeoutKillsProcessTree(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// cmd.exe spawns ping as a child; both must die when the timeout fires
+	config := &Config{
+		Command:    "cmd",
+		Args:       []string{"/c", "ping -n 30 127.0.0.1 > nul"},
+		InputFile:  "NUL",
+		OutputFile: filepath.Join(tmpDir, "output.txt"),
+		StderrFile: filepath.Join(tmpDir, "stderr.txt"),
+		Timeout:    500 * time.Millisecond,
+		Verbose:    true, // stderr goes through a pipe the child inherits
+	}
+
+	start := time.Now()
+	result, err := Execute(config)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	if result.Status != StatusTimeout {
+		t.Errorf("Status = %s, want %s", result.Status, StatusTimeout)
+	}
+	if result.ExitCode != -1 {
+		t.Errorf("ExitCode = %d, want -1", result.ExitCode)
+	}
+	// Without killing the tree, Wait blocks until ping exits and closes the stderr pipe
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Execute took %v; the child process was not killed", elapsed)
+	}
+}
+
+func TestExecuteWindowsExitCode(t *testing.T) {
+	tmpDir := t.TempDir()
+	config := &Config{
+		Command:    "cmd",
+		Args:       []string{"/c", "exit 3"},
+		InputFile:  "NUL",
+		OutputFile: filepath.Join(tmpDir, "output.txt"),
+		StderrFile: filepath.Join(tmpDir, "stderr.txt"),
+	}
+
+	result, err := Execute(config)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.ExitCode != 3 || result.Status != StatusFailed {
+		t.Errorf("Got exit code %d and status %s, want 3 and %s", result.ExitCode, result.Status, StatusFailed)
+	}
+}
+
+func TestProcessTreeAttachResumesSuspendedProcess(t *testing.T) {
+	cmd := exec.Command("cmd", "/c", "exit 3")
+	tree := newProcessTree(cmd)
+	defer tree.release()
+
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	tree.attach(cmd.Process)
+	if tree.job == 0 {
+		t.Error("process was not assigned to a job object")
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case <-done:
+		if code := cmd.ProcessState.ExitCode(); code != 3 {
+			t.Errorf("ExitCode = %d, want 3", code)
+		}
+	case <-time.After(10 * time.Second):
+		_ = tree.kill()
+		t.Fatal("process was left suspended")
+	}
+}
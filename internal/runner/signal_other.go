@@ -0,0 +1,18 @@
+//go:build !linux && !darwin
+
+package runner
+
+import (
+	"errors"
+	"os"
+)
+
+// exitSignal is not supported on this platform
+func exitSignal(state *os.ProcessState) (string, bool) {
+	return "", false
+}
+
+// terminate is not supported on this platform; only the hard timeout applies
+func terminate(process *os.Process) error {
+	return errors.ErrUnsupported
+}
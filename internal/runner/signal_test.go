@@ -0,0 +1,115 @@
+//go:build linux || darwin
+
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExecuteSignal(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		wantStatus   Status
+		wantExitCode int
+		wantSignal   string
+	}{
+		{name: "normal exit", script: "exit 3", wantStatus: StatusFailed, wantExitCode: 3},
+		{name: "segmentation fault", script: "ulimit -c 0; kill -SEGV $$", wantStatus: StatusFailed, wantExitCode: -1, wantSignal: "SIGSEGV"},
+		{name: "floating point exception", script: "ulimit -c 0; kill -FPE $$", wantStatus: StatusFailed, wantExitCode: -1, wantSignal: "SIGFPE"},
+		{name: "terminated", script: "kill -TERM $$", wantStatus: StatusFailed, wantExitCode: -1, wantSignal: "SIGTERM"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			result, err := Execute(&Config{
+				Command:    "sh",
+				Args:       []string{"-c", tt.script},
+				InputFile:  createTempFile(t, tmpDir, "input.txt", ""),
+				OutputFile: filepath.Join(tmpDir, "output.txt"),
+				StderrFile: filepath.Join(tmpDir, "stderr.txt"),
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Status != tt.wantStatus || result.ExitCode != tt.wantExitCode {
+				t.Errorf("status = %s (exit code %d), want %s (exit code %d)", result.Status, result.ExitCode, tt.wantStatus, tt.wantExitCode)
+			}
+			if result.Signal != tt.wantSignal {
+				t.Errorf("signal = %q, want %q", result.Signal, tt.wantSignal)
+			}
+			// Core dumps are disabled, so no signal may report one
+			if result.CoreDumped {
+				t.Error("core_dumped = true with core dumps disabled")
+			}
+		})
+	}
+}
+
+func TestExecuteSignalTimeout(t *testing.T) {
+	tmpDir := t.TempDir()
+	result, err := Execute(&Config{
+		Command:    "sleep",
+		Args:       []string{"5"},
+		InputFile:  createTempFile(t, tmpDir, "input.txt", ""),
+		OutputFile: filepath.Join(tmpDir, "output.txt"),
+		StderrFile: filepath.Join(tmpDir, "stderr.txt"),
+		Timeout:    50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The kill on timeout is ghost's own, not a crash of the command
+	if result.Status != StatusTimeout || result.Signal != "" {
+		t.Errorf("status = %s, signal = %q, want timeout without a signal", result.Status, result.Signal)
+	}
+}
+
+func TestExecuteSoftTimeout(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       string
+		softTimeout  time.Duration
+		wantStatus   Status
+		wantDeadline string
+		wantOutput   string
+	}{
+		{name: "exits on SIGTERM", script: "trap 'echo partial; exit 0' TERM; sleep 5 & wait", softTimeout: 100 * time.Millisecond, wantStatus: StatusTimeout, wantDeadline: DeadlineSoft, wantOutput: "partial\n"},
+		{name: "ignores SIGTERM", script: "trap '' TERM; echo started; sleep 5 & wait", softTimeout: 100 * time.Millisecond, wantStatus: StatusTimeout, wantDeadline: DeadlineHard, wantOutput: "started\n"},
+		{name: "finishes in time", script: "echo done", softTimeout: 100 * time.Millisecond, wantStatus: StatusSuccess, wantOutput: "done\n"},
+		{name: "without soft timeout", script: "sleep 5", wantStatus: StatusTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			outputFile := filepath.Join(tmpDir, "output.txt")
+			result, err := Execute(&Config{
+				Command:     "sh",
+				Args:        []string{"-c", tt.script},
+				InputFile:   createTempFile(t, tmpDir, "input.txt", ""),
+				OutputFile:  outputFile,
+				StderrFile:  filepath.Join(tmpDir, "stderr.txt"),
+				Timeout:     500 * time.Millisecond,
+				SoftTimeout: tt.softTimeout,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Status != tt.wantStatus || result.Deadline != tt.wantDeadline {
+				t.Errorf("status = %s, deadline = %q, want %s, %q", result.Status, result.Deadline, tt.wantStatus, tt.wantDeadline)
+			}
+			got, err := os.ReadFile(outputFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.wantOutput {
+				t.Errorf("output = %q, want %q", got, tt.wantOutput)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+//go:build linux || darwin
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// exitSignal returns the name of the signal that killed the process ("" if it
+// exited normally) and whether it dumped core
+func exitSignal(state *os.ProcessState) (string, bool) {
+	if state == nil {
+		return "", false
+	}
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return "", false
+	}
+	sig := status.Signal()
+	name := unix.SignalName(sig)
+	if name == "" {
+		name = fmt.Sprintf("signal %d", int(sig))
+	}
+	return name, status.CoreDump()
+}
+
+// terminate asks a process to exit with SIGTERM
+func terminate(process *os.Process) error {
+	if process == nil {
+		return os.ErrProcessDone
+	}
+	return process.Signal(syscall.SIGTERM)
+}
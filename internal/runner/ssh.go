@@ -0,0 +1,204 @@
+package runner
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sshExitError is the exit code ssh reports for connection and authentication failures
+const sshExitError = 255
+
+// remoteExitWrapper runs the command and records its exit status in the file
+// named by $1 when ssh's own exit code would be ambiguous: 255 is also a failed
+// connection, 124 and 137 are also the remote timeout. The status is only
+// recorded if the command finished by itself; a timeout kills the wrapper too.
+const remoteExitWrapper = `f=$1; shift; "$@"; s=$?; case $s in 124|137|255) echo $s > "$f";; esac; exit $s`
+
+// Exit codes of coreutils timeout when the command timed out, after TERM or KILL
+const (
+	remoteTimeoutExit = 124
+	remoteKilledExit  = 128 + 9
+)
+
+// SSHGracePeriod is how long past the timeout ghost waits for ssh before killing it
+// locally, in case the connection hangs after the remote side stopped the command
+var SSHGracePeriod = 10 * time.Second
+
+// SSHExecutor runs commands on a remote host through the ssh client
+// The ssh client's configuration (~/.ssh/config, keys, agent) is used as is and
+// must allow non-interactive login. The input file is streamed to the remote
+// command's stdin and its stdout and stderr are captured into the local files.
+// The timeout is enforced on the remote side with coreutils timeout and
+// --max-forks with ulimit, so both must be available on the remote host.
+// Exit codes that ssh or timeout could also have caused are checked against the
+// status the command recorded, over a second connection.
+type SSHExecutor struct {
+	Binary string // ssh client to invoke
+	Host   string // [user@]host or an ssh config alias
+	Dir    string // remote working directory ("" = login directory)
+}
+
+// newSSHExecutor creates an SSH executor from the host and dir options
+func newSSHExecutor(options ExecutorOptions) (Executor, error) {
+	host := options["host"]
+	if host == "" {
+		return nil, fmt.Errorf("the ssh executor requires a host (--ssh-host)")
+	}
+	if strings.HasPrefix(host, "-") {
+		return nil, fmt.Errorf("invalid ssh host %q", host)
+	}
+	return &SSHExecutor{Binary: "ssh", Host: host, Dir: options["dir"]}, nil
+}
+
+// Name returns the executor name
+func (s *SSHExecutor) Name() string {
+	return "ssh"
+}
+
+// String describes the executor with its host
+func (s *SSHExecutor) String() string {
+	return fmt.Sprintf("ssh (%s)", s.Host)
+}
+
+// Run executes the command on the remote host and waits for it to finish
+func (s *SSHExecutor) Run(config *Config, verbose bool) (*Execution, error) {
+	statusFile, err := remoteStatusFile()
+	if err != nil {
+		return nil, err
+	}
+
+	// The ssh client runs locally; it is only killed if it outlives the
+	// remote timeout by the grace period
+	client := *config
+	client.Command = s.Binary
+	client.Args = s.sshArgs(s.remoteCommand(config, statusFile))
+	client.MaxForks = 0
+	client.Priority = nil
+	client.SoftTimeout = 0
+	client.Executor = nil
+	if config.Timeout > 0 {
+		client.Timeout = config.Timeout + SSHGracePeriod
+	}
+
+	execution, err := LocalExecutor{}.Run(&client, verbose)
+	if err != nil {
+		return nil, err
+	}
+	// Memory sampling measured the ssh client, not the remote command
+	execution.MaxRSSKB = 0
+
+	switch execution.ExitCode {
+	case sshExitError, remoteTimeoutExit, remoteKilledExit:
+		if execution.TimedOut {
+			break
+		}
+		// Ask the remote host whether the command itself exited with this code
+		status, exited, err := s.remoteStatus(statusFile)
+		switch {
+		case err != nil || (!exited && execution.ExitCode == sshExitError):
+			return nil, fmt.Errorf("failed to run command on %s (ssh exited with code %d, see the stderr file)", s.Host, sshExitError)
+		case exited:
+			execution.ExitCode = status
+		case config.Timeout > 0 && execution.ExitCode == remoteTimeoutExit:
+			execution.TimedOut, execution.Deadline = true, DeadlineHard
+			if config.SoftTimeout > 0 {
+				// The command exited after TERM, before the hard timeout
+				execution.Deadline = DeadlineSoft
+			}
+		case config.Timeout > 0:
+			execution.TimedOut, execution.Deadline = true, DeadlineHard
+		}
+	}
+	return execution, nil
+}
+
+// sshArgs returns the ssh client arguments running command on the host
+func (s *SSHExecutor) sshArgs(command string) []string {
+	return []string{"-o", "BatchMode=yes", "-T", "--", s.Host, command}
+}
+
+// remoteStatus reads and removes the exit status recorded by remoteExitWrapper
+// exited is false if the command did not finish by itself; err is set if the
+// host cannot be reached.
+func (s *SSHExecutor) remoteStatus(statusFile string) (status int, exited bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), SSHGracePeriod)
+	defer cancel()
+	command := "f=" + statusFile + `; cat "$f" && rm -f "$f"`
+	out, err := exec.CommandContext(ctx, s.Binary, s.sshArgs(command)...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() != sshExitError {
+		// No status was recorded
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read exit status from %s: %w", s.Host, err)
+	}
+	status, err = strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid exit status from %s: %q", s.Host, out)
+	}
+	return status, true, nil
+}
+
+// remoteStatusFile returns a unique remote path for the exit status, expanded
+// by the remote shell
+func remoteStatusFile() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate exit status file name: %w", err)
+	}
+	return `"${TMPDIR:-/tmp}/ghost-` + hex.EncodeToString(b) + `.exit"`, nil
+}
+
+// remoteCommand builds the shell command line run on the remote host, which
+// records ambiguous exit statuses in statusFile
+func (s *SSHExecutor) remoteCommand(config *Config, statusFile string) string {
+	var parts []string
+	if s.Dir != "" {
+		parts = append(parts, "cd "+shellQuote(s.Dir)+" || exit 127;")
+	}
+	if config.MaxForks > 0 {
+		// bash and zsh call the process limit -u, dash -p
+		n := strconv.Itoa(config.MaxForks)
+		parts = append(parts, "{ ulimit -u "+n+" || ulimit -p "+n+"; } 2>/dev/null;")
+	}
+	parts = append(parts, "exec")
+	if len(config.Env) > 0 {
+		parts = append(parts, "env")
+		for _, kv := range config.Env {
+			parts = append(parts, shellQuote(kv))
+		}
+	}
+	if config.SoftTimeout > 0 {
+		// TERM at the soft timeout, KILL at the timeout if the command ignores it
+		grace := strconv.FormatFloat((config.Timeout - config.SoftTimeout).Seconds(), 'f', -1, 64)
+		seconds := strconv.FormatFloat(config.SoftTimeout.Seconds(), 'f', -1, 64)
+		parts = append(parts, "timeout", "-k", grace, seconds)
+	} else if config.Timeout > 0 {
+		// TERM at the timeout, KILL a second later if the command ignores it
+		seconds := strconv.FormatFloat(config.Timeout.Seconds(), 'f', -1, 64)
+		parts = append(parts, "timeout", "-k", "1", seconds)
+	}
+	if config.Priority != nil {
+		parts = append(parts, config.Priority.command()...)
+	}
+	parts = append(parts, "sh", "-c", shellQuote(remoteExitWrapper), "sh", statusFile, shellQuote(config.Command))
+	for _, arg := range config.Args {
+		parts = append(parts, shellQuote(arg))
+	}
+	return strings.Join(parts, " ")
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func init() {
+	RegisterExecutor("ssh", newSSHExecutor)
+}
@@ -0,0 +1,220 @@
+//go:build linux || darwin
+
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSSH emulates the ssh client by running the remote command locally
+const fakeSSH = `#!/bin/sh
+echo "$@" > "$FAKE_SSH_DIR/args"
+while [ "$1" != "--" ]; do shift; done
+shift 2 # -- host
+if [ -n "$FAKE_SSH_FAIL" ]; then
+	echo "ssh: connect to host grader-worker-3 port 22: Connection refused" >&2
+	exit 255
+fi
+exec sh -c "$1"
+`
+
+func newFakeSSH(t *testing.T) (*SSHExecutor, string) {
+	t.Helper()
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "ssh")
+	if err := os.WriteFile(binary, []byte(fakeSSH), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FAKE_SSH_DIR", dir)
+	return &SSHExecutor{Binary: binary, Host: "grader-worker-3"}, dir
+}
+
+func TestNewSSHExecutor(t *testing.T) {
+	tests := []struct {
+		options ExecutorOptions
+		wantErr string
+	}{
+		{options: ExecutorOptions{"host": "grader@worker-3", "dir": "/srv/grading"}},
+		{options: nil, wantErr: "requires a host (--ssh-host)"},
+		{options: ExecutorOptions{"host": "-oProxyCommand=evil"}, wantErr: "invalid ssh host"},
+	}
+
+	for _, tt := range tests {
+		executor, err := NewExecutor("ssh", tt.options)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("NewExecutor(ssh, %v) error = %v, want %q", tt.options, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("NewExecutor(ssh) error = %v", err)
+		}
+		if got := executor.(*SSHExecutor).String(); got != "ssh (grader@worker-3)" {
+			t.Errorf("String() = %q", got)
+		}
+	}
+}
+
+func TestSSHRemoteCommand(t *testing.T) {
+	executor := &SSHExecutor{Host: "worker", Dir: "/srv/it's here"}
+	wrapper := "sh -c " + shellQuote(remoteExitWrapper) + " sh $F"
+	got := executor.remoteCommand(&Config{
+		Command:  "./solution",
+		Args:     []string{"a b", "$HOME"},
+		Timeout:  1500 * time.Millisecond,
+		MaxForks: 16,
+	}, "$F")
+	want := `cd '/srv/it'\''s here' || exit 127; { ulimit -u 16 || ulimit -p 16; } 2>/dev/null; exec timeout -k 1 1.5 ` + wrapper + ` './solution' 'a b' '$HOME'`
+	if got != want {
+		t.Errorf("remoteCommand() =\n%s\nwant\n%s", got, want)
+	}
+
+	executor.Dir = ""
+	got = executor.remoteCommand(&Config{Command: "./solution", Timeout: 10 * time.Second, SoftTimeout: 8 * time.Second}, "$F")
+	if want := `exec timeout -k 2 8 ` + wrapper + ` './solution'`; got != want {
+		t.Errorf("remoteCommand() with soft timeout = %q, want %q", got, want)
+	}
+	if got := executor.remoteCommand(&Config{Command: "echo"}, "$F"); got != "exec "+wrapper+" 'echo'" {
+		t.Errorf("remoteCommand() = %q", got)
+	}
+	if got := executor.remoteCommand(&Config{Command: "echo", Env: []string{"A=it's"}}, "$F"); got != `exec env 'A=it'\''s' `+wrapper+` 'echo'` {
+		t.Errorf("remoteCommand() = %q", got)
+	}
+	got = executor.remoteCommand(&Config{Command: "echo", Timeout: time.Second, Priority: &Priority{Nice: 10, IOClass: IOClassBestEffort, IOLevel: 7}}, "$F")
+	if want := `exec timeout -k 1 1 nice -n 10 ionice -c 2 -n 7 ` + wrapper + ` 'echo'`; got != want {
+		t.Errorf("remoteCommand() with priority = %q, want %q", got, want)
+	}
+}
+
+func TestSSHExecutor(t *testing.T) {
+	tests := []struct {
+		name         string
+		command      string
+		timeout      time.Duration
+		wantStatus   Status
+		wantExitCode int
+		wantOutput   string
+		wantStderr   string
+	}{
+		{
+			name:       "stdin streamed and output captured",
+			command:    "cat; echo remote error >&2",
+			wantStatus: StatusSuccess,
+			wantOutput: "hello remote\n",
+			wantStderr: "remote error\n",
+		},
+		{
+			name:         "remote exit code",
+			command:      "exit 3",
+			wantStatus:   StatusFailed,
+			wantExitCode: 3,
+		},
+		// Exit codes ssh and timeout also use are the command's own
+		{
+			name:         "remote exit code 255",
+			command:      "exit 255",
+			wantStatus:   StatusFailed,
+			wantExitCode: 255,
+		},
+		{
+			name:         "remote exit code 124 before the timeout",
+			command:      "exit 124",
+			timeout:      5 * time.Second,
+			wantStatus:   StatusFailed,
+			wantExitCode: 124,
+		},
+		{
+			name:         "remote kill before the timeout",
+			command:      "kill -KILL $$",
+			timeout:      5 * time.Second,
+			wantStatus:   StatusFailed,
+			wantExitCode: 137,
+		},
+		{
+			name:         "remote timeout",
+			command:      "sleep 5",
+			timeout:      200 * time.Millisecond,
+			wantStatus:   StatusTimeout,
+			wantExitCode: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor, sshDir := newFakeSSH(t)
+
+			dir := t.TempDir()
+			inputFile := filepath.Join(dir, "input.txt")
+			outputFile := filepath.Join(dir, "output.txt")
+			stderrFile := filepath.Join(dir, "stderr.txt")
+			_ = os.WriteFile(inputFile, []byte("hello remote\n"), 0644)
+
+			start := time.Now()
+			result, err := Execute(&Config{
+				Command:    "sh",
+				Args:       []string{"-c", tt.command},
+				InputFile:  inputFile,
+				OutputFile: outputFile,
+				StderrFile: stderrFile,
+				Timeout:    tt.timeout,
+				Executor:   executor,
+			})
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 3*time.Second {
+				t.Errorf("Execute() took %v, remote timeout was not enforced", elapsed)
+			}
+
+			if result.Status != tt.wantStatus || result.ExitCode != tt.wantExitCode {
+				t.Errorf("Result = %s/%d, want %s/%d", result.Status, result.ExitCode, tt.wantStatus, tt.wantExitCode)
+			}
+			if tt.wantOutput != "" {
+				if data, _ := os.ReadFile(outputFile); string(data) != tt.wantOutput {
+					t.Errorf("Output = %q, want %q", data, tt.wantOutput)
+				}
+				if data, _ := os.ReadFile(stderrFile); string(data) != tt.wantStderr {
+					t.Errorf("Stderr = %q, want %q", data, tt.wantStderr)
+				}
+			}
+
+			args, _ := os.ReadFile(filepath.Join(sshDir, "args"))
+			if !strings.HasPrefix(string(args), "-o BatchMode=yes -T -- grader-worker-3 ") {
+				t.Errorf("ssh args = %q", args)
+			}
+		})
+	}
+}
+
+func TestSSHExecutorConnectionFailure(t *testing.T) {
+	executor, _ := newFakeSSH(t)
+	t.Setenv("FAKE_SSH_FAIL", "1")
+
+	dir := t.TempDir()
+	stderrFile := filepath.Join(dir, "stderr.txt")
+	_, err := Execute(&Config{
+		Command:    "true",
+		InputFile:  "/dev/null",
+		OutputFile: filepath.Join(dir, "output.txt"),
+		StderrFile: stderrFile,
+		Executor:   executor,
+	})
+	if err == nil || !strings.Contains(err.Error(), "failed to run command on grader-worker-3 (ssh exited with code 255") {
+		t.Errorf("Execute() error = %v", err)
+	}
+	if data, _ := os.ReadFile(stderrFile); !strings.Contains(string(data), "Connection refused") {
+		t.Errorf("Stderr = %q, want the ssh error", data)
+	}
+}
+
+func TestSSHExecutorRejectsResourceLimits(t *testing.T) {
+	err := ValidateResourceLimits(&SSHExecutor{Host: "worker"}, 1<<30, 0)
+	if err == nil || !strings.Contains(err.Error(), "not enforced by the ssh executor") {
+		t.Errorf("ValidateResourceLimits() error = %v", err)
+	}
+}
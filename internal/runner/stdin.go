@@ -0,0 +1,114 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"slices"
+	"time"
+)
+
+// stdinPollInterval is how often a command is checked for blocking on stdin
+var stdinPollInterval = 50 * time.Millisecond
+
+// ValidateStdinTimeout checks that the executor can detect a command blocked on stdin
+func ValidateStdinTimeout(executor Executor, timeout time.Duration) error {
+	if timeout < 0 {
+		return fmt.Errorf("invalid stdin timeout %s: must be 0 (off) or positive", timeout)
+	}
+	if timeout == 0 {
+		return nil
+	}
+	if _, ok := executor.(stdinWatcher); !ok {
+		return fmt.Errorf("--stdin-timeout is not supported by the %s executor", executor.Name())
+	}
+	if !stdinWatchSupported {
+		return fmt.Errorf("--stdin-timeout is not supported on %s", runtime.GOOS)
+	}
+	return nil
+}
+
+// ValidateStdinInput checks that a command can stall on the input file
+// A regular file ends with EOF, so a command reading it never blocks on it.
+func ValidateStdinInput(input string, timeout time.Duration) error {
+	if timeout == 0 {
+		return nil
+	}
+	if info, err := os.Stat(input); err == nil && info.Mode().IsRegular() {
+		return fmt.Errorf("--stdin-timeout requires a pipe, FIFO or terminal as input, not the regular file %s", input)
+	}
+	return nil
+}
+
+// stdinWatcher is implemented by executors whose command reads the input file
+// as a child process of ghost
+type stdinWatcher interface {
+	watchesStdin()
+}
+
+// watchesStdin marks StdinTimeout as detected for the local child process
+func (LocalExecutor) watchesStdin() {}
+
+// stdinWatch kills a command that stays blocked reading its exhausted input
+type stdinWatch struct {
+	stopCh chan struct{}
+	done   chan struct{}
+
+	// stalled is written by the watch goroutine before done is closed
+	stalled bool
+}
+
+// startStdinWatch polls the process with the given pid and its descendants
+// and, once one of them has been blocked reading the input for the timeout,
+// calls kill and kills the descendants (nil if no timeout is set)
+// A regular input file ends with EOF, so this only fires when the input is a
+// pipe, FIFO or terminal that stays open without data.
+func startStdinWatch(pid int, input os.FileInfo, timeout time.Duration, kill func() error) *stdinWatch {
+	if timeout <= 0 {
+		return nil
+	}
+	w := &stdinWatch{stopCh: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(stdinPollInterval)
+		defer ticker.Stop()
+
+		var blockedSince time.Time
+		for {
+			select {
+			case <-ticker.C:
+			case <-w.stopCh:
+				return
+			}
+			tree := append([]int{pid}, descendants(pid)...)
+			if !slices.ContainsFunc(tree, func(pid int) bool { return blockedOnStdin(pid, input) }) {
+				blockedSince = time.Time{}
+				continue
+			}
+			if blockedSince.IsZero() {
+				blockedSince = time.Now()
+			}
+			if time.Since(blockedSince) >= timeout {
+				// A descendant blocked on stdin would keep the output open
+				w.stalled = kill() == nil
+				for _, child := range tree[1:] {
+					if process, err := os.FindProcess(child); err == nil {
+						_ = process.Kill()
+					}
+				}
+				return
+			}
+		}
+	}()
+	return w
+}
+
+// stop ends polling and reports whether the watch killed the command
+func (w *stdinWatch) stop() bool {
+	if w == nil {
+		return false
+	}
+	close(w.stopCh)
+	<-w.done
+	return w.stalled
+}
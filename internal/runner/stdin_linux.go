@@ -0,0 +1,46 @@
+//go:build linux
+
+package runner
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+const stdinWatchSupported = true
+
+// descendants lists the children of the process and theirs from /proc
+func descendants(pid int) []int {
+	children, err := os.ReadFile(fmt.Sprintf("/proc/%d/task/%d/children", pid, pid))
+	if err != nil {
+		return nil
+	}
+	var pids []int
+	for _, field := range strings.Fields(string(children)) {
+		if child, err := strconv.Atoi(field); err == nil {
+			pids = append(append(pids, child), descendants(child)...)
+		}
+	}
+	return pids
+}
+
+// blockedOnStdin reports whether the process is blocked in read(2) on a stdin
+// that is the input file, from the system call in /proc/<pid>/syscall (which
+// holds "running" while it runs)
+// A process whose stdin was redirected elsewhere is not waiting for the input.
+func blockedOnStdin(pid int, input os.FileInfo) bool {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/syscall", pid))
+	if err != nil {
+		return false
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 2 || fields[0] != strconv.Itoa(unix.SYS_READ) || fields[1] != "0x0" {
+		return false
+	}
+	stdin, err := os.Stat(fmt.Sprintf("/proc/%d/fd/0", pid))
+	return err == nil && os.SameFile(stdin, input)
+}
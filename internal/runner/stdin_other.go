@@ -0,0 +1,17 @@
+//go:build !linux
+
+package runner
+
+import "os"
+
+const stdinWatchSupported = false
+
+// descendants is not supported on this platform
+func descendants(pid int) []int {
+	return nil
+}
+
+// blockedOnStdin is not supported on this platform
+func blockedOnStdin(pid int, input os.FileInfo) bool {
+	return false
+}
@@ -0,0 +1,106 @@
+//go:build linux
+
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// openFIFO creates a FIFO holding data that stays open for writing until the test ends
+func openFIFO(t *testing.T, dir, data string) string {
+	t.Helper()
+	path := filepath.Join(dir, "input.fifo")
+	if err := syscall.Mkfifo(path, 0600); err != nil {
+		t.Fatalf("Mkfifo() error = %v", err)
+	}
+	// Opening for reading and writing does not wait for a reader
+	writer, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("Failed to open FIFO: %v", err)
+	}
+	t.Cleanup(func() { _ = writer.Close() })
+	if _, err := writer.WriteString(data); err != nil {
+		t.Fatalf("Failed to write FIFO: %v", err)
+	}
+	return path
+}
+
+func TestExecuteStdinTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		script     string
+		fifo       bool
+		wantStatus Status
+		wantExit   int
+		wantOutput string
+	}{
+		{name: "waits for more input", script: `read a; echo "$a"; read b`, fifo: true, wantStatus: StatusStalled, wantExit: -1, wantOutput: "1\n"},
+		{name: "descendant waits for more input", script: `cat; echo done`, fifo: true, wantStatus: StatusStalled, wantExit: -1, wantOutput: "1\n"},
+		{name: "reads only what it needs", script: `read a; echo "$a"`, fifo: true, wantStatus: StatusSuccess, wantOutput: "1\n"},
+		{name: "busy after reading", script: `read a; sleep 0.5; echo "$a"`, fifo: true, wantStatus: StatusSuccess, wantOutput: "1\n"},
+		{name: "regular file ends with EOF", script: `cat; echo done`, wantStatus: StatusSuccess, wantOutput: "1\ndone\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			input := createTempFile(t, tmpDir, "input.txt", "1\n")
+			if tt.fifo {
+				input = openFIFO(t, tmpDir, "1\n")
+			}
+			outputFile := filepath.Join(tmpDir, "output.txt")
+			config := &Config{
+				Command:      "sh",
+				Args:         []string{"-c", tt.script},
+				InputFile:    input,
+				OutputFile:   outputFile,
+				StderrFile:   filepath.Join(tmpDir, "stderr.txt"),
+				Timeout:      5 * time.Second,
+				StdinTimeout: 200 * time.Millisecond,
+			}
+
+			result, err := Execute(config)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if result.Status != tt.wantStatus || result.ExitCode != tt.wantExit {
+				t.Errorf("Status = %s (exit code %d), want %s (exit code %d)", result.Status, result.ExitCode, tt.wantStatus, tt.wantExit)
+			}
+			if result.ExecutionTime >= 2000 {
+				t.Errorf("ExecutionTime = %dms, want the stall detected well before the timeout", result.ExecutionTime)
+			}
+			if output, _ := os.ReadFile(outputFile); string(output) != tt.wantOutput {
+				t.Errorf("Output = %q, want %q", output, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestValidateStdinTimeout(t *testing.T) {
+	docker, err := NewExecutor("docker", ExecutorOptions{"image": "alpine"})
+	if err != nil {
+		t.Fatalf("NewExecutor() error = %v", err)
+	}
+	tests := []struct {
+		name     string
+		executor Executor
+		timeout  time.Duration
+		wantErr  bool
+	}{
+		{name: "off", executor: docker},
+		{name: "local", executor: LocalExecutor{}, timeout: time.Second},
+		{name: "negative", executor: LocalExecutor{}, timeout: -time.Second, wantErr: true},
+		{name: "docker", executor: docker, timeout: time.Second, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateStdinTimeout(tt.executor, tt.timeout); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateStdinTimeout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
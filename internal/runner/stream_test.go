@@ -0,0 +1,117 @@
+//go:build linux || darwin
+
+package runner
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestIsStreamTarget(t *testing.T) {
+	tmpDir := t.TempDir()
+	fifo := filepath.Join(tmpDir, "fifo")
+	if err := syscall.Mkfifo(fifo, 0644); err != nil {
+		t.Fatalf("Failed to create FIFO: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"-", true},
+		{fifo, true},
+		{"/dev/null", true},
+		{createTempFile(t, tmpDir, "regular.txt", ""), false},
+		{filepath.Join(tmpDir, "missing.txt"), false},
+		{tmpDir, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := IsStreamTarget(tt.path); got != tt.want {
+				t.Errorf("IsStreamTarget(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteToFIFO(t *testing.T) {
+	tmpDir := t.TempDir()
+	fifo := filepath.Join(tmpDir, "output.fifo")
+	if err := syscall.Mkfifo(fifo, 0644); err != nil {
+		t.Fatalf("Failed to create FIFO: %v", err)
+	}
+
+	// Read the FIFO concurrently like a streaming consumer
+	received := make(chan string, 1)
+	go func() {
+		reader, err := os.Open(fifo)
+		if err != nil {
+			received <- ""
+			return
+		}
+		defer func() { _ = reader.Close() }()
+		data, _ := io.ReadAll(reader)
+		received <- string(data)
+	}()
+
+	config := &Config{
+		Command:    "echo",
+		Args:       []string{"streamed"},
+		InputFile:  createTempFile(t, tmpDir, "input.txt", ""),
+		OutputFile: fifo,
+		StderrFile: filepath.Join(tmpDir, "stderr.txt"),
+	}
+
+	result, err := Execute(config)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result.Status != StatusSuccess {
+		t.Errorf("Status = %s, want %s", result.Status, StatusSuccess)
+	}
+	if got := <-received; got != "streamed\n" {
+		t.Errorf("FIFO received %q, want %q", got, "streamed\n")
+	}
+
+	// The FIFO must not have been replaced by a regular file
+	if info, err := os.Stat(fifo); err != nil || info.Mode()&os.ModeNamedPipe == 0 {
+		t.Errorf("Expected %s to remain a FIFO", fifo)
+	}
+}
+
+func TestExecuteToStdStreams(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Redirect ghost's own stdout to observe "-"
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	defer func() { os.Stdout = oldStdout }()
+
+	config := &Config{
+		Command:    "echo",
+		Args:       []string{"to stdout"},
+		InputFile:  createTempFile(t, tmpDir, "input.txt", ""),
+		OutputFile: "-",
+		StderrFile: filepath.Join(tmpDir, "stderr.txt"),
+	}
+
+	_, err := Execute(config)
+	_ = w.Close()
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	data, _ := io.ReadAll(r)
+	if string(data) != "to stdout\n" {
+		t.Errorf("Stdout received %q, want %q", data, "to stdout\n")
+	}
+	if _, err := os.Stat("-"); !os.IsNotExist(err) {
+		t.Error("A file named \"-\" should not be created")
+	}
+}
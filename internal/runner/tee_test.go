@@ -0,0 +1,129 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExecuteTeeOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		tee        string
+		outputFile string // empty means a file in the temp dir
+		wantStdout string
+		wantStderr string
+	}{
+		{name: "off", tee: ""},
+		{name: "stdout", tee: TeeStdout, wantStdout: "hello\n"},
+		{name: "stderr", tee: TeeStderr, wantStderr: "hello\n"},
+		// Output already goes to stdout, so it must not be duplicated
+		{name: "stdout to stream", tee: TeeStdout, outputFile: "-", wantStdout: "hello\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			outputFile := tt.outputFile
+			if outputFile == "" {
+				outputFile = filepath.Join(tmpDir, "output.txt")
+			}
+
+			oldStdout, oldStderr := os.Stdout, os.Stderr
+			outR, outW, _ := os.Pipe()
+			errR, errW, _ := os.Pipe()
+			os.Stdout, os.Stderr = outW, errW
+			defer func() { os.Stdout, os.Stderr = oldStdout, oldStderr }()
+
+			config := &Config{
+				Command:    "echo",
+				Args:       []string{"hello"},
+				InputFile:  createTempFile(t, tmpDir, "input.txt", ""),
+				OutputFile: outputFile,
+				StderrFile: filepath.Join(tmpDir, "stderr.txt"),
+				TeeOutput:  tt.tee,
+			}
+
+			_, err := Execute(config)
+			_ = outW.Close()
+			_ = errW.Close()
+			os.Stdout, os.Stderr = oldStdout, oldStderr
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			stdout, _ := io.ReadAll(outR)
+			stderr, _ := io.ReadAll(errR)
+			if string(stdout) != tt.wantStdout {
+				t.Errorf("Stdout = %q, want %q", stdout, tt.wantStdout)
+			}
+			if string(stderr) != tt.wantStderr {
+				t.Errorf("Stderr = %q, want %q", stderr, tt.wantStderr)
+			}
+
+			if tt.outputFile == "" {
+				data, err := os.ReadFile(outputFile)
+				if err != nil {
+					t.Fatalf("Failed to read output file: %v", err)
+				}
+				if string(data) != "hello\n" {
+					t.Errorf("Output file = %q, want %q", data, "hello\n")
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteStreamOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "command", config: Config{Command: "sh", Args: []string{"-c", "echo DEBUG x; echo hello"}}},
+		{name: "pipeline", config: Config{Pipeline: []Step{
+			{Command: "sh", Args: []string{"-c", "echo DEBUG x; echo hello"}},
+			{Command: "cat"},
+		}}},
+		{name: "builtin", config: Config{Command: "builtin", Builtin: func(ctx context.Context, stdout, stderr io.Writer) int {
+			_, _ = io.WriteString(stdout, "DEBUG x\nhello\n")
+			return 0
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			var stream bytes.Buffer
+			config := tt.config
+			config.InputFile = "/dev/null"
+			config.OutputFile = filepath.Join(tmpDir, "output.txt")
+			config.StderrFile = filepath.Join(tmpDir, "stderr.txt")
+			config.StdoutFilter = mustRules(t, "drop:^DEBUG")
+			config.StreamOutput = &stream
+
+			if _, err := Execute(&config); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			// The stream receives the filtered output, as written to the file
+			data, _ := os.ReadFile(config.OutputFile)
+			if string(data) != "hello\n" || stream.String() != string(data) {
+				t.Errorf("Stream = %q, output file = %q, want both %q", stream.String(), data, "hello\n")
+			}
+		})
+	}
+}
+
+func TestValidateTeeOutput(t *testing.T) {
+	for _, target := range []string{"", TeeStdout, TeeStderr} {
+		if err := ValidateTeeOutput(target); err != nil {
+			t.Errorf("ValidateTeeOutput(%q) error = %v", target, err)
+		}
+	}
+	if err := ValidateTeeOutput("stdin"); err == nil {
+		t.Error("Expected error for invalid tee target")
+	}
+}
@@ -0,0 +1,192 @@
+// Package schema generates JSON Schemas of the payloads ghost writes and sends
+// to webhooks, so consumers can generate clients and validate what they receive
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+// Formats the schema of a payload can be written in
+const (
+	FormatJSONSchema = "json-schema"
+	FormatOpenAPI    = "openapi"
+)
+
+// Formats lists the supported formats
+var Formats = []string{FormatJSONSchema, FormatOpenAPI}
+
+// Draft is the JSON Schema dialect of the generated schemas
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Payload is a JSON document a schema can be generated for
+type Payload struct {
+	Type        reflect.Type
+	Description string
+}
+
+// Payloads holds the payloads by the name given to ghost schema
+var Payloads = map[string]Payload{
+	"result": {
+		Type:        reflect.TypeOf(output.Result{}),
+		Description: "Result of run, diff, judge, pipeline and check, written to stdout and sent as the completed webhook",
+	},
+	"event": {
+		Type:        reflect.TypeOf(output.Event{}),
+		Description: "Lifecycle webhook event sent before the final result (--webhook-events)",
+	},
+	"summary": {
+		Type:        reflect.TypeOf(output.ScoreSummary{}),
+		Description: "Summary of score aggregate, written to stdout and sent to its webhook",
+	},
+}
+
+// Names returns the payload names in sorted order
+func Names() []string {
+	names := make([]string, 0, len(Payloads))
+	for name := range Payloads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Generate returns the schema document of a payload in the given format
+// version selects the schema version of results (see output.SchemaVersions).
+func Generate(name, format, version string) (map[string]any, error) {
+	payload, ok := Payloads[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown payload %q (must be one of %s)", name, strings.Join(Names(), ", "))
+	}
+	if !slices.Contains(output.SchemaVersions, version) {
+		return nil, fmt.Errorf("unknown schema version %q (must be one of %s)", version, strings.Join(output.SchemaVersions, ", "))
+	}
+
+	switch format {
+	case FormatJSONSchema:
+		g := &generator{refPrefix: "#/$defs/", version: version, defs: make(map[string]any)}
+		root := g.schemaOf(payload.Type)
+		return map[string]any{
+			"$schema":     Draft,
+			"$id":         "urn:ghost:schema:" + name + ":" + version,
+			"title":       payload.Type.Name(),
+			"description": payload.Description,
+			"$ref":        root["$ref"],
+			"$defs":       g.defs,
+		}, nil
+	case FormatOpenAPI:
+		g := &generator{refPrefix: "#/components/schemas/", version: version, defs: make(map[string]any)}
+		g.schemaOf(payload.Type)
+		return map[string]any{
+			"openapi": "3.1.0",
+			"info": map[string]any{
+				"title":       "ghost " + name + " payload",
+				"description": payload.Description,
+				"version":     version,
+			},
+			"components": map[string]any{"schemas": g.defs},
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q (must be one of %s)", format, strings.Join(Formats, ", "))
+}
+
+var decimalType = reflect.TypeOf(decimal.Decimal{})
+
+// generator builds the schemas of Go types as encoding/json marshals them
+// Every struct becomes a named definition, referenced as refPrefix + name.
+// Fields that are not part of the schema version are left out.
+type generator struct {
+	refPrefix string
+	version   string
+	defs      map[string]any
+}
+
+func (g *generator) schemaOf(t reflect.Type) map[string]any {
+	// Decimals are marshaled as strings to keep their precision
+	if t == decimalType {
+		return map[string]any{"type": "string", "pattern": `^-?[0-9]+(\.[0-9]+)?$`}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Pointer:
+		return g.schemaOf(t.Elem())
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		name := t.Name()
+		if _, ok := g.defs[name]; !ok {
+			g.defs[name] = nil // placeholder for recursive types
+			g.defs[name] = g.structSchema(t)
+		}
+		return map[string]any{"$ref": g.refPrefix + name}
+	}
+	// Interfaces such as the user-provided context can hold any value
+	return map[string]any{}
+}
+
+func (g *generator) structSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || !output.InSchema(field, g.version) {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := g.schemaOf(field.Type)
+		omitempty := strings.Contains(opts, "omitempty")
+		if !omitempty && nullable(field.Type) {
+			property = map[string]any{"anyOf": []any{property, map[string]any{"type": "null"}}}
+		}
+		if name == "schema_version" {
+			property = map[string]any{"type": "string", "const": g.version}
+		}
+		properties[name] = property
+		if !omitempty {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// nullable reports whether encoding/json writes null for the zero value of t
+func nullable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package schema
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/zinc-sig/ghost/internal/output"
+)
+
+// refs collects every $ref in a schema document
+func refs(v any, found *[]string) {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if s, ok := value.(string); ok && key == "$ref" {
+				*found = append(*found, s)
+			}
+			refs(value, found)
+		}
+	case []any:
+		for _, value := range v {
+			refs(value, found)
+		}
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	for _, name := range Names() {
+		for _, format := range Formats {
+			t.Run(name+"/"+format, func(t *testing.T) {
+				document, err := Generate(name, format, output.SchemaVersion)
+				if err != nil {
+					t.Fatalf("Generate() error = %v", err)
+				}
+
+				// Round trip through JSON so the document is checked as it is printed
+				data, err := json.Marshal(document)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var doc map[string]any
+				_ = json.Unmarshal(data, &doc)
+
+				prefix, defs := "#/$defs/", doc["$defs"]
+				if format == FormatOpenAPI {
+					prefix, defs = "#/components/schemas/", doc["components"].(map[string]any)["schemas"]
+				}
+				var found []string
+				refs(doc, &found)
+				if len(found) == 0 {
+					t.Fatal("no $ref in document")
+				}
+				for _, ref := range found {
+					def, ok := strings.CutPrefix(ref, prefix)
+					if _, exists := defs.(map[string]any)[def]; !ok || !exists {
+						t.Errorf("unresolved $ref %q", ref)
+					}
+				}
+
+				root := defs.(map[string]any)[Payloads[name].Type.Name()].(map[string]any)
+				version := root["properties"].(map[string]any)["schema_version"].(map[string]any)
+				if version["const"] != output.SchemaVersion {
+					t.Errorf("schema_version = %v, want const %q", version, output.SchemaVersion)
+				}
+				if !slices.Contains(root["required"].([]any), any("schema_version")) {
+					t.Error("schema_version is not required")
+				}
+			})
+		}
+	}
+}
+
+func TestGenerateResultFields(t *testing.T) {
+	document, err := Generate("result", FormatJSONSchema, output.SchemaVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defs := document["$defs"].(map[string]any)
+	result := defs["Result"].(map[string]any)
+	properties := result["properties"].(map[string]any)
+
+	// Every field of a marshaled result is described
+	score := decimal.NewFromInt(10)
+	expected := "expected.txt"
+	data, _ := json.Marshal(output.Result{
+		Expected: &expected,
+		Score:    &score,
+		Rubric:   &output.RubricResult{},
+		Uploads:  []output.UploadResult{{Remote: "out.txt"}},
+		Context:  map[string]any{"case": 1},
+	})
+	var fields map[string]any
+	_ = json.Unmarshal(data, &fields)
+	for field := range fields {
+		if _, ok := properties[field]; !ok {
+			t.Errorf("field %q is missing from the schema", field)
+		}
+	}
+
+	tests := []struct {
+		property string
+		want     string
+	}{
+		{"run_id", `{"type":"string"}`},
+		{"execution_time", `{"type":"integer"}`},
+		{"score", `{"pattern":"^-?[0-9]+(\\.[0-9]+)?$","type":"string"}`},
+		{"context", `{}`},
+		{"uploads", `{"items":{"$ref":"#/$defs/UploadResult"},"type":"array"}`},
+		{"argv", `{"items":{"type":"string"},"type":"array"}`},
+	}
+	for _, tt := range tests {
+		got, _ := json.Marshal(properties[tt.property])
+		if string(got) != tt.want {
+			t.Errorf("%s = %s, want %s", tt.property, got, tt.want)
+		}
+	}
+
+	required := result["required"].([]string)
+	for _, name := range []string{"run_id", "command", "status", "exit_code"} {
+		if !slices.Contains(required, name) {
+			t.Errorf("%s is not required", name)
+		}
+	}
+	if slices.Contains(required, "score") {
+		t.Error("omitempty field score is required")
+	}
+}
+
+func TestGenerateResultV1(t *testing.T) {
+	document, err := Generate("result", FormatJSONSchema, output.SchemaV1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if document["$id"] != "urn:ghost:schema:result:1" {
+		t.Errorf("$id = %v, want urn:ghost:schema:result:1", document["$id"])
+	}
+	defs := document["$defs"].(map[string]any)
+	properties := defs["Result"].(map[string]any)["properties"].(map[string]any)
+
+	for _, field := range []string{"run_id", "status", "exit_code", "score", "context", "webhook_sent"} {
+		if _, ok := properties[field]; !ok {
+			t.Errorf("v1 field %q is missing from the schema", field)
+		}
+	}
+	for _, field := range []string{"uploads", "rubric", "argv", "signal", "webhook_response"} {
+		if _, ok := properties[field]; ok {
+			t.Errorf("v2 field %q is in the v1 schema", field)
+		}
+	}
+	// Types only used by v2 fields are not defined
+	if _, ok := defs["UploadResult"]; ok {
+		t.Error("UploadResult is defined in the v1 schema")
+	}
+	if got := properties["schema_version"].(map[string]any)["const"]; got != output.SchemaV1 {
+		t.Errorf("schema_version const = %v, want %q", got, output.SchemaV1)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	if _, err := Generate("report", FormatJSONSchema, output.SchemaVersion); err == nil || !strings.Contains(err.Error(), `unknown payload "report"`) {
+		t.Errorf("Generate(report) error = %v", err)
+	}
+	if _, err := Generate("result", "protobuf", output.SchemaVersion); err == nil || !strings.Contains(err.Error(), `unknown format "protobuf"`) {
+		t.Errorf("Generate(protobuf) error = %v", err)
+	}
+	if _, err := Generate("result", FormatJSONSchema, "3"); err == nil || !strings.Contains(err.Error(), `unknown schema version "3"`) {
+		t.Errorf("Generate(version 3) error = %v", err)
+	}
+}
@@ -0,0 +1,527 @@
+package score
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/shopspring/decimal"
+)
+
+// Expr is a parsed score expression
+//
+// The expression language supports numbers, strings, booleans, variables,
+// arithmetic (+ - * / %), comparisons (== != < <= > >=), logical operators
+// (&& || !), parentheses and the ternary operator (cond ? a : b).
+type Expr struct {
+	source string
+	root   node
+}
+
+// Parse parses a score expression
+func Parse(source string) (*Expr, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid score expression: %w", err)
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseTernary()
+	if err != nil {
+		return nil, fmt.Errorf("invalid score expression: %w", err)
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("invalid score expression: unexpected %q at position %d", tok.text, tok.pos)
+	}
+
+	return &Expr{source: source, root: root}, nil
+}
+
+// String returns the original expression source
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Variables returns the names of all variables referenced by the expression
+func (e *Expr) Variables() []string {
+	seen := make(map[string]bool)
+	var names []string
+	collectVariables(e.root, seen, &names)
+	return names
+}
+
+func collectVariables(n node, seen map[string]bool, names *[]string) {
+	switch v := n.(type) {
+	case *variableNode:
+		if !seen[v.name] {
+			seen[v.name] = true
+			*names = append(*names, v.name)
+		}
+	case *unaryNode:
+		collectVariables(v.operand, seen, names)
+	case *binaryNode:
+		collectVariables(v.left, seen, names)
+		collectVariables(v.right, seen, names)
+	case *ternaryNode:
+		collectVariables(v.cond, seen, names)
+		collectVariables(v.then, seen, names)
+		collectVariables(v.otherwise, seen, names)
+	}
+}
+
+// Evaluate evaluates the expression against the given variables and returns a numeric score
+// Variable values may be numbers (int, int64, float64, decimal.Decimal), strings or booleans.
+func (e *Expr) Evaluate(vars map[string]any) (decimal.Decimal, error) {
+	val, err := e.root.eval(vars)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to evaluate score expression: %w", err)
+	}
+
+	num, ok := val.(decimal.Decimal)
+	if !ok {
+		return decimal.Zero, fmt.Errorf("score expression must evaluate to a number, got %s", typeName(val))
+	}
+	return num, nil
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// Operators ordered so that two-character operators match first
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "+", "-", "*", "/", "%", "!", "?", ":", "(", ")"}
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), pos: start})
+
+		case r == '"' || r == '\'':
+			start := i
+			quote := r
+			i++
+			var sb strings.Builder
+			for i < len(runes) && runes[i] != quote {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++ // closing quote
+			tokens = append(tokens, token{kind: tokenString, text: sb.String(), pos: start})
+
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, token{kind: tokenOp, text: op, pos: i})
+					i += len([]rune(op))
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+			}
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEOF, pos: len(runes)})
+	return tokens, nil
+}
+
+// Parser (recursive descent, lowest to highest precedence)
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) acceptOp(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokenOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expectOp(op string) error {
+	if _, ok := p.acceptOp(op); !ok {
+		tok := p.peek()
+		if tok.kind == tokenEOF {
+			return fmt.Errorf("expected %q at end of expression", op)
+		}
+		return fmt.Errorf("expected %q at position %d, got %q", op, tok.pos, tok.text)
+	}
+	return nil
+}
+
+func (p *parser) parseTernary() (node, error) {
+	cond, err := p.parseBinary(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.acceptOp("?"); !ok {
+		return cond, nil
+	}
+
+	then, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expectOp(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.parseTernary()
+	if err != nil {
+		return nil, err
+	}
+	return &ternaryNode{cond: cond, then: then, otherwise: otherwise}, nil
+}
+
+// Binary operator precedence levels, from lowest to highest
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) parseBinary(level int) (node, error) {
+	if level >= len(precedence) {
+		return p.parseUnary()
+	}
+
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOp(precedence[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if op, ok := p.acceptOp("!", "-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		num, err := decimal.NewFromString(tok.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at position %d", tok.text, tok.pos)
+		}
+		return &literalNode{value: num}, nil
+	case tokenString:
+		return &literalNode{value: tok.text}, nil
+	case tokenIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		}
+		return &variableNode{name: tok.text}, nil
+	case tokenOp:
+		if tok.text == "(" {
+			inner, err := p.parseTernary()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectOp(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		}
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	default:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+}
+
+// AST nodes
+
+type node interface {
+	eval(vars map[string]any) (any, error)
+}
+
+type literalNode struct {
+	value any
+}
+
+func (n *literalNode) eval(map[string]any) (any, error) {
+	return n.value, nil
+}
+
+type variableNode struct {
+	name string
+}
+
+func (n *variableNode) eval(vars map[string]any) (any, error) {
+	val, ok := vars[n.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown variable %q", n.name)
+	}
+	return normalize(val)
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+func (n *unaryNode) eval(vars map[string]any) (any, error) {
+	val, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "!":
+		b, ok := val.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator ! requires a boolean, got %s", typeName(val))
+		}
+		return !b, nil
+	default: // "-"
+		num, ok := val.(decimal.Decimal)
+		if !ok {
+			return nil, fmt.Errorf("operator - requires a number, got %s", typeName(val))
+		}
+		return num.Neg(), nil
+	}
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n *binaryNode) eval(vars map[string]any) (any, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	// Short-circuit logical operators
+	if n.op == "&&" || n.op == "||" {
+		lb, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s requires booleans, got %s", n.op, typeName(left))
+		}
+		if (n.op == "&&" && !lb) || (n.op == "||" && lb) {
+			return lb, nil
+		}
+		right, err := n.right.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		rb, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s requires booleans, got %s", n.op, typeName(right))
+		}
+		return rb, nil
+	}
+
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	}
+
+	// String concatenation and comparison
+	if ls, ok := left.(string); ok {
+		rs, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("operator %s cannot mix string and %s", n.op, typeName(right))
+		}
+		switch n.op {
+		case "+":
+			return ls + rs, nil
+		case "<":
+			return ls < rs, nil
+		case "<=":
+			return ls <= rs, nil
+		case ">":
+			return ls > rs, nil
+		case ">=":
+			return ls >= rs, nil
+		}
+		return nil, fmt.Errorf("operator %s is not supported for strings", n.op)
+	}
+
+	ln, lok := left.(decimal.Decimal)
+	rn, rok := right.(decimal.Decimal)
+	if !lok || !rok {
+		return nil, fmt.Errorf("operator %s requires numbers, got %s and %s", n.op, typeName(left), typeName(right))
+	}
+
+	switch n.op {
+	case "+":
+		return ln.Add(rn), nil
+	case "-":
+		return ln.Sub(rn), nil
+	case "*":
+		return ln.Mul(rn), nil
+	case "/":
+		if rn.IsZero() {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return ln.Div(rn), nil
+	case "%":
+		if rn.IsZero() {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return ln.Mod(rn), nil
+	case "<":
+		return ln.LessThan(rn), nil
+	case "<=":
+		return ln.LessThanOrEqual(rn), nil
+	case ">":
+		return ln.GreaterThan(rn), nil
+	default: // ">="
+		return ln.GreaterThanOrEqual(rn), nil
+	}
+}
+
+type ternaryNode struct {
+	cond, then, otherwise node
+}
+
+func (n *ternaryNode) eval(vars map[string]any) (any, error) {
+	cond, err := n.cond.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := cond.(bool)
+	if !ok {
+		return nil, fmt.Errorf("ternary condition must be a boolean, got %s", typeName(cond))
+	}
+	if b {
+		return n.then.eval(vars)
+	}
+	return n.otherwise.eval(vars)
+}
+
+// Value helpers
+
+// normalize converts a variable value to one of the expression types: decimal.Decimal, string or bool
+func normalize(val any) (any, error) {
+	switch v := val.(type) {
+	case decimal.Decimal:
+		return v, nil
+	case int:
+		return decimal.NewFromInt(int64(v)), nil
+	case int64:
+		return decimal.NewFromInt(v), nil
+	case float64:
+		return decimal.NewFromFloat(v), nil
+	case string, bool:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("unsupported variable type %T", val)
+	}
+}
+
+func equal(left, right any) bool {
+	if ln, ok := left.(decimal.Decimal); ok {
+		if rn, ok := right.(decimal.Decimal); ok {
+			return ln.Equal(rn)
+		}
+		return false
+	}
+	return left == right
+}
+
+func typeName(val any) string {
+	switch val.(type) {
+	case decimal.Decimal:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	default:
+		return fmt.Sprintf("%T", val)
+	}
+}
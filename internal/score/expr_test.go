@@ -0,0 +1,105 @@
+package score
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	vars := map[string]any{
+		"exit_code":      1,
+		"status":         "failed",
+		"execution_time": int64(250),
+		"score":          100.0,
+	}
+
+	tests := []struct {
+		name    string
+		expr    string
+		want    string
+		wantErr string
+	}{
+		{name: "number literal", expr: "42", want: "42"},
+		{name: "decimal literal", expr: "12.5", want: "12.5"},
+		{name: "variable", expr: "exit_code", want: "1"},
+		{name: "arithmetic precedence", expr: "2 + 3 * 4", want: "14"},
+		{name: "parentheses", expr: "(2 + 3) * 4", want: "20"},
+		{name: "unary minus", expr: "-exit_code + 5", want: "4"},
+		{name: "modulo", expr: "execution_time % 100", want: "50"},
+		{name: "division", expr: "score / 4", want: "25"},
+		{name: "ternary true branch", expr: "exit_code == 1 ? 10 : 20", want: "10"},
+		{name: "ternary false branch", expr: "exit_code == 0 ? 10 : 20", want: "20"},
+		{name: "nested ternary", expr: `exit_code == 0 ? 100 : (status == "timeout" ? 0 : 50)`, want: "50"},
+		{name: "right associative ternary", expr: `status == "success" ? 100 : status == "failed" ? 25 : 0`, want: "25"},
+		{name: "single quoted string", expr: `status != 'success' ? 1 : 2`, want: "1"},
+		{name: "logical operators", expr: "exit_code > 0 && execution_time < 1000 ? 5 : 0", want: "5"},
+		{name: "short circuit or", expr: "true || unknown ? 1 : 0", want: "1"},
+		{name: "negation", expr: "!(exit_code == 0) ? score : 0", want: "100"},
+		{name: "comparison operators", expr: "execution_time >= 250 && execution_time <= 250 ? 1 : 0", want: "1"},
+		{name: "string result", expr: "status", wantErr: "must evaluate to a number"},
+		{name: "boolean result", expr: "exit_code == 1", wantErr: "must evaluate to a number"},
+		{name: "unknown variable", expr: "missing + 1", wantErr: "unknown variable"},
+		{name: "division by zero", expr: "score / 0", wantErr: "division by zero"},
+		{name: "type mismatch", expr: "status + 1", wantErr: "cannot mix string"},
+		{name: "non-boolean condition", expr: "exit_code ? 1 : 0", wantErr: "must be a boolean"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			got, err := expr.Evaluate(vars)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Evaluate() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Evaluate() = %s, want %s", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{name: "empty", expr: ""},
+		{name: "dangling operator", expr: "1 +"},
+		{name: "missing colon", expr: "true ? 1"},
+		{name: "unbalanced parentheses", expr: "(1 + 2"},
+		{name: "unterminated string", expr: `status == "success`},
+		{name: "invalid character", expr: "1 # 2"},
+		{name: "trailing tokens", expr: "1 2"},
+		{name: "invalid number", expr: "1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.expr); err == nil {
+				t.Errorf("Parse(%q) expected error, got nil", tt.expr)
+			}
+		})
+	}
+}
+
+func TestVariables(t *testing.T) {
+	expr, err := Parse(`exit_code == 0 ? score : (status == "timeout" ? 0 : score / 2)`)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	got := strings.Join(expr.Variables(), ",")
+	if got != "exit_code,score,status" {
+		t.Errorf("Variables() = %s, want exit_code,score,status", got)
+	}
+}
@@ -0,0 +1,174 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// awsBackend reads secrets from AWS Secrets Manager: awssm://<secret-id>
+// Credentials and region come from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,
+// AWS_SESSION_TOKEN and AWS_REGION (or AWS_DEFAULT_REGION). The endpoint can be
+// overridden with AWS_ENDPOINT_URL_SECRETS_MANAGER or AWS_ENDPOINT_URL.
+type awsBackend struct {
+	endpoint     string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	client       *http.Client
+	now          func() time.Time
+}
+
+func newAWSBackend() (Backend, error) {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		return nil, fmt.Errorf("AWS_REGION is not set")
+	}
+	b := &awsBackend{
+		region:       region,
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		client:       &http.Client{},
+		now:          time.Now,
+	}
+	if b.accessKey == "" || b.secretKey == "" {
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+
+	b.endpoint = os.Getenv("AWS_ENDPOINT_URL_SECRETS_MANAGER")
+	if b.endpoint == "" {
+		b.endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+	if b.endpoint == "" {
+		b.endpoint = "https://secretsmanager." + region + ".amazonaws.com"
+	}
+	return b, nil
+}
+
+func (b *awsBackend) Fetch(ctx context.Context, path string) (string, error) {
+	body, err := json.Marshal(map[string]string{"SecretId": path})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(b.endpoint, "/")+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	b.sign(req, body)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		SecretString *string `json:"SecretString"`
+		Type         string  `json:"__type"`
+		Message      string  `json:"message"`
+	}
+	_ = json.Unmarshal(data, &result)
+	if resp.StatusCode != http.StatusOK {
+		if result.Type != "" {
+			return "", fmt.Errorf("secrets manager returned %d: %s: %s", resp.StatusCode, result.Type, result.Message)
+		}
+		return "", fmt.Errorf("secrets manager returned %d", resp.StatusCode)
+	}
+	if result.SecretString == nil {
+		return "", fmt.Errorf("secret %s has no string value", path)
+	}
+	return *result.SecretString, nil
+}
+
+// sign adds an AWS Signature Version 4 to the request
+func (b *awsBackend) sign(req *http.Request, body []byte) {
+	const service = "secretsmanager"
+	now := b.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if b.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", b.sessionToken)
+	}
+
+	// Every header set above is signed, along with the host
+	names := []string{"content-type", "host", "x-amz-date", "x-amz-target"}
+	if b.sessionToken != "" {
+		names = append(names, "x-amz-security-token")
+	}
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hashHex(body),
+	}, "\n")
+
+	scope := date + "/" + b.region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hashHex([]byte(canonicalRequest))}, "\n")
+	signature := hex.EncodeToString(hmacSHA256(signingKey(b.secretKey, date, b.region, service), stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		b.accessKey, scope, signedHeaders, signature))
+}
+
+// signingKey derives the Signature Version 4 key of a day, region and service
+func signingKey(secretKey, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// canonicalQuery encodes query parameters sorted by key, as signed by AWS
+func canonicalQuery(values url.Values) string {
+	return strings.ReplaceAll(values.Encode(), "+", "%20")
+}
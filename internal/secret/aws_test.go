@@ -0,0 +1,93 @@
+package secret
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigningKey(t *testing.T) {
+	// Example from the AWS Signature Version 4 documentation
+	key := signingKey("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20120215", "us-east-1", "iam")
+	want := "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d"
+	if got := hex.EncodeToString(key); got != want {
+		t.Errorf("signingKey() = %s, want %s", got, want)
+	}
+}
+
+func TestAWSBackend(t *testing.T) {
+	var authorization, target, token string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		target = r.Header.Get("X-Amz-Target")
+		token = r.Header.Get("X-Amz-Security-Token")
+		body, _ := io.ReadAll(r.Body)
+		var request struct{ SecretId string }
+		_ = json.Unmarshal(body, &request)
+		switch request.SecretId {
+		case "prod/ghost":
+			_, _ = w.Write([]byte(`{"Name":"prod/ghost","SecretString":"{\"webhook_token\":\"tok\"}"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"Secrets Manager can't find the specified secret."}`))
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("AWS_REGION", "eu-west-1")
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+	t.Setenv("AWS_ENDPOINT_URL_SECRETS_MANAGER", server.URL)
+
+	got, err := NewResolver().Resolve(context.Background(), "awssm://prod/ghost#webhook_token")
+	if err != nil || got != "tok" {
+		t.Fatalf("Resolve() = %q, %v, want tok", got, err)
+	}
+	if target != "secretsmanager.GetSecretValue" || token != "session" {
+		t.Errorf("target = %q, token = %q", target, token)
+	}
+	wantPrefix := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"
+	if !strings.HasPrefix(authorization, wantPrefix) || !strings.Contains(authorization, "/eu-west-1/secretsmanager/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-target;x-amz-security-token, Signature=") {
+		t.Errorf("Authorization = %q", authorization)
+	}
+
+	_, err = NewResolver().Resolve(context.Background(), "awssm://missing")
+	if err == nil || !strings.Contains(err.Error(), "ResourceNotFoundException") {
+		t.Errorf("Resolve() error = %v, want the service error", err)
+	}
+}
+
+func TestAWSSignatureIsStable(t *testing.T) {
+	b := &awsBackend{
+		region:    "us-east-1",
+		accessKey: "AKID",
+		secretKey: "secret",
+		now:       func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) },
+	}
+	sign := func() string {
+		req, _ := http.NewRequest(http.MethodPost, "https://secretsmanager.us-east-1.amazonaws.com/", nil)
+		req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+		req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+		b.sign(req, []byte(`{"SecretId":"x"}`))
+		return req.Header.Get("Authorization")
+	}
+	first := sign()
+	if first != sign() || !strings.Contains(first, "Credential=AKID/20240102/us-east-1/secretsmanager/aws4_request") {
+		t.Errorf("Authorization = %q", first)
+	}
+}
+
+func TestAWSBackendConfiguration(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	if _, err := NewResolver().Resolve(context.Background(), "awssm://x"); err == nil || !strings.Contains(err.Error(), "AWS_REGION is not set") {
+		t.Errorf("Resolve() error = %v, want AWS_REGION to be required", err)
+	}
+}
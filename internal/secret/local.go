@@ -0,0 +1,33 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envBackend reads secrets from environment variables: env://NAME
+type envBackend struct{}
+
+func (envBackend) Fetch(ctx context.Context, path string) (string, error) {
+	value, ok := os.LookupEnv(path)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", path)
+	}
+	return value, nil
+}
+
+// fileBackend reads secrets from files, e.g. mounted Docker or Kubernetes
+// secrets: file:///run/secrets/name
+// A single trailing newline is removed.
+type fileBackend struct{}
+
+func (fileBackend) Fetch(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	value := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
@@ -0,0 +1,189 @@
+// Package secret resolves credential references such as
+// vault://secret/ghost#access_key or env://UPLOAD_SECRET in configuration
+// values, so secrets need not be passed in plain text.
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ResolveTimeout bounds the lookup of a single reference
+var ResolveTimeout = 10 * time.Second
+
+// Backend fetches secrets from a store
+type Backend interface {
+	// Fetch returns the secret at path; secrets with several fields are
+	// returned as a JSON object
+	Fetch(ctx context.Context, path string) (string, error)
+}
+
+// BackendFactory creates a backend, typically configured from the environment
+type BackendFactory func() (Backend, error)
+
+// Registry holds the backends by URL scheme
+var Registry = make(map[string]BackendFactory)
+
+// RegisterBackend registers a backend for references with the scheme
+func RegisterBackend(scheme string, factory BackendFactory) {
+	Registry[scheme] = factory
+}
+
+// init registers all built-in backends
+func init() {
+	RegisterBackend("env", func() (Backend, error) { return envBackend{}, nil })
+	RegisterBackend("file", func() (Backend, error) { return fileBackend{}, nil })
+	RegisterBackend("vault", newVaultBackend)
+	RegisterBackend("awssm", newAWSBackend)
+}
+
+// Schemes returns the registered schemes in sorted order
+func Schemes() []string {
+	schemes := make([]string, 0, len(Registry))
+	for scheme := range Registry {
+		schemes = append(schemes, scheme)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// Reference is a parsed scheme://path#field reference
+type Reference struct {
+	Scheme string
+	Path   string
+	Field  string // key of a JSON object secret; empty for the whole secret
+}
+
+// ParseReference parses a value as a reference to a registered backend
+// ok is false for values that are not references, which are used as-is.
+func ParseReference(value string) (ref Reference, ok bool) {
+	scheme, rest, found := strings.Cut(value, "://")
+	if !found {
+		return Reference{}, false
+	}
+	if _, registered := Registry[scheme]; !registered {
+		return Reference{}, false
+	}
+	path, field, _ := strings.Cut(rest, "#")
+	return Reference{Scheme: scheme, Path: path, Field: field}, true
+}
+
+// String formats the reference as it was written
+func (r Reference) String() string {
+	if r.Field == "" {
+		return r.Scheme + "://" + r.Path
+	}
+	return r.Scheme + "://" + r.Path + "#" + r.Field
+}
+
+// Resolver resolves references; backends are created on first use and shared
+// by later references
+type Resolver struct {
+	backends map[string]Backend
+}
+
+// NewResolver returns a resolver using the registered backends
+func NewResolver() *Resolver {
+	return &Resolver{backends: make(map[string]Backend)}
+}
+
+// Resolve returns the secret a value refers to, or the value itself if it is
+// not a reference
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	ref, ok := ParseReference(value)
+	if !ok {
+		return value, nil
+	}
+	if ref.Path == "" {
+		return "", fmt.Errorf("invalid secret reference %s: missing path", ref)
+	}
+
+	backend, ok := r.backends[ref.Scheme]
+	if !ok {
+		var err error
+		if backend, err = Registry[ref.Scheme](); err != nil {
+			return "", fmt.Errorf("failed to set up %s secrets: %w", ref.Scheme, err)
+		}
+		r.backends[ref.Scheme] = backend
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ResolveTimeout)
+	defer cancel()
+	secret, err := backend.Fetch(ctx, ref.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret %s: %w", ref, err)
+	}
+	if ref.Field == "" {
+		return secret, nil
+	}
+	return field(secret, ref)
+}
+
+// field extracts a field of a JSON object secret
+func field(secret string, ref Reference) (string, error) {
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object, so it has no field %q", ref, ref.Field)
+	}
+	value, ok := fields[ref.Field]
+	if !ok {
+		return "", fmt.Errorf("secret %s has no field %q", ref, ref.Field)
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode field %q of secret %s: %w", ref.Field, ref, err)
+	}
+	return string(data), nil
+}
+
+// ResolveConfig replaces references in a configuration map, in place
+// String values are resolved in nested maps and lists too.
+func ResolveConfig(ctx context.Context, config map[string]any) error {
+	return NewResolver().resolveMap(ctx, config)
+}
+
+func (r *Resolver) resolveMap(ctx context.Context, config map[string]any) error {
+	for key, value := range config {
+		resolved, err := r.resolveValue(ctx, value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		config[key] = resolved
+	}
+	return nil
+}
+
+func (r *Resolver) resolveValue(ctx context.Context, value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return r.Resolve(ctx, v)
+	case map[string]any:
+		return v, r.resolveMap(ctx, v)
+	case []any:
+		for i, item := range v {
+			resolved, err := r.resolveValue(ctx, item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = resolved
+		}
+		return v, nil
+	case []string:
+		for i, item := range v {
+			resolved, err := r.Resolve(ctx, item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = resolved
+		}
+		return v, nil
+	}
+	return value, nil
+}
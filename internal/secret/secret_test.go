@@ -0,0 +1,112 @@
+package secret
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   Reference
+		wantOK bool
+	}{
+		{value: "vault://secret/ghost#access_key", want: Reference{Scheme: "vault", Path: "secret/ghost", Field: "access_key"}, wantOK: true},
+		{value: "env://UPLOAD_SECRET", want: Reference{Scheme: "env", Path: "UPLOAD_SECRET"}, wantOK: true},
+		{value: "awssm://prod/ghost#token", want: Reference{Scheme: "awssm", Path: "prod/ghost", Field: "token"}, wantOK: true},
+		{value: "https://example.com/hook#frag"},
+		{value: "plain-secret"},
+		{value: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := ParseReference(tt.value)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("ParseReference(%q) = %+v, %v, want %+v, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+			if ok && got.String() != tt.value {
+				t.Errorf("String() = %q, want %q", got.String(), tt.value)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	t.Setenv("GHOST_TEST_TOKEN", "s3cr3t")
+	t.Setenv("GHOST_TEST_JSON", `{"user":"ghost","port":9000}`)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "key")
+	if err := os.WriteFile(file, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr string
+	}{
+		{name: "plain value", value: "not-a-reference", want: "not-a-reference"},
+		{name: "unregistered scheme", value: "https://example.com", want: "https://example.com"},
+		{name: "env", value: "env://GHOST_TEST_TOKEN", want: "s3cr3t"},
+		{name: "env field", value: "env://GHOST_TEST_JSON#user", want: "ghost"},
+		{name: "non-string field", value: "env://GHOST_TEST_JSON#port", want: "9000"},
+		{name: "file", value: "file://" + file, want: "from-file"},
+		{name: "unset env", value: "env://GHOST_TEST_UNSET", wantErr: "GHOST_TEST_UNSET is not set"},
+		{name: "missing field", value: "env://GHOST_TEST_JSON#password", wantErr: `has no field "password"`},
+		{name: "field of plain secret", value: "env://GHOST_TEST_TOKEN#user", wantErr: "is not a JSON object"},
+		{name: "missing path", value: "env://", wantErr: "missing path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewResolver().Resolve(context.Background(), tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Resolve() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveConfig(t *testing.T) {
+	t.Setenv("GHOST_TEST_KEY", "key")
+	config := map[string]any{
+		"access_key": "env://GHOST_TEST_KEY",
+		"endpoint":   "https://s3.example.com",
+		"retries":    3,
+		"headers":    map[string]any{"Authorization": "env://GHOST_TEST_KEY"},
+		"list":       []any{"env://GHOST_TEST_KEY", 1},
+		"fields":     []string{"env://GHOST_TEST_KEY"},
+	}
+	if err := ResolveConfig(context.Background(), config); err != nil {
+		t.Fatalf("ResolveConfig() error = %v", err)
+	}
+	want := map[string]any{
+		"access_key": "key",
+		"endpoint":   "https://s3.example.com",
+		"retries":    3,
+		"headers":    map[string]any{"Authorization": "key"},
+		"list":       []any{"key", 1},
+		"fields":     []string{"key"},
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("config = %v, want %v", config, want)
+	}
+
+	err := ResolveConfig(context.Background(), map[string]any{"secret_key": "env://GHOST_TEST_UNSET"})
+	if err == nil || !strings.HasPrefix(err.Error(), "secret_key: ") {
+		t.Errorf("ResolveConfig() error = %v, want it to name the key", err)
+	}
+}
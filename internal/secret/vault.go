@@ -0,0 +1,111 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// vaultBackend reads secrets from HashiCorp Vault: vault://<mount>/<path>
+// It is configured like the vault CLI: VAULT_ADDR, VAULT_TOKEN (or
+// ~/.vault-token) and VAULT_NAMESPACE. KV version 2 mounts are tried first,
+// then version 1.
+type vaultBackend struct {
+	addr      string
+	token     string
+	namespace string
+	client    *http.Client
+}
+
+func newVaultBackend() (Backend, error) {
+	addr := strings.TrimSuffix(os.Getenv("VAULT_ADDR"), "/")
+	if addr == "" {
+		return nil, fmt.Errorf("VAULT_ADDR is not set")
+	}
+	token := os.Getenv("VAULT_TOKEN")
+	if token == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			data, _ := os.ReadFile(filepath.Join(home, ".vault-token"))
+			token = strings.TrimSpace(string(data))
+		}
+	}
+	if token == "" {
+		return nil, fmt.Errorf("VAULT_TOKEN is not set and ~/.vault-token is missing")
+	}
+	return &vaultBackend{addr: addr, token: token, namespace: os.Getenv("VAULT_NAMESPACE"), client: &http.Client{}}, nil
+}
+
+func (b *vaultBackend) Fetch(ctx context.Context, path string) (string, error) {
+	path = strings.Trim(path, "/")
+	mount, rest, _ := strings.Cut(path, "/")
+
+	// KV v2 keeps the secret under <mount>/data/<path> and wraps it in data.data
+	if rest != "" {
+		data, status, err := b.read(ctx, mount+"/data/"+rest)
+		if err != nil {
+			return "", err
+		}
+		if status == http.StatusOK {
+			var body struct {
+				Data struct {
+					Data map[string]any `json:"data"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(data, &body); err == nil && body.Data.Data != nil {
+				return encodeFields(body.Data.Data)
+			}
+		}
+	}
+
+	data, status, err := b.read(ctx, path)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("vault returned %d for %s", status, path)
+	}
+	var body struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil || body.Data == nil {
+		return "", fmt.Errorf("unexpected vault response for %s", path)
+	}
+	return encodeFields(body.Data)
+}
+
+// read performs a GET of /v1/<path>
+func (b *vaultBackend) read(ctx context.Context, path string) ([]byte, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.addr+"/v1/"+path, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("X-Vault-Token", b.token)
+	if b.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", b.namespace)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, resp.StatusCode, nil
+}
+
+// encodeFields returns the fields of a secret as a JSON object
+func encodeFields(fields map[string]any) (string, error) {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,72 @@
+package secret
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVaultBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" || r.Header.Get("X-Vault-Namespace") != "team" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/ghost":
+			_, _ = w.Write([]byte(`{"data":{"data":{"access_key":"AKIA","secret_key":"shh"},"metadata":{"version":3}}}`))
+		case "/v1/kv1/ghost":
+			_, _ = w.Write([]byte(`{"data":{"token":"v1-token"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	t.Setenv("VAULT_ADDR", server.URL+"/")
+	t.Setenv("VAULT_TOKEN", "root")
+	t.Setenv("VAULT_NAMESPACE", "team")
+
+	tests := []struct {
+		value   string
+		want    string
+		wantErr string
+	}{
+		{value: "vault://secret/ghost#access_key", want: "AKIA"},
+		{value: "vault://secret/ghost#secret_key", want: "shh"},
+		{value: "vault://kv1/ghost#token", want: "v1-token"},
+		{value: "vault://secret/missing#key", wantErr: "vault returned 404"},
+	}
+	resolver := NewResolver()
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := resolver.Resolve(context.Background(), tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Resolve() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("Resolve() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVaultBackendConfiguration(t *testing.T) {
+	t.Setenv("VAULT_ADDR", "")
+	if _, err := NewResolver().Resolve(context.Background(), "vault://secret/ghost#key"); err == nil || !strings.Contains(err.Error(), "VAULT_ADDR is not set") {
+		t.Errorf("Resolve() error = %v, want VAULT_ADDR to be required", err)
+	}
+
+	t.Setenv("VAULT_ADDR", "http://127.0.0.1:8200")
+	t.Setenv("VAULT_TOKEN", "")
+	t.Setenv("HOME", t.TempDir())
+	if _, err := NewResolver().Resolve(context.Background(), "vault://secret/ghost#key"); err == nil || !strings.Contains(err.Error(), "VAULT_TOKEN is not set") {
+		t.Errorf("Resolve() error = %v, want VAULT_TOKEN to be required", err)
+	}
+}
@@ -0,0 +1,69 @@
+package stress
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SeedPlaceholder is replaced by the seed in the generator command
+const SeedPlaceholder = "{seed}"
+
+// MaxSeeds caps the number of seeds in a seed list
+const MaxSeeds = 1_000_000
+
+// ParseSeeds parses a comma-separated list of seeds and inclusive ranges
+// e.g. "1..50" or "1,7,100..120". Seeds are returned in the order given.
+func ParseSeeds(spec string) ([]int64, error) {
+	var seeds []int64
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		first, last := item, item
+		if from, to, ok := strings.Cut(item, ".."); ok {
+			first, last = strings.TrimSpace(from), strings.TrimSpace(to)
+		}
+		start, err := parseSeed(first)
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseSeed(last)
+		if err != nil {
+			return nil, err
+		}
+		if end < start {
+			return nil, fmt.Errorf("invalid seed range %q: end is before start", item)
+		}
+		if end-start >= MaxSeeds || len(seeds)+int(end-start)+1 > MaxSeeds {
+			return nil, fmt.Errorf("too many seeds in %q (at most %d)", spec, MaxSeeds)
+		}
+		for seed := start; seed <= end; seed++ {
+			seeds = append(seeds, seed)
+		}
+	}
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("no seeds given")
+	}
+	return seeds, nil
+}
+
+// parseSeed parses a single non-negative seed
+func parseSeed(s string) (int64, error) {
+	seed, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || seed < 0 {
+		return 0, fmt.Errorf("invalid seed %q: must be a non-negative integer", s)
+	}
+	return seed, nil
+}
+
+// ExpandSeed replaces SeedPlaceholder in every argument with the seed
+func ExpandSeed(args []string, seed int64) []string {
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		expanded[i] = strings.ReplaceAll(arg, SeedPlaceholder, strconv.FormatInt(seed, 10))
+	}
+	return expanded
+}
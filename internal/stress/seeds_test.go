@@ -0,0 +1,58 @@
+package stress
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseSeeds(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []int64
+		wantErr string
+	}{
+		{spec: "1..5", want: []int64{1, 2, 3, 4, 5}},
+		{spec: "7", want: []int64{7}},
+		{spec: "3, 1, 10..12", want: []int64{3, 1, 10, 11, 12}},
+		{spec: "0..0", want: []int64{0}},
+		{spec: " 4 .. 5 ,", want: []int64{4, 5}},
+		{spec: "", wantErr: "no seeds"},
+		{spec: "5..1", wantErr: "end is before start"},
+		{spec: "-1", wantErr: "non-negative"},
+		{spec: "a..3", wantErr: `invalid seed "a"`},
+		{spec: "1..", wantErr: `invalid seed ""`},
+		{spec: "1..2000000", wantErr: "too many seeds"},
+		{spec: "1..600000,1..600000", wantErr: "too many seeds"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseSeeds(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseSeeds(%q) error = %v, want %q", tt.spec, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSeeds(%q) unexpected error: %v", tt.spec, err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseSeeds(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandSeed(t *testing.T) {
+	args := []string{"--seed", "{seed}", "--out=case-{seed}.txt", "plain"}
+	got := ExpandSeed(args, 42)
+	want := []string{"--seed", "42", "--out=case-42.txt", "plain"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ExpandSeed = %v, want %v", got, want)
+	}
+	if args[1] != "{seed}" {
+		t.Error("ExpandSeed modified its input")
+	}
+}
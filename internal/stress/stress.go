@@ -0,0 +1,20 @@
+package stress
+
+// Outcomes of a seed besides the program's own execution status (failed, timeout, ...)
+const (
+	CasePassed          = "passed"           // the program's output matched the reference output
+	CaseWrongAnswer     = "wrong_answer"     // the outputs differ
+	CaseGeneratorFailed = "generator_failed" // the input generator did not succeed
+	CaseReferenceFailed = "reference_failed" // the reference solution did not succeed
+)
+
+// Files written for every seed, in <work dir>/<seed>/
+const (
+	InputFile           = "input.txt"
+	OutputFile          = "output.txt"
+	StderrFile          = "stderr.txt"
+	ExpectedFile        = "expected.txt" // reference output
+	DiffFile            = "diff.txt"
+	GeneratorStderrFile = "generator_stderr.txt"
+	ReferenceStderrFile = "reference_stderr.txt"
+)
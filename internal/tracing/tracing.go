@@ -0,0 +1,140 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name used for all ghost spans
+const TracerName = "github.com/zinc-sig/ghost"
+
+// ContextAttributePrefix prefixes context map keys when attached to spans
+const ContextAttributePrefix = "ghost.context."
+
+// Standard OTEL environment variables that enable tracing when set
+var endpointEnvVars = []string{
+	"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT",
+	"OTEL_EXPORTER_OTLP_ENDPOINT",
+}
+
+// Enabled reports whether tracing should be enabled for the given endpoint flag
+func Enabled(endpoint string) bool {
+	if endpoint != "" {
+		return true
+	}
+	for _, name := range endpointEnvVars {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Setup configures the global tracer provider with an OTLP/HTTP exporter
+// The endpoint flag takes precedence over the standard OTEL environment variables;
+// when neither is set tracing stays disabled (no-op). The returned shutdown function
+// flushes pending spans and must be called before exit.
+func Setup(ctx context.Context, endpoint string) (func(context.Context) error, error) {
+	noop := func(context.Context) error { return nil }
+	if !Enabled(endpoint) {
+		return noop, nil
+	}
+
+	var opts []otlptracehttp.Option
+	if endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpointURL(endpoint))
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return noop, fmt.Errorf("failed to create trace exporter: %w", err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults
+	res, err := resource.Merge(
+		resource.NewSchemaless(semconv.ServiceName("ghost")),
+		resource.Environment(),
+	)
+	if err != nil {
+		return noop, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the ghost tracer from the global provider
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// RecordError marks the span as failed with the given error
+func RecordError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// ContextAttributes flattens a context map into span attributes using dot notation
+// Non-map contexts are attached as a single attribute.
+func ContextAttributes(ctx any) []attribute.KeyValue {
+	if ctx == nil {
+		return nil
+	}
+
+	var attrs []attribute.KeyValue
+	flatten(strings.TrimSuffix(ContextAttributePrefix, "."), ctx, &attrs)
+	return attrs
+}
+
+func flatten(key string, value any, attrs *[]attribute.KeyValue) {
+	switch v := value.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			flatten(key+"."+k, v[k], attrs)
+		}
+	case string:
+		*attrs = append(*attrs, attribute.String(key, v))
+	case bool:
+		*attrs = append(*attrs, attribute.Bool(key, v))
+	case int:
+		*attrs = append(*attrs, attribute.Int(key, v))
+	case int64:
+		*attrs = append(*attrs, attribute.Int64(key, v))
+	case float64:
+		*attrs = append(*attrs, attribute.Float64(key, v))
+	case nil:
+		// Skip null values
+	default:
+		*attrs = append(*attrs, attribute.String(key, fmt.Sprint(v)))
+	}
+}
@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestContextAttributes(t *testing.T) {
+	ctx := map[string]any{
+		"student_id": "s123",
+		"attempt":    2,
+		"ratio":      0.5,
+		"late":       false,
+		"nested": map[string]any{
+			"course": "COMP1021",
+			"week":   float64(3),
+		},
+		"missing": nil,
+	}
+
+	attrs := ContextAttributes(ctx)
+	got := make(map[attribute.Key]attribute.Value)
+	for _, attr := range attrs {
+		got[attr.Key] = attr.Value
+	}
+
+	want := map[attribute.Key]attribute.Value{
+		"ghost.context.student_id":    attribute.StringValue("s123"),
+		"ghost.context.attempt":       attribute.IntValue(2),
+		"ghost.context.ratio":         attribute.Float64Value(0.5),
+		"ghost.context.late":          attribute.BoolValue(false),
+		"ghost.context.nested.course": attribute.StringValue("COMP1021"),
+		"ghost.context.nested.week":   attribute.Float64Value(3),
+	}
+
+	if len(got) != len(want) {
+		t.Errorf("Expected %d attributes, got %d: %v", len(want), len(got), attrs)
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("Attribute %s = %v, want %v", key, got[key].Emit(), value.Emit())
+		}
+	}
+}
+
+func TestContextAttributesNonMap(t *testing.T) {
+	attrs := ContextAttributes([]any{"a", "b"})
+	if len(attrs) != 1 || attrs[0].Key != "ghost.context" {
+		t.Errorf("Expected single ghost.context attribute, got %v", attrs)
+	}
+
+	if attrs := ContextAttributes(nil); attrs != nil {
+		t.Errorf("Expected nil attributes for nil context, got %v", attrs)
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+
+	if Enabled("") {
+		t.Error("Expected tracing disabled without endpoint or env vars")
+	}
+	if !Enabled("http://localhost:4318") {
+		t.Error("Expected tracing enabled with endpoint flag")
+	}
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318")
+	if !Enabled("") {
+		t.Error("Expected tracing enabled with OTEL_EXPORTER_OTLP_ENDPOINT")
+	}
+}
+
+func TestSetupDisabled(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+
+	shutdown, err := Setup(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	if err := shutdown(context.Background()); err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package upload
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Attributes are per-object settings given for an uploaded file
+type Attributes struct {
+	ContentType string            // MIME type ("" = detected)
+	Metadata    map[string]string // user metadata, merged over the provider's
+}
+
+// ValidateContentType checks that a content type is a valid MIME type such as text/csv
+func ValidateContentType(contentType string) error {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !strings.Contains(mediaType, "/") {
+		return fmt.Errorf("invalid content type %q", contentType)
+	}
+	return nil
+}
+
+// ValidateMetadata checks user metadata keys and values
+// Keys become X-Amz-Meta-<key> headers, so they are limited to letters, digits,
+// '-' and '_'; values must be printable ASCII.
+func ValidateMetadata(metadata map[string]string) error {
+	for key, value := range metadata {
+		if key == "" || strings.IndexFunc(key, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+		}) >= 0 {
+			return fmt.Errorf("invalid metadata key %q: use letters, digits, '-' or '_'", key)
+		}
+		if strings.IndexFunc(value, func(r rune) bool { return r < ' ' || r > '~' }) >= 0 {
+			return fmt.Errorf("invalid metadata value for %q: only printable ASCII is allowed", key)
+		}
+	}
+	return nil
+}
+
+// ParseMetadata converts user metadata from any config source
+// JSON config provides an object with scalar values, key-value or environment
+// sources a comma-separated list of key=value pairs.
+func ParseMetadata(value any) (map[string]string, error) {
+	metadata := make(map[string]string)
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		for key, val := range v {
+			switch val.(type) {
+			case string, float64, int, bool:
+				metadata[key] = fmt.Sprint(val)
+			default:
+				return nil, fmt.Errorf("metadata value for %q must be a string, number or boolean, got %T", key, val)
+			}
+		}
+	case string:
+		for _, pair := range strings.Split(v, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			key, val, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid metadata entry %q, expected key=value", pair)
+			}
+			metadata[strings.TrimSpace(key)] = strings.TrimSpace(val)
+		}
+	default:
+		return nil, fmt.Errorf("metadata must be an object, got %T", value)
+	}
+
+	if err := ValidateMetadata(metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// MergeMetadata returns base overlaid with override; either may be nil
+func MergeMetadata(base, override map[string]string) map[string]string {
+	if len(base) == 0 {
+		return override
+	}
+	if len(override) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		merged[key] = value
+	}
+	return merged
+}
+
+// DetectContentType guesses the content type of a file from its name, then its content
+// head is the start of the content (up to 512 bytes are used); "" is returned
+// if neither gives an answer, leaving the provider's default.
+func DetectContentType(name string, head []byte) string {
+	if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+		return contentType
+	}
+	if len(head) == 0 {
+		return ""
+	}
+	return http.DetectContentType(head)
+}
+
+// FormatMetadata renders metadata as sorted key=value pairs for display
+func FormatMetadata(metadata map[string]string) string {
+	pairs := make([]string, 0, len(metadata))
+	for key, value := range metadata {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
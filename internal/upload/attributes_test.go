@@ -0,0 +1,113 @@
+package upload
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   any
+		want    map[string]string
+		wantErr string
+	}{
+		{name: "unset", value: nil, want: nil},
+		{
+			name:  "object",
+			value: map[string]any{"student_id": "s123", "attempt": float64(2), "late": false},
+			want:  map[string]string{"student_id": "s123", "attempt": "2", "late": "false"},
+		},
+		{
+			name:  "key-value string",
+			value: "course=cs101, term = fall,",
+			want:  map[string]string{"course": "cs101", "term": "fall"},
+		},
+		{name: "nested value", value: map[string]any{"user": map[string]any{}}, wantErr: "must be a string, number or boolean"},
+		{name: "missing value", value: "course", wantErr: "expected key=value"},
+		{name: "invalid key", value: map[string]any{"student id": "s1"}, wantErr: "invalid metadata key"},
+		{name: "non-ASCII value", value: map[string]any{"name": "Zoë"}, wantErr: "printable ASCII"},
+		{name: "wrong type", value: []any{"a"}, wantErr: "must be an object"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseMetadata(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseMetadata() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMetadata() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMetadata() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateContentType(t *testing.T) {
+	tests := []struct {
+		contentType string
+		wantErr     bool
+	}{
+		{contentType: "text/csv"},
+		{contentType: "text/plain; charset=utf-8"},
+		{contentType: "application/vnd.ms-excel"},
+		{contentType: "csv", wantErr: true},
+		{contentType: "", wantErr: true},
+		{contentType: "text/csv; charset", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			err := ValidateContentType(tt.contentType)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateContentType(%q) error = %v, wantErr %v", tt.contentType, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDetectContentType(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		head []byte
+		want string
+	}{
+		{name: "by extension", file: "results/report.json", head: []byte("not json"), want: "application/json"},
+		{name: "sniffed text", file: "output", head: []byte("hello\n"), want: "text/plain; charset=utf-8"},
+		{name: "sniffed binary", file: "core", head: []byte{0, 1, 2, 3}, want: "application/octet-stream"},
+		{name: "empty file", file: "output", head: nil, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectContentType(tt.file, tt.head); got != tt.want {
+				t.Errorf("DetectContentType(%q) = %q, want %q", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeMetadata(t *testing.T) {
+	base := map[string]string{"course": "cs101", "term": "fall"}
+	override := map[string]string{"term": "spring", "student_id": "s1"}
+
+	got := MergeMetadata(base, override)
+	want := map[string]string{"course": "cs101", "term": "spring", "student_id": "s1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeMetadata() = %v, want %v", got, want)
+	}
+	if base["term"] != "fall" {
+		t.Error("MergeMetadata() modified the base map")
+	}
+	if got := MergeMetadata(nil, override); !reflect.DeepEqual(got, override) {
+		t.Errorf("MergeMetadata(nil, override) = %v", got)
+	}
+}
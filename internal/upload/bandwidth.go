@@ -0,0 +1,114 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/zinc-sig/ghost/internal/bytesize"
+	"golang.org/x/time/rate"
+)
+
+// ParseBandwidth parses an upload bandwidth limit such as 10MB/s into bytes per second
+// The "/s" suffix is optional; units are those of bytesize.Parse.
+func ParseBandwidth(value string) (int64, error) {
+	n, err := bytesize.Parse(strings.TrimSuffix(strings.TrimSpace(value), "/s"))
+	if err != nil || n == 0 || n > 1<<62 {
+		return 0, fmt.Errorf("invalid bandwidth limit %q (e.g. 10MB/s, 512KiB/s)", value)
+	}
+	return int64(n), nil
+}
+
+// NewBandwidthLimiter returns a limiter allowing bytesPerSecond, shared by the
+// uploads of an invocation so they don't add up to more (nil = unlimited)
+func NewBandwidthLimiter(bytesPerSecond int64) *rate.Limiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), int(bytesPerSecond))
+}
+
+// ThrottledReader reads no faster than its limiter allows
+type ThrottledReader struct {
+	Ctx     context.Context
+	Reader  io.Reader
+	Limiter *rate.Limiter // nil = unlimited
+}
+
+func (t *ThrottledReader) Read(p []byte) (int, error) {
+	if t.Limiter == nil {
+		return t.Reader.Read(p)
+	}
+	// Never read more than the limiter can grant at once
+	if burst := t.Limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+	n, err := t.Reader.Read(p)
+	if n > 0 {
+		if waitErr := t.Limiter.WaitN(t.Ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// Progress is a snapshot of a transfer
+type Progress struct {
+	Bytes   int64         // bytes transferred so far
+	Total   int64         // expected bytes (0 = unknown)
+	Elapsed time.Duration // time since the transfer started
+	Done    bool          // the reader reached the end
+}
+
+// Percent returns how much of the transfer is done, or -1 if the total is unknown
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return -1
+	}
+	return float64(p.Bytes) * 100 / float64(p.Total)
+}
+
+// Rate returns the average transfer rate in bytes per second
+func (p Progress) Rate() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Bytes) / p.Elapsed.Seconds()
+}
+
+// ProgressReader calls Report with the progress of the bytes read through it,
+// at most once per Interval and once more at the end
+type ProgressReader struct {
+	Reader   io.Reader
+	Total    int64
+	Interval time.Duration
+	Report   func(Progress)
+
+	n          int64
+	start      time.Time
+	lastReport time.Time
+	done       bool
+}
+
+func (p *ProgressReader) Read(b []byte) (int, error) {
+	now := time.Now()
+	if p.start.IsZero() {
+		p.start, p.lastReport = now, now
+	}
+	n, err := p.Reader.Read(b)
+	p.n += int64(n)
+
+	now = time.Now()
+	switch {
+	case p.done:
+	case err == io.EOF:
+		p.done = true
+		p.Report(Progress{Bytes: p.n, Total: p.Total, Elapsed: now.Sub(p.start), Done: true})
+	case now.Sub(p.lastReport) >= p.Interval:
+		p.lastReport = now
+		p.Report(Progress{Bytes: p.n, Total: p.Total, Elapsed: now.Sub(p.start)})
+	}
+	return n, err
+}
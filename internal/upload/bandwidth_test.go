@@ -0,0 +1,100 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseBandwidth(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "10MB/s", want: 10e6},
+		{value: "512KiB/s", want: 512 << 10},
+		{value: "2048", want: 2048},
+		{value: " 1 GB/s ", want: 1e9},
+		{value: "0/s", wantErr: true},
+		{value: "10MB/m", wantErr: true},
+		{value: "fast", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseBandwidth(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseBandwidth(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseBandwidth(%q) = %d, want %d", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestThrottledReader(t *testing.T) {
+	data := strings.Repeat("x", 3000)
+	reader := &ThrottledReader{Ctx: context.Background(), Reader: strings.NewReader(data), Limiter: NewBandwidthLimiter(2000)}
+
+	start := time.Now()
+	got, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The burst of 2000 bytes is free, the other 1000 take half a second
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("Reading 3000 bytes at 2000 B/s took %v, want about 500ms", elapsed)
+	}
+	if string(got) != data {
+		t.Errorf("Read %d bytes, want %d", len(got), len(data))
+	}
+
+	// Without a limiter the reader passes through
+	unlimited := &ThrottledReader{Ctx: context.Background(), Reader: strings.NewReader(data)}
+	if got, _ := io.ReadAll(unlimited); string(got) != data {
+		t.Errorf("Unlimited read %d bytes, want %d", len(got), len(data))
+	}
+}
+
+func TestThrottledReaderCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	reader := &ThrottledReader{Ctx: ctx, Reader: bytes.NewReader(make([]byte, 100)), Limiter: NewBandwidthLimiter(10)}
+	if _, err := io.ReadAll(reader); err == nil {
+		t.Error("Expected an error reading with a canceled context")
+	}
+}
+
+func TestProgressReader(t *testing.T) {
+	var reports []Progress
+	reader := &ProgressReader{
+		Reader:   strings.NewReader(strings.Repeat("x", 100)),
+		Total:    100,
+		Interval: time.Hour,
+		Report:   func(p Progress) { reports = append(reports, p) },
+	}
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		t.Fatal(err)
+	}
+	// Reads after the end are not reported again
+	_, _ = reader.Read(make([]byte, 1))
+
+	if len(reports) != 1 {
+		t.Fatalf("reports = %+v, want only the final one", reports)
+	}
+	final := reports[0]
+	if !final.Done || final.Bytes != 100 || final.Percent() != 100 {
+		t.Errorf("final report = %+v (%.0f%%), want 100 of 100 bytes done", final, final.Percent())
+	}
+
+	if p := (Progress{Bytes: 50}); p.Percent() != -1 {
+		t.Errorf("Percent() with unknown total = %v, want -1", p.Percent())
+	}
+	if p := (Progress{Bytes: 1000, Elapsed: 2 * time.Second}); p.Rate() != 500 {
+		t.Errorf("Rate() = %v, want 500", p.Rate())
+	}
+}
@@ -0,0 +1,88 @@
+package upload
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+)
+
+// Supported compression formats
+const (
+	CompressionNone = ""
+	CompressionGzip = "gzip"
+)
+
+// Options carries per-object metadata for an upload
+type Options struct {
+	ContentEncoding string      // e.g. "gzip" for compressed uploads
+	Encryption      *Encryption // Encrypt the object at rest (nil = provider default)
+	Size            int64       // Content length in bytes if known (0 = unknown, streamed)
+
+	ContentType string            // MIME type ("" = provider default)
+	Metadata    map[string]string // user metadata for this object
+}
+
+// OptionsUploader is implemented by providers that can attach metadata to uploaded objects
+type OptionsUploader interface {
+	UploadWithOptions(ctx context.Context, reader io.Reader, remotePath string, opts Options) error
+}
+
+// UploadWithOptions uploads through the provider, passing metadata if the provider supports it
+// Providers without metadata support receive a plain Upload call, unless encryption
+// is requested: uploading those objects unencrypted would defeat its purpose.
+func UploadWithOptions(ctx context.Context, provider Provider, reader io.Reader, remotePath string, opts Options) error {
+	if uploader, ok := provider.(OptionsUploader); ok {
+		return uploader.UploadWithOptions(ctx, reader, remotePath, opts)
+	}
+	if opts.Encryption != nil {
+		return fmt.Errorf("upload provider %s does not support encryption", provider.Name())
+	}
+	return provider.Upload(ctx, reader, remotePath)
+}
+
+// ValidateCompression checks that the compression format is supported
+func ValidateCompression(compression string) error {
+	switch compression {
+	case CompressionNone, CompressionGzip:
+		return nil
+	default:
+		return fmt.Errorf("unsupported upload compression %q (must be %s)", compression, CompressionGzip)
+	}
+}
+
+// CompressedPath returns the remote path for a file uploaded with the given compression
+func CompressedPath(remotePath, compression string) string {
+	if compression == CompressionGzip {
+		return remotePath + ".gz"
+	}
+	return remotePath
+}
+
+// GzipReader streams src through gzip without buffering the whole file in memory
+// The returned reader must be closed so the compressing goroutine exits even if
+// the upload stops reading early.
+func GzipReader(src io.Reader) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		gz := gzip.NewWriter(pw)
+		_, err := io.Copy(gz, src)
+		if closeErr := gz.Close(); err == nil {
+			err = closeErr
+		}
+		_ = pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+// CountingReader counts the bytes read through it
+type CountingReader struct {
+	Reader io.Reader
+	N      int64
+}
+
+func (c *CountingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.N += int64(n)
+	return n, err
+}
@@ -0,0 +1,104 @@
+package upload
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+// optionsProvider records the options passed to UploadWithOptions
+type optionsProvider struct {
+	MockProvider
+	opts Options
+}
+
+func (o *optionsProvider) UploadWithOptions(ctx context.Context, reader io.Reader, remotePath string, opts Options) error {
+	o.opts = opts
+	return o.Upload(ctx, reader, remotePath)
+}
+
+func TestGzipReader(t *testing.T) {
+	content := strings.Repeat("ghost output line\n", 1000)
+
+	counter := &CountingReader{Reader: GzipReader(strings.NewReader(content))}
+	compressed, err := io.ReadAll(counter)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v", err)
+	}
+	if counter.N != int64(len(compressed)) {
+		t.Errorf("CountingReader.N = %d, want %d", counter.N, len(compressed))
+	}
+	if len(compressed) >= len(content) {
+		t.Errorf("Expected compressed size < %d, got %d", len(content), len(compressed))
+	}
+
+	reader, err := gzip.NewReader(strings.NewReader(string(compressed)))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("Failed to decompress: %v", err)
+	}
+	if string(decompressed) != content {
+		t.Error("Decompressed content does not match original")
+	}
+}
+
+func TestGzipReaderCloseEarly(t *testing.T) {
+	// Closing before the stream is consumed must not leave the writer blocked
+	reader := GzipReader(strings.NewReader(strings.Repeat("x", 1<<20)))
+	buf := make([]byte, 16)
+	if _, err := reader.Read(buf); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if err := reader.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestValidateCompression(t *testing.T) {
+	tests := []struct {
+		compression string
+		wantErr     bool
+	}{
+		{"", false},
+		{"gzip", false},
+		{"zstd", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateCompression(tt.compression); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateCompression(%q) error = %v, wantErr %v", tt.compression, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCompressedPath(t *testing.T) {
+	if got := CompressedPath("logs/stderr.txt", CompressionGzip); got != "logs/stderr.txt.gz" {
+		t.Errorf("CompressedPath() = %q, want logs/stderr.txt.gz", got)
+	}
+	if got := CompressedPath("logs/stderr.txt", CompressionNone); got != "logs/stderr.txt" {
+		t.Errorf("CompressedPath() = %q, want logs/stderr.txt", got)
+	}
+}
+
+func TestUploadWithOptions(t *testing.T) {
+	withOptions := &optionsProvider{MockProvider: *NewMockProvider("options")}
+	if err := UploadWithOptions(context.Background(), withOptions, strings.NewReader("data"), "a.gz", Options{ContentEncoding: "gzip"}); err != nil {
+		t.Fatalf("UploadWithOptions() error = %v", err)
+	}
+	if withOptions.opts.ContentEncoding != "gzip" {
+		t.Errorf("ContentEncoding = %q, want gzip", withOptions.opts.ContentEncoding)
+	}
+
+	// Providers without metadata support fall back to Upload
+	plain := NewMockProvider("plain")
+	if err := UploadWithOptions(context.Background(), plain, strings.NewReader("data"), "b.gz", Options{ContentEncoding: "gzip"}); err != nil {
+		t.Fatalf("UploadWithOptions() error = %v", err)
+	}
+	if len(plain.uploads) != 1 || plain.uploads[0].remotePath != "b.gz" {
+		t.Errorf("Expected fallback upload to b.gz, got %v", plain.uploads)
+	}
+}
@@ -0,0 +1,101 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Lister is implemented by providers that can enumerate the objects under a remote directory
+type Lister interface {
+	// List returns the remote paths of the objects under remoteDir, recursively
+	List(ctx context.Context, remoteDir string) ([]string, error)
+}
+
+// IsRemoteDir reports whether a remote path names a directory, i.e. ends with a slash
+func IsRemoteDir(remotePath string) bool {
+	return strings.HasSuffix(remotePath, "/")
+}
+
+// ExpandRemotes resolves remote directories (paths ending with a slash) to the
+// objects under them, keeping other paths as they are
+func ExpandRemotes(ctx context.Context, provider Provider, remotePaths []string) ([]string, error) {
+	var expanded []string
+	for _, remotePath := range remotePaths {
+		if !IsRemoteDir(remotePath) {
+			expanded = append(expanded, remotePath)
+			continue
+		}
+		lister, ok := provider.(Lister)
+		if !ok {
+			return nil, fmt.Errorf("upload provider %s cannot list %s", provider.Name(), remotePath)
+		}
+		objects, err := lister.List(ctx, remotePath)
+		if err != nil {
+			return nil, err
+		}
+		if len(objects) == 0 {
+			return nil, fmt.Errorf("no files under %s", remotePath)
+		}
+		expanded = append(expanded, objects...)
+	}
+	return expanded, nil
+}
+
+// LocalPath returns where a remote path is downloaded to under dir
+// The remote path is kept, so files of different submissions don't collide;
+// paths that would escape dir are rejected.
+func LocalPath(dir, remotePath string) (string, error) {
+	rel := filepath.FromSlash(path.Clean(strings.TrimPrefix(remotePath, "/")))
+	if rel == "." || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("remote path %s cannot be downloaded into a directory", remotePath)
+	}
+	return filepath.Join(dir, rel), nil
+}
+
+// DownloadFile downloads a remote path to a local file, returning its size
+// The file is written under a temporary name and renamed once complete, so a
+// failed download leaves no partial file behind.
+func DownloadFile(ctx context.Context, provider Provider, remotePath, localPath string) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create directory for %s: %w", localPath, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(localPath), "."+filepath.Base(localPath)+".*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", localPath, err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	counter := &countingWriter{w: tmp}
+	err = provider.Download(ctx, remotePath, counter)
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write %s: %w", localPath, closeErr)
+	}
+	if err != nil {
+		return 0, err
+	}
+	// Temporary files are private; downloads get the mode of other written files
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", localPath, err)
+	}
+	if err := os.Rename(tmp.Name(), localPath); err != nil {
+		return 0, fmt.Errorf("failed to write %s: %w", localPath, err)
+	}
+	return counter.n, nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,74 @@
+package upload
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalPath(t *testing.T) {
+	tests := []struct {
+		remote  string
+		want    string
+		wantErr bool
+	}{
+		{remote: "hw1/alice/output.txt", want: filepath.Join("out", "hw1", "alice", "output.txt")},
+		{remote: "/hw1/output.txt", want: filepath.Join("out", "hw1", "output.txt")},
+		{remote: "hw1/../output.txt", want: filepath.Join("out", "output.txt")},
+		{remote: "../output.txt", wantErr: true},
+		{remote: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := LocalPath("out", tt.remote)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("LocalPath(%q) error = %v, wantErr %v", tt.remote, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("LocalPath(%q) = %q, want %q", tt.remote, got, tt.want)
+		}
+	}
+}
+
+func TestDownloadFile(t *testing.T) {
+	provider := NewMockProvider("mock")
+	_ = provider.Upload(context.Background(), strings.NewReader("student output"), "hw1/output.txt")
+
+	local := filepath.Join(t.TempDir(), "hw1", "output.txt")
+	size, err := DownloadFile(context.Background(), provider, "hw1/output.txt", local)
+	if err != nil {
+		t.Fatalf("DownloadFile() error = %v", err)
+	}
+	if size != int64(len("student output")) {
+		t.Errorf("DownloadFile() size = %d", size)
+	}
+	if got, _ := os.ReadFile(local); string(got) != "student output" {
+		t.Errorf("downloaded %q", got)
+	}
+
+	// A failed download leaves nothing behind
+	missing := filepath.Join(filepath.Dir(local), "missing.txt")
+	if _, err := DownloadFile(context.Background(), provider, "hw1/missing.txt", missing); err == nil {
+		t.Fatal("DownloadFile() of a missing file succeeded")
+	}
+	entries, _ := os.ReadDir(filepath.Dir(local))
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries after a failed download, want 1", len(entries))
+	}
+}
+
+func TestExpandRemotes(t *testing.T) {
+	// Files are kept as they are
+	provider := NewMockProvider("mock")
+	got, err := ExpandRemotes(context.Background(), provider, []string{"a.txt", "b/c.txt"})
+	if err != nil || strings.Join(got, ",") != "a.txt,b/c.txt" {
+		t.Errorf("ExpandRemotes() = %v, %v", got, err)
+	}
+
+	// Directories need a provider that can list them
+	if _, err := ExpandRemotes(context.Background(), provider, []string{"hw1/"}); err == nil {
+		t.Error("ExpandRemotes() of a directory succeeded without a Lister")
+	}
+}
@@ -0,0 +1,96 @@
+package upload
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Supported encryption methods
+const (
+	// EncryptionSSEC encrypts objects at rest with a customer-provided key (SSE-C)
+	// The object store encrypts with the key and discards it, so objects can only
+	// be read back by someone holding the same key.
+	EncryptionSSEC = "sse-c"
+)
+
+// SSECKeySize is the key size required by SSE-C (AES-256)
+const SSECKeySize = 32
+
+// Encryption holds the encryption applied to uploaded objects
+type Encryption struct {
+	Method      string
+	Key         []byte
+	Fingerprint string // "sha256:<hex>" of the key, safe to record
+}
+
+// ParseEncryption parses an --upload-encrypt value
+// Supported forms are "sse-c:file:<path>" (32 raw bytes, or 32 bytes as base64 or
+// hex) and "sse-c:env:<VAR>" (base64 or hex). Returns nil if spec is empty.
+func ParseEncryption(spec string) (*Encryption, error) {
+	if spec == "" {
+		return nil, nil
+	}
+
+	method, source, _ := strings.Cut(spec, ":")
+	switch method {
+	case EncryptionSSEC:
+	case "age":
+		return nil, fmt.Errorf("unsupported upload encryption %q: age encryption is not available, use %s", method, EncryptionSSEC)
+	default:
+		return nil, fmt.Errorf("unsupported upload encryption %q (must be %s)", method, EncryptionSSEC)
+	}
+
+	kind, location, ok := strings.Cut(source, ":")
+	if !ok || location == "" {
+		return nil, fmt.Errorf("invalid upload encryption %q: expected %s:file:<path> or %s:env:<VAR>", spec, EncryptionSSEC, EncryptionSSEC)
+	}
+
+	var raw []byte
+	switch kind {
+	case "file":
+		data, err := os.ReadFile(location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read encryption key: %w", err)
+		}
+		raw = data
+	case "env":
+		value, ok := os.LookupEnv(location)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("encryption key variable %s is not set", location)
+		}
+		raw = []byte(value)
+	default:
+		return nil, fmt.Errorf("invalid upload encryption key source %q (must be file or env)", kind)
+	}
+
+	key, err := decodeKey(raw)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(key)
+	return &Encryption{
+		Method:      method,
+		Key:         key,
+		Fingerprint: "sha256:" + hex.EncodeToString(sum[:]),
+	}, nil
+}
+
+// decodeKey accepts a raw, base64 or hex encoded 32-byte key
+func decodeKey(raw []byte) ([]byte, error) {
+	if len(raw) == SSECKeySize {
+		return raw, nil
+	}
+
+	text := strings.TrimSpace(string(raw))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == SSECKeySize {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == SSECKeySize {
+		return key, nil
+	}
+	return nil, fmt.Errorf("invalid encryption key: must be %d bytes (raw, base64 or hex)", SSECKeySize)
+}
@@ -0,0 +1,100 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// plainProvider is a provider without per-object options
+type plainProvider struct{ uploads int }
+
+func (p *plainProvider) Name() string                   { return "plain" }
+func (p *plainProvider) Configure(map[string]any) error { return nil }
+func (p *plainProvider) Upload(context.Context, io.Reader, string) error {
+	p.uploads++
+	return nil
+}
+
+func (p *plainProvider) Download(context.Context, string, io.Writer) error { return nil }
+
+func TestParseEncryption(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, SSECKeySize)
+	sum := sha256.Sum256(key)
+	fingerprint := "sha256:" + hex.EncodeToString(sum[:])
+
+	dir := t.TempDir()
+	rawFile := filepath.Join(dir, "raw.key")
+	_ = os.WriteFile(rawFile, key, 0600)
+	base64File := filepath.Join(dir, "base64.key")
+	_ = os.WriteFile(base64File, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600)
+	shortFile := filepath.Join(dir, "short.key")
+	_ = os.WriteFile(shortFile, []byte("too short"), 0600)
+	t.Setenv("GHOST_TEST_SSEC_KEY", hex.EncodeToString(key))
+
+	tests := []struct {
+		name    string
+		spec    string
+		wantNil bool
+		wantErr string
+	}{
+		{name: "disabled", spec: "", wantNil: true},
+		{name: "raw key file", spec: "sse-c:file:" + rawFile},
+		{name: "base64 key file", spec: "sse-c:file:" + base64File},
+		{name: "hex key in environment", spec: "sse-c:env:GHOST_TEST_SSEC_KEY"},
+		{name: "short key", spec: "sse-c:file:" + shortFile, wantErr: "must be 32 bytes"},
+		{name: "missing file", spec: "sse-c:file:" + filepath.Join(dir, "missing"), wantErr: "failed to read encryption key"},
+		{name: "unset variable", spec: "sse-c:env:GHOST_TEST_UNSET_KEY", wantErr: "is not set"},
+		{name: "missing source", spec: "sse-c", wantErr: "expected sse-c:file:<path>"},
+		{name: "unknown source", spec: "sse-c:vault:key", wantErr: "must be file or env"},
+		{name: "age", spec: "age:age1recipient", wantErr: "age encryption is not available"},
+		{name: "unknown method", spec: "aes:file:x", wantErr: "unsupported upload encryption"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encryption, err := ParseEncryption(tt.spec)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseEncryption() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseEncryption() error = %v", err)
+			}
+			if tt.wantNil {
+				if encryption != nil {
+					t.Errorf("Expected no encryption, got %+v", encryption)
+				}
+				return
+			}
+			if encryption.Method != EncryptionSSEC || !bytes.Equal(encryption.Key, key) {
+				t.Errorf("Encryption = %s with key %x, want %s with key %x", encryption.Method, encryption.Key, EncryptionSSEC, key)
+			}
+			if encryption.Fingerprint != fingerprint {
+				t.Errorf("Fingerprint = %s, want %s", encryption.Fingerprint, fingerprint)
+			}
+		})
+	}
+}
+
+func TestUploadWithOptionsEncryptionUnsupported(t *testing.T) {
+	provider := &plainProvider{}
+	encryption := &Encryption{Method: EncryptionSSEC, Key: make([]byte, SSECKeySize)}
+
+	err := UploadWithOptions(context.Background(), provider, strings.NewReader("x"), "out.txt", Options{Encryption: encryption})
+	if err == nil || !strings.Contains(err.Error(), "does not support encryption") {
+		t.Errorf("UploadWithOptions() error = %v, want unsupported encryption", err)
+	}
+	if provider.uploads != 0 {
+		t.Error("Expected nothing to be uploaded unencrypted")
+	}
+}
@@ -0,0 +1,60 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+)
+
+// Classes of upload errors, reported as uploads[].error_code
+const (
+	ErrorNetwork       = "network"        // the storage could not be reached, or the connection broke
+	ErrorThrottled     = "throttled"      // the storage asked to slow down
+	ErrorServer        = "server"         // the storage failed internally
+	ErrorAuth          = "auth"           // the credentials were rejected or lack permission
+	ErrorBucketMissing = "bucket_missing" // the bucket does not exist
+	ErrorQuota         = "quota"          // the storage or the bucket's quota is full
+	ErrorInvalid       = "invalid"        // the storage rejected the request itself
+	ErrorUnknown       = "unknown"        // anything not classified above
+)
+
+// ErrorClassifier is implemented by providers that can classify their own errors
+type ErrorClassifier interface {
+	// ClassifyError returns the class of an error returned by the provider, or ""
+	// if it does not recognize the error
+	ClassifyError(err error) string
+}
+
+// ClassifyError returns the class of an upload error
+// Errors the provider does not recognize are classified as network errors if a
+// connection failed, and unknown otherwise.
+func ClassifyError(provider Provider, err error) string {
+	if classifier, ok := provider.(ErrorClassifier); ok {
+		if class := classifier.ClassifyError(err); class != "" {
+			return class
+		}
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.As(err, &netErr),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, context.DeadlineExceeded):
+		return ErrorNetwork
+	}
+	return ErrorUnknown
+}
+
+// IsTransient reports whether an error of this class may succeed when retried
+// Unknown errors are retried, as they were before errors were classified.
+func IsTransient(class string) bool {
+	switch class {
+	case ErrorNetwork, ErrorThrottled, ErrorServer, ErrorUnknown:
+		return true
+	}
+	return false
+}
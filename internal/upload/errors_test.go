@@ -0,0 +1,62 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name      string
+		provider  Provider
+		err       error
+		wantClass string
+	}{
+		{name: "connection refused", provider: &MockProvider{}, err: fmt.Errorf("upload failed: %w", syscall.ECONNREFUSED), wantClass: ErrorNetwork},
+		{name: "dns failure", provider: &MockProvider{}, err: &net.DNSError{Err: "no such host", Name: "minio"}, wantClass: ErrorNetwork},
+		{name: "deadline", provider: &MockProvider{}, err: context.DeadlineExceeded, wantClass: ErrorNetwork},
+		{name: "unrecognized", provider: &MockProvider{}, err: errors.New("disk on fire"), wantClass: ErrorUnknown},
+		{name: "minio access denied", provider: &MinioProvider{}, err: minioError("AccessDenied", 403), wantClass: ErrorAuth},
+		{name: "minio bad signature", provider: &MinioProvider{}, err: minioError("SignatureDoesNotMatch", 403), wantClass: ErrorAuth},
+		{name: "minio missing bucket", provider: &MinioProvider{}, err: minioError("NoSuchBucket", 404), wantClass: ErrorBucketMissing},
+		{name: "minio bucket quota", provider: &MinioProvider{}, err: minioError("XMinioAdminBucketQuotaExceeded", 400), wantClass: ErrorQuota},
+		{name: "minio slow down", provider: &MinioProvider{}, err: minioError("SlowDownWrite", 503), wantClass: ErrorThrottled},
+		{name: "minio internal error", provider: &MinioProvider{}, err: minioError("InternalError", 500), wantClass: ErrorServer},
+		{name: "minio unknown server status", provider: &MinioProvider{}, err: minioError("", 502), wantClass: ErrorServer},
+		{name: "minio unknown client status", provider: &MinioProvider{}, err: minioError("", 400), wantClass: ErrorInvalid},
+		{name: "minio unauthorized status", provider: &MinioProvider{}, err: minioError("", 401), wantClass: ErrorAuth},
+		{name: "minio network error", provider: &MinioProvider{}, err: fmt.Errorf("upload failed: %w", syscall.ECONNRESET), wantClass: ErrorNetwork},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.provider, tt.err); got != tt.wantClass {
+				t.Errorf("ClassifyError() = %q, want %q", got, tt.wantClass)
+			}
+		})
+	}
+}
+
+// minioError wraps an S3 error response the way the provider returns it
+func minioError(code string, status int) error {
+	return fmt.Errorf("failed to upload to MinIO: %w", minio.ErrorResponse{Code: code, StatusCode: status})
+}
+
+func TestIsTransient(t *testing.T) {
+	for _, class := range []string{ErrorNetwork, ErrorThrottled, ErrorServer, ErrorUnknown} {
+		if !IsTransient(class) {
+			t.Errorf("IsTransient(%q) = false, want true", class)
+		}
+	}
+	for _, class := range []string{ErrorAuth, ErrorBucketMissing, ErrorQuota, ErrorInvalid} {
+		if IsTransient(class) {
+			t.Errorf("IsTransient(%q) = true, want false", class)
+		}
+	}
+}
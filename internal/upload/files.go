@@ -0,0 +1,124 @@
+package upload
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zinc-sig/ghost/internal/bytesize"
+)
+
+// Limits caps the size of the additional files ghost uploads (0 = unlimited)
+type Limits struct {
+	MaxFileSize  int64 // bytes per file
+	MaxTotalSize int64 // bytes across all files
+}
+
+// ParseLimits parses the per-file and total size limits such as 10MB ("" = unlimited)
+func ParseLimits(maxFileSize, maxTotalSize string) (Limits, error) {
+	var limits Limits
+	for _, limit := range []struct {
+		flag  string
+		value string
+		dest  *int64
+	}{
+		{"max file size", maxFileSize, &limits.MaxFileSize},
+		{"max total size", maxTotalSize, &limits.MaxTotalSize},
+	} {
+		if limit.value == "" {
+			continue
+		}
+		n, err := bytesize.Parse(strings.TrimSpace(limit.value))
+		if err != nil || n == 0 || n > 1<<62 {
+			return Limits{}, fmt.Errorf("invalid upload %s %q (e.g. 10MB, 512KiB)", limit.flag, limit.value)
+		}
+		*limit.dest = int64(n)
+	}
+	return limits, nil
+}
+
+// IsPattern reports whether a local upload path is a glob pattern
+func IsPattern(localPath string) bool {
+	return strings.ContainsAny(localPath, "*?[")
+}
+
+// ExpandPattern returns the files matching a glob pattern, sorted, with the
+// remote path of each
+// Matches are uploaded under remoteDir at their path relative to the
+// pattern's directory, or at their own path if remoteDir is empty. Matches
+// that are not regular files, including symbolic links, are quarantined:
+// they are returned in skipped rather than uploaded.
+func ExpandPattern(pattern, remoteDir string) (files map[string]string, skipped []string, err error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid upload file pattern %s: %w", pattern, err)
+	}
+	sort.Strings(matches)
+
+	base := patternDir(pattern)
+	files = make(map[string]string, len(matches))
+	for _, match := range matches {
+		info, err := os.Lstat(match)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to check upload file %s: %w", match, err)
+		}
+		if !info.Mode().IsRegular() {
+			skipped = append(skipped, match)
+			continue
+		}
+		remote := filepath.ToSlash(match)
+		if remoteDir != "" {
+			rel, err := filepath.Rel(base, match)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to resolve upload file %s: %w", match, err)
+			}
+			remote = path.Join(remoteDir, filepath.ToSlash(rel))
+		}
+		files[match] = remote
+	}
+	return files, skipped, nil
+}
+
+// patternDir returns the leading directories of a pattern without glob characters
+func patternDir(pattern string) string {
+	dir := filepath.Dir(pattern)
+	for IsPattern(dir) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// CheckFile checks that localPath is a regular file within the per-file limit,
+// and returns its size
+// Symbolic links are refused, so a submission cannot make ghost upload files
+// outside its own output, and devices such as /dev/zero never end.
+func (l Limits) CheckFile(localPath string) (int64, error) {
+	info, err := os.Lstat(localPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, fmt.Errorf("upload file does not exist: %s", localPath)
+		}
+		return 0, fmt.Errorf("failed to check upload file %s: %w", localPath, err)
+	}
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		return 0, fmt.Errorf("upload file %s is a symbolic link", localPath)
+	case !info.Mode().IsRegular():
+		return 0, fmt.Errorf("upload file %s is not a regular file", localPath)
+	case l.MaxFileSize > 0 && info.Size() > l.MaxFileSize:
+		return 0, fmt.Errorf("upload file %s is %s, over the limit of %s per file", localPath, bytesize.Format(info.Size()), bytesize.Format(l.MaxFileSize))
+	}
+	return info.Size(), nil
+}
+
+// CheckTotal checks the combined size of the files against the total limit
+func (l Limits) CheckTotal(total int64, count int) error {
+	if l.MaxTotalSize > 0 && total > l.MaxTotalSize {
+		return fmt.Errorf("%d upload files total %s, over the limit of %s", count, bytesize.Format(total), bytesize.Format(l.MaxTotalSize))
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package upload
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandPattern(t *testing.T) {
+	dir := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(dir, "out", "run1"), 0755)
+	_ = os.MkdirAll(filepath.Join(dir, "out", "run2"), 0755)
+	_ = os.WriteFile(filepath.Join(dir, "out", "run1", "plot.png"), []byte("1"), 0644)
+	_ = os.WriteFile(filepath.Join(dir, "out", "run2", "plot.png"), []byte("2"), 0644)
+	_ = os.Symlink("/etc/passwd", filepath.Join(dir, "out", "run3"))
+	_ = os.Mkdir(filepath.Join(dir, "out", "dir.png"), 0755)
+
+	tests := []struct {
+		name        string
+		pattern     string
+		remoteDir   string
+		want        map[string]string
+		wantSkipped []string
+	}{
+		{
+			name:      "relative to the pattern directory",
+			pattern:   filepath.Join(dir, "out", "*", "plot.png"),
+			remoteDir: "plots",
+			want: map[string]string{
+				filepath.Join(dir, "out", "run1", "plot.png"): "plots/run1/plot.png",
+				filepath.Join(dir, "out", "run2", "plot.png"): "plots/run2/plot.png",
+			},
+		},
+		{
+			name:    "own path without remote",
+			pattern: filepath.Join(dir, "out", "run1", "*.png"),
+			want: map[string]string{
+				filepath.Join(dir, "out", "run1", "plot.png"): filepath.ToSlash(filepath.Join(dir, "out", "run1", "plot.png")),
+			},
+		},
+		{
+			name:        "quarantines links and directories",
+			pattern:     filepath.Join(dir, "out", "*"),
+			remoteDir:   "out",
+			want:        map[string]string{},
+			wantSkipped: []string{filepath.Join(dir, "out", "dir.png"), filepath.Join(dir, "out", "run1"), filepath.Join(dir, "out", "run2"), filepath.Join(dir, "out", "run3")},
+		},
+		{
+			name:    "no matches",
+			pattern: filepath.Join(dir, "*.txt"),
+			want:    map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, skipped, err := ExpandPattern(tt.pattern, tt.remoteDir)
+			if err != nil {
+				t.Fatalf("ExpandPattern() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ExpandPattern() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(skipped, tt.wantSkipped) {
+				t.Errorf("skipped = %v, want %v", skipped, tt.wantSkipped)
+			}
+		})
+	}
+
+	if _, _, err := ExpandPattern("[", ""); err == nil {
+		t.Error("ExpandPattern() accepted a malformed pattern")
+	}
+}
+
+func TestLimits(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.txt")
+	_ = os.WriteFile(file, []byte(strings.Repeat("x", 2048)), 0644)
+	link := filepath.Join(dir, "link.txt")
+	_ = os.Symlink(file, link)
+
+	tests := []struct {
+		name    string
+		limits  Limits
+		path    string
+		wantErr string
+	}{
+		{name: "unlimited", path: file},
+		{name: "within limit", limits: Limits{MaxFileSize: 2048}, path: file},
+		{name: "over limit", limits: Limits{MaxFileSize: 1024}, path: file, wantErr: "is 2.0 KB, over the limit of 1.0 KB per file"},
+		{name: "symbolic link", path: link, wantErr: "is a symbolic link"},
+		{name: "directory", path: dir, wantErr: "is not a regular file"},
+		{name: "missing", path: filepath.Join(dir, "missing"), wantErr: "upload file does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size, err := tt.limits.CheckFile(tt.path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("CheckFile() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || size != 2048 {
+				t.Errorf("CheckFile() = %d, %v", size, err)
+			}
+		})
+	}
+
+	if err := (Limits{MaxTotalSize: 4096}).CheckTotal(4096, 2); err != nil {
+		t.Errorf("CheckTotal() error = %v", err)
+	}
+	if err := (Limits{MaxTotalSize: 4096}).CheckTotal(4097, 2); err == nil || !strings.Contains(err.Error(), "2 upload files total") {
+		t.Errorf("CheckTotal() error = %v", err)
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits("10MB", "1GiB")
+	if err != nil || limits != (Limits{MaxFileSize: 10_000_000, MaxTotalSize: 1 << 30}) {
+		t.Errorf("ParseLimits() = %+v, %v", limits, err)
+	}
+	if limits, err := ParseLimits("", ""); err != nil || limits != (Limits{}) {
+		t.Errorf("ParseLimits() = %+v, %v, want unlimited", limits, err)
+	}
+	for _, value := range []string{"0", "huge", "-1MB"} {
+		if _, err := ParseLimits(value, ""); err == nil {
+			t.Errorf("ParseLimits(%q) accepted an invalid size", value)
+		}
+	}
+}
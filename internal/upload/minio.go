@@ -2,21 +2,103 @@ package upload
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/zinc-sig/ghost/internal/bytesize"
+)
+
+// Multipart part size limits imposed by S3
+const (
+	MinioMinPartSize = 5 << 20 // 5 MiB
+	MinioMaxPartSize = 5 << 30 // 5 GiB
 )
 
 // MinioProvider implements the Provider interface for MinIO/S3 storage
 type MinioProvider struct {
-	client *minio.Client
-	bucket string
-	prefix string
+	client   *minio.Client
+	bucket   string
+	prefix   string
+	metadata map[string]string // user metadata added to every object
+	ttlDays  int               // retention tagged on every object (0 = none)
+	transfer minioTransfer
+}
+
+// minioTransfer holds multipart and checksum tuning for uploads
+type minioTransfer struct {
+	partSize    uint64             // 0 = minio-go default
+	concurrency uint               // parallel part uploads, 0 = minio-go default
+	checksum    minio.ChecksumType // checksum added to each upload (ChecksumNone = CRC32C default)
+	contentMD5  bool               // send Content-MD5 instead of a checksum header
+}
+
+// parseMinioTransfer reads the optional part_size, upload_concurrency and checksum keys
+func parseMinioTransfer(config map[string]any) (minioTransfer, error) {
+	var transfer minioTransfer
+
+	if val, ok := config["part_size"]; ok {
+		size, err := bytesize.Parse(val)
+		if err != nil {
+			return transfer, fmt.Errorf("minio: invalid part_size: %w", err)
+		}
+		if size < MinioMinPartSize || size > MinioMaxPartSize {
+			return transfer, fmt.Errorf("minio: part_size must be between 5MiB and 5GiB, got %d bytes", size)
+		}
+		transfer.partSize = size
+	}
+
+	if val, ok := config["upload_concurrency"]; ok {
+		n, err := parseIntValue(val)
+		if err != nil || n < 1 || n > 1024 {
+			return transfer, fmt.Errorf("minio: upload_concurrency must be between 1 and 1024, got %v", val)
+		}
+		transfer.concurrency = uint(n)
+	}
+
+	if val, ok := getStringValue(config, "checksum"); ok {
+		switch strings.ToLower(val) {
+		case "", "crc32c":
+			transfer.checksum = minio.ChecksumCRC32C
+		case "crc32":
+			transfer.checksum = minio.ChecksumCRC32
+		case "crc64nvme":
+			transfer.checksum = minio.ChecksumCRC64NVME
+		case "sha1":
+			transfer.checksum = minio.ChecksumSHA1
+		case "sha256":
+			transfer.checksum = minio.ChecksumSHA256
+		case "md5":
+			transfer.contentMD5 = true
+		default:
+			return transfer, fmt.Errorf("minio: unsupported checksum %q (must be crc32c, crc32, crc64nvme, sha1, sha256 or md5)", val)
+		}
+	}
+
+	return transfer, nil
+}
+
+// putOptions applies the transfer tuning for an object of the given size (-1 = unknown)
+func (t minioTransfer) putOptions(opts *minio.PutObjectOptions, size int64) {
+	opts.PartSize = t.partSize
+	opts.NumThreads = t.concurrency
+	opts.AutoChecksum = t.checksum
+	opts.SendContentMd5 = t.contentMD5
+
+	// Streams of unknown length are uploaded one part at a time unless parts are
+	// buffered (concurrency × part size bytes of memory) and sent in parallel
+	if size < 0 && t.concurrency > 1 {
+		opts.ConcurrentStreamParts = true
+	}
 }
 
 // NewMinioProvider creates a new MinioProvider
@@ -71,6 +153,21 @@ func (m *MinioProvider) Configure(config map[string]any) error {
 	// Optional configuration with defaults
 	region := getStringValueWithDefault(config, "region", "us-east-1")
 	prefix := getStringValueWithDefault(config, "prefix", "")
+	transfer, err := parseMinioTransfer(config)
+	if err != nil {
+		return err
+	}
+	metadata, err := ParseMetadata(config["metadata"])
+	if err != nil {
+		return fmt.Errorf("minio: invalid metadata: %w", err)
+	}
+	var ttlDays int
+	if val, ok := config["artifact_ttl"]; ok && val != "" {
+		ttlDays, err = ParseTTL(val)
+		if err != nil {
+			return fmt.Errorf("minio: %w", err)
+		}
+	}
 
 	// Create MinIO client
 	client, err := minio.New(endpoint, &minio.Options{
@@ -85,6 +182,9 @@ func (m *MinioProvider) Configure(config map[string]any) error {
 	m.client = client
 	m.bucket = bucket
 	m.prefix = prefix
+	m.metadata = metadata
+	m.ttlDays = ttlDays
+	m.transfer = transfer
 
 	// Check if bucket exists
 	ctx := context.Background()
@@ -101,19 +201,42 @@ func (m *MinioProvider) Configure(config map[string]any) error {
 
 // Upload uploads content from reader to MinIO
 func (m *MinioProvider) Upload(ctx context.Context, reader io.Reader, remotePath string) error {
+	return m.UploadWithOptions(ctx, reader, remotePath, Options{})
+}
+
+// UploadWithOptions uploads content from reader to MinIO with object metadata
+func (m *MinioProvider) UploadWithOptions(ctx context.Context, reader io.Reader, remotePath string, opts Options) error {
 	if m.client == nil {
 		return fmt.Errorf("minio: provider not configured")
 	}
 
-	// Combine prefix with remote path
-	objectName := remotePath
-	if m.prefix != "" {
-		objectName = filepath.Join(m.prefix, remotePath)
+	objectName := m.objectName(remotePath)
+	size := opts.Size
+	if size <= 0 {
+		size = -1 // unknown, streamed
 	}
 
-	// Upload the content
-	// -1 means unknown size, MinIO will handle streaming
-	_, err := m.client.PutObject(ctx, m.bucket, objectName, reader, -1, minio.PutObjectOptions{})
+	putOpts := minio.PutObjectOptions{
+		ContentEncoding: opts.ContentEncoding,
+		ContentType:     opts.ContentType,
+		UserMetadata:    MergeMetadata(m.metadata, opts.Metadata),
+	}
+	m.transfer.putOptions(&putOpts, size)
+	if m.ttlDays > 0 {
+		// Expired by a bucket lifecycle rule filtering on the tag
+		putOpts.UserTags = map[string]string{TTLTag: TTLTagValue(m.ttlDays)}
+	}
+	if opts.Encryption != nil {
+		sse, err := encrypt.NewSSEC(opts.Encryption.Key)
+		if err != nil {
+			return fmt.Errorf("minio: invalid encryption key: %w", err)
+		}
+		putOpts.ServerSideEncryption = sse
+	}
+
+	// Upload the content; a known size lets MinIO pick part sizes and upload
+	// parts of a file in parallel
+	_, err := m.client.PutObject(ctx, m.bucket, objectName, reader, size, putOpts)
 	if err != nil {
 		return fmt.Errorf("minio: failed to upload to %s: %w", objectName, err)
 	}
@@ -121,6 +244,155 @@ func (m *MinioProvider) Upload(ctx context.Context, reader io.Reader, remotePath
 	return nil
 }
 
+// Download writes the content of an object in MinIO to writer
+func (m *MinioProvider) Download(ctx context.Context, remotePath string, writer io.Writer) error {
+	if m.client == nil {
+		return fmt.Errorf("minio: provider not configured")
+	}
+
+	objectName := m.objectName(remotePath)
+	object, err := m.client.GetObject(ctx, m.bucket, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("minio: failed to download %s: %w", objectName, err)
+	}
+	defer func() { _ = object.Close() }()
+
+	if _, err := io.Copy(writer, object); err != nil {
+		return fmt.Errorf("minio: failed to download %s: %w", objectName, err)
+	}
+	return nil
+}
+
+// Stat describes an object in MinIO
+func (m *MinioProvider) Stat(ctx context.Context, remotePath string) (ObjectInfo, error) {
+	if m.client == nil {
+		return ObjectInfo{}, fmt.Errorf("minio: provider not configured")
+	}
+
+	objectName := m.objectName(remotePath)
+	info, err := m.client.StatObject(ctx, m.bucket, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == minio.NoSuchKey {
+			return ObjectInfo{}, fmt.Errorf("minio: %s: %w", objectName, ErrNotExist)
+		}
+		return ObjectInfo{}, fmt.Errorf("minio: failed to stat %s: %w", objectName, err)
+	}
+	return ObjectInfo{Size: info.Size, ModTime: info.LastModified, ETag: info.ETag}, nil
+}
+
+// Delete removes an object from MinIO
+func (m *MinioProvider) Delete(ctx context.Context, remotePath string) error {
+	if m.client == nil {
+		return fmt.Errorf("minio: provider not configured")
+	}
+
+	objectName := m.objectName(remotePath)
+	if err := m.client.RemoveObject(ctx, m.bucket, objectName, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("minio: failed to delete %s: %w", objectName, err)
+	}
+	return nil
+}
+
+// List returns the remote paths of the objects under a remote directory
+func (m *MinioProvider) List(ctx context.Context, remoteDir string) ([]string, error) {
+	if m.client == nil {
+		return nil, fmt.Errorf("minio: provider not configured")
+	}
+
+	// The trailing slash keeps hw1/ from matching hw10/
+	objectPrefix := strings.Trim(m.objectName(remoteDir), "/")
+	if objectPrefix != "" {
+		objectPrefix += "/"
+	}
+
+	var remotePaths []string
+	for object := range m.client.ListObjects(ctx, m.bucket, minio.ListObjectsOptions{Prefix: objectPrefix, Recursive: true}) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("minio: failed to list %s: %w", objectPrefix, object.Err)
+		}
+		remotePath := object.Key
+		if m.prefix != "" {
+			remotePath = strings.TrimPrefix(remotePath, strings.TrimSuffix(m.prefix, "/")+"/")
+		}
+		remotePaths = append(remotePaths, remotePath)
+	}
+	return remotePaths, nil
+}
+
+// PresignGet returns a presigned GET URL for an uploaded object
+func (m *MinioProvider) PresignGet(ctx context.Context, remotePath string, expiry time.Duration) (string, error) {
+	if m.client == nil {
+		return "", fmt.Errorf("minio: provider not configured")
+	}
+	objectName := m.objectName(remotePath)
+	u, err := m.client.PresignedGetObject(ctx, m.bucket, objectName, expiry, nil)
+	if err != nil {
+		return "", fmt.Errorf("minio: failed to presign %s: %w", objectName, err)
+	}
+	return u.String(), nil
+}
+
+// objectName combines the configured prefix with a remote path
+func (m *MinioProvider) objectName(remotePath string) string {
+	if m.prefix == "" {
+		return remotePath
+	}
+	return filepath.Join(m.prefix, remotePath)
+}
+
+// minioErrorClasses maps S3 error codes to the class of the error
+var minioErrorClasses = map[string]string{
+	"AccessDenied":                    ErrorAuth,
+	"InvalidAccessKeyId":              ErrorAuth,
+	"SignatureDoesNotMatch":           ErrorAuth,
+	"ExpiredToken":                    ErrorAuth,
+	"InvalidToken":                    ErrorAuth,
+	"NoSuchBucket":                    ErrorBucketMissing,
+	"QuotaExceeded":                   ErrorQuota,
+	"XMinioAdminBucketQuotaExceeded":  ErrorQuota,
+	"XMinioStorageFull":               ErrorQuota,
+	"SlowDown":                        ErrorThrottled,
+	"SlowDownWrite":                   ErrorThrottled,
+	"SlowDownRead":                    ErrorThrottled,
+	"Throttling":                      ErrorThrottled,
+	"RequestLimitExceeded":            ErrorThrottled,
+	"XMinioServerNotInitialized":      ErrorServer,
+	"InternalError":                   ErrorServer,
+	"ServiceUnavailable":              ErrorServer,
+	"RequestTimeout":                  ErrorNetwork,
+	"XAmzContentSHA256Mismatch":       ErrorNetwork, // the body was corrupted in transit
+	"IncompleteBody":                  ErrorNetwork,
+	"XMinioInvalidObjectName":         ErrorInvalid,
+	"InvalidArgument":                 ErrorInvalid,
+	"EntityTooLarge":                  ErrorInvalid,
+	"InvalidEncryptionAlgorithmError": ErrorInvalid,
+}
+
+// ClassifyError classifies the S3 error responses of MinIO by their code, or
+// their HTTP status for codes it does not know
+func (m *MinioProvider) ClassifyError(err error) string {
+	var response minio.ErrorResponse
+	if !errors.As(err, &response) {
+		return ""
+	}
+	if class, ok := minioErrorClasses[response.Code]; ok {
+		return class
+	}
+	switch status := response.StatusCode; {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return ErrorAuth
+	case status == http.StatusTooManyRequests:
+		return ErrorThrottled
+	case status == http.StatusInsufficientStorage:
+		return ErrorQuota
+	case status >= 500:
+		return ErrorServer
+	case status >= 400:
+		return ErrorInvalid
+	}
+	return ""
+}
+
 // Helper functions to extract values from config map
 func getStringValue(config map[string]any, key string) (string, bool) {
 	if val, ok := config[key]; ok {
@@ -151,3 +423,18 @@ func getBoolValue(config map[string]any, key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+// parseIntValue accepts a whole number from JSON/YAML (int or float64) or a decimal string
+func parseIntValue(val any) (int, error) {
+	switch v := val.(type) {
+	case int:
+		return v, nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	case string:
+		return strconv.Atoi(strings.TrimSpace(v))
+	}
+	return 0, fmt.Errorf("%v is not a whole number", val)
+}
@@ -0,0 +1,29 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// MaxPresignExpiry is the longest validity of a presigned URL (the S3 SigV4 limit)
+const MaxPresignExpiry = 7 * 24 * time.Hour
+
+// Presigner is implemented by providers that can create temporary download links
+type Presigner interface {
+	// PresignGet returns a URL that downloads the object at remotePath without
+	// credentials until expiry has passed
+	PresignGet(ctx context.Context, remotePath string, expiry time.Duration) (string, error)
+}
+
+// ParsePresignExpiry parses the validity of presigned URLs, such as 24h
+func ParsePresignExpiry(value string) (time.Duration, error) {
+	expiry, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid presign expiry %q: %w", value, err)
+	}
+	if expiry < time.Second || expiry > MaxPresignExpiry {
+		return 0, fmt.Errorf("presign expiry must be between 1s and 168h, got %s", value)
+	}
+	return expiry, nil
+}
@@ -0,0 +1,35 @@
+package upload
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePresignExpiry(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "24h", want: 24 * time.Hour},
+		{value: "90m", want: 90 * time.Minute},
+		{value: "168h", want: MaxPresignExpiry},
+		{value: "169h", wantErr: true},
+		{value: "0s", wantErr: true},
+		{value: "-1h", wantErr: true},
+		{value: "7d", wantErr: true},
+		{value: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParsePresignExpiry(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePresignExpiry(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePresignExpiry(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
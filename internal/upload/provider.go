@@ -10,6 +10,9 @@ type Provider interface {
 	// Upload uploads content from reader to the remote path
 	Upload(ctx context.Context, reader io.Reader, remotePath string) error
 
+	// Download writes the content at the remote path to writer
+	Download(ctx context.Context, remotePath string, writer io.Writer) error
+
 	// Configure sets up the provider with the given configuration
 	Configure(config map[string]any) error
 
@@ -2,9 +2,12 @@ package upload
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"strings"
 	"testing"
+
+	"github.com/minio/minio-go/v7"
 )
 
 // MockProvider implements Provider for testing
@@ -54,6 +57,16 @@ func (m *MockProvider) Upload(ctx context.Context, reader io.Reader, remotePath
 	return nil
 }
 
+func (m *MockProvider) Download(ctx context.Context, remotePath string, writer io.Writer) error {
+	for i := len(m.uploads) - 1; i >= 0; i-- {
+		if m.uploads[i].remotePath == remotePath {
+			_, err := io.WriteString(writer, m.uploads[i].content)
+			return err
+		}
+	}
+	return fmt.Errorf("%s not found", remotePath)
+}
+
 func TestProviderRegistry(t *testing.T) {
 	// Test registering a provider
 	testProviderName := "test-provider"
@@ -277,6 +290,66 @@ func TestMinioProviderConfigValidation(t *testing.T) {
 			expectErr: true,
 			errMsg:    "invalid endpoint URL",
 		},
+		{
+			name: "part_size too small",
+			config: map[string]any{
+				"endpoint":   "localhost:9000",
+				"access_key": "minioadmin",
+				"secret_key": "minioadmin",
+				"bucket":     "test",
+				"part_size":  "1MiB",
+			},
+			expectErr: true,
+			errMsg:    "part_size must be between 5MiB and 5GiB",
+		},
+		{
+			name: "invalid upload_concurrency",
+			config: map[string]any{
+				"endpoint":           "localhost:9000",
+				"access_key":         "minioadmin",
+				"secret_key":         "minioadmin",
+				"bucket":             "test",
+				"upload_concurrency": 0,
+			},
+			expectErr: true,
+			errMsg:    "upload_concurrency must be between 1 and 1024",
+		},
+		{
+			name: "unsupported checksum",
+			config: map[string]any{
+				"endpoint":   "localhost:9000",
+				"access_key": "minioadmin",
+				"secret_key": "minioadmin",
+				"bucket":     "test",
+				"checksum":   "md4",
+			},
+			expectErr: true,
+			errMsg:    "unsupported checksum",
+		},
+		{
+			name: "invalid metadata",
+			config: map[string]any{
+				"endpoint":   "localhost:9000",
+				"access_key": "minioadmin",
+				"secret_key": "minioadmin",
+				"bucket":     "test",
+				"metadata":   map[string]any{"student id": "s1"},
+			},
+			expectErr: true,
+			errMsg:    "minio: invalid metadata",
+		},
+		{
+			name: "invalid artifact TTL",
+			config: map[string]any{
+				"endpoint":     "localhost:9000",
+				"access_key":   "minioadmin",
+				"secret_key":   "minioadmin",
+				"bucket":       "test",
+				"artifact_ttl": "7 days",
+			},
+			expectErr: true,
+			errMsg:    "minio: invalid artifact TTL",
+		},
 	}
 
 	for _, tt := range tests {
@@ -296,3 +369,105 @@ func TestMinioProviderConfigValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestParseMinioTransfer(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    map[string]any
+		want      minioTransfer
+		expectErr bool
+	}{
+		{
+			name:   "defaults",
+			config: map[string]any{},
+			want:   minioTransfer{},
+		},
+		{
+			name:   "binary units",
+			config: map[string]any{"part_size": "64MiB", "upload_concurrency": 8},
+			want:   minioTransfer{partSize: 64 << 20, concurrency: 8},
+		},
+		{
+			name:   "decimal units and JSON numbers",
+			config: map[string]any{"part_size": "100MB", "upload_concurrency": float64(4)},
+			want:   minioTransfer{partSize: 100e6, concurrency: 4},
+		},
+		{
+			name:   "plain byte count",
+			config: map[string]any{"part_size": 16777216},
+			want:   minioTransfer{partSize: 16 << 20},
+		},
+		{
+			name:   "sha256 checksum",
+			config: map[string]any{"checksum": "SHA256"},
+			want:   minioTransfer{checksum: minio.ChecksumSHA256},
+		},
+		{
+			name:   "md5 checksum",
+			config: map[string]any{"checksum": "md5"},
+			want:   minioTransfer{contentMD5: true},
+		},
+		{
+			name:      "part_size too large",
+			config:    map[string]any{"part_size": "6GiB"},
+			expectErr: true,
+		},
+		{
+			name:      "part_size not a size",
+			config:    map[string]any{"part_size": "big"},
+			expectErr: true,
+		},
+		{
+			name:      "fractional concurrency",
+			config:    map[string]any{"upload_concurrency": 2.5},
+			expectErr: true,
+		},
+		{
+			name:   "string concurrency",
+			config: map[string]any{"upload_concurrency": "16"},
+			want:   minioTransfer{concurrency: 16},
+		},
+		{
+			name:      "concurrency with a size unit",
+			config:    map[string]any{"upload_concurrency": "1KiB"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMinioTransfer(tt.config)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("Expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parseMinioTransfer() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinioTransferPutOptions(t *testing.T) {
+	transfer := minioTransfer{partSize: 16 << 20, concurrency: 4, checksum: minio.ChecksumCRC32}
+
+	var known minio.PutObjectOptions
+	transfer.putOptions(&known, 1024)
+	if known.PartSize != 16<<20 || known.NumThreads != 4 || known.AutoChecksum != minio.ChecksumCRC32 {
+		t.Errorf("Unexpected options for known size: %+v", known)
+	}
+	if known.ConcurrentStreamParts {
+		t.Error("Known sizes should not buffer stream parts")
+	}
+
+	var streamed minio.PutObjectOptions
+	transfer.putOptions(&streamed, -1)
+	if !streamed.ConcurrentStreamParts {
+		t.Error("Unknown sizes with concurrency should upload stream parts concurrently")
+	}
+}
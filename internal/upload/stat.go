@@ -0,0 +1,42 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrNotExist is returned by Stat when nothing is stored at the remote path
+var ErrNotExist = errors.New("remote file does not exist")
+
+// ObjectInfo describes a stored file
+type ObjectInfo struct {
+	Size    int64
+	ModTime time.Time
+	ETag    string
+}
+
+// StatProvider is implemented by providers that can describe stored files
+type StatProvider interface {
+	// Stat returns the description of the file at remotePath, or an error
+	// wrapping ErrNotExist if there is none
+	Stat(ctx context.Context, remotePath string) (ObjectInfo, error)
+}
+
+// DeleteProvider is implemented by providers that can remove stored files
+type DeleteProvider interface {
+	// Delete removes the file at remotePath; removing a missing file is not an error
+	Delete(ctx context.Context, remotePath string) error
+}
+
+// Exists reports whether a file is stored at remotePath
+func Exists(ctx context.Context, provider StatProvider, remotePath string) (bool, error) {
+	_, err := provider.Stat(ctx, remotePath)
+	if errors.Is(err, ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
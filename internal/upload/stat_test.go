@@ -0,0 +1,40 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// statProvider is a StatProvider over a fixed set of files
+type statProvider struct {
+	files map[string]int64
+	err   error
+}
+
+func (p *statProvider) Stat(ctx context.Context, remotePath string) (ObjectInfo, error) {
+	if p.err != nil {
+		return ObjectInfo{}, p.err
+	}
+	size, ok := p.files[remotePath]
+	if !ok {
+		return ObjectInfo{}, fmt.Errorf("stat %s: %w", remotePath, ErrNotExist)
+	}
+	return ObjectInfo{Size: size}, nil
+}
+
+func TestExists(t *testing.T) {
+	provider := &statProvider{files: map[string]int64{"hw1/out.txt": 3}}
+	if exists, err := Exists(context.Background(), provider, "hw1/out.txt"); !exists || err != nil {
+		t.Errorf("Exists() of a stored file = %v, %v", exists, err)
+	}
+	if exists, err := Exists(context.Background(), provider, "hw1/err.txt"); exists || err != nil {
+		t.Errorf("Exists() of a missing file = %v, %v", exists, err)
+	}
+
+	provider.err = errors.New("connection refused")
+	if _, err := Exists(context.Background(), provider, "hw1/out.txt"); err == nil {
+		t.Error("Exists() hid the error of Stat")
+	}
+}
@@ -0,0 +1,45 @@
+package upload
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TTLTag is the object tag carrying the retention of an uploaded artifact
+// Bucket lifecycle rules filter on it to expire objects, e.g. tag ghost-ttl=7d
+// with an expiration of 7 days.
+const TTLTag = "ghost-ttl"
+
+const day = 24 * time.Hour
+
+// ParseTTL parses an artifact retention such as 7d, 36h or 90m
+// Lifecycle rules expire objects in whole days, so the retention is rounded up to
+// days. Returns the number of days.
+func ParseTTL(value any) (int, error) {
+	s := strings.TrimSpace(fmt.Sprint(value))
+	var ttl time.Duration
+	if n, ok := strings.CutSuffix(s, "d"); ok {
+		days, err := strconv.Atoi(n)
+		if err != nil {
+			return 0, fmt.Errorf("invalid artifact TTL %q: expected days (7d) or a duration (36h)", s)
+		}
+		ttl = time.Duration(days) * day
+	} else {
+		var err error
+		ttl, err = time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid artifact TTL %q: expected days (7d) or a duration (36h)", s)
+		}
+	}
+	if ttl <= 0 {
+		return 0, fmt.Errorf("artifact TTL must be positive, got %q", s)
+	}
+	return int((ttl + day - 1) / day), nil
+}
+
+// TTLTagValue returns the TTLTag value of a retention in days
+func TTLTagValue(days int) string {
+	return strconv.Itoa(days) + "d"
+}
@@ -0,0 +1,38 @@
+package upload
+
+import "testing"
+
+func TestParseTTL(t *testing.T) {
+	tests := []struct {
+		value   any
+		want    int
+		wantErr bool
+	}{
+		{value: "7d", want: 7},
+		{value: "1d", want: 1},
+		{value: "48h", want: 2},
+		{value: "36h", want: 2},
+		{value: "90m", want: 1},
+		{value: " 30d ", want: 30},
+		{value: "0d", wantErr: true},
+		{value: "-1d", wantErr: true},
+		{value: "-5h", wantErr: true},
+		{value: "7days", wantErr: true},
+		{value: "d", wantErr: true},
+		{value: "", wantErr: true},
+		{value: 7, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTTL(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseTTL(%v) = %d, want an error", tt.value, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseTTL(%v) = %d, %v, want %d", tt.value, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Package validate reports problems in ghost input files with their position
+// so malformed configs can be caught before anything is executed.
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is a single validation error, positioned in the file when known
+type Problem struct {
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error formats the problem as "line L, column C: message"
+func (p Problem) Error() string {
+	switch {
+	case p.Line > 0 && p.Column > 0:
+		return fmt.Sprintf("line %d, column %d: %s", p.Line, p.Column, p.Message)
+	case p.Line > 0:
+		return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+	default:
+		return p.Message
+	}
+}
+
+// At returns a problem positioned at a YAML node (nil = unknown position)
+func At(node *yaml.Node, format string, args ...any) Problem {
+	p := Problem{Message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		p.Line, p.Column = node.Line, node.Column
+	}
+	return p
+}
+
+var yamlLinePattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// FromError converts a parse error into problems
+// data is the parsed content, used to turn JSON byte offsets into line and
+// column. Joined errors and YAML type errors yield one problem per error.
+func FromError(err error, data []byte) []Problem {
+	if err == nil {
+		return nil
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var problems []Problem
+		for _, e := range joined.Unwrap() {
+			problems = append(problems, FromError(e, data)...)
+		}
+		return problems
+	}
+
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		problems := make([]Problem, 0, len(typeErr.Errors))
+		for _, msg := range typeErr.Errors {
+			problems = append(problems, fromYAMLMessage(msg))
+		}
+		return problems
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		line, column := Position(data, syntaxErr.Offset)
+		return []Problem{{Line: line, Column: column, Message: syntaxErr.Error()}}
+	}
+	var unmarshalErr *json.UnmarshalTypeError
+	if errors.As(err, &unmarshalErr) {
+		line, column := Position(data, unmarshalErr.Offset)
+		return []Problem{{Line: line, Column: column, Message: unmarshalErr.Error()}}
+	}
+
+	var problem Problem
+	if errors.As(err, &problem) {
+		return []Problem{problem}
+	}
+	return []Problem{fromYAMLMessage(err.Error())}
+}
+
+// fromYAMLMessage extracts the line number from a "yaml: line N: ..." message
+func fromYAMLMessage(msg string) Problem {
+	if match := yamlLinePattern.FindStringSubmatch(msg); match != nil {
+		line, _ := strconv.Atoi(match[1])
+		return Problem{Line: line, Message: match[2]}
+	}
+	return Problem{Message: msg}
+}
+
+// Position converts a byte offset into a 1-based line and column
+// JSON decoders report the offset just past the offending byte.
+func Position(data []byte, offset int64) (line, column int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	line, column = 1, 1
+	for _, b := range data[:max(offset-1, 0)] {
+		if b == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
+
+// ReadJSONFile reads and parses a JSON file
+func ReadJSONFile(path string) (any, []Problem) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, []Problem{{Message: err.Error()}}
+	}
+
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, FromError(err, data)
+	}
+	return value, nil
+}
+
+// ReadYAMLFile reads and parses a YAML (or JSON) file into its document node
+func ReadYAMLFile(path string) (*yaml.Node, []Problem) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, []Problem{{Message: err.Error()}}
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, FromError(err, data)
+	}
+	if len(doc.Content) == 0 {
+		return nil, []Problem{{Message: "file is empty"}}
+	}
+	return doc.Content[0], nil
+}
@@ -0,0 +1,118 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestPosition(t *testing.T) {
+	data := []byte("{\n  \"a\": 1,\n  \"b\": }\n")
+	tests := []struct {
+		offset     int64
+		wantLine   int
+		wantColumn int
+	}{
+		{offset: 1, wantLine: 1, wantColumn: 1},
+		{offset: 5, wantLine: 2, wantColumn: 3},
+		{offset: 20, wantLine: 3, wantColumn: 8},
+		{offset: 1000, wantLine: 3, wantColumn: 9},
+	}
+
+	for _, tt := range tests {
+		line, column := Position(data, tt.offset)
+		if line != tt.wantLine || column != tt.wantColumn {
+			t.Errorf("Position(%d) = %d:%d, want %d:%d", tt.offset, line, column, tt.wantLine, tt.wantColumn)
+		}
+	}
+}
+
+func TestFromError(t *testing.T) {
+	data := []byte("{\n  \"a\": 1,\n  \"b\": }\n")
+	var value any
+	jsonErr := json.Unmarshal(data, &value)
+
+	var cases struct {
+		Cases []struct {
+			Command []string `yaml:"command"`
+		} `yaml:"cases"`
+	}
+	yamlErr := yaml.Unmarshal([]byte("cases:\n  - command: 1\n  - command: {}\n"), &cases)
+
+	tests := []struct {
+		name string
+		err  error
+		want []Problem
+	}{
+		{
+			name: "json syntax error",
+			err:  jsonErr,
+			want: []Problem{{Line: 3, Column: 8, Message: "invalid character '}' looking for beginning of value"}},
+		},
+		{
+			name: "yaml type errors",
+			err:  yamlErr,
+			want: []Problem{
+				{Line: 2, Message: "cannot unmarshal !!int `1` into []string"},
+				{Line: 3, Message: "cannot unmarshal !!map into []string"},
+			},
+		},
+		{
+			name: "yaml syntax error",
+			err:  errors.New("yaml: line 4: mapping values are not allowed in this context"),
+			want: []Problem{{Line: 4, Message: "mapping values are not allowed in this context"}},
+		},
+		{
+			name: "joined errors",
+			err:  errors.Join(errors.New("first"), Problem{Line: 2, Message: "second"}),
+			want: []Problem{{Message: "first"}, {Line: 2, Message: "second"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FromError(tt.err, data)
+			if len(got) != len(tt.want) {
+				t.Fatalf("FromError() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Problem %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadYAMLFile(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.yaml")
+	_ = os.WriteFile(empty, nil, 0644)
+
+	if _, problems := ReadYAMLFile(empty); len(problems) != 1 || problems[0].Message != "file is empty" {
+		t.Errorf("Empty file problems = %+v", problems)
+	}
+	if _, problems := ReadYAMLFile(filepath.Join(dir, "missing.yaml")); len(problems) != 1 {
+		t.Errorf("Missing file problems = %+v", problems)
+	}
+}
+
+func TestProblemError(t *testing.T) {
+	tests := []struct {
+		problem Problem
+		want    string
+	}{
+		{Problem{Line: 3, Column: 8, Message: "bad"}, "line 3, column 8: bad"},
+		{Problem{Line: 3, Message: "bad"}, "line 3: bad"},
+		{Problem{Message: "bad"}, "bad"},
+	}
+	for _, tt := range tests {
+		if got := tt.problem.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
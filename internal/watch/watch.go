@@ -0,0 +1,96 @@
+// Package watch polls files for changes, to re-run a command while it is being edited
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Snapshot is the state of the watched files, by path
+type Snapshot map[string]fileState
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	mode    fs.FileMode
+}
+
+// Take records the state of every file under paths
+// Directories are walked, skipping hidden ones such as .git; missing paths are
+// left out, so creating them later counts as a change.
+func Take(paths []string) Snapshot {
+	snapshot := Snapshot{}
+	for _, path := range paths {
+		_ = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if file != path && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				snapshot[file] = fileState{modTime: info.ModTime(), size: info.Size(), mode: info.Mode()}
+			}
+			return nil
+		})
+		// WalkDir doesn't follow a symlink given as the root; watch its target
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			snapshot[path] = fileState{modTime: info.ModTime(), size: info.Size(), mode: info.Mode()}
+		}
+	}
+	return snapshot
+}
+
+// Changed lists the files that were added, removed or modified between two snapshots
+func Changed(before, after Snapshot) []string {
+	var changed []string
+	for path, state := range after {
+		if old, ok := before[path]; !ok || old != state {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// Wait polls paths every interval until they differ from since, returning the new
+// snapshot and the changed files. It returns once they have stayed the same for
+// an interval, so a file still being written, e.g. by a compiler, is not caught
+// halfway. It returns the context's error when ctx is done.
+func Wait(ctx context.Context, paths []string, since Snapshot, interval time.Duration) (Snapshot, []string, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var pending Snapshot // the latest state that differed from since
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		current := Take(paths)
+		switch {
+		case pending != nil && len(Changed(pending, current)) == 0:
+			return current, Changed(since, current), nil
+		case len(Changed(since, current)) > 0:
+			pending = current
+		default:
+			// Changed back before it settled
+			pending = nil
+		}
+	}
+}
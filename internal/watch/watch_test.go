@@ -0,0 +1,85 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChanged(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	main := write("src/main.c", "int main;")
+	lib := write("src/lib.c", "int lib;")
+	write("src/.git/HEAD", "ref")
+	binary := write("a.out", "binary")
+
+	paths := []string{filepath.Join(dir, "src"), binary, filepath.Join(dir, "missing")}
+	before := Take(paths)
+	if len(before) != 3 {
+		t.Errorf("Take() found %d files, want 3 (hidden directories skipped): %v", len(before), before)
+	}
+	if changed := Changed(before, Take(paths)); len(changed) != 0 {
+		t.Errorf("Changed() of unchanged files = %v", changed)
+	}
+
+	write("src/main.c", "int main();")
+	write("src/.git/HEAD", "other ref")
+	if err := os.Remove(lib); err != nil {
+		t.Fatal(err)
+	}
+	created := write("missing", "now here")
+
+	want := []string{created, lib, main}
+	if changed := Changed(before, Take(paths)); !reflect.DeepEqual(changed, want) {
+		t.Errorf("Changed() = %v, want %v", changed, want)
+	}
+}
+
+func TestWait(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.out")
+	if err := os.WriteFile(path, []byte("v1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	paths := []string{path}
+	since := Take(paths)
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		_ = os.WriteFile(path, []byte("version 2"), 0755)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	snapshot, changed, err := Wait(ctx, paths, since, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	if !reflect.DeepEqual(changed, paths) {
+		t.Errorf("Wait() changed = %v, want %v", changed, paths)
+	}
+	if snapshot[path].size != int64(len("version 2")) {
+		t.Errorf("Wait() snapshot = %v, want the new state", snapshot)
+	}
+
+	// Nothing changes until the context is done
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := Wait(ctx, paths, snapshot, 10*time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("Wait() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
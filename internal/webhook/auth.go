@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Authentication types of Config.AuthType
+const (
+	AuthNone   = "none"
+	AuthBearer = "bearer"  // Authorization: Bearer <token>
+	AuthAPIKey = "api-key" // X-API-Key: <token>
+	AuthBasic  = "basic"   // HTTP Basic auth, token is user:password
+	AuthHeader = "header"  // <AuthHeader>: <token>
+)
+
+// AuthTypes lists every supported authentication type
+var AuthTypes = []string{AuthNone, AuthBearer, AuthAPIKey, AuthBasic, AuthHeader}
+
+// ValidateAuth checks the authentication type and the settings it requires
+func ValidateAuth(authType, authToken, authHeader string) error {
+	switch authType {
+	case "", AuthNone, AuthBearer, AuthAPIKey:
+		return nil
+	case AuthBasic:
+		if !strings.Contains(authToken, ":") {
+			return fmt.Errorf("webhook auth type basic requires an auth token of the form user:password")
+		}
+		return nil
+	case AuthHeader:
+		if authHeader == "" {
+			return fmt.Errorf("webhook auth type header requires an auth header name (--webhook-auth-header)")
+		}
+		if !validHeaderName(authHeader) {
+			return fmt.Errorf("invalid webhook auth header name %q", authHeader)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown webhook auth type %q (available: %v)", authType, AuthTypes)
+}
+
+// validHeaderName reports whether name is a valid HTTP header field name (an RFC 9110 token)
+func validHeaderName(name string) bool {
+	return name != "" && !strings.ContainsFunc(name, func(r rune) bool {
+		return r > 0x7e || r <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r)
+	})
+}
+
+// setAuth adds the configured authentication to a request
+func (c *Config) setAuth(req *http.Request) {
+	switch c.AuthType {
+	case AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+c.AuthToken)
+	case AuthAPIKey:
+		req.Header.Set("X-API-Key", c.AuthToken)
+	case AuthBasic:
+		user, password, _ := strings.Cut(c.AuthToken, ":")
+		req.SetBasicAuth(user, password)
+	case AuthHeader:
+		req.Header.Set(c.AuthHeader, c.AuthToken)
+	}
+}
@@ -0,0 +1,44 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateAuth(t *testing.T) {
+	tests := []struct {
+		name       string
+		authType   string
+		authToken  string
+		authHeader string
+		wantErr    string
+	}{
+		{name: "unset", authType: ""},
+		{name: "none", authType: "none"},
+		{name: "bearer", authType: "bearer", authToken: "tok"},
+		{name: "api-key", authType: "api-key", authToken: "key"},
+		{name: "basic", authType: "basic", authToken: "user:pass"},
+		{name: "basic with empty password", authType: "basic", authToken: "user:"},
+		{name: "basic without colon", authType: "basic", authToken: "userpass", wantErr: "user:password"},
+		{name: "header", authType: "header", authToken: "tok", authHeader: "X-Service-Token"},
+		{name: "header without name", authType: "header", authToken: "tok", wantErr: "requires an auth header name"},
+		{name: "header with invalid name", authType: "header", authToken: "tok", authHeader: "X Token", wantErr: "invalid webhook auth header name"},
+		{name: "header with colon", authType: "header", authToken: "tok", authHeader: "X-Token:", wantErr: "invalid webhook auth header name"},
+		{name: "unknown", authType: "digest", wantErr: `unknown webhook auth type "digest"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAuth(tt.authType, tt.authToken, tt.authHeader)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
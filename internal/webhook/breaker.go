@@ -0,0 +1,139 @@
+package webhook
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrCircuitOpen is returned for deliveries skipped by an open circuit breaker
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// DefaultBreakerCooldown is how long an open circuit breaker waits before it
+// lets a probe delivery through
+const DefaultBreakerCooldown = 30 * time.Second
+
+// Breaker stops deliveries to an endpoint after consecutive failures
+// Its state lives in the spool directory, so the ghost processes of a batch
+// share it: once threshold deliveries in a row have failed, the circuit opens
+// and deliveries are spooled instead of attempted. After the cooldown one
+// delivery probes the endpoint (half-open); success closes the circuit, failure
+// opens it for another cooldown. Processes updating the state at the same time
+// may lose a count, which only delays opening the circuit.
+type Breaker struct {
+	path      string
+	url       string
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+}
+
+// breakerState is persisted between deliveries
+type breakerState struct {
+	URL      string    `json:"url"`
+	Failures int       `json:"failures"`            // consecutive failed deliveries
+	OpenedAt time.Time `json:"opened_at,omitempty"` // when the circuit last opened or probed
+}
+
+// NewBreaker returns the breaker of an endpoint with state in dir
+// A threshold of 0 disables it; a cooldown of 0 means DefaultBreakerCooldown.
+func NewBreaker(dir, url string, threshold int, cooldown time.Duration) *Breaker {
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	// Dot files are never picked up as spooled deliveries
+	sum := sha256.Sum256([]byte(url))
+	return &Breaker{
+		path:      filepath.Join(dir, ".breaker-"+hex.EncodeToString(sum[:8])+spoolExt),
+		url:       url,
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a delivery may be attempted
+// While the circuit is open it returns false; once the cooldown has passed it
+// returns true for a single probe and keeps the circuit open for the others.
+func (b *Breaker) Allow() bool {
+	if b == nil || b.threshold <= 0 {
+		return true
+	}
+	state := b.read()
+	if state.Failures < b.threshold {
+		return true
+	}
+	now := b.now()
+	if now.Sub(state.OpenedAt) < b.cooldown {
+		return false
+	}
+	state.URL = b.url
+	state.OpenedAt = now
+	_ = b.write(state)
+	return true
+}
+
+// Record updates the breaker with the outcome of an attempted delivery
+func (b *Breaker) Record(err error) {
+	if b == nil || b.threshold <= 0 {
+		return
+	}
+	state := b.read()
+	if err == nil {
+		if state.Failures > 0 {
+			_ = os.Remove(b.path)
+		}
+		return
+	}
+	state.URL = b.url
+	state.Failures++
+	if state.Failures >= b.threshold {
+		state.OpenedAt = b.now()
+	}
+	_ = b.write(state)
+}
+
+// read loads the state; a missing or unreadable state is a closed circuit
+func (b *Breaker) read() breakerState {
+	var state breakerState
+	data, err := os.ReadFile(b.path)
+	if err != nil {
+		return state
+	}
+	_ = json.Unmarshal(data, &state)
+	return state
+}
+
+// write saves the state atomically
+func (b *Breaker) write(state breakerState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(b.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create spool directory %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, ".breaker-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write breaker state: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write breaker state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write breaker state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), b.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to write breaker state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	newBreaker := func() *Breaker {
+		// Every process opens its own breaker on the shared state
+		b := NewBreaker(dir, "https://example.com/hook", 3, time.Minute)
+		b.now = func() time.Time { return now }
+		return b
+	}
+	failure := errors.New("connection refused")
+
+	for i := 0; i < 2; i++ {
+		if !newBreaker().Allow() {
+			t.Fatalf("failure %d: circuit opened below the threshold", i)
+		}
+		newBreaker().Record(failure)
+	}
+	// A success resets the count
+	newBreaker().Record(nil)
+	for i := 0; i < 3; i++ {
+		if !newBreaker().Allow() {
+			t.Fatalf("failure %d after reset: circuit opened below the threshold", i)
+		}
+		newBreaker().Record(failure)
+	}
+	if newBreaker().Allow() {
+		t.Fatal("circuit should be open after 3 consecutive failures")
+	}
+
+	// After the cooldown a single probe is let through
+	now = now.Add(time.Minute)
+	if !newBreaker().Allow() {
+		t.Fatal("circuit should let a probe through after the cooldown")
+	}
+	if newBreaker().Allow() {
+		t.Fatal("only one probe should be let through")
+	}
+
+	// A failed probe opens the circuit for another cooldown
+	newBreaker().Record(failure)
+	now = now.Add(30 * time.Second)
+	if newBreaker().Allow() {
+		t.Fatal("circuit should stay open after a failed probe")
+	}
+
+	// A successful probe closes it
+	now = now.Add(time.Minute)
+	if !newBreaker().Allow() {
+		t.Fatal("circuit should let a probe through after the cooldown")
+	}
+	newBreaker().Record(nil)
+	if !newBreaker().Allow() || !newBreaker().Allow() {
+		t.Fatal("circuit should be closed after a successful probe")
+	}
+
+	// Breaker state is never mistaken for a spooled delivery
+	newBreaker().Record(failure)
+	if ids, err := PendingDeliveries(dir); err != nil || len(ids) != 0 {
+		t.Errorf("pending = %v, %v, want none", ids, err)
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	var nilBreaker *Breaker
+	if !nilBreaker.Allow() {
+		t.Error("nil breaker should allow deliveries")
+	}
+	nilBreaker.Record(errors.New("failed"))
+
+	b := NewBreaker(t.TempDir(), "https://example.com", 0, 0)
+	for i := 0; i < 5; i++ {
+		b.Record(errors.New("failed"))
+	}
+	if !b.Allow() {
+		t.Error("breaker with threshold 0 should allow deliveries")
+	}
+}
+
+func TestBreakerPerURL(t *testing.T) {
+	dir := t.TempDir()
+	NewBreaker(dir, "https://a.example.com", 1, time.Minute).Record(errors.New("failed"))
+	if NewBreaker(dir, "https://a.example.com", 1, time.Minute).Allow() {
+		t.Error("circuit of a.example.com should be open")
+	}
+	if !NewBreaker(dir, "https://b.example.com", 1, time.Minute).Allow() {
+		t.Error("circuit of b.example.com should be closed")
+	}
+}
+
+func TestFlushSpoolBreaker(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	noRetry := &RetryConfig{MaxRetries: 0, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, Multiplier: 1}
+	config := &Config{URL: server.URL, Method: "POST", Timeout: 5 * time.Second, BreakerThreshold: 2, BreakerCooldown: time.Hour}
+	for i := 0; i < 5; i++ {
+		if _, err := SpoolDelivery(dir, config, noRetry, map[string]int{"case": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	flushed, err := FlushSpool(context.Background(), dir, 0, false)
+	if err != nil {
+		t.Fatalf("FlushSpool failed: %v", err)
+	}
+	if got := requests.Load(); got != 2 {
+		t.Errorf("requests = %d, want 2 before the circuit opens", got)
+	}
+	if len(flushed) != 5 {
+		t.Fatalf("flushed = %+v, want 5 entries", flushed)
+	}
+	for i, entry := range flushed {
+		wantAttempts, wantError := 1, ""
+		if i >= 2 {
+			wantAttempts, wantError = 0, ErrCircuitOpen.Error()
+		}
+		if entry.Status != FlushRetry || entry.Attempts != wantAttempts || (wantError != "" && entry.Error != wantError) {
+			t.Errorf("entry %d = %+v, want retry with %d attempts", i, entry, wantAttempts)
+		}
+	}
+	if pending, _ := PendingDeliveries(dir); len(pending) != 5 {
+		t.Errorf("pending = %d, want all 5 kept", len(pending))
+	}
+	if failed, _ := PendingDeliveries(filepath.Join(dir, SpoolFailedDir)); len(failed) != 0 {
+		t.Errorf("failed = %v, want none", failed)
+	}
+}
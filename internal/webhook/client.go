@@ -7,8 +7,18 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/zinc-sig/ghost/internal/redact"
+	"github.com/zinc-sig/ghost/internal/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
 )
 
 // Client represents a webhook HTTP client
@@ -16,7 +26,10 @@ type Client struct {
 	httpClient  *http.Client
 	config      *Config
 	retryConfig *RetryConfig
+	limiter     *rate.Limiter // nil when rate limiting is disabled
 	verbose     bool
+
+	receipts []Receipt // attempts of the last delivery
 }
 
 // NewClient creates a new webhook client
@@ -32,21 +45,58 @@ func NewClient(config *Config, retryConfig *RetryConfig, verbose bool) *Client {
 	}
 
 	return &Client{
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second, // Per-request timeout
-		},
+		httpClient:  sharedHTTPClient(TransportOptions{Proxy: config.Proxy, DisableKeepAlives: config.DisableKeepAlives}),
 		config:      config,
 		retryConfig: retryConfig,
+		limiter:     sharedLimiter(config.RateLimit),
 		verbose:     verbose,
 	}
 }
 
+// MaxResponseBody bounds how much of a response body is kept
+const MaxResponseBody = 64 << 10
+
+// Response is the receiver's reply to the last delivery attempt
+type Response struct {
+	StatusCode int
+	Body       []byte // at most MaxResponseBody bytes
+	Truncated  bool   // the body was longer than MaxResponseBody
+}
+
+// Receipt records one attempt of a delivery
+type Receipt struct {
+	Attempt    int           `json:"attempt"` // 1-based
+	StartedAt  time.Time     `json:"started_at"`
+	Duration   time.Duration `json:"duration"`
+	StatusCode int           `json:"status_code,omitempty"` // 0 if no response was received
+	Delivered  bool          `json:"delivered"`             // the receiver answered with an expected status
+	Error      string        `json:"error,omitempty"`       // why no response was received ("" if one was)
+}
+
+// Receipts returns the attempts of the last Deliver, oldest first
+func (c *Client) Receipts() []Receipt {
+	return c.receipts
+}
+
 // Send sends the payload to the webhook with retry logic
 func (c *Client) Send(ctx context.Context, payload interface{}) error {
+	_, err := c.Deliver(ctx, payload)
+	return err
+}
+
+// Deliver sends the payload like Send and also returns the last response received
+// The response is nil if no attempt got one.
+func (c *Client) Deliver(ctx context.Context, payload interface{}) (*Response, error) {
 	// Marshal the payload to JSON
 	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+		return nil, fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	// Strip fields that must not leave the machine
+	jsonPayload, err = FilterPayload(jsonPayload, c.config.IncludeFields, c.config.ExcludeFields)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create context with overall timeout
@@ -54,6 +104,8 @@ func (c *Client) Send(ctx context.Context, payload interface{}) error {
 	defer cancel()
 
 	var lastErr error
+	var lastResponse *Response
+	c.receipts = nil
 
 	for attempt := 0; attempt <= c.retryConfig.MaxRetries; attempt++ {
 		// Add backoff delay (skip on first attempt)
@@ -61,7 +113,7 @@ func (c *Client) Send(ctx context.Context, payload interface{}) error {
 			delay := calculateBackoff(attempt, c.retryConfig)
 
 			if c.verbose {
-				fmt.Fprintf(os.Stderr, "[WEBHOOK] Retry %d/%d after %v\n",
+				fmt.Fprintf(redact.Stderr, "[WEBHOOK] Retry %d/%d after %v\n",
 					attempt, c.retryConfig.MaxRetries, delay)
 			}
 
@@ -69,24 +121,48 @@ func (c *Client) Send(ctx context.Context, payload interface{}) error {
 			case <-time.After(delay):
 				// Continue after delay
 			case <-ctx.Done():
-				return fmt.Errorf("webhook timeout after %d attempts: %w", attempt, ctx.Err())
+				return lastResponse, fmt.Errorf("webhook timeout after %d attempts: %w", attempt, ctx.Err())
+			}
+		}
+
+		// Respect the delivery rate limit shared by all clients
+		if c.limiter != nil {
+			if err := c.limiter.Wait(ctx); err != nil {
+				return lastResponse, fmt.Errorf("webhook rate limit wait failed after %d attempts: %w", attempt, err)
 			}
 		}
 
 		// Attempt to send
-		statusCode, err := c.sendRequest(ctx, jsonPayload)
+		startedAt := time.Now()
+		response, err := c.sendAttempt(ctx, jsonPayload, attempt+1)
+		receipt := Receipt{Attempt: attempt + 1, StartedAt: startedAt, Duration: time.Since(startedAt)}
+		statusCode := 0
+		if response != nil {
+			lastResponse = response
+			statusCode = response.StatusCode
+		}
+		receipt.StatusCode = statusCode
+		receipt.Delivered = err == nil && c.isExpectedStatus(statusCode)
+		if err != nil {
+			receipt.Error = redact.Error(err)
+		}
+		c.receipts = append(c.receipts, receipt)
 
-		if err == nil && statusCode >= 200 && statusCode < 300 {
+		if err == nil && c.isExpectedStatus(statusCode) {
 			// Success!
 			if c.verbose {
-				fmt.Fprintf(os.Stderr, "[WEBHOOK] Successfully sent (status: %d)\n", statusCode)
+				fmt.Fprintf(redact.Stderr, "[WEBHOOK] Successfully sent (status: %d)\n", statusCode)
 			}
-			return nil
+			return response, nil
 		}
 
 		// Record the error
 		if err != nil {
 			lastErr = fmt.Errorf("attempt %d failed: %w", attempt+1, err)
+		} else if statusCode >= 200 && statusCode < 300 {
+			// The receiver accepted the delivery, so it must not be repeated
+			lastErr = fmt.Errorf("attempt %d got unexpected status %d (expected %s)", attempt+1, statusCode, formatStatuses(c.config.ExpectStatus))
+			return response, lastErr
 		} else {
 			lastErr = fmt.Errorf("attempt %d failed with status %d", attempt+1, statusCode)
 		}
@@ -94,43 +170,86 @@ func (c *Client) Send(ctx context.Context, payload interface{}) error {
 		// Check if we should retry this status code
 		if statusCode > 0 && !isRetryableStatus(statusCode) {
 			if c.verbose {
-				fmt.Fprintf(os.Stderr, "[WEBHOOK] Non-retryable status %d, giving up\n", statusCode)
+				fmt.Fprintf(redact.Stderr, "[WEBHOOK] Non-retryable status %d, giving up\n", statusCode)
 			}
-			return lastErr
+			return response, lastErr
 		}
 	}
 
-	return fmt.Errorf("webhook failed after %d attempts: %w", c.retryConfig.MaxRetries+1, lastErr)
+	return lastResponse, fmt.Errorf("webhook failed after %d attempts: %w", c.retryConfig.MaxRetries+1, lastErr)
+}
+
+// isExpectedStatus reports whether a status code counts as a successful delivery
+// Any 2xx status does unless expected statuses are configured.
+func (c *Client) isExpectedStatus(code int) bool {
+	if len(c.config.ExpectStatus) == 0 {
+		return code >= 200 && code < 300
+	}
+	return slices.Contains(c.config.ExpectStatus, code)
+}
+
+// sendAttempt sends a single request inside a tracing span
+func (c *Client) sendAttempt(ctx context.Context, payload []byte, attempt int) (*Response, error) {
+	ctx, span := tracing.Tracer().Start(ctx, "webhook",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", c.config.Method),
+			attribute.String("url.full", c.config.URL),
+			attribute.Int("ghost.webhook.attempt", attempt),
+		),
+	)
+	defer span.End()
+
+	response, err := c.sendRequest(ctx, payload)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return nil, err
+	}
+	span.SetAttributes(attribute.Int("http.response.status_code", response.StatusCode))
+	if !c.isExpectedStatus(response.StatusCode) {
+		tracing.RecordError(span, fmt.Errorf("unexpected status %d", response.StatusCode))
+	}
+	return response, nil
 }
 
-func (c *Client) sendRequest(ctx context.Context, payload []byte) (int, error) {
+func (c *Client) sendRequest(ctx context.Context, payload []byte) (*Response, error) {
 	req, err := http.NewRequestWithContext(ctx, c.config.Method, c.config.URL, bytes.NewReader(payload))
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	// Set headers
+	// Set headers, propagating the trace context to the receiver
 	req.Header.Set("Content-Type", "application/json")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 	for k, v := range c.config.Headers {
 		req.Header.Set(k, v)
 	}
 
 	// Set authentication
-	switch c.config.AuthType {
-	case "bearer":
-		req.Header.Set("Authorization", "Bearer "+c.config.AuthToken)
-	case "api-key":
-		req.Header.Set("X-API-Key", c.config.AuthToken)
-	}
+	c.config.setAuth(req)
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	// Drain response body to reuse connection
+	// Keep the start of the body, then drain the rest to reuse the connection
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, MaxResponseBody+1))
 	_, _ = io.Copy(io.Discard, resp.Body)
 
-	return resp.StatusCode, nil
+	response := &Response{StatusCode: resp.StatusCode, Body: body}
+	if len(body) > MaxResponseBody {
+		response.Body, response.Truncated = body[:MaxResponseBody], true
+	}
+	return response, nil
+}
+
+// formatStatuses lists status codes for error messages
+func formatStatuses(codes []int) string {
+	parts := make([]string, len(codes))
+	for i, code := range codes {
+		parts[i] = strconv.Itoa(code)
+	}
+	return strings.Join(parts, " or ")
 }
@@ -98,6 +98,7 @@ func TestClientSend_AuthHeaders(t *testing.T) {
 		name           string
 		authType       string
 		authToken      string
+		authHeader     string
 		expectedHeader string
 		expectedValue  string
 	}{
@@ -115,6 +116,21 @@ func TestClientSend_AuthHeaders(t *testing.T) {
 			expectedHeader: "X-API-Key",
 			expectedValue:  "api-key-value",
 		},
+		{
+			name:           "basic auth",
+			authType:       "basic",
+			authToken:      "grader:s3cr:et",
+			expectedHeader: "Authorization",
+			expectedValue:  "Basic Z3JhZGVyOnMzY3I6ZXQ=",
+		},
+		{
+			name:           "header auth",
+			authType:       "header",
+			authToken:      "service-token",
+			authHeader:     "X-Service-Token",
+			expectedHeader: "X-Service-Token",
+			expectedValue:  "service-token",
+		},
 		{
 			name:           "no auth",
 			authType:       "none",
@@ -139,10 +155,11 @@ func TestClientSend_AuthHeaders(t *testing.T) {
 			defer server.Close()
 
 			config := &Config{
-				URL:       server.URL,
-				AuthType:  tt.authType,
-				AuthToken: tt.authToken,
-				Timeout:   5 * time.Second,
+				URL:        server.URL,
+				AuthType:   tt.authType,
+				AuthToken:  tt.authToken,
+				AuthHeader: tt.authHeader,
+				Timeout:    5 * time.Second,
 			}
 
 			client := NewClient(config, DefaultRetryConfig(), false)
@@ -377,3 +394,150 @@ func TestClientSend_MaxRetriesExceeded(t *testing.T) {
 		t.Errorf("Expected 3 attempts, got %d", finalAttempts)
 	}
 }
+
+func TestClientDeliver_Response(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		wantBody      string
+		wantTruncated bool
+	}{
+		{name: "json token", body: `{"token":"abc123"}`, wantBody: `{"token":"abc123"}`},
+		{name: "empty body", body: "", wantBody: ""},
+		{
+			name:          "oversized body",
+			body:          strings.Repeat("x", MaxResponseBody+100),
+			wantBody:      strings.Repeat("x", MaxResponseBody),
+			wantTruncated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer server.Close()
+
+			client := NewClient(&Config{URL: server.URL, Timeout: 5 * time.Second}, &RetryConfig{}, false)
+			response, err := client.Deliver(context.Background(), &output.Result{Command: "test"})
+			if err != nil {
+				t.Fatalf("Deliver() error = %v", err)
+			}
+			if response == nil {
+				t.Fatal("Deliver() response = nil")
+			}
+			if response.StatusCode != http.StatusCreated {
+				t.Errorf("StatusCode = %d, want %d", response.StatusCode, http.StatusCreated)
+			}
+			if string(response.Body) != tt.wantBody {
+				t.Errorf("Body has %d bytes, want %d", len(response.Body), len(tt.wantBody))
+			}
+			if response.Truncated != tt.wantTruncated {
+				t.Errorf("Truncated = %v, want %v", response.Truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
+
+func TestClientDeliver_Receipts(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(&Config{URL: server.URL, Timeout: 5 * time.Second}, &RetryConfig{MaxRetries: 2, InitialDelay: 10 * time.Millisecond, MaxDelay: 10 * time.Millisecond, Multiplier: 1}, false)
+	before := time.Now()
+	if _, err := client.Deliver(context.Background(), &output.Result{Command: "test"}); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	receipts := client.Receipts()
+	if len(receipts) != 2 {
+		t.Fatalf("Receipts() = %+v, want 2 attempts", receipts)
+	}
+	for i, want := range []struct {
+		status    int
+		delivered bool
+	}{{http.StatusBadGateway, false}, {http.StatusOK, true}} {
+		r := receipts[i]
+		if r.Attempt != i+1 || r.StatusCode != want.status || r.Delivered != want.delivered || r.Error != "" {
+			t.Errorf("Receipt %d = %+v, want status %d delivered %v", i, r, want.status, want.delivered)
+		}
+		if r.StartedAt.Before(before) || r.Duration < 0 {
+			t.Errorf("Receipt %d = %+v, want a start time and duration of this delivery", i, r)
+		}
+	}
+	if !receipts[1].StartedAt.After(receipts[0].StartedAt) {
+		t.Errorf("Receipts %+v are not in attempt order", receipts)
+	}
+
+	// A connection failure has no status code but an error
+	server.Close()
+	_, _ = client.Deliver(context.Background(), &output.Result{Command: "test"})
+	receipts = client.Receipts()
+	if len(receipts) != 3 || receipts[0].StatusCode != 0 || receipts[0].Delivered || receipts[0].Error == "" {
+		t.Errorf("Receipts() = %+v, want 3 failed attempts with errors", receipts)
+	}
+}
+
+func TestClientSend_ExpectStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		expectStatus []int
+		wantErr      string
+		wantAttempts int32
+	}{
+		{name: "any 2xx by default", status: http.StatusAccepted, wantAttempts: 1},
+		{name: "expected status", status: http.StatusOK, expectStatus: []int{200}, wantAttempts: 1},
+		{name: "one of several", status: http.StatusCreated, expectStatus: []int{200, 201}, wantAttempts: 1},
+		{
+			name:         "unexpected success is not retried",
+			status:       http.StatusAccepted,
+			expectStatus: []int{200},
+			wantErr:      "unexpected status 202 (expected 200)",
+			wantAttempts: 1,
+		},
+		{name: "expected conflict", status: http.StatusConflict, expectStatus: []int{200, 409}, wantAttempts: 1},
+		{
+			name:         "retryable failure still retried",
+			status:       http.StatusServiceUnavailable,
+			expectStatus: []int{200},
+			wantErr:      "status 503",
+			wantAttempts: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			config := &Config{URL: server.URL, Timeout: 5 * time.Second, ExpectStatus: tt.expectStatus}
+			retryConfig := &RetryConfig{MaxRetries: 2, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, Multiplier: 1}
+			client := NewClient(config, retryConfig, false)
+
+			err := client.Send(context.Background(), &output.Result{Command: "test"})
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Send() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Send() error = %v, want containing %q", err, tt.wantErr)
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
@@ -1,31 +1,45 @@
 package webhook
 
-import "time"
+import (
+	"time"
+
+	"github.com/zinc-sig/ghost/internal/retry"
+)
 
 // Config holds webhook endpoint configuration
 type Config struct {
-	URL       string            // Webhook endpoint URL
-	Method    string            // HTTP method (default: POST)
-	Headers   map[string]string // Custom headers
-	Timeout   time.Duration     // Overall timeout for all retries
-	AuthType  string            // Authentication type: none, bearer, api-key
-	AuthToken string            // Authentication token
+	URL        string            // Webhook endpoint URL
+	Method     string            // HTTP method (default: POST)
+	Headers    map[string]string // Custom headers
+	Timeout    time.Duration     // Overall timeout for all retries
+	AuthType   string            // Authentication type: none, bearer, api-key, basic, header
+	AuthToken  string            // Authentication token (user:password for basic, header value for header)
+	AuthHeader string            // Header carrying the token with auth type header
+
+	RateLimit float64 // Maximum deliveries per second across the process (0 = unlimited)
+
+	Proxy             string // Proxy URL (empty = HTTP_PROXY/HTTPS_PROXY/NO_PROXY from the environment)
+	DisableKeepAlives bool   // Don't reuse connections between deliveries
+
+	IncludeFields []string // Payload fields to send (dot notation, empty = all)
+	ExcludeFields []string // Payload fields to strip (dot notation)
+
+	Events []string // Lifecycle events to deliver (empty = final result only)
+
+	ExpectStatus    []int // Status codes that count as delivered (empty = any 2xx)
+	CaptureResponse bool  // Record the receiver's response in the result
+
+	Async    bool   // Spool deliveries for `ghost webhook flush` instead of sending them
+	SpoolDir string // Spool directory for async deliveries (empty = DefaultSpoolDir)
+
+	BreakerThreshold int           // Consecutive failed deliveries that open the circuit (0 = no breaker)
+	BreakerCooldown  time.Duration // Wait before probing an open circuit (0 = DefaultBreakerCooldown)
 }
 
 // RetryConfig holds retry configuration
-type RetryConfig struct {
-	MaxRetries   int           // Maximum retry attempts (default: 3)
-	InitialDelay time.Duration // Initial delay between retries (default: 1s)
-	MaxDelay     time.Duration // Maximum delay (default: 30s)
-	Multiplier   float64       // Backoff multiplier (default: 2.0)
-}
+type RetryConfig = retry.Config
 
 // DefaultRetryConfig returns default retry configuration
 func DefaultRetryConfig() *RetryConfig {
-	return &RetryConfig{
-		MaxRetries:   3,
-		InitialDelay: 1 * time.Second,
-		MaxDelay:     30 * time.Second,
-		Multiplier:   2.0,
-	}
+	return retry.DefaultConfig()
 }
@@ -0,0 +1,36 @@
+package webhook
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Lifecycle events that can be delivered when Config.Events is set
+const (
+	EventStarted        = "started"         // Before the command runs
+	EventTimeout        = "timeout"         // The command was killed by the timeout
+	EventUploadFinished = "upload_finished" // Uploads finished (successfully or not)
+	EventCompleted      = "completed"       // The final result
+)
+
+// Events lists every supported event in lifecycle order
+var Events = []string{EventStarted, EventTimeout, EventUploadFinished, EventCompleted}
+
+// ValidateEvents checks that every event name is supported
+func ValidateEvents(events []string) error {
+	for _, event := range events {
+		if !slices.Contains(Events, event) {
+			return fmt.Errorf("unknown webhook event %q (available: %v)", event, Events)
+		}
+	}
+	return nil
+}
+
+// EventEnabled reports whether the event should be delivered
+// Without configured events only the final result is delivered.
+func (c *Config) EventEnabled(event string) bool {
+	if len(c.Events) == 0 {
+		return event == EventCompleted
+	}
+	return slices.Contains(c.Events, event)
+}
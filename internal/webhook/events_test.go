@@ -0,0 +1,35 @@
+package webhook
+
+import "testing"
+
+func TestValidateEvents(t *testing.T) {
+	if err := ValidateEvents(Events); err != nil {
+		t.Errorf("ValidateEvents(%v) error = %v", Events, err)
+	}
+	if err := ValidateEvents([]string{EventStarted, "finished"}); err == nil {
+		t.Error("Expected error for unknown event")
+	}
+}
+
+func TestConfigEventEnabled(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []string
+		event  string
+		want   bool
+	}{
+		{"default completed", nil, EventCompleted, true},
+		{"default started", nil, EventStarted, false},
+		{"selected", []string{EventStarted, EventTimeout}, EventTimeout, true},
+		{"completed left out", []string{EventStarted}, EventCompleted, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Events: tt.events}
+			if got := config.EventEnabled(tt.event); got != tt.want {
+				t.Errorf("EventEnabled(%q) = %v, want %v", tt.event, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ValidateFieldPaths checks that every field path is a non-empty dot-notation path
+func ValidateFieldPaths(paths []string) error {
+	for _, path := range paths {
+		for _, segment := range strings.Split(path, ".") {
+			if segment == "" {
+				return fmt.Errorf("invalid field path %q", path)
+			}
+		}
+	}
+	return nil
+}
+
+// FilterPayload applies include and exclude field paths to a JSON payload
+// Paths use dot notation to address nested objects (e.g. "context.user.email").
+// When include paths are given only those fields are kept; exclude paths are
+// removed afterwards. Paths that do not exist in the payload are ignored.
+func FilterPayload(payload []byte, include, exclude []string) ([]byte, error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return payload, nil
+	}
+
+	var data map[string]any
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode webhook payload for filtering: %w", err)
+	}
+
+	if len(include) > 0 {
+		filtered := make(map[string]any)
+		for _, path := range include {
+			copyPath(data, filtered, strings.Split(path, "."))
+		}
+		data = filtered
+	}
+
+	for _, path := range exclude {
+		deletePath(data, strings.Split(path, "."))
+	}
+
+	filtered, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode filtered webhook payload: %w", err)
+	}
+	return filtered, nil
+}
+
+// copyPath copies the value at path from src into dst, creating intermediate objects
+func copyPath(src, dst map[string]any, path []string) {
+	value, ok := src[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		dst[path[0]] = value
+		return
+	}
+
+	srcChild, ok := value.(map[string]any)
+	if !ok {
+		return
+	}
+	dstChild, ok := dst[path[0]].(map[string]any)
+	if !ok {
+		dstChild = make(map[string]any)
+	}
+	copyPath(srcChild, dstChild, path[1:])
+	if len(dstChild) > 0 {
+		dst[path[0]] = dstChild
+	}
+}
+
+// deletePath removes the value at path from data
+func deletePath(data map[string]any, path []string) {
+	if len(path) == 1 {
+		delete(data, path[0])
+		return
+	}
+	if child, ok := data[path[0]].(map[string]any); ok {
+		deletePath(child, path[1:])
+	}
+}
@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestFilterPayload(t *testing.T) {
+	payload := `{"command":"echo","status":"success","score":"10","context":{"user":{"id":1,"email":"a@b.c"},"token":"secret"}}`
+
+	tests := []struct {
+		name    string
+		include []string
+		exclude []string
+		want    string
+	}{
+		{
+			name: "no filters",
+			want: payload,
+		},
+		{
+			name:    "exclude top-level field",
+			exclude: []string{"score"},
+			want:    `{"command":"echo","status":"success","context":{"user":{"id":1,"email":"a@b.c"},"token":"secret"}}`,
+		},
+		{
+			name:    "exclude nested context keys",
+			exclude: []string{"context.token", "context.user.email"},
+			want:    `{"command":"echo","status":"success","score":"10","context":{"user":{"id":1}}}`,
+		},
+		{
+			name:    "include selected fields",
+			include: []string{"status", "context.user.id"},
+			want:    `{"status":"success","context":{"user":{"id":1}}}`,
+		},
+		{
+			name:    "include then exclude",
+			include: []string{"status", "context"},
+			exclude: []string{"context.token"},
+			want:    `{"status":"success","context":{"user":{"id":1,"email":"a@b.c"}}}`,
+		},
+		{
+			name:    "missing paths are ignored",
+			include: []string{"status", "context.missing", "score.value"},
+			exclude: []string{"nothing.here"},
+			want:    `{"status":"success"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FilterPayload([]byte(payload), tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("FilterPayload() error = %v", err)
+			}
+
+			var gotData, wantData any
+			_ = json.Unmarshal(got, &gotData)
+			_ = json.Unmarshal([]byte(tt.want), &wantData)
+			if !reflect.DeepEqual(gotData, wantData) {
+				t.Errorf("FilterPayload() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateFieldPaths(t *testing.T) {
+	tests := []struct {
+		name    string
+		paths   []string
+		wantErr bool
+	}{
+		{"valid paths", []string{"status", "context.user.email"}, false},
+		{"empty path", []string{""}, true},
+		{"empty segment", []string{"context..token"}, true},
+		{"trailing dot", []string{"context."}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFieldPaths(tt.paths)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateFieldPaths() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
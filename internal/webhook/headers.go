@@ -0,0 +1,48 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ParseHeader parses a custom header given as "Name: value"
+func ParseHeader(spec string) (name, value string, err error) {
+	name, value, ok := strings.Cut(spec, ":")
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+	if !ok || name == "" {
+		return "", "", fmt.Errorf("invalid webhook header %q (format: Name: value)", spec)
+	}
+	if err := validateHeader(name, value); err != nil {
+		return "", "", err
+	}
+	return http.CanonicalHeaderKey(name), value, nil
+}
+
+// ValidateHeaders checks the names and values of custom headers
+func ValidateHeaders(headers map[string]string) error {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := validateHeader(name, headers[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateHeader rejects header names that are not tokens and values that would
+// split the request
+func validateHeader(name, value string) error {
+	if !validHeaderName(name) {
+		return fmt.Errorf("invalid webhook header name %q", name)
+	}
+	if strings.ContainsAny(value, "\r\n\x00") {
+		return fmt.Errorf("invalid webhook header %s: value must not contain line breaks", name)
+	}
+	return nil
+}
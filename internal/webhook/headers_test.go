@@ -0,0 +1,42 @@
+package webhook
+
+import "testing"
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		spec      string
+		wantName  string
+		wantValue string
+		wantErr   bool
+	}{
+		{spec: "X-Course: cs101", wantName: "X-Course", wantValue: "cs101"},
+		{spec: "x-course:cs101", wantName: "X-Course", wantValue: "cs101"},
+		{spec: "X-Time: 12:30", wantName: "X-Time", wantValue: "12:30"},
+		{spec: "X-Empty:", wantName: "X-Empty", wantValue: ""},
+		{spec: "X-Course", wantErr: true},
+		{spec: ": cs101", wantErr: true},
+		{spec: "X Course: cs101", wantErr: true},
+		{spec: "X-Course: cs101\r\nX-Injected: 1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			name, value, err := ParseHeader(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseHeader() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if name != tt.wantName || value != tt.wantValue {
+				t.Errorf("ParseHeader() = %q, %q, want %q, %q", name, value, tt.wantName, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestValidateHeaders(t *testing.T) {
+	if err := ValidateHeaders(map[string]string{"X-Course": "cs101"}); err != nil {
+		t.Errorf("ValidateHeaders() error = %v", err)
+	}
+	if err := ValidateHeaders(map[string]string{"X-Course": "a\nb"}); err == nil {
+		t.Error("ValidateHeaders() accepted a value with a line break")
+	}
+}
@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Connection pool settings for the shared HTTP client
+const (
+	RequestTimeout      = 10 * time.Second // Per-request timeout
+	MaxIdleConns        = 100
+	MaxIdleConnsPerHost = 32
+	IdleConnTimeout     = 90 * time.Second
+)
+
+// TransportOptions selects how a shared HTTP client connects to the receiver
+type TransportOptions struct {
+	Proxy             string // Proxy URL; empty uses HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	DisableKeepAlives bool   // Open a new connection for every request
+}
+
+var (
+	clientsMu sync.Mutex
+	clients   = map[TransportOptions]*http.Client{}
+
+	limitersMu sync.Mutex
+	limiters   = map[float64]*rate.Limiter{}
+)
+
+// SharedHTTPClient returns the process-wide HTTP client used for webhook delivery
+// Reusing one client keeps connections to the receiver alive between deliveries
+// instead of opening a new connection (and ephemeral port) for every webhook.
+func SharedHTTPClient() *http.Client {
+	return sharedHTTPClient(TransportOptions{})
+}
+
+// sharedHTTPClient returns the process-wide HTTP client for the transport options
+// The proxy URL must have been validated with ValidateProxyURL.
+func sharedHTTPClient(opts TransportOptions) *http.Client {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+
+	if client, ok := clients[opts]; ok {
+		return client
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = MaxIdleConns
+	transport.MaxIdleConnsPerHost = MaxIdleConnsPerHost
+	transport.IdleConnTimeout = IdleConnTimeout
+	transport.DisableKeepAlives = opts.DisableKeepAlives
+	// Negotiate HTTP/2 over TLS even though the transport is customised
+	transport.ForceAttemptHTTP2 = true
+
+	transport.Proxy = http.ProxyFromEnvironment
+	if opts.Proxy != "" {
+		if proxyURL, err := url.Parse(opts.Proxy); err == nil {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+
+	client := &http.Client{
+		Timeout:   RequestTimeout,
+		Transport: transport,
+	}
+	clients[opts] = client
+	return client
+}
+
+// ValidateProxyURL checks that a proxy URL is usable by the webhook client
+func ValidateProxyURL(proxy string) error {
+	if proxy == "" {
+		return nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return fmt.Errorf("invalid webhook proxy URL: %w", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return fmt.Errorf("invalid webhook proxy URL %q: scheme must be http, https or socks5", u.Redacted())
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid webhook proxy URL %q: missing host", u.Redacted())
+	}
+	return nil
+}
+
+// sharedLimiter returns the process-wide limiter for the given rate in requests per second
+// Clients configured with the same rate share one limiter so the limit holds across
+// all deliveries. A rate of zero or less disables limiting and returns nil.
+func sharedLimiter(requestsPerSecond float64) *rate.Limiter {
+	if requestsPerSecond <= 0 {
+		return nil
+	}
+
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+
+	limiter, ok := limiters[requestsPerSecond]
+	if !ok {
+		// Burst of one spaces requests evenly instead of allowing spikes
+		limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), 1)
+		limiters[requestsPerSecond] = limiter
+	}
+	return limiter
+}
@@ -1,30 +1,14 @@
 package webhook
 
 import (
-	"math"
-	"math/rand"
 	"time"
+
+	"github.com/zinc-sig/ghost/internal/retry"
 )
 
 // calculateBackoff calculates the backoff duration for a given retry attempt
 func calculateBackoff(attempt int, config *RetryConfig) time.Duration {
-	if attempt <= 0 {
-		return 0
-	}
-
-	// Exponential: delay = initialDelay * (multiplier ^ (attempt-1))
-	delay := float64(config.InitialDelay) * math.Pow(config.Multiplier, float64(attempt-1))
-
-	// Cap at maximum
-	if delay > float64(config.MaxDelay) {
-		delay = float64(config.MaxDelay)
-	}
-
-	// Add small jitter (±10%) to prevent thundering herd
-	jitter := delay * 0.1
-	delay = delay + (rand.Float64()*2-1)*jitter
-
-	return time.Duration(delay)
+	return retry.Backoff(attempt, config)
 }
 
 // isRetryableStatus checks if an HTTP status code should trigger a retry
@@ -0,0 +1,373 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zinc-sig/ghost/internal/redact"
+)
+
+// Spool layout: pending deliveries are <dir>/<id>.json, a flush claims one by
+// renaming it to <id>.json.sending, and deliveries given up on move to <dir>/failed.
+const (
+	spoolExt       = ".json"
+	claimExt       = ".sending"
+	SpoolFailedDir = "failed"
+)
+
+// Outcomes of a flushed delivery
+const (
+	FlushDelivered = "delivered" // the receiver accepted it; removed from the spool
+	FlushRetry     = "retry"     // delivery failed or its circuit breaker is open; kept for the next flush
+	FlushFailed    = "failed"    // delivery failed too often; moved to the failed directory
+)
+
+// StaleClaim is how long a delivery may stay claimed before another flush takes
+// it over, in case the flush that claimed it was killed
+var StaleClaim = 10 * time.Minute
+
+// SpoolEntry is a delivery persisted by an async webhook for a later flush
+// It carries everything needed to deliver it, including the auth token, so spool
+// files are only readable by their owner.
+type SpoolEntry struct {
+	URL               string            `json:"url"`
+	Method            string            `json:"method"`
+	Headers           map[string]string `json:"headers,omitempty"`
+	AuthType          string            `json:"auth_type,omitempty"`
+	AuthToken         string            `json:"auth_token,omitempty"`
+	AuthHeader        string            `json:"auth_header,omitempty"`
+	Timeout           time.Duration     `json:"timeout"`
+	RateLimit         float64           `json:"rate_limit,omitempty"`
+	ExpectStatus      []int             `json:"expect_status,omitempty"`
+	Proxy             string            `json:"proxy,omitempty"`
+	DisableKeepAlives bool              `json:"disable_keep_alives,omitempty"`
+	BreakerThreshold  int               `json:"breaker_threshold,omitempty"`
+	BreakerCooldown   time.Duration     `json:"breaker_cooldown,omitempty"`
+	Retry             RetryConfig       `json:"retry"`
+	Payload           json.RawMessage   `json:"payload"` // already filtered
+	Created           time.Time         `json:"created"`
+	Attempts          int               `json:"attempts"` // failed deliveries so far, by the run or a flush
+	LastError         string            `json:"last_error,omitempty"`
+	Receipts          []Receipt         `json:"receipts,omitempty"` // every failed delivery attempt, oldest first
+}
+
+// FlushedEntry reports what a flush did with one spooled delivery
+type FlushedEntry struct {
+	ID       string
+	Status   string // FlushDelivered, FlushRetry or FlushFailed
+	Attempts int
+	Error    string
+}
+
+// DefaultSpoolDir returns the spool directory used when none is configured
+func DefaultSpoolDir() string {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		base = os.TempDir()
+	}
+	return filepath.Join(base, "ghost", "webhook-spool")
+}
+
+// SpoolDelivery persists a delivery to the spool directory instead of sending it
+// The payload is filtered as it would be when sent. Returns the delivery ID.
+func SpoolDelivery(dir string, config *Config, retryConfig *RetryConfig, payload any) (string, error) {
+	entry, err := newSpoolEntry(config, retryConfig, payload)
+	if err != nil {
+		return "", err
+	}
+	return writeNewSpoolEntry(dir, entry)
+}
+
+// RecordFailedDelivery persists a delivery that failed to the failed directory
+// with the receipts of its attempts, so it can be audited after ghost exits. It is
+// not retried by a flush unless it is moved back into the spool. Returns the
+// delivery ID.
+func RecordFailedDelivery(dir string, config *Config, retryConfig *RetryConfig, payload any, receipts []Receipt, deliveryErr error) (string, error) {
+	entry, err := newSpoolEntry(config, retryConfig, payload)
+	if err != nil {
+		return "", err
+	}
+	entry.Attempts = 1
+	entry.LastError = redact.Error(deliveryErr)
+	entry.Receipts = receipts
+	return writeNewSpoolEntry(filepath.Join(dir, SpoolFailedDir), entry)
+}
+
+// newSpoolEntry captures a delivery with its filtered payload
+func newSpoolEntry(config *Config, retryConfig *RetryConfig, payload any) (*SpoolEntry, error) {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+	jsonPayload, err = FilterPayload(jsonPayload, config.IncludeFields, config.ExcludeFields)
+	if err != nil {
+		return nil, err
+	}
+	if retryConfig == nil {
+		retryConfig = DefaultRetryConfig()
+	}
+
+	return &SpoolEntry{
+		URL:               config.URL,
+		Method:            config.Method,
+		Headers:           config.Headers,
+		AuthType:          config.AuthType,
+		AuthToken:         config.AuthToken,
+		AuthHeader:        config.AuthHeader,
+		Timeout:           config.Timeout,
+		RateLimit:         config.RateLimit,
+		ExpectStatus:      config.ExpectStatus,
+		Proxy:             config.Proxy,
+		DisableKeepAlives: config.DisableKeepAlives,
+		BreakerThreshold:  config.BreakerThreshold,
+		BreakerCooldown:   config.BreakerCooldown,
+		Retry:             *retryConfig,
+		Payload:           jsonPayload,
+		Created:           time.Now().UTC(),
+	}, nil
+}
+
+// writeNewSpoolEntry writes an entry to dir under a new ID
+func writeNewSpoolEntry(dir string, entry *SpoolEntry) (string, error) {
+	id, err := newSpoolID()
+	if err != nil {
+		return "", err
+	}
+	if err := writeSpoolEntry(filepath.Join(dir, id+spoolExt), entry); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// newSpoolID returns an ID that sorts spooled deliveries in creation order
+func newSpoolID() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate spool ID: %w", err)
+	}
+	return fmt.Sprintf("%019d-%s", time.Now().UnixNano(), hex.EncodeToString(b)), nil
+}
+
+// writeSpoolEntry writes an entry atomically, readable only by its owner
+func writeSpoolEntry(path string, entry *SpoolEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal spool entry: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create spool directory %s: %w", dir, err)
+	}
+	// The temp file has no .json suffix, so a flush never picks it up half-written
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write spool entry: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write spool entry: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write spool entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write spool entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write spool entry: %w", err)
+	}
+	return nil
+}
+
+// readSpoolEntry reads a spooled delivery
+func readSpoolEntry(path string) (*SpoolEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool entry: %w", err)
+	}
+	var entry SpoolEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to parse spool entry %s: %w", filepath.Base(path), err)
+	}
+	return &entry, nil
+}
+
+// PendingDeliveries returns the IDs of the deliveries waiting in the spool, oldest first
+func PendingDeliveries(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory: %w", err)
+	}
+	var ids []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && strings.HasSuffix(e.Name(), spoolExt) && !strings.HasPrefix(e.Name(), ".") {
+			ids = append(ids, strings.TrimSuffix(e.Name(), spoolExt))
+		}
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// FlushSpool delivers the spooled deliveries in creation order
+// Each delivery is sent with the retry settings it was spooled with. Failed
+// deliveries stay in the spool for the next flush, or move to the failed
+// directory after maxAttempts flushes (0 = keep retrying). Flushes running at
+// the same time never deliver the same entry twice. The flush stops early, leaving
+// the remaining deliveries in the spool, when ctx is cancelled.
+func FlushSpool(ctx context.Context, dir string, maxAttempts int, verbose bool) ([]FlushedEntry, error) {
+	recoverStaleClaims(dir)
+
+	ids, err := PendingDeliveries(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var flushed []FlushedEntry
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		result, ok, err := flushEntry(ctx, dir, id, maxAttempts, verbose)
+		if err != nil {
+			return flushed, err
+		}
+		if ok {
+			flushed = append(flushed, result)
+		}
+	}
+	return flushed, nil
+}
+
+// flushEntry claims and delivers one spooled delivery
+// ok is false if another flush claimed it first.
+func flushEntry(ctx context.Context, dir, id string, maxAttempts int, verbose bool) (result FlushedEntry, ok bool, err error) {
+	pending := filepath.Join(dir, id+spoolExt)
+	claim := pending + claimExt
+	if err := os.Rename(pending, claim); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return FlushedEntry{}, false, nil
+		}
+		return FlushedEntry{}, false, fmt.Errorf("failed to claim spool entry %s: %w", id, err)
+	}
+
+	entry, err := readSpoolEntry(claim)
+	if err != nil {
+		// Unreadable entries can never be delivered
+		if err := os.MkdirAll(filepath.Join(dir, SpoolFailedDir), 0700); err == nil {
+			_ = os.Rename(claim, filepath.Join(dir, SpoolFailedDir, id+spoolExt))
+		}
+		return FlushedEntry{ID: id, Status: FlushFailed, Error: err.Error()}, true, nil
+	}
+
+	config, retryConfig := entry.deliveryConfig()
+	redact.AddConfig(map[string]any{"url": entry.URL, "proxy": entry.Proxy, "auth_token": entry.AuthToken, "headers": entry.Headers})
+
+	// Deliveries to an endpoint whose circuit is open wait for a later flush
+	// without counting as an attempt
+	breaker := NewBreaker(dir, entry.URL, entry.BreakerThreshold, entry.BreakerCooldown)
+	if !breaker.Allow() {
+		if err := os.Rename(claim, pending); err != nil {
+			return FlushedEntry{}, false, fmt.Errorf("failed to release spool entry %s: %w", id, err)
+		}
+		if verbose {
+			fmt.Fprintf(redact.Stderr, "[WEBHOOK] Skipping %s: circuit breaker open for %s\n", id, redact.URL(entry.URL))
+		}
+		return FlushedEntry{ID: id, Status: FlushRetry, Attempts: entry.Attempts, Error: ErrCircuitOpen.Error()}, true, nil
+	}
+
+	if verbose {
+		fmt.Fprintf(redact.Stderr, "[WEBHOOK] Flushing %s to %s\n", id, redact.URL(entry.URL))
+	}
+	client := NewClient(config, retryConfig, verbose)
+	_, sendErr := client.Deliver(ctx, entry.Payload)
+	if ctx.Err() == nil {
+		breaker.Record(sendErr)
+	}
+	if sendErr == nil {
+		if err := os.Remove(claim); err != nil {
+			return FlushedEntry{}, false, fmt.Errorf("failed to remove delivered spool entry %s: %w", id, err)
+		}
+		return FlushedEntry{ID: id, Status: FlushDelivered, Attempts: entry.Attempts + 1}, true, nil
+	}
+
+	if ctx.Err() != nil {
+		// An interrupted delivery does not count as an attempt
+		if err := os.Rename(claim, pending); err != nil {
+			return FlushedEntry{}, false, fmt.Errorf("failed to release spool entry %s: %w", id, err)
+		}
+		return FlushedEntry{}, false, nil
+	}
+
+	entry.Attempts++
+	entry.LastError = redact.Error(sendErr)
+	entry.Receipts = append(entry.Receipts, client.Receipts()...)
+	result = FlushedEntry{ID: id, Status: FlushRetry, Attempts: entry.Attempts, Error: entry.LastError}
+	target := pending
+	if maxAttempts > 0 && entry.Attempts >= maxAttempts {
+		result.Status = FlushFailed
+		target = filepath.Join(dir, SpoolFailedDir, id+spoolExt)
+	}
+	if err := writeSpoolEntry(target, entry); err != nil {
+		return FlushedEntry{}, false, err
+	}
+	if err := os.Remove(claim); err != nil {
+		return FlushedEntry{}, false, fmt.Errorf("failed to release spool entry %s: %w", id, err)
+	}
+	return result, true, nil
+}
+
+// recoverStaleClaims returns deliveries claimed by a flush that never finished to the spool
+func recoverStaleClaims(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), spoolExt+claimExt) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || time.Since(info.ModTime()) < StaleClaim {
+			continue
+		}
+		claim := filepath.Join(dir, e.Name())
+		_ = os.Rename(claim, strings.TrimSuffix(claim, claimExt))
+	}
+}
+
+// deliveryConfig rebuilds the client configuration of a spooled delivery
+func (e *SpoolEntry) deliveryConfig() (*Config, *RetryConfig) {
+	retryConfig := e.Retry
+	return &Config{
+		URL:               e.URL,
+		Method:            e.Method,
+		Headers:           e.Headers,
+		Timeout:           e.Timeout,
+		AuthType:          e.AuthType,
+		AuthToken:         e.AuthToken,
+		AuthHeader:        e.AuthHeader,
+		RateLimit:         e.RateLimit,
+		Proxy:             e.Proxy,
+		DisableKeepAlives: e.DisableKeepAlives,
+		ExpectStatus:      e.ExpectStatus,
+		BreakerThreshold:  e.BreakerThreshold,
+		BreakerCooldown:   e.BreakerCooldown,
+	}, &retryConfig
+}
@@ -0,0 +1,297 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSpoolDelivery(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spool")
+	config := &Config{
+		URL:           "https://example.com/hook",
+		Method:        "PUT",
+		Headers:       map[string]string{"X-Ghost-Run-Id": "run-1"},
+		AuthType:      "bearer",
+		AuthToken:     "secret",
+		Timeout:       5 * time.Second,
+		ExcludeFields: []string{"context"},
+	}
+	payload := map[string]any{"status": "success", "context": map[string]any{"token": "x"}}
+
+	id, err := SpoolDelivery(dir, config, nil, payload)
+	if err != nil {
+		t.Fatalf("SpoolDelivery failed: %v", err)
+	}
+
+	ids, err := PendingDeliveries(dir)
+	if err != nil {
+		t.Fatalf("PendingDeliveries failed: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != id {
+		t.Fatalf("pending = %v, want [%s]", ids, id)
+	}
+
+	path := filepath.Join(dir, id+".json")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("spool entry mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	entry, err := readSpoolEntry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.URL != config.URL || entry.Method != "PUT" || entry.AuthToken != "secret" {
+		t.Errorf("unexpected entry: %+v", entry)
+	}
+	if entry.Headers["X-Ghost-Run-Id"] != "run-1" {
+		t.Errorf("headers not spooled: %v", entry.Headers)
+	}
+	if entry.Retry.MaxRetries != DefaultRetryConfig().MaxRetries {
+		t.Errorf("retry = %+v, want defaults", entry.Retry)
+	}
+	if string(entry.Payload) != `{"status":"success"}` {
+		t.Errorf("payload = %s, want the filtered payload", entry.Payload)
+	}
+}
+
+func TestRecordFailedDelivery(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spool")
+	receipts := []Receipt{
+		{Attempt: 1, StartedAt: time.Now().UTC(), Duration: time.Second, StatusCode: 503},
+		{Attempt: 2, StartedAt: time.Now().UTC(), Duration: time.Second, Error: "connection refused"},
+	}
+
+	id, err := RecordFailedDelivery(dir, &Config{URL: "https://example.com/hook", Method: "POST"}, nil,
+		map[string]string{"status": "success"}, receipts, errors.New("webhook failed after 2 attempts"))
+	if err != nil {
+		t.Fatalf("RecordFailedDelivery failed: %v", err)
+	}
+
+	// Recorded deliveries are kept for auditing, not retried by a flush
+	if pending, _ := PendingDeliveries(dir); len(pending) != 0 {
+		t.Errorf("pending = %v, want none", pending)
+	}
+	entry, err := readSpoolEntry(filepath.Join(dir, SpoolFailedDir, id+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Attempts != 1 || entry.LastError != "webhook failed after 2 attempts" {
+		t.Errorf("entry attempts=%d last_error=%q", entry.Attempts, entry.LastError)
+	}
+	if len(entry.Receipts) != 2 || entry.Receipts[0].StatusCode != 503 || entry.Receipts[1].Error != "connection refused" {
+		t.Errorf("receipts = %+v, want %+v", entry.Receipts, receipts)
+	}
+}
+
+func TestPendingDeliveriesOrder(t *testing.T) {
+	dir := t.TempDir()
+	if ids, err := PendingDeliveries(filepath.Join(dir, "missing")); err != nil || ids != nil {
+		t.Fatalf("missing spool: ids=%v err=%v", ids, err)
+	}
+
+	var want []string
+	for i := 0; i < 3; i++ {
+		id, err := SpoolDelivery(dir, &Config{URL: "https://example.com"}, nil, map[string]int{"n": i})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, id)
+	}
+	// Claimed and temporary files are not pending
+	for _, name := range []string{"x.json.sending", ".y.json.123.tmp"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids, err := PendingDeliveries(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != len(want) {
+		t.Fatalf("pending = %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Errorf("pending[%d] = %s, want %s", i, ids[i], want[i])
+		}
+	}
+}
+
+func TestFlushSpool(t *testing.T) {
+	var received []string
+	var fail atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, r.Method+" "+r.Header.Get("Authorization")+" "+string(body))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	noRetry := &RetryConfig{MaxRetries: 0, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, Multiplier: 1}
+	config := &Config{URL: server.URL, Method: "POST", AuthType: "bearer", AuthToken: "tok", Timeout: 5 * time.Second}
+
+	tests := []struct {
+		name        string
+		fail        bool
+		maxAttempts int
+		priorFails  int
+		wantStatus  string
+		wantPending int
+		wantFailed  int
+	}{
+		{name: "delivered", wantStatus: FlushDelivered},
+		{name: "failure is kept", fail: true, maxAttempts: 3, wantStatus: FlushRetry, wantPending: 1},
+		{name: "max attempts moves to failed", fail: true, maxAttempts: 3, priorFails: 2, wantStatus: FlushFailed, wantFailed: 1},
+		{name: "unlimited attempts", fail: true, priorFails: 50, wantStatus: FlushRetry, wantPending: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received = nil
+			fail.Store(tt.fail)
+			dir := t.TempDir()
+
+			id, err := SpoolDelivery(dir, config, noRetry, map[string]string{"status": "success"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.priorFails > 0 {
+				path := filepath.Join(dir, id+".json")
+				entry, _ := readSpoolEntry(path)
+				entry.Attempts = tt.priorFails
+				if err := writeSpoolEntry(path, entry); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			flushed, err := FlushSpool(context.Background(), dir, tt.maxAttempts, false)
+			if err != nil {
+				t.Fatalf("FlushSpool failed: %v", err)
+			}
+			if len(flushed) != 1 || flushed[0].ID != id || flushed[0].Status != tt.wantStatus {
+				t.Fatalf("flushed = %+v, want %s for %s", flushed, tt.wantStatus, id)
+			}
+			if flushed[0].Attempts != tt.priorFails+1 {
+				t.Errorf("attempts = %d, want %d", flushed[0].Attempts, tt.priorFails+1)
+			}
+
+			pending, _ := PendingDeliveries(dir)
+			if len(pending) != tt.wantPending {
+				t.Errorf("pending = %v, want %d", pending, tt.wantPending)
+			}
+			failed, _ := PendingDeliveries(filepath.Join(dir, SpoolFailedDir))
+			if len(failed) != tt.wantFailed {
+				t.Errorf("failed = %v, want %d", failed, tt.wantFailed)
+			}
+
+			if tt.wantStatus == FlushDelivered {
+				if len(received) != 1 || received[0] != `POST Bearer tok {"status":"success"}` {
+					t.Errorf("received = %q", received)
+				}
+				return
+			}
+			if flushed[0].Error == "" {
+				t.Error("expected the delivery error to be reported")
+			}
+			dirs := []string{dir, filepath.Join(dir, SpoolFailedDir)}
+			entry, err := readSpoolEntry(filepath.Join(dirs[tt.wantFailed], id+".json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if entry.Attempts != tt.priorFails+1 || entry.LastError == "" {
+				t.Errorf("entry attempts=%d last_error=%q", entry.Attempts, entry.LastError)
+			}
+			if len(entry.Receipts) != 1 || entry.Receipts[0].Delivered || entry.Receipts[0].StatusCode == 0 {
+				t.Errorf("entry receipts = %+v, want the failed attempt of this flush", entry.Receipts)
+			}
+		})
+	}
+}
+
+func TestFlushSpoolClaims(t *testing.T) {
+	var count atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	config := &Config{URL: server.URL, Method: "POST"}
+	for _, name := range []string{"fresh", "stale"} {
+		id, err := SpoolDelivery(dir, config, nil, map[string]string{"name": name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Claimed by another flush
+		path := filepath.Join(dir, id+".json")
+		if err := os.Rename(path, path+".sending"); err != nil {
+			t.Fatal(err)
+		}
+		if name == "stale" {
+			old := time.Now().Add(-2 * StaleClaim)
+			if err := os.Chtimes(path+".sending", old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	flushed, err := FlushSpool(context.Background(), dir, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flushed) != 1 || count.Load() != 1 {
+		t.Fatalf("flushed = %+v with %d requests, want only the stale claim delivered", flushed, count.Load())
+	}
+}
+
+func TestFlushSpoolUnreadableEntry(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	flushed, err := FlushSpool(context.Background(), dir, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(flushed) != 1 || flushed[0].Status != FlushFailed {
+		t.Fatalf("flushed = %+v, want the entry failed", flushed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, SpoolFailedDir, "broken.json")); err != nil {
+		t.Errorf("unreadable entry not moved to failed: %v", err)
+	}
+}
+
+func TestSpoolEntryRoundTrip(t *testing.T) {
+	entry := &SpoolEntry{URL: "https://example.com", Timeout: time.Second, Retry: *DefaultRetryConfig(), Payload: json.RawMessage(`{"a":1}`)}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded SpoolEntry
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	config, retryConfig := decoded.deliveryConfig()
+	if config.Timeout != time.Second || retryConfig.InitialDelay != time.Second || string(decoded.Payload) != `{"a":1}` {
+		t.Errorf("round trip lost settings: %+v %+v", config, retryConfig)
+	}
+}
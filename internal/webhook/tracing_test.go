@@ -0,0 +1,67 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestClientSend_TracingSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	oldProvider := otel.GetTracerProvider()
+	oldPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer func() {
+		otel.SetTracerProvider(oldProvider)
+		otel.SetTextMapPropagator(oldPropagator)
+	}()
+
+	var attempts int32
+	var traceparent atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent.Store(r.Header.Get("traceparent"))
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	retryConfig := &RetryConfig{
+		MaxRetries:   2,
+		InitialDelay: 1 * time.Millisecond,
+		MaxDelay:     5 * time.Millisecond,
+		Multiplier:   2.0,
+	}
+	client := NewClient(&Config{URL: server.URL}, retryConfig, false)
+
+	if err := client.Send(context.Background(), map[string]string{"status": "success"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 webhook spans (one per attempt), got %d", len(spans))
+	}
+	if spans[0].Status().Code != codes.Error {
+		t.Errorf("Expected first attempt span to be marked as error, got %v", spans[0].Status().Code)
+	}
+	if spans[1].Status().Code == codes.Error {
+		t.Errorf("Expected second attempt span to succeed, got %v", spans[1].Status())
+	}
+	if tp, _ := traceparent.Load().(string); tp == "" {
+		t.Error("Expected traceparent header to be propagated")
+	}
+}
@@ -1,7 +1,14 @@
 package main
 
-import "github.com/zinc-sig/ghost/cmd"
+import (
+	"github.com/zinc-sig/ghost/cmd"
+	"github.com/zinc-sig/ghost/cmd/helpers"
+)
+
+// Version is set by release builds with -ldflags "-X main.Version=..."
+var Version = "dev"
 
 func main() {
+	helpers.Version = Version
 	cmd.Execute()
 }